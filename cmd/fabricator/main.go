@@ -1,19 +1,33 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"runtime/pprof"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/SGNL-ai/fabricator/pkg/config"
+	"github.com/SGNL-ai/fabricator/pkg/console"
 	"github.com/SGNL-ai/fabricator/pkg/diagrams"
+	"github.com/SGNL-ai/fabricator/pkg/encryption"
+	"github.com/SGNL-ai/fabricator/pkg/errcode"
+	"github.com/SGNL-ai/fabricator/pkg/events"
+	"github.com/SGNL-ai/fabricator/pkg/generators/pipeline"
 	"github.com/SGNL-ai/fabricator/pkg/orchestrator"
 	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/SGNL-ai/fabricator/pkg/report"
 	"github.com/SGNL-ai/fabricator/pkg/subcommands"
+	"github.com/SGNL-ai/fabricator/pkg/telemetry"
+	"github.com/SGNL-ai/fabricator/pkg/util"
 	"github.com/fatih/color"
 )
 
@@ -22,6 +36,13 @@ var (
 	version = "dev"
 )
 
+// emitter receives progress events for the current run (nil when no sinks are configured)
+var emitter *events.Emitter
+
+// fileLayout names the entity files the current run writes or reads, from
+// --filename-replacement and --domain-folders
+var fileLayout pipeline.FileLayout
+
 // Command line flags
 var (
 	// Show version
@@ -30,6 +51,13 @@ var (
 	// Input file
 	inputFile string
 
+	// YAML file of values for ${NAME} references in the input file, and the values once loaded
+	varsFile  string
+	variables map[string]string
+
+	// Overlay files patching the input file, applied in order
+	overlays stringList
+
 	// Output directory
 	outputDir string
 
@@ -39,6 +67,26 @@ var (
 	// Count configuration file
 	countConfigFile string
 
+	// Per-entity row counts from --count flags, over -n and the count configuration
+	countOverrides = config.CountOverrides{}
+
+	// Write header-only files for entities with a row count of 0
+	includeEmptyEntities bool
+
+	// Fail when row counts cannot satisfy relationships
+	strictCounts bool
+
+	// Fail when scoped-unique attributes run out of values
+	strictUniqueness bool
+
+	// Skip count configuration entries for entities missing from the SOR, with a warning
+	ignoreUnknownCounts bool
+
+	// What to do with files already in the output directory
+	cleanOutput  bool
+	failIfExists bool
+	mergeOutput  bool
+
 	// Auto-cardinality for relationships
 	autoCardinality bool
 
@@ -48,14 +96,170 @@ var (
 	// Generate ER diagram
 	generateDiagram bool
 
+	// Write a single-file HTML report of the run to this path
+	reportHTML string
+
 	// Validation-only mode (skip CSV generation)
 	validateOnly bool
 
+	// Per-relationship validation level overrides (YAML file)
+	relationshipValidationFile string
+
+	// Validate files row by row, keeping only key indexes in memory
+	streamingValidation bool
+
+	// Entity files loaded and indexed at the same time when validating
+	validationWorkers int
+
+	// File caching validation results between runs, keyed by file checksums
+	validationCache string
+
+	// Report every value coerced to its attribute's type when validating
+	strictCoercion bool
+
+	// Directory to write a copy of the validated files to without rows repeating a key
+	dedupeOutput string
+
+	// Error budget per validation check (YAML file)
+	validationConfigFile string
+
+	// Role and SoD distribution for entitlement assignments (YAML file)
+	accessConfigFile string
+
+	// Place boundary values in the first rows of each entity
+	edgeCases bool
+
+	// Also write a copy with sensitive attributes redacted to <output>-redacted
+	redacted bool
+
+	// Encrypt data files as they are written, e.g. "aes:FABRICATOR_KEY"
+	encryptSpec string
+
+	// Decrypt the files validated, given the --encrypt value they were written with
+	decryptSpec string
+
+	// Transient write errors injected into data files, e.g. "enospc=0.01,eacces=0.005"
+	writeFaultSpec string
+
+	// Objects per entity in sample ingestion payloads (0 = none)
+	ingestionSamples int
+
+	// Named profile in the count configuration file, and the profile once loaded
+	profileName string
+	profile     *config.GenerationProfile
+
+	// Seed for reproducible runs (0 = random)
+	seed int64
+
+	// JSONL file recording the run's random decisions
+	auditLog string
+
+	// JSON file describing the generated dataset for infrastructure as code steps
+	descriptor string
+
+	// Rules picking primary key formats, e.g. "type:Integer=sequence,*Guid=uuid"
+	idFormats string
+
+	// Entities generated and written first, e.g. "User,Group"
+	entityOrder string
+
+	// File of the primary keys earlier runs generated, and the share of rows reusing them
+	keyRegistry string
+	keyReuse    float64
+
+	// Tenant SOR schema export (file or URL) the SOR must match, and the variable holding its API token
+	tenantSchema   string
+	tenantTokenEnv string
+
+	// Size of the shared population of synthetic people (0 = disabled)
+	population int
+
+	// Built-in theme, "random" or theme file naming departments, groups, projects and applications
+	theme string
+
+	// How closely the address parts of address-like entities agree: strict, loose or off
+	addressCoherence string
+
+	// Replaces characters invalid in Windows filenames in entity output filenames
+	filenameReplacement string
+
+	// Write entities with a domain into per-domain subfolders
+	domainFolders bool
+
+	// Comma-separated entity tags selecting optional parts of the SOR, and the
+	// external IDs of the entities they leave out
+	withTags    string
+	withoutTags string
+	tagExcluded []string
+
+	// Directory of partial CSVs to fill in
+	fillFromDir string
+
+	// YAML file of exact rows always written, with generated rows around them
+	fixturesFile string
+
+	// YAML file of assertions checked over the generated data
+	assertionsFile string
+
+	// Event sink specs (e.g. "stdout,jsonl:events.jsonl")
+	eventSinks string
+
+	// Output format for generated rows (csv, jsonl, go or neo4j)
+	outputFormat string
+
+	// Package clause of Go fixture files (--format go)
+	goPackage string
+
+	// What each line of JSON lines output holds (--format jsonl)
+	jsonlShape string
+
+	// Generate PII in obviously fake formats
+	noRealLookingPII bool
+
+	// Identity mapping export
+	mappingFile   string
+	mappingKeyEnv string
+	noMapping     bool
+
+	// Output pacing (rows per second, overall and per entity)
+	rowsPerSecond       float64
+	entityRowsPerSecond string
+
+	// Entity files written at the same time, and bytes buffered per file
+	writeWorkers    int
+	writeFileBuffer int
+
+	// OTLP/HTTP collector endpoint for traces and metrics
+	otelEndpoint string
+
 	// Profiling options
 	cpuProfile string
 	memProfile string
+
+	// Memory in use (MiB) at which a heap profile and a partial manifest are written
+	// to the output directory; 0 disables it
+	memProfileThreshold int
+
+	// Keep a copy of every value instead of sharing repeated ones (for debugging memory use)
+	noIntern bool
+
+	// Terminal output without ANSI colors, or as plain ASCII text
+	noColor bool
+	plain   bool
 )
 
+// stringList is a flag that may be repeated, collecting its values in order
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
 func init() {
 	// Define flags with both short and long forms
 	flag.BoolVar(&showVersion, "v", false, "Display version information")
@@ -63,20 +267,47 @@ func init() {
 
 	flag.StringVar(&inputFile, "f", "", "Path to the YAML definition file (required)")
 	flag.StringVar(&inputFile, "file", "", "Path to the YAML definition file (required)")
+	flag.StringVar(&varsFile, "vars-file", "", "YAML file of values for ${NAME} references in the definition file; the environment supplies the others")
+	flag.Var(&overlays, "overlay", "YAML file patching the definition file for a scenario; repeat to apply several in order")
 
 	flag.StringVar(&outputDir, "o", "output", "Directory to store generated CSV files")
 	flag.StringVar(&outputDir, "output", "output", "Directory to store generated CSV files")
+	flag.BoolVar(&cleanOutput, "clean", false, "Remove everything in the output directory before generating (only earlier fabricator output, which has a manifest.json)")
+	flag.BoolVar(&failIfExists, "fail-if-exists", false, "Fail if the output directory holds any file")
+	flag.BoolVar(&mergeOutput, "merge", false, "Keep the files of entities this run doesn't generate and list them in the manifest")
 
 	flag.IntVar(&dataVolume, "n", 100, "Number of rows to generate for each entity")
 	flag.IntVar(&dataVolume, "num-rows", 100, "Number of rows to generate for each entity")
 
 	flag.StringVar(&countConfigFile, "count-config", "", "Path to row count configuration YAML file")
 	flag.StringVar(&countConfigFile, "c", "", "Path to row count configuration YAML file")
+	flag.Var(countOverrides, "count", "Row count of one entity as <entity>=<count>, over -n and --count-config; repeat for more entities")
+	flag.BoolVar(&includeEmptyEntities, "include-empty-entities", false, "Allow a row count of 0 (count config or -n) and write a header-only file for those entities")
+	flag.BoolVar(&strictCounts, "strict-counts", false, "Fail before generating when row counts would leave relationship rows unmatched or dropped")
+	flag.BoolVar(&strictUniqueness, "strict-uniqueness", false, "Fail when more rows share a scope than a uniqueWithin attribute has distinct values, instead of adding numeric suffixes")
+	flag.BoolVar(&ignoreUnknownCounts, "ignore-unknown-counts", false, "Warn about and skip count configuration entries for entities not in the SOR instead of failing")
+
+	flag.StringVar(&profileName, "profile", "", "Apply a named profile (e.g. smoke, load, soak) from the --count-config file")
+	flag.Int64Var(&seed, "seed", 0, "Seed the random generator so runs with the same SOR and settings produce the same data (0 = random)")
+	flag.StringVar(&addressCoherence, "address-coherence", pipeline.AddressCoherenceStrict, "How closely street, city, state, postal code and country attributes agree: strict (one real place), loose (one country) or off (each part on its own)")
+	flag.StringVar(&theme, "theme", "", "Name departments, groups, projects and applications from a theme's vocabulary: "+strings.Join(pipeline.ThemeNames(), ", ")+", "+pipeline.ThemeRandom+" or a theme YAML file")
+	flag.IntVar(&population, "population", 0, "Fill names, emails, employee IDs and usernames of person-like entities from a population of N people derived from --seed, so the same people appear across entities and SORs (0 = disabled)")
 
 	flag.BoolVar(&autoCardinality, "a", true, "Enable automatic cardinality detection for relationships")
 	flag.BoolVar(&autoCardinality, "auto-cardinality", true, "Enable automatic cardinality detection for relationships")
 
 	flag.BoolVar(&validateOnly, "validate-only", false, "Validate existing CSV files without generating new data")
+	flag.StringVar(&relationshipValidationFile, "relationship-validation", "", "YAML file mapping relationship keys to skip, warn or error for --validate-only")
+	flag.StringVar(&validationConfigFile, "validation-config", "", "YAML file of per-check tolerances for --validate-only; validation fails when a check exceeds its tolerance")
+	flag.BoolVar(&streamingValidation, "streaming-validation", false, "Validate CSV files row by row for --validate-only, keeping only key indexes in memory")
+	flag.IntVar(&validationWorkers, "validation-workers", pipeline.DefaultValidationWorkers, "Entity CSV files loaded and indexed at the same time for --validate-only")
+	flag.StringVar(&validationCache, "validation-cache", "", "File caching --validate-only results by file checksum, so re-runs only check changed files")
+	flag.BoolVar(&strictCoercion, "strict-coercion", false, "Report every value --validate-only coerces to its attribute's type (trimmed, booleans, dates) as an error")
+	flag.StringVar(&dedupeOutput, "dedupe-output", "", "Directory to write a copy of the files --validate-only checks to, without the rows repeating a key")
+
+	flag.StringVar(&fillFromDir, "fill-from", "", "Directory of partial CSV files whose missing columns should be generated")
+	flag.StringVar(&fixturesFile, "fixtures", "", "YAML file of exact rows per entity always written, with generated rows making up the rest")
+	flag.StringVar(&assertionsFile, "assertions", "", "YAML file of assertions checked over the generated data; a failing assertion of error severity fails the run")
 
 	// Set default for validation to true
 	validateRelationships = true
@@ -84,23 +315,51 @@ func init() {
 	// Add a standard boolean flag for validation
 	flag.BoolVar(&validateRelationships, "validate", true, "Validate relationships consistency in output CSV files")
 
-	// Set default for diagram generation based on GraphViz availability
-	generateDiagram = diagrams.IsGraphvizAvailable()
-
-	// Add a flag to control diagram generation with appropriate default message
-	diagramDesc := "Generate Entity-Relationship diagram"
-	if generateDiagram {
-		diagramDesc += " (default: false - Graphviz not found)"
-	} else {
-		diagramDesc += " (default: true)"
-	}
-
-	flag.BoolVar(&generateDiagram, "diagram", generateDiagram, diagramDesc)
-	flag.BoolVar(&generateDiagram, "d", generateDiagram, diagramDesc)
+	// Diagrams are drawn by the diagram subcommand; --diagram still adds one to the output
+	diagramDesc := "Also generate an Entity-Relationship diagram in the output directory (deprecated: use fabricator diagram)"
+	flag.BoolVar(&generateDiagram, "diagram", false, diagramDesc)
+	flag.BoolVar(&generateDiagram, "d", false, diagramDesc)
+
+	flag.StringVar(&accessConfigFile, "access-config", "", "Distribute entitlement assignments by role and plant SoD violations (YAML file)")
+	flag.BoolVar(&redacted, "redacted", false, "Also write a copy of the files with attributes marked sensitive masked or hashed to the sibling directory <output>"+pipeline.RedactedDirSuffix)
+	flag.StringVar(&encryptSpec, "encrypt", "", "Encrypt data files as they are written: aes:<VAR> encrypts with the passphrase in environment variable VAR")
+	flag.StringVar(&writeFaultSpec, "inject-write-faults", "", "Inject transient write errors into data files at these rates, e.g. enospc=0.01,eacces=0.005[,seed=N], to test retries and the marking of partial output")
+	flag.BoolVar(&edgeCases, "edge-cases", false, "Put boundary values (empty and max-length strings, min/max numbers, epoch and far-future dates, unicode) in the first rows of each entity")
+	flag.IntVar(&ingestionSamples, "ingestion-samples", 0, "Write up to N rows per entity as SGNL ingestion payloads (attributes keyed by externalId, typed values) for checking adapter mappings")
+	flag.StringVar(&filenameReplacement, "filename-replacement", pipeline.DefaultFilenameReplacement, "Replacement for characters invalid in Windows filenames (<>:\"/\\|?*) when naming entity files")
+	flag.BoolVar(&domainFolders, "domain-folders", false, "Write and read each entity's file in a subfolder named after its domain")
+	flag.StringVar(&withTags, "with-tags", "", "Comma-separated tags; tagged entities are generated only with one of them (untagged entities always are)")
+	flag.StringVar(&withoutTags, "without-tags", "", "Comma-separated tags; entities with one of them are left out")
+	flag.StringVar(&reportHTML, "report-html", "", "Write a single-file HTML report summarizing the run to this path")
+	flag.StringVar(&eventSinks, "events", "", "Comma-separated event sinks for run progress (stdout, jsonl:<path>)")
+	flag.StringVar(&outputFormat, "format", pipeline.OutputFormatCSV, "Output format for generated rows: csv, jsonl (one JSON message per row with topic and key), go (a Go source file per entity with a struct type and its rows, for test fixtures), or neo4j (node and relationship edge CSVs for neo4j-admin import)")
+	flag.StringVar(&goPackage, "go-package", "", "Package clause of the files written with --format go (default \""+pipeline.DefaultGoPackage+"\")")
+	flag.StringVar(&jsonlShape, "jsonl-shape", "", "Lines written with --format jsonl: message (topic, key and string values) or row (the row itself with typed values) (default \""+pipeline.JSONLShapeMessage+"\")")
+	flag.StringVar(&mappingFile, "mapping-file", "", "Write a mapping of generated IDs to synthetic identity attributes (JSON lines)")
+	flag.StringVar(&mappingKeyEnv, "mapping-key-env", "", "Encrypt the mapping file with the passphrase in this environment variable")
+	flag.BoolVar(&noMapping, "no-mapping", false, "Never write an identity mapping file, even if --mapping-file is set")
+	flag.StringVar(&idFormats, "id-formats", "", "Comma-separated <pattern>=<format> rules picking primary key formats (uuid or sequence); patterns are type:<Type> or a glob over the external ID, e.g. \"*Number=sequence\"")
+	flag.StringVar(&entityOrder, "entity-order", "", "Comma-separated external IDs of entities generated and written first, in this order, e.g. User,Group; each still follows the entities it references")
+	flag.StringVar(&keyRegistry, "key-registry", "", "File of the primary keys earlier runs generated, updated with this run's; new keys avoid those it holds")
+	flag.Float64Var(&keyReuse, "key-reuse", 0, "Share (0 to 1) of each entity's rows taking keys from --key-registry instead of new ones")
+	flag.StringVar(&tenantSchema, "tenant-schema", "", "File or http(s) URL of the tenant's SOR schema export; generation stops if the SOR's entities, attributes or types differ")
+	flag.StringVar(&tenantTokenEnv, "tenant-token-env", "", "Environment variable holding the bearer token sent to a --tenant-schema URL")
+	flag.StringVar(&auditLog, "audit-log", "", "Write the run's random decisions (seed, per-entity sub-seeds, cardinality choices, timeline clusters) to this file as JSON lines")
+	flag.StringVar(&descriptor, "descriptor", "", "Write a JSON dataset descriptor (paths, row counts, seed, schema hash, dataset version) to this file for infrastructure as code steps")
+	flag.BoolVar(&noRealLookingPII, "no-real-looking-pii", false, "Generate PII (SSNs, cards, IBANs, IPs, emails, phones) in obviously fake formats")
+	flag.Float64Var(&rowsPerSecond, "rows-per-second", 0, "Limit output to this many rows per second per entity (0 = unlimited)")
+	flag.StringVar(&entityRowsPerSecond, "entity-rows-per-second", "", "Per-entity rate overrides (e.g. User=10,Group=2)")
+	flag.IntVar(&writeWorkers, "write-workers", pipeline.DefaultWriteWorkers, "Entity CSV files written at the same time")
+	flag.IntVar(&writeFileBuffer, "write-file-buffer", pipeline.DefaultWriteFileBuffer, "Bytes buffered per output file between writes to storage")
+	flag.StringVar(&otelEndpoint, "otel-endpoint", "", "Export OpenTelemetry traces and metrics to an OTLP/HTTP collector (e.g. localhost:4318)")
 
 	// Add profiling flags
 	flag.StringVar(&cpuProfile, "cpuprofile", "", "Write CPU profile to file")
 	flag.StringVar(&memProfile, "memprofile", "", "Write memory profile to file")
+	flag.IntVar(&memProfileThreshold, "memprofile-threshold", 0, "Write a heap profile and a partial manifest to the output directory once memory in use reaches this many MiB (0 = disabled)")
+	flag.BoolVar(&noIntern, "no-intern", false, "Don't share repeated values between rows (for debugging memory use)")
+	flag.BoolVar(&noColor, "no-color", false, "Don't color output (as when the NO_COLOR environment variable is set)")
+	flag.BoolVar(&plain, "plain", false, "Print plain ASCII text without colors or unicode symbols, for CI logs and scripts (as when "+console.PlainEnv+" is set)")
 
 	// Override default usage output
 	flag.Usage = func() {
@@ -110,20 +369,83 @@ func init() {
 }
 
 func main() {
-	// Check for subcommands before parsing flags
-	// Subcommands are verbs like "init-count-config" that come before flags
+	// Commands are verbs like "generate" or "init-count-config" that come before
+	// flags; without one, the flags are those of generate
 	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
-		switch os.Args[1] {
-		case "init-count-config":
-			handleInitCountConfigSubcommand(os.Args[2:])
-			return
+		if !runCommand(os.Args[1], os.Args[2:]) {
+			color.Red("Error: unknown command '%s'", os.Args[1])
+			color.Yellow("Run 'fabricator help' for the list of commands.")
+			os.Exit(1)
+		}
+		return
+	}
+	runGenerateCommand(os.Args[1:])
+}
+
+// runCommand runs the named command with its arguments, returning false if there is
+// no such command
+func runCommand(name string, args []string) bool {
+	switch name {
+	case "generate":
+		runGenerateCommand(args)
+	case "validate":
+		handleValidateSubcommand(args)
+	case "diagram", "diagram-only":
+		handleDiagramSubcommand(args)
+	case "analyze":
+		handleAnalyzeSubcommand(args)
+	case "init-count-config":
+		handleInitCountConfigSubcommand(args)
+	case "decrypt-mapping":
+		handleDecryptMappingSubcommand(args)
+	case "decrypt":
+		handleDecryptSubcommand(args)
+	case "dependency-layers":
+		handleDependencyLayersSubcommand(args)
+	case "check-relationships":
+		handleCheckRelationshipsSubcommand(args)
+	case "compare-schema":
+		handleCompareSchemaSubcommand(args)
+	case "audit-types":
+		handleAuditTypesSubcommand(args)
+	case "export-schema":
+		handleExportSchemaSubcommand(args)
+	case "import-openapi":
+		handleImportOpenAPISubcommand(args)
+	case "infer":
+		handleInferSubcommand(args)
+	case "trace":
+		handleTraceSubcommand(args)
+	case "help":
+		// "help <command>" shows the command's flags
+		if len(args) == 0 {
+			fmt.Printf("Usage: %s <command> [flags]\n", filepath.Base(os.Args[0]))
+			printUsage()
+			return true
+		}
+		if !runCommand(args[0], []string{"-h"}) {
+			color.Red("Error: unknown command '%s'", args[0])
+			os.Exit(1)
 		}
-		// If not a recognized subcommand, continue with normal flag parsing
-		// This allows for backward compatibility with non-subcommand usage
+	default:
+		return false
 	}
+	return true
+}
 
+// runGenerateCommand generates data as configured by the main command flags, which
+// also hold the legacy --validate-only mode
+func runGenerateCommand(args []string) {
 	// Parse command-line flags
-	flag.Parse()
+	_ = flag.CommandLine.Parse(args)
+
+	// Colors and symbols apply to everything printed from here on
+	if noColor {
+		console.DisableColor()
+	}
+	if plain {
+		console.SetPlain(true)
+	}
 
 	// Display version information if requested
 	if showVersion {
@@ -140,21 +462,178 @@ func main() {
 	}
 
 	// Validate flag conflicts: cannot use both -n and --count-config
-	if dataVolume != 100 && countConfigFile != "" {
+	// A profile's counts may be combined with -n for the entities they don't list
+	if dataVolume != 100 && countConfigFile != "" && profileName == "" {
 		color.Red("Error: Cannot use both -n/--num-rows and --count-config flags simultaneously.")
 		color.Yellow("Suggestion: Choose one approach:")
-		color.Yellow("  • Use -n for uniform row counts across all entities")
-		color.Yellow("  • Use --count-config for per-entity row counts")
+		color.Yellow(console.Text("  • Use -n for uniform row counts across all entities"))
+		color.Yellow(console.Text("  • Use --count-config for per-entity row counts"))
+		os.Exit(1)
+	}
+
+	// Fill in settings from the selected profile; explicit flags take precedence
+	if profileName != "" {
+		if countConfigFile == "" {
+			color.Red("Error: --profile requires --count-config with a 'profiles' section.")
+			os.Exit(1)
+		}
+		loaded, err := config.LoadProfile(countConfigFile, profileName)
+		if err != nil {
+			color.Red("Error: failed to load profile: %v", err)
+			os.Exit(1)
+		}
+		profile = loaded
+		applyProfile(profile)
+	}
+
+	if rowsPerSecond < 0 {
+		color.Red("Error: --rows-per-second must be zero or a positive number.")
+		os.Exit(1)
+	}
+
+	if writeWorkers < 1 {
+		color.Red("Error: --write-workers must be at least 1.")
+		os.Exit(1)
+	}
+
+	if validationWorkers < 1 {
+		color.Red("Error: --validation-workers must be at least 1.")
+		os.Exit(1)
+	}
+
+	if validationCache != "" && streamingValidation {
+		color.Red("Error: --validation-cache can't be combined with --streaming-validation.")
+		os.Exit(1)
+	}
+
+	// Encrypted runs leave nothing in the clear, and these files hold keys and values as they are
+	if encryptSpec != "" {
+		conflicts := []struct{ flag, value string }{
+			{"--key-registry", keyRegistry}, {"--validation-cache", validationCache}, {"--dedupe-output", dedupeOutput},
+		}
+		for _, conflict := range conflicts {
+			if conflict.value != "" {
+				color.Red("Error: %s can't be combined with --encrypt; it would write keys or values in the clear.", conflict.flag)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if writeFileBuffer < pipeline.MinWriteFileBuffer {
+		color.Red("Error: --write-file-buffer must be at least %d bytes.", pipeline.MinWriteFileBuffer)
+		os.Exit(1)
+	}
+
+	if population < 0 || population > pipeline.MaxPopulation {
+		color.Red("Error: --population must be between 0 and %d.", pipeline.MaxPopulation)
+		os.Exit(1)
+	}
+
+	if !slices.Contains(pipeline.AddressCoherenceModes, addressCoherence) {
+		color.Red("Error: --address-coherence must be one of %s.", strings.Join(pipeline.AddressCoherenceModes, ", "))
+		os.Exit(1)
+	}
+
+	modes := 0
+	for _, set := range []bool{cleanOutput, failIfExists, mergeOutput} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		color.Red("Error: --clean, --fail-if-exists and --merge cannot be combined.")
+		os.Exit(1)
+	}
+
+	if ingestionSamples < 0 {
+		color.Red("Error: --ingestion-samples must be zero or a positive number.")
+		os.Exit(1)
+	}
+
+	if keyReuse < 0 || keyReuse > 1 {
+		color.Red("Error: --key-reuse must be between 0 and 1.")
+		os.Exit(1)
+	}
+
+	if keyReuse > 0 && keyRegistry == "" {
+		color.Red("Error: --key-reuse requires --key-registry.")
+		os.Exit(1)
+	}
+
+	if memProfileThreshold < 0 {
+		color.Red("Error: --memprofile-threshold must be zero or a positive number of MiB.")
+		os.Exit(1)
+	}
+
+	if tenantTokenEnv != "" && tenantSchema == "" {
+		color.Red("Error: --tenant-token-env requires --tenant-schema.")
 		os.Exit(1)
 	}
 
 	// Main application logic
 	if err := run(inputFile, outputDir, dataVolume, countConfigFile, autoCardinality); err != nil {
-		color.Red("Error: %v", err)
+		printError(err)
 		os.Exit(1)
 	}
 }
 
+// printError reports a failed command, with the error's code when it has one so
+// scripts can tell failures apart without matching messages
+func printError(err error) {
+	if code := errcode.Of(err); code != errcode.Unknown {
+		color.Red("Error [%s]: %v", code, err)
+		return
+	}
+	color.Red("Error: %v", err)
+}
+
+// applyProfile sets each flag the profile configures, unless it was given on the
+// command line
+func applyProfile(p *config.GenerationProfile) {
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	set := func(names ...string) bool {
+		for _, name := range names {
+			if explicit[name] {
+				return false
+			}
+		}
+		return true
+	}
+
+	if p.Rows != nil && set("n", "num-rows") {
+		dataVolume = *p.Rows
+	}
+	if p.Seed != nil && set("seed") {
+		seed = *p.Seed
+	}
+	if p.Format != nil && set("format") {
+		outputFormat = *p.Format
+	}
+	if p.AutoCardinality != nil && set("a", "auto-cardinality") {
+		autoCardinality = *p.AutoCardinality
+	}
+	if p.EdgeCases != nil && set("edge-cases") {
+		edgeCases = *p.EdgeCases
+	}
+	if p.ClearlyFakePII != nil && set("no-real-looking-pii") {
+		noRealLookingPII = *p.ClearlyFakePII
+	}
+	if p.RowsPerSecond != nil && set("rows-per-second") {
+		rowsPerSecond = *p.RowsPerSecond
+	}
+	if len(p.EntityRowsPerSecond) > 0 && set("entity-rows-per-second") {
+		overrides := make([]string, 0, len(p.EntityRowsPerSecond))
+		for entity, rate := range p.EntityRowsPerSecond {
+			overrides = append(overrides, fmt.Sprintf("%s=%g", entity, rate))
+		}
+		sort.Strings(overrides)
+		entityRowsPerSecond = strings.Join(overrides, ",")
+	}
+}
+
 // run performs the main application logic
 func run(inputFile, outputDir string, dataVolume int, countConfigFile string, autoCardinality bool) error {
 	// Start profiling if requested
@@ -174,37 +653,263 @@ func run(inputFile, outputDir string, dataVolume int, countConfigFile string, au
 	// Print start message
 	printHeader()
 	color.Cyan("Input file: %s", inputFile)
+	if varsFile != "" {
+		color.Cyan("Vars file: %s", varsFile)
+	}
+	if len(overlays) > 0 {
+		color.Cyan("Overlays: %s", overlays.String())
+	}
 	color.Cyan("Output directory: %s", outputDir)
+	if profile != nil {
+		color.Cyan("Profile: %s (%s)", profile.Name, profile.SourceFile)
+	}
 	if !validateOnly {
 		color.Cyan("Data volume: %d rows per entity", dataVolume)
+		if len(countOverrides) > 0 {
+			color.Cyan("Row count overrides: %s", countOverrides)
+		}
 		color.Cyan("Auto-cardinality: %t", autoCardinality)
+		if includeEmptyEntities {
+			color.Cyan("Include empty entities: true")
+		}
+		if strictCounts {
+			color.Cyan("Strict counts: true")
+		}
+		if strictUniqueness {
+			color.Cyan("Strict uniqueness: true")
+		}
+		if ignoreUnknownCounts {
+			color.Cyan("Ignore unknown counts: true")
+		}
+		if mode := outputMode(); mode != orchestrator.OutputModeOverwrite {
+			color.Cyan("Output mode: %s", mode)
+		}
+		if fillFromDir != "" {
+			color.Cyan("Fill from partial CSVs: %s", fillFromDir)
+		}
+		if fixturesFile != "" {
+			color.Cyan("Fixtures: %s", fixturesFile)
+		}
+		if assertionsFile != "" {
+			color.Cyan("Assertions: %s", assertionsFile)
+		}
+		if accessConfigFile != "" {
+			color.Cyan("Access simulation: %s", accessConfigFile)
+		}
+		if edgeCases {
+			color.Cyan("Edge cases: true")
+		}
+		if redacted {
+			color.Cyan("Redacted copy: %s", pipeline.RedactedDir(outputDir))
+		}
+		if encryptSpec != "" {
+			color.Cyan("Encryption: %s", encryptSpec)
+		}
+		if writeFaultSpec != "" {
+			color.Cyan("Injected write faults: %s", writeFaultSpec)
+		}
+		if ingestionSamples > 0 {
+			color.Cyan("Ingestion samples: %d rows per entity", ingestionSamples)
+		}
+		if seed != 0 {
+			color.Cyan("Seed: %d", seed)
+		}
+		if auditLog != "" {
+			color.Cyan("Audit log: %s", auditLog)
+		}
+		if descriptor != "" {
+			color.Cyan("Dataset descriptor: %s", descriptor)
+		}
+		if idFormats != "" {
+			color.Cyan("ID formats: %s", idFormats)
+		}
+		if entityOrder != "" {
+			color.Cyan("Entity order: %s", entityOrder)
+		}
+		if keyRegistry != "" {
+			color.Cyan("Key registry: %s (reuse %g)", keyRegistry, keyReuse)
+		}
+		if tenantSchema != "" {
+			color.Cyan("Tenant schema: %s", tenantSchema)
+		}
+		if population > 0 {
+			color.Cyan("Population: %d people", population)
+		}
+		if theme != "" {
+			color.Cyan("Theme: %s", theme)
+		}
+		if addressCoherence != pipeline.AddressCoherenceStrict {
+			color.Cyan("Address coherence: %s", addressCoherence)
+		}
+		if outputFormat != pipeline.OutputFormatCSV {
+			color.Cyan("Output format: %s", outputFormat)
+		}
+		if goPackage != "" {
+			color.Cyan("Go package: %s", goPackage)
+		}
+		if jsonlShape != "" {
+			color.Cyan("JSON lines shape: %s", jsonlShape)
+		}
+		if noMapping {
+			color.Cyan("Identity mapping: disabled (--no-mapping)")
+		} else if mappingFile != "" {
+			color.Cyan("Identity mapping: %s (encrypted: %t)", mappingFile, mappingKeyEnv != "" || encryptSpec != "")
+		}
+		if noRealLookingPII {
+			color.Cyan("Clearly fake PII: true")
+		}
+		if rowsPerSecond > 0 || entityRowsPerSecond != "" {
+			color.Cyan("Output rate: %g rows/sec (overrides: %s)", rowsPerSecond, entityRowsPerSecond)
+		}
+		if writeWorkers > 1 {
+			color.Cyan("Write workers: %d", writeWorkers)
+		}
 	}
 	color.Cyan("Validation-only mode: %t", validateOnly)
+	if validateOnly && relationshipValidationFile != "" {
+		color.Cyan("Relationship validation overrides: %s", relationshipValidationFile)
+	}
+	if validateOnly && streamingValidation {
+		color.Cyan("Streaming validation: %t", streamingValidation)
+	}
+	if validateOnly && validationWorkers > 1 {
+		color.Cyan("Validation workers: %d", validationWorkers)
+	}
+	if validateOnly && validationCache != "" {
+		color.Cyan("Validation cache: %s", validationCache)
+	}
+	if validateOnly && strictCoercion {
+		color.Cyan("Strict coercion: %t", strictCoercion)
+	}
+	if validateOnly && dedupeOutput != "" {
+		color.Cyan("Dedupe output: %s", dedupeOutput)
+	}
+	if validateOnly && validationConfigFile != "" {
+		color.Cyan("Validation tolerances: %s", validationConfigFile)
+	}
+	if filenameReplacement != pipeline.DefaultFilenameReplacement {
+		color.Cyan("Filename replacement: %q", filenameReplacement)
+	}
+	if domainFolders {
+		color.Cyan("Domain folders: true")
+	}
+	if withTags != "" {
+		color.Cyan("With tags: %s", withTags)
+	}
+	if withoutTags != "" {
+		color.Cyan("Without tags: %s", withoutTags)
+	}
 	color.Cyan("Validate relationships: %t", validateRelationships)
 	color.Cyan("Generate ER diagram: %t", generateDiagram)
+	if reportHTML != "" {
+		color.Cyan("HTML report: %s", reportHTML)
+	}
+	if eventSinks != "" {
+		color.Cyan("Event sinks: %s", eventSinks)
+	}
+	if otelEndpoint != "" {
+		color.Cyan("OpenTelemetry endpoint: %s", otelEndpoint)
+	}
 	if cpuProfile != "" {
 		color.Cyan("CPU profiling: %s", cpuProfile)
 	}
 	if memProfile != "" {
 		color.Cyan("Memory profiling: %s", memProfile)
 	}
+	if memProfileThreshold > 0 {
+		color.Cyan("Memory profile threshold: %d MiB", memProfileThreshold)
+	}
+	if noIntern {
+		color.Cyan("Value interning: disabled")
+	}
 	color.Cyan("==================")
 
+	// Writers and validators name entity files with the same layout
+	layout, err := pipeline.NewFileLayout(filenameReplacement, domainFolders)
+	if err != nil {
+		return fmt.Errorf("invalid --filename-replacement value: %w", err)
+	}
+	fileLayout = layout
+
+	// Set up event sinks for observing the run
+	sinks, err := events.ParseSinks(eventSinks)
+	if err != nil {
+		return fmt.Errorf("invalid --events value: %w", err)
+	}
+	if otelEndpoint != "" {
+		provider, err := telemetry.Setup(context.Background(), otelEndpoint)
+		if err != nil {
+			return fmt.Errorf("failed to set up OpenTelemetry: %w", err)
+		}
+		// Registered before the emitter's Close so spans are ended before flushing
+		defer func() {
+			if err := provider.Shutdown(context.Background()); err != nil {
+				color.Yellow("Warning: failed to export telemetry: %v", err)
+			}
+		}()
+
+		sink, err := provider.NewSink()
+		if err != nil {
+			return fmt.Errorf("failed to set up OpenTelemetry: %w", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	var runReport *report.Report
+	if reportHTML != "" {
+		runReport = report.New(reportHTML, fmt.Sprintf("Fabricator report: %s", filepath.Base(inputFile)))
+		addReportSettings(runReport)
+		sinks = append(sinks, runReport)
+	}
+	emitter = events.NewEmitter(sinks...)
+	defer func() {
+		if err := emitter.Close(); err != nil {
+			color.Yellow("Warning: failed to deliver some events: %v", err)
+		}
+	}()
+
 	// Create a parser and parse the YAML file
 	color.Yellow("Parsing YAML definition file...")
-	parser := parser.NewParser(inputFile)
-	err := parser.Parse()
+	started := emitter.PhaseStarted("parse")
+	sorParser := parser.NewParser(inputFile)
+	if varsFile != "" {
+		if variables, err = parser.LoadVariables(varsFile); err != nil {
+			return fmt.Errorf("failed to load --vars-file: %w", err)
+		}
+		sorParser.Variables = variables
+	}
+	sorParser.Overlays = overlays
+	sorParser.RequireVariables = true
+	err = sorParser.Parse()
 	if err != nil {
 		// Extract details about relationship validation issues for better reporting
-		if strings.Contains(err.Error(), "relationship issues") {
+		if errors.Is(err, parser.ErrRelationshipIssues) {
 			// The full error message has detailed info, let's keep it
 			return fmt.Errorf("failed to parse YAML file due to relationship validation issues:\n%w", err)
 		}
 		return fmt.Errorf("failed to parse YAML file: %w", err)
 	}
 
-	// Extract definition from parser
-	def := parser.Definition
+	emitter.PhaseFinished("parse", started)
+
+	// Row counts set by overlays apply over -n and --count-config, and under --count
+	for entityID, count := range sorParser.OverlayCounts {
+		if _, set := countOverrides[entityID]; !set {
+			countOverrides[entityID] = count
+		}
+	}
+
+	// Extract definition from parser, leaving out the entities the tag filters drop
+	var def *parser.SORDefinition
+	def, tagExcluded, err = parser.FilterByTags(sorParser.Definition, parser.ParseTags(withTags), parser.ParseTags(withoutTags))
+	if err != nil {
+		return fmt.Errorf("invalid tag filter: %w", err)
+	}
+	if len(tagExcluded) > 0 {
+		color.Cyan("Excluded by tags: %s", strings.Join(tagExcluded, ", "))
+		if runReport != nil {
+			runReport.AddSetting("Excluded by tags", strings.Join(tagExcluded, ", "))
+		}
+	}
 
 	// Resolve output directory
 	absOutputDir, err := filepath.Abs(outputDir)
@@ -230,7 +935,10 @@ func run(inputFile, outputDir string, dataVolume int, countConfigFile string, au
 	if generateDiagram {
 		diagramResult, err := orchestrator.RunDiagramGeneration(def, absOutputDir, orchestrator.DiagramOptions{})
 		if err == nil && diagramResult.Generated {
-			color.Green("✓ Generated ER diagram at %s", diagramResult.Path)
+			color.Green(console.Text("✓ Generated ER diagram at %s"), diagramResult.Path)
+			if runReport != nil {
+				runReport.SetDiagram(diagramResult.Path)
+			}
 		}
 	}
 
@@ -251,54 +959,390 @@ func run(inputFile, outputDir string, dataVolume int, countConfigFile string, au
 	return nil
 }
 
-// runGenerationMode handles data generation workflow
-func runGenerationMode(def *parser.SORDefinition, outputDir string, dataVolume int, countConfigFile string, autoCardinality bool) error {
-	// Load count configuration if provided
-	var countConfig *config.CountConfiguration
-	if countConfigFile != "" {
-		color.Yellow("Loading row count configuration from %s...", countConfigFile)
-		cfg, err := config.LoadConfiguration(countConfigFile)
-		if err != nil {
-			return fmt.Errorf("failed to load count configuration: %w", err)
+// addReportSettings records the run's configuration in the HTML report
+func addReportSettings(runReport *report.Report) {
+	runReport.AddSetting("Input file", inputFile)
+	if varsFile != "" {
+		runReport.AddSetting("Vars file", varsFile)
+	}
+	if len(overlays) > 0 {
+		runReport.AddSetting("Overlays", overlays.String())
+	}
+	runReport.AddSetting("Output directory", outputDir)
+	runReport.AddSetting("Validation-only mode", fmt.Sprintf("%t", validateOnly))
+	if filenameReplacement != pipeline.DefaultFilenameReplacement {
+		runReport.AddSetting("Filename replacement", fmt.Sprintf("%q", filenameReplacement))
+	}
+	if domainFolders {
+		runReport.AddSetting("Domain folders", "true")
+	}
+	if withTags != "" {
+		runReport.AddSetting("With tags", withTags)
+	}
+	if withoutTags != "" {
+		runReport.AddSetting("Without tags", withoutTags)
+	}
+	if noIntern {
+		runReport.AddSetting("Value interning", "disabled")
+	}
+	if memProfileThreshold > 0 {
+		runReport.AddSetting("Memory profile threshold", fmt.Sprintf("%d MiB", memProfileThreshold))
+	}
+	if !validateOnly {
+		if profile != nil {
+			runReport.AddSetting("Profile", fmt.Sprintf("%s (%s)", profile.Name, profile.SourceFile))
+			runReport.AddSetting("Data volume", fmt.Sprintf("%d rows per entity", dataVolume))
+		} else if countConfigFile != "" {
+			runReport.AddSetting("Row counts", countConfigFile)
+		} else {
+			runReport.AddSetting("Data volume", fmt.Sprintf("%d rows per entity", dataVolume))
 		}
-		countConfig = cfg
-
-		// Validate configuration against SOR entities
-		var entityIDs []string
-		for _, entity := range def.Entities {
-			entityIDs = append(entityIDs, entity.ExternalId)
+		if len(countOverrides) > 0 {
+			runReport.AddSetting("Row count overrides", countOverrides.String())
 		}
-		if err := countConfig.Validate(entityIDs); err != nil {
-			return fmt.Errorf("count configuration validation failed: %w", err)
+		runReport.AddSetting("Auto-cardinality", fmt.Sprintf("%t", autoCardinality))
+		runReport.AddSetting("Include empty entities", fmt.Sprintf("%t", includeEmptyEntities))
+		runReport.AddSetting("Strict counts", fmt.Sprintf("%t", strictCounts))
+		if strictUniqueness {
+			runReport.AddSetting("Strict uniqueness", "true")
 		}
-		color.Green("✓ Row count configuration loaded and validated")
-	}
-
-	// Calculate estimated number of records
-	totalRecords := len(def.Entities) * dataVolume
-	if countConfig != nil {
-		// Recalculate with per-entity counts
-		totalRecords = 0
-		for _, entity := range def.Entities {
-			totalRecords += countConfig.GetCount(entity.ExternalId, dataVolume)
+		if ignoreUnknownCounts {
+			runReport.AddSetting("Ignore unknown counts", "true")
 		}
-	}
-	color.Yellow("Estimated total CSV records to generate: %d", totalRecords)
-
-	// Generate data using orchestrator
-	totalEntities := len(def.Entities)
+		if mode := outputMode(); mode != orchestrator.OutputModeOverwrite {
+			runReport.AddSetting("Output mode", mode)
+		}
+		runReport.AddSetting("Output format", outputFormat)
+		if goPackage != "" {
+			runReport.AddSetting("Go package", goPackage)
+		}
+		if jsonlShape != "" {
+			runReport.AddSetting("JSON lines shape", jsonlShape)
+		}
+		if fillFromDir != "" {
+			runReport.AddSetting("Fill from partial CSVs", fillFromDir)
+		}
+		if fixturesFile != "" {
+			runReport.AddSetting("Fixtures", fixturesFile)
+		}
+		if assertionsFile != "" {
+			runReport.AddSetting("Assertions", assertionsFile)
+		}
+		if accessConfigFile != "" {
+			runReport.AddSetting("Access simulation", accessConfigFile)
+		}
+		if edgeCases {
+			runReport.AddSetting("Edge cases", "true")
+		}
+		if redacted {
+			runReport.AddSetting("Redacted copy", pipeline.RedactedDir(outputDir))
+		}
+		if encryptSpec != "" {
+			runReport.AddSetting("Encryption", encryptSpec)
+		}
+		if writeFaultSpec != "" {
+			runReport.AddSetting("Injected write faults", writeFaultSpec)
+		}
+		if ingestionSamples > 0 {
+			runReport.AddSetting("Ingestion samples", fmt.Sprintf("%d rows per entity", ingestionSamples))
+		}
+		if writeWorkers > 1 {
+			runReport.AddSetting("Write workers", fmt.Sprintf("%d", writeWorkers))
+		}
+		if seed != 0 {
+			runReport.AddSetting("Seed", fmt.Sprintf("%d", seed))
+		}
+		if auditLog != "" {
+			runReport.AddSetting("Audit log", auditLog)
+		}
+		if descriptor != "" {
+			runReport.AddSetting("Dataset descriptor", descriptor)
+		}
+		if idFormats != "" {
+			runReport.AddSetting("ID formats", idFormats)
+		}
+		if entityOrder != "" {
+			runReport.AddSetting("Entity order", entityOrder)
+		}
+		if keyRegistry != "" {
+			runReport.AddSetting("Key registry", fmt.Sprintf("%s (reuse %g)", keyRegistry, keyReuse))
+		}
+		if tenantSchema != "" {
+			runReport.AddSetting("Tenant schema", tenantSchema)
+		}
+		if population > 0 {
+			runReport.AddSetting("Population", fmt.Sprintf("%d people", population))
+		}
+		if theme != "" {
+			runReport.AddSetting("Theme", theme)
+		}
+		if addressCoherence != pipeline.AddressCoherenceStrict {
+			runReport.AddSetting("Address coherence", addressCoherence)
+		}
+	} else {
+		if relationshipValidationFile != "" {
+			runReport.AddSetting("Relationship validation overrides", relationshipValidationFile)
+		}
+		if streamingValidation {
+			runReport.AddSetting("Streaming validation", "true")
+		}
+		if validationWorkers > 1 {
+			runReport.AddSetting("Validation workers", fmt.Sprintf("%d", validationWorkers))
+		}
+		if validationCache != "" {
+			runReport.AddSetting("Validation cache", validationCache)
+		}
+		if strictCoercion {
+			runReport.AddSetting("Strict coercion", "true")
+		}
+		if dedupeOutput != "" {
+			runReport.AddSetting("Dedupe output", dedupeOutput)
+		}
+		if validationConfigFile != "" {
+			runReport.AddSetting("Validation tolerances", validationConfigFile)
+		}
+	}
+	runReport.AddSetting("Validate relationships", fmt.Sprintf("%t", validateRelationships))
+	runReport.AddSetting("Generate ER diagram", fmt.Sprintf("%t", generateDiagram))
+	runReport.AddSetting("Version", version)
+}
+
+// runGenerationMode handles data generation workflow
+func runGenerationMode(def *parser.SORDefinition, outputDir string, dataVolume int, countConfigFile string, autoCardinality bool) error {
+	// Check the SOR against the tenant's before generating anything from it
+	if tenantSchema != "" {
+		var token string
+		if tenantTokenEnv != "" {
+			if token = os.Getenv(tenantTokenEnv); token == "" {
+				return fmt.Errorf("environment variable %s (from --tenant-token-env) is empty or unset", tenantTokenEnv)
+			}
+		}
+		color.Yellow("Comparing the SOR with the tenant's schema...")
+		if err := subcommands.CompareSchema(subcommands.CompareSchemaOptions{
+			SORFile:   inputFile,
+			Variables: variables,
+			Overlays:  overlays,
+			Tenant:    tenantSchema,
+			Token:     token,
+			Output:    os.Stdout,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Load count configuration if provided
+	var countConfig *config.CountConfiguration
+	if profile != nil {
+		countConfig = profile.CountConfiguration()
+	} else if countConfigFile != "" {
+		color.Yellow("Loading row count configuration from %s...", countConfigFile)
+		cfg, err := config.LoadConfiguration(countConfigFile)
+		if err != nil {
+			return fmt.Errorf("failed to load count configuration: %w", err)
+		}
+		countConfig = cfg
+	}
+	countConfig = countOverrides.Apply(countConfig)
+	if countConfig != nil {
+		countConfig.AllowEmpty = includeEmptyEntities
+		countConfig.IgnoreUnknown = ignoreUnknownCounts
+
+		// Validate configuration against SOR entities
+		// Entities left out by tags keep their counts for runs that include them
+		entityIDs := append([]string{}, tagExcluded...)
+		for _, entity := range def.Entities {
+			entityIDs = append(entityIDs, entity.ExternalId)
+		}
+		if err := countConfig.Validate(entityIDs); err != nil {
+			return fmt.Errorf("count configuration validation failed: %w", err)
+		}
+		for _, warning := range countConfig.Warnings {
+			color.Yellow(console.Text("⚠️  %s"), warning)
+			emitter.Warning(warning)
+		}
+		color.Green(console.Text("✓ Row count configuration loaded and validated"))
+	}
+
+	// Load the fixed rows if provided
+	var fixtures *config.Fixtures
+	if fixturesFile != "" {
+		loaded, err := config.LoadFixtures(fixturesFile)
+		if err != nil {
+			return fmt.Errorf("failed to load fixtures: %w", err)
+		}
+		if err := loaded.Validate(def); err != nil {
+			return fmt.Errorf("fixtures validation failed: %w", err)
+		}
+		fixtures = loaded
+		fixedRows := 0
+		for entityID := range loaded.Rows {
+			fixedRows += loaded.Count(entityID)
+		}
+		color.Green(console.Text("✓ Fixtures loaded and validated (%d fixed rows)"), fixedRows)
+	}
+
+	// Load the assertions file if provided; generation checks them against the SOR
+	var assertions []parser.Assertion
+	if assertionsFile != "" {
+		loaded, err := config.LoadAssertions(assertionsFile)
+		if err != nil {
+			return fmt.Errorf("failed to load assertions: %w", err)
+		}
+		assertions = loaded
+	}
+
+	// Calculate estimated number of records, with per-entity counts, inline data and
+	// fixtures beyond an entity's count
+	totalRecords := 0
+	for entityID, count := range orchestrator.BuildRowCountsMap(def, countConfig, dataVolume) {
+		if fixtures != nil {
+			count = max(count, fixtures.Count(entityID))
+		}
+		totalRecords += count
+	}
+	color.Yellow("Estimated total CSV records to generate: %d", totalRecords)
+
+	// Generate data using orchestrator
+	totalEntities := len(def.Entities)
 	color.Yellow("Generating data for %d entities...", totalEntities)
 	color.Yellow("Writing CSV files to %s...", outputDir)
 
+	rateOverrides, err := pipeline.ParseRateOverrides(entityRowsPerSecond)
+	if err != nil {
+		return fmt.Errorf("invalid --entity-rows-per-second value: %w", err)
+	}
+	idFormatRules, err := pipeline.ParseIDFormats(idFormats)
+	if err != nil {
+		return fmt.Errorf("invalid --id-formats value: %w", err)
+	}
+
+	// Resolve identity mapping output; --no-mapping always wins
+	var mappingPassphrase string
+	if noMapping {
+		mappingFile = ""
+	} else if mappingFile != "" && mappingKeyEnv != "" {
+		mappingPassphrase = os.Getenv(mappingKeyEnv)
+		if mappingPassphrase == "" {
+			return fmt.Errorf("environment variable %s (from --mapping-key-env) is empty or unset", mappingKeyEnv)
+		}
+	}
+
+	// Resolve output encryption; the passphrase never appears on the command line
+	var encryptor *encryption.Encryptor
+	if encryptSpec != "" {
+		spec, err := encryption.ParseSpec(encryptSpec)
+		if err != nil {
+			return fmt.Errorf("invalid --encrypt value: %w", err)
+		}
+		passphrase := os.Getenv(spec.KeyEnv)
+		if passphrase == "" {
+			return fmt.Errorf("environment variable %s (from --encrypt) is empty or unset", spec.KeyEnv)
+		}
+		if encryptor, err = encryption.NewEncryptor(passphrase); err != nil {
+			return err
+		}
+		// The identity mapping holds generated values too, so it isn't left in the clear
+		if mappingFile != "" && mappingPassphrase == "" {
+			mappingPassphrase = passphrase
+		}
+	}
+
+	var writeFaults *pipeline.WriteFaults
+	if writeFaultSpec != "" {
+		faults, err := pipeline.ParseWriteFaults(writeFaultSpec)
+		if err != nil {
+			return fmt.Errorf("invalid --inject-write-faults value: %w", err)
+		}
+		writeFaults = faults
+	}
+
+	// Load the access simulation if provided
+	var accessConfig *config.AccessConfiguration
+	if accessConfigFile != "" {
+		cfg, err := config.LoadAccessConfiguration(accessConfigFile)
+		if err != nil {
+			return fmt.Errorf("failed to load access configuration: %w", err)
+		}
+		accessConfig = cfg
+		color.Green(console.Text("✓ Access configuration loaded (%d roles, %d SoD rules)"), len(cfg.Roles), len(cfg.SoDRules))
+	}
+
+	var order []string
+	for _, entityID := range strings.Split(entityOrder, ",") {
+		if entityID = strings.TrimSpace(entityID); entityID != "" {
+			order = append(order, entityID)
+		}
+	}
+
 	options := orchestrator.GenerationOptions{
 		DataVolume:      dataVolume,
 		CountConfig:     countConfig,
 		AutoCardinality: autoCardinality,
 		GenerateDiagram: generateDiagram,
 		ValidateResults: false, // Skip validation in generation mode for performance
+		PartialInputDir: fillFromDir,
+		Fixtures:        fixtures,
+		Events:          emitter,
+		OutputFormat:    outputFormat,
+		GoPackage:       goPackage,
+		JSONLShape:      jsonlShape,
+		ClearlyFakePII:  noRealLookingPII,
+		Layout:          fileLayout,
+
+		IncludeEmptyEntities: includeEmptyEntities,
+		NoValueInterning:     noIntern,
+		StrictCounts:         strictCounts,
+		StrictUniqueness:     strictUniqueness,
+		AddressCoherence:     addressCoherence,
+		OutputMode:           outputMode(),
+
+		MappingFile:       mappingFile,
+		MappingPassphrase: mappingPassphrase,
+
+		RowsPerSecond:       rowsPerSecond,
+		EntityRowsPerSecond: rateOverrides,
+
+		WriteWorkers:    writeWorkers,
+		WriteFileBuffer: writeFileBuffer,
+
+		AccessConfig: accessConfig,
+		Seed:         seed,
+		Population:   population,
+		Theme:        theme,
+		EdgeCases:    edgeCases,
+		Redacted:     redacted,
+		Encryptor:    encryptor,
+		WriteFaults:  writeFaults,
+		Assertions:   assertions,
+		AuditLog:     auditLog,
+		Descriptor:   descriptor,
+		IDFormats:    idFormatRules,
+		EntityOrder:  order,
+		KeyRegistry:  keyRegistry,
+		KeyReuse:     keyReuse,
+
+		MemoryThreshold: uint64(memProfileThreshold) << 20,
+
+		IngestionSampleRows: ingestionSamples,
 	}
 
 	result, err := orchestrator.RunGeneration(def, outputDir, options)
+	if writeFaults != nil {
+		color.Yellow("Injected %d write faults", writeFaults.Injected())
+	}
+	if result != nil && len(result.Assertions) > 0 {
+		color.Cyan("\nAssertions:")
+		for _, assertion := range result.Assertions {
+			switch {
+			case assertion.Passed:
+				color.Green(console.Text("  ✓ %s"), assertion)
+			case assertion.Severity == pipeline.AssertionSeverityWarn:
+				color.Yellow(console.Text("  ⚠️  %s"), assertion)
+			default:
+				color.Red(console.Text("  ✗ %s"), assertion)
+			}
+		}
+	}
 	if err != nil {
 		return fmt.Errorf("failed to generate CSV data: %w", err)
 	}
@@ -308,13 +1352,13 @@ func runGenerationMode(def *parser.SORDefinition, outputDir string, dataVolume i
 		if len(result.ValidationSummary.Errors) > 0 {
 			color.Yellow("Found relationship consistency issues:")
 			for _, errMsg := range result.ValidationSummary.Errors {
-				color.Red("  • %s", errMsg)
+				color.Red(console.Text("  • %s"), errMsg)
 			}
 			color.Yellow("\nSome relationships have consistency issues. This might be expected with random data generation.")
 		} else {
-			color.Green("✓ All relationships are consistent across generated files!")
+			color.Green(console.Text("✓ All relationships are consistent across generated files!"))
 		}
-		color.Green("✓ All unique constraints are respected in generated files!")
+		color.Green(console.Text("✓ All unique constraints are respected in generated files!"))
 	}
 
 	// Print completion summary
@@ -326,8 +1370,58 @@ func runGenerationMode(def *parser.SORDefinition, outputDir string, dataVolume i
 func runValidationMode(def *parser.SORDefinition, outputDir string) error {
 	color.Yellow("Validation-only mode: Loading and validating existing CSV files from %s...", outputDir)
 
+	if dedupeOutput != "" {
+		dedupeDir, err := filepath.Abs(dedupeOutput)
+		if err != nil {
+			return fmt.Errorf("failed to resolve --dedupe-output path: %w", err)
+		}
+		if dedupeDir == outputDir {
+			return fmt.Errorf("--dedupe-output must be a different directory from the one validated")
+		}
+	}
+
 	options := orchestrator.ValidationOptions{
 		GenerateDiagram: generateDiagram,
+		Streaming:       streamingValidation,
+		Workers:         validationWorkers,
+		Cache:           validationCache,
+		StrictCoercion:  strictCoercion,
+		DedupeOutput:    dedupeOutput,
+		Events:          emitter,
+		Layout:          fileLayout,
+
+		NoValueInterning: noIntern,
+	}
+
+	// Resolve decryption of the files; the passphrase never appears on the command line
+	if decryptSpec != "" {
+		spec, err := encryption.ParseSpec(decryptSpec)
+		if err != nil {
+			return fmt.Errorf("invalid --decrypt value: %w", err)
+		}
+		passphrase := os.Getenv(spec.KeyEnv)
+		if passphrase == "" {
+			return fmt.Errorf("environment variable %s (from --decrypt) is empty or unset", spec.KeyEnv)
+		}
+		if options.Decryptor, err = encryption.NewDecryptor(passphrase); err != nil {
+			return err
+		}
+	}
+
+	if relationshipValidationFile != "" {
+		levels, err := config.LoadRelationshipValidation(relationshipValidationFile)
+		if err != nil {
+			return err
+		}
+		options.RelationshipValidation = levels
+	}
+
+	if validationConfigFile != "" {
+		validationConfig, err := config.LoadValidationConfiguration(validationConfigFile)
+		if err != nil {
+			return err
+		}
+		options.Tolerances = validationConfig.Tolerances
 	}
 
 	result, err := orchestrator.RunValidation(def, outputDir, options)
@@ -335,64 +1429,306 @@ func runValidationMode(def *parser.SORDefinition, outputDir string) error {
 		return fmt.Errorf("validation-only mode failed: %w", err)
 	}
 
+	if validationCache != "" {
+		color.Cyan("Validation cache: replayed %d unchanged checks, ran %d", result.CachedChecks, result.RunChecks)
+	}
+
 	// Report validation results
+	if len(result.ValidationWarnings) > 0 {
+		color.Yellow("Found %d validation warnings:", len(result.ValidationWarnings))
+		for _, warning := range result.ValidationWarnings {
+			color.Yellow(console.Text("  • %s"), warning)
+		}
+	}
 	if len(result.ValidationErrors) > 0 {
 		color.Yellow("Found %d validation issues:", len(result.ValidationErrors))
 		for _, errMsg := range result.ValidationErrors {
-			color.Red("  • %s", errMsg)
+			color.Red(console.Text("  • %s"), errMsg)
 		}
 		color.Yellow("\nSome relationships have consistency issues. This might be expected with existing data.")
 	} else {
-		color.Green("✓ All CSV files validated successfully - no issues found!")
+		color.Green(console.Text("✓ All CSV files validated successfully - no issues found!"))
+	}
+
+	if len(result.DuplicateKeys) > 0 {
+		color.Yellow("\nDuplicate keys, by likely cause:")
+		for _, group := range result.DuplicateKeys {
+			color.Red(console.Text("  • %s"), group)
+			color.Yellow(console.Text("    → %s"), group.Suggestion())
+		}
+	}
+	if dedupeOutput != "" {
+		color.Green(console.Text("✓ Wrote a copy of the files without duplicate keys to %s (%d rows dropped)"),
+			dedupeOutput, result.DedupeDropped)
+	}
+
+	for _, tolerance := range result.ToleranceResults {
+		if tolerance.Within {
+			color.Green(console.Text("✓ %s"), tolerance)
+		} else {
+			color.Red(console.Text("✗ %s"), tolerance)
+		}
 	}
 
 	// Print validation summary
 	printValidationSummary(outputDir, result, generateDiagram)
+
+	// With an error budget, issues beyond it fail the run so CI can gate on it
+	if validationConfigFile != "" && len(result.ValidationErrors) > 0 {
+		return fmt.Errorf("validation failed: %d issues remain beyond the tolerances in %s",
+			len(result.ValidationErrors), validationConfigFile)
+	}
 	return nil
 }
 
+// outputMode returns the orchestrator output mode the flags select
+func outputMode() string {
+	switch {
+	case cleanOutput:
+		return orchestrator.OutputModeClean
+	case failIfExists:
+		return orchestrator.OutputModeFailIfExists
+	case mergeOutput:
+		return orchestrator.OutputModeMerge
+	default:
+		return orchestrator.OutputModeOverwrite
+	}
+}
+
 // printUsage displays the usage information with proper double-dash syntax for long options
 func printUsage() {
-	// Subcommands section
-	_, _ = color.New(color.FgCyan, color.Bold).Println("\nSubcommands:")
-	fmt.Println("  init-count-config\n\tGenerate a row count configuration template from a SOR YAML file")
+	// Commands section
+	_, _ = color.New(color.FgCyan, color.Bold).Println("\nCommands:")
+	fmt.Println("  generate\n\tGenerate data from a SOR YAML file; the default when no command is given (see Generate Flags)")
+	fmt.Println("\n\tUsage: fabricator generate -f <sor.yaml> [flags]")
+	fmt.Println("\tExample:")
+	fmt.Println("\t  fabricator generate -f my-sor.yaml -n 100 -o output/")
+	fmt.Println("\n  validate\n\tValidate existing CSV files against a SOR without generating data")
+	fmt.Println("\n\tUsage: fabricator validate -f <sor.yaml> [options]")
+	fmt.Println("\tOptions:")
+	fmt.Println("\t  -f, --file                 Path to the SOR YAML definition file (required)")
+	fmt.Println("\t  -i, --input                Directory holding the CSV files to validate (default: output)")
+	fmt.Println("\t  --relationship-validation  YAML file mapping relationship keys to skip, warn or error")
+	fmt.Println("\t  --validation-config        YAML file of per-check tolerances")
+	fmt.Println("\t  --streaming-validation     Validate row by row, keeping only key indexes in memory")
+	fmt.Println("\t  --validation-cache         File caching results by file checksum; re-runs only check changed files")
+	fmt.Println("\t  --validation-workers       Entity CSV files loaded and indexed at the same time (default: 1)")
+	fmt.Println("\t  --strict-coercion          Report every value coerced to its attribute's type as an error")
+	fmt.Println("\t  --dedupe-output            Write a copy of the files without the rows repeating a key to this directory")
+	fmt.Println("\t  --domain-folders           Read each entity's file from a subfolder named after its domain")
+	fmt.Println("\t  --with-tags, --without-tags  Validate only the entities selected by their tags")
+	fmt.Println("\t  --filename-replacement     Replacement used for invalid filename characters (default: _)")
+	fmt.Println("\t  -d, --diagram              Also generate an Entity-Relationship diagram in the directory")
+	fmt.Println("\t  --report-html              Write a single-file HTML report of the validation")
+	fmt.Println("\t  --decrypt                  Decrypt files written with --encrypt: aes:<VAR>, passphrase in env var VAR")
+	fmt.Println("\tExample:")
+	fmt.Println("\t  fabricator validate -f my-sor.yaml -i output/ --validation-config tolerances.yaml")
+	fmt.Println("\n  diagram (or diagram-only)\n\tGenerate a SOR's Entity-Relationship diagram (rendered with Graphviz, DOT source without)")
+	fmt.Println("\n\tUsage: fabricator diagram -f <sor.yaml> [options]")
+	fmt.Println("\tOptions:")
+	fmt.Println("\t  -f, --file         Path to the SOR YAML definition file (required)")
+	fmt.Println("\t  -o, --output       Diagram file, or directory to write it to named after the SOR (default: output)")
+	fmt.Println("\t  --layout           Graphviz layout engine: dot, neato or fdp (default: dot)")
+	fmt.Println("\t  --rankdir          Direction of the dot layout: TB, LR, BT or RL (default: TB)")
+	fmt.Println("\t  --format           svg, png, pdf or dot (default: the output file's extension)")
+	fmt.Println("\t  --include          Comma-separated entities to draw, by externalId or name; globs such as 'Okta*' allowed")
+	fmt.Println("\t  --domain           Comma-separated domains whose entities are drawn")
+	fmt.Println("\t  --watch            Redraw the diagram each time the SOR file changes, until interrupted")
+	fmt.Println("\tExample:")
+	fmt.Println("\t  fabricator diagram -f my-sor.yaml -o er.svg --layout neato --include 'User,Group*'")
+	fmt.Println("\n  analyze\n\tShow planned rows, relationship cardinalities and truncation warnings without generating data")
+	fmt.Println("\n\tUsage: fabricator analyze -f <sor.yaml> [options]")
+	fmt.Println("\tOptions:")
+	fmt.Println("\t  -f, --file         Path to the SOR YAML definition file (required)")
+	fmt.Println("\t  -c, --count-config Path to row count configuration YAML file")
+	fmt.Println("\t  -n, --num-rows     Row count of entities without a configured count (default: 100)")
+	fmt.Println("\t  -o, --output       Write to this file instead of stdout")
+	fmt.Println("\tExample:")
+	fmt.Println("\t  fabricator analyze -f my-sor.yaml -c counts.yaml")
+	fmt.Println("\n  help [command]\n\tShow this help, or the flags of one command")
+	fmt.Println("\n  init-count-config\n\tGenerate a row count configuration template from a SOR YAML file")
 	fmt.Println("\n\tUsage: fabricator init-count-config -f <sor.yaml> [options]")
 	fmt.Println("\tOptions:")
 	fmt.Println("\t  -f, --file         Path to the SOR YAML definition file (required)")
 	fmt.Println("\t  -n, --num-rows     Default row count for all entities (default: 100)")
 	fmt.Println("\tExample:")
 	fmt.Println("\t  fabricator init-count-config -f my-sor.yaml > counts.yaml")
+	fmt.Println("\n  dependency-layers\n\tShow the topological layers used to order generation and the relationships behind them")
+	fmt.Println("\n\tUsage: fabricator dependency-layers -f <sor.yaml> [options]")
+	fmt.Println("\tOptions:")
+	fmt.Println("\t  -f, --file         Path to the SOR YAML definition file (required)")
+	fmt.Println("\t  --format           Output format: text or dot (default: text)")
+	fmt.Println("\t  -o, --output       Write to this file instead of stdout")
+	fmt.Println("\tExample:")
+	fmt.Println("\t  fabricator dependency-layers -f my-sor.yaml --format dot -o layers.dot")
+	fmt.Println("\n  check-relationships\n\tList every relationship attribute that matches no entity attribute, with the closest references")
+	fmt.Println("\n\tUsage: fabricator check-relationships -f <sor.yaml>")
+	fmt.Println("\tOptions:")
+	fmt.Println("\t  -f, --file         Path to the SOR YAML definition file (required)")
+	fmt.Println("\tExample:")
+	fmt.Println("\t  fabricator check-relationships -f my-sor.yaml")
+	fmt.Println("\n  compare-schema\n\tList the entities, attributes and types of the SOR that differ from the tenant's SOR schema")
+	fmt.Println("\n\tUsage: fabricator compare-schema -f <sor.yaml> --tenant <file|URL> [options]")
+	fmt.Println("\tOptions:")
+	fmt.Println("\t  -f, --file         Path to the SOR YAML definition file (required)")
+	fmt.Println("\t  --tenant           File or http(s) URL of the tenant's SOR schema export (required)")
+	fmt.Println("\t  --token-env        Environment variable holding the bearer token sent to a URL")
+	fmt.Println("\tExample:")
+	fmt.Println("\t  fabricator compare-schema -f my-sor.yaml --tenant https://tenant.example.com/sor/schema --token-env SGNL_TOKEN")
+	fmt.Println("\n  audit-types\n\tShow which generator or heuristic produces each attribute's values, to catch mis-detected columns")
+	fmt.Println("\n\tUsage: fabricator audit-types -f <sor.yaml>")
+	fmt.Println("\tOptions:")
+	fmt.Println("\t  -f, --file         Path to the SOR YAML definition file (required)")
+	fmt.Println("\tExample:")
+	fmt.Println("\t  fabricator audit-types -f my-sor.yaml")
+	fmt.Println("\n  export-schema\n\tExport per-entity schemas of the generated data as JSON Schema, Avro or SQL DDL")
+	fmt.Println("\n\tUsage: fabricator export-schema -f <sor.yaml> --format <jsonschema|avro|ddl> [options]")
+	fmt.Println("\tOptions:")
+	fmt.Println("\t  -f, --file         Path to the SOR YAML definition file (required)")
+	fmt.Println("\t  --format           Schema format: jsonschema, avro or ddl (required)")
+	fmt.Println("\t  -o, --output       Write one schema file per entity to this directory instead of stdout")
+	fmt.Println("\tExample:")
+	fmt.Println("\t  fabricator export-schema -f my-sor.yaml --format avro -o schemas/")
+	fmt.Println("\n  import-openapi\n\tConvert an OpenAPI spec's schemas into a draft SOR YAML to review before fabrication")
+	fmt.Println("\n\tUsage: fabricator import-openapi -i <openapi.yaml|json> [options]")
+	fmt.Println("\tOptions:")
+	fmt.Println("\t  -i, --input        Path to the OpenAPI 3 or Swagger 2 document (required)")
+	fmt.Println("\t  -o, --output       Write the draft SOR to this file instead of stdout")
+	fmt.Println("\tExample:")
+	fmt.Println("\t  fabricator import-openapi -i openapi.yaml -o draft-sor.yaml")
+	fmt.Println("\n  infer\n\tInfer a draft SOR YAML from a directory of CSV files, one file per entity")
+	fmt.Println("\n\tUsage: fabricator infer -i <directory> [options]")
+	fmt.Println("\tOptions:")
+	fmt.Println("\t  -i, --input        Directory holding the CSV files (required)")
+	fmt.Println("\t  -o, --output       Write the draft SOR to this file instead of stdout")
+	fmt.Println("\t  --sample-rows      Rows read from each file, 0 for all (default: 10000)")
+	fmt.Println("\tExample:")
+	fmt.Println("\t  fabricator infer -i data/ -o sor.yaml")
+	fmt.Println("\n  trace\n\tPrint the rows connected to one row along a path of relationships in generated CSV files")
+	fmt.Println("\n\tUsage: fabricator trace -f <sor.yaml> --from <entity> --id <id> [options]")
+	fmt.Println("\tOptions:")
+	fmt.Println("\t  -f, --file         Path to the SOR YAML definition file (required)")
+	fmt.Println("\t  -i, --input        Directory holding the generated CSV files (default: output)")
+	fmt.Println("\t  --from             Starting entity: external ID, YAML key or display name (required)")
+	fmt.Println("\t  --id               Unique ID of the starting row (required)")
+	fmt.Println("\t  --path             Comma-separated relationship keys or names, each optionally :forward or :backward")
+	fmt.Println("\t  --domain-folders   Read each entity's file from a subfolder named after its domain")
+	fmt.Println("\t  -o, --output       Write to this file instead of stdout")
+	fmt.Println("\tExample:")
+	fmt.Println("\t  fabricator trace -f my-sor.yaml -i output/ --from User --id u123 --path Member,GroupMembership")
 
 	// Main command flags
-	_, _ = color.New(color.FgCyan, color.Bold).Println("\nMain Command Flags:")
+	_, _ = color.New(color.FgCyan, color.Bold).Println("\nGenerate Flags (fabricator generate, or no command):")
 	fmt.Println("  -v, --version\n\tDisplay version information")
 	fmt.Println("  -f, --file string\n\tPath to the YAML definition file (required)")
+	fmt.Println("  --vars-file string\n\tYAML file of values for ${NAME} references in the definition file, taking precedence over the environment")
+	fmt.Println("  --overlay string\n\tYAML file patching the definition file for a scenario: entities and attributes to add, change or remove (null), and row counts; repeat to apply several in order")
 	fmt.Println("  -o, --output string\n\tDirectory to store generated CSV files (default \"output\")")
+	fmt.Println("  --clean\n\tRemove everything in the output directory before generating; only a directory with a manifest.json (earlier fabricator output) is cleaned")
+	fmt.Println("  --fail-if-exists\n\tFail if the output directory holds any file")
+	fmt.Println("  --merge\n\tKeep the files of entities this run doesn't generate and list them in the manifest, instead of warning about them")
 	fmt.Println("  -n, --num-rows int\n\tNumber of rows to generate for each entity (default 100)")
 	fmt.Println("  --count-config, -c string\n\tPath to row count configuration YAML file (alternative to -n)")
+	fmt.Println("  --count entity=count\n\tRow count of one entity, over -n and --count-config; repeat for more entities")
+	fmt.Println("  --profile string\n\tApply a named profile (e.g. smoke, load, soak) from the --count-config file; explicit flags take precedence")
+	fmt.Println("  --seed int\n\tSeed the random generator so runs with the same SOR and settings produce the same data (0 = random)")
+	fmt.Println("  --address-coherence string\n\tHow closely street, city, state, postal code and country attributes agree: strict (one real place), loose (one country) or off (each part on its own) (default \"strict\")")
+	fmt.Println("  --theme string\n\tName departments, groups, projects and applications from a theme's vocabulary: " + strings.Join(pipeline.ThemeNames(), ", ") + ", " + pipeline.ThemeRandom + " (drawn from --seed) or a theme YAML file")
+	fmt.Println("  --population int\n\tFill names, emails, employee IDs and usernames of person-like entities from a population of N people derived from --seed, so the same people appear across entities and SORs (0 = disabled)")
+	fmt.Println("  --include-empty-entities\n\tAllow a row count of 0 (count config or -n) and write a header-only file for those entities")
+	fmt.Println("  --strict-counts\n\tFail before generating when row counts would leave relationship rows unmatched or dropped")
+	fmt.Println("  --strict-uniqueness\n\tFail when more rows share a scope than a uniqueWithin attribute has distinct values (e.g. a status unique per tenant), instead of adding numeric suffixes")
+	fmt.Println("  --ignore-unknown-counts\n\tWarn about and skip count configuration entries for entities not in the SOR instead of failing")
 	fmt.Println("  -a, --auto-cardinality\n\tEnable automatic cardinality detection for relationships")
 	fmt.Println("  --validate\n\tValidate relationships consistency in output CSV files (default true)")
 	fmt.Println("  --validate-only\n\tValidate existing CSV files without generating new data")
+	fmt.Println("  --relationship-validation string\n\tYAML file mapping relationship keys to skip, warn or error for --validate-only")
+	fmt.Println("  --validation-config string\n\tYAML file of per-check tolerances for --validate-only; validation fails when a check exceeds its tolerance")
+	fmt.Println("  --streaming-validation\n\tValidate CSV files row by row for --validate-only, keeping only key indexes in memory")
+	fmt.Println("  --validation-cache string\n\tFile caching --validate-only results by file checksum, so re-runs only check changed files and the relationships touching them")
+	fmt.Println("  --strict-coercion\n\tReport every value --validate-only coerces to its attribute's type (trimmed whitespace, booleans, dates) as an error instead of accepting it")
+	fmt.Println("  --dedupe-output string\n\tDirectory to write a copy of the files --validate-only checks to, keeping the first row with each key and dropping the rows repeating it")
+	fmt.Println("  --validation-workers int\n\tEntity CSV files loaded and indexed at the same time for --validate-only; foreign keys are checked once all are loaded (default 1)")
+	fmt.Println("  --fill-from string\n\tDirectory of partial CSV files; provided values are kept and missing columns generated")
+	fmt.Println("  --fixtures string\n\tYAML file of exact rows per entity, always written and counted toward its row count")
+	fmt.Println("  --assertions string\n\tYAML file of assertions checked over the generated data, added to those of the SOR")
+	fmt.Println("  --access-config string\n\tDistribute entitlement assignments by role share and plant SoD violations, writing their ground truth")
+	fmt.Println("  --redacted\n\tAlso write a copy of the files with attributes marked sensitive masked (keys hashed) to <output>-redacted")
+	fmt.Println("  --encrypt string\n\tEncrypt data files as they are written: aes:<VAR> encrypts with the passphrase in environment variable VAR")
+	fmt.Println("  --inject-write-faults string\n\tInject transient write errors into data files at these rates (enospc=<rate>,eacces=<rate>[,seed=N]); a failed run's manifest marks its partial files")
+	fmt.Println("  --edge-cases\n\tPut boundary values in the first rows of each entity and list them in edge_cases.json")
+	fmt.Println("  --ingestion-samples int\n\tWrite up to N rows per entity as SGNL ingestion payloads to ingestion-samples/ in the output directory")
+	fmt.Println("  --filename-replacement string\n\tReplacement for characters invalid in Windows filenames when naming entity files (default \"_\")")
+	fmt.Println("  --domain-folders\n\tWrite and read each entity's file in a subfolder named after its YAML domain")
+	fmt.Println("  --with-tags string\n\tComma-separated tags; tagged entities are generated only with one of them (untagged entities always are)")
+	fmt.Println("  --without-tags string\n\tComma-separated tags; entities with one of them are left out")
+	fmt.Println("  --report-html string\n\tWrite a single-file HTML report (entity counts and timing, validation issues, ER diagram, configuration)")
+	fmt.Println("  --events string\n\tComma-separated event sinks for run progress: stdout, jsonl:<path>")
+	fmt.Println("  --format string\n\tOutput format for generated rows: csv, jsonl, go or neo4j (default \"csv\")")
+	fmt.Println("  --go-package string\n\tPackage clause of the files written with --format go (default \"fixtures\")")
+	fmt.Println("  --jsonl-shape string\n\tLines written with --format jsonl: message (topic, key and string values) or row (the row with typed values) (default \"message\")")
+	fmt.Println("  --mapping-file string\n\tWrite a mapping of generated IDs to synthetic identity attributes (JSON lines)")
+	fmt.Println("  --mapping-key-env string\n\tEncrypt the mapping file with the passphrase in this environment variable")
+	fmt.Println("  --no-mapping\n\tNever write an identity mapping file, even if --mapping-file is set")
+	fmt.Println("  --id-formats string\n\tComma-separated <pattern>=<format> rules picking primary key formats (uuid, sequence), checked before the defaults \"type:Integer=sequence,type:Int64=sequence,*Guid=uuid,*Uuid=uuid\"")
+	fmt.Println("  --entity-order string\n\tComma-separated external IDs of entities generated and written first, in this order; each still follows the entities it references")
+	fmt.Println("  --key-registry string\n\tFile of the primary keys earlier runs generated, created if missing and updated with this run's keys; new keys avoid those it holds")
+	fmt.Println("  --key-reuse float\n\tShare (0 to 1) of each entity's rows taking keys from --key-registry instead of new ones (default 0)")
+	fmt.Println("  --tenant-schema string\n\tFile or http(s) URL of the tenant's SOR schema export; generation stops if the SOR's entities, attributes or types differ")
+	fmt.Println("  --tenant-token-env string\n\tEnvironment variable holding the bearer token sent to a --tenant-schema URL")
+	fmt.Println("  --audit-log string\n\tWrite the run's random decisions to this file as JSON lines; draws and records a seed when --seed is not set")
+	fmt.Println("  --descriptor string\n\tWrite a JSON dataset descriptor (paths, row counts, seed, schema hash, dataset version) for infrastructure as code steps; draws a seed when --seed is not set")
+	fmt.Println("  --no-real-looking-pii\n\tGenerate PII (SSNs, cards, IBANs, IPs, emails, phones) in obviously fake formats")
+	fmt.Println("  --rows-per-second float\n\tLimit output to this many rows per second per entity (default 0 = unlimited)")
+	fmt.Println("  --entity-rows-per-second string\n\tPer-entity rate overrides, e.g. User=10,Group=2 (0 = unlimited)")
+	fmt.Println("  --write-workers int\n\tEntity CSV files written at the same time, e.g. 8 on network storage (default 1)")
+	fmt.Println("  --write-file-buffer int\n\tBytes buffered per output file between writes to storage (default 1048576)")
+	fmt.Println("  --otel-endpoint string\n\tExport OpenTelemetry traces and metrics to an OTLP/HTTP collector (e.g. localhost:4318)")
 	fmt.Println("  --cpuprofile string\n\tWrite CPU profile to file")
 	fmt.Println("  --memprofile string\n\tWrite memory profile to file")
+	fmt.Println("  --memprofile-threshold int\n\tWrite a heap profile and a partial manifest to the output directory once memory in use reaches this many MiB, for runs that may run out of memory (default 0 = disabled)")
+	fmt.Println("  --no-intern\n\tDon't share repeated values (statuses, booleans, foreign keys) between rows; for debugging memory use")
+	fmt.Println("  --no-color\n\tDon't color output; setting the NO_COLOR environment variable does the same for every command")
+	fmt.Println("  --plain\n\tPrint plain ASCII text without colors or unicode symbols, for CI logs and scripts; setting " + console.PlainEnv + " does the same for every command")
 
-	// Build diagram flag description with dynamic default based on Graphviz availability
-	diagDesc := "Generate Entity-Relationship diagram"
-	if diagrams.IsGraphvizAvailable() {
-		diagDesc += " (default true)"
-	} else {
-		diagDesc += " (default false - Graphviz not found)"
-	}
-	fmt.Println("  -d, --diagram\n\t" + diagDesc)
+	fmt.Println("  -d, --diagram\n\tAlso generate an Entity-Relationship diagram in the output directory (deprecated: use fabricator diagram)")
 
 	// Examples section
 	_, _ = color.New(color.FgCyan, color.Bold).Println("\nExamples:")
 	fmt.Println("  # Generate CSVs with uniform row counts")
-	fmt.Println("  fabricator -f sor.yaml -n 100 -o output/")
+	fmt.Println("  fabricator generate -f sor.yaml -n 100 -o output/")
 	fmt.Println("\n  # Generate CSVs with per-entity row counts")
 	fmt.Println("  fabricator -f sor.yaml --count-config counts.yaml -o output/")
+	fmt.Println("\n  # Write rows as JSON messages in dependency order")
+	fmt.Println("  fabricator -f sor.yaml --format jsonl -o output/")
+	fmt.Println("\n  # Write small datasets as Go test fixtures")
+	fmt.Println("  fabricator -f sor.yaml -n 5 --format go --go-package fixtures -o internal/fixtures/")
+	fmt.Println("\n  # Check planned rows and cardinalities before generating")
+	fmt.Println("  fabricator analyze -f sor.yaml --count-config counts.yaml")
+	fmt.Println("\n  # Validate CSV files produced elsewhere")
+	fmt.Println("  fabricator validate -f sor.yaml -i output/")
 	fmt.Println("\n  # Generate a row count configuration template")
 	fmt.Println("  fabricator init-count-config -f sor.yaml > counts.yaml")
+	fmt.Println("\n  # Show generation order as topological layers")
+	fmt.Println("  fabricator dependency-layers -f sor.yaml")
+	fmt.Println("\n  # Find relationship attributes with mistyped names")
+	fmt.Println("  fabricator check-relationships -f sor.yaml")
+	fmt.Println("\n  # Check the SOR still matches the tenant's before generating")
+	fmt.Println("  fabricator compare-schema -f sor.yaml --tenant tenant-schema.yaml")
+	fmt.Println("\n  # See how each attribute's values are generated")
+	fmt.Println("  fabricator audit-types -f sor.yaml")
+	fmt.Println("\n  # Export SQL DDL for the generated tables")
+	fmt.Println("  fabricator export-schema -f sor.yaml --format ddl > schema.sql")
+	fmt.Println("\n  # Draft a SOR definition from an API's OpenAPI spec")
+	fmt.Println("  fabricator import-openapi -i openapi.yaml -o draft-sor.yaml")
+	fmt.Println("\n  # Draft a SOR definition from existing CSV files")
+	fmt.Println("  fabricator infer -i data/ -o sor.yaml")
+	fmt.Println("\n  # Show the groups a generated user belongs to")
+	fmt.Println("  fabricator trace -f sor.yaml --from User --id u123 --path Member,GroupMembership")
+	fmt.Println("\n  # Read an encrypted identity mapping")
+	fmt.Println("  fabricator decrypt-mapping -i mapping.enc --key-env MAPPING_KEY")
+	fmt.Println("\n  # Read a data file written with --encrypt")
+	fmt.Println("  fabricator decrypt -i output/User.csv.enc --key-env FABRICATOR_KEY > User.csv")
 }
 
 // SummaryInfo holds common information needed for printing operation summaries
@@ -403,12 +1739,13 @@ type SummaryInfo struct {
 	DiagramGenerated bool
 	DiagramPath      string
 	FinalMessage     string
+	Hints            []string // Suggested next steps, printed before the final message
 }
 
 // printOperationSummary displays a unified operation completion summary
 func printOperationSummary(info SummaryInfo, diagramEnabled bool, printMetrics func()) {
 	successColor := color.New(color.FgGreen, color.Bold)
-	_, _ = successColor.Printf("\n✓ %s!\n", info.Title)
+	_, _ = successColor.Printf(console.Text("\n✓ %s!\n"), info.Title)
 	color.Green("  %s: %s", info.DirectoryLabel, info.Directory)
 
 	// Print operation-specific metrics
@@ -421,6 +1758,13 @@ func printOperationSummary(info SummaryInfo, diagramEnabled bool, printMetrics f
 		color.Green("  Entity-Relationship diagram (DOT): %s", info.DiagramPath)
 	}
 
+	if len(info.Hints) > 0 {
+		color.Yellow("\nNext steps:")
+		for _, hint := range info.Hints {
+			color.Yellow(console.Text("  • %s"), hint)
+		}
+	}
+
 	color.Yellow("\n%s", info.FinalMessage)
 }
 
@@ -434,12 +1778,49 @@ func printGenerationSummary(outputDir string, result *orchestrator.GenerationRes
 		DiagramPath:      result.DiagramPath,
 		FinalMessage:     "Use these CSV files to populate your system-of-record.",
 	}
+	for _, hint := range result.Hints {
+		info.Hints = append(info.Hints, hint.String())
+	}
 
 	printOperationSummary(info, diagramGenerated, func() {
 		color.Green("  CSV files generated: %d", result.CSVFilesGenerated)
 		color.Green("  Entities processed: %d", result.EntitiesProcessed)
 		color.Green("  Records per entity: %d", result.RecordsPerEntity)
 		color.Green("  Total records generated: %d", result.TotalRecords)
+		if result.MappingEntries > 0 {
+			color.Green("  Identity mapping entries: %d", result.MappingEntries)
+		}
+		if result.Theme != "" && result.Theme != theme {
+			color.Green("  Theme: %s", result.Theme)
+		}
+		if result.AccessGroundTruth != "" {
+			color.Green("  SoD violations planted: %d (ground truth: %s)", result.SoDViolations, result.AccessGroundTruth)
+		}
+		if result.EdgeCasesFile != "" {
+			color.Green("  Edge case values placed: %d (listed in %s)", result.EdgeCasesPlaced, result.EdgeCasesFile)
+		}
+		color.Green("  File manifest: %s", result.ManifestFile)
+		if result.RedactedDir != "" {
+			color.Green("  Redacted copy: %s", result.RedactedDir)
+		}
+		if result.CardinalityReport != "" {
+			color.Green("  Cardinality choices: %s", result.CardinalityReport)
+		}
+		if result.IngestionSamples != "" {
+			color.Green("  Ingestion samples: %s", result.IngestionSamples)
+		}
+		if result.AuditLog != "" {
+			color.Green("  Audit log: %s (seed %d)", result.AuditLog, result.Seed)
+		}
+		if result.Descriptor != "" {
+			color.Green("  Dataset descriptor: %s (version %s)", result.Descriptor, result.DatasetVersion)
+		}
+		if result.KeyRegistry != "" {
+			color.Green("  Key registry: %s (%d new keys)", result.KeyRegistry, result.KeysRecorded)
+		}
+		if capture := result.MemoryCapture; capture != nil && capture.Profile != "" {
+			color.Green("  Memory threshold heap profile: %s (%d MiB in use)", capture.Profile, capture.Bytes>>20)
+		}
 	})
 }
 
@@ -461,6 +1842,9 @@ func printValidationSummary(outputDir string, result *orchestrator.ValidationRes
 		if len(result.ValidationErrors) > 0 {
 			color.Green("  Validation issues found: %d", len(result.ValidationErrors))
 		}
+		if len(result.ValidationWarnings) > 0 {
+			color.Green("  Validation warnings: %d", len(result.ValidationWarnings))
+		}
 	})
 }
 
@@ -482,50 +1866,824 @@ func printHeader() {
 	fmt.Println() // Add an extra newline
 }
 
-// handleInitCountConfigSubcommand handles the init-count-config subcommand
-// which generates a row count configuration template from a SOR YAML file
-func handleInitCountConfigSubcommand(args []string) {
-	// Create a new flag set for the subcommand
-	initFlags := flag.NewFlagSet("init-count-config", flag.ExitOnError)
+// handleValidateSubcommand handles the validate subcommand, which checks existing
+// CSV files against a SOR like the legacy --validate-only flag
+func handleValidateSubcommand(args []string) {
+	validateFlags := flag.NewFlagSet("validate", flag.ExitOnError)
+
+	var directory string
+
+	validateFlags.StringVar(&inputFile, "f", "", "Path to the SOR YAML definition file (required)")
+	validateFlags.StringVar(&inputFile, "file", "", "Path to the SOR YAML definition file (required)")
+	validateFlags.StringVar(&directory, "i", "output", "Directory holding the CSV files to validate")
+	validateFlags.StringVar(&directory, "input", "output", "Directory holding the CSV files to validate")
+	validateFlags.StringVar(&relationshipValidationFile, "relationship-validation", "", "YAML file mapping relationship keys to skip, warn or error")
+	validateFlags.StringVar(&validationConfigFile, "validation-config", "", "YAML file of per-check tolerances; validation fails when a check exceeds its tolerance")
+	validateFlags.BoolVar(&streamingValidation, "streaming-validation", false, "Validate CSV files row by row, keeping only key indexes in memory")
+	validateFlags.IntVar(&validationWorkers, "validation-workers", pipeline.DefaultValidationWorkers, "Entity CSV files loaded and indexed at the same time")
+	validateFlags.StringVar(&validationCache, "validation-cache", "", "File caching results by file checksum, so re-runs only check changed files")
+	validateFlags.BoolVar(&strictCoercion, "strict-coercion", false, "Report every value coerced to its attribute's type (trimmed, booleans, dates) as an error")
+	validateFlags.StringVar(&dedupeOutput, "dedupe-output", "", "Directory to write a copy of the files to without the rows repeating a key")
+	validateFlags.BoolVar(&domainFolders, "domain-folders", false, "Read each entity's file from a subfolder named after its domain")
+	validateFlags.StringVar(&withTags, "with-tags", "", "Comma-separated tags; tagged entities are validated only with one of them")
+	validateFlags.StringVar(&withoutTags, "without-tags", "", "Comma-separated tags; entities with one of them are not validated")
+	validateFlags.StringVar(&filenameReplacement, "filename-replacement", pipeline.DefaultFilenameReplacement, "Replacement for characters invalid in Windows filenames used when the files were written")
+	validateFlags.BoolVar(&generateDiagram, "d", false, "Also generate an Entity-Relationship diagram in the directory")
+	validateFlags.BoolVar(&generateDiagram, "diagram", false, "Also generate an Entity-Relationship diagram in the directory")
+	validateFlags.StringVar(&reportHTML, "report-html", "", "Write a single-file HTML report of the validation to this path")
+	validateFlags.StringVar(&decryptSpec, "decrypt", "", "Decrypt files written with --encrypt: aes:<VAR> decrypts with the passphrase in environment variable VAR")
+
+	if err := validateFlags.Parse(args); err != nil {
+		color.Red("Error parsing flags: %v", err)
+		os.Exit(1)
+	}
+
+	if inputFile == "" {
+		color.Red("Error: SOR file is required for validate subcommand")
+		color.Yellow("\nUsage: fabricator validate -f <sor.yaml> [options]")
+		color.Yellow("\nOptions:")
+		color.Yellow("  -f, --file                Path to the SOR YAML definition file (required)")
+		color.Yellow("  -i, --input               Directory holding the CSV files to validate (default: output)")
+		color.Yellow("  --relationship-validation YAML file mapping relationship keys to skip, warn or error")
+		color.Yellow("  --validation-config       YAML file of per-check tolerances")
+		color.Yellow("  --streaming-validation    Validate row by row, keeping only key indexes in memory")
+		color.Yellow("  --validation-workers      Entity CSV files loaded and indexed at the same time (default: 1)")
+		color.Yellow("  --validation-cache        File caching results by file checksum; re-runs only check changed files")
+		color.Yellow("  --strict-coercion         Report every value coerced to its attribute's type as an error")
+		color.Yellow("  --dedupe-output           Write a copy of the files without the rows repeating a key to this directory")
+		color.Yellow("  --decrypt                 Decrypt files written with --encrypt, e.g. aes:FABRICATOR_KEY")
+		color.Yellow("\nExample:")
+		color.Yellow("  fabricator validate -f my-sor.yaml -i output/ --validation-config tolerances.yaml")
+		os.Exit(1)
+	}
+
+	if validationWorkers < 1 {
+		color.Red("Error: --validation-workers must be at least 1.")
+		os.Exit(1)
+	}
+
+	if validationCache != "" && streamingValidation {
+		color.Red("Error: --validation-cache can't be combined with --streaming-validation.")
+		os.Exit(1)
+	}
+
+	// Decrypted files are only read; these would hold keys or values in the clear
+	if decryptSpec != "" {
+		conflicts := []struct{ flag, value string }{
+			{"--validation-cache", validationCache}, {"--dedupe-output", dedupeOutput},
+		}
+		for _, conflict := range conflicts {
+			if conflict.value != "" {
+				color.Red("Error: %s can't be combined with --decrypt; it would write keys or values in the clear.", conflict.flag)
+				os.Exit(1)
+			}
+		}
+	}
+
+	validateOnly = true
+	if err := run(inputFile, directory, dataVolume, "", false); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
+}
+
+// diagramWatchInterval is how often diagram --watch checks the SOR file for changes
+const diagramWatchInterval = 500 * time.Millisecond
+
+// handleDiagramSubcommand handles the diagram subcommand, which draws a SOR's
+// Entity-Relationship diagram without generating data
+func handleDiagramSubcommand(args []string) {
+	diagramFlags := flag.NewFlagSet("diagram", flag.ExitOnError)
 
-	// Define flags for the init-count-config subcommand
 	var (
-		sorFile      string
-		defaultCount int
+		sorFile string
+		output  string
+		include string
+		domains string
+		watch   bool
+		render  diagrams.Options
 	)
 
-	initFlags.StringVar(&sorFile, "f", "", "Path to the SOR YAML definition file (required)")
-	initFlags.StringVar(&sorFile, "file", "", "Path to the SOR YAML definition file (required)")
-	initFlags.IntVar(&defaultCount, "n", 100, "Default row count for all entities")
-	initFlags.IntVar(&defaultCount, "num-rows", 100, "Default row count for all entities")
-
-	// Parse the subcommand flags
-	if err := initFlags.Parse(args); err != nil {
+	diagramFlags.StringVar(&sorFile, "f", "", "Path to the SOR YAML definition file (required)")
+	diagramFlags.StringVar(&sorFile, "file", "", "Path to the SOR YAML definition file (required)")
+	diagramFlags.StringVar(&output, "o", "output", "Diagram file, or directory to write it to named after the SOR")
+	diagramFlags.StringVar(&output, "output", "output", "Diagram file, or directory to write it to named after the SOR")
+	diagramFlags.StringVar(&render.Layout, "layout", "dot", "Graphviz layout engine: "+strings.Join(diagrams.Layouts, ", "))
+	diagramFlags.StringVar(&render.RankDir, "rankdir", "TB", "Direction of the dot layout: "+strings.Join(diagrams.RankDirs, ", "))
+	diagramFlags.StringVar(&render.Format, "format", "", "Diagram format: "+strings.Join(diagrams.Formats, ", ")+" (default: the output file's extension)")
+	diagramFlags.StringVar(&include, "include", "", "Comma-separated entities to draw, by externalId or name; globs such as 'Okta*' allowed")
+	diagramFlags.StringVar(&domains, "domain", "", "Comma-separated domains whose entities are drawn")
+	diagramFlags.BoolVar(&watch, "watch", false, "Redraw the diagram each time the SOR file changes, until interrupted")
+
+	if err := diagramFlags.Parse(args); err != nil {
 		color.Red("Error parsing flags: %v", err)
 		os.Exit(1)
 	}
 
-	// Validate required flags
 	if sorFile == "" {
-		color.Red("Error: SOR file is required for init-count-config subcommand")
-		color.Yellow("\nUsage: fabricator init-count-config -f <sor.yaml> [options]")
+		color.Red("Error: SOR file is required for diagram subcommand")
+		color.Yellow("\nUsage: fabricator diagram -f <sor.yaml> [options]")
 		color.Yellow("\nOptions:")
 		color.Yellow("  -f, --file         Path to the SOR YAML definition file (required)")
-		color.Yellow("  -n, --num-rows     Default row count for all entities (default: 100)")
+		color.Yellow("  -o, --output       Diagram file, or directory to write it to named after the SOR (default: output)")
+		color.Yellow("  --layout           Graphviz layout engine: dot, neato or fdp (default: dot)")
+		color.Yellow("  --rankdir          Direction of the dot layout: TB, LR, BT or RL (default: TB)")
+		color.Yellow("  --format           svg, png, pdf or dot (default: the output file's extension)")
+		color.Yellow("  --include          Comma-separated entities to draw, by externalId or name")
+		color.Yellow("  --domain           Comma-separated domains whose entities are drawn")
+		color.Yellow("  --watch            Redraw the diagram each time the SOR file changes, until interrupted")
 		color.Yellow("\nExample:")
-		color.Yellow("  fabricator init-count-config -f my-sor.yaml > counts.yaml")
+		color.Yellow("  fabricator diagram -f my-sor.yaml -o er.svg --layout neato --include 'User,Group*'")
 		os.Exit(1)
 	}
 
-	// Call the subcommand implementation
-	opts := subcommands.InitCountConfigOptions{
-		SORFile:      sorFile,
-		DefaultCount: defaultCount,
-		Output:       os.Stdout,
+	if include != "" {
+		render.Include = strings.Split(include, ",")
 	}
-
-	if err := subcommands.InitCountConfig(opts); err != nil {
+	if domains != "" {
+		render.Domains = strings.Split(domains, ",")
+	}
+	if err := render.Validate(); err != nil {
 		color.Red("Error: %v", err)
 		os.Exit(1)
 	}
+
+	// An output without an extension, or an existing directory, is where the diagram
+	// is written named after the SOR
+	options := orchestrator.DiagramOptions{Render: render}
+	directory := output
+	if info, err := os.Stat(output); (err != nil || !info.IsDir()) && filepath.Ext(output) != "" {
+		options.File = output
+		directory = filepath.Dir(output)
+	}
+	if err := os.MkdirAll(directory, 0750); err != nil {
+		color.Red("Error: failed to create %s: %v", directory, err)
+		os.Exit(1)
+	}
+
+	// Only the definition is parsed: no row counts, graph or generation
+	draw := func() error {
+		p := parser.NewParser(sorFile)
+		if err := p.Parse(); err != nil {
+			return fmt.Errorf("failed to parse SOR file: %w", err)
+		}
+		result, err := orchestrator.RunDiagramGeneration(p.Definition, directory, options)
+		if err != nil {
+			return fmt.Errorf("failed to generate diagram: %w", err)
+		}
+		color.Green(console.Text("✓ Generated ER diagram at %s"), result.Path)
+		if filepath.Ext(result.Path) == ".dot" && render.Format != "dot" && !diagrams.IsGraphvizAvailable() {
+			color.Yellow("Graphviz not found: wrote the DOT source; install Graphviz to render it")
+		}
+		return nil
+	}
+
+	if err := draw(); err != nil {
+		color.Red("Error: %v", err)
+		if !watch {
+			os.Exit(1)
+		}
+	}
+	if !watch {
+		return
+	}
+
+	// A broken edit is reported and the previous diagram kept until the next save
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	color.Cyan("Watching %s for changes (Ctrl+C to stop)...", sorFile)
+	err := util.WatchFile(ctx, sorFile, diagramWatchInterval, func() {
+		color.Yellow("%s changed, redrawing...", sorFile)
+		if err := draw(); err != nil {
+			color.Red("Error: %v", err)
+		}
+	})
+	if err != nil {
+		color.Red("Error: failed to watch %s: %v", sorFile, err)
+		os.Exit(1)
+	}
+}
+
+// handleAnalyzeSubcommand handles the analyze subcommand, which reports the planned
+// rows, relationship cardinalities and truncation warnings without generating data
+func handleAnalyzeSubcommand(args []string) {
+	analyzeFlags := flag.NewFlagSet("analyze", flag.ExitOnError)
+
+	var (
+		sorFile     string
+		countConfig string
+		rows        int
+		outputPath  string
+	)
+
+	analyzeFlags.StringVar(&sorFile, "f", "", "Path to the SOR YAML definition file (required)")
+	analyzeFlags.StringVar(&sorFile, "file", "", "Path to the SOR YAML definition file (required)")
+	analyzeFlags.StringVar(&countConfig, "c", "", "Path to row count configuration YAML file")
+	analyzeFlags.StringVar(&countConfig, "count-config", "", "Path to row count configuration YAML file")
+	analyzeFlags.IntVar(&rows, "n", subcommands.DefaultAnalyzeRows, "Row count of entities without a configured count")
+	analyzeFlags.IntVar(&rows, "num-rows", subcommands.DefaultAnalyzeRows, "Row count of entities without a configured count")
+	analyzeFlags.StringVar(&outputPath, "o", "", "Write to this file instead of stdout")
+	analyzeFlags.StringVar(&outputPath, "output", "", "Write to this file instead of stdout")
+
+	if err := analyzeFlags.Parse(args); err != nil {
+		color.Red("Error parsing flags: %v", err)
+		os.Exit(1)
+	}
+
+	if sorFile == "" {
+		color.Red("Error: SOR file is required for analyze subcommand")
+		color.Yellow("\nUsage: fabricator analyze -f <sor.yaml> [options]")
+		color.Yellow("\nOptions:")
+		color.Yellow("  -f, --file         Path to the SOR YAML definition file (required)")
+		color.Yellow("  -c, --count-config Path to row count configuration YAML file")
+		color.Yellow("  -n, --num-rows     Row count of entities without a configured count (default: 100)")
+		color.Yellow("  -o, --output       Write to this file instead of stdout")
+		color.Yellow("\nExample:")
+		color.Yellow("  fabricator analyze -f my-sor.yaml -c counts.yaml")
+		os.Exit(1)
+	}
+
+	opts := subcommands.AnalyzeOptions{
+		SORFile:         sorFile,
+		CountConfigFile: countConfig,
+		DataVolume:      rows,
+		Output:          os.Stdout,
+	}
+
+	if outputPath != "" {
+		file, err := os.Create(filepath.Clean(outputPath))
+		if err != nil {
+			color.Red("Error: failed to create %s: %v", outputPath, err)
+			os.Exit(1)
+		}
+		defer func() { _ = file.Close() }()
+		opts.Output = file
+	}
+
+	if err := subcommands.Analyze(opts); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
+}
+
+// handleInitCountConfigSubcommand handles the init-count-config subcommand
+// which generates a row count configuration template from a SOR YAML file
+func handleInitCountConfigSubcommand(args []string) {
+	// Create a new flag set for the subcommand
+	initFlags := flag.NewFlagSet("init-count-config", flag.ExitOnError)
+
+	// Define flags for the init-count-config subcommand
+	var (
+		sorFile      string
+		defaultCount int
+	)
+
+	initFlags.StringVar(&sorFile, "f", "", "Path to the SOR YAML definition file (required)")
+	initFlags.StringVar(&sorFile, "file", "", "Path to the SOR YAML definition file (required)")
+	initFlags.IntVar(&defaultCount, "n", 100, "Default row count for all entities")
+	initFlags.IntVar(&defaultCount, "num-rows", 100, "Default row count for all entities")
+
+	// Parse the subcommand flags
+	if err := initFlags.Parse(args); err != nil {
+		color.Red("Error parsing flags: %v", err)
+		os.Exit(1)
+	}
+
+	// Validate required flags
+	if sorFile == "" {
+		color.Red("Error: SOR file is required for init-count-config subcommand")
+		color.Yellow("\nUsage: fabricator init-count-config -f <sor.yaml> [options]")
+		color.Yellow("\nOptions:")
+		color.Yellow("  -f, --file         Path to the SOR YAML definition file (required)")
+		color.Yellow("  -n, --num-rows     Default row count for all entities (default: 100)")
+		color.Yellow("\nExample:")
+		color.Yellow("  fabricator init-count-config -f my-sor.yaml > counts.yaml")
+		os.Exit(1)
+	}
+
+	// Call the subcommand implementation
+	opts := subcommands.InitCountConfigOptions{
+		SORFile:      sorFile,
+		DefaultCount: defaultCount,
+		Output:       os.Stdout,
+	}
+
+	if err := subcommands.InitCountConfig(opts); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
+}
+
+// handleDecryptMappingSubcommand handles the decrypt-mapping subcommand
+func handleDecryptMappingSubcommand(args []string) {
+	decryptFlags := flag.NewFlagSet("decrypt-mapping", flag.ExitOnError)
+
+	var (
+		inputPath string
+		keyEnv    string
+	)
+
+	decryptFlags.StringVar(&inputPath, "i", "", "Path to the encrypted mapping file (required)")
+	decryptFlags.StringVar(&inputPath, "input", "", "Path to the encrypted mapping file (required)")
+	decryptFlags.StringVar(&keyEnv, "key-env", "", "Environment variable holding the mapping passphrase (required)")
+
+	if err := decryptFlags.Parse(args); err != nil {
+		color.Red("Error parsing flags: %v", err)
+		os.Exit(1)
+	}
+
+	if inputPath == "" || keyEnv == "" {
+		color.Red("Error: input file and key environment variable are required for decrypt-mapping subcommand")
+		color.Yellow("\nUsage: fabricator decrypt-mapping -i <mapping file> --key-env <VAR>")
+		color.Yellow("\nOptions:")
+		color.Yellow("  -i, --input        Path to the encrypted mapping file (required)")
+		color.Yellow("  --key-env          Environment variable holding the mapping passphrase (required)")
+		color.Yellow("\nExample:")
+		color.Yellow("  fabricator decrypt-mapping -i mapping.enc --key-env MAPPING_KEY > mapping.jsonl")
+		os.Exit(1)
+	}
+
+	opts := subcommands.DecryptMappingOptions{
+		InputFile:  inputPath,
+		Passphrase: os.Getenv(keyEnv),
+		Output:     os.Stdout,
+	}
+
+	if err := subcommands.DecryptMapping(opts); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
+}
+
+// handleDecryptSubcommand handles the decrypt subcommand, which reads a data file
+// written with --encrypt
+func handleDecryptSubcommand(args []string) {
+	decryptFlags := flag.NewFlagSet("decrypt", flag.ExitOnError)
+
+	var (
+		inputPath string
+		keyEnv    string
+	)
+
+	decryptFlags.StringVar(&inputPath, "i", "", "Path to the encrypted data file (required)")
+	decryptFlags.StringVar(&inputPath, "input", "", "Path to the encrypted data file (required)")
+	decryptFlags.StringVar(&keyEnv, "key-env", "", "Environment variable holding the passphrase (required)")
+
+	if err := decryptFlags.Parse(args); err != nil {
+		color.Red("Error parsing flags: %v", err)
+		os.Exit(1)
+	}
+
+	if inputPath == "" || keyEnv == "" {
+		color.Red("Error: input file and key environment variable are required for decrypt subcommand")
+		color.Yellow("\nUsage: fabricator decrypt -i <encrypted file> --key-env <VAR>")
+		color.Yellow("\nOptions:")
+		color.Yellow("  -i, --input        Path to the encrypted data file (required)")
+		color.Yellow("  --key-env          Environment variable holding the passphrase (required)")
+		color.Yellow("\nExample:")
+		color.Yellow("  fabricator decrypt -i output/User.csv.enc --key-env FABRICATOR_KEY > User.csv")
+		os.Exit(1)
+	}
+
+	opts := subcommands.DecryptOptions{
+		InputFile:  inputPath,
+		Passphrase: os.Getenv(keyEnv),
+		Output:     os.Stdout,
+	}
+
+	if err := subcommands.Decrypt(opts); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
+}
+
+// handleDependencyLayersSubcommand handles the dependency-layers subcommand
+// which shows the topological layering used to order generation
+func handleDependencyLayersSubcommand(args []string) {
+	layersFlags := flag.NewFlagSet("dependency-layers", flag.ExitOnError)
+
+	var (
+		sorFile    string
+		format     string
+		outputPath string
+	)
+
+	layersFlags.StringVar(&sorFile, "f", "", "Path to the SOR YAML definition file (required)")
+	layersFlags.StringVar(&sorFile, "file", "", "Path to the SOR YAML definition file (required)")
+	layersFlags.StringVar(&format, "format", subcommands.LayersFormatText, "Output format: text or dot")
+	layersFlags.StringVar(&outputPath, "o", "", "Write to this file instead of stdout")
+	layersFlags.StringVar(&outputPath, "output", "", "Write to this file instead of stdout")
+
+	if err := layersFlags.Parse(args); err != nil {
+		color.Red("Error parsing flags: %v", err)
+		os.Exit(1)
+	}
+
+	if sorFile == "" {
+		color.Red("Error: SOR file is required for dependency-layers subcommand")
+		color.Yellow("\nUsage: fabricator dependency-layers -f <sor.yaml> [options]")
+		color.Yellow("\nOptions:")
+		color.Yellow("  -f, --file         Path to the SOR YAML definition file (required)")
+		color.Yellow("  --format           Output format: text or dot (default: text)")
+		color.Yellow("  -o, --output       Write to this file instead of stdout")
+		color.Yellow("\nExample:")
+		color.Yellow("  fabricator dependency-layers -f my-sor.yaml --format dot -o layers.dot")
+		os.Exit(1)
+	}
+
+	opts := subcommands.DependencyLayersOptions{
+		SORFile: sorFile,
+		Format:  format,
+		Output:  os.Stdout,
+	}
+
+	if outputPath != "" {
+		file, err := os.Create(filepath.Clean(outputPath))
+		if err != nil {
+			color.Red("Error: failed to create %s: %v", outputPath, err)
+			os.Exit(1)
+		}
+		defer func() { _ = file.Close() }()
+		opts.Output = file
+	}
+
+	if err := subcommands.DependencyLayers(opts); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
+}
+
+// handleCheckRelationshipsSubcommand handles the check-relationships subcommand
+func handleCheckRelationshipsSubcommand(args []string) {
+	checkFlags := flag.NewFlagSet("check-relationships", flag.ExitOnError)
+
+	var sorFile string
+
+	checkFlags.StringVar(&sorFile, "f", "", "Path to the SOR YAML definition file (required)")
+	checkFlags.StringVar(&sorFile, "file", "", "Path to the SOR YAML definition file (required)")
+
+	if err := checkFlags.Parse(args); err != nil {
+		color.Red("Error parsing flags: %v", err)
+		os.Exit(1)
+	}
+
+	if sorFile == "" {
+		color.Red("Error: SOR file is required for check-relationships subcommand")
+		color.Yellow("\nUsage: fabricator check-relationships -f <sor.yaml>")
+		color.Yellow("\nOptions:")
+		color.Yellow("  -f, --file         Path to the SOR YAML definition file (required)")
+		color.Yellow("\nExample:")
+		color.Yellow("  fabricator check-relationships -f my-sor.yaml")
+		os.Exit(1)
+	}
+
+	opts := subcommands.CheckRelationshipsOptions{
+		SORFile: sorFile,
+		Output:  os.Stdout,
+	}
+
+	if err := subcommands.CheckRelationships(opts); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
+}
+
+// handleCompareSchemaSubcommand handles the compare-schema subcommand
+func handleCompareSchemaSubcommand(args []string) {
+	compareFlags := flag.NewFlagSet("compare-schema", flag.ExitOnError)
+
+	var sorFile, varsFile, tenant, tokenEnv string
+	var overlayFiles stringList
+
+	compareFlags.StringVar(&sorFile, "f", "", "Path to the SOR YAML definition file (required)")
+	compareFlags.StringVar(&sorFile, "file", "", "Path to the SOR YAML definition file (required)")
+	compareFlags.StringVar(&varsFile, "vars-file", "", "YAML file of values for ${NAME} references in the SOR")
+	compareFlags.Var(&overlayFiles, "overlay", "YAML file patching the SOR; repeat to apply several in order")
+	compareFlags.StringVar(&tenant, "tenant", "", "File or http(s) URL of the tenant's SOR schema export (required)")
+	compareFlags.StringVar(&tokenEnv, "token-env", "", "Environment variable holding the bearer token sent to a URL")
+
+	if err := compareFlags.Parse(args); err != nil {
+		color.Red("Error parsing flags: %v", err)
+		os.Exit(1)
+	}
+
+	if sorFile == "" || tenant == "" {
+		color.Red("Error: SOR file and tenant schema are required for compare-schema subcommand")
+		color.Yellow("\nUsage: fabricator compare-schema -f <sor.yaml> --tenant <file|URL> [options]")
+		color.Yellow("\nOptions:")
+		color.Yellow("  -f, --file         Path to the SOR YAML definition file (required)")
+		color.Yellow("  --tenant           File or http(s) URL of the tenant's SOR schema export (required)")
+		color.Yellow("  --token-env        Environment variable holding the bearer token sent to a URL")
+		color.Yellow("  --vars-file        YAML file of values for ${NAME} references in the SOR")
+		color.Yellow("  --overlay          YAML file patching the SOR; repeat to apply several in order")
+		color.Yellow("\nExample:")
+		color.Yellow("  fabricator compare-schema -f my-sor.yaml --tenant https://tenant.example.com/sor/schema --token-env SGNL_TOKEN")
+		os.Exit(1)
+	}
+
+	var token string
+	if tokenEnv != "" {
+		if token = os.Getenv(tokenEnv); token == "" {
+			color.Red("Error: environment variable %s (from --token-env) is empty or unset", tokenEnv)
+			os.Exit(1)
+		}
+	}
+
+	var variables map[string]string
+	if varsFile != "" {
+		var err error
+		if variables, err = parser.LoadVariables(varsFile); err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+	}
+
+	opts := subcommands.CompareSchemaOptions{
+		SORFile:   sorFile,
+		Variables: variables,
+		Overlays:  overlayFiles,
+		Tenant:    tenant,
+		Token:     token,
+		Output:    os.Stdout,
+	}
+
+	if err := subcommands.CompareSchema(opts); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
+}
+
+// handleAuditTypesSubcommand handles the audit-types subcommand
+func handleAuditTypesSubcommand(args []string) {
+	auditFlags := flag.NewFlagSet("audit-types", flag.ExitOnError)
+
+	var sorFile string
+
+	auditFlags.StringVar(&sorFile, "f", "", "Path to the SOR YAML definition file (required)")
+	auditFlags.StringVar(&sorFile, "file", "", "Path to the SOR YAML definition file (required)")
+
+	if err := auditFlags.Parse(args); err != nil {
+		color.Red("Error parsing flags: %v", err)
+		os.Exit(1)
+	}
+
+	if sorFile == "" {
+		color.Red("Error: SOR file is required for audit-types subcommand")
+		color.Yellow("\nUsage: fabricator audit-types -f <sor.yaml>")
+		color.Yellow("\nOptions:")
+		color.Yellow("  -f, --file         Path to the SOR YAML definition file (required)")
+		color.Yellow("\nExample:")
+		color.Yellow("  fabricator audit-types -f my-sor.yaml")
+		os.Exit(1)
+	}
+
+	opts := subcommands.AuditTypesOptions{
+		SORFile: sorFile,
+		Output:  os.Stdout,
+	}
+
+	if err := subcommands.AuditTypes(opts); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
+}
+
+// handleExportSchemaSubcommand handles the export-schema subcommand
+func handleExportSchemaSubcommand(args []string) {
+	exportFlags := flag.NewFlagSet("export-schema", flag.ExitOnError)
+
+	var (
+		sorFile   string
+		format    string
+		outputDir string
+	)
+
+	exportFlags.StringVar(&sorFile, "f", "", "Path to the SOR YAML definition file (required)")
+	exportFlags.StringVar(&sorFile, "file", "", "Path to the SOR YAML definition file (required)")
+	exportFlags.StringVar(&format, "format", "", "Schema format: jsonschema, avro or ddl (required)")
+	exportFlags.StringVar(&outputDir, "o", "", "Write one schema file per entity to this directory instead of stdout")
+	exportFlags.StringVar(&outputDir, "output", "", "Write one schema file per entity to this directory instead of stdout")
+
+	if err := exportFlags.Parse(args); err != nil {
+		color.Red("Error parsing flags: %v", err)
+		os.Exit(1)
+	}
+
+	if sorFile == "" || format == "" {
+		color.Red("Error: SOR file and format are required for export-schema subcommand")
+		color.Yellow("\nUsage: fabricator export-schema -f <sor.yaml> --format <jsonschema|avro|ddl> [options]")
+		color.Yellow("\nOptions:")
+		color.Yellow("  -f, --file         Path to the SOR YAML definition file (required)")
+		color.Yellow("  --format           Schema format: jsonschema, avro or ddl (required)")
+		color.Yellow("  -o, --output       Write one schema file per entity to this directory instead of stdout")
+		color.Yellow("\nExample:")
+		color.Yellow("  fabricator export-schema -f my-sor.yaml --format avro -o schemas/")
+		os.Exit(1)
+	}
+
+	opts := subcommands.ExportSchemaOptions{
+		SORFile:   sorFile,
+		Format:    format,
+		OutputDir: outputDir,
+		Output:    os.Stdout,
+	}
+
+	if err := subcommands.ExportSchema(opts); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
+}
+
+// handleImportOpenAPISubcommand handles the import-openapi subcommand
+func handleImportOpenAPISubcommand(args []string) {
+	importFlags := flag.NewFlagSet("import-openapi", flag.ExitOnError)
+
+	var (
+		specFile   string
+		outputFile string
+	)
+
+	importFlags.StringVar(&specFile, "i", "", "Path to the OpenAPI 3 or Swagger 2 document (required)")
+	importFlags.StringVar(&specFile, "input", "", "Path to the OpenAPI 3 or Swagger 2 document (required)")
+	importFlags.StringVar(&outputFile, "o", "", "Write the draft SOR to this file instead of stdout")
+	importFlags.StringVar(&outputFile, "output", "", "Write the draft SOR to this file instead of stdout")
+
+	if err := importFlags.Parse(args); err != nil {
+		color.Red("Error parsing flags: %v", err)
+		os.Exit(1)
+	}
+
+	if specFile == "" {
+		color.Red("Error: OpenAPI file is required for import-openapi subcommand")
+		color.Yellow("\nUsage: fabricator import-openapi -i <openapi.yaml|json> [options]")
+		color.Yellow("\nOptions:")
+		color.Yellow("  -i, --input        Path to the OpenAPI 3 or Swagger 2 document (required)")
+		color.Yellow("  -o, --output       Write the draft SOR to this file instead of stdout")
+		color.Yellow("\nExample:")
+		color.Yellow("  fabricator import-openapi -i openapi.yaml -o draft-sor.yaml")
+		os.Exit(1)
+	}
+
+	opts := subcommands.ImportOpenAPIOptions{
+		SpecFile: specFile,
+		Output:   os.Stdout,
+	}
+
+	if outputFile != "" {
+		file, err := os.Create(filepath.Clean(outputFile))
+		if err != nil {
+			color.Red("Error: failed to create %s: %v", outputFile, err)
+			os.Exit(1)
+		}
+		defer func() { _ = file.Close() }()
+		opts.Output = file
+	}
+
+	if err := subcommands.ImportOpenAPI(opts); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
+	if outputFile != "" {
+		color.Green(console.Text("✓ Draft SOR written to %s"), outputFile)
+	}
+}
+
+// handleInferSubcommand handles the infer subcommand
+func handleInferSubcommand(args []string) {
+	inferFlags := flag.NewFlagSet("infer", flag.ExitOnError)
+
+	var (
+		inputDir   string
+		outputFile string
+		sampleRows int
+	)
+
+	inferFlags.StringVar(&inputDir, "i", "", "Directory holding the CSV files (required)")
+	inferFlags.StringVar(&inputDir, "input", "", "Directory holding the CSV files (required)")
+	inferFlags.StringVar(&outputFile, "o", "", "Write the draft SOR to this file instead of stdout")
+	inferFlags.StringVar(&outputFile, "output", "", "Write the draft SOR to this file instead of stdout")
+	inferFlags.IntVar(&sampleRows, "sample-rows", subcommands.DefaultInferSampleRows, "Rows read from each file, 0 for all")
+
+	if err := inferFlags.Parse(args); err != nil {
+		color.Red("Error parsing flags: %v", err)
+		os.Exit(1)
+	}
+
+	if inputDir == "" {
+		color.Red("Error: input directory is required for infer subcommand")
+		color.Yellow("\nUsage: fabricator infer -i <directory> [options]")
+		color.Yellow("\nOptions:")
+		color.Yellow("  -i, --input        Directory holding the CSV files (required)")
+		color.Yellow("  -o, --output       Write the draft SOR to this file instead of stdout")
+		color.Yellow("  --sample-rows      Rows read from each file, 0 for all (default: %d)", subcommands.DefaultInferSampleRows)
+		color.Yellow("\nExample:")
+		color.Yellow("  fabricator infer -i data/ -o sor.yaml")
+		os.Exit(1)
+	}
+	if sampleRows < 0 {
+		color.Red("Error: --sample-rows must not be negative")
+		os.Exit(1)
+	}
+
+	opts := subcommands.InferOptions{
+		InputDir:   inputDir,
+		SampleRows: sampleRows,
+		Output:     os.Stdout,
+	}
+
+	if outputFile != "" {
+		file, err := os.Create(filepath.Clean(outputFile))
+		if err != nil {
+			color.Red("Error: failed to create %s: %v", outputFile, err)
+			os.Exit(1)
+		}
+		defer func() { _ = file.Close() }()
+		opts.Output = file
+	}
+
+	if err := subcommands.Infer(opts); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
+	if outputFile != "" {
+		color.Green(console.Text("✓ Draft SOR written to %s"), outputFile)
+	}
+}
+
+// handleTraceSubcommand handles the trace subcommand
+func handleTraceSubcommand(args []string) {
+	traceFlags := flag.NewFlagSet("trace", flag.ExitOnError)
+
+	var (
+		sorFile       string
+		inputDir      string
+		from          string
+		id            string
+		path          string
+		domainFolders bool
+		replacement   string
+		outputFile    string
+	)
+
+	traceFlags.StringVar(&sorFile, "f", "", "Path to the SOR YAML definition file (required)")
+	traceFlags.StringVar(&sorFile, "file", "", "Path to the SOR YAML definition file (required)")
+	traceFlags.StringVar(&inputDir, "i", "output", "Directory holding the generated CSV files")
+	traceFlags.StringVar(&inputDir, "input", "output", "Directory holding the generated CSV files")
+	traceFlags.StringVar(&from, "from", "", "Starting entity: external ID, YAML key or display name (required)")
+	traceFlags.StringVar(&id, "id", "", "Unique ID of the starting row (required)")
+	traceFlags.StringVar(&path, "path", "", "Comma-separated relationship keys or names, each optionally :forward or :backward")
+	traceFlags.BoolVar(&domainFolders, "domain-folders", false, "Read each entity's file from a subfolder named after its domain")
+	traceFlags.StringVar(&replacement, "filename-replacement", pipeline.DefaultFilenameReplacement, "Replacement for characters invalid in Windows filenames used when the files were written")
+	traceFlags.StringVar(&outputFile, "o", "", "Write to this file instead of stdout")
+	traceFlags.StringVar(&outputFile, "output", "", "Write to this file instead of stdout")
+
+	if err := traceFlags.Parse(args); err != nil {
+		color.Red("Error parsing flags: %v", err)
+		os.Exit(1)
+	}
+
+	if sorFile == "" || from == "" || id == "" {
+		color.Red("Error: SOR file, starting entity and ID are required for trace subcommand")
+		color.Yellow("\nUsage: fabricator trace -f <sor.yaml> --from <entity> --id <id> [options]")
+		color.Yellow("\nOptions:")
+		color.Yellow("  -f, --file         Path to the SOR YAML definition file (required)")
+		color.Yellow("  -i, --input        Directory holding the generated CSV files (default: output)")
+		color.Yellow("  --from             Starting entity: external ID, YAML key or display name (required)")
+		color.Yellow("  --id               Unique ID of the starting row (required)")
+		color.Yellow("  --path             Comma-separated relationship keys or names, each optionally :forward or :backward")
+		color.Yellow("  --domain-folders   Read each entity's file from a subfolder named after its domain")
+		color.Yellow("  --filename-replacement  Replacement for characters invalid in filenames used when the files were written")
+		color.Yellow("  -o, --output       Write to this file instead of stdout")
+		color.Yellow("\nExample:")
+		color.Yellow("  fabricator trace -f my-sor.yaml -i output/ --from User --id u123 --path Member,GroupMembership")
+		os.Exit(1)
+	}
+
+	opts := subcommands.TraceOptions{
+		SORFile:       sorFile,
+		InputDir:      inputDir,
+		DomainFolders: domainFolders,
+		Query:         subcommands.TraceQuery{From: from, ID: id},
+		Output:        os.Stdout,
+
+		FilenameReplacement: replacement,
+	}
+	if path != "" {
+		opts.Query.Path = strings.Split(path, ",")
+	}
+
+	if outputFile != "" {
+		file, err := os.Create(filepath.Clean(outputFile))
+		if err != nil {
+			color.Red("Error: failed to create %s: %v", outputFile, err)
+			os.Exit(1)
+		}
+		defer func() { _ = file.Close() }()
+		opts.Output = file
+	}
+
+	if err := subcommands.Trace(opts); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
 }
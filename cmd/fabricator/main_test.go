@@ -395,6 +395,19 @@ func TestPrintOperationSummaryBranches(t *testing.T) {
 			// Empty metrics function
 		})
 	})
+
+	t.Run("with next step hints", func(t *testing.T) {
+		info := SummaryInfo{
+			Title:          "Test Complete",
+			DirectoryLabel: "Output directory",
+			Directory:      "/tmp/test",
+			FinalMessage:   "Test complete",
+			Hints:          []string{"1 relationship(s) had low parent coverage; consider --auto-cardinality"},
+		}
+		printOperationSummary(info, false, func() {
+			// Empty metrics function
+		})
+	})
 }
 
 func TestRunWithRelationshipValidationErrors(t *testing.T) {
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AccessConfiguration describes how entitlements are assigned to users when
+// simulating access for identity-governance testing: which entity holds the
+// assignments, the roles users hold and the separation-of-duties (SoD) rules
+// whose violations are planted at a known rate.
+type AccessConfiguration struct {
+	// Assignment is the external ID of the entity whose rows assign an entitlement to a user
+	Assignment string `yaml:"assignment"`
+
+	// User is the name of the assignment attribute referencing users
+	User string `yaml:"user"`
+
+	// Entitlement is the name of the assignment attribute referencing entitlements
+	Entitlement string `yaml:"entitlement"`
+
+	// Roles are granted to a share of users; each grants its own entitlements
+	Roles []AccessRole `yaml:"roles"`
+
+	// SoDRules name roles that no user should hold together
+	SoDRules []SoDRule `yaml:"sodRules"`
+
+	// SourceFile is the path to the configuration file (for error messages)
+	SourceFile string `yaml:"-"`
+}
+
+// AccessRole is a set of entitlements held by a share of users
+type AccessRole struct {
+	// Name identifies the role in SoD rules and the ground truth report
+	Name string `yaml:"name"`
+
+	// Entitlements is how many entitlement rows the role grants
+	Entitlements int `yaml:"entitlements"`
+
+	// Users is the share of users holding the role
+	Users Share `yaml:"users"`
+}
+
+// SoDRule is a toxic combination of roles
+type SoDRule struct {
+	// Name identifies the rule in the ground truth report
+	Name string `yaml:"name"`
+
+	// Roles are the role names a user must not hold together
+	Roles []string `yaml:"roles"`
+
+	// Rate is the share of users given every role of the rule
+	Rate Share `yaml:"rate"`
+}
+
+// Share is a fraction of users in [0, 1], written in YAML as a percentage ("5%")
+// or a fraction (0.05)
+type Share float64
+
+// UnmarshalYAML accepts a percentage string or a fraction
+func (s *Share) UnmarshalYAML(value *yaml.Node) error {
+	text := strings.TrimSpace(value.Value)
+	if percent, ok := strings.CutSuffix(text, "%"); ok {
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid percentage '%s'", value.Line, value.Value)
+		}
+		*s = Share(parsed / 100)
+		return nil
+	}
+
+	parsed, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid share '%s' (use a percentage like 5%% or a fraction like 0.05)", value.Line, value.Value)
+	}
+	*s = Share(parsed)
+	return nil
+}
+
+// LoadAccessConfiguration reads an access simulation configuration file:
+//
+//	assignment: GroupMember
+//	user: userId
+//	entitlement: groupId
+//	roles:
+//	  - {name: admin, entitlements: 2, users: 5%}
+//	  - {name: payments-approve, entitlements: 1, users: 10%}
+//	  - {name: payments-create, entitlements: 1, users: 30%}
+//	sodRules:
+//	  - {name: approve-own-payments, roles: [payments-approve, payments-create], rate: 2%}
+//
+// The configuration is validated for internal consistency; references to the SOR
+// are checked when generation starts.
+func LoadAccessConfiguration(path string) (*AccessConfiguration, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path is from CLI argument, user-controlled
+	if err != nil {
+		return nil, &ValidationError{
+			Message:    fmt.Sprintf("Access configuration file not found: %s", path),
+			Suggestion: "Check the path passed to --access-config",
+		}
+	}
+
+	var cfg AccessConfiguration
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, &ValidationError{
+			Message:    fmt.Sprintf("Invalid YAML syntax in %s: %v", path, err),
+			Suggestion: "Validate YAML syntax at yamllint.com or use a YAML validator",
+		}
+	}
+	cfg.SourceFile = path
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks that the assignment entity and attributes are named, roles are
+// unique with positive entitlement counts and SoD rules combine at least two known
+// roles. Shares must lie in [0, 1].
+func (c *AccessConfiguration) Validate() error {
+	if c.Assignment == "" || c.User == "" || c.Entitlement == "" {
+		return &ValidationError{
+			Field:      "assignment",
+			Message:    fmt.Sprintf("Access configuration %s must set assignment, user and entitlement", c.SourceFile),
+			Suggestion: "Name the assignment entity and its user and entitlement foreign key attributes",
+		}
+	}
+
+	roles := make(map[string]bool, len(c.Roles))
+	for _, role := range c.Roles {
+		if role.Name == "" {
+			return &ValidationError{
+				Field:      "roles",
+				Message:    "Access role without a name",
+				Suggestion: "Give every role a name so SoD rules can reference it",
+			}
+		}
+		if roles[role.Name] {
+			return &ValidationError{
+				Field:      "roles",
+				Value:      role.Name,
+				Message:    fmt.Sprintf("Duplicate access role '%s'", role.Name),
+				Suggestion: "Use a unique name for each role",
+			}
+		}
+		roles[role.Name] = true
+
+		if role.Entitlements < 1 {
+			return &ValidationError{
+				Field:      "entitlements",
+				Value:      role.Entitlements,
+				Message:    fmt.Sprintf("Access role '%s' must grant at least 1 entitlement, got %d", role.Name, role.Entitlements),
+				Suggestion: "Set entitlements to the number of entitlement rows the role grants",
+			}
+		}
+		if err := validateShare("users", role.Name, role.Users); err != nil {
+			return err
+		}
+	}
+
+	for _, rule := range c.SoDRules {
+		if len(rule.Roles) < 2 {
+			return &ValidationError{
+				Field:      "sodRules",
+				Value:      rule.Name,
+				Message:    fmt.Sprintf("SoD rule '%s' must combine at least 2 roles", rule.Name),
+				Suggestion: "List the roles that must not be held together",
+			}
+		}
+		for _, name := range rule.Roles {
+			if !roles[name] {
+				return &ValidationError{
+					Field:      "sodRules",
+					Value:      name,
+					Message:    fmt.Sprintf("SoD rule '%s' references unknown role '%s'", rule.Name, name),
+					Suggestion: "Declare the role under roles or fix its spelling",
+				}
+			}
+		}
+		if err := validateShare("rate", rule.Name, rule.Rate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateShare checks that a share lies in [0, 1]
+func validateShare(field, owner string, share Share) error {
+	if share < 0 || share > 1 {
+		return &ValidationError{
+			Field:      field,
+			Value:      float64(share),
+			Message:    fmt.Sprintf("Invalid %s for '%s': %g%% (expected 0%% to 100%%)", field, owner, float64(share)*100),
+			Suggestion: "Use a percentage like 5% or a fraction like 0.05",
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAccessConfiguration(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "access.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	t.Run("parses roles, rules and shares", func(t *testing.T) {
+		cfg, err := LoadAccessConfiguration(write(t, `assignment: GroupMember
+user: userId
+entitlement: groupId
+roles:
+  - {name: admin, entitlements: 2, users: 5%}
+  - {name: approve, entitlements: 1, users: 0.1}
+  - {name: create, entitlements: 1, users: "30 %"}
+sodRules:
+  - {name: approve-own, roles: [approve, create], rate: 2%}
+`))
+		require.NoError(t, err)
+		assert.Equal(t, "GroupMember", cfg.Assignment)
+		require.Len(t, cfg.Roles, 3)
+		assert.InDelta(t, 0.05, float64(cfg.Roles[0].Users), 1e-9)
+		assert.InDelta(t, 0.1, float64(cfg.Roles[1].Users), 1e-9)
+		assert.InDelta(t, 0.3, float64(cfg.Roles[2].Users), 1e-9)
+		require.Len(t, cfg.SoDRules, 1)
+		assert.InDelta(t, 0.02, float64(cfg.SoDRules[0].Rate), 1e-9)
+	})
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "missing assignment", content: "user: userId\nentitlement: groupId\n", wantErr: "must set assignment, user and entitlement"},
+		{name: "invalid share", content: "assignment: A\nuser: u\nentitlement: e\nroles: [{name: r, entitlements: 1, users: lots}]\n", wantErr: "invalid share 'lots'"},
+		{name: "share above 100%", content: "assignment: A\nuser: u\nentitlement: e\nroles: [{name: r, entitlements: 1, users: 150%}]\n", wantErr: "Invalid users for 'r'"},
+		{name: "duplicate role", content: "assignment: A\nuser: u\nentitlement: e\nroles: [{name: r, entitlements: 1}, {name: r, entitlements: 1}]\n", wantErr: "Duplicate access role 'r'"},
+		{name: "role without entitlements", content: "assignment: A\nuser: u\nentitlement: e\nroles: [{name: r}]\n", wantErr: "must grant at least 1 entitlement"},
+		{name: "rule with one role", content: "assignment: A\nuser: u\nentitlement: e\nroles: [{name: r, entitlements: 1}]\nsodRules: [{name: s, roles: [r]}]\n", wantErr: "must combine at least 2 roles"},
+		{name: "rule with unknown role", content: "assignment: A\nuser: u\nentitlement: e\nroles: [{name: r, entitlements: 1}]\nsodRules: [{name: s, roles: [r, q]}]\n", wantErr: "unknown role 'q'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadAccessConfiguration(write(t, tt.content))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadAccessConfiguration(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.ErrorContains(t, err, "Access configuration file not found")
+	})
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadAssertions reads a file of assertions about the generated data, written like
+// the assertions section of a SOR:
+//
+//	assertions:
+//	  - name: no ticket closed before created
+//	    entity: Ticket
+//	    compare: closedAt >= createdAt
+//
+// References to the SOR are checked when generation compiles them.
+func LoadAssertions(path string) ([]parser.Assertion, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path is from CLI argument, user-controlled
+	if err != nil {
+		return nil, &ValidationError{
+			Message:    fmt.Sprintf("Assertions file not found: %s", path),
+			Suggestion: "Check the path passed to --assertions",
+		}
+	}
+
+	var file struct {
+		Assertions []parser.Assertion `yaml:"assertions"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, &ValidationError{
+			Message:    fmt.Sprintf("Invalid YAML syntax in %s: %v", path, err),
+			Suggestion: "List the assertions under an assertions: key, each with a name and an entity",
+		}
+	}
+	if len(file.Assertions) == 0 {
+		return nil, &ValidationError{
+			Message:    fmt.Sprintf("No assertions in %s", path),
+			Suggestion: "List the assertions under an assertions: key, each with a name and an entity",
+		}
+	}
+	return file.Assertions, nil
+}
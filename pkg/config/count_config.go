@@ -2,7 +2,11 @@ package config
 
 import (
 	"fmt"
+	"maps"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -19,6 +23,16 @@ type CountConfiguration struct {
 
 	// LoadedAt is when the configuration was loaded
 	LoadedAt time.Time
+
+	// AllowEmpty accepts a count of 0, generating a header-only file for the entity
+	AllowEmpty bool
+
+	// IgnoreUnknown drops entities missing from the SOR during Validate instead of
+	// failing, so one file can serve slightly different SOR versions
+	IgnoreUnknown bool
+
+	// Warnings describes the entities Validate dropped under IgnoreUnknown
+	Warnings []string
 }
 
 // LoadConfiguration reads and parses a row count configuration YAML file.
@@ -41,6 +55,15 @@ func LoadConfiguration(path string) (*CountConfiguration, error) {
 		}
 	}
 
+	// A file of named profiles needs one selected
+	if names, ok := hasProfiles(data); ok {
+		return nil, &ValidationError{
+			Field:      "profiles",
+			Message:    fmt.Sprintf("Count configuration %s defines profiles", path),
+			Suggestion: fmt.Sprintf("Select one with --profile <name> (available: %s)", strings.Join(names, ", ")),
+		}
+	}
+
 	// Parse YAML
 	var entityCounts map[string]int
 	err = yaml.Unmarshal(data, &entityCounts)
@@ -59,10 +82,11 @@ func LoadConfiguration(path string) (*CountConfiguration, error) {
 }
 
 // GetCount returns the row count for an entity, or defaultCount if not specified.
-// If the entity has a count of 0 in the map, defaultCount is returned.
+// If the entity has a count of 0 in the map, defaultCount is returned unless
+// AllowEmpty is set.
 func (c *CountConfiguration) GetCount(entityExternalID string, defaultCount int) int {
 	count, exists := c.EntityCounts[entityExternalID]
-	if !exists || count == 0 {
+	if !exists || (count == 0 && !c.AllowEmpty) {
 		return defaultCount
 	}
 	return count
@@ -70,8 +94,9 @@ func (c *CountConfiguration) GetCount(entityExternalID string, defaultCount int)
 
 // Validate checks the configuration against SOR entities.
 // It verifies that:
-// - All entities referenced in the config exist in the SOR
-// - All count values are positive integers (>0)
+// - All entities referenced in the config exist in the SOR (suggesting the nearest external ID for typos)
+// - With IgnoreUnknown, unknown entities are removed and described in Warnings instead
+// - All count values are positive integers (>0), or zero when AllowEmpty is set
 //
 // Returns a ValidationError if validation fails.
 func (c *CountConfiguration) Validate(sorEntities []string) error {
@@ -81,21 +106,59 @@ func (c *CountConfiguration) Validate(sorEntities []string) error {
 		validEntities[entity] = true
 	}
 
-	// Validate each entity in the configuration
-	for entityID, count := range c.EntityCounts {
+	// Validate entities in a stable order so the first error is reproducible
+	entityIDs := make([]string, 0, len(c.EntityCounts))
+	for entityID := range c.EntityCounts {
+		entityIDs = append(entityIDs, entityID)
+	}
+	sort.Strings(entityIDs)
+
+	c.Warnings = nil
+	for _, entityID := range entityIDs {
+		count := c.EntityCounts[entityID]
+
 		// Check if entity exists in SOR
 		if !validEntities[entityID] {
+			nearest := nearestName(entityID, sorEntities)
+			if c.IgnoreUnknown {
+				warning := fmt.Sprintf("Ignoring count for entity '%s' not found in SOR YAML", entityID)
+				if nearest != "" {
+					warning += fmt.Sprintf(" (did you mean '%s'?)", nearest)
+				}
+				c.Warnings = append(c.Warnings, warning)
+				delete(c.EntityCounts, entityID)
+				continue
+			}
+
+			suggestion := fmt.Sprintf("Remove '%s' or check entity external_id spelling", entityID)
+			if nearest != "" {
+				suggestion = fmt.Sprintf("Did you mean '%s'? Otherwise remove '%s'", nearest, entityID)
+			}
 			return &ValidationError{
 				EntityID:   entityID,
 				Field:      "entity",
 				Value:      entityID,
 				Message:    fmt.Sprintf("Entity '%s' in count configuration not found in SOR YAML\nAvailable entities: %v", entityID, sorEntities),
-				Suggestion: fmt.Sprintf("Remove '%s' or check entity external_id spelling", entityID),
+				Suggestion: suggestion + " (or pass --ignore-unknown-counts to skip unknown entities)",
 			}
 		}
 
+		// Zero produces a header-only file when empty entities are allowed
+		if count == 0 && c.AllowEmpty {
+			continue
+		}
+
 		// Check if count is positive
-		if count <= 0 {
+		if count == 0 {
+			return &ValidationError{
+				EntityID:   entityID,
+				Field:      "count",
+				Value:      count,
+				Message:    fmt.Sprintf("Invalid count for entity '%s': 0 (expected positive integer)", entityID),
+				Suggestion: "Use --include-empty-entities to generate a header-only file for this entity",
+			}
+		}
+		if count < 0 {
 			return &ValidationError{
 				EntityID:   entityID,
 				Field:      "count",
@@ -114,3 +177,57 @@ func (c *CountConfiguration) HasEntity(entityExternalID string) bool {
 	_, exists := c.EntityCounts[entityExternalID]
 	return exists
 }
+
+// CountOverrides are per-entity row counts given on the command line, keyed by
+// entity external ID. They take precedence over -n and the count configuration.
+// As a flag.Value, each --count User=5000 flag adds one.
+type CountOverrides map[string]int
+
+// String lists the overrides in entity order
+func (o CountOverrides) String() string {
+	entityIDs := make([]string, 0, len(o))
+	for entityID := range o {
+		entityIDs = append(entityIDs, entityID)
+	}
+	sort.Strings(entityIDs)
+	pairs := make([]string, len(entityIDs))
+	for i, entityID := range entityIDs {
+		pairs[i] = fmt.Sprintf("%s=%d", entityID, o[entityID])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Set adds an <entity>=<count> override; a later one for the same entity wins
+func (o CountOverrides) Set(spec string) error {
+	entityID, value, found := strings.Cut(spec, "=")
+	entityID = strings.TrimSpace(entityID)
+	if !found || entityID == "" {
+		return fmt.Errorf("invalid count override '%s' (expected <entity>=<count>)", spec)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || count < 0 {
+		return fmt.Errorf("invalid count '%s' for entity %s (expected a non-negative integer)", strings.TrimSpace(value), entityID)
+	}
+	o[entityID] = count
+	return nil
+}
+
+// Apply returns a configuration with the overrides replacing c's counts, leaving
+// c unchanged. Without a configuration (c is nil) the overrides are the only
+// per-entity counts; other entities get -n. Returns c when there are no overrides.
+func (o CountOverrides) Apply(c *CountConfiguration) *CountConfiguration {
+	if len(o) == 0 {
+		return c
+	}
+	applied := &CountConfiguration{SourceFile: "--count", LoadedAt: time.Now()}
+	if c != nil {
+		*applied = *c
+		applied.SourceFile = c.SourceFile + " and --count"
+	}
+	applied.EntityCounts = make(map[string]int, len(applied.EntityCounts)+len(o))
+	if c != nil {
+		maps.Copy(applied.EntityCounts, c.EntityCounts)
+	}
+	maps.Copy(applied.EntityCounts, o)
+	return applied
+}
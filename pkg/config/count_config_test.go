@@ -212,6 +212,24 @@ func TestValidate_ZeroCount(t *testing.T) {
 	}
 }
 
+// Test GetCount and Validate accept zero counts when empty entities are allowed
+func TestCountConfiguration_AllowEmpty(t *testing.T) {
+	config := &CountConfiguration{
+		EntityCounts: map[string]int{
+			"users":  0,
+			"groups": 50,
+		},
+		AllowEmpty: true,
+	}
+
+	assert.Equal(t, 0, config.GetCount("users", 100), "GetCount should return zero when empty entities are allowed")
+	assert.Equal(t, 100, config.GetCount("permissions", 100), "GetCount should still default missing entities")
+	assert.NoError(t, config.Validate([]string{"users", "groups"}), "Validate should accept zero counts")
+
+	config.EntityCounts["groups"] = -1
+	assert.Error(t, config.Validate([]string{"users", "groups"}), "Validate should still reject negative counts")
+}
+
 // T019: Test Validate with non-integer values would be caught by YAML parser
 // This test verifies that LoadConfiguration handles non-integer gracefully
 func TestLoadConfiguration_NonIntegerValues(t *testing.T) {
@@ -247,3 +265,71 @@ func TestHasEntity(t *testing.T) {
 	assert.False(t, config.HasEntity("groups"), "HasEntity should return false for missing entity")
 	assert.False(t, config.HasEntity("nonexistent"), "HasEntity should return false for nonexistent entity")
 }
+
+// Test Validate suggests the nearest entity for likely typos
+func TestValidate_SuggestsNearestEntity(t *testing.T) {
+	config := &CountConfiguration{
+		EntityCounts: map[string]int{"Userz": 10},
+	}
+
+	err := config.Validate([]string{"users", "groups", "permissions"})
+
+	var valErr *ValidationError
+	require.ErrorAs(t, err, &valErr)
+	assert.Equal(t, "Userz", valErr.EntityID)
+	assert.Contains(t, valErr.Suggestion, "Did you mean 'users'?")
+	assert.Contains(t, valErr.Suggestion, "--ignore-unknown-counts")
+}
+
+// Test Validate drops unknown entities with a warning when IgnoreUnknown is set
+func TestValidate_IgnoreUnknown(t *testing.T) {
+	config := &CountConfiguration{
+		EntityCounts:  map[string]int{"users": 10, "grups": 5, "legacy_audit": 3},
+		IgnoreUnknown: true,
+	}
+
+	require.NoError(t, config.Validate([]string{"users", "groups"}))
+
+	assert.Equal(t, map[string]int{"users": 10}, config.EntityCounts)
+	assert.Equal(t, []string{
+		"Ignoring count for entity 'grups' not found in SOR YAML (did you mean 'groups'?)",
+		"Ignoring count for entity 'legacy_audit' not found in SOR YAML",
+	}, config.Warnings)
+
+	// Invalid counts for known entities still fail
+	config.EntityCounts["users"] = -1
+	assert.Error(t, config.Validate([]string{"users", "groups"}))
+}
+
+func TestNearestName(t *testing.T) {
+	candidates := []string{"users", "groups", "permissions"}
+
+	assert.Equal(t, "permissions", nearestName("permisions", candidates))
+	assert.Equal(t, "groups", nearestName("Groups", candidates), "case is ignored")
+	assert.Equal(t, "", nearestName("nonexistent", candidates))
+}
+
+func TestCountOverrides(t *testing.T) {
+	overrides := CountOverrides{}
+	require.NoError(t, overrides.Set("users=5000"))
+	require.NoError(t, overrides.Set(" roles = 50 "))
+	assert.Equal(t, "roles=50,users=5000", overrides.String())
+
+	assert.EqualError(t, overrides.Set("users"), "invalid count override 'users' (expected <entity>=<count>)")
+	assert.EqualError(t, overrides.Set("users=-1"), "invalid count '-1' for entity users (expected a non-negative integer)")
+
+	t.Run("overrides replace configured counts", func(t *testing.T) {
+		configured := &CountConfiguration{EntityCounts: map[string]int{"users": 10, "groups": 20}, SourceFile: "counts.yaml"}
+		applied := overrides.Apply(configured)
+		assert.Equal(t, map[string]int{"users": 5000, "groups": 20, "roles": 50}, applied.EntityCounts)
+		assert.Equal(t, "counts.yaml and --count", applied.SourceFile)
+		assert.Equal(t, 10, configured.EntityCounts["users"], "the configuration is left unchanged")
+	})
+
+	t.Run("overrides alone leave other entities at the default", func(t *testing.T) {
+		applied := overrides.Apply(nil)
+		assert.Equal(t, 5000, applied.GetCount("users", 100))
+		assert.Equal(t, 100, applied.GetCount("groups", 100))
+		assert.Nil(t, CountOverrides{}.Apply(nil))
+	})
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// Fixtures holds exact rows that are always written, keyed by entity external ID.
+// Generated rows make up the rest of each entity's row count, and relationships can
+// reference the fixed rows like any other.
+type Fixtures struct {
+	// Rows maps entity external_id → rows, each keyed by attribute name or externalId
+	Rows map[string][]map[string]string
+
+	// SourceFile is the path to the fixtures file (for error messages)
+	SourceFile string
+}
+
+// LoadFixtures reads a fixtures file:
+//
+//	User:
+//	  - id: admin
+//	    email: admin@example.com
+//	    groupId: admins
+//	Group:
+//	  - id: admins
+//	    name: Administrators
+//
+// Attributes a row leaves out are generated. References to the SOR are checked by
+// Validate.
+func LoadFixtures(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path is from CLI argument, user-controlled
+	if err != nil {
+		return nil, &ValidationError{
+			Message:    fmt.Sprintf("Fixtures file not found: %s", path),
+			Suggestion: "Check the path passed to --fixtures",
+		}
+	}
+
+	var rows map[string][]map[string]string
+	if err := yaml.Unmarshal(data, &rows); err != nil {
+		return nil, &ValidationError{
+			Message:    fmt.Sprintf("Invalid YAML syntax in %s: %v", path, err),
+			Suggestion: "List each entity's rows as a sequence of attribute: value mappings",
+		}
+	}
+
+	return &Fixtures{Rows: rows, SourceFile: path}, nil
+}
+
+// Validate checks the fixtures against the SOR: every entity exists and is generated
+// (not embedded with data or derived), and every row names only the entity's
+// attributes, holds values valid for their types and has its own unique ID.
+func (f *Fixtures) Validate(def *parser.SORDefinition) error {
+	entities := make(map[string]parser.Entity, len(def.Entities))
+	externalIDs := make([]string, 0, len(def.Entities))
+	for _, entity := range def.Entities {
+		entities[entity.ExternalId] = entity
+		externalIDs = append(externalIDs, entity.ExternalId)
+	}
+	sort.Strings(externalIDs)
+
+	// Validate entities in a stable order so the first error is reproducible
+	entityIDs := make([]string, 0, len(f.Rows))
+	for entityID := range f.Rows {
+		entityIDs = append(entityIDs, entityID)
+	}
+	sort.Strings(entityIDs)
+
+	for _, entityID := range entityIDs {
+		entity, exists := entities[entityID]
+		if !exists {
+			suggestion := fmt.Sprintf("Remove '%s' or check entity external_id spelling", entityID)
+			if nearest := nearestName(entityID, externalIDs); nearest != "" {
+				suggestion = fmt.Sprintf("Did you mean '%s'? Otherwise remove '%s'", nearest, entityID)
+			}
+			return &ValidationError{
+				EntityID:   entityID,
+				Field:      "entity",
+				Value:      entityID,
+				Message:    fmt.Sprintf("Entity '%s' in fixtures %s not found in SOR YAML", entityID, f.SourceFile),
+				Suggestion: suggestion,
+			}
+		}
+
+		if len(entity.Data) > 0 || entity.Derived != nil {
+			return &ValidationError{
+				EntityID:   entityID,
+				Field:      "entity",
+				Value:      entityID,
+				Message:    fmt.Sprintf("Entity '%s' in fixtures %s has all its rows set by the SOR", entityID, f.SourceFile),
+				Suggestion: "Remove its fixtures; entities with inline data or derived rows are not generated",
+			}
+		}
+
+		if err := parser.ValidateRows(entityID, entity, f.Rows[entityID], "fixture row"); err != nil {
+			return &ValidationError{
+				EntityID:   entityID,
+				Message:    fmt.Sprintf("Invalid fixtures in %s: %v", f.SourceFile, err),
+				Suggestion: "Set the uniqueId and valid values for the attributes of every fixture row",
+			}
+		}
+	}
+	return nil
+}
+
+// Count returns the number of fixed rows of an entity
+func (f *Fixtures) Count(entityExternalID string) int {
+	return len(f.Rows[entityExternalID])
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixtures(t *testing.T) {
+	def := &parser.SORDefinition{
+		Entities: map[string]parser.Entity{
+			"user": {
+				ExternalId: "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "email", ExternalId: "mail", Type: "String"},
+					{Name: "age", ExternalId: "age", Type: "Integer"},
+				},
+			},
+			"tier": {
+				ExternalId: "Tier",
+				Attributes: []parser.Attribute{{Name: "id", ExternalId: "id", Type: "String", UniqueId: true}},
+				Data:       []map[string]string{{"id": "gold"}},
+			},
+		},
+	}
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "fixtures.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	t.Run("parses rows per entity", func(t *testing.T) {
+		fixtures, err := LoadFixtures(write(t, "User:\n  - {id: admin, mail: admin@example.com, age: 42}\n  - {id: 7}\n"))
+		require.NoError(t, err)
+		require.NoError(t, fixtures.Validate(def))
+		assert.Equal(t, 2, fixtures.Count("User"))
+		assert.Equal(t, "42", fixtures.Rows["User"][0]["age"], "scalars are read as text")
+		assert.Equal(t, "7", fixtures.Rows["User"][1]["id"])
+		assert.Zero(t, fixtures.Count("Tier"))
+	})
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "unknown entity", content: "Users:\n  - {id: admin}\n", wantErr: "Did you mean 'User'?"},
+		{name: "inline data entity", content: "Tier:\n  - {id: silver}\n", wantErr: "has all its rows set by the SOR"},
+		{name: "unknown attribute", content: "User:\n  - {id: admin, name: Admin}\n", wantErr: "fixture row 1 sets unknown attribute 'name'"},
+		{name: "missing unique ID", content: "User:\n  - {mail: admin@example.com}\n", wantErr: "fixture row 1 has no value for its uniqueId 'id'"},
+		{name: "invalid value", content: "User:\n  - {id: admin, age: old}\n", wantErr: "value 'old' of 'age' is not a valid Integer"},
+		{name: "duplicate unique ID", content: "User:\n  - {id: admin}\n  - {id: admin}\n", wantErr: "fixture rows 1 and 2 have the same uniqueId 'admin'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixtures, err := LoadFixtures(write(t, tt.content))
+			require.NoError(t, err)
+			assert.ErrorContains(t, fixtures.Validate(def), tt.wantErr)
+		})
+	}
+
+	t.Run("invalid YAML", func(t *testing.T) {
+		_, err := LoadFixtures(write(t, "User: {id: admin}\n"))
+		assert.ErrorContains(t, err, "Invalid YAML syntax")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadFixtures(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.ErrorContains(t, err, "Fixtures file not found")
+	})
+}
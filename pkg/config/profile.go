@@ -0,0 +1,170 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GenerationProfile bundles the generation settings of one test tier (e.g. smoke,
+// load or soak). Fields left out of the profile keep their command-line values.
+type GenerationProfile struct {
+	// Name is the profile's key in the configuration file
+	Name string `yaml:"-"`
+
+	// Rows is the row count for entities without an entry in Counts (-n)
+	Rows *int `yaml:"rows"`
+
+	// Counts maps entity external IDs to row counts, like a count configuration
+	Counts map[string]int `yaml:"counts"`
+
+	// Seed makes runs reproducible (--seed)
+	Seed *int64 `yaml:"seed"`
+
+	// Format is the output format, csv, jsonl, go or neo4j (--format)
+	Format *string `yaml:"format"`
+
+	// AutoCardinality enables automatic cardinality detection (--auto-cardinality)
+	AutoCardinality *bool `yaml:"autoCardinality"`
+
+	// EdgeCases places boundary values in the first rows (--edge-cases)
+	EdgeCases *bool `yaml:"edgeCases"`
+
+	// ClearlyFakePII uses obviously fake PII formats (--no-real-looking-pii)
+	ClearlyFakePII *bool `yaml:"clearlyFakePII"`
+
+	// RowsPerSecond paces output for soak tests (--rows-per-second)
+	RowsPerSecond *float64 `yaml:"rowsPerSecond"`
+
+	// EntityRowsPerSecond overrides the pace per entity (--entity-rows-per-second)
+	EntityRowsPerSecond map[string]float64 `yaml:"entityRowsPerSecond"`
+
+	// SourceFile is the path to the configuration file (for error messages)
+	SourceFile string `yaml:"-"`
+}
+
+// profileFile is a configuration file holding named profiles
+type profileFile struct {
+	Profiles map[string]*GenerationProfile `yaml:"profiles"`
+}
+
+// LoadProfile reads the named profile from a configuration file of profiles:
+//
+//	profiles:
+//	  smoke:
+//	    rows: 10
+//	    seed: 1
+//	  load:
+//	    counts: {User: 100000, Group: 5000}
+//	    seed: 42
+//	    format: jsonl
+//	  soak:
+//	    rows: 1000
+//	    rowsPerSecond: 50
+//
+// Unknown fields are rejected so typos don't silently fall back to defaults.
+func LoadProfile(path, name string) (*GenerationProfile, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path is from CLI argument, user-controlled
+	if err != nil {
+		return nil, &ValidationError{
+			Message:    fmt.Sprintf("Profile configuration file not found: %s", path),
+			Suggestion: "Check the path passed to --count-config",
+		}
+	}
+
+	var file profileFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, &ValidationError{
+			Message:    fmt.Sprintf("Invalid profile configuration in %s: %v", path, err),
+			Suggestion: "Put profiles under a top-level 'profiles' key; see the README for the supported fields",
+		}
+	}
+
+	profile, exists := file.Profiles[name]
+	if !exists || profile == nil {
+		return nil, &ValidationError{
+			Field:      "profile",
+			Value:      name,
+			Message:    fmt.Sprintf("Profile '%s' not found in %s", name, path),
+			Suggestion: fmt.Sprintf("Available profiles: %s", strings.Join(profileNames(file.Profiles), ", ")),
+		}
+	}
+	profile.Name = name
+	profile.SourceFile = path
+
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// profileNames returns the sorted names of the profiles
+func profileNames(profiles map[string]*GenerationProfile) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that row counts and rates are usable. Entity names in Counts are
+// checked against the SOR by the count configuration.
+func (p *GenerationProfile) Validate() error {
+	if p.Rows != nil && *p.Rows < 1 {
+		return &ValidationError{
+			Field:      "rows",
+			Value:      *p.Rows,
+			Message:    fmt.Sprintf("Invalid rows for profile '%s': %d (expected positive integer)", p.Name, *p.Rows),
+			Suggestion: "Use a number like 100, 1000, etc.",
+		}
+	}
+	if p.RowsPerSecond != nil && *p.RowsPerSecond < 0 {
+		return &ValidationError{
+			Field:      "rowsPerSecond",
+			Value:      *p.RowsPerSecond,
+			Message:    fmt.Sprintf("Invalid rowsPerSecond for profile '%s': %g", p.Name, *p.RowsPerSecond),
+			Suggestion: "Use 0 for unlimited or a positive rate",
+		}
+	}
+	return nil
+}
+
+// CountConfiguration returns the profile's per-entity counts as a count
+// configuration, or nil if the profile sets none
+func (p *GenerationProfile) CountConfiguration() *CountConfiguration {
+	if len(p.Counts) == 0 {
+		return nil
+	}
+	return &CountConfiguration{
+		EntityCounts: p.Counts,
+		SourceFile:   fmt.Sprintf("%s (profile %s)", p.SourceFile, p.Name),
+		LoadedAt:     time.Now(),
+	}
+}
+
+// hasProfiles reports whether configuration data holds named profiles rather than
+// entity counts, returning their names
+func hasProfiles(data []byte) ([]string, bool) {
+	var file map[string]interface{}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, false
+	}
+	profiles, ok := file["profiles"].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, true
+}
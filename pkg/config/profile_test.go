@@ -0,0 +1,96 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tiersYAML = `profiles:
+  smoke:
+    rows: 10
+    seed: 1
+    edgeCases: true
+  load:
+    counts: {users: 100000, groups: 5000}
+    seed: 42
+    format: jsonl
+    autoCardinality: false
+  soak:
+    rows: 1000
+    rowsPerSecond: 50
+    entityRowsPerSecond: {users: 10}
+`
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tiers.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(tiersYAML), 0600))
+
+	t.Run("loads only the fields a profile sets", func(t *testing.T) {
+		smoke, err := LoadProfile(path, "smoke")
+		require.NoError(t, err)
+		assert.Equal(t, "smoke", smoke.Name)
+		require.NotNil(t, smoke.Rows)
+		assert.Equal(t, 10, *smoke.Rows)
+		assert.Equal(t, int64(1), *smoke.Seed)
+		assert.True(t, *smoke.EdgeCases)
+		assert.Nil(t, smoke.Format)
+		assert.Nil(t, smoke.AutoCardinality)
+		assert.Nil(t, smoke.CountConfiguration(), "no counts means no count configuration")
+
+		soak, err := LoadProfile(path, "soak")
+		require.NoError(t, err)
+		assert.Equal(t, 50.0, *soak.RowsPerSecond)
+		assert.Equal(t, map[string]float64{"users": 10}, soak.EntityRowsPerSecond)
+	})
+
+	t.Run("turns counts into a count configuration", func(t *testing.T) {
+		load, err := LoadProfile(path, "load")
+		require.NoError(t, err)
+		assert.Equal(t, "jsonl", *load.Format)
+		assert.False(t, *load.AutoCardinality)
+
+		counts := load.CountConfiguration()
+		require.NotNil(t, counts)
+		assert.Equal(t, 5000, counts.GetCount("groups", 100))
+		assert.Equal(t, 100, counts.GetCount("roles", 100))
+		assert.Contains(t, counts.SourceFile, "profile load")
+	})
+
+	tests := []struct {
+		name    string
+		content string
+		profile string
+		errText string
+	}{
+		{name: "unknown profile", content: tiersYAML, profile: "nightly", errText: "Available profiles: load, smoke, soak"},
+		{name: "unknown field", content: "profiles:\n  smoke:\n    rowz: 10\n", profile: "smoke", errText: "rowz"},
+		{name: "non-positive rows", content: "profiles:\n  smoke:\n    rows: 0\n", profile: "smoke", errText: "Invalid rows"},
+		{name: "negative rate", content: "profiles:\n  soak:\n    rowsPerSecond: -1\n", profile: "soak", errText: "Invalid rowsPerSecond"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tiers.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0600))
+
+			_, err := LoadProfile(path, tt.profile)
+			require.Error(t, err)
+			var valErr *ValidationError
+			require.ErrorAs(t, err, &valErr)
+			assert.Contains(t, valErr.Message+" "+valErr.Suggestion, tt.errText)
+		})
+	}
+}
+
+func TestLoadConfiguration_RejectsProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tiers.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(tiersYAML), 0600))
+
+	_, err := LoadConfiguration(path)
+	var valErr *ValidationError
+	require.ErrorAs(t, err, &valErr)
+	assert.Contains(t, valErr.Suggestion, "--profile <name> (available: load, smoke, soak)")
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadRelationshipValidation reads a relationship validation override file.
+// Returns a map of relationship key → validation level.
+//
+// The YAML file maps relationship keys from the SOR definition to a level:
+//
+//	user_to_group: warn
+//	legacy_owner: skip
+//
+// Valid levels are skip, warn and error.
+func LoadRelationshipValidation(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path is from CLI argument, user-controlled
+	if err != nil {
+		return nil, &ValidationError{
+			Message:    fmt.Sprintf("Relationship validation file not found: %s", path),
+			Suggestion: "Check the path passed to --relationship-validation",
+		}
+	}
+
+	var levels map[string]string
+	if err := yaml.Unmarshal(data, &levels); err != nil {
+		return nil, &ValidationError{
+			Message:    fmt.Sprintf("Invalid YAML syntax in %s: %v", path, err),
+			Suggestion: "Use a flat mapping of relationship key to skip, warn or error",
+		}
+	}
+
+	keys := make([]string, 0, len(levels))
+	for key := range levels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch levels[key] {
+		case "skip", "warn", "error":
+		default:
+			return nil, &ValidationError{
+				Field:      key,
+				Value:      levels[key],
+				Message:    fmt.Sprintf("Invalid validation level '%s' for relationship %s in %s", levels[key], key, path),
+				Suggestion: "Use one of: skip, warn, error",
+			}
+		}
+	}
+
+	return levels, nil
+}
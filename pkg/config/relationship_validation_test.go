@@ -0,0 +1,55 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRelationshipValidation(t *testing.T) {
+	writeFile := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "validation.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	t.Run("loads levels", func(t *testing.T) {
+		path := writeFile(t, "user_to_group: warn\nlegacy_owner: skip\nstrict: error\n")
+
+		levels, err := LoadRelationshipValidation(path)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"user_to_group": "warn",
+			"legacy_owner":  "skip",
+			"strict":        "error",
+		}, levels)
+	})
+
+	t.Run("rejects unknown level", func(t *testing.T) {
+		path := writeFile(t, "user_to_group: ignore\n")
+
+		_, err := LoadRelationshipValidation(path)
+		var valErr *ValidationError
+		require.ErrorAs(t, err, &valErr)
+		assert.Equal(t, "user_to_group", valErr.Field)
+		assert.Contains(t, valErr.Error(), "skip, warn, error")
+	})
+
+	t.Run("invalid YAML", func(t *testing.T) {
+		path := writeFile(t, "user_to_group: [warn\n")
+
+		_, err := LoadRelationshipValidation(path)
+		var valErr *ValidationError
+		assert.ErrorAs(t, err, &valErr)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadRelationshipValidation(filepath.Join(t.TempDir(), "missing.yaml"))
+		var valErr *ValidationError
+		assert.ErrorAs(t, err, &valErr)
+		assert.Contains(t, err.Error(), "not found")
+	})
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/util/editdistance"
+)
+
+// nearestName returns the candidate closest to name by edit distance, ignoring
+// case, or "" when none is close enough to be a likely typo
+func nearestName(name string, candidates []string) string {
+	// Allow roughly one edit per three characters, and at least two
+	limit := max(2, len(name)/3)
+
+	best, bestDistance := "", limit+1
+	for _, candidate := range candidates {
+		distance := editdistance.Levenshtein(strings.ToLower(name), strings.ToLower(candidate))
+		if distance < bestDistance || (distance == bestDistance && candidate < best) {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
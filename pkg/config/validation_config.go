@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Validation checks an error budget can cover
+const (
+	// ValidationCheckForeignKeys counts foreign key values missing from their target
+	ValidationCheckForeignKeys = "orphanedForeignKeys"
+
+	// ValidationCheckUniqueWithin counts values repeated within their uniqueWithin scope
+	ValidationCheckUniqueWithin = "uniqueWithin"
+)
+
+// ValidationChecks lists the checks a validation config can set tolerances for
+var ValidationChecks = []string{ValidationCheckForeignKeys, ValidationCheckUniqueWithin}
+
+// ValidationConfiguration is the error budget for --validate-only: how many issues
+// each check may find before validation fails. Intentionally noisy datasets can
+// then pass CI while they stay within expected bounds.
+type ValidationConfiguration struct {
+	// Tolerances maps a check name to the issues it may find
+	Tolerances map[string]Tolerance `yaml:"tolerances"`
+}
+
+// Tolerance is the number of issues a check may find, written in YAML as a
+// percentage of the values checked ("0.1%") or an absolute count (25)
+type Tolerance struct {
+	Count   int     // Issues allowed when Percent is false
+	Share   float64 // Fraction of checked values allowed when Percent is true
+	Percent bool
+}
+
+// UnmarshalYAML accepts a percentage string or a non-negative integer
+func (t *Tolerance) UnmarshalYAML(value *yaml.Node) error {
+	tolerance, err := ParseTolerance(value.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	*t = tolerance
+	return nil
+}
+
+// ParseTolerance parses a tolerance written as a percentage ("0.1%") or a
+// non-negative count ("25")
+func ParseTolerance(text string) (Tolerance, error) {
+	trimmed := strings.TrimSpace(text)
+	if percent, ok := strings.CutSuffix(trimmed, "%"); ok {
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+		if err != nil || parsed < 0 || parsed > 100 {
+			return Tolerance{}, fmt.Errorf("invalid percentage '%s' (expected 0%% to 100%%)", text)
+		}
+		return Tolerance{Share: parsed / 100, Percent: true}, nil
+	}
+
+	count, err := strconv.Atoi(trimmed)
+	if err != nil || count < 0 {
+		return Tolerance{}, fmt.Errorf("invalid tolerance '%s' (use a percentage like 0.1%% or a count like 25)", text)
+	}
+	return Tolerance{Count: count}, nil
+}
+
+// Allows reports whether failed issues among checked values are within the tolerance
+func (t Tolerance) Allows(failed, checked int) bool {
+	if t.Percent {
+		// Allow for rounding in the share, so 1 of 1000 passes 0.1%
+		return float64(failed) <= t.Share*float64(checked)+1e-9
+	}
+	return failed <= t.Count
+}
+
+// String returns the tolerance as written in the configuration
+func (t Tolerance) String() string {
+	if t.Percent {
+		return strconv.FormatFloat(t.Share*100, 'f', -1, 64) + "%"
+	}
+	return strconv.Itoa(t.Count)
+}
+
+// LoadValidationConfiguration reads a validation error budget file:
+//
+//	tolerances:
+//	  orphanedForeignKeys: 0.1%   # of foreign key values checked
+//	  uniqueWithin: 25            # issues, regardless of how many values were checked
+//
+// Checks without a tolerance allow no issues.
+func LoadValidationConfiguration(path string) (*ValidationConfiguration, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path is from CLI argument, user-controlled
+	if err != nil {
+		return nil, &ValidationError{
+			Message:    fmt.Sprintf("Validation config file not found: %s", path),
+			Suggestion: "Check the path passed to --validation-config",
+		}
+	}
+
+	var cfg ValidationConfiguration
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, &ValidationError{
+			Message:    fmt.Sprintf("Invalid YAML syntax in %s: %v", path, err),
+			Suggestion: "Set tolerances as a percentage (0.1%) or a count (25) per check",
+		}
+	}
+
+	checks := make([]string, 0, len(cfg.Tolerances))
+	for check := range cfg.Tolerances {
+		checks = append(checks, check)
+	}
+	sort.Strings(checks)
+
+	for _, check := range checks {
+		known := false
+		for _, candidate := range ValidationChecks {
+			known = known || check == candidate
+		}
+		if known {
+			continue
+		}
+		suggestion := fmt.Sprintf("Use one of: %s", strings.Join(ValidationChecks, ", "))
+		if nearest := nearestName(check, ValidationChecks); nearest != "" {
+			suggestion = fmt.Sprintf("Did you mean '%s'? %s", nearest, suggestion)
+		}
+		return nil, &ValidationError{
+			Field:      check,
+			Message:    fmt.Sprintf("Unknown validation check '%s' in %s", check, path),
+			Suggestion: suggestion,
+		}
+	}
+
+	return &cfg, nil
+}
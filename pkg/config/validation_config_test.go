@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadValidationConfiguration(t *testing.T) {
+	writeFile := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "tolerances.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	t.Run("loads percentages and counts", func(t *testing.T) {
+		path := writeFile(t, "tolerances:\n  orphanedForeignKeys: 0.1%\n  uniqueWithin: 25\n")
+
+		cfg, err := LoadValidationConfiguration(path)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]Tolerance{
+			ValidationCheckForeignKeys:  {Share: 0.001, Percent: true},
+			ValidationCheckUniqueWithin: {Count: 25},
+		}, cfg.Tolerances)
+		assert.Equal(t, "0.1%", cfg.Tolerances[ValidationCheckForeignKeys].String())
+	})
+
+	t.Run("suggests the nearest check for typos", func(t *testing.T) {
+		path := writeFile(t, "tolerances:\n  orphanedForeignKey: 1%\n")
+
+		_, err := LoadValidationConfiguration(path)
+		var valErr *ValidationError
+		require.ErrorAs(t, err, &valErr)
+		assert.Equal(t, "orphanedForeignKey", valErr.Field)
+		assert.Contains(t, valErr.Suggestion, "Did you mean 'orphanedForeignKeys'?")
+	})
+
+	t.Run("rejects invalid tolerances", func(t *testing.T) {
+		for _, value := range []string{"-1", "150%", "0.5", "lots"} {
+			_, err := LoadValidationConfiguration(writeFile(t, "tolerances:\n  uniqueWithin: "+value+"\n"))
+			assert.Error(t, err, value)
+		}
+	})
+
+	t.Run("reports missing files", func(t *testing.T) {
+		_, err := LoadValidationConfiguration(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.ErrorContains(t, err, "Validation config file not found")
+	})
+}
+
+func TestToleranceAllows(t *testing.T) {
+	share := Tolerance{Share: 0.001, Percent: true}
+	assert.True(t, share.Allows(1, 1000))
+	assert.False(t, share.Allows(2, 1000))
+	assert.True(t, share.Allows(0, 0))
+
+	count := Tolerance{Count: 3}
+	assert.True(t, count.Allows(3, 10))
+	assert.False(t, count.Allows(4, 1000000))
+}
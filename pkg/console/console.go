@@ -0,0 +1,75 @@
+// Package console controls how commands decorate their terminal output: ANSI
+// colors, and the unicode symbols marking successes, warnings and list items.
+package console
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// PlainEnv names the environment variable that turns on plain output for every
+// command when set to a non-empty value, as NO_COLOR turns off colors
+const PlainEnv = "FABRICATOR_PLAIN"
+
+// plain replaces the unicode symbols of console output with ASCII and turns off colors
+var plain bool
+
+// asciiSymbols replaces the symbols console output uses with their ASCII forms
+var asciiSymbols = strings.NewReplacer(
+	"⚠️ ", "WARNING:",
+	"⚠️", "WARNING:",
+	"✓", "OK",
+	"✗", "FAIL",
+	"•", "-",
+	"→", "->",
+	"←", "<-",
+)
+
+func init() {
+	SetPlain(os.Getenv(PlainEnv) != "")
+}
+
+// SetPlain configures plain output: ASCII in place of unicode symbols and no
+// colors, for CI logs and programs reading the output
+func SetPlain(enabled bool) {
+	plain = enabled
+	if enabled {
+		DisableColor()
+	}
+}
+
+// DisableColor turns off ANSI colors. The color package already leaves them out
+// when NO_COLOR is set or output isn't a terminal.
+func DisableColor() {
+	color.NoColor = true
+}
+
+// Text returns s with its unicode symbols replaced by ASCII under plain output
+func Text(s string) string {
+	if !plain {
+		return s
+	}
+	return asciiSymbols.Replace(s)
+}
+
+// Progress overwrites the current line with a progress message. Plain output
+// prints each message on a line of its own instead.
+func Progress(format string, args ...any) {
+	if plain {
+		fmt.Printf(Text(format)+"\n", args...)
+		return
+	}
+	ClearLine()
+	fmt.Printf(format, args...)
+}
+
+// ClearLine clears a progress message off the current line; plain output leaves
+// none to clear
+func ClearLine() {
+	if !plain {
+		fmt.Printf("\r%-80s\r", "")
+	}
+}
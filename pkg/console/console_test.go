@@ -0,0 +1,36 @@
+package console
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestText(t *testing.T) {
+	noColor := color.NoColor
+	t.Cleanup(func() {
+		SetPlain(false)
+		color.NoColor = noColor
+	})
+
+	SetPlain(false)
+	assert.Equal(t, "✓ Generated User.csv", Text("✓ Generated User.csv"))
+
+	SetPlain(true)
+	assert.True(t, color.NoColor, "plain output has no colors")
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"✓ Generated User.csv", "OK Generated User.csv"},
+		{"✗ foreign keys", "FAIL foreign keys"},
+		{"⚠️  Truncation Warnings:", "WARNING: Truncation Warnings:"},
+		{"  • User.managerId → User.id", "  - User.managerId -> User.id"},
+		{"    ← Group via owner", "    <- Group via owner"},
+		{"plain text", "plain text"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, Text(tt.input))
+	}
+}
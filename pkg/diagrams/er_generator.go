@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/SGNL-ai/fabricator/pkg/parser"
@@ -20,6 +21,7 @@ type Entity struct {
 	ID         string
 	Name       string
 	ExternalID string
+	Domain     string // Entities sharing a domain are drawn in one cluster
 }
 
 // Relationship represents an edge in the ER diagram
@@ -40,6 +42,7 @@ type ERDiagramGenerator struct {
 	Definition    *parser.SORDefinition
 	Entities      map[string]Entity
 	Relationships []Relationship
+	Options       Options
 }
 
 // NewERDiagramGenerator creates a new ERDiagramGenerator instance
@@ -75,12 +78,36 @@ func GenerateERDiagram(def *parser.SORDefinition, outputPath string) error {
 	return generator.Generate(outputPath)
 }
 
+// GenerateERDiagramWithOptions creates an ER diagram from the SOR definition laid
+// out, formatted and filtered as the options say. Without Graphviz, or for the dot
+// format, it writes the DOT source, next to outputPath when that names another
+// format. It returns the path of the file written.
+func GenerateERDiagramWithOptions(def *parser.SORDefinition, outputPath string, options Options) (string, error) {
+	generator := NewERDiagramGenerator(def)
+	generator.Options = options
+	return generator.generate(outputPath)
+}
+
 // Generate creates the ER diagram as a DOT file
 func (g *ERDiagramGenerator) Generate(outputPath string) error {
+	_, err := g.generate(outputPath)
+	return err
+}
+
+// generate creates the ER diagram, returning the path of the file written
+func (g *ERDiagramGenerator) generate(outputPath string) (string, error) {
+	if err := g.Options.Validate(); err != nil {
+		return "", err
+	}
+	selected, err := g.Options.selectEntities(g.Definition)
+	if err != nil {
+		return "", err
+	}
+
 	// Create the output directory if needed
-	err := os.MkdirAll(filepath.Dir(outputPath), 0750)
+	err = os.MkdirAll(filepath.Dir(outputPath), 0750)
 	if err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+		return "", fmt.Errorf("failed to create output directory: %w", err)
 	}
 
 	// Extract entity and relationship data
@@ -100,6 +127,13 @@ func (g *ERDiagramGenerator) Generate(outputPath string) error {
 		g.extractRelationshipsFromGraph(entityGraph)
 	}
 
+	// Leave out the entities the options don't select, with their relationships
+	if g.Options.filtered() {
+		if err := g.keepEntities(entityGraph, selected); err != nil {
+			return "", err
+		}
+	}
+
 	// Add or update entities as vertices with attributes for styling
 	for id, entity := range g.Entities {
 		// Create vertex attribute map for styling
@@ -131,10 +165,10 @@ func (g *ERDiagramGenerator) Generate(outputPath string) error {
 			// Vertex doesn't exist, add it with attributes
 			err = entityGraph.AddVertex(id, graph.VertexAttributes(attributes))
 			if err != nil {
-				return fmt.Errorf("failed to add vertex for entity %s: %w", id, err)
+				return "", fmt.Errorf("failed to add vertex for entity %s: %w", id, err)
 			}
 		} else {
-			return fmt.Errorf("failed to check vertex %s: %w", id, err)
+			return "", fmt.Errorf("failed to check vertex %s: %w", id, err)
 		}
 	}
 
@@ -189,7 +223,8 @@ func (g *ERDiagramGenerator) Generate(outputPath string) error {
 	// Generate DOT representation with styling for the overall graph
 	var dotBuf bytes.Buffer
 	err = draw.DOT(entityGraph, &dotBuf,
-		// draw.GraphAttribute("rankdir", "LR"),
+		draw.GraphAttribute("layout", g.Options.layout()),
+		draw.GraphAttribute("rankdir", g.Options.rankDir()),
 		draw.GraphAttribute("concentrate", "true"),
 		draw.GraphAttribute("splines", "curved"),
 		draw.GraphAttribute("overlap", "scalexy"),
@@ -202,35 +237,37 @@ func (g *ERDiagramGenerator) Generate(outputPath string) error {
 		draw.GraphAttribute("dpi", "72"),
 	)
 	if err != nil {
-		return fmt.Errorf("failed to generate DOT file: %w", err)
+		return "", fmt.Errorf("failed to generate DOT file: %w", err)
 	}
+	dot := g.addDomainClusters(dotBuf.Bytes())
 
-	// Ensure the output path has the correct extension based on whether we'll generate SVG or DOT
-	isSvgOutput := IsGraphvizAvailable() && filepath.Ext(outputPath) == ".svg"
+	// Graphviz renders every format but DOT source, which is written as is
+	format := g.Options.format(outputPath)
+	render := format != "dot" && IsGraphvizAvailable()
 
 	// Create a temporary DOT file
 	tmpDotFile, err := createTemp("", "er-diagram-*.dot")
 	if err != nil {
-		return fmt.Errorf("failed to create temporary DOT file: %w", err)
+		return "", fmt.Errorf("failed to create temporary DOT file: %w", err)
 	}
 	defer func() { _ = os.Remove(tmpDotFile.Name()) }() // Clean up the temporary file when done
 
 	// Write DOT content to temporary file
-	if _, err := tmpDotFile.Write(dotBuf.Bytes()); err != nil {
-		return fmt.Errorf("failed to write to temporary DOT file: %w", err)
+	if _, err := tmpDotFile.Write(dot); err != nil {
+		return "", fmt.Errorf("failed to write to temporary DOT file: %w", err)
 	}
 	if err := tmpDotFile.Close(); err != nil {
-		return fmt.Errorf("failed to close temporary DOT file: %w", err)
+		return "", fmt.Errorf("failed to close temporary DOT file: %w", err)
 	}
 
-	// If Graphviz is available and we want SVG output, use it to generate the SVG
-	if isSvgOutput {
-		// Use the dot command to convert DOT to SVG
-		cmd := execCommand("dot", "-Tsvg", tmpDotFile.Name(), "-o", outputPath)
+	// If Graphviz is available, use it to render the diagram; the DOT source names
+	// its layout engine
+	if render {
+		cmd := execCommand("dot", "-T"+format, tmpDotFile.Name(), "-o", outputPath)
 		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to run Graphviz dot command: %w", err)
+			return "", fmt.Errorf("failed to run Graphviz dot command: %w", err)
 		}
-		return nil
+		return outputPath, nil
 	}
 
 	// If Graphviz isn't available or we explicitly want DOT output, just copy the DOT file
@@ -243,14 +280,55 @@ func (g *ERDiagramGenerator) Generate(outputPath string) error {
 	// Copy the DOT content to the final location
 	dotContent, err := os.ReadFile(tmpDotFile.Name())
 	if err != nil {
-		return fmt.Errorf("failed to read temporary DOT file: %w", err)
+		return "", fmt.Errorf("failed to read temporary DOT file: %w", err)
 	}
 
 	err = os.WriteFile(dotOutputPath, dotContent, 0600)
 	if err != nil {
-		return fmt.Errorf("failed to write DOT file: %w", err)
+		return "", fmt.Errorf("failed to write DOT file: %w", err)
 	}
 
+	return dotOutputPath, nil
+}
+
+// keepEntities removes the entities not selected, and the relationships touching
+// them, from the diagram and the dependency graph
+func (g *ERDiagramGenerator) keepEntities(entityGraph graph.Graph[string, string], selected map[string]bool) error {
+	for id := range g.Entities {
+		if !selected[id] {
+			delete(g.Entities, id)
+		}
+	}
+	kept := g.Relationships[:0]
+	for _, rel := range g.Relationships {
+		if selected[rel.FromEntity] && selected[rel.ToEntity] {
+			kept = append(kept, rel)
+		}
+	}
+	g.Relationships = kept
+
+	edges, err := entityGraph.Edges()
+	if err != nil {
+		return fmt.Errorf("failed to list diagram edges: %w", err)
+	}
+	for _, edge := range edges {
+		if !selected[edge.Source] || !selected[edge.Target] {
+			if err := entityGraph.RemoveEdge(edge.Source, edge.Target); err != nil {
+				return fmt.Errorf("failed to remove edge %s -> %s: %w", edge.Source, edge.Target, err)
+			}
+		}
+	}
+	adjacency, err := entityGraph.AdjacencyMap()
+	if err != nil {
+		return fmt.Errorf("failed to list diagram vertices: %w", err)
+	}
+	for id := range adjacency {
+		if !selected[id] {
+			if err := entityGraph.RemoveVertex(id); err != nil {
+				return fmt.Errorf("failed to remove entity %s: %w", id, err)
+			}
+		}
+	}
 	return nil
 }
 
@@ -363,21 +441,64 @@ func (g *ERDiagramGenerator) extractRelationshipsFromGraph(entityGraph graph.Gra
 
 // extractEntities extracts entity information from the definition
 func (g *ERDiagramGenerator) extractEntities() {
+	// Use DisplayName if available, otherwise ExternalId without namespace, qualified
+	// where entities share a name so every box is distinguishable
+	displayNames := parser.DistinctDisplayNames(g.Definition.Entities)
 	for id, entity := range g.Definition.Entities {
-		// Use DisplayName if available, otherwise ExternalId
-		displayName := entity.DisplayName
-		if displayName == "" {
-			// Extract just the entity name from ExternalId if it contains a namespace
-			parts := strings.Split(entity.ExternalId, "/")
-			displayName = parts[len(parts)-1]
-		}
-
 		g.Entities[id] = Entity{
 			ID:         id,
-			Name:       displayName,
+			Name:       displayNames[id],
 			ExternalID: entity.ExternalId,
+			Domain:     entity.Domain,
+		}
+	}
+}
+
+// addDomainClusters groups the entities of each domain into a labeled Graphviz
+// cluster. The DOT renderer has no subgraph support, so the clusters are appended
+// before the graph's closing brace; listing a node in a subgraph places it there.
+func (g *ERDiagramGenerator) addDomainClusters(dot []byte) []byte {
+	members := make(map[string][]string)
+	for id, entity := range g.Entities {
+		if entity.Domain != "" {
+			members[entity.Domain] = append(members[entity.Domain], id)
+		}
+	}
+	if len(members) == 0 {
+		return dot
+	}
+
+	domains := make([]string, 0, len(members))
+	for domain := range members {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	var clusters strings.Builder
+	for i, domain := range domains {
+		ids := members[domain]
+		sort.Strings(ids)
+		fmt.Fprintf(&clusters, "\tsubgraph \"cluster_%d\" {\n", i)
+		fmt.Fprintf(&clusters, "\t\tlabel=%s;\n\t\tstyle=\"rounded,dashed\";\n\t\tcolor=\"#6c7a89\";\n", dotQuote(domain))
+		for _, id := range ids {
+			fmt.Fprintf(&clusters, "\t\t%s;\n", dotQuote(id))
 		}
+		clusters.WriteString("\t}\n")
+	}
+
+	closing := bytes.LastIndexByte(dot, '}')
+	if closing < 0 {
+		return dot
 	}
+	result := make([]byte, 0, len(dot)+clusters.Len())
+	result = append(result, dot[:closing]...)
+	result = append(result, clusters.String()...)
+	return append(result, dot[closing:]...)
+}
+
+// dotQuote quotes a string as a DOT identifier
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`) + `"`
 }
 
 // extractRelationships extracts relationship information
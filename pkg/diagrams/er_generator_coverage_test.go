@@ -770,3 +770,41 @@ func TestGenerateErrorPathsCoverage(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+// TestGenerateDomainClusters tests that entities sharing a domain are drawn in one cluster
+func TestGenerateDomainClusters(t *testing.T) {
+	testOutputPath := filepath.Join(t.TempDir(), "domains.dot")
+	entity := func(name, domain string) parser.Entity {
+		return parser.Entity{
+			DisplayName: name,
+			ExternalId:  name,
+			Domain:      domain,
+			Attributes:  []parser.Attribute{{Name: "id", ExternalId: "id", Type: "String", UniqueId: true}},
+		}
+	}
+	definition := &parser.SORDefinition{
+		DisplayName: "Domains",
+		Entities: map[string]parser.Entity{
+			"user":    entity("User", "identity"),
+			"group":   entity("Group", "identity"),
+			"invoice": entity("Invoice", `billing "EU"`),
+			"log":     entity("Log", ""),
+		},
+	}
+
+	originalFunc := IsGraphvizAvailable
+	defer func() { IsGraphvizAvailable = originalFunc }()
+	IsGraphvizAvailable = func() bool { return false }
+
+	assert.NoError(t, GenerateERDiagram(definition, testOutputPath))
+	content, err := os.ReadFile(testOutputPath) // #nosec G304 - test file
+	assert.NoError(t, err)
+	dot := string(content)
+
+	assert.Contains(t, dot, "subgraph \"cluster_0\" {\n\t\tlabel=\"billing \\\"EU\\\"\";")
+	assert.Contains(t, dot, "\t\t\"invoice\";\n\t}")
+	assert.Contains(t, dot, "subgraph \"cluster_1\" {\n\t\tlabel=\"identity\";")
+	assert.Contains(t, dot, "\t\t\"group\";\n\t\t\"user\";\n\t}")
+	assert.NotContains(t, dot, "\t\t\"log\";", "entities without a domain stay outside clusters")
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(dot), "}"))
+}
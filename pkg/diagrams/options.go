@@ -0,0 +1,120 @@
+package diagrams
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// Layouts, rank directions and formats a diagram can be drawn with
+var (
+	Layouts  = []string{"dot", "neato", "fdp"}
+	RankDirs = []string{"TB", "LR", "BT", "RL"}
+	Formats  = []string{"svg", "png", "pdf", "dot"}
+)
+
+// Options controls how a diagram is laid out, which format it's written in and
+// which entities it shows. The zero value draws every entity with the dot layout,
+// in the format named by the output path's extension.
+type Options struct {
+	Layout  string   // Graphviz layout engine: dot, neato or fdp
+	RankDir string   // Direction of the dot layout's ranks: TB, LR, BT or RL
+	Format  string   // svg, png, pdf or dot; taken from the output path when empty
+	Include []string // Entity patterns (path.Match globs of externalIds or names) to draw
+	Domains []string // Domains whose entities are drawn
+}
+
+// Validate checks the layout, rank direction and format are known
+func (o Options) Validate() error {
+	for _, option := range []struct {
+		name, value string
+		known       []string
+	}{
+		{"layout", o.Layout, Layouts},
+		{"rankdir", o.RankDir, RankDirs},
+		{"format", o.Format, Formats},
+	} {
+		if option.value != "" && !slices.Contains(option.known, option.value) {
+			return fmt.Errorf("unknown %s '%s' (expected one of %s)", option.name, option.value, strings.Join(option.known, ", "))
+		}
+	}
+	for _, pattern := range o.Include {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid entity pattern '%s': %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// layout returns the layout engine, dot by default
+func (o Options) layout() string {
+	if o.Layout == "" {
+		return "dot"
+	}
+	return o.Layout
+}
+
+// rankDir returns the direction of the ranks, top to bottom by default
+func (o Options) rankDir() string {
+	if o.RankDir == "" {
+		return "TB"
+	}
+	return o.RankDir
+}
+
+// format returns the format a diagram is written in: the configured one, or the one
+// named by the output path's extension, DOT source for other extensions
+func (o Options) format(outputPath string) string {
+	if o.Format != "" {
+		return o.Format
+	}
+	extension := strings.TrimPrefix(filepath.Ext(outputPath), ".")
+	if slices.Contains(Formats, extension) {
+		return extension
+	}
+	return "dot"
+}
+
+// filtered reports whether the options draw only some entities
+func (o Options) filtered() bool {
+	return len(o.Include) > 0 || len(o.Domains) > 0
+}
+
+// selectEntities returns the IDs of the entities an include pattern or domain
+// selects, and an error for a pattern or domain selecting none
+func (o Options) selectEntities(def *parser.SORDefinition) (map[string]bool, error) {
+	displayNames := parser.DistinctDisplayNames(def.Entities)
+	selected := make(map[string]bool)
+	for _, pattern := range o.Include {
+		matched := false
+		for id, entity := range def.Entities {
+			for _, name := range []string{entity.ExternalId, displayNames[id], id} {
+				if ok, _ := path.Match(pattern, name); ok {
+					selected[id] = true
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("no entity matches '%s'", pattern)
+		}
+	}
+	for _, domain := range o.Domains {
+		matched := false
+		for id, entity := range def.Entities {
+			if entity.Domain == domain {
+				selected[id] = true
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("no entity is in domain '%s'", domain)
+		}
+	}
+	return selected, nil
+}
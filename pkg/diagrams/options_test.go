@@ -0,0 +1,110 @@
+package diagrams
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateERDiagramWithOptions(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Options",
+		Entities: map[string]parser.Entity{
+			"user": {DisplayName: "User", ExternalId: "OktaUser", Domain: "identity", Attributes: []parser.Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true, AttributeAlias: "user-id"},
+				{Name: "groupId", ExternalId: "groupId", Type: "String", AttributeAlias: "user-group"},
+			}},
+			"group": {DisplayName: "Group", ExternalId: "OktaGroup", Domain: "identity", Attributes: []parser.Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true, AttributeAlias: "group-id"},
+				{Name: "appId", ExternalId: "appId", Type: "String", AttributeAlias: "group-app"},
+			}},
+			"application": {DisplayName: "Application", ExternalId: "Application", Domain: "apps", Attributes: []parser.Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true, AttributeAlias: "app-id"},
+			}},
+		},
+		Relationships: map[string]parser.Relationship{
+			"member": {DisplayName: "Member", FromAttribute: "user-group", ToAttribute: "group-id"},
+			"access": {DisplayName: "Access", FromAttribute: "group-app", ToAttribute: "app-id"},
+		},
+	}
+	draw := func(t *testing.T, file string, options Options) (string, string) {
+		path, err := GenerateERDiagramWithOptions(def, filepath.Join(t.TempDir(), file), options)
+		require.NoError(t, err)
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return path, string(content)
+	}
+
+	t.Run("layout and rank direction are in the DOT source", func(t *testing.T) {
+		path, dot := draw(t, "er.dot", Options{Layout: "neato", RankDir: "LR"})
+		assert.Equal(t, "er.dot", filepath.Base(path))
+		assert.Contains(t, dot, `layout="neato"`)
+		assert.Contains(t, dot, `rankdir="LR"`)
+	})
+
+	t.Run("dot format is written whatever the extension", func(t *testing.T) {
+		path, _ := draw(t, "er.svg", Options{Format: "dot"})
+		assert.Equal(t, "er.dot", filepath.Base(path))
+	})
+
+	t.Run("graphviz renders the format", func(t *testing.T) {
+		originalIsGraphvizAvailable := IsGraphvizAvailable
+		originalExecCommand := execCommand
+		defer func() { IsGraphvizAvailable, execCommand = originalIsGraphvizAvailable, originalExecCommand }()
+		IsGraphvizAvailable = func() bool { return true }
+		var args []string
+		execCommand = func(name string, arg ...string) *exec.Cmd {
+			args = append([]string{name}, arg...)
+			return exec.Command("true")
+		}
+
+		path, err := GenerateERDiagramWithOptions(def, filepath.Join(t.TempDir(), "er.png"), Options{})
+		require.NoError(t, err)
+		assert.Equal(t, "er.png", filepath.Base(path))
+		require.Len(t, args, 5)
+		assert.Equal(t, []string{"dot", "-Tpng"}, args[:2])
+	})
+
+	t.Run("include patterns draw only the matching entities", func(t *testing.T) {
+		_, dot := draw(t, "er.dot", Options{Include: []string{"Okta*"}})
+		assert.Contains(t, dot, `"user"`)
+		assert.Contains(t, dot, `"group"`)
+		assert.NotContains(t, dot, `"application"`)
+		assert.Contains(t, dot, `"group" -> "user"`, "relationships between drawn entities are kept")
+	})
+
+	t.Run("domains draw their entities", func(t *testing.T) {
+		_, dot := draw(t, "er.dot", Options{Domains: []string{"apps"}, Include: []string{"Group"}})
+		assert.Contains(t, dot, `"application"`)
+		assert.Contains(t, dot, `"group"`)
+		assert.NotContains(t, dot, `"user"`)
+	})
+
+	tests := []struct {
+		name    string
+		options Options
+		wantErr string
+	}{
+		{name: "unknown layout", options: Options{Layout: "circo"},
+			wantErr: "unknown layout 'circo' (expected one of dot, neato, fdp)"},
+		{name: "unknown rank direction", options: Options{RankDir: "UP"},
+			wantErr: "unknown rankdir 'UP' (expected one of TB, LR, BT, RL)"},
+		{name: "unknown format", options: Options{Format: "jpg"},
+			wantErr: "unknown format 'jpg' (expected one of svg, png, pdf, dot)"},
+		{name: "pattern matching nothing", options: Options{Include: []string{"Role*"}},
+			wantErr: "no entity matches 'Role*'"},
+		{name: "unknown domain", options: Options{Domains: []string{"hr"}},
+			wantErr: "no entity is in domain 'hr'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := GenerateERDiagramWithOptions(def, filepath.Join(t.TempDir(), "er.dot"), tt.options)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,290 @@
+// Package encryption encrypts output files as they are written, so synthetic
+// datasets never reach storage in the clear. Files are sealed with AES-256-GCM in
+// fixed-size chunks, each nonce holding the chunk's position and whether it is the
+// last, so chunks can't be reordered, dropped or truncated unnoticed. The key is
+// derived from a passphrase with PBKDF2-SHA256.
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+)
+
+// Encrypted file layout: magic | salt | nonce prefix | sealed chunks
+const (
+	Scheme    = "aes-256-gcm-stream" // Recorded in manifests
+	KDF       = "pbkdf2-sha256"      // Recorded in manifests
+	Extension = ".enc"               // Appended to encrypted files' names
+	ChunkSize = 64 * 1024            // Plaintext bytes per sealed chunk
+	SaltSize  = 16                   // Bytes of random salt each key is derived with
+
+	magic           = "FABENC1\n"
+	keySize         = 32
+	kdfIterations   = 600000
+	noncePrefixSize = 7 // Followed by a 4-byte chunk counter and a last-chunk flag
+	headerSize      = len(magic) + SaltSize + noncePrefixSize
+)
+
+// ErrNotEncrypted is returned for data that lacks the encrypted file header
+var ErrNotEncrypted = errors.New("data is not an encrypted fabricator file")
+
+// Spec is a parsed --encrypt value
+type Spec struct {
+	Scheme string // Only "aes" is supported
+	KeyEnv string // Environment variable holding the passphrase
+}
+
+// ParseSpec parses an encryption setting of the form aes:<env var>, naming the
+// environment variable that holds the passphrase
+func ParseSpec(value string) (*Spec, error) {
+	scheme, arg, ok := strings.Cut(value, ":")
+	switch {
+	case !ok || arg == "":
+		return nil, fmt.Errorf("invalid encryption '%s' (expected aes:<env var>)", value)
+	case scheme == "aes":
+		return &Spec{Scheme: scheme, KeyEnv: arg}, nil
+	case scheme == "age":
+		return nil, fmt.Errorf("age encryption is not supported; use aes:<env var> with a passphrase")
+	default:
+		return nil, fmt.Errorf("unsupported encryption scheme '%s' (supported: aes)", scheme)
+	}
+}
+
+// Encryptor encrypts files with a key derived once, so a run with many files
+// pays for the key derivation once
+type Encryptor struct {
+	salt []byte
+	aead cipher.AEAD
+}
+
+// NewEncryptor derives a key from passphrase with a random salt
+func NewEncryptor(passphrase string) (*Encryptor, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	aead, err := NewAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	return &Encryptor{salt: salt, aead: aead}, nil
+}
+
+// NewWriter writes the encrypted file header to w and returns a writer encrypting
+// to it. Close writes the last chunk; it doesn't close w.
+func (e *Encryptor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	header := make([]byte, 0, headerSize)
+	header = append(header, magic...)
+	header = append(header, e.salt...)
+	header = append(header, prefix...)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &writer{dst: w, aead: e.aead, prefix: prefix, buffer: make([]byte, 0, ChunkSize)}, nil
+}
+
+// writer buffers a chunk of plaintext and seals it once more data follows, so
+// the last chunk is only known, and flagged, when the writer is closed
+type writer struct {
+	dst     io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	buffer  []byte
+	sealed  []byte
+	closed  bool
+	err     error
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.closed {
+		return 0, errors.New("write to a closed encrypted file")
+	}
+	n := 0
+	for len(p) > 0 {
+		if len(w.buffer) == ChunkSize {
+			if err := w.seal(false); err != nil {
+				return n, err
+			}
+		}
+		take := min(ChunkSize-len(w.buffer), len(p))
+		w.buffer = append(w.buffer, p[:take]...)
+		p = p[take:]
+		n += take
+	}
+	return n, nil
+}
+
+func (w *writer) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	if w.err != nil {
+		return w.err
+	}
+	return w.seal(true)
+}
+
+// seal encrypts the buffered chunk to the destination
+func (w *writer) seal(last bool) error {
+	if w.counter == math.MaxUint32 {
+		w.err = errors.New("encrypted file is too large")
+		return w.err
+	}
+	w.sealed = w.aead.Seal(w.sealed[:0], chunkNonce(w.prefix, w.counter, last), w.buffer, []byte(magic))
+	if _, err := w.dst.Write(w.sealed); err != nil {
+		w.err = err
+		return err
+	}
+	w.counter++
+	w.buffer = w.buffer[:0]
+	return nil
+}
+
+// Decryptor decrypts files written by an Encryptor, deriving each salt's key once.
+// It is safe for concurrent use.
+type Decryptor struct {
+	passphrase string
+
+	mu   sync.Mutex
+	keys map[string]cipher.AEAD
+}
+
+// NewDecryptor creates a decryptor for files encrypted with passphrase
+func NewDecryptor(passphrase string) (*Decryptor, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("encryption passphrase must not be empty")
+	}
+	return &Decryptor{passphrase: passphrase, keys: make(map[string]cipher.AEAD)}, nil
+}
+
+// NewReader reads the encrypted file header from r and returns a reader of the
+// plaintext. Reads fail if the file was altered, truncated or encrypted with
+// another passphrase.
+func (d *Decryptor) NewReader(r io.Reader) (io.Reader, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.HasPrefix(header, []byte(magic)) {
+		return nil, ErrNotEncrypted
+	}
+	salt := header[len(magic) : len(magic)+SaltSize]
+
+	d.mu.Lock()
+	aead, ok := d.keys[string(salt)]
+	if !ok {
+		var err error
+		if aead, err = NewAEAD(d.passphrase, salt); err != nil {
+			d.mu.Unlock()
+			return nil, err
+		}
+		d.keys[string(salt)] = aead
+	}
+	d.mu.Unlock()
+	return &reader{
+		src:    bufio.NewReaderSize(r, ChunkSize+aead.Overhead()),
+		aead:   aead,
+		prefix: header[len(magic)+SaltSize:],
+		sealed: make([]byte, ChunkSize+aead.Overhead()),
+	}, nil
+}
+
+type reader struct {
+	src     *bufio.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	sealed  []byte
+	plain   []byte
+	pending []byte // Decrypted bytes not yet read
+	done    bool
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// next decrypts the following chunk; a short chunk, or a full one at the end of
+// the input, must be flagged as the last
+func (r *reader) next() error {
+	n, err := io.ReadFull(r.src, r.sealed)
+	last := false
+	switch {
+	case err == io.EOF:
+		return fmt.Errorf("encrypted file is truncated")
+	case err == io.ErrUnexpectedEOF:
+		last = true
+	case err != nil:
+		return err
+	default:
+		_, peekErr := r.src.Peek(1)
+		last = peekErr == io.EOF
+	}
+
+	plain, err := r.aead.Open(r.plain[:0], chunkNonce(r.prefix, r.counter, last), r.sealed[:n], []byte(magic))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt (wrong key or corrupted file)")
+	}
+	r.plain = plain
+	r.pending = plain
+	r.counter++
+	r.done = last
+	return nil
+}
+
+// chunkNonce builds a chunk's nonce: the file's prefix, the chunk counter and
+// whether it is the last chunk
+func chunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 0, noncePrefixSize+5)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	if last {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// NewAEAD derives the AES-256-GCM cipher for a passphrase and salt with
+// PBKDF2-SHA256. Encrypted data files and identity mappings share it.
+func NewAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("encryption passphrase must not be empty")
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, kdfIterations, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,103 @@
+package encryption
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	encryptor, err := NewEncryptor("correct horse")
+	require.NoError(t, err)
+	decryptor, err := NewDecryptor("correct horse")
+	require.NoError(t, err)
+
+	encrypt := func(t *testing.T, plaintext []byte) []byte {
+		t.Helper()
+		var out bytes.Buffer
+		writer, err := encryptor.NewWriter(&out)
+		require.NoError(t, err)
+		// Uneven writes cross chunk boundaries
+		for len(plaintext) > 0 {
+			n := min(1000, len(plaintext))
+			_, err := writer.Write(plaintext[:n])
+			require.NoError(t, err)
+			plaintext = plaintext[n:]
+		}
+		require.NoError(t, writer.Close())
+		return out.Bytes()
+	}
+	decrypt := func(d *Decryptor, data []byte) ([]byte, error) {
+		reader, err := d.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(reader)
+	}
+
+	for _, size := range []int{0, 1, ChunkSize, ChunkSize + 1, 3 * ChunkSize} {
+		plaintext := bytes.Repeat([]byte("id,name\n"), size/8+1)[:size]
+		data := encrypt(t, plaintext)
+		assert.NotContains(t, string(data), "id,name")
+
+		got, err := decrypt(decryptor, data)
+		require.NoError(t, err, "size %d", size)
+		assert.Equal(t, plaintext, got, "size %d", size)
+	}
+
+	data := encrypt(t, bytes.Repeat([]byte("x"), 2*ChunkSize+10))
+	t.Run("wrong passphrase", func(t *testing.T) {
+		other, err := NewDecryptor("battery staple")
+		require.NoError(t, err)
+		_, err = decrypt(other, data)
+		assert.ErrorContains(t, err, "wrong key or corrupted file")
+	})
+
+	t.Run("truncated at a chunk boundary", func(t *testing.T) {
+		sealedChunk := ChunkSize + 16
+		_, err := decrypt(decryptor, data[:headerSize+sealedChunk])
+		assert.ErrorContains(t, err, "wrong key or corrupted file")
+		_, err = decrypt(decryptor, data[:headerSize])
+		assert.ErrorContains(t, err, "truncated")
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		tampered := bytes.Clone(data)
+		tampered[headerSize+5] ^= 1
+		_, err := decrypt(decryptor, tampered)
+		assert.ErrorContains(t, err, "wrong key or corrupted file")
+	})
+
+	t.Run("not encrypted", func(t *testing.T) {
+		_, err := decrypt(decryptor, []byte("id,name\n1,Ada\n"))
+		assert.ErrorIs(t, err, ErrNotEncrypted)
+	})
+}
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    *Spec
+		wantErr string
+	}{
+		{value: "aes:FABRICATOR_KEY", want: &Spec{Scheme: "aes", KeyEnv: "FABRICATOR_KEY"}},
+		{value: "aes", wantErr: "expected aes:<env var>"},
+		{value: "aes:", wantErr: "expected aes:<env var>"},
+		{value: "age:age1qyqszqgpqyqszqgpqyqszqgp", wantErr: "age encryption is not supported"},
+		{value: "rot13:KEY", wantErr: "unsupported encryption scheme 'rot13'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			spec, err := ParseSpec(tt.value)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, spec)
+		})
+	}
+}
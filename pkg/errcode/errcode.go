@@ -0,0 +1,112 @@
+// Package errcode gives the errors of fabricator's packages stable codes, so callers
+// can branch on the kind of a failure, or report it, without matching its message.
+//
+// Each package declares its error kinds as sentinels made with New and matched with
+// errors.Is, e.g. parser.ErrRelationshipIssues. Of returns the code of an error.
+package errcode
+
+// Code identifies a kind of failure. Codes are stable across releases.
+type Code string
+
+// Failure codes
+const (
+	Unknown              Code = "unknown"                // Not one of the kinds below
+	FileUnreadable       Code = "file_unreadable"        // An input file couldn't be read
+	SchemaInvalid        Code = "schema_invalid"         // The SOR YAML doesn't match the schema
+	YAMLInvalid          Code = "yaml_invalid"           // The SOR YAML couldn't be parsed
+	DefinitionInvalid    Code = "definition_invalid"     // The SOR definition is inconsistent
+	RelationshipIssues   Code = "relationship_issues"    // Relationships reference unknown attributes
+	RelationshipInvalid  Code = "relationship_invalid"   // A relationship can't be built
+	RelationshipNotFound Code = "relationship_not_found" // A relationship lookup failed
+	EntityNotFound       Code = "entity_not_found"       // An entity lookup failed
+	CircularDependency   Code = "circular_dependency"    // Relationships form a required cycle
+	ReferencedKey        Code = "referenced_key"         // A referenced key was changed or removed
+	CountsUnsatisfiable  Code = "counts_unsatisfiable"   // Row counts can't satisfy relationships
+	GenerationFailed     Code = "generation_failed"      // Data generation failed
+	ValidationFailed     Code = "validation_failed"      // Validating existing data failed
+	OutputExists         Code = "output_exists"          // The output directory holds files the output mode doesn't allow
+	AssertionsFailed     Code = "assertions_failed"      // Generated data breaks an assertion of error severity
+	SchemaMismatch       Code = "schema_mismatch"        // The SOR YAML differs from the tenant's SOR schema
+)
+
+// Kind is a sentinel error with a code
+type Kind struct {
+	code    Code
+	message string
+}
+
+// New returns a sentinel error of the given code and message
+func New(code Code, message string) *Kind {
+	return &Kind{code: code, message: message}
+}
+
+// Error returns the kind's message
+func (k *Kind) Error() string {
+	return k.message
+}
+
+// Code returns the kind's code
+func (k *Kind) Code() Code {
+	return k.code
+}
+
+// Wrap marks err as being of kind without changing its message, so errors.Is(err,
+// kind) holds while err's own chain is kept. A nil err stays nil.
+func Wrap(kind *Kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+// kindError is an error marked by Wrap
+type kindError struct {
+	kind *Kind
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// Of returns the code of err: that of the innermost kind in its chain, as the most
+// specific, or Unknown when it has none. A nil err has no code.
+func Of(err error) Code {
+	if err == nil {
+		return ""
+	}
+	code, _ := innermost(err, 0)
+	if code == "" {
+		return Unknown
+	}
+	return code
+}
+
+// innermost returns the code of the deepest kind in err's chain and its depth
+func innermost(err error, depth int) (Code, int) {
+	var found Code
+	foundDepth := -1
+	if kind, ok := err.(*Kind); ok {
+		found, foundDepth = kind.code, depth
+	}
+
+	var children []error
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() error }:
+		if child := wrapped.Unwrap(); child != nil {
+			children = []error{child}
+		}
+	case interface{ Unwrap() []error }:
+		children = wrapped.Unwrap()
+	}
+	for _, child := range children {
+		if code, childDepth := innermost(child, depth+1); code != "" && childDepth > foundDepth {
+			found, foundDepth = code, childDepth
+		}
+	}
+	return found, foundDepth
+}
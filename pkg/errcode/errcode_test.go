@@ -0,0 +1,34 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOf(t *testing.T) {
+	outer := New(GenerationFailed, "data generation failed")
+	inner := New(EntityNotFound, "entity not found")
+	cause := errors.New("disk full")
+
+	assert.Equal(t, Code(""), Of(nil))
+	assert.Equal(t, Unknown, Of(cause))
+	assert.Equal(t, EntityNotFound, Of(inner))
+	assert.Equal(t, GenerationFailed, Of(fmt.Errorf("%w: %w", outer, cause)))
+	assert.Equal(t, EntityNotFound, Of(fmt.Errorf("%w: %w", outer, fmt.Errorf("lookup: %w", inner))),
+		"the innermost kind is the most specific")
+}
+
+func TestWrap(t *testing.T) {
+	kind := New(DefinitionInvalid, "invalid definition")
+	cause := errors.New("entity user has no attributes")
+
+	err := Wrap(kind, cause)
+	assert.Equal(t, cause.Error(), err.Error(), "the message is unchanged")
+	assert.ErrorIs(t, err, kind)
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, DefinitionInvalid, Of(err))
+	assert.NoError(t, Wrap(kind, nil))
+}
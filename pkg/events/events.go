@@ -0,0 +1,142 @@
+package events
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Type identifies the kind of event emitted during a run
+type Type string
+
+// Event types emitted by the orchestrator and pipeline
+const (
+	PhaseStarted    Type = "phase_started"
+	PhaseFinished   Type = "phase_finished"
+	EntityGenerated Type = "entity_generated"
+	RowsWritten     Type = "rows_written"
+	Warning         Type = "warning"
+
+	RelationshipLinked Type = "relationship_linked"
+	ValidationIssue    Type = "validation_issue"
+)
+
+// Event is a single observation about the progress of a run
+type Event struct {
+	Type         Type      `json:"type"`
+	Timestamp    time.Time `json:"timestamp"`
+	Phase        string    `json:"phase,omitempty"`
+	Entity       string    `json:"entity,omitempty"`
+	Relationship string    `json:"relationship,omitempty"`
+	Rows         int       `json:"rows,omitempty"`
+	DurationMs   int64     `json:"durationMs,omitempty"`
+	Message      string    `json:"message,omitempty"`
+}
+
+// Sink receives events. Implementations must be safe for sequential use;
+// the Emitter serializes calls to Emit.
+type Sink interface {
+	Emit(event Event) error
+	Close() error
+}
+
+// Emitter fans events out to a set of sinks.
+// A nil *Emitter is valid and discards all events, so callers never need to
+// check whether observability was configured.
+type Emitter struct {
+	mu    sync.Mutex
+	sinks []Sink
+	errs  []error
+}
+
+// NewEmitter creates an emitter that forwards events to the given sinks
+func NewEmitter(sinks ...Sink) *Emitter {
+	return &Emitter{sinks: sinks}
+}
+
+// Emit forwards an event to every sink, stamping it with the current time if unset.
+// Sink failures don't interrupt the run; they are reported by Close.
+func (e *Emitter) Emit(event Event) {
+	if e == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, sink := range e.sinks {
+		if err := sink.Emit(event); err != nil {
+			e.errs = append(e.errs, err)
+		}
+	}
+}
+
+// PhaseStarted emits a phase start event and returns the start time for PhaseFinished
+func (e *Emitter) PhaseStarted(phase string) time.Time {
+	started := time.Now()
+	e.Emit(Event{Type: PhaseStarted, Timestamp: started, Phase: phase})
+	return started
+}
+
+// PhaseFinished emits a phase completion event with the elapsed time since started
+func (e *Emitter) PhaseFinished(phase string, started time.Time) {
+	e.Emit(Event{Type: PhaseFinished, Phase: phase, DurationMs: time.Since(started).Milliseconds()})
+}
+
+// EntityGenerated emits an event recording the rows generated for an entity
+func (e *Emitter) EntityGenerated(entity string, rows int) {
+	e.Emit(Event{Type: EntityGenerated, Entity: entity, Rows: rows})
+}
+
+// RowsWritten emits an event recording the rows written for an entity
+func (e *Emitter) RowsWritten(entity string, rows int) {
+	e.Emit(Event{Type: RowsWritten, Entity: entity, Rows: rows})
+}
+
+// EntityWritten emits a RowsWritten event for an entity that also records how
+// long writing its rows took
+func (e *Emitter) EntityWritten(entity string, rows int, elapsed time.Duration) {
+	e.Emit(Event{Type: RowsWritten, Entity: entity, Rows: rows, DurationMs: elapsed.Milliseconds()})
+}
+
+// RelationshipLinked emits an event recording the foreign key values assigned for a relationship
+func (e *Emitter) RelationshipLinked(relationship, sourceEntity string, assigned int) {
+	e.Emit(Event{Type: RelationshipLinked, Relationship: relationship, Entity: sourceEntity, Rows: assigned})
+}
+
+// ValidationIssue emits an event for a single validation finding
+func (e *Emitter) ValidationIssue(message string) {
+	e.Emit(Event{Type: ValidationIssue, Message: message})
+}
+
+// Enabled reports whether events are being delivered anywhere, so callers can
+// skip computing data that only feeds events
+func (e *Emitter) Enabled() bool {
+	return e != nil && len(e.sinks) > 0
+}
+
+// Warning emits a non-fatal warning event
+func (e *Emitter) Warning(message string) {
+	e.Emit(Event{Type: Warning, Message: message})
+}
+
+// Close closes every sink and returns any errors raised while emitting or closing
+func (e *Emitter) Close() error {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	errs := e.errs
+	for _, sink := range e.sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	e.sinks = nil
+	e.errs = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,155 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink captures events for assertions
+type recordingSink struct {
+	events   []Event
+	emitErr  error
+	closeErr error
+	closed   bool
+}
+
+func (s *recordingSink) Emit(event Event) error {
+	s.events = append(s.events, event)
+	return s.emitErr
+}
+
+func (s *recordingSink) Close() error {
+	s.closed = true
+	return s.closeErr
+}
+
+func TestEmitter(t *testing.T) {
+	t.Run("nil emitter discards events", func(t *testing.T) {
+		var emitter *Emitter
+		started := emitter.PhaseStarted("ids")
+		emitter.PhaseFinished("ids", started)
+		emitter.Warning("ignored")
+		assert.NoError(t, emitter.Close())
+	})
+
+	t.Run("fans out to all sinks", func(t *testing.T) {
+		first, second := &recordingSink{}, &recordingSink{}
+		emitter := NewEmitter(first, second)
+
+		started := emitter.PhaseStarted("write")
+		emitter.RowsWritten("User", 10)
+		emitter.PhaseFinished("write", started)
+		require.NoError(t, emitter.Close())
+
+		for _, sink := range []*recordingSink{first, second} {
+			require.Len(t, sink.events, 3)
+			assert.Equal(t, PhaseStarted, sink.events[0].Type)
+			assert.Equal(t, RowsWritten, sink.events[1].Type)
+			assert.Equal(t, "User", sink.events[1].Entity)
+			assert.Equal(t, 10, sink.events[1].Rows)
+			assert.Equal(t, PhaseFinished, sink.events[2].Type)
+			assert.False(t, sink.events[1].Timestamp.IsZero())
+			assert.True(t, sink.closed)
+		}
+	})
+
+	t.Run("reports sink errors on close", func(t *testing.T) {
+		failing := &recordingSink{emitErr: errors.New("emit failed"), closeErr: errors.New("close failed")}
+		emitter := NewEmitter(failing)
+		emitter.Warning("something odd")
+
+		err := emitter.Close()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "emit failed")
+		assert.Contains(t, err.Error(), "close failed")
+	})
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+
+	require.NoError(t, sink.Emit(Event{Type: PhaseFinished, Phase: "fields", DurationMs: 42}))
+	require.NoError(t, sink.Emit(Event{Type: EntityGenerated, Entity: "Group", Rows: 5}))
+	require.NoError(t, sink.Emit(Event{Type: Warning, Message: "imbalance"}))
+
+	assert.Equal(t,
+		"[event] phase_finished phase=fields duration=42ms\n"+
+			"[event] entity_generated entity=Group rows=5\n"+
+			"[event] warning message=\"imbalance\"\n",
+		buf.String())
+}
+
+func TestParseSinks(t *testing.T) {
+	tests := []struct {
+		name      string
+		specs     string
+		wantCount int
+		wantErr   bool
+	}{
+		{name: "empty spec", specs: "", wantCount: 0},
+		{name: "stdout", specs: "stdout", wantCount: 1},
+		{name: "jsonl with path", specs: "stdout, jsonl:" + filepath.Join(os.TempDir(), "fabricator-events-test.jsonl"), wantCount: 2},
+		{name: "jsonl without path", specs: "jsonl", wantErr: true},
+		{name: "unknown sink", specs: "kafka:topic", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sinks, err := ParseSinks(tt.specs)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, sinks, tt.wantCount)
+			closeAll(sinks)
+		})
+	}
+
+	t.Run("registered sinks are available", func(t *testing.T) {
+		RegisterSink("recording", func(string) (Sink, error) { return &recordingSink{}, nil })
+		sinks, err := ParseSinks("recording")
+		require.NoError(t, err)
+		require.Len(t, sinks, 1)
+		assert.IsType(t, &recordingSink{}, sinks[0])
+	})
+}
+
+func TestJSONLinesFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sinks, err := ParseSinks("jsonl:" + path)
+	require.NoError(t, err)
+
+	emitter := NewEmitter(sinks...)
+	emitter.Emit(Event{Type: PhaseStarted, Phase: "ids", Timestamp: time.Unix(0, 0).UTC()})
+	emitter.EntityGenerated("User", 3)
+	require.NoError(t, emitter.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	var decoded []Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		decoded = append(decoded, event)
+	}
+
+	require.Len(t, decoded, 2)
+	assert.Equal(t, PhaseStarted, decoded[0].Type)
+	assert.Equal(t, "ids", decoded[0].Phase)
+	assert.Equal(t, EntityGenerated, decoded[1].Type)
+	assert.Equal(t, 3, decoded[1].Rows)
+}
@@ -0,0 +1,168 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SinkFactory creates a sink from the argument part of a sink spec ("name:arg")
+type SinkFactory func(arg string) (Sink, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]SinkFactory{
+		"stdout": func(string) (Sink, error) { return NewWriterSink(os.Stdout), nil },
+		"jsonl":  newJSONLinesFileSink,
+	}
+)
+
+// RegisterSink makes a sink available to ParseSinks under the given name.
+// Registering an existing name replaces its factory.
+func RegisterSink(name string, factory SinkFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+// ParseSinks builds sinks from a comma-separated list of specs such as
+// "stdout,jsonl:events.jsonl". Sinks created before a failure are closed.
+func ParseSinks(specs string) ([]Sink, error) {
+	var sinks []Sink
+	for _, spec := range strings.Split(specs, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		name, arg, _ := strings.Cut(spec, ":")
+		registryMu.RLock()
+		factory, exists := registry[name]
+		registryMu.RUnlock()
+		if !exists {
+			closeAll(sinks)
+			return nil, fmt.Errorf("unknown event sink '%s' (available: %s)", name, strings.Join(sinkNames(), ", "))
+		}
+
+		sink, err := factory(arg)
+		if err != nil {
+			closeAll(sinks)
+			return nil, fmt.Errorf("failed to create event sink '%s': %w", spec, err)
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+// sinkNames returns the registered sink names in sorted order
+func sinkNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func closeAll(sinks []Sink) {
+	for _, sink := range sinks {
+		_ = sink.Close()
+	}
+}
+
+// WriterSink writes events as human-readable key=value lines
+type WriterSink struct {
+	w io.Writer
+}
+
+// NewWriterSink creates a sink that writes readable event lines to w
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Emit writes a single event line
+func (s *WriterSink) Emit(event Event) error {
+	var line strings.Builder
+	line.WriteString("[event] ")
+	line.WriteString(string(event.Type))
+	if event.Phase != "" {
+		fmt.Fprintf(&line, " phase=%s", event.Phase)
+	}
+	if event.Relationship != "" {
+		fmt.Fprintf(&line, " relationship=%s", event.Relationship)
+	}
+	if event.Entity != "" {
+		fmt.Fprintf(&line, " entity=%s", event.Entity)
+	}
+	if event.Rows > 0 {
+		fmt.Fprintf(&line, " rows=%d", event.Rows)
+	}
+	if event.Type == PhaseFinished || event.DurationMs > 0 {
+		fmt.Fprintf(&line, " duration=%dms", event.DurationMs)
+	}
+	if event.Message != "" {
+		fmt.Fprintf(&line, " message=%q", event.Message)
+	}
+	line.WriteString("\n")
+
+	_, err := io.WriteString(s.w, line.String())
+	return err
+}
+
+// Close is a no-op; the underlying writer is owned by the caller
+func (s *WriterSink) Close() error {
+	return nil
+}
+
+// JSONLinesSink writes one JSON object per event
+type JSONLinesSink struct {
+	w       *bufio.Writer
+	closer  io.Closer
+	encoder *json.Encoder
+}
+
+// NewJSONLinesSink creates a sink that writes JSON lines to w.
+// If w implements io.Closer it is closed when the sink is closed.
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	buffered := bufio.NewWriter(w)
+	sink := &JSONLinesSink{w: buffered, encoder: json.NewEncoder(buffered)}
+	if closer, ok := w.(io.Closer); ok {
+		sink.closer = closer
+	}
+	return sink
+}
+
+// newJSONLinesFileSink creates a JSON lines sink writing to the file at path
+func newJSONLinesFileSink(path string) (Sink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("jsonl sink requires a file path (jsonl:<path>)")
+	}
+	file, err := os.Create(path) // #nosec G304 - path is from CLI argument
+	if err != nil {
+		return nil, fmt.Errorf("failed to create events file: %w", err)
+	}
+	return NewJSONLinesSink(file), nil
+}
+
+// Emit writes the event as a single JSON line
+func (s *JSONLinesSink) Emit(event Event) error {
+	return s.encoder.Encode(event)
+}
+
+// Close flushes buffered events and closes the underlying writer if owned
+func (s *JSONLinesSink) Close() error {
+	if err := s.w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush events: %w", err)
+	}
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+	return nil
+}
@@ -1,13 +1,14 @@
 package fabricator
 
 import (
+	"github.com/SGNL-ai/fabricator/pkg/console"
 	"github.com/SGNL-ai/fabricator/pkg/generators/model"
 	"github.com/fatih/color"
 )
 
 // PrintGraphStatistics displays detailed statistics about the parsed graph
 func PrintGraphStatistics(stats *model.GraphStatistics) {
-	color.Green("✓ Successfully parsed YAML definition")
+	color.Green(console.Text("✓ Successfully parsed YAML definition"))
 	color.Green("  SOR name: %s", stats.SORName)
 	color.Green("  Description: %s", stats.Description)
 
@@ -1,5 +1,7 @@
 package model
 
+import "github.com/SGNL-ai/fabricator/pkg/parser"
+
 // Attribute represents an entity attribute and its properties
 type Attribute struct {
 	name           string
@@ -12,6 +14,16 @@ type Attribute struct {
 	relatedEntity  string
 	relatedAttr    string
 	attributeAlias string
+	generator      *parser.Generator    // Optional built-in value generator from the YAML
+	uniqueWithin   string               // Name of the attribute scoping this attribute's uniqueness
+	constValue     *string              // Value every row gets, or nil if none was set
+	defaultValue   *string              // Value used when no generator or name inference applies, or nil
+	listEncoding   string               // How a list attribute's values are written; empty for single values
+	sensitive      bool                 // Masked or hashed in the redacted copy of the output
+	lookup         *parser.Lookup       // Attribute copied from the row a foreign key references, or nil
+	transform      *parser.Transform    // Rewrites the values as they are written, or nil
+	sharedValues   *parser.SharedValues // Attribute of another entity part of the values come from, or nil
+	timeZone       *parser.TimeZone     // Zone DateTime values are written in, or nil
 }
 
 // newAttribute creates a new attribute with the specified properties
@@ -48,6 +60,65 @@ func (a *Attribute) GetDataType() string {
 	return a.dataType
 }
 
+// GetGenerator returns the attribute's built-in generator hint, or nil if none was set
+func (a *Attribute) GetGenerator() *parser.Generator {
+	return a.generator
+}
+
+// GetUniqueWithin returns the name of the attribute whose value scopes this
+// attribute's uniqueness, or an empty string if it has no uniqueness scope
+func (a *Attribute) GetUniqueWithin() string {
+	return a.uniqueWithin
+}
+
+// GetConst returns the value every row gets, or nil if the attribute has no const
+func (a *Attribute) GetConst() *string {
+	return a.constValue
+}
+
+// GetDefault returns the value used when neither a generator hint nor the attribute
+// name selects a generator, or nil if the attribute has no default
+func (a *Attribute) GetDefault() *string {
+	return a.defaultValue
+}
+
+// GetListEncoding returns how the attribute's list of values is written
+// (parser.ListEncodingJSON, ListEncodingSemicolon or ListEncodingRows), or an empty
+// string if the attribute holds a single value
+func (a *Attribute) GetListEncoding() string {
+	return a.listEncoding
+}
+
+// IsSensitive returns whether the attribute's values are masked or hashed in the
+// redacted copy of the output
+func (a *Attribute) IsSensitive() bool {
+	return a.sensitive
+}
+
+// GetLookup returns the foreign key and referenced attribute the attribute copies
+// its value from, or nil if the attribute isn't a lookup
+func (a *Attribute) GetLookup() *parser.Lookup {
+	return a.lookup
+}
+
+// GetTransform returns how the attribute's values are rewritten as they are
+// written, or nil if they are written as generated
+func (a *Attribute) GetTransform() *parser.Transform {
+	return a.transform
+}
+
+// GetSharedValues returns the attribute of another entity a share of the
+// attribute's values is taken from, or nil if its values are all its own
+func (a *Attribute) GetSharedValues() *parser.SharedValues {
+	return a.sharedValues
+}
+
+// GetTimeZone returns the zone the attribute's DateTime values are written in, or
+// nil if they are written as generated
+func (a *Attribute) GetTimeZone() *parser.TimeZone {
+	return a.timeZone
+}
+
 // IsUnique returns whether attribute requires unique values
 func (a *Attribute) IsUnique() bool {
 	return a.isUnique
@@ -3,16 +3,16 @@ package model
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
 )
 
 // ErrSkipRow signals ForEachRow to skip re-adding the current row
 // Used to efficiently remove duplicate rows without expensive RemoveRow calls
 var ErrSkipRow = errors.New("skip row - do not re-add")
 
-// TODO make interface
-// Row represents a single row of data for an entity
-
 // Entity represents a data entity and manages its attributes and row data
 type Entity struct {
 	id                string
@@ -22,11 +22,24 @@ type Entity struct {
 	attributes        map[string]AttributeInterface // Map attribute name to attribute object
 	attributesByExtID map[string]AttributeInterface // Map attribute external ID to attribute object
 	attrList          []AttributeInterface          // Ordered list of attributes
-	rows              []*Row
+	rows              []*Row                        // Row handles in order; values live in store
+	store             *columnStore                  // Row values, one column per attribute
 	primaryKey        AttributeInterface
-	graph             GraphInterface  // Reference to parent graph for lookups
-	usedPKValues      map[string]bool // Track used primary key values for O(1) duplicate detection
-	usedCompositeKeys map[string]bool // Track used composite FK keys for junction table duplicate prevention
+	graph             GraphInterface       // Reference to parent graph for lookups
+	usedPKValues      map[string]bool      // Track used primary key values for O(1) duplicate detection
+	usedCompositeKeys map[string]bool      // Track used composite FK keys for junction table duplicate prevention
+	normalize         ValueNormalizer      // Form of values the key indexes compare, or nil to compare them as they are
+	correlations      []parser.Correlation // Target correlations between numeric attributes
+	domain            string               // Logical group the entity belongs to, if any
+	timeline          *parser.Timeline     // Clustering of the creation timestamp, if declared
+	effectiveDating   *parser.EffectiveDating // How the rows are dated as versions of records, if declared
+	inlineData        []map[string]string  // Rows embedded in the SOR, used instead of generated ones
+	payloads          *parser.Payloads     // Whether generated payloads must differ or repeat, if declared
+	junction          *parser.Junction     // How the foreign keys in pairAttributes pair up, if declared
+	pairAttributes    map[string]string    // Foreign keys referencing the same attribute, to that attribute
+	derived           *parser.Derived      // How the rows are computed from other entities, if declared
+	partitionBy       string               // Name of the attribute splitting the output into a file per value, if any
+	profile           *parser.Profile      // Statistics of a real table the generated columns follow, if declared
 }
 
 // newEntity creates a new entity with basic properties and attributes
@@ -58,6 +71,7 @@ func newEntity(id, externalID, name string, description string, attributes []Att
 	}
 
 	// Add attributes to entity
+	fields := make([]string, 0, len(attributes))
 	for _, attr := range attributes {
 		if attr != nil {
 			// Set parent entity reference
@@ -75,8 +89,10 @@ func newEntity(id, externalID, name string, description string, attributes []Att
 				entity.attributesByExtID[attr.GetAttributeAlias()] = attr
 			}
 			entity.attrList = append(entity.attrList, attr)
+			fields = append(fields, attr.GetName())
 		}
 	}
+	entity.store = newColumnStore(fields, expectedRows)
 
 	// Validate the entity
 	if err := entity.validate(); err != nil {
@@ -224,6 +240,57 @@ func (e *Entity) GetNonRelationshipAttributes() []AttributeInterface {
 	return result
 }
 
+// GetDomain returns the logical group the entity belongs to, or an empty string
+func (e *Entity) GetDomain() string {
+	return e.domain
+}
+
+// GetTimeline returns the declared clustering of the creation timestamp, or nil
+func (e *Entity) GetTimeline() *parser.Timeline {
+	return e.timeline
+}
+
+// GetEffectiveDating returns how the rows are dated as versions of records, or nil
+func (e *Entity) GetEffectiveDating() *parser.EffectiveDating {
+	return e.effectiveDating
+}
+
+// GetInlineData returns the rows the SOR embeds for the entity, or nil
+func (e *Entity) GetInlineData() []map[string]string {
+	return e.inlineData
+}
+
+// GetPayloads returns whether rows' generated payloads must differ or repeat, or nil
+func (e *Entity) GetPayloads() *parser.Payloads {
+	return e.payloads
+}
+
+// GetJunction returns how the entity's self-join foreign keys pair up, or nil
+func (e *Entity) GetJunction() *parser.Junction {
+	return e.junction
+}
+
+// GetDerived returns how the entity's rows are computed from other entities, or nil
+func (e *Entity) GetDerived() *parser.Derived {
+	return e.derived
+}
+
+// GetPartitionBy returns the name of the attribute splitting the entity's output
+// into a file per value, or "" when it is written to a single file
+func (e *Entity) GetPartitionBy() string {
+	return e.partitionBy
+}
+
+// GetProfile returns the statistical profile the entity's generated columns follow, or nil
+func (e *Entity) GetProfile() *parser.Profile {
+	return e.profile
+}
+
+// GetCorrelations returns the declared correlations between numeric attributes
+func (e *Entity) GetCorrelations() []parser.Correlation {
+	return e.correlations
+}
+
 // GetRowCount returns the number of rows
 func (e *Entity) GetRowCount() int {
 	return len(e.rows)
@@ -238,14 +305,15 @@ func (e *Entity) AddRow(row *Row) error {
 		return err
 	}
 
-	// Add row to entity
+	// Move the row's values into the entity's columns
+	row.detach()
+	row.attach(e.store)
 	e.rows = append(e.rows, row)
 
 	// Track the primary key value in our hash map for future duplicate detection
 	if e.primaryKey != nil {
-		pkName := e.primaryKey.GetName()
-		if pkValue, exists := row.values[pkName]; exists && pkValue != "" {
-			e.usedPKValues[pkValue] = true
+		if pkValue := row.GetValue(e.primaryKey.GetName()); pkValue != "" {
+			e.usedPKValues[e.keyOf(pkValue)] = true
 		}
 	}
 
@@ -255,18 +323,94 @@ func (e *Entity) AddRow(row *Row) error {
 	return nil
 }
 
+// RowError is the error AddRows returns for the row of a batch that couldn't be added
+type RowError struct {
+	Index int // Position of the row in the batch
+	Err   error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Index, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// AddRows adds a batch of rows as AddRow would one at a time, stopping at the first
+// invalid row with a *RowError and keeping the rows before it. The rows, primary
+// key index and columns are grown once for the whole batch, and the attributes
+// making up the composite key are looked up once.
+func (e *Entity) AddRows(rows []*Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	e.growRows(len(rows))
+
+	var pkName string
+	if e.primaryKey != nil {
+		pkName = e.primaryKey.GetName()
+	}
+	fkAttributes := e.GetRelationshipAttributes()
+
+	for i, row := range rows {
+		if err := e.validateRow(row); err != nil {
+			return &RowError{Index: i, Err: err}
+		}
+
+		row.detach()
+		row.attach(e.store)
+		e.rows = append(e.rows, row)
+
+		if pkName != "" {
+			if pkValue := row.GetValue(pkName); pkValue != "" {
+				e.usedPKValues[e.keyOf(pkValue)] = true
+			}
+		}
+		if len(fkAttributes) > 0 {
+			if compositeKey := e.buildCompositeKey(row, fkAttributes); compositeKey != "" {
+				e.usedCompositeKeys[compositeKey] = true
+			}
+		}
+	}
+
+	return nil
+}
+
+// growRows makes room for n more rows. The primary key index can only be sized
+// when it's created, so it is only pre-grown while empty.
+func (e *Entity) growRows(n int) {
+	needed := len(e.rows) + n
+	e.rows = slices.Grow(e.rows, n)
+	if len(e.usedPKValues) == 0 {
+		e.usedPKValues = make(map[string]bool, needed)
+	}
+	e.store.reserve(needed)
+}
+
 // ForEachRow iterates over all rows and allows in-place modification
 // Validates PK uniqueness only if PK value changes (performance optimization)
 // Returns ErrSkipRow from callback to skip including a row in the result
+// Rows of other entities referencing a changed or removed PK are left as they are
 func (e *Entity) ForEachRow(fn func(row *Row, index int) error) error {
+	return e.ForEachRowWithPolicy(ReferencesIgnore, fn)
+}
+
+// ForEachRowWithPolicy is ForEachRow with control, through policy, over the rows of
+// other entities referencing a PK the callback changes or removes
+func (e *Entity) ForEachRowWithPolicy(policy ReferencePolicy, fn func(row *Row, index int) error) error {
 	var originalPKValue string // Reuse - no allocations per iteration
 	var newPKValue string       // Reuse - no allocations per iteration
 
 	pk := e.GetPrimaryKey()
 	pkName := pk.GetName()
 
-	// Build new slice of rows to keep (pre-allocate with original capacity)
-	newRows := make([]*Row, 0, len(e.rows))
+	// Rows keep their positions in the store until the loop ends
+	keep := make([]bool, len(e.rows))
+	skipped := false
+
+	// Changed and removed PKs, for the references to them
+	changes := newKeyChanges(e, policy)
 
 	for i, row := range e.rows {
 		// Capture original PK value
@@ -277,8 +421,12 @@ func (e *Entity) ForEachRow(fn func(row *Row, index int) error) error {
 
 		// Check if callback signals to skip this row
 		if errors.Is(err, ErrSkipRow) {
-			// Don't add to newRows - effectively removes this row
-			delete(e.usedPKValues, originalPKValue)  // Clean up PK tracking
+			// Leave out of keep - effectively removes this row
+			if err := changes.record(originalPKValue, ""); err != nil {
+				return fmt.Errorf("error processing row %d in entity %s: %w", i, e.name, err)
+			}
+			delete(e.usedPKValues, e.keyOf(originalPKValue)) // Clean up PK tracking
+			skipped = true
 			continue
 		}
 
@@ -294,20 +442,44 @@ func (e *Entity) ForEachRow(fn func(row *Row, index int) error) error {
 			if e.CheckKeyExists(newPKValue) {
 				return fmt.Errorf("duplicate value '%s' for unique attribute '%s'", newPKValue, pkName)
 			}
+			if err := changes.record(originalPKValue, newPKValue); err != nil {
+				return fmt.Errorf("error processing row %d in entity %s: %w", i, e.name, err)
+			}
 
 			// Validation passed - update PK tracking
-			delete(e.usedPKValues, originalPKValue)
-			e.usedPKValues[newPKValue] = true
+			delete(e.usedPKValues, e.keyOf(originalPKValue))
+			e.usedPKValues[e.keyOf(newPKValue)] = true
 		}
 
-		// Row validated - add to new slice
-		newRows = append(newRows, row)
+		// Row validated - keep it
+		keep[i] = true
 	}
 
-	// Replace entire row slice with filtered results
-	e.rows = newRows
+	if skipped {
+		e.keepRows(keep)
+	}
 
-	return nil
+	return changes.cascade()
+}
+
+// keepRows drops the rows whose keep flag is unset, detaching them so callers
+// holding them can still read their values
+func (e *Entity) keepRows(keep []bool) {
+	kept := 0
+	for i, row := range e.rows {
+		if !keep[i] {
+			row.detach()
+			continue
+		}
+		row.index = kept
+		e.rows[kept] = row
+		kept++
+	}
+	for i := kept; i < len(e.rows); i++ {
+		e.rows[i] = nil
+	}
+	e.rows = e.rows[:kept]
+	e.store.keep(keep)
 }
 
 // ToCSV returns CSV representation of the entity
@@ -324,7 +496,7 @@ func (e *Entity) ToCSV() *CSVData {
 		csvRow := make([]string, 0, len(headers))
 		for _, attr := range e.attrList {
 			// Use attribute name to look up value in row (rows are keyed by name)
-			csvRow = append(csvRow, row.values[attr.GetName()])
+			csvRow = append(csvRow, row.GetValue(attr.GetName()))
 		}
 		csvRows = append(csvRows, csvRow)
 	}
@@ -349,15 +521,15 @@ func (e *Entity) validateRow(row *Row) error {
 	// Check that all required values are provided
 	if e.primaryKey != nil {
 		pkName := e.primaryKey.GetName()
-		pkValue, exists := row.values[pkName]
+		pkValue := row.GetValue(pkName)
 
 		// Primary key is required
-		if !exists || pkValue == "" {
+		if pkValue == "" {
 			return fmt.Errorf("missing required primary key value for attribute '%s'", pkName)
 		}
 
 		// Check uniqueness constraint using O(1) hash map lookup
-		if e.usedPKValues[pkValue] {
+		if e.usedPKValues[e.keyOf(pkValue)] {
 			return fmt.Errorf("duplicate value '%s' for unique attribute '%s'", pkValue, pkName)
 		}
 	}
@@ -370,8 +542,9 @@ func (e *Entity) preAllocateRows(expectedRowCount int) {
 	if cap(e.rows) < expectedRowCount {
 		// Pre-allocate with exact capacity to avoid slice growth
 		e.rows = make([]*Row, 0, expectedRowCount)
-		// Also pre-allocate the PK hash map with expected size
+		// Also pre-allocate the PK hash map and columns with expected size
 		e.usedPKValues = make(map[string]bool, expectedRowCount)
+		e.store.reserve(expectedRowCount)
 	}
 }
 
@@ -387,7 +560,7 @@ func (e *Entity) RemoveRow(rowIndex int) error {
 	if e.primaryKey != nil {
 		pkName := e.primaryKey.GetName()
 		if pkValue := row.GetValue(pkName); pkValue != "" {
-			delete(e.usedPKValues, pkValue)
+			delete(e.usedPKValues, e.keyOf(pkValue))
 		}
 	}
 
@@ -398,8 +571,12 @@ func (e *Entity) RemoveRow(rowIndex int) error {
 		delete(e.usedCompositeKeys, compositeKey)
 	}
 
-	// Remove row from slice
-	e.rows = append(e.rows[:rowIndex], e.rows[rowIndex+1:]...)
+	// Remove row from slice and its values from the columns
+	keep := make([]bool, len(e.rows))
+	for i := range keep {
+		keep[i] = i != rowIndex
+	}
+	e.keepRows(keep)
 
 	return nil
 }
@@ -414,7 +591,7 @@ func (e *Entity) GetRowByIndex(index int) *Row {
 
 // CheckKeyExists checks if a key value exists in the used primary key values (O(1) lookup)
 func (e *Entity) CheckKeyExists(keyValue string) bool {
-	return e.usedPKValues[keyValue]
+	return e.usedPKValues[e.keyOf(keyValue)]
 }
 
 // IsForeignKeyUnique checks if a row's FK combination is unique
@@ -467,12 +644,15 @@ func (e *Entity) getCompositeKey(row *Row) string {
 
 // buildCompositeKey creates a composite key string from FK attribute values
 func (e *Entity) buildCompositeKey(row *Row, fkAttributes []AttributeInterface) string {
+	if e.junction != nil && e.junction.Unordered {
+		return e.unorderedCompositeKey(row, fkAttributes)
+	}
 	compositeKey := ""
 	for i, fkAttr := range fkAttributes {
 		if i > 0 {
 			compositeKey += "|"
 		}
-		compositeKey += row.GetValue(fkAttr.GetName())
+		compositeKey += e.comparable(fkAttr, row.GetValue(fkAttr.GetName()))
 	}
 	return compositeKey
 }
@@ -483,15 +663,20 @@ func (e *Entity) ValidateAllForeignKeys() []string {
 
 	// Check all relationship attributes, including unique ones
 	for _, attr := range e.GetRelationshipAttributes() {
-		attrName := attr.GetName()
-
-		// Check each row's FK value
-		for i, row := range e.rows {
-			value, exists := row.values[attrName]
-			if exists && value != "" {
-				if err := e.validateForeignKeyValue(attrName, value); err != nil {
-					errors = append(errors, fmt.Sprintf("row %d: %v", i, err))
-				}
+		errors = append(errors, e.ValidateForeignKeys(attr.GetName())...)
+	}
+
+	return errors
+}
+
+// ValidateForeignKeys validates every row's value for a single FK attribute
+func (e *Entity) ValidateForeignKeys(attributeName string) []string {
+	var errors []string
+
+	for i, row := range e.rows {
+		if value := row.GetValue(attributeName); value != "" {
+			if err := e.validateForeignKeyValue(attributeName, value); err != nil {
+				errors = append(errors, fmt.Sprintf("row %d: %v", i, err))
 			}
 		}
 	}
@@ -541,8 +726,9 @@ func (e *Entity) validateForeignKeyValue(attributeName string, value string) err
 	} else {
 		// For non-unique attributes, fall back to linear search (rare case)
 		valueFound := false
+		wanted := e.comparable(relatedAttr, value)
 		for _, row := range relatedEntity.getRows() {
-			if attrValue, exists := row.values[relatedAttributeName]; exists && attrValue == value {
+			if e.comparable(relatedAttr, row.GetValue(relatedAttributeName)) == wanted {
 				valueFound = true
 				break
 			}
@@ -1584,3 +1584,95 @@ func TestEntity_AddRelationship_ErrorCases(t *testing.T) {
 		assert.Contains(t, err.Error(), "target attribute")
 	})
 }
+
+func TestEntity_AddRows(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	newTestEntity := func(t *testing.T) EntityInterface {
+		mockGraph := NewMockGraphInterface(ctrl)
+		mockGraph.EXPECT().GetExpectedDataVolume().Return(100).AnyTimes()
+		entity, err := newEntity("test_entity", "test_ext_id", "Test Entity", "Description", []AttributeInterface{
+			&Attribute{name: "id", externalID: "id", dataType: "String", isUnique: true},
+			&Attribute{name: "name", externalID: "name", dataType: "String"},
+		}, mockGraph)
+		require.NoError(t, err)
+		return entity
+	}
+
+	t.Run("should add every row of the batch", func(t *testing.T) {
+		entity := newTestEntity(t)
+		require.NoError(t, entity.AddRow(NewRow(map[string]string{"id": "0", "name": "first"})))
+
+		rows := make([]*Row, 100)
+		for i := range rows {
+			rows[i] = NewRow(map[string]string{"id": fmt.Sprint(i + 1), "name": fmt.Sprintf("row %d", i+1)})
+		}
+		require.NoError(t, entity.AddRows(rows))
+		require.NoError(t, entity.AddRows(nil))
+
+		assert.Equal(t, 101, entity.GetRowCount())
+		assert.True(t, entity.CheckKeyExists("0"))
+		assert.True(t, entity.CheckKeyExists("100"))
+		assert.Equal(t, "row 50", entity.GetRowByIndex(50).GetValue("name"))
+	})
+
+	t.Run("should stop at the first invalid row, keeping the rows before it", func(t *testing.T) {
+		entity := newTestEntity(t)
+		err := entity.AddRows([]*Row{
+			NewRow(map[string]string{"id": "1"}),
+			NewRow(map[string]string{"id": "2"}),
+			NewRow(map[string]string{"id": "1"}),
+			NewRow(map[string]string{"id": "3"}),
+		})
+
+		var rowErr *RowError
+		require.ErrorAs(t, err, &rowErr)
+		assert.Equal(t, 2, rowErr.Index)
+		assert.EqualError(t, err, "row 2: duplicate value '1' for unique attribute 'id'")
+		assert.Equal(t, 2, entity.GetRowCount())
+		assert.False(t, entity.CheckKeyExists("3"))
+	})
+}
+
+// Bulk row insertion against one AddRow call per row
+func BenchmarkEntity_AddRow(b *testing.B) {
+	benchmarkAddRows(b, func(entity EntityInterface, rows []*Row) error {
+		for _, row := range rows {
+			if err := entity.AddRow(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func BenchmarkEntity_AddRows(b *testing.B) {
+	benchmarkAddRows(b, func(entity EntityInterface, rows []*Row) error {
+		return entity.AddRows(rows)
+	})
+}
+
+func benchmarkAddRows(b *testing.B, add func(entity EntityInterface, rows []*Row) error) {
+	const rowCount = 100000
+	ids := make([]string, rowCount)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("id-%d", i)
+	}
+	graph := &Graph{entities: make(map[string]EntityInterface), dataVolume: 1}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		entity, err := newEntity("bench", "Bench", "Bench", "", []AttributeInterface{
+			&Attribute{name: "id", externalID: "id", dataType: "String", isUnique: true},
+		}, graph)
+		require.NoError(b, err)
+		rows := make([]*Row, rowCount)
+		for i, id := range ids {
+			rows[i] = NewRow(map[string]string{"id": id})
+		}
+		b.StartTimer()
+
+		require.NoError(b, add(entity, rows))
+	}
+}
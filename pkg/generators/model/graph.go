@@ -3,8 +3,10 @@ package model
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/SGNL-ai/fabricator/pkg/errcode"
 	"github.com/SGNL-ai/fabricator/pkg/parser"
 	"github.com/SGNL-ai/fabricator/pkg/util"
 	"github.com/dominikbraun/graph"
@@ -12,12 +14,13 @@ import (
 
 // Error definitions for Graph operations
 var (
-	ErrNilYAMLModel         = errors.New("YAML model cannot be nil")
-	ErrNoEntities           = errors.New("YAML model must contain at least one entity")
-	ErrEntityNotFound       = errors.New("entity not found")
-	ErrRelationshipNotFound = errors.New("relationship not found")
-	ErrCircularDependency   = errors.New("circular dependency detected in entity relationships")
-	ErrInvalidRelationship  = errors.New("invalid relationship definition")
+	ErrNilYAMLModel         = errcode.New(errcode.DefinitionInvalid, "YAML model cannot be nil")
+	ErrNoEntities           = errcode.New(errcode.DefinitionInvalid, "YAML model must contain at least one entity")
+	ErrInvalidEntity        = errcode.New(errcode.DefinitionInvalid, "invalid entity definition")
+	ErrEntityNotFound       = errcode.New(errcode.EntityNotFound, "entity not found")
+	ErrRelationshipNotFound = errcode.New(errcode.RelationshipNotFound, "relationship not found")
+	ErrCircularDependency   = errcode.New(errcode.CircularDependency, "circular dependency detected in entity relationships")
+	ErrInvalidRelationship  = errcode.New(errcode.RelationshipInvalid, "invalid relationship definition")
 )
 
 // Graph represents the overall model including entities and relationships
@@ -28,6 +31,8 @@ type Graph struct {
 	relationshipsList   []RelationshipInterface            // Pre-computed list of all relationships
 	entityRelationships map[string][]RelationshipInterface // Maps entity ID to its relationships
 	attributeToEntity   map[string]EntityInterface         // Maps attribute externalID to its containing entity
+	inverseOf           map[string]string                  // Maps relationships folded into an inverse pair to the one kept
+	entityOrder         bool                               // entitiesList is in the order SetEntityOrder configured
 	yamlModel           *parser.SORDefinition              // Reference to original YAML model
 	dataVolume          int                                // Expected number of rows per entity for memory optimization
 }
@@ -47,6 +52,7 @@ func NewGraph(yamlModel *parser.SORDefinition, dataVolume int) (GraphInterface,
 		relationshipsList:   make([]RelationshipInterface, 0),
 		entityRelationships: make(map[string][]RelationshipInterface),
 		attributeToEntity:   make(map[string]EntityInterface),
+		inverseOf:           make(map[string]string),
 		yamlModel:           yamlModel,
 		dataVolume:          dataVolume,
 	}
@@ -69,8 +75,18 @@ func NewGraph(yamlModel *parser.SORDefinition, dataVolume int) (GraphInterface,
 		return nil, err
 	}
 
-	// 4. Build optimized data structures for access
+	// 4. Build optimized data structures for access, pair junction keys and check
+	// that lookups copy attributes through foreign keys and foreign keys share no values
 	graph.buildIndexes()
+	if err := graph.pairJunctionKeys(); err != nil {
+		return nil, err
+	}
+	if err := graph.checkLookups(); err != nil {
+		return nil, err
+	}
+	if err := graph.checkSharedValues(); err != nil {
+		return nil, err
+	}
 
 	return graph, nil
 }
@@ -138,9 +154,15 @@ func (g *Graph) GetExpectedDataVolume() int {
 
 // createEntitiesFromYAML creates Entity objects from YAML model definition
 func (g *Graph) createEntitiesFromYAML(yamlEntities map[string]parser.Entity) error {
+	// Display names identify entities; entities sharing one are qualified so none
+	// overwrites another
+	displayNames := parser.DistinctDisplayNames(yamlEntities)
+	yamlKeys := make(map[string]string, len(yamlEntities))
+
 	// First, create all entities with their attributes
-	for _, yamlEntity := range yamlEntities {
-		entityID := yamlEntity.DisplayName // Use DisplayName as the primary entity identifier
+	for key, yamlEntity := range yamlEntities {
+		entityID := displayNames[key] // Use the distinct display name as the primary entity identifier
+		yamlKeys[entityID] = key
 		// Convert YAML attributes to model attributes
 		attributes := make([]AttributeInterface, 0, len(yamlEntity.Attributes))
 
@@ -155,6 +177,18 @@ func (g *Graph) createEntitiesFromYAML(yamlEntities map[string]parser.Entity) er
 				yamlAttr.Description,
 				nil, // Parent entity will be set by newEntity
 			)
+			if concrete, ok := attr.(*Attribute); ok {
+				concrete.generator = yamlAttr.Generator
+				concrete.uniqueWithin = yamlAttr.UniqueWithin
+				concrete.constValue = yamlAttr.Const
+				concrete.defaultValue = yamlAttr.Default
+				concrete.listEncoding = yamlAttr.ListEncoding
+				concrete.sensitive = yamlAttr.Sensitive
+				concrete.lookup = yamlAttr.Lookup
+				concrete.transform = yamlAttr.Transform
+				concrete.sharedValues = yamlAttr.SharedValues
+				concrete.timeZone = yamlAttr.TimeZone
+			}
 			attributes = append(attributes, attr)
 		}
 
@@ -162,14 +196,33 @@ func (g *Graph) createEntitiesFromYAML(yamlEntities map[string]parser.Entity) er
 		entity, err := newEntity(
 			entityID,
 			yamlEntity.ExternalId,
-			yamlEntity.DisplayName,
+			entityID,
 			yamlEntity.Description,
 			attributes,
 			g,
 		)
 
 		if err != nil {
-			return fmt.Errorf("failed to create entity %s: %w", entityID, err)
+			return errcode.Wrap(ErrInvalidEntity, fmt.Errorf("failed to create entity %s: %w", entityID, err))
+		}
+
+		if concrete, ok := entity.(*Entity); ok {
+			concrete.correlations = yamlEntity.Correlations
+			concrete.domain = yamlEntity.Domain
+			concrete.timeline = yamlEntity.Timeline
+			concrete.effectiveDating = yamlEntity.EffectiveDating
+			concrete.inlineData = yamlEntity.Data
+			concrete.payloads = yamlEntity.Payloads
+			concrete.junction = yamlEntity.Junction
+			concrete.derived = yamlEntity.Derived
+			concrete.partitionBy = yamlEntity.PartitionBy
+			if yamlEntity.Profile != "" {
+				profile, err := parser.LoadProfile(yamlEntity.Profile)
+				if err != nil {
+					return errcode.Wrap(ErrInvalidEntity, fmt.Errorf("failed to create entity %s: %w", entityID, err))
+				}
+				concrete.profile = profile
+			}
 		}
 
 		// Add entity to the graph
@@ -179,14 +232,7 @@ func (g *Graph) createEntitiesFromYAML(yamlEntities map[string]parser.Entity) er
 	// Then build the attribute to entity lookup map
 	// Support two formats with priority: 1) attributeAlias, 2) ExternalId.ExternalId
 	for entityID, entity := range g.entities {
-		// Find the corresponding YAML entity by matching DisplayName
-		var yamlEntity parser.Entity
-		for _, yEntity := range g.yamlModel.Entities {
-			if yEntity.DisplayName == entityID {
-				yamlEntity = yEntity
-				break
-			}
-		}
+		yamlEntity := yamlEntities[yamlKeys[entityID]]
 
 		for _, yamlAttr := range yamlEntity.Attributes {
 			// Priority 1: attributeAlias (highest priority)
@@ -203,6 +249,8 @@ func (g *Graph) createEntitiesFromYAML(yamlEntities map[string]parser.Entity) er
 
 // createRelationshipsFromYAML creates Relationship objects from YAML model definition
 func (g *Graph) createRelationshipsFromYAML(yamlRelationships map[string]parser.Relationship) error {
+	g.inverseOf = g.findInverseRelationships(yamlRelationships)
+
 	// Iterate over the relationships in the YAML
 	for relationshipID, yamlRel := range yamlRelationships {
 		// Skip relationships defined with path (complex relationships)
@@ -211,17 +259,27 @@ func (g *Graph) createRelationshipsFromYAML(yamlRelationships map[string]parser.
 			continue
 		}
 
+		// External relationships target another SOR's output and are linked by the pipeline
+		if yamlRel.ExternalDirectory != "" {
+			continue
+		}
+
+		// The other half of an inverse pair carries the link for both directions
+		if _, folded := g.inverseOf[relationshipID]; folded {
+			continue
+		}
+
 		// Get source entity from FromAttribute
 		sourceEntity := g.attributeToEntity[yamlRel.FromAttribute]
 		if sourceEntity == nil {
-			return fmt.Errorf("source entity not found for relationship %s (attribute: %s)\n%s",
+			return fmt.Errorf("source %w for relationship %s (attribute: %s)\n%s", ErrEntityNotFound,
 				relationshipID, yamlRel.FromAttribute, g.buildAvailableAttributesMessage(yamlRel.FromAttribute))
 		}
 
 		// Get target entity from ToAttribute
 		targetEntity := g.attributeToEntity[yamlRel.ToAttribute]
 		if targetEntity == nil {
-			return fmt.Errorf("target entity not found for relationship %s (attribute: %s)\n%s",
+			return fmt.Errorf("target %w for relationship %s (attribute: %s)\n%s", ErrEntityNotFound,
 				relationshipID, yamlRel.ToAttribute, g.buildAvailableAttributesMessage(yamlRel.ToAttribute))
 		}
 
@@ -236,18 +294,160 @@ func (g *Graph) createRelationshipsFromYAML(yamlRelationships map[string]parser.
 		)
 
 		if err != nil {
-			return fmt.Errorf("failed to create relationship %s: %w", relationshipID, err)
+			return errcode.Wrap(ErrInvalidRelationship, fmt.Errorf("failed to create relationship %s: %w", relationshipID, err))
+		}
+
+		// Foreign keys take their values from the relationship
+		if relationship != nil {
+			if source := relationship.GetSourceAttribute(); source.GetConst() != nil || source.GetDefault() != nil {
+				return errcode.Wrap(ErrInvalidRelationship, fmt.Errorf("failed to create relationship %s: attribute '%s' is a foreign key and cannot have a const or default value",
+					relationshipID, source.GetName()))
+			}
 		}
 
 		// Only add non-nil relationships to the graph
 		if relationship != nil {
+			if err := setRelationshipWhere(relationship, yamlRel.Where); err != nil {
+				return errcode.Wrap(ErrInvalidRelationship, fmt.Errorf("failed to create relationship %s: %w", relationshipID, err))
+			}
+			if err := setRelationshipWeights(relationship, yamlRel.Weights); err != nil {
+				return errcode.Wrap(ErrInvalidRelationship, fmt.Errorf("failed to create relationship %s: %w", relationshipID, err))
+			}
 			g.relationships[relationshipID] = relationship
 		}
 	}
 
+	// Tell each kept relationship which one it also stands for
+	for inverseID, keptID := range g.inverseOf {
+		if len(yamlRelationships[inverseID].Where) > 0 || len(yamlRelationships[keptID].Where) > 0 {
+			return errcode.Wrap(ErrInvalidRelationship, fmt.Errorf(
+				"relationships %s and %s are inverses, so every target row is referenced and neither can have a where", keptID, inverseID))
+		}
+		if len(yamlRelationships[inverseID].Weights) > 0 || len(yamlRelationships[keptID].Weights) > 0 {
+			return errcode.Wrap(ErrInvalidRelationship, fmt.Errorf(
+				"relationships %s and %s are inverses, so every target row is referenced once and neither can have weights", keptID, inverseID))
+		}
+		if kept, ok := g.relationships[keptID].(*Relationship); ok {
+			kept.inverseID = inverseID
+		}
+	}
+
 	return nil
 }
 
+// setRelationshipWhere resolves the attributes of a relationship's where, by name
+// or externalId, in its target entity
+func setRelationshipWhere(relationship RelationshipInterface, where map[string]string) error {
+	kept, ok := relationship.(*Relationship)
+	if !ok || len(where) == 0 {
+		return nil
+	}
+	target := relationship.GetTargetEntity()
+	kept.where = make(map[string]string, len(where))
+	for name, value := range where {
+		attr, exists := target.GetAttribute(name)
+		if !exists {
+			attr, exists = target.GetAttributeByExternalID(name)
+		}
+		if !exists {
+			return fmt.Errorf("where references unknown attribute '%s' of %s", name, target.GetExternalID())
+		}
+		kept.where[attr.GetName()] = value
+	}
+	return nil
+}
+
+// setRelationshipWeights resolves the attributes of a relationship's weights, by
+// name or externalId, in its target entity. One-to-one relationships reference
+// each target row at most once, so they can't be weighted.
+func setRelationshipWeights(relationship RelationshipInterface, weights []parser.TargetWeight) error {
+	kept, ok := relationship.(*Relationship)
+	if !ok || len(weights) == 0 {
+		return nil
+	}
+	if relationship.GetSourceAttribute().IsUnique() && relationship.GetTargetAttribute().IsUnique() {
+		return fmt.Errorf("weights don't apply to one-to-one relationships")
+	}
+	target := relationship.GetTargetEntity()
+	kept.weights = make([]TargetWeight, 0, len(weights))
+	for _, weight := range weights {
+		name, op, value, err := parser.ParseWeightCondition(weight.When)
+		if err != nil {
+			return err
+		}
+		attr, exists := target.GetAttribute(name)
+		if !exists {
+			attr, exists = target.GetAttributeByExternalID(name)
+		}
+		if !exists {
+			return fmt.Errorf("weight references unknown attribute '%s' of %s", name, target.GetExternalID())
+		}
+		kept.weights = append(kept.weights, TargetWeight{Attribute: attr.GetName(), Op: op, Value: value, Weight: weight.Weight})
+	}
+	return nil
+}
+
+// findInverseRelationships finds pairs of relationships that link the same two
+// attributes in opposite directions (A.x → B.y and B.y → A.x). Generating both as
+// independent foreign keys would give contradictory references, so one of each
+// pair is kept: the one pointing at a unique attribute, or the lower ID when both
+// or neither do. Returns a map from each folded relationship to the one kept.
+// Relationships whose attributes don't resolve are left for the caller to report.
+func (g *Graph) findInverseRelationships(yamlRelationships map[string]parser.Relationship) map[string]string {
+	type link struct{ from, to AttributeInterface }
+
+	links := make(map[string]link)
+	byLink := make(map[link]string)
+	ids := make([]string, 0, len(yamlRelationships))
+	for id := range yamlRelationships {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		yamlRel := yamlRelationships[id]
+		if len(yamlRel.Path) > 0 || yamlRel.ExternalDirectory != "" {
+			continue
+		}
+		sourceEntity, targetEntity := g.attributeToEntity[yamlRel.FromAttribute], g.attributeToEntity[yamlRel.ToAttribute]
+		if sourceEntity == nil || targetEntity == nil {
+			continue
+		}
+		from, fromOK := sourceEntity.findAttributeByReference(yamlRel.FromAttribute)
+		to, toOK := targetEntity.findAttributeByReference(yamlRel.ToAttribute)
+		if !fromOK || !toOK || from == to {
+			continue
+		}
+		links[id] = link{from: from, to: to}
+		if _, exists := byLink[links[id]]; !exists {
+			byLink[links[id]] = id
+		}
+	}
+
+	inverseOf := make(map[string]string)
+	for _, id := range ids {
+		l, exists := links[id]
+		if !exists {
+			continue
+		}
+		otherID, paired := byLink[link{from: l.to, to: l.from}]
+		if !paired || id > otherID {
+			continue // Each pair is decided once, from its lower ID
+		}
+		if _, folded := inverseOf[otherID]; folded {
+			continue
+		}
+
+		// Keep the direction whose target is unique, i.e. the real foreign key
+		kept, folded := id, otherID
+		if !l.to.IsUnique() && l.from.IsUnique() {
+			kept, folded = otherID, id
+		}
+		inverseOf[folded] = kept
+	}
+	return inverseOf
+}
+
 // buildAvailableAttributesMessage creates helpful debugging information when an attribute cannot be found
 func (g *Graph) buildAvailableAttributesMessage(attrRef string) string {
 	var msg strings.Builder
@@ -319,18 +519,25 @@ func (g *Graph) buildIndexes() {
 	g.relationshipsList = make([]RelationshipInterface, 0, len(g.relationships))
 	g.entityRelationships = make(map[string][]RelationshipInterface, len(g.entities))
 
-	// Build entities list
+	// Build entities list, sorted by ID so seeded runs visit entities in the same order
 	for _, entity := range g.entities {
 		g.entitiesList = append(g.entitiesList, entity)
 
 		// Pre-allocate relationship list for each entity (estimate: avg 2 relationships per entity)
 		g.entityRelationships[entity.GetID()] = make([]RelationshipInterface, 0, 2)
 	}
+	sort.Slice(g.entitiesList, func(i, j int) bool {
+		return g.entitiesList[i].GetID() < g.entitiesList[j].GetID()
+	})
 
-	// Build relationships list and entity relationships map
+	// Build relationships list and entity relationships map, also sorted by ID
 	for _, rel := range g.relationships {
 		g.relationshipsList = append(g.relationshipsList, rel)
-
+	}
+	sort.Slice(g.relationshipsList, func(i, j int) bool {
+		return g.relationshipsList[i].GetID() < g.relationshipsList[j].GetID()
+	})
+	for _, rel := range g.relationshipsList {
 		// Add to source entity's relationships
 		sourceEntityID := rel.GetSourceEntity().GetID()
 		g.entityRelationships[sourceEntityID] = append(g.entityRelationships[sourceEntityID], rel)
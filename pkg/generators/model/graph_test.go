@@ -122,8 +122,14 @@ func TestNewGraph(t *testing.T) {
 		// Verify entities were created
 		assert.Equal(t, 3, len(graph.GetAllEntities()))
 
-		// Verify relationships were created
-		assert.Equal(t, 2, len(graph.GetAllRelationships()))
+		// Verify relationships were created; the two relationships link the same
+		// attributes in opposite directions, so they share one foreign key
+		require.Equal(t, 1, len(graph.GetAllRelationships()))
+		relationship, exists := graph.GetRelationship("user_to_userrole")
+		require.True(t, exists, "the direction pointing at the unique attribute is kept")
+		assert.Equal(t, "role_to_userrole", relationship.GetInverseID())
+		userID, _ := graph.GetAllEntities()["User"].GetAttribute("id")
+		assert.False(t, userID.IsRelationship(), "the primary key doesn't become a foreign key")
 	})
 
 	t.Run("should validate YAML definition is not nil", func(t *testing.T) {
@@ -626,6 +632,48 @@ func TestGraphUUIDEntityIDs(t *testing.T) {
 	assert.True(t, targetAttr.IsUnique(), "Target should remain as primary key")
 }
 
+func TestGraphSharedDisplayNames(t *testing.T) {
+	user := func(externalID string) parser.Entity {
+		return parser.Entity{
+			DisplayName: "User",
+			ExternalId:  externalID,
+			Attributes: []parser.Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "groupId", ExternalId: "groupId", Type: "String"},
+			},
+		}
+	}
+	def := &parser.SORDefinition{
+		DisplayName: "Merged catalog",
+		Description: "Two directories with a User entity each",
+		Entities: map[string]parser.Entity{
+			"okta_user":  user("Okta/User"),
+			"azure_user": user("Azure/User"),
+			"group": {
+				DisplayName: "Group",
+				ExternalId:  "Okta/Group",
+				Attributes:  []parser.Attribute{{Name: "id", ExternalId: "id", Type: "String", UniqueId: true}},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"member_of": {Name: "member_of", FromAttribute: "Azure/User.groupId", ToAttribute: "Okta/Group.id"},
+		},
+	}
+
+	graph, err := NewGraph(def, 10)
+	require.NoError(t, err)
+
+	entities := graph.GetAllEntities()
+	require.Len(t, entities, 3, "entities sharing a display name don't overwrite each other")
+	assert.Equal(t, "Okta/User", entities["User (Okta)"].GetExternalID())
+	assert.Equal(t, "User (Azure)", entities["User (Azure)"].GetName())
+	assert.Contains(t, entities, "Group")
+
+	relationship, exists := graph.GetRelationship("member_of")
+	require.True(t, exists)
+	assert.Equal(t, "User (Azure)", relationship.GetSourceEntity().GetID())
+}
+
 // Test GetEntitiesList method
 func TestGetEntitiesList(t *testing.T) {
 	graph, err := NewGraph(testSORDefinition, 100)
@@ -1390,3 +1438,38 @@ func TestAttributeAliasRelationshipMarking(t *testing.T) {
 	assert.Len(t, groupRelAttrs, 0, "Group entity should have NO relationship attributes (PK side)")
 	assert.Len(t, groupNonRelAttrs, 2, "Group entity should have 2 non-relationship attributes (id, name)")
 }
+
+func TestGraph_RelationshipWhere(t *testing.T) {
+	definition := func(where map[string]string) *parser.SORDefinition {
+		return &parser.SORDefinition{
+			DisplayName: "Where SOR",
+			Entities: map[string]parser.Entity{
+				"user": {DisplayName: "User", ExternalId: "User", Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", UniqueId: true},
+					{Name: "status", ExternalId: "accountStatus"},
+				}},
+				"ticket": {DisplayName: "Ticket", ExternalId: "Ticket", Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", UniqueId: true},
+					{Name: "assigneeId", ExternalId: "assigneeId"},
+				}},
+			},
+			Relationships: map[string]parser.Relationship{
+				"ticket_assignee": {Name: "ticket_assignee", FromAttribute: "Ticket.assigneeId", ToAttribute: "User.id", Where: where},
+			},
+		}
+	}
+
+	t.Run("should resolve where attributes by externalId to names", func(t *testing.T) {
+		graph, err := NewGraph(definition(map[string]string{"accountStatus": "active"}), 10)
+		require.NoError(t, err)
+		relationship, exists := graph.GetRelationship("ticket_assignee")
+		require.True(t, exists)
+		assert.Equal(t, map[string]string{"status": "active"}, relationship.GetWhere())
+	})
+
+	t.Run("should reject unknown where attributes", func(t *testing.T) {
+		_, err := NewGraph(definition(map[string]string{"state": "active"}), 10)
+		assert.ErrorIs(t, err, ErrInvalidRelationship)
+		assert.ErrorContains(t, err, "where references unknown attribute 'state' of User")
+	})
+}
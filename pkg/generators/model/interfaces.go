@@ -4,31 +4,6 @@ import (
 	"github.com/SGNL-ai/fabricator/pkg/parser"
 )
 
-type Row struct {
-	values map[string]string
-}
-
-// NewRow creates a new Row with the given values
-func NewRow(values map[string]string) *Row {
-	return &Row{values: values}
-}
-
-// SetValue updates a field value in the row
-func (r *Row) SetValue(fieldName, value string) {
-	if r.values == nil {
-		r.values = make(map[string]string)
-	}
-	r.values[fieldName] = value
-}
-
-// GetValue gets a field value from the row
-func (r *Row) GetValue(fieldName string) string {
-	if r.values == nil {
-		return ""
-	}
-	return r.values[fieldName]
-}
-
 // GraphInterface defines the operations that can be performed on a Graph
 type GraphInterface interface {
 	GetEntity(id string) (EntityInterface, bool)
@@ -56,9 +31,21 @@ type EntityInterface interface {
 	GetNonUniqueAttributes() []AttributeInterface
 	GetRelationshipAttributes() []AttributeInterface
 	GetNonRelationshipAttributes() []AttributeInterface
+	GetCorrelations() []parser.Correlation
+	GetDomain() string
+	GetTimeline() *parser.Timeline
+	GetEffectiveDating() *parser.EffectiveDating
+	GetInlineData() []map[string]string
+	GetPayloads() *parser.Payloads
+	GetJunction() *parser.Junction
+	GetDerived() *parser.Derived
+	GetPartitionBy() string
+	GetProfile() *parser.Profile
 	GetRowCount() int
 	AddRow(row *Row) error
+	AddRows(rows []*Row) error
 	ForEachRow(fn func(row *Row, index int) error) error
+	ForEachRowWithPolicy(policy ReferencePolicy, fn func(row *Row, index int) error) error
 	ToCSV() *CSVData
 
 	// Internal method for relationships
@@ -67,6 +54,7 @@ type EntityInterface interface {
 
 	// Post-generation validation
 	ValidateAllForeignKeys() []string
+	ValidateForeignKeys(attributeName string) []string
 
 	// Helper for foreign key validation
 	validateForeignKeyValue(attributeName string, value string) error
@@ -86,6 +74,7 @@ type EntityInterface interface {
 	RemoveRow(rowIndex int) error            // Remove row and update hash maps
 	RegisterCompositeKey(row *Row)           // Register composite key after all FKs set (for duplicate detection)
 	IsCompositeKeyRegistered(row *Row) bool  // Check if composite key already registered
+	IsSelfPair(row *Row) bool                // Check if a junction without self-pairs pairs a key with itself
 }
 
 // RelationshipInterface defines operations for relationships
@@ -97,12 +86,18 @@ type RelationshipInterface interface {
 	GetSourceAttribute() AttributeInterface
 	GetTargetAttribute() AttributeInterface
 	GetCardinality() string
+	GetCardinalityReason() string
+	DescribeDistribution(autoCardinality bool) string
 	IsOneToOne() bool
 	IsOneToMany() bool
 	IsManyToOne() bool
+	GetInverseID() string
+	GetWhere() map[string]string
+	GetWeights() []TargetWeight
 
 	// Target value selection for FK population
 	GetTargetValueForSourceRow(sourceRowIndex int, autoCardinality bool) (string, error)
+	SelectTargetIndex(sourceRowIndex, targetRowCount int, autoCardinality bool) int
 }
 
 // AttributeInterface defines operations for attributes
@@ -116,6 +111,16 @@ type AttributeInterface interface {
 	GetParentEntity() EntityInterface
 	GetRelatedEntityID() string
 	GetRelatedAttribute() string
+	GetGenerator() *parser.Generator
+	GetUniqueWithin() string
+	GetConst() *string
+	GetDefault() *string
+	GetListEncoding() string
+	IsSensitive() bool
+	GetLookup() *parser.Lookup
+	GetTransform() *parser.Transform
+	GetSharedValues() *parser.SharedValues
+	GetTimeZone() *parser.TimeZone
 
 	// Required for relationship handling
 	setRelationship(relatedEntityID, relatedAttributeName string)
@@ -0,0 +1,47 @@
+package model
+
+// maxInternedValues is the number of distinct values a column interns before it's
+// treated as high-cardinality (IDs, names, free text) and stops interning
+const maxInternedValues = 1024
+
+// SetValueInterning enables or disables interning of repeated values in the
+// graph's entities, for values set afterwards. Interning is on by default;
+// disabling it helps when debugging memory use, since every value then keeps its
+// own copy.
+func (g *Graph) SetValueInterning(enabled bool) {
+	for _, entity := range g.entitiesList {
+		if concrete, ok := entity.(*Entity); ok {
+			concrete.store.setInterning(enabled)
+		}
+	}
+}
+
+// interner makes equal values of one column share a single string, so millions of
+// rows with a few statuses, booleans or foreign keys hold a few copies rather than
+// millions. It gives up once the column shows too many distinct values to benefit.
+type interner struct {
+	values    map[string]string
+	abandoned bool
+}
+
+// newInterner returns an interner
+func newInterner() *interner {
+	return &interner{values: make(map[string]string)}
+}
+
+// intern returns the shared copy of value, remembering value if it's new
+func (i *interner) intern(value string) string {
+	if i == nil || i.abandoned || value == "" {
+		return value
+	}
+	if shared, exists := i.values[value]; exists {
+		return shared
+	}
+	if len(i.values) >= maxInternedValues {
+		i.abandoned = true
+		i.values = nil
+		return value
+	}
+	i.values[value] = value
+	return value
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sameString reports whether two strings share their bytes
+func sameString(a, b string) bool {
+	return unsafe.StringData(a) == unsafe.StringData(b)
+}
+
+func TestValueInterning(t *testing.T) {
+	t.Run("should share equal values within a column", func(t *testing.T) {
+		entity := newColumnarTestEntity(t)
+		for i := 0; i < 3; i++ {
+			// Cloned so each value starts as a separate copy
+			require.NoError(t, entity.AddRow(NewRow(map[string]string{"id": fmt.Sprint(i), "name": strings.Clone("active")})))
+		}
+
+		first := entity.GetRowByIndex(0).GetValue("name")
+		for i := 1; i < 3; i++ {
+			assert.True(t, sameString(first, entity.GetRowByIndex(i).GetValue("name")))
+		}
+	})
+
+	t.Run("should stop interning high-cardinality columns", func(t *testing.T) {
+		i := newInterner()
+		for n := 0; n < maxInternedValues; n++ {
+			i.intern(fmt.Sprint(n))
+		}
+		assert.False(t, i.abandoned)
+
+		i.intern("one too many")
+		assert.True(t, i.abandoned)
+		assert.Nil(t, i.values, "interned values are released")
+
+		copied := strings.Clone("0")
+		assert.True(t, sameString(copied, i.intern(copied)), "values pass through unchanged")
+	})
+
+	t.Run("should keep every copy when disabled", func(t *testing.T) {
+		graph, err := NewGraph(&parser.SORDefinition{
+			DisplayName: "Interning",
+			Entities: map[string]parser.Entity{
+				"test": {DisplayName: "Test", ExternalId: "Test", Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "name", ExternalId: "name", Type: "String"},
+				}},
+			},
+		}, 0)
+		require.NoError(t, err)
+		graph.(*Graph).SetValueInterning(false)
+
+		entity, exists := graph.GetEntity("Test")
+		require.True(t, exists)
+		values := []string{strings.Clone("active"), strings.Clone("active")}
+		for i, value := range values {
+			require.NoError(t, entity.AddRow(NewRow(map[string]string{"id": fmt.Sprint(i), "name": value})))
+		}
+		assert.False(t, sameString(entity.GetRowByIndex(0).GetValue("name"), entity.GetRowByIndex(1).GetValue("name")))
+	})
+}
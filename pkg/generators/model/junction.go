@@ -0,0 +1,88 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/errcode"
+)
+
+// pairJunctionKeys finds, for each entity with junction options, the foreign keys
+// referencing the same attribute, whose values form the pairs the options apply to
+func (g *Graph) pairJunctionKeys() error {
+	for _, entity := range g.entitiesList {
+		concrete, ok := entity.(*Entity)
+		if !ok || concrete.junction == nil {
+			continue
+		}
+
+		byTarget := make(map[string][]string)
+		for _, relationship := range g.relationshipsList {
+			if relationship.GetSourceEntity().GetID() != concrete.id {
+				continue
+			}
+			target := relationship.GetTargetEntity().GetID() + "." + relationship.GetTargetAttribute().GetName()
+			byTarget[target] = append(byTarget[target], relationship.GetSourceAttribute().GetName())
+		}
+
+		concrete.pairAttributes = make(map[string]string)
+		for target, names := range byTarget {
+			if len(names) < 2 {
+				continue
+			}
+			for _, name := range names {
+				concrete.pairAttributes[name] = target
+			}
+		}
+		if len(concrete.pairAttributes) == 0 {
+			return errcode.Wrap(ErrInvalidEntity, fmt.Errorf(
+				"entity %s has junction options but no two foreign keys referencing the same attribute", concrete.externalID))
+		}
+	}
+	return nil
+}
+
+// unorderedCompositeKey builds the composite key of a row with the values of each
+// group of paired foreign keys sorted, so (a,b) and (b,a) share a key
+func (e *Entity) unorderedCompositeKey(row *Row, fkAttributes []AttributeInterface) string {
+	values := make([]string, len(fkAttributes))
+	positions := make(map[string][]int)
+	for i, fkAttr := range fkAttributes {
+		values[i] = e.comparable(fkAttr, row.GetValue(fkAttr.GetName()))
+		if target, paired := e.pairAttributes[fkAttr.GetName()]; paired {
+			positions[target] = append(positions[target], i)
+		}
+	}
+	for _, group := range positions {
+		paired := make([]string, len(group))
+		for i, position := range group {
+			paired[i] = values[position]
+		}
+		sort.Strings(paired)
+		for i, position := range group {
+			values[position] = paired[i]
+		}
+	}
+	return strings.Join(values, "|")
+}
+
+// IsSelfPair reports whether the row pairs a key with itself in an entity whose
+// junction rules that out
+func (e *Entity) IsSelfPair(row *Row) bool {
+	if e.junction == nil || !e.junction.NoSelfPairs {
+		return false
+	}
+	seen := make(map[string]bool, len(e.pairAttributes))
+	for name, target := range e.pairAttributes {
+		value := row.GetValue(name)
+		if value == "" {
+			continue
+		}
+		if seen[target+"|"+value] {
+			return true
+		}
+		seen[target+"|"+value] = true
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/SGNL-ai/fabricator/pkg/errcode"
+)
+
+// LookupRelationship returns the relationship whose foreign key a lookup attribute
+// of entity copies its value through, or nil if the attribute isn't a lookup or its
+// foreign key references nothing
+func (g *Graph) LookupRelationship(entity EntityInterface, attr AttributeInterface) RelationshipInterface {
+	lookup := attr.GetLookup()
+	if lookup == nil {
+		return nil
+	}
+	return g.ForeignKeyRelationship(entity, lookup.ForeignKey)
+}
+
+// checkLookups checks that each lookup attribute copies an existing attribute of
+// the entity its foreign key references, and that no lookups copy each other in a
+// cycle, which would leave their values undefined
+func (g *Graph) checkLookups() error {
+	for _, entity := range g.entitiesList {
+		for _, attr := range entity.GetAttributes() {
+			lookup := attr.GetLookup()
+			if lookup == nil {
+				continue
+			}
+			if attr.IsRelationship() {
+				return errcode.Wrap(ErrInvalidEntity, fmt.Errorf(
+					"entity %s attribute '%s' is a foreign key and cannot be a lookup", entity.GetExternalID(), attr.GetName()))
+			}
+
+			// Follow lookups of lookups until an attribute is generated, or one repeats
+			seen := map[AttributeInterface]bool{attr: true}
+			for current, currentEntity := attr, entity; current.GetLookup() != nil; {
+				relationship := g.LookupRelationship(currentEntity, current)
+				if relationship == nil {
+					return errcode.Wrap(ErrInvalidEntity, fmt.Errorf(
+						"entity %s attribute '%s' looks up through '%s', which is not a foreign key",
+						currentEntity.GetExternalID(), current.GetName(), current.GetLookup().ForeignKey))
+				}
+				target := relationship.GetTargetEntity()
+				copied, exists := target.GetAttribute(current.GetLookup().Attribute)
+				if !exists {
+					return errcode.Wrap(ErrInvalidEntity, fmt.Errorf(
+						"entity %s attribute '%s' looks up unknown attribute '%s' of %s",
+						currentEntity.GetExternalID(), current.GetName(), current.GetLookup().Attribute, target.GetExternalID()))
+				}
+				if seen[copied] {
+					return errcode.Wrap(ErrInvalidEntity, fmt.Errorf(
+						"entity %s attribute '%s' is part of a cycle of lookups", entity.GetExternalID(), attr.GetName()))
+				}
+				seen[copied] = true
+				current, currentEntity = copied, target
+			}
+		}
+	}
+	return nil
+}
@@ -207,6 +207,20 @@ func (mr *MockEntityInterfaceMockRecorder) AddRow(row any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRow", reflect.TypeOf((*MockEntityInterface)(nil).AddRow), row)
 }
 
+// AddRows mocks base method.
+func (m *MockEntityInterface) AddRows(rows []*Row) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRows", rows)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRows indicates an expected call of AddRows.
+func (mr *MockEntityInterfaceMockRecorder) AddRows(rows any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRows", reflect.TypeOf((*MockEntityInterface)(nil).AddRows), rows)
+}
+
 // CheckKeyExists mocks base method.
 func (m *MockEntityInterface) CheckKeyExists(keyValue string) bool {
 	m.ctrl.T.Helper()
@@ -235,6 +249,20 @@ func (mr *MockEntityInterfaceMockRecorder) ForEachRow(fn any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForEachRow", reflect.TypeOf((*MockEntityInterface)(nil).ForEachRow), fn)
 }
 
+// ForEachRowWithPolicy mocks base method.
+func (m *MockEntityInterface) ForEachRowWithPolicy(policy ReferencePolicy, fn func(*Row, int) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForEachRowWithPolicy", policy, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForEachRowWithPolicy indicates an expected call of ForEachRowWithPolicy.
+func (mr *MockEntityInterfaceMockRecorder) ForEachRowWithPolicy(policy, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForEachRowWithPolicy", reflect.TypeOf((*MockEntityInterface)(nil).ForEachRowWithPolicy), policy, fn)
+}
+
 // GetAttribute mocks base method.
 func (m *MockEntityInterface) GetAttribute(name string) (AttributeInterface, bool) {
 	m.ctrl.T.Helper()
@@ -279,6 +307,146 @@ func (mr *MockEntityInterfaceMockRecorder) GetAttributes() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttributes", reflect.TypeOf((*MockEntityInterface)(nil).GetAttributes))
 }
 
+// GetCorrelations mocks base method.
+func (m *MockEntityInterface) GetCorrelations() []parser.Correlation {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCorrelations")
+	ret0, _ := ret[0].([]parser.Correlation)
+	return ret0
+}
+
+// GetCorrelations indicates an expected call of GetCorrelations.
+func (mr *MockEntityInterfaceMockRecorder) GetCorrelations() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCorrelations", reflect.TypeOf((*MockEntityInterface)(nil).GetCorrelations))
+}
+
+// GetDomain mocks base method.
+func (m *MockEntityInterface) GetDomain() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDomain")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetDomain indicates an expected call of GetDomain.
+func (mr *MockEntityInterfaceMockRecorder) GetDomain() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDomain", reflect.TypeOf((*MockEntityInterface)(nil).GetDomain))
+}
+
+// GetTimeline mocks base method.
+func (m *MockEntityInterface) GetTimeline() *parser.Timeline {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTimeline")
+	ret0, _ := ret[0].(*parser.Timeline)
+	return ret0
+}
+
+// GetTimeline indicates an expected call of GetTimeline.
+func (mr *MockEntityInterfaceMockRecorder) GetTimeline() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTimeline", reflect.TypeOf((*MockEntityInterface)(nil).GetTimeline))
+}
+
+// GetEffectiveDating mocks base method.
+func (m *MockEntityInterface) GetEffectiveDating() *parser.EffectiveDating {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEffectiveDating")
+	ret0, _ := ret[0].(*parser.EffectiveDating)
+	return ret0
+}
+
+// GetEffectiveDating indicates an expected call of GetEffectiveDating.
+func (mr *MockEntityInterfaceMockRecorder) GetEffectiveDating() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEffectiveDating", reflect.TypeOf((*MockEntityInterface)(nil).GetEffectiveDating))
+}
+
+// GetInlineData mocks base method.
+func (m *MockEntityInterface) GetInlineData() []map[string]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInlineData")
+	ret0, _ := ret[0].([]map[string]string)
+	return ret0
+}
+
+// GetInlineData indicates an expected call of GetInlineData.
+func (mr *MockEntityInterfaceMockRecorder) GetInlineData() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInlineData", reflect.TypeOf((*MockEntityInterface)(nil).GetInlineData))
+}
+
+// GetPayloads mocks base method.
+func (m *MockEntityInterface) GetPayloads() *parser.Payloads {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayloads")
+	ret0, _ := ret[0].(*parser.Payloads)
+	return ret0
+}
+
+// GetPayloads indicates an expected call of GetPayloads.
+func (mr *MockEntityInterfaceMockRecorder) GetPayloads() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayloads", reflect.TypeOf((*MockEntityInterface)(nil).GetPayloads))
+}
+
+// GetJunction mocks base method.
+func (m *MockEntityInterface) GetJunction() *parser.Junction {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJunction")
+	ret0, _ := ret[0].(*parser.Junction)
+	return ret0
+}
+
+// GetJunction indicates an expected call of GetJunction.
+func (mr *MockEntityInterfaceMockRecorder) GetJunction() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJunction", reflect.TypeOf((*MockEntityInterface)(nil).GetJunction))
+}
+
+// GetDerived mocks base method.
+func (m *MockEntityInterface) GetDerived() *parser.Derived {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDerived")
+	ret0, _ := ret[0].(*parser.Derived)
+	return ret0
+}
+
+// GetDerived indicates an expected call of GetDerived.
+func (mr *MockEntityInterfaceMockRecorder) GetDerived() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDerived", reflect.TypeOf((*MockEntityInterface)(nil).GetDerived))
+}
+
+// GetPartitionBy mocks base method.
+func (m *MockEntityInterface) GetPartitionBy() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPartitionBy")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetPartitionBy indicates an expected call of GetPartitionBy.
+func (mr *MockEntityInterfaceMockRecorder) GetPartitionBy() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPartitionBy", reflect.TypeOf((*MockEntityInterface)(nil).GetPartitionBy))
+}
+
+// GetProfile mocks base method.
+func (m *MockEntityInterface) GetProfile() *parser.Profile {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfile")
+	ret0, _ := ret[0].(*parser.Profile)
+	return ret0
+}
+
+// GetProfile indicates an expected call of GetProfile.
+func (mr *MockEntityInterfaceMockRecorder) GetProfile() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockEntityInterface)(nil).GetProfile))
+}
+
 // GetDescription mocks base method.
 func (m *MockEntityInterface) GetDescription() string {
 	m.ctrl.T.Helper()
@@ -461,6 +629,20 @@ func (mr *MockEntityInterfaceMockRecorder) ToCSV() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ToCSV", reflect.TypeOf((*MockEntityInterface)(nil).ToCSV))
 }
 
+// ValidateForeignKeys mocks base method.
+func (m *MockEntityInterface) ValidateForeignKeys(attributeName string) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateForeignKeys", attributeName)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// ValidateForeignKeys indicates an expected call of ValidateForeignKeys.
+func (mr *MockEntityInterfaceMockRecorder) ValidateForeignKeys(attributeName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateForeignKeys", reflect.TypeOf((*MockEntityInterface)(nil).ValidateForeignKeys), attributeName)
+}
+
 // ValidateAllForeignKeys mocks base method.
 func (m *MockEntityInterface) ValidateAllForeignKeys() []string {
 	m.ctrl.T.Helper()
@@ -571,6 +753,90 @@ func (mr *MockRelationshipInterfaceMockRecorder) GetCardinality() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCardinality", reflect.TypeOf((*MockRelationshipInterface)(nil).GetCardinality))
 }
 
+// GetCardinalityReason mocks base method.
+func (m *MockRelationshipInterface) GetCardinalityReason() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCardinalityReason")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetCardinalityReason indicates an expected call of GetCardinalityReason.
+func (mr *MockRelationshipInterfaceMockRecorder) GetCardinalityReason() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCardinalityReason", reflect.TypeOf((*MockRelationshipInterface)(nil).GetCardinalityReason))
+}
+
+// DescribeDistribution mocks base method.
+func (m *MockRelationshipInterface) DescribeDistribution(autoCardinality bool) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeDistribution", autoCardinality)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// DescribeDistribution indicates an expected call of DescribeDistribution.
+func (mr *MockRelationshipInterfaceMockRecorder) DescribeDistribution(autoCardinality any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeDistribution", reflect.TypeOf((*MockRelationshipInterface)(nil).DescribeDistribution), autoCardinality)
+}
+
+// GetInverseID mocks base method.
+func (m *MockRelationshipInterface) GetInverseID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInverseID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetInverseID indicates an expected call of GetInverseID.
+func (mr *MockRelationshipInterfaceMockRecorder) GetInverseID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInverseID", reflect.TypeOf((*MockRelationshipInterface)(nil).GetInverseID))
+}
+
+// GetWeights mocks base method.
+func (m *MockRelationshipInterface) GetWeights() []TargetWeight {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWeights")
+	ret0, _ := ret[0].([]TargetWeight)
+	return ret0
+}
+
+// GetWeights indicates an expected call of GetWeights.
+func (mr *MockRelationshipInterfaceMockRecorder) GetWeights() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWeights", reflect.TypeOf((*MockRelationshipInterface)(nil).GetWeights))
+}
+
+// GetWhere mocks base method.
+func (m *MockRelationshipInterface) GetWhere() map[string]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWhere")
+	ret0, _ := ret[0].(map[string]string)
+	return ret0
+}
+
+// GetWhere indicates an expected call of GetWhere.
+func (mr *MockRelationshipInterfaceMockRecorder) GetWhere() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWhere", reflect.TypeOf((*MockRelationshipInterface)(nil).GetWhere))
+}
+
+// SelectTargetIndex mocks base method.
+func (m *MockRelationshipInterface) SelectTargetIndex(sourceRowIndex, targetRowCount int, autoCardinality bool) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectTargetIndex", sourceRowIndex, targetRowCount, autoCardinality)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// SelectTargetIndex indicates an expected call of SelectTargetIndex.
+func (mr *MockRelationshipInterfaceMockRecorder) SelectTargetIndex(sourceRowIndex, targetRowCount, autoCardinality any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectTargetIndex", reflect.TypeOf((*MockRelationshipInterface)(nil).SelectTargetIndex), sourceRowIndex, targetRowCount, autoCardinality)
+}
+
 // GetID mocks base method.
 func (m *MockRelationshipInterface) GetID() string {
 	m.ctrl.T.Helper()
@@ -764,6 +1030,104 @@ func (mr *MockAttributeInterfaceMockRecorder) GetDataType() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataType", reflect.TypeOf((*MockAttributeInterface)(nil).GetDataType))
 }
 
+// GetGenerator mocks base method.
+func (m *MockAttributeInterface) GetGenerator() *parser.Generator {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGenerator")
+	ret0, _ := ret[0].(*parser.Generator)
+	return ret0
+}
+
+// GetGenerator indicates an expected call of GetGenerator.
+func (mr *MockAttributeInterfaceMockRecorder) GetGenerator() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGenerator", reflect.TypeOf((*MockAttributeInterface)(nil).GetGenerator))
+}
+
+// GetSharedValues mocks base method.
+func (m *MockAttributeInterface) GetSharedValues() *parser.SharedValues {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSharedValues")
+	ret0, _ := ret[0].(*parser.SharedValues)
+	return ret0
+}
+
+// GetSharedValues indicates an expected call of GetSharedValues.
+func (mr *MockAttributeInterfaceMockRecorder) GetSharedValues() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSharedValues", reflect.TypeOf((*MockAttributeInterface)(nil).GetSharedValues))
+}
+
+// GetTimeZone mocks base method.
+func (m *MockAttributeInterface) GetTimeZone() *parser.TimeZone {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTimeZone")
+	ret0, _ := ret[0].(*parser.TimeZone)
+	return ret0
+}
+
+// GetTimeZone indicates an expected call of GetTimeZone.
+func (mr *MockAttributeInterfaceMockRecorder) GetTimeZone() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTimeZone", reflect.TypeOf((*MockAttributeInterface)(nil).GetTimeZone))
+}
+
+// GetTransform mocks base method.
+func (m *MockAttributeInterface) GetTransform() *parser.Transform {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransform")
+	ret0, _ := ret[0].(*parser.Transform)
+	return ret0
+}
+
+// GetTransform indicates an expected call of GetTransform.
+func (mr *MockAttributeInterfaceMockRecorder) GetTransform() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransform", reflect.TypeOf((*MockAttributeInterface)(nil).GetTransform))
+}
+
+// GetUniqueWithin mocks base method.
+func (m *MockAttributeInterface) GetUniqueWithin() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUniqueWithin")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetUniqueWithin indicates an expected call of GetUniqueWithin.
+func (mr *MockAttributeInterfaceMockRecorder) GetUniqueWithin() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUniqueWithin", reflect.TypeOf((*MockAttributeInterface)(nil).GetUniqueWithin))
+}
+
+// GetConst mocks base method.
+func (m *MockAttributeInterface) GetConst() *string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConst")
+	ret0, _ := ret[0].(*string)
+	return ret0
+}
+
+// GetConst indicates an expected call of GetConst.
+func (mr *MockAttributeInterfaceMockRecorder) GetConst() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConst", reflect.TypeOf((*MockAttributeInterface)(nil).GetConst))
+}
+
+// GetDefault mocks base method.
+func (m *MockAttributeInterface) GetDefault() *string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDefault")
+	ret0, _ := ret[0].(*string)
+	return ret0
+}
+
+// GetDefault indicates an expected call of GetDefault.
+func (mr *MockAttributeInterfaceMockRecorder) GetDefault() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDefault", reflect.TypeOf((*MockAttributeInterface)(nil).GetDefault))
+}
+
 // GetExternalID mocks base method.
 func (m *MockAttributeInterface) GetExternalID() string {
 	m.ctrl.T.Helper()
@@ -778,6 +1142,34 @@ func (mr *MockAttributeInterfaceMockRecorder) GetExternalID() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExternalID", reflect.TypeOf((*MockAttributeInterface)(nil).GetExternalID))
 }
 
+// GetListEncoding mocks base method.
+func (m *MockAttributeInterface) GetListEncoding() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetListEncoding")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetListEncoding indicates an expected call of GetListEncoding.
+func (mr *MockAttributeInterfaceMockRecorder) GetListEncoding() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetListEncoding", reflect.TypeOf((*MockAttributeInterface)(nil).GetListEncoding))
+}
+
+// GetLookup mocks base method.
+func (m *MockAttributeInterface) GetLookup() *parser.Lookup {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLookup")
+	ret0, _ := ret[0].(*parser.Lookup)
+	return ret0
+}
+
+// GetLookup indicates an expected call of GetLookup.
+func (mr *MockAttributeInterfaceMockRecorder) GetLookup() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLookup", reflect.TypeOf((*MockAttributeInterface)(nil).GetLookup))
+}
+
 // GetName mocks base method.
 func (m *MockAttributeInterface) GetName() string {
 	m.ctrl.T.Helper()
@@ -848,6 +1240,20 @@ func (mr *MockAttributeInterfaceMockRecorder) IsRelationship() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRelationship", reflect.TypeOf((*MockAttributeInterface)(nil).IsRelationship))
 }
 
+// IsSensitive mocks base method.
+func (m *MockAttributeInterface) IsSensitive() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsSensitive")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsSensitive indicates an expected call of IsSensitive.
+func (mr *MockAttributeInterfaceMockRecorder) IsSensitive() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSensitive", reflect.TypeOf((*MockAttributeInterface)(nil).IsSensitive))
+}
+
 // IsUnique mocks base method.
 func (m *MockAttributeInterface) IsUnique() bool {
 	m.ctrl.T.Helper()
@@ -900,6 +1306,20 @@ func (mr *MockEntityInterfaceMockRecorder) IsCompositeKeyRegistered(row any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsCompositeKeyRegistered", reflect.TypeOf((*MockEntityInterface)(nil).IsCompositeKeyRegistered), row)
 }
 
+// IsSelfPair mocks base method.
+func (m *MockEntityInterface) IsSelfPair(row *Row) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsSelfPair", row)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsSelfPair indicates an expected call of IsSelfPair.
+func (mr *MockEntityInterfaceMockRecorder) IsSelfPair(row any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSelfPair", reflect.TypeOf((*MockEntityInterface)(nil).IsSelfPair), row)
+}
+
 // RegisterCompositeKey mocks base method.
 func (m *MockEntityInterface) RegisterCompositeKey(row *Row) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,31 @@
+package model
+
+// ValueNormalizer returns the form of an attribute's value that comparisons use, so
+// values written differently but meaning the same, such as 042 and 42 in an
+// Integer column, compare equal
+type ValueNormalizer func(attr AttributeInterface, value string) string
+
+// SetValueNormalizer makes the graph's entities compare primary keys, foreign keys
+// and composite keys by their normalized form, for rows added afterwards. Rows keep
+// their values as they were set. A nil normalizer compares values as they are,
+// which is the default.
+func (g *Graph) SetValueNormalizer(normalize ValueNormalizer) {
+	for _, entity := range g.entitiesList {
+		if concrete, ok := entity.(*Entity); ok {
+			concrete.normalize = normalize
+		}
+	}
+}
+
+// comparable returns the form of an attribute's value that comparisons use
+func (e *Entity) comparable(attr AttributeInterface, value string) string {
+	if e.normalize == nil || value == "" {
+		return value
+	}
+	return e.normalize(attr, value)
+}
+
+// keyOf returns the form of a primary key value the key index holds
+func (e *Entity) keyOf(value string) string {
+	return e.comparable(e.primaryKey, value)
+}
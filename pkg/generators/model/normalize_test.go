@@ -0,0 +1,38 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueNormalizer(t *testing.T) {
+	graph, err := NewGraph(&parser.SORDefinition{
+		DisplayName: "Normalizing",
+		Entities: map[string]parser.Entity{
+			"test": {DisplayName: "Test", ExternalId: "Test", Attributes: []parser.Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+			}},
+		},
+	}, 0)
+	require.NoError(t, err)
+	graph.(*Graph).SetValueNormalizer(func(attr AttributeInterface, value string) string {
+		return strings.TrimSpace(value)
+	})
+
+	entity, exists := graph.GetEntity("Test")
+	require.True(t, exists)
+	require.NoError(t, entity.AddRow(NewRow(map[string]string{"id": " user-1 "})))
+
+	t.Run("should keep values as they were set", func(t *testing.T) {
+		assert.Equal(t, " user-1 ", entity.GetRowByIndex(0).GetValue("id"))
+	})
+
+	t.Run("should compare keys in their normalized form", func(t *testing.T) {
+		assert.True(t, entity.CheckKeyExists("user-1"))
+		assert.Error(t, entity.AddRow(NewRow(map[string]string{"id": "user-1"})))
+	})
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SetEntityOrder reorders the entities every generation step and writer visits:
+// the listed entities (by external ID) go first, in the listed order, then the
+// others in their current order, and each entity comes after the entities its
+// foreign keys reference. It fails if an entity is unknown, or listed before one it
+// depends on.
+func (g *Graph) SetEntityOrder(externalIDs []string) error {
+	listed := make(map[EntityInterface]bool, len(externalIDs))
+	for _, externalID := range externalIDs {
+		entity := g.entityByExternalID(externalID)
+		if entity == nil {
+			return fmt.Errorf("%w: entity order lists unknown entity '%s'", ErrEntityNotFound, externalID)
+		}
+		if listed[entity] {
+			return fmt.Errorf("entity order lists %s twice", externalID)
+		}
+		listed[entity] = true
+	}
+
+	// dependsOn[e] holds the entities e's foreign keys reference
+	dependsOn := make(map[EntityInterface]map[EntityInterface]bool, len(g.entitiesList))
+	for _, relationship := range g.relationshipsList {
+		source, target := relationship.GetSourceEntity(), relationship.GetTargetEntity()
+		if source == target {
+			continue
+		}
+		if dependsOn[source] == nil {
+			dependsOn[source] = make(map[EntityInterface]bool)
+		}
+		dependsOn[source][target] = true
+	}
+
+	// Listed entities are placed first, each right after the dependencies not yet
+	// placed, then the others; a cycle is broken where it is entered
+	ordered := make([]EntityInterface, 0, len(g.entitiesList))
+	placedBy := make(map[EntityInterface]EntityInterface, len(g.entitiesList)) // The entity whose placement placed each
+	var place func(entity, by EntityInterface)
+	place = func(entity, by EntityInterface) {
+		if placedBy[entity] != nil {
+			return
+		}
+		placedBy[entity] = by
+		for _, dependency := range g.entitiesList {
+			if dependsOn[entity][dependency] {
+				place(dependency, by)
+			}
+		}
+		ordered = append(ordered, entity)
+	}
+	for _, externalID := range externalIDs {
+		entity := g.entityByExternalID(externalID)
+		place(entity, entity)
+	}
+	for _, entity := range g.entitiesList {
+		place(entity, entity)
+	}
+
+	// Listed entities must keep their relative order; an entity listed earlier that
+	// depends on a later one pulls it ahead
+	for i := 1; i < len(externalIDs); i++ {
+		earlier, later := g.entityByExternalID(externalIDs[i-1]), g.entityByExternalID(externalIDs[i])
+		if slices.Index(ordered, earlier) > slices.Index(ordered, later) {
+			dependent := placedBy[later].GetExternalID()
+			return fmt.Errorf("entity order lists %s before %s, but %s depends on %s",
+				dependent, externalIDs[i], dependent, externalIDs[i])
+		}
+	}
+
+	g.entitiesList = ordered
+	g.entityOrder = true
+	return nil
+}
+
+// HasEntityOrder returns whether SetEntityOrder configured the entities' order
+func (g *Graph) HasEntityOrder() bool {
+	return g.entityOrder
+}
+
+// entityByExternalID returns the entity with the given external ID, or nil
+func (g *Graph) entityByExternalID(externalID string) EntityInterface {
+	for _, entity := range g.entitiesList {
+		if entity.GetExternalID() == externalID {
+			return entity
+		}
+	}
+	return nil
+}
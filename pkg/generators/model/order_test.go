@@ -0,0 +1,63 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph_SetEntityOrder(t *testing.T) {
+	entity := func(name string, attributes ...string) parser.Entity {
+		attrs := []parser.Attribute{{Name: "id", ExternalId: "id", Type: "String", UniqueId: true}}
+		for _, attr := range attributes {
+			attrs = append(attrs, parser.Attribute{Name: attr, ExternalId: attr, Type: "String"})
+		}
+		return parser.Entity{DisplayName: name, ExternalId: name, Attributes: attrs}
+	}
+	def := &parser.SORDefinition{
+		DisplayName: "Order",
+		Entities: map[string]parser.Entity{
+			"application": entity("Application"),
+			"group":       entity("Group"),
+			"member":      entity("GroupMember", "groupId", "userId"),
+			"user":        entity("User"),
+		},
+		Relationships: map[string]parser.Relationship{
+			"member_group": {DisplayName: "Member Group", Name: "member_group", FromAttribute: "GroupMember.groupId", ToAttribute: "Group.id"},
+			"member_user":  {DisplayName: "Member User", Name: "member_user", FromAttribute: "GroupMember.userId", ToAttribute: "User.id"},
+		},
+	}
+	order := func(graph *Graph) []string {
+		var ids []string
+		for _, entity := range graph.GetEntitiesList() {
+			ids = append(ids, entity.GetExternalID())
+		}
+		return ids
+	}
+	newGraph := func(t *testing.T) *Graph {
+		graph, err := NewGraph(def, 10)
+		require.NoError(t, err)
+		return graph.(*Graph)
+	}
+
+	graph := newGraph(t)
+	assert.Equal(t, []string{"Application", "Group", "GroupMember", "User"}, order(graph))
+
+	require.NoError(t, graph.SetEntityOrder([]string{"User", "Group"}))
+	assert.Equal(t, []string{"User", "Group", "Application", "GroupMember"}, order(graph))
+
+	graph = newGraph(t)
+	require.NoError(t, graph.SetEntityOrder([]string{"User", "GroupMember", "Application"}))
+	assert.Equal(t, []string{"User", "Group", "GroupMember", "Application"}, order(graph), "a listed entity waits for its dependencies")
+
+	graph = newGraph(t)
+	err := graph.SetEntityOrder([]string{"GroupMember", "User"})
+	assert.EqualError(t, err, "entity order lists GroupMember before User, but GroupMember depends on User")
+	assert.Equal(t, []string{"Application", "Group", "GroupMember", "User"}, order(graph), "a rejected order changes nothing")
+
+	err = graph.SetEntityOrder([]string{"Users"})
+	assert.ErrorIs(t, err, ErrEntityNotFound)
+	assert.EqualError(t, graph.SetEntityOrder([]string{"User", "User"}), "entity order lists User twice")
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/SGNL-ai/fabricator/pkg/errcode"
+)
+
+// ReferencePolicy decides what ForEachRowWithPolicy does with rows of other entities
+// whose foreign keys reference a primary key value the callback changes or removes
+type ReferencePolicy int
+
+const (
+	// ReferencesIgnore leaves referencing rows as they are, orphaning them
+	ReferencesIgnore ReferencePolicy = iota
+	// ReferencesCascade rewrites referencing foreign keys to the new PK value, and
+	// clears those referencing a removed row. A referencing row whose own PK changes
+	// this way cascades in turn.
+	ReferencesCascade
+	// ReferencesReject fails with ErrReferencedKey at the first referenced PK value
+	// that changes or is removed. Rows processed before it keep their changes.
+	ReferencesReject
+)
+
+// ErrReferencedKey reports a change to a PK value that other rows reference
+var ErrReferencedKey = errcode.New(errcode.ReferencedKey, "key is referenced")
+
+// keyChanges tracks the PK values changed in one ForEachRowWithPolicy pass
+type keyChanges struct {
+	entity     *Entity
+	policy     ReferencePolicy
+	incoming   []RelationshipInterface // Relationships targeting the PK, once looked up
+	lookedUp   bool
+	referenced map[string]string // Referenced PK value → referencing attribute (reject)
+	changed    map[string]string // Old PK value → new value, "" when removed (cascade)
+}
+
+func newKeyChanges(entity *Entity, policy ReferencePolicy) *keyChanges {
+	return &keyChanges{entity: entity, policy: policy, changed: make(map[string]string)}
+}
+
+// record notes that a PK value changed from oldValue to newValue, or was removed
+// when newValue is empty
+func (c *keyChanges) record(oldValue, newValue string) error {
+	if c.policy == ReferencesIgnore || oldValue == "" {
+		return nil
+	}
+	if !c.lookedUp {
+		c.incoming = c.entity.incomingReferences()
+		c.lookedUp = true
+	}
+	if len(c.incoming) == 0 {
+		return nil
+	}
+
+	if c.policy == ReferencesCascade {
+		c.changed[oldValue] = newValue
+		return nil
+	}
+
+	if c.referenced == nil {
+		c.referenced = make(map[string]string)
+		for _, relationship := range c.incoming {
+			source := relationship.GetSourceEntity()
+			attrName := relationship.GetSourceAttribute().GetName()
+			_ = source.ForEachRow(func(row *Row, _ int) error {
+				if value := row.GetValue(attrName); value != "" {
+					c.referenced[value] = source.GetName() + "." + attrName
+				}
+				return nil
+			})
+		}
+	}
+	if by, exists := c.referenced[oldValue]; exists {
+		return fmt.Errorf("%w: '%s' is referenced by %s", ErrReferencedKey, oldValue, by)
+	}
+	return nil
+}
+
+// cascade rewrites the foreign keys referencing the changed PK values
+func (c *keyChanges) cascade() error {
+	if len(c.changed) == 0 {
+		return nil
+	}
+	for _, relationship := range c.incoming {
+		source := relationship.GetSourceEntity()
+		attrName := relationship.GetSourceAttribute().GetName()
+		child, _ := source.(*Entity)
+		err := source.ForEachRowWithPolicy(ReferencesCascade, func(row *Row, _ int) error {
+			newValue, exists := c.changed[row.GetValue(attrName)]
+			if !exists {
+				return nil
+			}
+			// Keep the junction table index in step with the row's foreign keys
+			if child != nil {
+				delete(child.usedCompositeKeys, child.getCompositeKey(row))
+			}
+			row.SetValue(attrName, newValue)
+			if child != nil {
+				child.addCompositeKeyToIndex(row)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update references from %s: %w", source.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// incomingReferences returns the relationships whose foreign keys reference the
+// entity's primary key
+func (e *Entity) incomingReferences() []RelationshipInterface {
+	if e.graph == nil || e.primaryKey == nil {
+		return nil
+	}
+	var incoming []RelationshipInterface
+	for _, relationship := range e.graph.GetAllRelationships() {
+		if relationship.GetTargetEntity().GetID() == e.id &&
+			relationship.GetTargetAttribute().GetName() == e.primaryKey.GetName() {
+			incoming = append(incoming, relationship)
+		}
+	}
+	return incoming
+}
@@ -0,0 +1,123 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// referencesGraph builds users, profiles keyed by their user, settings of a profile
+// and memberships of a user
+func referencesGraph(t *testing.T) GraphInterface {
+	t.Helper()
+	def := &parser.SORDefinition{
+		DisplayName: "References",
+		Entities: map[string]parser.Entity{
+			"user": {DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{{Name: "id", ExternalId: "id", Type: "String", UniqueId: true}}},
+			"profile": {DisplayName: "Profile", ExternalId: "Profile",
+				Attributes: []parser.Attribute{{Name: "userId", ExternalId: "userId", Type: "String", UniqueId: true}}},
+			"setting": {DisplayName: "Setting", ExternalId: "Setting",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "profileId", ExternalId: "profileId", Type: "String"},
+				}},
+			"membership": {DisplayName: "Membership", ExternalId: "Membership",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "userId", ExternalId: "userId", Type: "String"},
+				}},
+		},
+		Relationships: map[string]parser.Relationship{
+			"profile":    {DisplayName: "Profile", Name: "profile", FromAttribute: "Profile.userId", ToAttribute: "User.id"},
+			"setting":    {DisplayName: "Setting", Name: "setting", FromAttribute: "Setting.profileId", ToAttribute: "Profile.userId"},
+			"membership": {DisplayName: "Membership", Name: "membership", FromAttribute: "Membership.userId", ToAttribute: "User.id"},
+		},
+	}
+	graph, err := NewGraph(def, 10)
+	require.NoError(t, err)
+
+	rows := map[string][]map[string]string{
+		"User":       {{"id": "u1"}, {"id": "u2"}},
+		"Profile":    {{"userId": "u1"}, {"userId": "u2"}},
+		"Setting":    {{"id": "s1", "profileId": "u1"}, {"id": "s2", "profileId": "u2"}},
+		"Membership": {{"id": "m1", "userId": "u1"}, {"id": "m2", "userId": "u2"}, {"id": "m3", "userId": "u1"}},
+	}
+	for id, entityRows := range rows {
+		entity, ok := graph.GetEntity(id)
+		require.True(t, ok, id)
+		for _, values := range entityRows {
+			require.NoError(t, entity.AddRow(NewRow(values)))
+		}
+	}
+	return graph
+}
+
+// columnValues returns the values of an attribute in row order
+func columnValues(t *testing.T, graph GraphInterface, entityID, attrName string) []string {
+	t.Helper()
+	entity, ok := graph.GetEntity(entityID)
+	require.True(t, ok, entityID)
+	var values []string
+	require.NoError(t, entity.ForEachRow(func(row *Row, _ int) error {
+		values = append(values, row.GetValue(attrName))
+		return nil
+	}))
+	return values
+}
+
+func TestForEachRowWithPolicy(t *testing.T) {
+	// Renames u1 and removes u2
+	edit := func(row *Row, _ int) error {
+		if row.GetValue("id") == "u2" {
+			return ErrSkipRow
+		}
+		row.SetValue("id", "user-"+row.GetValue("id"))
+		return nil
+	}
+
+	t.Run("ignore leaves references orphaned", func(t *testing.T) {
+		graph := referencesGraph(t)
+		user, _ := graph.GetEntity("User")
+		require.NoError(t, user.ForEachRowWithPolicy(ReferencesIgnore, edit))
+
+		assert.Equal(t, []string{"user-u1"}, columnValues(t, graph, "User", "id"))
+		assert.Equal(t, []string{"u1", "u2", "u1"}, columnValues(t, graph, "Membership", "userId"))
+	})
+
+	t.Run("cascade rewrites and clears references", func(t *testing.T) {
+		graph := referencesGraph(t)
+		user, _ := graph.GetEntity("User")
+		require.NoError(t, user.ForEachRowWithPolicy(ReferencesCascade, edit))
+
+		assert.Equal(t, []string{"user-u1", "", "user-u1"}, columnValues(t, graph, "Membership", "userId"))
+		assert.Equal(t, []string{"user-u1", ""}, columnValues(t, graph, "Profile", "userId"))
+		assert.Equal(t, []string{"user-u1", ""}, columnValues(t, graph, "Setting", "profileId"),
+			"a referencing PK that changes cascades in turn")
+
+		profile, _ := graph.GetEntity("Profile")
+		assert.True(t, profile.CheckKeyExists("user-u1"))
+		assert.False(t, profile.CheckKeyExists("u1"))
+	})
+
+	t.Run("reject fails at the first referenced key", func(t *testing.T) {
+		graph := referencesGraph(t)
+		user, _ := graph.GetEntity("User")
+		err := user.ForEachRowWithPolicy(ReferencesReject, edit)
+		require.ErrorIs(t, err, ErrReferencedKey)
+		assert.Contains(t, err.Error(), "'u1'")
+		assert.Equal(t, []string{"u1", "u2", "u1"}, columnValues(t, graph, "Membership", "userId"))
+	})
+
+	t.Run("reject allows unreferenced keys to change", func(t *testing.T) {
+		graph := referencesGraph(t)
+		membership, _ := graph.GetEntity("Membership")
+		require.NoError(t, membership.ForEachRowWithPolicy(ReferencesReject, func(row *Row, _ int) error {
+			row.SetValue("id", "membership-"+row.GetValue("id"))
+			return nil
+		}))
+		assert.Equal(t, []string{"membership-m1", "membership-m2", "membership-m3"}, columnValues(t, graph, "Membership", "id"))
+	})
+}
@@ -18,6 +18,19 @@ type Relationship struct {
 	sourceAttrName string // Store attribute names for setup
 	targetAttrName string
 	cardinality    string
+	reason         string            // Why the cardinality was chosen
+	inverseID      string            // Relationship declaring the same link in the opposite direction, if any
+	where          map[string]string // Target attribute name → value a row must hold to be referenced; nil allows every row
+	weights        []TargetWeight    // How often target rows matching conditions are referenced; nil weighs every row alike
+}
+
+// TargetWeight is a relationship weight with its condition resolved against the
+// target entity
+type TargetWeight struct {
+	Attribute string  // Target attribute name
+	Op        string  // contains, ==, !=, <, <=, > or >=
+	Value     string  // Value the attribute is compared with
+	Weight    float64 // Multiplies the weight of the rows satisfying the condition
 }
 
 // Cardinality constants
@@ -137,6 +150,24 @@ func (r *Relationship) GetTargetAttribute() AttributeInterface {
 	return r.targetAttr
 }
 
+// GetInverseID returns the ID of the relationship declaring this link in the
+// opposite direction, or an empty string when the SOR declares only this one
+func (r *Relationship) GetInverseID() string {
+	return r.inverseID
+}
+
+// GetWhere returns the values, by target attribute name, that a target row must
+// hold for foreign keys to reference it; nil when every row can be referenced
+func (r *Relationship) GetWhere() map[string]string {
+	return r.where
+}
+
+// GetWeights returns the weights scaling how often target rows are referenced;
+// nil when every row is as likely
+func (r *Relationship) GetWeights() []TargetWeight {
+	return r.weights
+}
+
 // GetCardinality returns relationship cardinality (1:1, 1:N, N:1)
 func (r *Relationship) GetCardinality() string {
 	return r.cardinality
@@ -157,28 +188,63 @@ func (r *Relationship) IsManyToOne() bool {
 	return r.cardinality == ManyToOne
 }
 
-// determineCardinality analyzes attributes to determine cardinality
+// GetCardinalityReason explains why the relationship has its cardinality
+func (r *Relationship) GetCardinalityReason() string {
+	return r.reason
+}
+
+// DescribeDistribution explains how target rows are chosen for source rows, the
+// way GetTargetValueForSourceRow chooses them
+func (r *Relationship) DescribeDistribution(autoCardinality bool) string {
+	var distribution string
+	switch {
+	case len(r.weights) > 0 && !autoCardinality:
+		distribution = "shares of the rows in proportion to target rows' weights (auto-cardinality off)"
+	case len(r.weights) > 0:
+		distribution = "random draws in proportion to target rows' weights"
+	case !autoCardinality:
+		distribution = "round-robin over target rows (auto-cardinality off)"
+	case r.cardinality == OneToOne:
+		distribution = "round-robin over target rows (1:1)"
+	default:
+		distribution = fmt.Sprintf("power law with alpha %.2f, clustering references on the first target rows", r.powerLawAlpha())
+	}
+	if r.inverseID != "" {
+		return "each target row once (inverse " + r.inverseID + "), then " + distribution
+	}
+	return distribution
+}
+
+// determineCardinality analyzes attributes to determine cardinality. It depends only
+// on the attributes' uniqueness in the SOR, so it is the same on every run.
 func (r *Relationship) determineCardinality() {
+	source := r.sourceEntity.GetExternalID() + "." + r.sourceAttr.GetExternalID()
+	target := r.targetEntity.GetExternalID() + "." + r.targetAttr.GetExternalID()
+
 	// Both are unique: one-to-one relationship
 	if r.sourceAttr.IsUnique() && r.targetAttr.IsUnique() {
 		r.cardinality = OneToOne
+		r.reason = fmt.Sprintf("%s and %s are both unique IDs", source, target)
 		return
 	}
 
 	// Source is unique, target is not: one-to-many relationship
 	if r.sourceAttr.IsUnique() && !r.targetAttr.IsUnique() {
 		r.cardinality = OneToMany
+		r.reason = fmt.Sprintf("%s is a unique ID and %s is not", source, target)
 		return
 	}
 
 	// Target is unique, source is not: many-to-one relationship
 	if !r.sourceAttr.IsUnique() && r.targetAttr.IsUnique() {
 		r.cardinality = ManyToOne
+		r.reason = fmt.Sprintf("%s is a unique ID and %s is not", target, source)
 		return
 	}
 
 	// Default to many-to-one, though this should not happen due to validation
 	r.cardinality = ManyToOne
+	r.reason = "neither attribute is a unique ID"
 }
 
 // GetTargetValueForSourceRow returns a target PK value for a specific source row
@@ -203,7 +269,7 @@ func (r *Relationship) GetTargetValueForSourceRow(sourceRowIndex int, autoCardin
 	}
 
 	// Select target index using appropriate algorithm
-	targetIndex := r.selectTargetIndex(sourceRowIndex, targetRowCount, autoCardinality)
+	targetIndex := r.SelectTargetIndex(sourceRowIndex, targetRowCount, autoCardinality)
 
 	// Get the target row and extract PK value
 	targetRow := r.targetEntity.GetRowByIndex(targetIndex)
@@ -219,8 +285,15 @@ func (r *Relationship) GetTargetValueForSourceRow(sourceRowIndex int, autoCardin
 	return targetValue, nil
 }
 
-// selectTargetIndex chooses the appropriate target index based on cardinality and settings
-func (r *Relationship) selectTargetIndex(sourceRowIndex, targetRowCount int, autoCardinality bool) int {
+// SelectTargetIndex chooses the appropriate target index based on cardinality and
+// settings, among targetRowCount candidate rows
+func (r *Relationship) SelectTargetIndex(sourceRowIndex, targetRowCount int, autoCardinality bool) int {
+	// The inverse relationship references every target row, so each gets a source
+	// row before the remaining rows follow the usual distribution
+	if r.inverseID != "" && sourceRowIndex < targetRowCount {
+		return sourceRowIndex
+	}
+
 	if !autoCardinality || r.cardinality == OneToOne {
 		// Round-robin for predictable distribution or 1:1 unique assignment
 		return sourceRowIndex % targetRowCount
@@ -230,6 +303,15 @@ func (r *Relationship) selectTargetIndex(sourceRowIndex, targetRowCount int, aut
 	return r.powerLawIndex(sourceRowIndex, targetRowCount)
 }
 
+// powerLawAlpha returns the relationship's power law exponent. It varies with the
+// relationship ID's length so relationships don't share identical distributions,
+// while staying the same on every run of the same SOR.
+func (r *Relationship) powerLawAlpha() float64 {
+	baseAlpha := 1.3                               // Base power law exponent for moderate clustering
+	alphaVariation := float64(len(r.id)%10) * 0.05 // Vary alpha by 0-0.45 based on relationship ID length
+	return baseAlpha + alphaVariation
+}
+
 // powerLawIndex generates a power law distributed index for realistic clustering
 func (r *Relationship) powerLawIndex(sourceRowIndex, targetCount int) int {
 	if targetCount <= 1 {
@@ -251,14 +333,9 @@ func (r *Relationship) powerLawIndex(sourceRowIndex, targetCount int) int {
 	// Normalize sourceRowIndex to [0, 1] range
 	normalizedIndex := float64(sourceRowIndex) / float64(maxSourceIndex)
 
-	// Create relationship-specific alpha variation to avoid identical distributions
-	baseAlpha := 1.3                               // Base power law exponent for moderate clustering
-	alphaVariation := float64(len(r.id)%10) * 0.05 // Vary alpha by 0-0.45 based on relationship ID length
-	alpha := baseAlpha + alphaVariation
-
 	// Apply power law: y = x^alpha where x is normalized input
 	// This creates clustering toward index 0 (lower indices more popular)
-	powerValue := math.Pow(normalizedIndex, alpha)
+	powerValue := math.Pow(normalizedIndex, r.powerLawAlpha())
 
 	// Scale to target count range [0, targetCount-1]
 	index := int(powerValue * float64(targetCount-1))
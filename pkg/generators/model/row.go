@@ -0,0 +1,271 @@
+package model
+
+// Row is a handle to one row of entity data. Until it's added to an entity the
+// row holds its own values; once added, its values live in the entity's columns
+// and the row only records its position, so large entities need no per-row maps.
+type Row struct {
+	values map[string]string // Values of a row not yet added to an entity
+	pinned map[string]bool   // Fields supplied externally that generation stages must preserve
+
+	store *columnStore // Columns holding the values once added to an entity
+	index int          // Position in store
+}
+
+// NewRow creates a new Row with the given values
+func NewRow(values map[string]string) *Row {
+	return &Row{values: values}
+}
+
+// NewPinnedRow creates a Row whose non-empty values were supplied externally
+// (e.g. loaded from partial input CSVs) and must not be regenerated
+func NewPinnedRow(values map[string]string) *Row {
+	pinned := make(map[string]bool, len(values))
+	for field, value := range values {
+		if value != "" {
+			pinned[field] = true
+		}
+	}
+	return &Row{values: values, pinned: pinned}
+}
+
+// IsPinned reports whether a field value was supplied externally and must be preserved
+func (r *Row) IsPinned(fieldName string) bool {
+	if r.store != nil {
+		return r.store.isPinned(fieldName, r.index)
+	}
+	return r.pinned[fieldName]
+}
+
+// SetValue updates a field value in the row
+func (r *Row) SetValue(fieldName, value string) {
+	if r.store != nil {
+		r.store.set(fieldName, r.index, value)
+		return
+	}
+	if r.values == nil {
+		r.values = make(map[string]string)
+	}
+	r.values[fieldName] = value
+}
+
+// SetPinnedValue sets a field value that later generation stages must preserve
+func (r *Row) SetPinnedValue(fieldName, value string) {
+	r.SetValue(fieldName, value)
+	if r.store != nil {
+		r.store.pin(fieldName, r.index)
+		return
+	}
+	if r.pinned == nil {
+		r.pinned = make(map[string]bool)
+	}
+	r.pinned[fieldName] = true
+}
+
+// GetValue gets a field value from the row
+func (r *Row) GetValue(fieldName string) string {
+	if r.store != nil {
+		return r.store.get(fieldName, r.index)
+	}
+	if r.values == nil {
+		return ""
+	}
+	return r.values[fieldName]
+}
+
+// attach moves the row's values into the last position of store
+func (r *Row) attach(store *columnStore) {
+	r.index = store.appendRow(r.values, r.pinned)
+	r.store = store
+	r.values = nil
+	r.pinned = nil
+}
+
+// detach copies the row's values out of its store so the row stays readable
+// after the entity drops it
+func (r *Row) detach() {
+	if r.store == nil {
+		return
+	}
+	r.values, r.pinned = r.store.rowValues(r.index)
+	r.store = nil
+	r.index = 0
+}
+
+// columnStore holds an entity's row values column by column: one string slice
+// per attribute instead of a map per row. Fields that aren't attributes get a
+// column the first time a row sets them.
+type columnStore struct {
+	columnIndex map[string]int // Field name to column
+	names       []string       // Column to field name
+	columns     [][]string     // Values per column, indexed by row
+	pinned      [][]bool       // Pinned flags per column; nil until a value in the column is pinned
+	interners   []*interner    // Shares repeated values per column; nil when interning is disabled
+	noInterning bool           // Keep every value's own copy, for columns added later too
+	length      int            // Number of rows
+}
+
+// newColumnStore creates a store with a column per field, sized for capacity rows
+func newColumnStore(fields []string, capacity int) *columnStore {
+	store := &columnStore{
+		columnIndex: make(map[string]int, len(fields)),
+		names:       make([]string, 0, len(fields)),
+		columns:     make([][]string, 0, len(fields)),
+		pinned:      make([][]bool, 0, len(fields)),
+		interners:   make([]*interner, 0, len(fields)),
+	}
+	for _, field := range fields {
+		store.addColumn(field, capacity)
+	}
+	return store
+}
+
+// addColumn adds an empty column for field, returning its position
+func (s *columnStore) addColumn(field string, capacity int) int {
+	if capacity < s.length {
+		capacity = s.length
+	}
+	s.columnIndex[field] = len(s.columns)
+	s.names = append(s.names, field)
+	s.columns = append(s.columns, make([]string, s.length, capacity))
+	s.pinned = append(s.pinned, nil)
+	var columnInterner *interner
+	if !s.noInterning {
+		columnInterner = newInterner()
+	}
+	s.interners = append(s.interners, columnInterner)
+	return len(s.columns) - 1
+}
+
+// setInterning enables or disables interning of the values set afterwards
+func (s *columnStore) setInterning(enabled bool) {
+	s.noInterning = !enabled
+	for column := range s.interners {
+		if !enabled {
+			s.interners[column] = nil
+		} else if s.interners[column] == nil {
+			s.interners[column] = newInterner()
+		}
+	}
+}
+
+// reserve grows every column's capacity to hold capacity rows
+func (s *columnStore) reserve(capacity int) {
+	for i, column := range s.columns {
+		if cap(column) < capacity {
+			grown := make([]string, len(column), capacity)
+			copy(grown, column)
+			s.columns[i] = grown
+		}
+	}
+}
+
+// appendRow adds a row with the given values, returning its position
+func (s *columnStore) appendRow(values map[string]string, pinned map[string]bool) int {
+	index := s.length
+	s.length++
+	for i := range s.columns {
+		s.columns[i] = append(s.columns[i], "")
+		if s.pinned[i] != nil {
+			s.pinned[i] = append(s.pinned[i], false)
+		}
+	}
+	for field, value := range values {
+		s.set(field, index, value)
+	}
+	for field, isPinned := range pinned {
+		if isPinned {
+			s.pin(field, index)
+		}
+	}
+	return index
+}
+
+func (s *columnStore) get(field string, index int) string {
+	column, exists := s.columnIndex[field]
+	if !exists {
+		return ""
+	}
+	return s.columns[column][index]
+}
+
+func (s *columnStore) set(field string, index int, value string) {
+	column, exists := s.columnIndex[field]
+	if !exists {
+		if value == "" {
+			return
+		}
+		column = s.addColumn(field, 0)
+	}
+	s.columns[column][index] = s.interners[column].intern(value)
+}
+
+func (s *columnStore) isPinned(field string, index int) bool {
+	column, exists := s.columnIndex[field]
+	if !exists || s.pinned[column] == nil {
+		return false
+	}
+	return s.pinned[column][index]
+}
+
+func (s *columnStore) pin(field string, index int) {
+	column, exists := s.columnIndex[field]
+	if !exists {
+		column = s.addColumn(field, 0)
+	}
+	if s.pinned[column] == nil {
+		s.pinned[column] = make([]bool, s.length)
+	}
+	s.pinned[column][index] = true
+}
+
+// rowValues copies the non-empty values and pins of a row into maps
+func (s *columnStore) rowValues(index int) (map[string]string, map[string]bool) {
+	values := make(map[string]string, len(s.columns))
+	var pinned map[string]bool
+	for i, field := range s.names {
+		if value := s.columns[i][index]; value != "" {
+			values[field] = value
+		}
+		if s.pinned[i] != nil && s.pinned[i][index] {
+			if pinned == nil {
+				pinned = make(map[string]bool)
+			}
+			pinned[field] = true
+		}
+	}
+	return values, pinned
+}
+
+// keep compacts the store to the rows whose keep flag is set, preserving order
+func (s *columnStore) keep(keep []bool) {
+	for i := range s.columns {
+		s.columns[i] = compactColumn(s.columns[i], keep)
+		if s.pinned[i] != nil {
+			s.pinned[i] = compactColumn(s.pinned[i], keep)
+		}
+	}
+	kept := 0
+	for _, k := range keep {
+		if k {
+			kept++
+		}
+	}
+	s.length = kept
+}
+
+// compactColumn moves the kept values to the front of column and clears the rest,
+// so dropped strings can be collected
+func compactColumn[T any](column []T, keep []bool) []T {
+	var zero T
+	kept := 0
+	for i, value := range column {
+		if keep[i] {
+			column[kept] = value
+			kept++
+		}
+	}
+	for i := kept; i < len(column); i++ {
+		column[i] = zero
+	}
+	return column[:kept]
+}
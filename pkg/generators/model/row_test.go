@@ -0,0 +1,89 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newColumnarTestEntity creates an entity with an id primary key and a name attribute
+func newColumnarTestEntity(t *testing.T) *Entity {
+	t.Helper()
+	idAttr := &Attribute{name: "id", externalID: "id", isUnique: true}
+	nameAttr := &Attribute{name: "name", externalID: "name"}
+	entity, err := newEntity("test", "Test", "Test Entity", "Description", []AttributeInterface{idAttr, nameAttr}, nil)
+	require.NoError(t, err)
+	return entity.(*Entity)
+}
+
+func TestColumnarRowStorage(t *testing.T) {
+	t.Run("should move row values into the entity's columns", func(t *testing.T) {
+		entity := newColumnarTestEntity(t)
+		row := NewRow(map[string]string{"id": "1", "name": "Alice"})
+		require.NoError(t, entity.AddRow(row))
+
+		assert.Nil(t, row.values, "added rows hold no map of their own")
+		assert.Equal(t, []string{"1"}, entity.store.columns[0])
+		assert.Equal(t, "Alice", row.GetValue("name"))
+
+		row.SetValue("name", "Alicia")
+		assert.Equal(t, "Alicia", entity.GetRowByIndex(0).GetValue("name"))
+		assert.Equal(t, [][]string{{"1", "Alicia"}}, entity.ToCSV().Rows)
+	})
+
+	t.Run("should keep fields that aren't attributes", func(t *testing.T) {
+		entity := newColumnarTestEntity(t)
+		require.NoError(t, entity.AddRow(NewRow(map[string]string{"id": "1"})))
+		require.NoError(t, entity.AddRow(NewRow(map[string]string{"id": "2", "extra": "x"})))
+
+		assert.Equal(t, "", entity.GetRowByIndex(0).GetValue("extra"))
+		assert.Equal(t, "x", entity.GetRowByIndex(1).GetValue("extra"))
+		assert.Equal(t, [][]string{{"1", ""}, {"2", ""}}, entity.ToCSV().Rows, "CSV only has attribute columns")
+	})
+
+	t.Run("should keep pinned fields when added", func(t *testing.T) {
+		entity := newColumnarTestEntity(t)
+		require.NoError(t, entity.AddRow(NewPinnedRow(map[string]string{"id": "1", "name": ""})))
+		require.NoError(t, entity.AddRow(NewRow(map[string]string{"id": "2"})))
+
+		first, second := entity.GetRowByIndex(0), entity.GetRowByIndex(1)
+		assert.True(t, first.IsPinned("id"))
+		assert.False(t, first.IsPinned("name"), "empty values aren't pinned")
+		assert.False(t, second.IsPinned("id"))
+
+		second.SetPinnedValue("name", "Bob")
+		assert.True(t, second.IsPinned("name"))
+		assert.False(t, first.IsPinned("name"))
+	})
+
+	t.Run("should compact columns when rows are removed", func(t *testing.T) {
+		entity := newColumnarTestEntity(t)
+		rows := make([]*Row, 4)
+		for i := range rows {
+			rows[i] = NewRow(map[string]string{"id": fmt.Sprintf("%d", i), "name": fmt.Sprintf("name-%d", i)})
+			require.NoError(t, entity.AddRow(rows[i]))
+		}
+
+		require.NoError(t, entity.RemoveRow(1))
+		err := entity.ForEachRow(func(row *Row, index int) error {
+			if row.GetValue("id") == "2" {
+				return ErrSkipRow
+			}
+			return nil
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, [][]string{{"0", "name-0"}, {"3", "name-3"}}, entity.ToCSV().Rows)
+		assert.Equal(t, 2, entity.store.length)
+		assert.Same(t, rows[3], entity.GetRowByIndex(1), "kept rows keep their identity")
+		assert.Equal(t, "name-1", rows[1].GetValue("name"), "removed rows stay readable")
+		assert.Equal(t, "name-2", rows[2].GetValue("name"), "skipped rows stay readable")
+
+		rows[3].SetValue("name", "changed")
+		assert.Equal(t, "changed", entity.GetRowByIndex(1).GetValue("name"))
+		assert.False(t, entity.CheckKeyExists("1"))
+		assert.False(t, entity.CheckKeyExists("2"))
+	})
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/errcode"
+)
+
+// SharedValuesSource returns the entity and attribute a share of attr's values is
+// taken from, or nils if attr takes no values from another entity
+func (g *Graph) SharedValuesSource(attr AttributeInterface) (EntityInterface, AttributeInterface) {
+	shared := attr.GetSharedValues()
+	if shared == nil {
+		return nil, nil
+	}
+	entityID, attrID, _ := strings.Cut(shared.From, ".")
+	entity := g.entityByExternalID(entityID)
+	if entity == nil {
+		return nil, nil
+	}
+	for _, source := range entity.GetAttributes() {
+		if source.GetExternalID() == attrID {
+			return entity, source
+		}
+	}
+	return nil, nil
+}
+
+// checkSharedValues checks that no foreign key shares values, as its values must
+// reference rows of the entity it targets
+func (g *Graph) checkSharedValues() error {
+	for _, entity := range g.entitiesList {
+		for _, attr := range entity.GetAttributes() {
+			if attr.GetSharedValues() != nil && attr.IsRelationship() {
+				return errcode.Wrap(ErrInvalidEntity, fmt.Errorf(
+					"entity %s attribute '%s' is a foreign key and cannot share values", entity.GetExternalID(), attr.GetName()))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package model
+
+// Traversal follows relationships between rows: from a row to the row its foreign
+// key references, and back to the rows referencing it. The key indexes it needs are
+// built on first use and reflect the rows at that time, so create a traversal once
+// the rows and keys it follows are generated, and a new one after they change.
+type Traversal struct {
+	graph       *Graph
+	indexes     map[keyColumn]map[string][]int      // Indexes of the rows holding each value of a column
+	foreignKeys map[keyColumn]RelationshipInterface // Relationship of each foreign key followed, nil for other attributes
+}
+
+// keyColumn identifies an indexed column by entity ID and attribute name
+type keyColumn struct {
+	entity    string
+	attribute string
+}
+
+// NewTraversal returns a traversal of the graph's rows
+func (g *Graph) NewTraversal() *Traversal {
+	return &Traversal{
+		graph:       g,
+		indexes:     make(map[keyColumn]map[string][]int),
+		foreignKeys: make(map[keyColumn]RelationshipInterface),
+	}
+}
+
+// ForeignKeyRelationship returns the relationship whose foreign key is the named
+// attribute of entity, or nil if the attribute references nothing
+func (g *Graph) ForeignKeyRelationship(entity EntityInterface, fkAttr string) RelationshipInterface {
+	for _, relationship := range g.relationshipsList {
+		if relationship.GetSourceEntity().GetID() == entity.GetID() &&
+			relationship.GetSourceAttribute().GetName() == fkAttr {
+			return relationship
+		}
+	}
+	return nil
+}
+
+// GetParentRow returns the row the foreign key fkAttr of entity's row references:
+// the first row of the relationship's target entity holding the key's value. It
+// returns false when fkAttr is not a foreign key, or the row's key is empty or
+// references no row.
+func (t *Traversal) GetParentRow(entity EntityInterface, row *Row, fkAttr string) (*Row, bool) {
+	column := keyColumn{entity.GetID(), fkAttr}
+	relationship, exists := t.foreignKeys[column]
+	if !exists {
+		relationship = t.graph.ForeignKeyRelationship(entity, fkAttr)
+		t.foreignKeys[column] = relationship
+	}
+	if relationship == nil {
+		return nil, false
+	}
+	return t.parent(relationship, row.GetValue(fkAttr))
+}
+
+// GetChildren returns the rows of the relationship's source entity whose foreign key
+// references parent, a row of its target entity, in row order
+func (t *Traversal) GetChildren(relationship RelationshipInterface, parent *Row) []*Row {
+	key := parent.GetValue(relationship.GetTargetAttribute().GetName())
+	if key == "" {
+		return nil
+	}
+	source := relationship.GetSourceEntity()
+	indexes := t.index(source, relationship.GetSourceAttribute().GetName())[key]
+	children := make([]*Row, 0, len(indexes))
+	for _, i := range indexes {
+		children = append(children, source.GetRowByIndex(i))
+	}
+	return children
+}
+
+// ForEachJoinedRow calls fn with each row of the relationship's source entity and
+// the row its foreign key references, in source row order. Rows whose key is empty
+// or references no row are skipped. Iteration stops at the first error fn returns.
+func (t *Traversal) ForEachJoinedRow(relationship RelationshipInterface, fn func(child, parent *Row) error) error {
+	foreignKey := relationship.GetSourceAttribute().GetName()
+	return relationship.GetSourceEntity().ForEachRow(func(row *Row, _ int) error {
+		parent, found := t.parent(relationship, row.GetValue(foreignKey))
+		if !found {
+			return nil
+		}
+		return fn(row, parent)
+	})
+}
+
+// parent returns the first row of the relationship's target entity holding key
+func (t *Traversal) parent(relationship RelationshipInterface, key string) (*Row, bool) {
+	if key == "" {
+		return nil, false
+	}
+	target := relationship.GetTargetEntity()
+	indexes := t.index(target, relationship.GetTargetAttribute().GetName())[key]
+	if len(indexes) == 0 {
+		return nil, false
+	}
+	return target.GetRowByIndex(indexes[0]), true
+}
+
+// index returns the row indexes holding each value of an entity's attribute,
+// building it on first use
+func (t *Traversal) index(entity EntityInterface, attribute string) map[string][]int {
+	column := keyColumn{entity.GetID(), attribute}
+	if index, exists := t.indexes[column]; exists {
+		return index
+	}
+	index := make(map[string][]int, entity.GetRowCount())
+	for i := 0; i < entity.GetRowCount(); i++ {
+		value := entity.GetRowByIndex(i).GetValue(attribute)
+		index[value] = append(index[value], i)
+	}
+	t.indexes[column] = index
+	return index
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraversal(t *testing.T) {
+	graphInterface := referencesGraph(t)
+	graph := graphInterface.(*Graph)
+	user, _ := graph.GetEntity("User")
+	membership, _ := graph.GetEntity("Membership")
+	relationship, _ := graph.GetRelationship("membership")
+	ids := func(rows []*Row) []string {
+		var values []string
+		for _, row := range rows {
+			values = append(values, row.GetValue("id"))
+		}
+		return values
+	}
+
+	// A membership whose user doesn't exist, and one without a user
+	require.NoError(t, membership.AddRow(NewRow(map[string]string{"id": "m4", "userId": "u9"})))
+	require.NoError(t, membership.AddRow(NewRow(map[string]string{"id": "m5"})))
+	traversal := graph.NewTraversal()
+
+	t.Run("parent row", func(t *testing.T) {
+		parent, found := traversal.GetParentRow(membership, membership.GetRowByIndex(2), "userId")
+		require.True(t, found)
+		assert.Equal(t, "u1", parent.GetValue("id"))
+
+		_, found = traversal.GetParentRow(membership, membership.GetRowByIndex(3), "userId")
+		assert.False(t, found, "dangling key")
+		_, found = traversal.GetParentRow(membership, membership.GetRowByIndex(4), "userId")
+		assert.False(t, found, "empty key")
+		_, found = traversal.GetParentRow(membership, membership.GetRowByIndex(0), "id")
+		assert.False(t, found, "not a foreign key")
+	})
+
+	t.Run("children", func(t *testing.T) {
+		assert.Equal(t, []string{"m1", "m3"}, ids(traversal.GetChildren(relationship, user.GetRowByIndex(0))))
+		assert.Equal(t, []string{"m2"}, ids(traversal.GetChildren(relationship, user.GetRowByIndex(1))))
+	})
+
+	t.Run("joined rows", func(t *testing.T) {
+		var pairs []string
+		require.NoError(t, traversal.ForEachJoinedRow(relationship, func(child, parent *Row) error {
+			pairs = append(pairs, child.GetValue("id")+"->"+parent.GetValue("id"))
+			return nil
+		}))
+		assert.Equal(t, []string{"m1->u1", "m2->u2", "m3->u1"}, pairs, "rows without a parent are skipped")
+
+		stop := errors.New("stop")
+		calls := 0
+		err := traversal.ForEachJoinedRow(relationship, func(_, _ *Row) error {
+			calls++
+			return stop
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, calls)
+	})
+}
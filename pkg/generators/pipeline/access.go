@@ -0,0 +1,312 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/SGNL-ai/fabricator/pkg/config"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// AccessGroundTruthFile is the name of the access simulation report in the output directory
+const AccessGroundTruthFile = "access_ground_truth.json"
+
+// AccessGroundTruth records which users hold each simulated role and which violate
+// each SoD rule, computed from the final assignment rows so policy engines can be
+// checked against it
+type AccessGroundTruth struct {
+	Assignment string             `json:"assignment"` // External ID of the assignment entity
+	Users      int                `json:"users"`      // Number of users considered
+	Roles      []RoleGroundTruth  `json:"roles"`
+	SoDRules   []SoDRuleViolators `json:"sodRules"`
+}
+
+// RoleGroundTruth lists a role's entitlements and the users holding all of them
+type RoleGroundTruth struct {
+	Role         string   `json:"role"`
+	Entitlements []string `json:"entitlements"`
+	Users        []string `json:"users"`
+}
+
+// SoDRuleViolators lists the users holding every role of an SoD rule
+type SoDRuleViolators struct {
+	Rule      string   `json:"rule"`
+	Roles     []string `json:"roles"`
+	Violators []string `json:"violators"`
+}
+
+// accessLinks are the assignment entity's foreign keys to users and entitlements
+type accessLinks struct {
+	assignment   model.EntityInterface
+	userAttr     string   // Assignment attribute holding the user key
+	entAttr      string   // Assignment attribute holding the entitlement key
+	users        []string // User keys in row order
+	entitlements []string // Entitlement keys in row order
+}
+
+// resolveAccessLinks finds the assignment entity and the users and entitlements its
+// foreign keys reference
+func resolveAccessLinks(graph *model.Graph, cfg *config.AccessConfiguration) (*accessLinks, error) {
+	assignment, exists := graph.GetEntity(cfg.Assignment)
+	if !exists {
+		return nil, fmt.Errorf("assignment entity '%s' not found", cfg.Assignment)
+	}
+
+	links := &accessLinks{assignment: assignment, userAttr: cfg.User, entAttr: cfg.Entitlement}
+	for _, relationship := range graph.GetRelationshipsForEntity(assignment.GetID()) {
+		if relationship.GetSourceEntity().GetID() != assignment.GetID() ||
+			relationship.GetTargetEntity().GetID() == assignment.GetID() {
+			continue
+		}
+		switch relationship.GetSourceAttribute().GetName() {
+		case cfg.User:
+			links.users = columnValues(relationship.GetTargetEntity(), relationship.GetTargetAttribute().GetName())
+		case cfg.Entitlement:
+			links.entitlements = columnValues(relationship.GetTargetEntity(), relationship.GetTargetAttribute().GetName())
+		}
+	}
+
+	for _, attr := range []string{cfg.User, cfg.Entitlement} {
+		if _, exists := assignment.GetAttribute(attr); !exists {
+			return nil, fmt.Errorf("attribute '%s' not found in assignment entity %s", attr, cfg.Assignment)
+		}
+	}
+	if links.users == nil {
+		return nil, fmt.Errorf("%s.%s is not a relationship to a user entity", cfg.Assignment, cfg.User)
+	}
+	if links.entitlements == nil {
+		return nil, fmt.Errorf("%s.%s is not a relationship to an entitlement entity", cfg.Assignment, cfg.Entitlement)
+	}
+	return links, nil
+}
+
+// columnValues returns an attribute's values in row order
+func columnValues(entity model.EntityInterface, attrName string) []string {
+	values := make([]string, 0, entity.GetRowCount())
+	for i := 0; i < entity.GetRowCount(); i++ {
+		values = append(values, entity.GetRowByIndex(i).GetValue(attrName))
+	}
+	return values
+}
+
+// shareOf returns how many of n users a share covers, rounded to the nearest user
+func shareOf(share config.Share, n int) int {
+	return int(math.Round(float64(share) * float64(n)))
+}
+
+// simulateAccess rewrites the assignment entity's user and entitlement keys so roles
+// and SoD violations follow the configured distribution:
+//
+//   - each SoD rule gets rate × users violators, holding every role of the rule
+//   - each role is then topped up to its share of users, never completing a rule
+//     for a user who isn't one of its planted violators
+//   - assignment rows beyond those the roles need get entitlements outside every
+//     role; rows that can't get a distinct pair are dropped
+//
+// The ground truth is computed from the rewritten rows.
+func simulateAccess(graph *model.Graph, cfg *config.AccessConfiguration, streams *RandomStreams) (*AccessGroundTruth, error) {
+	links, err := resolveAccessLinks(graph, cfg)
+	if err != nil {
+		return nil, err
+	}
+	users := len(links.users)
+
+	// Roles take consecutive entitlements in row order; the rest are unprivileged
+	roleEntitlements := make(map[string][]string, len(cfg.Roles))
+	offset := 0
+	for _, role := range cfg.Roles {
+		if offset+role.Entitlements > len(links.entitlements) {
+			return nil, fmt.Errorf("roles grant more entitlements than the %d generated; raise the entitlement entity's row count",
+				len(links.entitlements))
+		}
+		roleEntitlements[role.Name] = links.entitlements[offset : offset+role.Entitlements]
+		offset += role.Entitlements
+	}
+	unprivileged := links.entitlements[offset:]
+
+	// held[user][role] records the roles assigned to each user index
+	held := make([]map[string]bool, users)
+	for i := range held {
+		held[i] = make(map[string]bool)
+	}
+
+	// Plant each rule's violators, taking distinct users while they last; the order
+	// is drawn from a stream of its own, like entities' values
+	faker := streams.stream("access")
+	order := shuffledIndexes(users, faker)
+	next := 0
+	for _, rule := range cfg.SoDRules {
+		for n := shareOf(rule.Rate, users); n > 0 && users > 0; n-- {
+			user := order[next%users]
+			next++
+			for _, role := range rule.Roles {
+				held[user][role] = true
+			}
+		}
+	}
+
+	// Top up each role without creating violations that weren't planted
+	completesRule := func(user int, role string) bool {
+		for _, rule := range cfg.SoDRules {
+			completes, inRule := true, false
+			for _, ruleRole := range rule.Roles {
+				if ruleRole == role {
+					inRule = true
+				} else if !held[user][ruleRole] {
+					completes = false
+				}
+			}
+			if inRule && completes {
+				return true
+			}
+		}
+		return false
+	}
+	for _, role := range cfg.Roles {
+		holders := 0
+		for user := range held {
+			if held[user][role.Name] {
+				holders++
+			}
+		}
+		for _, user := range shuffledIndexes(users, faker) {
+			if holders >= shareOf(role.Users, users) {
+				break
+			}
+			if held[user][role.Name] || completesRule(user, role.Name) {
+				continue
+			}
+			held[user][role.Name] = true
+			holders++
+		}
+	}
+
+	// Expand role holdings into (user, entitlement) pairs in user order
+	var pairs [][2]string
+	for user, roles := range held {
+		for _, role := range cfg.Roles {
+			if !roles[role.Name] {
+				continue
+			}
+			for _, entitlement := range roleEntitlements[role.Name] {
+				pairs = append(pairs, [2]string{links.users[user], entitlement})
+			}
+		}
+	}
+
+	assignment := links.assignment
+	if len(pairs) > assignment.GetRowCount() {
+		return nil, fmt.Errorf("roles need %d %s rows but only %d are generated; raise its row count",
+			len(pairs), assignment.GetExternalID(), assignment.GetRowCount())
+	}
+
+	// Remaining rows get distinct unprivileged pairs: pass j gives user u the
+	// entitlement (u+j) mod the unprivileged count
+	filler := func(k int) ([2]string, bool) {
+		if users == 0 || k >= users*len(unprivileged) {
+			return [2]string{}, false
+		}
+		user, pass := k%users, k/users
+		return [2]string{links.users[user], unprivileged[(user+pass)%len(unprivileged)]}, true
+	}
+
+	err = assignment.ForEachRow(func(row *model.Row, index int) error {
+		pair := [2]string{}
+		if index < len(pairs) {
+			pair = pairs[index]
+		} else if filled, ok := filler(index - len(pairs)); ok {
+			pair = filled
+		} else {
+			return model.ErrSkipRow
+		}
+		row.SetValue(links.userAttr, pair[0])
+		row.SetValue(links.entAttr, pair[1])
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewrite %s rows: %w", assignment.GetExternalID(), err)
+	}
+
+	return accessGroundTruth(links, cfg, roleEntitlements), nil
+}
+
+// accessGroundTruth reads the assignment rows back and reports the users holding
+// each role (all of its entitlements) and each SoD rule (all of its roles)
+func accessGroundTruth(links *accessLinks, cfg *config.AccessConfiguration, roleEntitlements map[string][]string) *AccessGroundTruth {
+	assigned := make(map[string]map[string]bool, len(links.users))
+	for i := 0; i < links.assignment.GetRowCount(); i++ {
+		row := links.assignment.GetRowByIndex(i)
+		user := row.GetValue(links.userAttr)
+		if assigned[user] == nil {
+			assigned[user] = make(map[string]bool)
+		}
+		assigned[user][row.GetValue(links.entAttr)] = true
+	}
+
+	holds := func(user, role string) bool {
+		for _, entitlement := range roleEntitlements[role] {
+			if !assigned[user][entitlement] {
+				return false
+			}
+		}
+		return true
+	}
+
+	truth := &AccessGroundTruth{
+		Assignment: links.assignment.GetExternalID(),
+		Users:      len(links.users),
+		Roles:      make([]RoleGroundTruth, 0, len(cfg.Roles)),
+		SoDRules:   make([]SoDRuleViolators, 0, len(cfg.SoDRules)),
+	}
+	for _, role := range cfg.Roles {
+		result := RoleGroundTruth{Role: role.Name, Entitlements: roleEntitlements[role.Name], Users: []string{}}
+		for _, user := range links.users {
+			if holds(user, role.Name) {
+				result.Users = append(result.Users, user)
+			}
+		}
+		truth.Roles = append(truth.Roles, result)
+	}
+	for _, rule := range cfg.SoDRules {
+		result := SoDRuleViolators{Rule: rule.Name, Roles: rule.Roles, Violators: []string{}}
+		for _, user := range links.users {
+			violates := true
+			for _, role := range rule.Roles {
+				if !holds(user, role) {
+					violates = false
+					break
+				}
+			}
+			if violates {
+				result.Violators = append(result.Violators, user)
+			}
+		}
+		truth.SoDRules = append(truth.SoDRules, result)
+	}
+	return truth
+}
+
+// shuffledIndexes returns 0..n-1 in an order drawn from faker
+func shuffledIndexes(n int, faker *gofakeit.Faker) []int {
+	indexes := make([]int, n)
+	for i := range indexes {
+		indexes[i] = i
+	}
+	faker.ShuffleInts(indexes)
+	return indexes
+}
+
+// WriteAccessGroundTruth writes the access simulation report as indented JSON,
+// encrypted when files are
+func WriteAccessGroundTruth(path string, truth *AccessGroundTruth, files *OutputFiles) error {
+	content, err := json.MarshalIndent(truth, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode access ground truth: %w", err)
+	}
+	if err := files.write(path, append(content, '\n')); err != nil {
+		return fmt.Errorf("failed to write access ground truth: %w", err)
+	}
+	return nil
+}
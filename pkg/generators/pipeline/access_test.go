@@ -0,0 +1,206 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/config"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// accessTestDefinition returns users, groups and a GroupMember assignment entity
+func accessTestDefinition() *parser.SORDefinition {
+	return &parser.SORDefinition{
+		DisplayName: "IGA",
+		Description: "SOR with entitlement assignments",
+		Entities: map[string]parser.Entity{
+			"user": {DisplayName: "User", ExternalId: "User", Attributes: []parser.Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+			}},
+			"group": {DisplayName: "Group", ExternalId: "Group", Attributes: []parser.Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+			}},
+			"member": {DisplayName: "GroupMember", ExternalId: "GroupMember", Attributes: []parser.Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "userId", ExternalId: "userId", Type: "String"},
+				{Name: "groupId", ExternalId: "groupId", Type: "String"},
+			}},
+		},
+		Relationships: map[string]parser.Relationship{
+			"member_user":  {Name: "member_user", FromAttribute: "GroupMember.userId", ToAttribute: "User.id"},
+			"member_group": {Name: "member_group", FromAttribute: "GroupMember.groupId", ToAttribute: "Group.id"},
+		},
+	}
+}
+
+// accessTestGraph builds the entities of accessTestDefinition with linked relationships
+func accessTestGraph(t *testing.T, rowCounts map[string]int) *model.Graph {
+	t.Helper()
+	graphInterface, err := model.NewGraph(accessTestDefinition(), 100)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	require.NoError(t, NewIDGenerator().GenerateIDs(graph, rowCounts))
+	require.NoError(t, NewRelationshipLinker().LinkRelationships(graph, false))
+	return graph
+}
+
+func accessTestConfig() *config.AccessConfiguration {
+	return &config.AccessConfiguration{
+		Assignment:  "GroupMember",
+		User:        "userId",
+		Entitlement: "groupId",
+		Roles: []config.AccessRole{
+			{Name: "admin", Entitlements: 2, Users: 0.05},
+			{Name: "payments-approve", Entitlements: 1, Users: 0.10},
+			{Name: "payments-create", Entitlements: 1, Users: 0.30},
+		},
+		SoDRules: []config.SoDRule{
+			{Name: "approve-own-payments", Roles: []string{"payments-approve", "payments-create"}, Rate: 0.02},
+		},
+	}
+}
+
+func TestSimulateAccess(t *testing.T) {
+	// Coprime user and group counts keep every linked GroupMember row distinct
+	graph := accessTestGraph(t, map[string]int{"User": 100, "Group": 11, "GroupMember": 300})
+
+	truth, err := simulateAccess(graph, accessTestConfig(), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "GroupMember", truth.Assignment)
+	assert.Equal(t, 100, truth.Users)
+	require.Len(t, truth.Roles, 3)
+	assert.Len(t, truth.Roles[0].Entitlements, 2)
+	assert.Len(t, truth.Roles[0].Users, 5, "5% of users are admins")
+	assert.Len(t, truth.Roles[1].Users, 10, "10% of users approve payments")
+	assert.Len(t, truth.Roles[2].Users, 30, "30% of users create payments")
+
+	require.Len(t, truth.SoDRules, 1)
+	assert.Len(t, truth.SoDRules[0].Violators, 2, "exactly the planted 2% violate the rule")
+
+	// Violators hold both roles; nobody else does
+	approvers := make(map[string]bool)
+	for _, user := range truth.Roles[1].Users {
+		approvers[user] = true
+	}
+	var both []string
+	for _, user := range truth.Roles[2].Users {
+		if approvers[user] {
+			both = append(both, user)
+		}
+	}
+	assert.ElementsMatch(t, both, truth.SoDRules[0].Violators)
+
+	// Every assignment row is kept with a distinct, valid pair
+	member, _ := graph.GetEntity("GroupMember")
+	assert.Equal(t, 300, member.GetRowCount())
+	seen := make(map[[2]string]bool)
+	for i := 0; i < member.GetRowCount(); i++ {
+		row := member.GetRowByIndex(i)
+		pair := [2]string{row.GetValue("userId"), row.GetValue("groupId")}
+		assert.False(t, seen[pair], "row %d repeats an assignment", i)
+		seen[pair] = true
+	}
+	assert.Empty(t, NewValidation().ValidateRelationships(graph))
+}
+
+func TestSimulateAccess_DropsRowsWithoutDistinctPairs(t *testing.T) {
+	// 10 users × 3 unprivileged groups leave room for only 30 filler rows
+	graph := accessTestGraph(t, map[string]int{"User": 10, "Group": 7, "GroupMember": 50})
+	cfg := accessTestConfig()
+	cfg.SoDRules = nil
+
+	truth, err := simulateAccess(graph, cfg, nil)
+	require.NoError(t, err)
+
+	member, _ := graph.GetEntity("GroupMember")
+	roleRows := 2*len(truth.Roles[0].Users) + len(truth.Roles[1].Users) + len(truth.Roles[2].Users)
+	assert.Equal(t, roleRows+30, member.GetRowCount())
+}
+
+func TestSimulateAccess_AfterWeightedRelationships(t *testing.T) {
+	// Weighted keys are assigned again once fields are generated; the assignments
+	// access simulation writes must not be among them
+	def := accessTestDefinition()
+	memberGroup := def.Relationships["member_group"]
+	memberGroup.Weights = []parser.TargetWeight{{When: "id contains a", Weight: 5}}
+	def.Relationships["member_group"] = memberGroup
+	graphInterface, err := model.NewGraph(def, 100)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+
+	generator := NewDataGenerator(t.TempDir(), map[string]int{"User": 100, "Group": 11, "GroupMember": 300}, true)
+	generator.SetAccessSimulation(accessTestConfig())
+	audit := NewAuditLog()
+	generator.SetAuditLog(audit)
+	require.NoError(t, generator.Generate(graph))
+
+	member, _ := graph.GetEntity("GroupMember")
+	held := make(map[[2]string]bool)
+	for i := 0; i < member.GetRowCount(); i++ {
+		row := member.GetRowByIndex(i)
+		held[[2]string{row.GetValue("userId"), row.GetValue("groupId")}] = true
+	}
+	truth := generator.AccessGroundTruth()
+	require.NotNil(t, truth)
+	for _, role := range truth.Roles {
+		for _, user := range role.Users {
+			for _, entitlement := range role.Entitlements {
+				assert.True(t, held[[2]string{user, entitlement}], "%s holds role %s", user, role.Role)
+			}
+		}
+	}
+
+	// The audit explains the keys as written
+	for _, record := range audit.Records() {
+		if record.Decision == AuditCardinality && record.Relationship == "member_group" {
+			assert.Equal(t, member.GetRowCount(), record.Count)
+		}
+	}
+}
+
+func TestSimulateAccess_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		rowCounts map[string]int
+		modify    func(*config.AccessConfiguration)
+		wantErr   string
+	}{
+		{
+			name:      "unknown assignment entity",
+			rowCounts: map[string]int{"User": 10, "Group": 10, "GroupMember": 10},
+			modify:    func(c *config.AccessConfiguration) { c.Assignment = "Grant" },
+			wantErr:   "assignment entity 'Grant' not found",
+		},
+		{
+			name:      "attribute that is not a relationship",
+			rowCounts: map[string]int{"User": 10, "Group": 10, "GroupMember": 10},
+			modify:    func(c *config.AccessConfiguration) { c.User = "id" },
+			wantErr:   "GroupMember.id is not a relationship to a user entity",
+		},
+		{
+			name:      "more role entitlements than rows",
+			rowCounts: map[string]int{"User": 10, "Group": 3, "GroupMember": 10},
+			modify:    func(*config.AccessConfiguration) {},
+			wantErr:   "roles grant more entitlements than the 3 generated",
+		},
+		{
+			name:      "too few assignment rows",
+			rowCounts: map[string]int{"User": 100, "Group": 10, "GroupMember": 20},
+			modify:    func(*config.AccessConfiguration) {},
+			wantErr:   "roles need 50 GroupMember rows but only 20 are generated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := accessTestGraph(t, tt.rowCounts)
+			cfg := accessTestConfig()
+			tt.modify(cfg)
+			_, err := simulateAccess(graph, cfg, nil)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// How closely the parts of an entity's addresses agree
+const (
+	AddressCoherenceStrict = "strict" // Street, city, state, postal code and country all from one place
+	AddressCoherenceLoose  = "loose"  // Parts from the same country, but not necessarily the same place
+	AddressCoherenceOff    = "off"    // Each part generated on its own
+)
+
+// AddressCoherenceModes lists the accepted address coherence modes
+var AddressCoherenceModes = []string{AddressCoherenceStrict, AddressCoherenceLoose, AddressCoherenceOff}
+
+// Address parts an address-like entity's attributes can hold
+const (
+	addressStreet      = "street"
+	addressCity        = "city"
+	addressState       = "state"
+	addressPostalCode  = "postalCode"
+	addressCountry     = "country"
+	addressCountryCode = "countryCode"
+	addressFull        = "address" // The other parts formatted as one line
+)
+
+// addressAttributes maps normalized attribute names (see personAttributeName) to the
+// address part they hold
+var addressAttributes = map[string]string{
+	"street":         addressStreet,
+	"streetaddress":  addressStreet,
+	"street1":        addressStreet,
+	"address1":       addressStreet,
+	"addressline1":   addressStreet,
+	"city":           addressCity,
+	"town":           addressCity,
+	"locality":       addressCity,
+	"state":          addressState,
+	"province":       addressState,
+	"region":         addressState,
+	"stateprovince":  addressState,
+	"zip":            addressPostalCode,
+	"zipcode":        addressPostalCode,
+	"postalcode":     addressPostalCode,
+	"postcode":       addressPostalCode,
+	"country":        addressCountry,
+	"countryname":    addressCountry,
+	"countrycode":    addressCountryCode,
+	"address":        addressFull,
+	"fulladdress":    addressFull,
+	"mailingaddress": addressFull,
+	"postaladdress":  addressFull,
+}
+
+// addressLocale is a country addresses are drawn from, with the places in it
+type addressLocale struct {
+	code   string
+	name   string
+	places []addressPlace
+}
+
+// addressPlace is a city with its state or region and the pattern of its postal
+// codes: # is a digit and ? a letter
+type addressPlace struct {
+	city   string
+	state  string
+	postal string
+}
+
+// addressLocales holds the countries addresses are drawn from, the locales of the
+// nationalId generator
+var addressLocales = []addressLocale{
+	{code: "CA", name: "Canada", places: []addressPlace{
+		{"Toronto", "ON", "M5? #?#"},
+		{"Ottawa", "ON", "K1? #?#"},
+		{"Montreal", "QC", "H2? #?#"},
+		{"Vancouver", "BC", "V6? #?#"},
+		{"Calgary", "AB", "T2? #?#"},
+		{"Halifax", "NS", "B3? #?#"},
+	}},
+	{code: "GB", name: "United Kingdom", places: []addressPlace{
+		{"London", "England", "SE1 #??"},
+		{"Manchester", "England", "M1 #??"},
+		{"Birmingham", "England", "B1 #??"},
+		{"Edinburgh", "Scotland", "EH1 #??"},
+		{"Glasgow", "Scotland", "G1 #??"},
+		{"Cardiff", "Wales", "CF10 #??"},
+		{"Belfast", "Northern Ireland", "BT1 #??"},
+	}},
+	{code: "IN", name: "India", places: []addressPlace{
+		{"Mumbai", "Maharashtra", "4000##"},
+		{"Pune", "Maharashtra", "4110##"},
+		{"Bengaluru", "Karnataka", "5600##"},
+		{"Chennai", "Tamil Nadu", "6000##"},
+		{"New Delhi", "Delhi", "1100##"},
+		{"Hyderabad", "Telangana", "5000##"},
+		{"Kolkata", "West Bengal", "7000##"},
+	}},
+	{code: "US", name: "United States", places: []addressPlace{
+		{"San Francisco", "CA", "941##"},
+		{"Los Angeles", "CA", "900##"},
+		{"New York", "NY", "100##"},
+		{"Buffalo", "NY", "142##"},
+		{"Austin", "TX", "787##"},
+		{"Houston", "TX", "770##"},
+		{"Chicago", "IL", "606##"},
+		{"Seattle", "WA", "981##"},
+		{"Boston", "MA", "021##"},
+		{"Denver", "CO", "802##"},
+		{"Atlanta", "GA", "303##"},
+		{"Miami", "FL", "331##"},
+	}},
+}
+
+// postalLetters are the letters drawn for ? in postal code patterns, those valid
+// in both Canadian postal codes and the inward part of British postcodes
+const postalLetters = "ABEGHJLNPRSTWXYZ"
+
+// Address is one generated postal address
+type Address struct {
+	Street      string
+	City        string
+	State       string
+	PostalCode  string
+	Country     string
+	CountryCode string
+}
+
+// addressFields returns the address part each attribute of an address-like entity
+// holds, keyed by attribute name. An entity is address-like when at least two of
+// its attributes hold a city, state, postal code or country, so a lone state or
+// region attribute keeps its generated values. Attributes with a generator hint, a
+// default or a list encoding are left to generate.
+func addressFields(attributes []model.AttributeInterface) map[string]string {
+	fields := make(map[string]string)
+	located := 0
+	for _, attr := range attributes {
+		if attr.GetGenerator() != nil || attr.GetDefault() != nil || attr.GetListEncoding() != "" {
+			continue
+		}
+		if part, exists := addressAttributes[personAttributeName(attr.GetName())]; exists {
+			fields[attr.GetName()] = part
+			if part != addressStreet && part != addressFull {
+				located++
+			}
+		}
+	}
+	if located < 2 {
+		return nil
+	}
+	return fields
+}
+
+// newAddress draws an address. Strict coherence takes every part from one place;
+// loose coherence keeps the country but draws the state, city and postal code from
+// any of its places.
+func newAddress(coherence string, faker *gofakeit.Faker) Address {
+	locale := addressLocales[faker.Number(0, len(addressLocales)-1)]
+	pick := func() addressPlace {
+		return locale.places[faker.Number(0, len(locale.places)-1)]
+	}
+	place := pick()
+	city, postal := place, place
+	if coherence == AddressCoherenceLoose {
+		city, postal = pick(), pick()
+	}
+	return Address{
+		Street:      faker.Street(),
+		City:        city.city,
+		State:       place.state,
+		PostalCode:  postalCode(postal.postal, faker),
+		Country:     locale.name,
+		CountryCode: locale.code,
+	}
+}
+
+// postalCode fills a postal code pattern with random digits and letters
+func postalCode(pattern string, faker *gofakeit.Faker) string {
+	var code strings.Builder
+	for _, c := range pattern {
+		switch c {
+		case '#':
+			code.WriteByte(byte('0' + faker.Number(0, 9)))
+		case '?':
+			code.WriteByte(postalLetters[faker.Number(0, len(postalLetters)-1)])
+		default:
+			code.WriteRune(c)
+		}
+	}
+	return code.String()
+}
+
+// value returns the address's value for an address part
+func (a Address) value(part string) string {
+	switch part {
+	case addressStreet:
+		return a.Street
+	case addressCity:
+		return a.City
+	case addressState:
+		return a.State
+	case addressPostalCode:
+		return a.PostalCode
+	case addressCountry:
+		return a.Country
+	case addressCountryCode:
+		return a.CountryCode
+	}
+	return a.String()
+}
+
+// String formats the address on one line, the way its country writes it
+func (a Address) String() string {
+	if a.CountryCode == "GB" {
+		return fmt.Sprintf("%s, %s %s, %s", a.Street, a.City, a.PostalCode, a.Country)
+	}
+	return fmt.Sprintf("%s, %s, %s %s, %s", a.Street, a.City, a.State, a.PostalCode, a.Country)
+}
@@ -0,0 +1,127 @@
+package pipeline
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addressPlaceOf returns the place of a city, and whether it is one
+func addressPlaceOf(city string) (addressLocale, addressPlace, bool) {
+	for _, locale := range addressLocales {
+		for _, place := range locale.places {
+			if place.city == city {
+				return locale, place, true
+			}
+		}
+	}
+	return addressLocale{}, addressPlace{}, false
+}
+
+// postalPattern matches the postal codes of a place's pattern
+func postalPattern(pattern string) *regexp.Regexp {
+	expression := strings.NewReplacer("#", `\d`, "?", "["+postalLetters+"]").Replace(pattern)
+	return regexp.MustCompile("^" + expression + "$")
+}
+
+func TestFieldGenerator_AddressCoherence(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Addresses",
+		Entities: map[string]parser.Entity{
+			"office": {
+				DisplayName: "Office", ExternalId: "Office",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "street_address", ExternalId: "street_address", Type: "String"},
+					{Name: "city", ExternalId: "city", Type: "String"},
+					{Name: "stateProvince", ExternalId: "stateProvince", Type: "String"},
+					{Name: "postalCode", ExternalId: "postalCode", Type: "String"},
+					{Name: "countryCode", ExternalId: "countryCode", Type: "String"},
+					{Name: "address", ExternalId: "address", Type: "String"},
+				},
+			},
+			"ticket": {
+				DisplayName: "Ticket", ExternalId: "Ticket",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "state", ExternalId: "state", Type: "String"},
+				},
+			},
+		},
+	}
+	generate := func(t *testing.T, coherence string) (model.EntityInterface, model.EntityInterface) {
+		graphInterface, err := model.NewGraph(def, 50)
+		require.NoError(t, err)
+		graph := graphInterface.(*model.Graph)
+		require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"Office": 50, "Ticket": 5}))
+		generator := &FieldGenerator{}
+		generator.SetAddressCoherence(coherence)
+		require.NoError(t, generator.GenerateFields(graph))
+		office, _ := graph.GetEntity("Office")
+		ticket, _ := graph.GetEntity("Ticket")
+		return office, ticket
+	}
+
+	t.Run("strict addresses come from one place", func(t *testing.T) {
+		office, ticket := generate(t, "")
+		for i := 0; i < office.GetRowCount(); i++ {
+			row := office.GetRowByIndex(i)
+			locale, place, exists := addressPlaceOf(row.GetValue("city"))
+			require.True(t, exists, "unknown city %q", row.GetValue("city"))
+			assert.Equal(t, place.state, row.GetValue("stateProvince"))
+			assert.Regexp(t, postalPattern(place.postal), row.GetValue("postalCode"))
+			assert.Equal(t, locale.code, row.GetValue("countryCode"))
+			assert.NotEmpty(t, row.GetValue("street_address"))
+			assert.True(t, strings.HasPrefix(row.GetValue("address"), row.GetValue("street_address")+", "+place.city))
+			assert.True(t, strings.HasSuffix(row.GetValue("address"), locale.name))
+		}
+		for i := 0; i < ticket.GetRowCount(); i++ {
+			_, _, exists := addressPlaceOf(ticket.GetRowByIndex(i).GetValue("state"))
+			assert.False(t, exists)
+		}
+		for _, locale := range addressLocales {
+			for _, place := range locale.places {
+				assert.Regexp(t, postalPattern(place.postal), postalCode(place.postal, gofakeit.New(0)), "a place's pattern fills in")
+			}
+		}
+	})
+
+	t.Run("loose addresses share a country", func(t *testing.T) {
+		office, _ := generate(t, AddressCoherenceLoose)
+		for i := 0; i < office.GetRowCount(); i++ {
+			row := office.GetRowByIndex(i)
+			locale, _, exists := addressPlaceOf(row.GetValue("city"))
+			require.True(t, exists)
+			assert.Equal(t, locale.code, row.GetValue("countryCode"))
+			states := make(map[string]bool)
+			for _, place := range locale.places {
+				states[place.state] = true
+			}
+			assert.True(t, states[row.GetValue("stateProvince")], "the state is in the city's country")
+		}
+	})
+
+	t.Run("value sources report the address parts", func(t *testing.T) {
+		graph, err := model.NewGraph(def, 10)
+		require.NoError(t, err)
+		sources := make(map[string]ValueSource)
+		for _, source := range ValueSources(graph.(*model.Graph)) {
+			sources[source.Entity+"."+source.Attribute] = source
+		}
+		assert.Equal(t, ValueSourceAddress, sources["Office.city"].Source)
+		assert.Equal(t, "postalCode of a coherent address", sources["Office.postalCode"].Detail)
+		assert.Equal(t, ValueSourceFallback, sources["Ticket.state"].Source, "a lone state isn't an address")
+	})
+
+	t.Run("off generates each part on its own", func(t *testing.T) {
+		office, _ := generate(t, AddressCoherenceOff)
+		_, _, exists := addressPlaceOf(office.GetRowByIndex(0).GetValue("city"))
+		assert.False(t, exists)
+	})
+}
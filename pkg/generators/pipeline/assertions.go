@@ -0,0 +1,373 @@
+package pipeline
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/config"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// Severities of an assertion
+const (
+	AssertionSeverityError = "error" // A failing assertion fails the run
+	AssertionSeverityWarn  = "warn"  // A failing assertion is only reported
+)
+
+// maxAssertionExamples is how many failing rows an assertion result names
+const maxAssertionExamples = 5
+
+// AssertionResult is the outcome of one assertion over the generated rows
+type AssertionResult struct {
+	Name      string
+	Entity    string // External ID of the entity checked
+	Severity  string // AssertionSeverityError or AssertionSeverityWarn
+	Checked   int    // Rows the assertion applied to
+	Failed    int    // Rows breaking it
+	Tolerance string // Failing rows allowed, as written; empty allows none
+	Passed    bool   // Failed is within the tolerance
+	Examples  []string
+}
+
+// String describes the result, e.g. "active users have a role (User): 2 of 40 rows fail, tolerance 5%"
+func (r AssertionResult) String() string {
+	tolerance := ""
+	if r.Tolerance != "" {
+		tolerance = ", tolerance " + r.Tolerance
+	}
+	description := fmt.Sprintf("%s (%s): %d of %d rows fail%s", r.Name, r.Entity, r.Failed, r.Checked, tolerance)
+	if len(r.Examples) > 0 {
+		description += " (e.g. " + strings.Join(r.Examples, ", ") + ")"
+	}
+	return description
+}
+
+// Assertions are the assertions of a run, checked against the SOR before
+// generation so a mistake doesn't surface only once the data is written
+type Assertions struct {
+	graph    *model.Graph
+	compiled []*compiledAssertion
+}
+
+// compiledAssertion is an assertion with its entity and attributes resolved
+type compiledAssertion struct {
+	spec      parser.Assertion
+	entity    model.EntityInterface
+	where     map[string]string // Attribute name → value rows must hold to be checked
+	tolerance config.Tolerance
+	severity  string
+
+	references   model.RelationshipInterface   // Set for a references assertion
+	referencedBy []model.RelationshipInterface // Relationships counted by a referencedBy assertion
+	min, max     int                           // Bounds of a referencedBy count; max < 0 is unbounded
+
+	left, op, right string // Set for a compare assertion
+	rightAttribute  bool   // right names an attribute rather than a value
+}
+
+// comparison matches "<attribute> <op> <attribute or value>"
+var comparison = regexp.MustCompile(`^\s*(\S+)\s*(<=|>=|==|!=|<|>)\s*(.+?)\s*$`)
+
+// CompileAssertions resolves assertions against the graph, failing on an unknown
+// entity or attribute, a foreign key without a relationship, or an assertion that
+// doesn't set exactly one check
+func CompileAssertions(graph *model.Graph, assertions []parser.Assertion) (*Assertions, error) {
+	compiled := &Assertions{graph: graph}
+	for _, spec := range assertions {
+		assertion, err := compileAssertion(graph, spec)
+		if err != nil {
+			return nil, fmt.Errorf("assertion '%s': %w", spec.Name, err)
+		}
+		compiled.compiled = append(compiled.compiled, assertion)
+	}
+	return compiled, nil
+}
+
+func compileAssertion(graph *model.Graph, spec parser.Assertion) (*compiledAssertion, error) {
+	if spec.Name == "" {
+		return nil, fmt.Errorf("needs a name")
+	}
+	entity := assertionEntity(graph, spec.Entity)
+	if entity == nil {
+		return nil, fmt.Errorf("unknown entity '%s'", spec.Entity)
+	}
+	assertion := &compiledAssertion{spec: spec, entity: entity, severity: spec.Severity, max: -1}
+
+	checks := 0
+	for _, set := range []bool{spec.References != "", spec.ReferencedBy != "", spec.Compare != ""} {
+		if set {
+			checks++
+		}
+	}
+	if checks != 1 {
+		return nil, fmt.Errorf("needs exactly one of references, referencedBy or compare")
+	}
+	if spec.ReferencedBy == "" && (spec.Via != "" || spec.Min != nil || spec.Max != nil) {
+		return nil, fmt.Errorf("via, min and max only apply to referencedBy")
+	}
+
+	switch assertion.severity {
+	case "":
+		assertion.severity = AssertionSeverityError
+	case AssertionSeverityError, AssertionSeverityWarn:
+	default:
+		return nil, fmt.Errorf("unknown severity '%s' (expected error or warn)", spec.Severity)
+	}
+	if spec.Tolerance != "" {
+		tolerance, err := config.ParseTolerance(spec.Tolerance)
+		if err != nil {
+			return nil, err
+		}
+		assertion.tolerance = tolerance
+	}
+
+	assertion.where = make(map[string]string, len(spec.Where))
+	for name, value := range spec.Where {
+		attr, err := assertionAttribute(entity, name)
+		if err != nil {
+			return nil, err
+		}
+		assertion.where[attr.GetName()] = value
+	}
+
+	switch {
+	case spec.References != "":
+		attr, err := assertionAttribute(entity, spec.References)
+		if err != nil {
+			return nil, err
+		}
+		assertion.references = graph.ForeignKeyRelationship(entity, attr.GetName())
+		if assertion.references == nil {
+			return nil, fmt.Errorf("'%s' is not a foreign key of %s", spec.References, entity.GetExternalID())
+		}
+	case spec.ReferencedBy != "":
+		if err := assertion.compileReferencedBy(graph); err != nil {
+			return nil, err
+		}
+	default:
+		if err := assertion.compileCompare(); err != nil {
+			return nil, err
+		}
+	}
+	return assertion, nil
+}
+
+// compileReferencedBy finds the relationships whose foreign keys in the
+// referencedBy entity reference the checked entity
+func (a *compiledAssertion) compileReferencedBy(graph *model.Graph) error {
+	spec := a.spec
+	referencing := assertionEntity(graph, spec.ReferencedBy)
+	if referencing == nil {
+		return fmt.Errorf("unknown entity '%s'", spec.ReferencedBy)
+	}
+	via := ""
+	if spec.Via != "" {
+		attr, err := assertionAttribute(referencing, spec.Via)
+		if err != nil {
+			return err
+		}
+		via = attr.GetName()
+	}
+	for _, relationship := range graph.GetAllRelationships() {
+		if relationship.GetSourceEntity().GetID() == referencing.GetID() &&
+			relationship.GetTargetEntity().GetID() == a.entity.GetID() &&
+			(via == "" || relationship.GetSourceAttribute().GetName() == via) {
+			a.referencedBy = append(a.referencedBy, relationship)
+		}
+	}
+	if len(a.referencedBy) == 0 {
+		if via != "" {
+			return fmt.Errorf("%s.%s doesn't reference %s", referencing.GetExternalID(), spec.Via, a.entity.GetExternalID())
+		}
+		return fmt.Errorf("no foreign key of %s references %s", referencing.GetExternalID(), a.entity.GetExternalID())
+	}
+
+	a.min = 1
+	if spec.Min != nil {
+		a.min = *spec.Min
+	}
+	if spec.Max != nil {
+		a.max = *spec.Max
+	}
+	if a.min < 0 || (a.max >= 0 && a.max < a.min) {
+		return fmt.Errorf("min %d and max %d don't leave a valid count", a.min, a.max)
+	}
+	return nil
+}
+
+// compileCompare parses the comparison, resolving its attributes
+func (a *compiledAssertion) compileCompare() error {
+	match := comparison.FindStringSubmatch(a.spec.Compare)
+	if match == nil {
+		return fmt.Errorf("invalid comparison '%s' (expected <attribute> <op> <attribute or value>, op one of < <= > >= == !=)", a.spec.Compare)
+	}
+	left, err := assertionAttribute(a.entity, match[1])
+	if err != nil {
+		return err
+	}
+	a.left, a.op, a.right = left.GetName(), match[2], strings.Trim(match[3], `"'`)
+	if right, err := assertionAttribute(a.entity, match[3]); err == nil {
+		a.right, a.rightAttribute = right.GetName(), true
+	}
+	return nil
+}
+
+// assertionEntity returns the entity with an external ID or ID, nil when none has it
+func assertionEntity(graph *model.Graph, name string) model.EntityInterface {
+	for _, entity := range graph.GetEntitiesList() {
+		if entity.GetExternalID() == name || entity.GetID() == name {
+			return entity
+		}
+	}
+	return nil
+}
+
+// assertionAttribute returns an entity's attribute by name or external ID
+func assertionAttribute(entity model.EntityInterface, name string) (model.AttributeInterface, error) {
+	if attr, exists := entity.GetAttribute(name); exists {
+		return attr, nil
+	}
+	if attr, exists := entity.GetAttributeByExternalID(name); exists {
+		return attr, nil
+	}
+	return nil, fmt.Errorf("%s has no attribute '%s'", entity.GetExternalID(), name)
+}
+
+// Evaluate checks every assertion over the generated rows, in the order declared
+func (a *Assertions) Evaluate() []AssertionResult {
+	results := make([]AssertionResult, 0, len(a.compiled))
+	traversal := a.graph.NewTraversal()
+	for _, assertion := range a.compiled {
+		results = append(results, assertion.evaluate(traversal))
+	}
+	return results
+}
+
+// AssertionsFailed reports whether an assertion of error severity failed
+func AssertionsFailed(results []AssertionResult) bool {
+	for _, result := range results {
+		if !result.Passed && result.Severity == AssertionSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *compiledAssertion) evaluate(traversal *model.Traversal) AssertionResult {
+	result := AssertionResult{
+		Name:      a.spec.Name,
+		Entity:    a.entity.GetExternalID(),
+		Severity:  a.severity,
+		Tolerance: a.spec.Tolerance,
+	}
+	fails := a.check(traversal)
+	key := a.entity.GetPrimaryKey()
+	for i := 0; i < a.entity.GetRowCount(); i++ {
+		row := a.entity.GetRowByIndex(i)
+		if !a.selects(row) {
+			continue
+		}
+		failed, applies := fails(row)
+		if !applies {
+			continue
+		}
+		result.Checked++
+		if !failed {
+			continue
+		}
+		result.Failed++
+		if len(result.Examples) < maxAssertionExamples {
+			if key != nil && row.GetValue(key.GetName()) != "" {
+				result.Examples = append(result.Examples, row.GetValue(key.GetName()))
+			} else {
+				result.Examples = append(result.Examples, "row "+strconv.Itoa(i+1))
+			}
+		}
+	}
+	result.Passed = a.tolerance.Allows(result.Failed, result.Checked)
+	return result
+}
+
+// selects reports whether a row holds the values of the assertion's where clause
+func (a *compiledAssertion) selects(row *model.Row) bool {
+	for name, value := range a.where {
+		if row.GetValue(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// check returns the test of a row: whether it breaks the assertion, and whether the
+// assertion applies to it at all. References are followed by traversal.
+func (a *compiledAssertion) check(traversal *model.Traversal) func(row *model.Row) (failed, applies bool) {
+	switch {
+	case a.references != nil:
+		name := a.references.GetSourceAttribute().GetName()
+		return func(row *model.Row) (bool, bool) {
+			_, found := traversal.GetParentRow(a.entity, row, name)
+			return !found, true
+		}
+	case a.referencedBy != nil:
+		return func(row *model.Row) (bool, bool) {
+			count := 0
+			for _, relationship := range a.referencedBy {
+				count += len(traversal.GetChildren(relationship, row))
+			}
+			return count < a.min || (a.max >= 0 && count > a.max), true
+		}
+	default:
+		return func(row *model.Row) (bool, bool) {
+			left, right := row.GetValue(a.left), a.right
+			if a.rightAttribute {
+				right = row.GetValue(a.right)
+			}
+			if left == "" || right == "" {
+				return false, false
+			}
+			return !compareHolds(compareValues(left, right), a.op), true
+		}
+	}
+}
+
+// compareValues orders two values as numbers when both are, as dates or timestamps
+// when both are, and as text otherwise
+func compareValues(left, right string) int {
+	if l, err := strconv.ParseFloat(left, 64); err == nil {
+		if r, err := strconv.ParseFloat(right, 64); err == nil {
+			switch {
+			case l < r:
+				return -1
+			case l > r:
+				return 1
+			}
+			return 0
+		}
+	}
+	if l, err := parser.ParseTimelineTime(left); err == nil {
+		if r, err := parser.ParseTimelineTime(right); err == nil {
+			return l.Compare(r)
+		}
+	}
+	return strings.Compare(left, right)
+}
+
+// compareHolds reports whether an ordering satisfies a comparison operator
+func compareHolds(order int, op string) bool {
+	switch op {
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	case "==":
+		return order == 0
+	}
+	return order != 0
+}
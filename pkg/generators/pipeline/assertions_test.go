@@ -0,0 +1,130 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertions(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Assertions",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "status", ExternalId: "status", Type: "String"},
+				},
+			},
+			"userRole": {
+				DisplayName: "UserRole", ExternalId: "UserRole",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "userId", ExternalId: "userId", Type: "String"},
+				},
+			},
+			"ticket": {
+				DisplayName: "Ticket", ExternalId: "Ticket",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "createdAt", ExternalId: "createdAt", Type: "DateTime"},
+					{Name: "closedAt", ExternalId: "closedAt", Type: "DateTime"},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"user_role": {DisplayName: "User Role", Name: "user_role", FromAttribute: "UserRole.userId", ToAttribute: "User.id"},
+		},
+	}
+	graphInterface, err := model.NewGraph(def, 4)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	addRows := func(entityID string, rows ...map[string]string) {
+		entity, _ := graph.GetEntity(entityID)
+		for _, values := range rows {
+			require.NoError(t, entity.AddRow(model.NewRow(values)))
+		}
+	}
+	addRows("User",
+		map[string]string{"id": "u1", "status": "active"},
+		map[string]string{"id": "u2", "status": "active"},
+		map[string]string{"id": "u3", "status": "disabled"},
+	)
+	addRows("UserRole",
+		map[string]string{"id": "r1", "userId": "u1"},
+		map[string]string{"id": "r2", "userId": "u1"},
+		map[string]string{"id": "r3", "userId": "u9"},
+	)
+	addRows("Ticket",
+		map[string]string{"id": "t1", "createdAt": "2024-01-02T00:00:00Z", "closedAt": "2024-01-03T00:00:00Z"},
+		map[string]string{"id": "t2", "createdAt": "2024-01-02T00:00:00Z", "closedAt": "2024-01-01T00:00:00Z"},
+		map[string]string{"id": "t3", "createdAt": "2024-01-02T00:00:00Z", "closedAt": ""},
+	)
+	evaluate := func(t *testing.T, assertion parser.Assertion) AssertionResult {
+		assertions, err := CompileAssertions(graph, []parser.Assertion{assertion})
+		require.NoError(t, err)
+		results := assertions.Evaluate()
+		require.Len(t, results, 1)
+		return results[0]
+	}
+	two := 2
+
+	t.Run("referencedBy counts the referencing rows", func(t *testing.T) {
+		result := evaluate(t, parser.Assertion{Name: "active users have a role", Entity: "User",
+			Where: map[string]string{"status": "active"}, ReferencedBy: "UserRole"})
+		assert.Equal(t, 2, result.Checked, "the disabled user isn't checked")
+		assert.Equal(t, 1, result.Failed)
+		assert.Equal(t, []string{"u2"}, result.Examples)
+		assert.False(t, result.Passed)
+		assert.True(t, AssertionsFailed([]AssertionResult{result}))
+
+		result = evaluate(t, parser.Assertion{Name: "at most one role", Entity: "User",
+			ReferencedBy: "UserRole", Via: "userId", Min: new(int), Max: &two})
+		assert.True(t, result.Passed)
+	})
+
+	t.Run("references finds orphans", func(t *testing.T) {
+		result := evaluate(t, parser.Assertion{Name: "roles have a user", Entity: "UserRole", References: "userId"})
+		assert.Equal(t, 1, result.Failed)
+		assert.Equal(t, []string{"r3"}, result.Examples)
+
+		result = evaluate(t, parser.Assertion{Name: "few orphan roles", Entity: "UserRole", References: "userId", Tolerance: "50%"})
+		assert.True(t, result.Passed)
+	})
+
+	t.Run("compare skips empty values", func(t *testing.T) {
+		result := evaluate(t, parser.Assertion{Name: "closed after created", Entity: "Ticket",
+			Compare: "closedAt >= createdAt", Severity: AssertionSeverityWarn})
+		assert.Equal(t, 2, result.Checked)
+		assert.Equal(t, 1, result.Failed)
+		assert.Equal(t, "closed after created (Ticket): 1 of 2 rows fail (e.g. t2)", result.String())
+		assert.False(t, AssertionsFailed([]AssertionResult{result}), "a warning doesn't fail the run")
+
+		result = evaluate(t, parser.Assertion{Name: "not t2", Entity: "Ticket", Compare: "id != 't2'"})
+		assert.Equal(t, 1, result.Failed)
+	})
+
+	t.Run("invalid assertions are rejected", func(t *testing.T) {
+		tests := []struct {
+			assertion parser.Assertion
+			wantErr   string
+		}{
+			{parser.Assertion{Name: "a", Entity: "Group", References: "x"}, "assertion 'a': unknown entity 'Group'"},
+			{parser.Assertion{Name: "a", Entity: "User"}, "assertion 'a': needs exactly one of references, referencedBy or compare"},
+			{parser.Assertion{Name: "a", Entity: "User", References: "status"}, "assertion 'a': 'status' is not a foreign key of User"},
+			{parser.Assertion{Name: "a", Entity: "User", ReferencedBy: "Ticket"}, "assertion 'a': no foreign key of Ticket references User"},
+			{parser.Assertion{Name: "a", Entity: "Ticket", Compare: "closedAt ~ createdAt"}, "assertion 'a': invalid comparison 'closedAt ~ createdAt' (expected <attribute> <op> <attribute or value>, op one of < <= > >= == !=)"},
+			{parser.Assertion{Name: "a", Entity: "Ticket", Compare: "closedAt > 1", Max: &two}, "assertion 'a': via, min and max only apply to referencedBy"},
+			{parser.Assertion{Name: "a", Entity: "User", Where: map[string]string{"role": "x"}, ReferencedBy: "UserRole"}, "assertion 'a': User has no attribute 'role'"},
+			{parser.Assertion{Name: "a", Entity: "User", ReferencedBy: "UserRole", Severity: "fatal"}, "assertion 'a': unknown severity 'fatal' (expected error or warn)"},
+		}
+		for _, tt := range tests {
+			_, err := CompileAssertions(graph, []parser.Assertion{tt.assertion})
+			assert.EqualError(t, err, tt.wantErr)
+		}
+	})
+}
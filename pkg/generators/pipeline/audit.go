@@ -0,0 +1,103 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+)
+
+// Decisions recorded in an audit log
+const (
+	AuditSeed             = "seed"              // The run's seed
+	AuditEntitySeed       = "entity_seed"       // The sub-seed an entity's field values are drawn from
+	AuditRows             = "rows"              // An entity's row count, and how many rows were provided
+	AuditCardinality      = "cardinality"       // How a relationship's foreign keys were assigned
+	AuditTimelineCluster  = "timeline_cluster"  // The rows whose creation time falls in a timeline cluster
+	AuditDuplicatePayload = "duplicate_payload" // A row copying another row's payload; Rows holds the copy, then the original
+)
+
+// AuditRecord is one random decision of a run. Fields a decision doesn't use are
+// left empty.
+type AuditRecord struct {
+	Decision     string `json:"decision"` // One of the Audit* decisions
+	Entity       string `json:"entity,omitempty"`
+	Relationship string `json:"relationship,omitempty"`
+	Attribute    string `json:"attribute,omitempty"`
+	Seed         int64  `json:"seed,omitempty"`
+	Value        string `json:"value,omitempty"`  // What was chosen, e.g. a cardinality or cluster start
+	Reason       string `json:"reason,omitempty"` // Why it was chosen
+	Detail       string `json:"detail,omitempty"`
+	Count        int    `json:"count,omitempty"`
+	Provided     int    `json:"provided,omitempty"` // Rows taken from inline data, fixtures or partial input
+	Rows         []int  `json:"rows,omitempty"`     // Indices of the rows the decision applies to
+}
+
+// AuditLog collects the random decisions of a run in the order they are made.
+// A nil *AuditLog is valid and records nothing, so generators needn't check
+// whether auditing was requested.
+type AuditLog struct {
+	records []AuditRecord
+}
+
+// NewAuditLog creates an empty audit log
+func NewAuditLog() *AuditLog {
+	return &AuditLog{}
+}
+
+// Record appends a decision
+func (a *AuditLog) Record(record AuditRecord) {
+	if a == nil {
+		return
+	}
+	a.records = append(a.records, record)
+}
+
+// Enabled reports whether decisions are being recorded, so callers can skip
+// computing data that only feeds the log
+func (a *AuditLog) Enabled() bool {
+	return a != nil
+}
+
+// Records returns the decisions recorded so far
+func (a *AuditLog) Records() []AuditRecord {
+	if a == nil {
+		return nil
+	}
+	return a.records
+}
+
+// RecordCardinality records how each relationship's foreign keys were assigned
+func (a *AuditLog) RecordCardinality(choices []CardinalityChoice) {
+	for _, choice := range choices {
+		a.Record(AuditRecord{
+			Decision:     AuditCardinality,
+			Relationship: choice.Relationship,
+			Value:        choice.Cardinality,
+			Reason:       choice.Reason,
+			Detail:       choice.Distribution,
+			Count:        choice.ForeignKeys,
+		})
+	}
+}
+
+// WriteAuditLog writes the log's decisions to path as JSON lines, encrypted when
+// files are
+func WriteAuditLog(path string, log *AuditLog, files *OutputFiles) error {
+	file, err := files.create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, record := range log.Records() {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return file.Close()
+}
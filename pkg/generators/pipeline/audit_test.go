@@ -0,0 +1,93 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Audit SOR",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User",
+				ExternalId:  "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "createdAt", ExternalId: "createdAt", Type: "DateTime"},
+				},
+				Timeline: &parser.Timeline{
+					Attribute: "createdAt",
+					From:      "2023-01-01",
+					To:        "2024-12-31",
+					Clusters:  []parser.TimelineCluster{{At: "2023-03-01", WindowDays: 30, Share: 0.5}},
+				},
+			},
+		},
+	}
+
+	t.Run("field generation records sub-seeds and cluster rows", func(t *testing.T) {
+		graph := buildGraphWithIDs(t, def, 20)
+		audit := NewAuditLog()
+		generator := NewFieldGenerator().(*FieldGenerator)
+		generator.SetAuditLog(audit)
+		generator.SetRandomStreams(NewRandomStreams(7, nil))
+		require.NoError(t, generator.GenerateFields(graph))
+
+		records := audit.Records()
+		require.Len(t, records, 2)
+		assert.Equal(t, AuditEntitySeed, records[0].Decision)
+		assert.Equal(t, "User", records[0].Entity)
+		assert.NotZero(t, records[0].Seed)
+
+		cluster := records[1]
+		assert.Equal(t, AuditTimelineCluster, cluster.Decision)
+		assert.Equal(t, "2023-03-01", cluster.Value)
+		assert.Equal(t, 10, cluster.Count)
+		require.Len(t, cluster.Rows, 10)
+
+		entity, _ := graph.GetEntity("User")
+		for _, index := range cluster.Rows {
+			created := entity.GetRowByIndex(index).GetValue("createdAt")
+			assert.True(t, created >= "2023-03-01" && created < "2023-04-01", "row %d created at %s", index, created)
+		}
+	})
+
+	t.Run("nil log records nothing", func(t *testing.T) {
+		var audit *AuditLog
+		audit.Record(AuditRecord{Decision: AuditSeed, Seed: 1})
+		assert.False(t, audit.Enabled())
+		assert.Nil(t, audit.Records())
+	})
+
+	t.Run("writes one JSON line per decision", func(t *testing.T) {
+		audit := NewAuditLog()
+		audit.Record(AuditRecord{Decision: AuditSeed, Seed: 42, Reason: "given"})
+		audit.RecordCardinality([]CardinalityChoice{{Relationship: "user_group", Cardinality: "1:N", Reason: "declared", ForeignKeys: 3}})
+
+		path := filepath.Join(t.TempDir(), "audit.jsonl")
+		require.NoError(t, WriteAuditLog(path, audit, nil))
+
+		file, err := os.Open(path) // #nosec G304 - test file
+		require.NoError(t, err)
+		defer func() { _ = file.Close() }()
+		var decoded []AuditRecord
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var record AuditRecord
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+			decoded = append(decoded, record)
+		}
+		assert.Equal(t, []AuditRecord{
+			{Decision: AuditSeed, Seed: 42, Reason: "given"},
+			{Decision: AuditCardinality, Relationship: "user_group", Value: "1:N", Reason: "declared", Count: 3},
+		}, decoded)
+	})
+}
@@ -0,0 +1,99 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// CardinalityReportFile is the name of the auto-cardinality explanation in the output directory
+const CardinalityReportFile = "cardinality.json"
+
+// CardinalityChoice explains how the foreign keys of one relationship were
+// assigned, and the shape that produced
+type CardinalityChoice struct {
+	Relationship string `json:"relationship"`      // Relationship key
+	Inverse      string `json:"inverse,omitempty"` // Key of the inverse relationship linked with it
+	From         string `json:"from"`              // Foreign key, as Entity.attribute
+	To           string `json:"to"`                // Referenced attribute, as Entity.attribute
+	Cardinality  string `json:"cardinality"`
+	Reason       string `json:"reason"`       // Why the cardinality was chosen
+	Distribution string `json:"distribution"` // How target rows were chosen for source rows
+
+	// Shape of the generated links
+	ForeignKeys       int `json:"foreignKeys"`       // Source rows holding a value
+	TargetRows        int `json:"targetRows"`        // Rows of the target entity, or those matching the where
+	TargetsReferenced int `json:"targetsReferenced"` // Distinct target values referenced
+	MaxPerTarget      int `json:"maxPerTarget"`      // Most source rows referencing one target value
+}
+
+// ExplainCardinality describes, per relationship in ID order, the cardinality the
+// linker used and why, and counts how the generated foreign keys spread over the
+// target rows. Choices depend only on the SOR and row counts, so the same inputs
+// always produce the same shape.
+func ExplainCardinality(graph *model.Graph, autoCardinality bool) []CardinalityChoice {
+	relationships := graph.GetAllRelationships()
+	choices := make([]CardinalityChoice, 0, len(relationships))
+	for _, relationship := range relationships {
+		source, target := relationship.GetSourceAttribute(), relationship.GetTargetAttribute()
+		sourceEntity, targetEntity := relationship.GetSourceEntity(), relationship.GetTargetEntity()
+
+		choice := CardinalityChoice{
+			Relationship: relationship.GetID(),
+			Inverse:      relationship.GetInverseID(),
+			From:         sourceEntity.GetExternalID() + "." + source.GetExternalID(),
+			To:           targetEntity.GetExternalID() + "." + target.GetExternalID(),
+			Cardinality:  relationship.GetCardinality(),
+			Reason:       relationship.GetCardinalityReason(),
+			TargetRows:   targetEntity.GetRowCount(),
+		}
+
+		// Mirror the linker's special cases before the relationship's own distribution
+		switch {
+		case sourceEntity == targetEntity && hierarchicalCodeGenerator(target) != nil:
+			choice.Distribution = "each row references its parent code in the hierarchy"
+		case source.IsUnique() && target.IsUnique():
+			choice.Distribution = relationship.DescribeDistribution(false) + ", up to the smaller entity's row count"
+		default:
+			choice.Distribution = relationship.DescribeDistribution(autoCardinality)
+		}
+		if where := relationship.GetWhere(); len(where) > 0 {
+			choice.Distribution += ", over the target rows where " + describeWhere(where)
+			choice.TargetRows = 0
+			for i := 0; i < targetEntity.GetRowCount(); i++ {
+				if matchesWhere(targetEntity.GetRowByIndex(i), where) {
+					choice.TargetRows++
+				}
+			}
+		}
+
+		references := make(map[string]int)
+		for i := 0; i < sourceEntity.GetRowCount(); i++ {
+			if value := sourceEntity.GetRowByIndex(i).GetValue(source.GetName()); value != "" {
+				references[value]++
+				choice.ForeignKeys++
+			}
+		}
+		choice.TargetsReferenced = len(references)
+		for _, count := range references {
+			choice.MaxPerTarget = max(choice.MaxPerTarget, count)
+		}
+
+		choices = append(choices, choice)
+	}
+	return choices
+}
+
+// WriteCardinalityReport writes the cardinality choices as indented JSON, encrypted
+// like the data files
+func WriteCardinalityReport(path string, choices []CardinalityChoice, files *OutputFiles) error {
+	content, err := json.MarshalIndent(choices, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cardinality report: %w", err)
+	}
+	if err := files.write(path, append(content, '\n')); err != nil {
+		return fmt.Errorf("failed to write cardinality report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cardinalityTestGraph(t *testing.T, autoCardinality bool) *model.Graph {
+	t.Helper()
+	def := partialInputDefinition()
+	user := def.Entities["user"]
+	user.Attributes = append(user.Attributes, parser.Attribute{Name: "managerId", ExternalId: "managerId", Type: "String"})
+	def.Entities["user"] = user
+	def.Relationships["user_manager"] = parser.Relationship{
+		DisplayName: "Manager", Name: "manager", FromAttribute: "User.managerId", ToAttribute: "User.id",
+	}
+
+	graphInterface, err := model.NewGraph(def, 40)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"User": 40, "Group": 30}))
+	require.NoError(t, NewRelationshipLinker().LinkRelationships(graph, autoCardinality))
+	return graph
+}
+
+func TestExplainCardinality(t *testing.T) {
+	graph := cardinalityTestGraph(t, true)
+	choices := ExplainCardinality(graph, true)
+	require.Len(t, choices, 2)
+
+	owner := choices[0]
+	assert.Equal(t, "group_owner", owner.Relationship)
+	assert.Equal(t, "Group.ownerId", owner.From)
+	assert.Equal(t, "User.id", owner.To)
+	assert.Equal(t, model.ManyToOne, owner.Cardinality)
+	assert.Equal(t, "User.id is a unique ID and Group.ownerId is not", owner.Reason)
+	assert.Equal(t, "power law with alpha 1.35, clustering references on the first target rows", owner.Distribution)
+	assert.Equal(t, 30, owner.ForeignKeys)
+	assert.Equal(t, 40, owner.TargetRows)
+	assert.Less(t, owner.TargetsReferenced, 30, "the power law reuses popular targets")
+	assert.Greater(t, owner.MaxPerTarget, 1)
+
+	manager := choices[1]
+	assert.Equal(t, "user_manager", manager.Relationship)
+	assert.Equal(t, 40, manager.ForeignKeys)
+
+	t.Run("without auto-cardinality targets are used in turn", func(t *testing.T) {
+		choices := ExplainCardinality(cardinalityTestGraph(t, false), false)
+		assert.Equal(t, "round-robin over target rows (auto-cardinality off)", choices[0].Distribution)
+		assert.Equal(t, 30, choices[0].TargetsReferenced)
+		assert.Equal(t, 1, choices[0].MaxPerTarget)
+	})
+
+	t.Run("report is written as JSON", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), CardinalityReportFile)
+		require.NoError(t, WriteCardinalityReport(path, choices, nil))
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		var decoded []CardinalityChoice
+		require.NoError(t, json.Unmarshal(content, &decoded))
+		assert.Equal(t, choices, decoded)
+	})
+}
+
+func TestAutoCardinalityShapeIsStable(t *testing.T) {
+	// IDs differ between runs; which target row each source row references must not
+	targetIndexes := func(graph *model.Graph) map[string][]int {
+		indexes := make(map[string][]int)
+		for _, relationship := range graph.GetAllRelationships() {
+			source, target := relationship.GetSourceEntity(), relationship.GetTargetEntity()
+			position := make(map[string]int, target.GetRowCount())
+			for i := 0; i < target.GetRowCount(); i++ {
+				position[target.GetRowByIndex(i).GetValue(relationship.GetTargetAttribute().GetName())] = i
+			}
+			for i := 0; i < source.GetRowCount(); i++ {
+				value := source.GetRowByIndex(i).GetValue(relationship.GetSourceAttribute().GetName())
+				indexes[relationship.GetID()] = append(indexes[relationship.GetID()], position[value])
+			}
+		}
+		return indexes
+	}
+
+	first := cardinalityTestGraph(t, true)
+	second := cardinalityTestGraph(t, true)
+	assert.NotEqual(t, first.GetEntitiesList()[1].GetRowByIndex(0).GetValue("id"),
+		second.GetEntitiesList()[1].GetRowByIndex(0).GetValue("id"), "runs generate different IDs")
+	assert.Equal(t, targetIndexes(first), targetIndexes(second))
+	assert.Equal(t, ExplainCardinality(first, true), ExplainCardinality(second, true))
+}
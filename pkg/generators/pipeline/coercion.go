@@ -0,0 +1,149 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// Layouts that existing files write dates and date-times in, tried in order.
+// Values without a zone are read as UTC.
+var (
+	coercionDateLayouts     = []string{"2006-01-02", "2006/01/02", "20060102", time.RFC3339Nano}
+	coercionDateTimeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05Z07:00",
+		"2006-01-02 15:04:05", "2006-01-02"}
+)
+
+// coerceValue returns a value read from an existing CSV file written the way
+// fabricator writes values of the type, so formatting alone doesn't fail a
+// comparison: surrounding whitespace is trimmed, booleans become true or false,
+// integers lose signs and leading zeros, dates become 2006-01-02 and date-times
+// RFC 3339. A value that doesn't parse as its type is only trimmed and is left
+// for the checks to report. Values are compared in this form, but kept as they
+// were read (see valueCoercion).
+func coerceValue(dataType, value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return value
+	}
+
+	switch dataType {
+	case "Integer", "Int64":
+		if number, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return strconv.FormatInt(number, 10)
+		}
+	case "Boolean", "Bool":
+		switch strings.ToLower(value) {
+		case "true", "t", "yes", "y", "1":
+			return "true"
+		case "false", "f", "no", "n", "0":
+			return "false"
+		}
+	case "Date":
+		for _, layout := range coercionDateLayouts {
+			parsed, err := time.Parse(layout, value)
+			// A timestamp is only a date at midnight
+			if err == nil && parsed.Format("15:04:05.999999999") == "00:00:00" {
+				return parsed.Format("2006-01-02")
+			}
+		}
+	case "DateTime":
+		for _, layout := range coercionDateTimeLayouts {
+			if parsed, err := time.Parse(layout, value); err == nil {
+				return parsed.Format(time.RFC3339Nano)
+			}
+		}
+	}
+	return value
+}
+
+// valueCoercion compares the values of existing CSV files by their coerced form
+// (see coerceValue) without changing the values themselves. Attributes with a
+// transform, and foreign keys, lookups and shared values copying theirs, are
+// written in the transform's form rather than their type's, so they are compared
+// as they are and never reported as coerced.
+type valueCoercion struct {
+	transformed map[model.AttributeInterface]bool
+}
+
+// newValueCoercion finds the graph's attributes written in a transform's form
+func newValueCoercion(graph *model.Graph) *valueCoercion {
+	_, copied := valueSources(graph)
+	coercion := &valueCoercion{transformed: make(map[model.AttributeInterface]bool)}
+	for _, entity := range graph.GetEntitiesList() {
+		for _, attr := range entity.GetAttributes() {
+			// A cycle of copies has no transform to follow
+			seen := make(map[model.AttributeInterface]bool)
+			for source := attr; source != nil && !seen[source]; source = copied[source] {
+				seen[source] = true
+				if source.GetTransform() != nil {
+					coercion.transformed[attr] = true
+					break
+				}
+			}
+		}
+	}
+	return coercion
+}
+
+// normalize is the model.ValueNormalizer comparing values in their coerced form
+func (c *valueCoercion) normalize(attr model.AttributeInterface, value string) string {
+	if attr == nil || c.transformed[attr] {
+		return value
+	}
+	return coerceValue(attr.GetDataType(), value)
+}
+
+// normalizedValue returns the form of an attribute's value normalize returns, or
+// the value when normalize is nil
+func normalizedValue(normalize model.ValueNormalizer, attr model.AttributeInterface, value string) string {
+	if normalize == nil {
+		return value
+	}
+	return normalize(attr, value)
+}
+
+// columnAttributes returns the attribute each header names, by external ID or
+// name, or nil for headers that name no attribute
+func columnAttributes(entity model.EntityInterface, headers []string) []model.AttributeInterface {
+	attributes := make([]model.AttributeInterface, len(headers))
+	for i, header := range headers {
+		attr, exists := entity.GetAttributeByExternalID(header)
+		if !exists {
+			attr, exists = entity.GetAttribute(header)
+		}
+		if exists {
+			attributes[i] = attr
+		}
+	}
+	return attributes
+}
+
+// normalizeRecord sets normalized to the coerced form of a record's values by their
+// column's attribute, calling coerced with the column and coerced form of each
+// value whose form differs. Columns without an attribute are kept as they are. It returns
+// normalized, grown to the record's length.
+func (c *valueCoercion) normalizeRecord(attributes []model.AttributeInterface, record, normalized []string,
+	coerced func(column int, to string)) []string {
+	normalized = append(normalized[:0], record...)
+	for i, value := range record {
+		if i >= len(attributes) {
+			break
+		}
+		if attributes[i] == nil {
+			continue
+		}
+		if normalized[i] = c.normalize(attributes[i], value); normalized[i] != value && coerced != nil {
+			coerced(i, normalized[i])
+		}
+	}
+	return normalized
+}
+
+// coercionIssue describes a value changed by coercion, for strict coercion
+func coercionIssue(entityID string, row int, column, from, to string) string {
+	return fmt.Sprintf("entity %s: row %d: coerced %s '%s' to '%s'", entityID, row, column, from, to)
+}
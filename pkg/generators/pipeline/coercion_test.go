@@ -0,0 +1,143 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoerceValue(t *testing.T) {
+	tests := []struct {
+		dataType, value, want string
+	}{
+		{"String", "  user-1 ", "user-1"},
+		{"String", "", ""},
+		{"Integer", " +007", "7"},
+		{"Int64", "-42", "-42"},
+		{"Integer", "4.5", "4.5"},
+		{"Float", " 12.50 ", "12.50"},
+		{"Boolean", "Yes", "true"},
+		{"Bool", "0", "false"},
+		{"Boolean", "TRUE", "true"},
+		{"Boolean", "maybe", "maybe"},
+		{"Date", "2024/01/31", "2024-01-31"},
+		{"Date", "20240131", "2024-01-31"},
+		{"Date", "2024-01-31T00:00:00+02:00", "2024-01-31"},
+		{"Date", "2024-01-31T09:30:00Z", "2024-01-31T09:30:00Z"},
+		{"DateTime", "2024-01-31 09:30:00", "2024-01-31T09:30:00Z"},
+		{"DateTime", "2024-01-31T09:30:00.000+02:00", "2024-01-31T09:30:00+02:00"},
+		{"DateTime", "2024-01-31", "2024-01-31T00:00:00Z"},
+		{"DateTime", "yesterday", "yesterday"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dataType+" "+tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, coerceValue(tt.dataType, tt.value))
+		})
+	}
+}
+
+func TestValidationCoercion(t *testing.T) {
+	// Keys padded by a spreadsheet still match once trimmed
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "User.csv"), []byte("id,roleId\nuser-1, role-1\nuser-2,role-1\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Role.csv"), []byte("id\nrole-1 \n"), 0600))
+
+	processors := map[string]func() ValidationProcessorInterface{
+		"in memory": NewValidationProcessor,
+		"streaming": NewStreamingValidationProcessor,
+	}
+	for name, newProcessor := range processors {
+		t.Run(name, func(t *testing.T) {
+			t.Run("should accept values once coerced", func(t *testing.T) {
+				report, err := newProcessor().ValidateExistingCSVFilesReport(userRoleDefinition(""), dir)
+				require.NoError(t, err)
+				assert.Empty(t, report.Errors)
+			})
+
+			t.Run("should report every coercion when strict", func(t *testing.T) {
+				processor := newProcessor()
+				processor.(interface{ SetStrictCoercion(bool) }).SetStrictCoercion(true)
+				report, err := processor.ValidateExistingCSVFilesReport(userRoleDefinition(""), dir)
+				require.NoError(t, err)
+				assert.ElementsMatch(t, []string{
+					"entity Role: row 1: coerced id 'role-1 ' to 'role-1'",
+					"entity User: row 1: coerced roleId ' role-1' to 'role-1'",
+				}, report.Errors)
+			})
+		})
+	}
+
+	t.Run("should not replay cached results across strictness", func(t *testing.T) {
+		cachePath := filepath.Join(t.TempDir(), "cache.json")
+		validate := func(strict bool) *ValidationReport {
+			cache, err := LoadValidationCache(cachePath)
+			require.NoError(t, err)
+			processor := NewValidationProcessor().(*ValidationProcessor)
+			processor.SetCache(cache)
+			processor.SetStrictCoercion(strict)
+			report, err := processor.ValidateExistingCSVFilesReport(userRoleDefinition(""), dir)
+			require.NoError(t, err)
+			require.NoError(t, cache.Save())
+			return report
+		}
+
+		assert.Empty(t, validate(false).Errors)
+		assert.Len(t, validate(true).Errors, 2)
+	})
+}
+
+func TestValidationCoercion_KeepsValues(t *testing.T) {
+	// A role number padded by a transform, and an integer group key padded in the file
+	def := userRoleDefinition("")
+	role := def.Entities["role"]
+	role.Attributes = append(role.Attributes,
+		parser.Attribute{Name: "number", ExternalId: "number", Type: "Integer", Transform: &parser.Transform{PadWidth: 6}})
+	def.Entities["role"] = role
+	user := def.Entities["user"]
+	user.Attributes = append(user.Attributes, parser.Attribute{Name: "groupId", ExternalId: "groupId", Type: "Integer"})
+	def.Entities["user"] = user
+	def.Entities["group"] = parser.Entity{
+		DisplayName: "Group",
+		ExternalId:  "Group",
+		Attributes: []parser.Attribute{
+			{Name: "id", ExternalId: "id", Type: "Integer", UniqueId: true},
+		},
+	}
+	def.Relationships["user_group"] = parser.Relationship{
+		DisplayName:   "User Group",
+		Name:          "user_group",
+		FromAttribute: "User.groupId",
+		ToAttribute:   "Group.id",
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "User.csv"), []byte("id,roleId,groupId\nuser-1,role-1,7\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Role.csv"), []byte("id,number\nrole-1,000042\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Group.csv"), []byte("id\n007\n"), 0600))
+
+	processors := map[string]func() ValidationProcessorInterface{
+		"in memory": NewValidationProcessor,
+		"streaming": NewStreamingValidationProcessor,
+	}
+	for name, newProcessor := range processors {
+		t.Run(name, func(t *testing.T) {
+			t.Run("should match keys in their type's form", func(t *testing.T) {
+				report, err := newProcessor().ValidateExistingCSVFilesReport(def, dir)
+				require.NoError(t, err)
+				assert.Empty(t, report.Errors)
+			})
+
+			t.Run("should report only values a transform didn't write when strict", func(t *testing.T) {
+				processor := newProcessor()
+				processor.(interface{ SetStrictCoercion(bool) }).SetStrictCoercion(true)
+				report, err := processor.ValidateExistingCSVFilesReport(def, dir)
+				require.NoError(t, err)
+				assert.Equal(t, []string{"entity Group: row 1: coerced id '007' to '7'"}, report.Errors)
+			})
+		})
+	}
+}
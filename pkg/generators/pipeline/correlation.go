@@ -0,0 +1,156 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// Value ranges for generated numeric fields
+const (
+	minGeneratedInteger = 1
+	maxGeneratedInteger = 1000
+	minGeneratedFloat   = 1.0
+	maxGeneratedFloat   = 100.0
+)
+
+// correlatedSampler draws values for an entity's correlated numeric attributes.
+// It uses a Gaussian copula: correlated standard normals are mapped through the
+// normal CDF to uniform values, so each column keeps the same distribution as
+// independently generated numbers while pairs achieve the declared correlation.
+type correlatedSampler struct {
+	attributes []model.AttributeInterface
+	cholesky   [][]float64 // Lower-triangular factor of the latent correlation matrix
+	faker      *gofakeit.Faker
+}
+
+// newCorrelatedSampler builds a sampler for the entity's declared correlations,
+// drawing from faker. Returns nil when the entity declares none.
+func newCorrelatedSampler(entity model.EntityInterface, faker *gofakeit.Faker) (*correlatedSampler, error) {
+	correlations := entity.GetCorrelations()
+	if len(correlations) == 0 {
+		return nil, nil
+	}
+
+	// Index each correlated attribute in declaration order
+	sampler := &correlatedSampler{faker: faker}
+	index := make(map[string]int)
+	for _, correlation := range correlations {
+		for _, name := range correlation.Attributes {
+			if _, exists := index[name]; exists {
+				continue
+			}
+			attr, exists := entity.GetAttribute(name)
+			if !exists {
+				return nil, fmt.Errorf("correlation references unknown attribute '%s'", name)
+			}
+			index[name] = len(sampler.attributes)
+			sampler.attributes = append(sampler.attributes, attr)
+		}
+	}
+
+	// Build the latent correlation matrix. Mapping normals to uniforms shrinks
+	// Pearson correlation, so the latent value is chosen to land on the target:
+	// r_uniform = (6/π)·asin(ρ/2)  ⇒  ρ = 2·sin(π·r/6)
+	n := len(sampler.attributes)
+	matrix := make([][]float64, n)
+	for i := range matrix {
+		matrix[i] = make([]float64, n)
+		matrix[i][i] = 1
+	}
+	for _, correlation := range correlations {
+		i, j := index[correlation.Attributes[0]], index[correlation.Attributes[1]]
+		latent := 2 * math.Sin(math.Pi*correlation.Coefficient/6)
+		matrix[i][j], matrix[j][i] = latent, latent
+	}
+
+	factor, err := choleskyDecompose(matrix)
+	if err != nil {
+		return nil, fmt.Errorf("correlations for entity %s are inconsistent: %w", entity.GetExternalID(), err)
+	}
+	sampler.cholesky = factor
+	return sampler, nil
+}
+
+// sample returns one correlated value per attribute, keyed by attribute name
+func (s *correlatedSampler) sample() map[string]string {
+	if s == nil {
+		return nil
+	}
+
+	n := len(s.attributes)
+	independent := make([]float64, n)
+	for i := range independent {
+		independent[i] = standardNormal(s.faker)
+	}
+
+	values := make(map[string]string, n)
+	for i, attr := range s.attributes {
+		correlated := 0.0
+		for j := 0; j <= i; j++ {
+			correlated += s.cholesky[i][j] * independent[j]
+		}
+		uniform := 0.5 * math.Erfc(-correlated/math.Sqrt2)
+		values[attr.GetName()] = numericFromUniform(attr.GetDataType(), uniform)
+	}
+	return values
+}
+
+// numericFromUniform maps a value in [0, 1] onto the generated range for a numeric type
+func numericFromUniform(dataType string, uniform float64) string {
+	switch dataType {
+	case "Integer", "Int64":
+		value := minGeneratedInteger + int(uniform*float64(maxGeneratedInteger-minGeneratedInteger+1))
+		if value > maxGeneratedInteger {
+			value = maxGeneratedInteger
+		}
+		return strconv.Itoa(value)
+	default:
+		return fmt.Sprintf("%.2f", minGeneratedFloat+uniform*(maxGeneratedFloat-minGeneratedFloat))
+	}
+}
+
+// standardNormal draws from N(0, 1) using the Box-Muller transform on faker's
+// random source, so seeded runs stay reproducible
+func standardNormal(faker *gofakeit.Faker) float64 {
+	u1 := 1 - faker.Float64Range(0, 1) // (0, 1] avoids log(0)
+	u2 := faker.Float64Range(0, 1)
+	return math.Sqrt(-2*math.Log(u1)) * math.Cos(2*math.Pi*u2)
+}
+
+// choleskyDecompose factors a symmetric positive semi-definite matrix into L·Lᵀ
+func choleskyDecompose(matrix [][]float64) ([][]float64, error) {
+	const tolerance = 1e-9
+
+	n := len(matrix)
+	factor := make([][]float64, n)
+	for i := range factor {
+		factor[i] = make([]float64, n)
+	}
+
+	for j := 0; j < n; j++ {
+		diagonal := matrix[j][j]
+		for k := 0; k < j; k++ {
+			diagonal -= factor[j][k] * factor[j][k]
+		}
+		if diagonal < -tolerance {
+			return nil, fmt.Errorf("the correlation matrix is not positive semi-definite")
+		}
+		factor[j][j] = math.Sqrt(math.Max(diagonal, 0))
+
+		for i := j + 1; i < n; i++ {
+			if factor[j][j] < tolerance {
+				continue // Perfectly dependent column; remaining entries stay zero
+			}
+			sum := matrix[i][j]
+			for k := 0; k < j; k++ {
+				sum -= factor[i][k] * factor[j][k]
+			}
+			factor[i][j] = sum / factor[j][j]
+		}
+	}
+	return factor, nil
+}
@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func correlatedDefinition(correlations ...parser.Correlation) *parser.SORDefinition {
+	return &parser.SORDefinition{
+		DisplayName: "Correlation SOR",
+		Description: "SOR used for correlation tests",
+		Entities: map[string]parser.Entity{
+			"employee": {
+				DisplayName: "Employee",
+				ExternalId:  "Employee",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "tenure", ExternalId: "tenure", Type: "Integer"},
+					{Name: "salary", ExternalId: "salary", Type: "Float"},
+					{Name: "bonus", ExternalId: "bonus", Type: "Double"},
+				},
+				Correlations: correlations,
+			},
+		},
+	}
+}
+
+// pearson computes the sample correlation of two numeric columns
+func pearson(t *testing.T, entity model.EntityInterface, a, b string) float64 {
+	t.Helper()
+	var xs, ys []float64
+	for i := 0; i < entity.GetRowCount(); i++ {
+		row := entity.GetRowByIndex(i)
+		x, err := strconv.ParseFloat(row.GetValue(a), 64)
+		require.NoError(t, err)
+		y, err := strconv.ParseFloat(row.GetValue(b), 64)
+		require.NoError(t, err)
+		xs, ys = append(xs, x), append(ys, y)
+	}
+
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(len(xs))
+	meanY /= float64(len(ys))
+
+	var cov, varX, varY float64
+	for i := range xs {
+		cov += (xs[i] - meanX) * (ys[i] - meanY)
+		varX += (xs[i] - meanX) * (xs[i] - meanX)
+		varY += (ys[i] - meanY) * (ys[i] - meanY)
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+func TestFieldGenerator_Correlations(t *testing.T) {
+	tests := []struct {
+		name        string
+		coefficient float64
+	}{
+		{name: "Positive correlation", coefficient: 0.6},
+		{name: "Negative correlation", coefficient: -0.4},
+		{name: "Perfect correlation", coefficient: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := correlatedDefinition(parser.Correlation{Attributes: []string{"tenure", "salary"}, Coefficient: tt.coefficient})
+			graphInterface, err := model.NewGraph(def, 5000)
+			require.NoError(t, err)
+			graph := graphInterface.(*model.Graph)
+
+			require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"Employee": 5000}))
+			generator := &FieldGenerator{}
+			generator.SetRandomStreams(NewRandomStreams(42, nil))
+			require.NoError(t, generator.GenerateFields(graph))
+
+			employee, _ := graph.GetEntity("Employee")
+			assert.InDelta(t, tt.coefficient, pearson(t, employee, "tenure", "salary"), 0.05)
+			assert.InDelta(t, 0, pearson(t, employee, "tenure", "bonus"), 0.05, "undeclared pairs stay independent")
+
+			for i := 0; i < employee.GetRowCount(); i++ {
+				tenure, err := strconv.Atoi(employee.GetRowByIndex(i).GetValue("tenure"))
+				require.NoError(t, err, "integer attributes stay integers")
+				assert.True(t, tenure >= minGeneratedInteger && tenure <= maxGeneratedInteger)
+			}
+		})
+	}
+
+	t.Run("Inconsistent correlations are rejected", func(t *testing.T) {
+		def := correlatedDefinition(
+			parser.Correlation{Attributes: []string{"tenure", "salary"}, Coefficient: 0.9},
+			parser.Correlation{Attributes: []string{"salary", "bonus"}, Coefficient: 0.9},
+			parser.Correlation{Attributes: []string{"tenure", "bonus"}, Coefficient: -0.9},
+		)
+		graphInterface, err := model.NewGraph(def, 10)
+		require.NoError(t, err)
+		graph := graphInterface.(*model.Graph)
+
+		require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"Employee": 10}))
+		err = NewFieldGenerator().GenerateFields(graph)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "inconsistent")
+	})
+}
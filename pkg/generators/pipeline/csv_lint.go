@@ -0,0 +1,210 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// maxLintIssuesPerFile caps how many structural issues are reported for one file
+const maxLintIssuesPerFile = 20
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// csvLinter scans raw CSV bytes for structural problems that encoding/csv either
+// rejects with a terse error or silently tolerates
+type csvLinter struct {
+	path   string
+	issues []string
+	total  int
+
+	line        int  // Current physical line (1-based)
+	recordLine  int  // Line on which the current record started
+	fieldCount  int  // Fields seen so far in the current record
+	headerWidth int  // Field count of the header record (0 until known)
+	inQuotes    bool // Inside a quoted field
+	quoteLine   int  // Line where the open quoted field started
+	afterQuote  bool // Just read a closing quote
+	fieldStart  bool // At the first byte of a field
+	recordEmpty bool // Nothing read yet for the current record
+
+	crlfLine int // First line ending in CRLF (0 if none)
+	lfLine   int // First line ending in bare LF (0 if none)
+}
+
+// LintCSVFile checks a CSV file's structure: stray byte order marks, mixed CRLF/LF
+// line endings, unescaped or unterminated quotes, and rows whose column count
+// differs from the header. Issues are formatted as "<path>:<line>: <message>".
+func LintCSVFile(path string) ([]string, error) {
+	file, err := os.Open(path) // #nosec G304 - path is built from the validated directory
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file %s: %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	return LintCSV(path, file)
+}
+
+// LintCSV checks CSV content read from r; path is only used in issue messages
+func LintCSV(path string, r io.Reader) ([]string, error) {
+	l := &csvLinter{path: path, line: 1, recordLine: 1, fieldStart: true, recordEmpty: true}
+	reader := bufio.NewReader(r)
+
+	// A leading BOM is tolerated by some tools and rejected by others
+	if prefix, err := reader.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+		l.report(1, "file starts with a UTF-8 byte order mark")
+		_, _ = reader.Discard(len(utf8BOM))
+	}
+
+	for {
+		b, err := reader.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV file %s: %w", path, err)
+		}
+
+		// BOMs anywhere else are almost always concatenation artifacts
+		if b == utf8BOM[0] {
+			if next, err := reader.Peek(2); err == nil && bytes.Equal(next, utf8BOM[1:]) {
+				_, _ = reader.Discard(2)
+				l.report(l.line, "stray UTF-8 byte order mark")
+				continue
+			}
+		}
+
+		if b == '\r' {
+			if next, err := reader.Peek(1); err == nil && next[0] == '\n' {
+				_, _ = reader.Discard(1)
+				l.newline(true)
+				continue
+			}
+		}
+		if b == '\n' {
+			l.newline(false)
+			continue
+		}
+		l.consume(b)
+	}
+
+	l.finish()
+	return l.result(), nil
+}
+
+// consume processes one non-newline byte
+func (l *csvLinter) consume(b byte) {
+	l.recordEmpty = false
+
+	if l.inQuotes {
+		if b == '"' {
+			l.inQuotes = false
+			l.afterQuote = true
+		}
+		return
+	}
+
+	if l.afterQuote {
+		l.afterQuote = false
+		switch b {
+		case '"':
+			// Escaped quote ("") inside a quoted field
+			l.inQuotes = true
+			return
+		case ',':
+			l.endField()
+			return
+		default:
+			l.report(l.line, fmt.Sprintf("unexpected character %q after closing quote in field %d", b, l.fieldCount+1))
+			return
+		}
+	}
+
+	switch {
+	case b == ',':
+		l.endField()
+	case b == '"' && l.fieldStart:
+		l.inQuotes = true
+		l.quoteLine = l.line
+		l.fieldStart = false
+	case b == '"':
+		l.report(l.line, fmt.Sprintf("unescaped quote in unquoted field %d", l.fieldCount+1))
+		l.fieldStart = false
+	default:
+		l.fieldStart = false
+	}
+}
+
+// newline processes a line terminator
+func (l *csvLinter) newline(crlf bool) {
+	if crlf && l.crlfLine == 0 {
+		l.crlfLine = l.line
+	}
+	if !crlf && l.lfLine == 0 {
+		l.lfLine = l.line
+	}
+
+	if l.inQuotes {
+		// Newlines are allowed inside quoted fields
+		l.line++
+		return
+	}
+
+	l.afterQuote = false
+	if !l.recordEmpty {
+		l.endRecord()
+	}
+	l.line++
+	l.recordLine = l.line
+}
+
+// endField closes the current field
+func (l *csvLinter) endField() {
+	l.fieldCount++
+	l.fieldStart = true
+}
+
+// endRecord closes the current record and checks its width against the header
+func (l *csvLinter) endRecord() {
+	fields := l.fieldCount + 1
+	if l.headerWidth == 0 {
+		l.headerWidth = fields
+	} else if fields != l.headerWidth {
+		l.report(l.recordLine, fmt.Sprintf("row has %d columns, header has %d", fields, l.headerWidth))
+	}
+	l.fieldCount = 0
+	l.fieldStart = true
+	l.recordEmpty = true
+}
+
+// finish reports problems that are only known at end of input
+func (l *csvLinter) finish() {
+	if l.inQuotes {
+		l.report(l.quoteLine, "quoted field is never closed")
+	} else if !l.recordEmpty {
+		l.endRecord()
+	}
+
+	if l.crlfLine > 0 && l.lfLine > 0 {
+		l.report(max(l.crlfLine, l.lfLine), fmt.Sprintf("mixed line endings (CRLF first on line %d, LF first on line %d)",
+			l.crlfLine, l.lfLine))
+	}
+}
+
+// report records an issue, keeping at most maxLintIssuesPerFile
+func (l *csvLinter) report(line int, message string) {
+	l.total++
+	if len(l.issues) < maxLintIssuesPerFile {
+		l.issues = append(l.issues, fmt.Sprintf("%s:%d: %s", l.path, line, message))
+	}
+}
+
+// result returns the reported issues with a note for any that were dropped
+func (l *csvLinter) result() []string {
+	if l.total > len(l.issues) {
+		return append(l.issues, fmt.Sprintf("%s: %d more structural issues not shown", l.path, l.total-len(l.issues)))
+	}
+	return l.issues
+}
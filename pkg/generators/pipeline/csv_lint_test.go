@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:    "Well-formed file",
+			content: "id,name\n1,\"Smith, \"\"Jo\"\"\"\n2,\"multi\nline\"\n3,plain\n",
+		},
+		{
+			name:    "Missing trailing newline",
+			content: "id,name\n1,a",
+		},
+		{
+			name:     "Leading BOM",
+			content:  "\xEF\xBB\xBFid,name\n1,a\n",
+			expected: []string{"f.csv:1: file starts with a UTF-8 byte order mark"},
+		},
+		{
+			name:     "Stray BOM",
+			content:  "id,name\n1,a\n\xEF\xBB\xBF2,b\n",
+			expected: []string{"f.csv:3: stray UTF-8 byte order mark"},
+		},
+		{
+			name:     "Mixed line endings",
+			content:  "id,name\r\n1,a\r\n2,b\n",
+			expected: []string{"f.csv:3: mixed line endings (CRLF first on line 1, LF first on line 3)"},
+		},
+		{
+			name:     "Unescaped quote",
+			content:  "id,name\n1,Jo \"JJ\" Smith\n",
+			expected: []string{"f.csv:2: unescaped quote in unquoted field 2", "f.csv:2: unescaped quote in unquoted field 2"},
+		},
+		{
+			name:     "Text after closing quote",
+			content:  "id,name\n1,\"Jo\"x\n",
+			expected: []string{"f.csv:2: unexpected character 'x' after closing quote in field 2"},
+		},
+		{
+			name:     "Unterminated quote",
+			content:  "id,name\n1,\"Jo\n2,b\n",
+			expected: []string{"f.csv:2: quoted field is never closed"},
+		},
+		{
+			name:     "Inconsistent column counts",
+			content:  "id,name\n1,a,extra\n2\n3,c\n",
+			expected: []string{"f.csv:2: row has 3 columns, header has 2", "f.csv:3: row has 1 columns, header has 2"},
+		},
+		{
+			name:     "Column count reported at record start for multi-line fields",
+			content:  "id,name\n1,\"a\nb\",extra\n",
+			expected: []string{"f.csv:2: row has 3 columns, header has 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := LintCSV("f.csv", strings.NewReader(tt.content))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, issues)
+		})
+	}
+
+	t.Run("Caps issues per file", func(t *testing.T) {
+		content := "id,name\n" + strings.Repeat("1\n", maxLintIssuesPerFile+5)
+		issues, err := LintCSV("f.csv", strings.NewReader(content))
+		require.NoError(t, err)
+		require.Len(t, issues, maxLintIssuesPerFile+1)
+		assert.Equal(t, "f.csv: 5 more structural issues not shown", issues[maxLintIssuesPerFile])
+	})
+}
+
+func TestValidationProcessor_ReportsCSVStructure(t *testing.T) {
+	dir := t.TempDir()
+	def := &parser.SORDefinition{
+		DisplayName: "Lint SOR",
+		Description: "SOR used for structural validation tests",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User",
+				ExternalId:  "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "name", ExternalId: "name", Type: "String"},
+				},
+			},
+		},
+	}
+	writeCSV(t, filepath.Join(dir, "User.csv"), [][]string{{"id", "name"}, {"u1", "Ann"}, {"u2", "Bob", "extra"}})
+
+	issues, err := NewValidationProcessor().ValidateExistingCSVFiles(def, dir)
+	require.NoError(t, err)
+	require.NotEmpty(t, issues)
+	assert.Equal(t, "CSV structure: "+filepath.Join(dir, "User.csv")+":3: row has 3 columns, header has 2", issues[0])
+}
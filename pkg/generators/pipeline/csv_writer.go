@@ -1,18 +1,38 @@
 package pipeline
 
 import (
+	"bufio"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
+	"github.com/SGNL-ai/fabricator/pkg/console"
+	"github.com/SGNL-ai/fabricator/pkg/events"
 	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
 	"github.com/fatih/color"
 )
 
 // CSVWriter handles writing entity data to CSV files
 type CSVWriter struct {
-	outputDir string
+	outputDir  string
+	throttle   *Throttle // Optional row pacing; nil writes as fast as possible
+	workers    int       // Files written at the same time; 0 uses DefaultWriteWorkers
+	fileBuffer int       // Bytes buffered per file; 0 uses DefaultWriteFileBuffer
+
+	// Directory of a copy with sensitive attributes redacted; empty writes none
+	redactedDir string
+
+	// Creates the data files, e.g. encrypted; nil writes them in the clear
+	files *OutputFiles
+
+	// Wraps each entity's sink, e.g. to rewrite its headers; nil writes it as is
+	wrapSink func(recordSink) recordSink
+
+	// Observability
+	events *events.Emitter
 }
 
 // NewCSVWriter creates a new CSV writer
@@ -22,69 +42,248 @@ func NewCSVWriter(outputDir string) CSVWriterInterface {
 	}
 }
 
+// SetThrottle configures row pacing for subsequent writes
+func (w *CSVWriter) SetThrottle(throttle *Throttle) {
+	w.throttle = throttle
+}
+
+// SetEventEmitter configures where each entity's written rows and write time are reported
+func (w *CSVWriter) SetEventEmitter(emitter *events.Emitter) {
+	w.events = emitter
+}
+
+// SetWorkers configures how many entity files are written at the same time
+func (w *CSVWriter) SetWorkers(workers int) {
+	w.workers = workers
+}
+
+// SetFileBufferSize configures how many bytes are buffered per file between writes to storage
+func (w *CSVWriter) SetFileBufferSize(bytes int) {
+	w.fileBuffer = bytes
+}
+
+// SetRedactedOutput configures a second copy of the files, written to dir after
+// the full one, with sensitive attributes masked or hashed
+func (w *CSVWriter) SetRedactedOutput(dir string) {
+	w.redactedDir = dir
+}
+
+// SetOutputFiles configures how the data files are created, e.g. encrypted
+func (w *CSVWriter) SetOutputFiles(files *OutputFiles) {
+	w.files = files
+}
+
 // WriteFiles writes all entity data to CSV files
 func (w *CSVWriter) WriteFiles(graph *model.Graph) error {
+	if err := w.writeFiles(graph, w.outputDir, nil, w.throttle, w.events); err != nil {
+		return err
+	}
+	if w.redactedDir == "" {
+		return nil
+	}
+
+	// The redacted copy isn't paced or reported as written rows again
+	redactor, err := NewRedactor(graph)
+	if err != nil {
+		return err
+	}
+	return w.writeFiles(graph, w.redactedDir, redactor, nil, nil)
+}
+
+// writeFiles writes all entity data to CSV files in outputDir, redacted by redactor
+// unless it is nil
+func (w *CSVWriter) writeFiles(graph *model.Graph, outputDir string, redactor *Redactor, throttle *Throttle, emitter *events.Emitter) error {
 	// Create the output directory if it doesn't exist
-	err := os.MkdirAll(w.outputDir, 0750)
+	err := os.MkdirAll(outputDir, 0750)
 	if err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
 	// Write each entity's data to a CSV file
-	for _, entity := range graph.GetAllEntities() {
-		csvData := entity.ToCSV()
+	newSink := func() recordSink {
+		sink := recordSink(newPartitionSink(&csvSink{outputDir: outputDir, fileBuffer: w.fileBuffer, files: w.files}, func(file string) recordSink {
+			return &csvSink{outputDir: outputDir, fileBuffer: w.fileBuffer, files: w.files, path: file}
+		}, ".csv", w.files.Layout(), !w.files.Encrypted()))
+		if w.wrapSink != nil {
+			sink = w.wrapSink(sink)
+		}
+		if redactor != nil {
+			sink = redactor.wrap(sink)
+		}
+		return sink
+	}
+	if err := writeRecordsParallel(graph.GetEntitiesList(), newSink, w.workers, throttle, emitter); err != nil {
+		return err
+	}
+
+	// Rows-encoded lists are written to their own files
+	return writeListFiles(graph, outputDir, w.fileBuffer, w.files, redactor)
+}
+
+// getEntityFileName extracts filename from external ID
+func (w *CSVWriter) getEntityFileName(externalID string) string {
+	return w.files.Layout().entityFileBase(externalID) + ".csv"
+}
+
+// csvSink writes each entity's records to <entity>.csv, or <domain>/<entity>.csv
+// with domain folders. Columns of rows-encoded lists are left out; writeListFiles
+// writes their values.
+type csvSink struct {
+	outputDir  string
+	fileBuffer int          // Bytes buffered between writes to the file; 0 uses DefaultWriteFileBuffer
+	files      *OutputFiles // Creates the file; nil writes it in the clear
+	path       string       // File written instead of the entity's own, e.g. one of its partitions
+	file       io.WriteCloser
+	buffer     *bufio.Writer
+	writer     *csv.Writer
+	filename   string
+	filePath   string
+	columns    []int // Indexes of the record values written; nil writes them all
+	rows       int
+}
+
+func (s *csvSink) begin(entity model.EntityInterface, headers []string) error {
+	// Get the filename based on the entity's external ID
+	s.filename = s.path
+	if s.filename == "" {
+		s.filename = s.files.Layout().entityFilePath(entity, ".csv")
+	}
+	s.filename = s.files.FileName(s.filename)
+	s.filePath = filepath.Join(s.outputDir, s.filename)
+	s.rows = 0
+
+	if err := os.MkdirAll(filepath.Dir(s.filePath), 0750); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", s.filePath, err)
+	}
 
-		// Get the filename based on the entity's external ID
-		filename := w.getEntityFileName(csvData.ExternalId)
-		filePath := filepath.Join(w.outputDir, filename)
+	file, err := s.files.create(s.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", s.filePath, err)
+	}
+	s.file = file
+	s.buffer = bufio.NewWriterSize(file, fileBufferSize(s.fileBuffer))
+	s.writer = csv.NewWriter(s.buffer)
 
-		file, err := os.Create(filepath.Clean(filePath))
-		if err != nil {
-			return fmt.Errorf("failed to create file %s: %w", filePath, err)
+	s.columns = nil
+	if len(rowsEncodedAttributes(entity)) > 0 {
+		for i, attr := range entity.GetAttributes() {
+			if attr.GetListEncoding() != parser.ListEncodingRows {
+				s.columns = append(s.columns, i)
+			}
 		}
-		defer func() { _ = file.Close() }()
+	}
 
-		writer := csv.NewWriter(file)
-		defer writer.Flush()
+	// Write headers
+	if err := s.writer.Write(s.record(headers)); err != nil {
+		return fmt.Errorf("failed to write headers to %s: %w", s.filePath, err)
+	}
+	return nil
+}
 
-		// Write headers
-		err = writer.Write(csvData.Headers)
-		if err != nil {
-			return fmt.Errorf("failed to write headers to %s: %w", filePath, err)
+func (s *csvSink) write(record []string, flush bool) error {
+	if err := s.writer.Write(s.record(record)); err != nil {
+		return fmt.Errorf("failed to write row to %s: %w", s.filePath, err)
+	}
+	if flush {
+		if err := s.flush(); err != nil {
+			return fmt.Errorf("failed to write row to %s: %w", s.filePath, err)
 		}
+	}
+	s.rows++
+	return nil
+}
 
-		// Write data rows
-		for _, row := range csvData.Rows {
-			err = writer.Write(row)
-			if err != nil {
-				return fmt.Errorf("failed to write row to %s: %w", filePath, err)
-			}
+func (s *csvSink) end() error {
+	// A suspended file is already written out
+	if s.file != nil {
+		if err := s.suspend(); err != nil {
+			return err
 		}
+	}
 
-		// Clear progress line and show completion message
-		fmt.Printf("\r%-80s\r", "") // Clear line with 80 spaces, then return to start
-		color.Green("✓ Generated %s with %d rows", filename, len(csvData.Rows))
+	// Clear progress line and show completion message
+	console.ClearLine()
+	color.Green(console.Text("✓ Generated %s with %d rows"), s.filename, s.rows)
+	return nil
+}
+
+// suspend writes out the buffered records and closes the file
+func (s *csvSink) suspend() error {
+	err := s.flush()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	s.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.filePath, err)
 	}
+	return nil
+}
 
+// resume reopens a suspended file to append the entity's further records
+func (s *csvSink) resume() error {
+	file, err := s.files.reopen(s.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to reopen file %s: %w", s.filePath, err)
+	}
+	s.file = file
+	s.buffer = bufio.NewWriterSize(file, fileBufferSize(s.fileBuffer))
+	s.writer = csv.NewWriter(s.buffer)
 	return nil
 }
 
-// getEntityFileName extracts filename from external ID
-func (w *CSVWriter) getEntityFileName(externalID string) string {
+// record returns the values of a record that are written to the entity's file
+func (s *csvSink) record(values []string) []string {
+	if s.columns == nil {
+		return values
+	}
+	kept := make([]string, len(s.columns))
+	for i, column := range s.columns {
+		kept[i] = values[column]
+	}
+	return kept
+}
+
+// flush moves buffered records through the file buffer to the file
+func (s *csvSink) flush() error {
+	s.writer.Flush()
+	if err := s.writer.Error(); err != nil {
+		return err
+	}
+	return s.buffer.Flush()
+}
+
+func (s *csvSink) close() {
+	if s.file != nil {
+		_ = s.file.Close()
+		s.file = nil
+	}
+}
+
+// fileBufferSize returns the per-file buffer size to use for a configured size
+func fileBufferSize(configured int) int {
+	if configured <= 0 {
+		return DefaultWriteFileBuffer
+	}
+	return configured
+}
+
+// entityNamespaceBase returns an external ID without its namespace prefix
+func entityNamespaceBase(externalID string) string {
 	// Handle both formats: with namespace prefix (e.g., "KeystoneV1/Entity") and without
 	if len(externalID) == 0 {
-		return "unknown.csv"
+		return "unknown"
 	}
 
 	// If there's a slash, take the part after the last slash
 	if lastSlash := len(externalID) - 1; lastSlash >= 0 {
 		for i := lastSlash; i >= 0; i-- {
 			if externalID[i] == '/' {
-				return externalID[i+1:] + ".csv"
+				return externalID[i+1:]
 			}
 		}
 	}
 
 	// No slash found, use the whole external ID
-	return externalID + ".csv"
+	return externalID
 }
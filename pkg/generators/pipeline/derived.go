@@ -0,0 +1,144 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// deriveEntities computes the rows of derived entities once the others are
+// generated: one row per distinct value of the From attribute, in the order the
+// values first appear, with each aggregate taken over the rows referencing that
+// value. Derived entities already populated, e.g. from partial input, keep their rows.
+func deriveEntities(graph *model.Graph) error {
+	for _, entity := range graph.GetEntitiesList() {
+		derived := entity.GetDerived()
+		if derived == nil || entity.GetRowCount() > 0 {
+			continue
+		}
+
+		from, fromAttr, err := derivedColumn(graph, derived.From)
+		if err != nil {
+			return fmt.Errorf("entity %s: %w", entity.GetExternalID(), err)
+		}
+		var keys []string
+		seen := make(map[string]bool)
+		for i := 0; i < from.GetRowCount(); i++ {
+			value := from.GetRowByIndex(i).GetValue(fromAttr.GetName())
+			if value != "" && !seen[value] {
+				seen[value] = true
+				keys = append(keys, value)
+			}
+		}
+
+		results := make([]map[string]string, len(derived.Aggregates))
+		for i, aggregate := range derived.Aggregates {
+			results[i], err = aggregateValues(graph, aggregate)
+			if err != nil {
+				return fmt.Errorf("entity %s aggregate '%s': %w", entity.GetExternalID(), aggregate.Attribute, err)
+			}
+		}
+
+		for _, key := range keys {
+			rowData := map[string]string{derived.Key: key}
+			for i, aggregate := range derived.Aggregates {
+				value, exists := results[i][key]
+				if !exists && aggregate.Function != parser.AggregateMin && aggregate.Function != parser.AggregateMax {
+					value = "0" // No rows reference the key; min and max are left blank
+				}
+				rowData[aggregate.Attribute] = value
+			}
+			if err := entity.AddRow(model.NewRow(rowData)); err != nil {
+				return fmt.Errorf("failed to add row to entity %s: %w", entity.GetExternalID(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// derivedColumn resolves an Entity.attribute reference by externalIds
+func derivedColumn(graph *model.Graph, reference string) (model.EntityInterface, model.AttributeInterface, error) {
+	entityID, attrID, _ := strings.Cut(reference, ".")
+	entity, exists := graph.GetEntity(entityID)
+	if !exists {
+		return nil, nil, fmt.Errorf("'%s' references unknown entity %s", reference, entityID)
+	}
+	attr, exists := entity.GetAttributeByExternalID(attrID)
+	if !exists {
+		return nil, nil, fmt.Errorf("'%s' references unknown attribute %s of %s", reference, attrID, entityID)
+	}
+	return entity, attr, nil
+}
+
+// aggregateValues computes an aggregate for every value of its Over attribute,
+// keyed by that value
+func aggregateValues(graph *model.Graph, aggregate parser.Aggregate) (map[string]string, error) {
+	over, overAttr, err := derivedColumn(graph, aggregate.Over)
+	if err != nil {
+		return nil, err
+	}
+
+	var valueAttr model.AttributeInterface
+	if aggregate.Function != parser.AggregateCount {
+		attr, exists := over.GetAttribute(aggregate.Value)
+		if !exists {
+			return nil, fmt.Errorf("unknown value attribute '%s' of %s", aggregate.Value, over.GetExternalID())
+		}
+		valueAttr = attr
+	}
+	numeric := valueAttr != nil && parser.IsNumericType(valueAttr.GetDataType())
+
+	counts := make(map[string]int)
+	sums := make(map[string]float64)
+	results := make(map[string]string)
+	for i := 0; i < over.GetRowCount(); i++ {
+		row := over.GetRowByIndex(i)
+		key := row.GetValue(overAttr.GetName())
+		if key == "" {
+			continue
+		}
+		if aggregate.Function == parser.AggregateCount {
+			counts[key]++
+			results[key] = strconv.Itoa(counts[key])
+			continue
+		}
+
+		value := row.GetValue(valueAttr.GetName())
+		if value == "" {
+			continue
+		}
+		var number float64
+		if numeric {
+			number, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("row %d of %s has non-numeric %s value '%s'", i+1, over.GetExternalID(), valueAttr.GetName(), value)
+			}
+		}
+
+		current, exists := results[key]
+		switch aggregate.Function {
+		case parser.AggregateSum:
+			sums[key] += number
+			results[key] = strconv.FormatFloat(sums[key], 'f', -1, 64)
+		case parser.AggregateMin, parser.AggregateMax:
+			if !exists || precedes(value, current, numeric) == (aggregate.Function == parser.AggregateMin) {
+				results[key] = value
+			}
+		}
+	}
+	return results, nil
+}
+
+// precedes reports whether a sorts before b, as numbers when numeric and as
+// strings otherwise, which orders ISO dates chronologically
+func precedes(a, b string, numeric bool) bool {
+	if numeric {
+		x, _ := strconv.ParseFloat(a, 64)
+		y, _ := strconv.ParseFloat(b, 64)
+		return x < y
+	}
+	return a < b
+}
@@ -0,0 +1,96 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveEntities(t *testing.T) {
+	str := func(name string) parser.Attribute {
+		return parser.Attribute{Name: name, ExternalId: name, Type: "String"}
+	}
+	key := func(name string) parser.Attribute {
+		return parser.Attribute{Name: name, ExternalId: name, Type: "String", UniqueId: true}
+	}
+	num := func(name string) parser.Attribute {
+		return parser.Attribute{Name: name, ExternalId: name, Type: "Int64"}
+	}
+	definition := func() *parser.SORDefinition {
+		return &parser.SORDefinition{
+			DisplayName: "Derived SOR",
+			Entities: map[string]parser.Entity{
+				"user": {DisplayName: "User", ExternalId: "User",
+					Attributes: []parser.Attribute{key("id"), str("department")},
+					Data: []map[string]string{
+						{"id": "u1", "department": "sales"},
+						{"id": "u2", "department": "it"},
+						{"id": "u3", "department": "sales"},
+					}},
+				"order": {DisplayName: "Order", ExternalId: "Order",
+					Attributes: []parser.Attribute{key("id"), str("userId"), num("amount")}},
+				"user_summary": {DisplayName: "UserSummary", ExternalId: "UserSummary",
+					Attributes: []parser.Attribute{key("userId"), num("orders"), num("spent"), num("largest")},
+					Derived: &parser.Derived{From: "User.id", Key: "userId", Aggregates: []parser.Aggregate{
+						{Attribute: "orders", Function: parser.AggregateCount, Over: "Order.userId"},
+						{Attribute: "spent", Function: parser.AggregateSum, Over: "Order.userId", Value: "amount"},
+						{Attribute: "largest", Function: parser.AggregateMax, Over: "Order.userId", Value: "amount"},
+					}}},
+				"department_summary": {DisplayName: "DepartmentSummary", ExternalId: "DepartmentSummary",
+					Attributes: []parser.Attribute{key("department"), num("users")},
+					Derived: &parser.Derived{From: "User.department", Key: "department", Aggregates: []parser.Aggregate{
+						{Attribute: "users", Function: parser.AggregateCount, Over: "User.department"},
+					}}},
+			},
+			Relationships: map[string]parser.Relationship{
+				"order_user": {Name: "order_user", FromAttribute: "Order.userId", ToAttribute: "User.id"},
+			},
+		}
+	}
+
+	dir := t.TempDir()
+	graph, err := model.NewGraph(definition(), 20)
+	require.NoError(t, err)
+	generator := NewDataGenerator(dir, map[string]int{"User": 3, "Order": 20, "UserSummary": 0, "DepartmentSummary": 0}, false)
+	require.NoError(t, generator.Generate(graph.(*model.Graph)))
+
+	t.Run("one row per distinct value", func(t *testing.T) {
+		departments := readCSV(t, filepath.Join(dir, "DepartmentSummary.csv"))
+		assert.Equal(t, [][]string{{"department", "users"}, {"sales", "2"}, {"it", "1"}}, departments)
+	})
+
+	t.Run("aggregates cover the rows referencing each value", func(t *testing.T) {
+		orders := readCSV(t, filepath.Join(dir, "Order.csv"))
+		count := make(map[string]int)
+		spent := make(map[string]int)
+		largest := make(map[string]int)
+		for _, order := range orders[1:] {
+			amount, err := strconv.Atoi(order[2])
+			require.NoError(t, err)
+			count[order[1]]++
+			spent[order[1]] += amount
+			largest[order[1]] = max(largest[order[1]], amount)
+		}
+
+		summaries := readCSV(t, filepath.Join(dir, "UserSummary.csv"))
+		require.Len(t, summaries, 4)
+		assert.Equal(t, []string{"userId", "orders", "spent", "largest"}, summaries[0])
+		total := 0
+		for i, summary := range summaries[1:] {
+			user := "u" + strconv.Itoa(i+1)
+			assert.Equal(t, user, summary[0])
+			assert.Equal(t, strconv.Itoa(count[user]), summary[1])
+			assert.Equal(t, strconv.Itoa(spent[user]), summary[2])
+			if count[user] > 0 {
+				assert.Equal(t, strconv.Itoa(largest[user]), summary[3])
+			}
+			total += count[user]
+		}
+		assert.Equal(t, 20, total)
+	})
+}
@@ -0,0 +1,137 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// DescriptorVersion is the version of the dataset descriptor's format, raised when
+// fields change in ways consumers must handle
+const DescriptorVersion = 1
+
+// DatasetDescriptor is a small summary of a generated dataset for infrastructure as
+// code steps standing up test environments: where the files are, how many rows
+// each entity has, and what identifies this version of the data
+type DatasetDescriptor struct {
+	DescriptorVersion int    `json:"descriptorVersion"`
+	DatasetVersion    string `json:"datasetVersion"` // Hash of the schema and the files' names and contents
+	SOR               string `json:"sor"`            // Display name of the SOR
+	SchemaHash        string `json:"schemaHash"`     // sha256:<hex> of the SOR definition the data was generated from
+	Seed              int64  `json:"seed"`           // Seed reproducing the data; 0 when it came from a random source
+	Format            string `json:"format"`         // Output format, as in the manifest
+	OutputDirectory   string `json:"outputDirectory"`
+	Manifest          string `json:"manifest"` // Path of the manifest listing the files in detail
+	TotalRows         int    `json:"totalRows"`
+
+	Entities []DescriptorEntity `json:"entities"` // In dependency order
+}
+
+// DescriptorEntity lists the rows and data files of one entity
+type DescriptorEntity struct {
+	Entity string   `json:"entity"` // Entity external ID
+	Rows   int      `json:"rows"`
+	Files  []string `json:"files"` // Paths within the output directory, with / separators
+}
+
+// SchemaHash returns the sha256:<hex> hash of a SOR definition, which changes with
+// any of its entities, attributes, relationships or settings
+func SchemaHash(def *parser.SORDefinition) (string, error) {
+	encoded, err := json.Marshal(def)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash SOR definition: %w", err)
+	}
+	sum := sha256.Sum256(encoded)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// NewDatasetDescriptor describes the files of manifest, in outputDir, generated from
+// def with seed. The dataset version hashes the schema hash with every data file's
+// name and contents, so it changes exactly when the data or its schema does.
+func NewDatasetDescriptor(def *parser.SORDefinition, manifest *Manifest, outputDir string, seed int64) (*DatasetDescriptor, error) {
+	schemaHash, err := SchemaHash(def)
+	if err != nil {
+		return nil, err
+	}
+	absolute, err := filepath.Abs(outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve output directory: %w", err)
+	}
+
+	descriptor := &DatasetDescriptor{
+		DescriptorVersion: DescriptorVersion,
+		SOR:               def.DisplayName,
+		SchemaHash:        schemaHash,
+		Seed:              seed,
+		Format:            manifest.Format,
+		OutputDirectory:   absolute,
+		Manifest:          filepath.Join(absolute, ManifestFile),
+		Entities:          make([]DescriptorEntity, 0, len(manifest.Files)),
+	}
+
+	version := sha256.New()
+	_, _ = io.WriteString(version, schemaHash)
+	for _, entry := range manifest.Files {
+		entity := DescriptorEntity{Entity: entry.Entity, Rows: entry.Rows, Files: []string{}}
+		for _, file := range entry.DataFiles() {
+			written, err := hashDataFile(version, absolute, file)
+			if err != nil {
+				return nil, err
+			}
+			if written {
+				entity.Files = append(entity.Files, file)
+			}
+		}
+		descriptor.Entities = append(descriptor.Entities, entity)
+		descriptor.TotalRows += entry.Rows
+	}
+	for _, edge := range manifest.Edges {
+		if _, err := hashDataFile(version, absolute, edge.File); err != nil {
+			return nil, err
+		}
+	}
+	descriptor.DatasetVersion = hex.EncodeToString(version.Sum(nil))[:16]
+	return descriptor, nil
+}
+
+// hashDataFile adds a data file's name, size and contents to hash, reporting
+// whether the file exists; formats such as go don't write one file per entry
+func hashDataFile(hash io.Writer, dir, file string) (bool, error) {
+	path := filepath.Join(dir, filepath.FromSlash(file))
+	content, err := os.Open(path) // #nosec G304 - path is a manifest entry under the output directory
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	defer func() { _ = content.Close() }()
+	info, err := content.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	_, _ = fmt.Fprintf(hash, "\x00%s\x00%d\x00", file, info.Size())
+	if _, err := io.Copy(hash, content); err != nil {
+		return false, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// WriteDatasetDescriptor writes the descriptor as indented JSON, encrypted when
+// files are, as its seed regenerates the data
+func WriteDatasetDescriptor(path string, descriptor *DatasetDescriptor, files *OutputFiles) error {
+	content, err := json.MarshalIndent(descriptor, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode dataset descriptor: %w", err)
+	}
+	if err := files.write(path, append(content, '\n')); err != nil {
+		return fmt.Errorf("failed to write dataset descriptor: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDatasetDescriptor(t *testing.T) {
+	def := userRoleDefinition("")
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Role.csv"), []byte("id\nrole-1\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "User.csv"), []byte("id,roleId\nuser-1,role-1\n"), 0600))
+	manifest := &Manifest{Format: OutputFormatCSV, Files: []ManifestEntry{
+		{Entity: "Role", File: "Role.csv", Rows: 1},
+		{Entity: "User", File: "User.csv", Rows: 1, ListFiles: map[string]string{"tags": "User_tags.csv"}},
+	}}
+
+	descriptor, err := NewDatasetDescriptor(def, manifest, dir, 42)
+	require.NoError(t, err)
+	assert.Equal(t, []DescriptorEntity{
+		{Entity: "Role", Rows: 1, Files: []string{"Role.csv"}},
+		{Entity: "User", Rows: 1, Files: []string{"User.csv"}},
+	}, descriptor.Entities, "files that weren't written aren't listed")
+	assert.Equal(t, 2, descriptor.TotalRows)
+	assert.Equal(t, int64(42), descriptor.Seed)
+	assert.Equal(t, filepath.Join(dir, ManifestFile), descriptor.Manifest)
+	assert.Len(t, descriptor.DatasetVersion, 16)
+
+	// The version follows the data, and the schema hash the definition
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "User.csv"), []byte("id,roleId\nuser-2,role-1\n"), 0600))
+	changed, err := NewDatasetDescriptor(def, manifest, dir, 42)
+	require.NoError(t, err)
+	assert.NotEqual(t, descriptor.DatasetVersion, changed.DatasetVersion)
+	assert.Equal(t, descriptor.SchemaHash, changed.SchemaHash)
+
+	def.DisplayName = "Renamed"
+	renamed, err := SchemaHash(def)
+	require.NoError(t, err)
+	assert.NotEqual(t, descriptor.SchemaHash, renamed)
+}
@@ -0,0 +1,321 @@
+package pipeline
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// Likely causes of duplicate keys, told apart by where the rows sharing a key are
+const (
+	DuplicateCauseStaleFile = "stale_file"   // One of the rows is in a file the directory's manifest doesn't list
+	DuplicateCauseOverlap   = "file_overlap" // The rows are in different files of the entity
+	DuplicateCauseRepeated  = "repeated_row" // A whole row is repeated within one file
+	DuplicateCauseConflict  = "conflict"     // Different rows of one file share a key
+	DuplicateCauseCase      = "case"         // Keys differ only in case
+)
+
+// duplicateCauseOrder lists the causes in the order groups are reported
+var duplicateCauseOrder = []string{DuplicateCauseStaleFile, DuplicateCauseOverlap, DuplicateCauseRepeated,
+	DuplicateCauseConflict, DuplicateCauseCase}
+
+// maxDuplicateExamples is how many values a group of duplicate keys lists
+const maxDuplicateExamples = 3
+
+var (
+	uuidValue = regexp.MustCompile(`(?i)^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	digitRun  = regexp.MustCompile(`[0-9]+`)
+)
+
+// DuplicateKeyGroup gathers the duplicate primary keys of an entity sharing a value
+// pattern and a likely cause
+type DuplicateKeyGroup struct {
+	Entity    string   // Entity external ID
+	Attribute string   // Primary key attribute name
+	Pattern   string   // The values with digit runs as #, e.g. user-#, or <uuid>
+	Cause     string   // One of the DuplicateCause constants
+	Rows      int      // Rows repeating an earlier row's key, or differing from it only in case
+	Examples  []string // Up to maxDuplicateExamples of the values
+}
+
+// String describes the group, e.g. "User.id: 120 duplicate keys like 'user-#' (user-1, user-2, user-3)"
+func (g DuplicateKeyGroup) String() string {
+	kind := "duplicate keys"
+	if g.Cause == DuplicateCauseCase {
+		kind = "keys differing only in case from another"
+	}
+	return fmt.Sprintf("%s.%s: %d %s like '%s' (%s)", g.Entity, g.Attribute, g.Rows, kind, g.Pattern,
+		strings.Join(g.Examples, ", "))
+}
+
+// Suggestion explains the group's likely cause and how to fix it
+func (g DuplicateKeyGroup) Suggestion() string {
+	switch g.Cause {
+	case DuplicateCauseStaleFile:
+		return "some rows are in files the manifest doesn't list, likely left by an earlier run: regenerate with --clean, or delete those files"
+	case DuplicateCauseOverlap:
+		return "the same keys are in more than one of the entity's files, as when chunked or partitioned files overlap: check how the rows were split"
+	case DuplicateCauseRepeated:
+		return "whole rows are repeated in one file, as when an export is appended to an earlier one instead of replacing it"
+	case DuplicateCauseCase:
+		return "systems comparing keys case-insensitively treat these as duplicates: make the keys' case consistent"
+	default:
+		return "different rows share a key: the source reuses keys, or the attribute marked uniqueId doesn't identify rows"
+	}
+}
+
+// DuplicateKeyReport is the outcome of AnalyzeDuplicateKeys
+type DuplicateKeyReport struct {
+	Groups  []DuplicateKeyGroup
+	Dropped int // Rows left out of the deduplicated copy
+}
+
+// keyPattern returns the pattern a duplicate key is grouped by
+func keyPattern(value string) string {
+	if uuidValue.MatchString(value) {
+		return "<uuid>"
+	}
+	return digitRun.ReplaceAllString(value, "#")
+}
+
+// AnalyzeDuplicateKeys reads the primary keys of the entities' CSV files in
+// directory, values coerced as validation does, and groups the rows repeating an
+// earlier row's key, and the keys differing from another only in case, by entity,
+// likely cause and value pattern. When dedupeDir isn't empty, a copy of each file
+// is written to the same path under it, without the rows repeating a key. Files the
+// directory's manifest lists are read before the others, so their rows are kept.
+// The files are found with layout, as they were written, and opened with files.
+func AnalyzeDuplicateKeys(def *parser.SORDefinition, directory, dedupeDir string, layout FileLayout, files *InputFiles) (*DuplicateKeyReport, error) {
+	graphInterface, err := model.NewGraph(def, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create graph: %w", err)
+	}
+	graph, ok := graphInterface.(*model.Graph)
+	if !ok {
+		return nil, fmt.Errorf("failed to convert graph to concrete type")
+	}
+
+	// Without a manifest, every file is taken to be current
+	var listed map[string]bool
+	if manifest, err := ReadManifest(filepath.Join(directory, ManifestFile)); err == nil {
+		listed = make(map[string]bool)
+		for _, file := range manifest.DataFiles() {
+			listed[filepath.Join(directory, filepath.FromSlash(file))] = true
+		}
+	}
+
+	entities := graph.GetEntitiesList()
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].GetExternalID() < entities[j].GetExternalID()
+	})
+
+	report := &DuplicateKeyReport{}
+	coercion := newValueCoercion(graph)
+	for _, entity := range entities {
+		var entityFiles []string
+		var stale []bool
+		for _, current := range []bool{true, false} {
+			for _, file := range layout.entityDataFiles(directory, entity, files.FileName(".csv")) {
+				if listed == nil || listed[file] == current {
+					entityFiles = append(entityFiles, file)
+					stale = append(stale, !current)
+				}
+			}
+			if listed == nil {
+				break
+			}
+		}
+
+		scan := &duplicateScan{entity: entity, files: files, coercion: coercion, seen: make(map[string]keySighting),
+			cases: make(map[string]string), groups: make(map[[2]string]*DuplicateKeyGroup)}
+		for i, file := range entityFiles {
+			var output string
+			if dedupeDir != "" {
+				relative, err := filepath.Rel(directory, file)
+				if err != nil {
+					return nil, err
+				}
+				output = filepath.Join(dedupeDir, relative)
+			}
+			if err := scan.file(file, i, stale, output); err != nil {
+				return nil, err
+			}
+		}
+		report.Groups = append(report.Groups, scan.sortedGroups()...)
+		report.Dropped += scan.dropped
+	}
+	return report, nil
+}
+
+// keySighting is where a key was first seen
+type keySighting struct {
+	file   int    // Position of the file among the entity's
+	record string // The whole row, its fields joined by NUL
+}
+
+// duplicateScan finds the duplicate keys of one entity's files. Keys and rows are
+// compared as they are rather than by hash, so no two rows are ever taken for
+// duplicates, and dropped from the deduplicated copy, unless they share a key.
+type duplicateScan struct {
+	entity   model.EntityInterface
+	files    *InputFiles            // Opens the entity's files
+	coercion *valueCoercion         // Forms keys are compared in
+	seen     map[string]keySighting // Key → first sighting
+	cases    map[string]string      // Lower-case key → the first key with that form
+	groups   map[[2]string]*DuplicateKeyGroup
+	dropped  int
+}
+
+// file scans one of the entity's files, copying it to output without the rows
+// repeating a key when output isn't empty
+func (s *duplicateScan) file(csvPath string, index int, stale []bool, output string) error {
+	file, err := s.files.Open(csvPath)
+	if err != nil {
+		return fmt.Errorf("failed to open CSV file %s: %w", csvPath, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // Malformed rows are copied as they are
+	headers, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read CSV file %s: %w", csvPath, err)
+	}
+
+	var writer *csv.Writer
+	if output != "" {
+		if err := os.MkdirAll(filepath.Dir(output), 0750); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", output, err)
+		}
+		copied, err := os.Create(output) // #nosec G304 - output is under the directory given by --dedupe-output
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", output, err)
+		}
+		defer func() { _ = copied.Close() }()
+		writer = csv.NewWriter(copied)
+		if err := writer.Write(headers); err != nil {
+			return fmt.Errorf("failed to write %s: %w", output, err)
+		}
+	}
+
+	pk := s.entity.GetPrimaryKey()
+	pkColumn := -1
+	if pk != nil {
+		for i, header := range headers {
+			if header == pk.GetExternalID() || header == pk.GetName() {
+				pkColumn = i
+				break
+			}
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV file %s: %w", csvPath, err)
+		}
+		if pkColumn >= 0 && pkColumn < len(record) && s.duplicate(record, pkColumn, index, stale) {
+			s.dropped++
+			continue
+		}
+		if writer != nil {
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+		}
+	}
+
+	if writer != nil {
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", output, err)
+		}
+	}
+	return nil
+}
+
+// duplicate records a row's key, reporting whether an earlier row has the same one
+func (s *duplicateScan) duplicate(record []string, pkColumn, index int, stale []bool) bool {
+	value := s.coercion.normalize(s.entity.GetPrimaryKey(), record[pkColumn])
+	if value == "" {
+		return false // Reported by validation
+	}
+	row := strings.Join(record, "\x00")
+
+	first, exists := s.seen[value]
+	if !exists {
+		s.seen[value] = keySighting{file: index, record: row}
+		folded := strings.ToLower(value)
+		if original, exists := s.cases[folded]; exists && original != value {
+			s.add(DuplicateCauseCase, value)
+		} else if !exists {
+			s.cases[folded] = value
+		}
+		return false
+	}
+
+	switch {
+	case stale[index] || stale[first.file]:
+		s.add(DuplicateCauseStaleFile, value)
+	case first.file != index:
+		s.add(DuplicateCauseOverlap, value)
+	case first.record == row:
+		s.add(DuplicateCauseRepeated, value)
+	default:
+		s.add(DuplicateCauseConflict, value)
+	}
+	return true
+}
+
+// add counts a row in the group of its cause and its key's pattern
+func (s *duplicateScan) add(cause, value string) {
+	id := [2]string{cause, keyPattern(value)}
+	group, exists := s.groups[id]
+	if !exists {
+		group = &DuplicateKeyGroup{Entity: s.entity.GetExternalID(), Attribute: s.entity.GetPrimaryKey().GetName(),
+			Pattern: id[1], Cause: cause}
+		s.groups[id] = group
+	}
+	group.Rows++
+	if len(group.Examples) < maxDuplicateExamples && !slices.Contains(group.Examples, value) {
+		group.Examples = append(group.Examples, value)
+	}
+}
+
+// sortedGroups returns the groups by cause, then the most rows first
+func (s *duplicateScan) sortedGroups() []DuplicateKeyGroup {
+	rank := make(map[string]int, len(duplicateCauseOrder))
+	for i, cause := range duplicateCauseOrder {
+		rank[cause] = i
+	}
+	groups := make([]DuplicateKeyGroup, 0, len(s.groups))
+	for _, group := range s.groups {
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Cause != groups[j].Cause {
+			return rank[groups[i].Cause] < rank[groups[j].Cause]
+		}
+		if groups[i].Rows != groups[j].Rows {
+			return groups[i].Rows > groups[j].Rows
+		}
+		return groups[i].Pattern < groups[j].Pattern
+	})
+	return groups
+}
@@ -0,0 +1,76 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeDuplicateKeys(t *testing.T) {
+	def := userRoleDefinition("")
+	user := def.Entities["user"]
+	user.PartitionBy = "roleId"
+	def.Entities["user"] = user
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "User"), 0750))
+	files := map[string]string{
+		"Role.csv":                "id\nrole-1\nrole-2\n",
+		"User/roleId=role-1.csv":  "id,roleId\nuser-1,role-1\nuser-2,role-1\nuser-2,role-1\nuser-3,role-1\nuser-3,role-9\nuser-4,role-1\n",
+		"User/roleId=role-2.csv":  "id,roleId\nuser-1,role-2\nUSER-4,role-2\n",
+		"User/roleId=retired.csv": "id,roleId\nuser-5,retired\nuser-2,retired\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(content), 0600))
+	}
+	// The retired partition was left by an earlier run
+	require.NoError(t, WriteManifest(filepath.Join(dir, ManifestFile), &Manifest{
+		Format: OutputFormatCSV,
+		Files: []ManifestEntry{
+			{Entity: "Role", File: "Role.csv", Rows: 2},
+			{Entity: "User", File: "User", Rows: 8, Partitions: []ManifestPartition{
+				{Value: "role-1", File: "User/roleId=role-1.csv", Rows: 6},
+				{Value: "role-2", File: "User/roleId=role-2.csv", Rows: 2},
+			}},
+		},
+	}))
+
+	dedupeDir := filepath.Join(t.TempDir(), "deduped")
+	report, err := AnalyzeDuplicateKeys(def, dir, dedupeDir, FileLayout{}, nil)
+	require.NoError(t, err)
+
+	group := func(cause, pattern string, examples ...string) DuplicateKeyGroup {
+		return DuplicateKeyGroup{Entity: "User", Attribute: "id", Pattern: pattern, Cause: cause, Rows: 1, Examples: examples}
+	}
+	assert.Equal(t, []DuplicateKeyGroup{
+		group(DuplicateCauseStaleFile, "user-#", "user-2"),
+		group(DuplicateCauseOverlap, "user-#", "user-1"),
+		group(DuplicateCauseRepeated, "user-#", "user-2"),
+		group(DuplicateCauseConflict, "user-#", "user-3"),
+		group(DuplicateCauseCase, "USER-#", "USER-4"),
+	}, report.Groups)
+	assert.Equal(t, "User.id: 1 duplicate keys like 'user-#' (user-2)", report.Groups[0].String())
+	assert.Contains(t, report.Groups[0].Suggestion(), "--clean")
+
+	// The copy keeps the first row with each key, reading the listed files first
+	assert.Equal(t, 4, report.Dropped)
+	deduped := map[string]string{
+		"Role.csv":                "id\nrole-1\nrole-2\n",
+		"User/roleId=role-1.csv":  "id,roleId\nuser-1,role-1\nuser-2,role-1\nuser-3,role-1\nuser-4,role-1\n",
+		"User/roleId=role-2.csv":  "id,roleId\nUSER-4,role-2\n",
+		"User/roleId=retired.csv": "id,roleId\nuser-5,retired\n",
+	}
+	for name, want := range deduped {
+		content, err := os.ReadFile(filepath.Join(dedupeDir, filepath.FromSlash(name)))
+		require.NoError(t, err)
+		assert.Equal(t, want, string(content), name)
+	}
+
+	t.Run("should group keys by pattern", func(t *testing.T) {
+		assert.Equal(t, "acct-#-#", keyPattern("acct-12-0042"))
+		assert.Equal(t, "<uuid>", keyPattern("0B6F9E2C-6C1A-4F8E-9B1D-2A3C4D5E6F70"))
+	})
+}
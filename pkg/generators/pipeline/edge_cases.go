@@ -0,0 +1,134 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// EdgeCasesFile is the name of the edge case placement report in the output directory
+const EdgeCasesFile = "edge_cases.json"
+
+// edgeCaseMaxLength is the length of the longest string edge case, a common
+// column limit
+const edgeCaseMaxLength = 255
+
+// EdgeCasePlacement records a boundary value written to an entity row
+type EdgeCasePlacement struct {
+	Entity    string `json:"entity"`    // Entity external ID
+	Row       int    `json:"row"`       // Data row number, starting at 1
+	Key       string `json:"key"`       // Primary key of the row
+	Attribute string `json:"attribute"` // Attribute name
+	Type      string `json:"type"`      // Attribute type
+	Case      string `json:"case"`      // Edge case name, e.g. "maxLength"
+}
+
+// edgeCase is a named boundary value
+type edgeCase struct {
+	name  string
+	value string
+}
+
+// edgeCasesByType lists the boundary values for each attribute type. Case i of
+// every type goes to row i, so the first rows hold the minimums, the next the
+// maximums and so on. Booleans have no boundaries and are left alone.
+var edgeCasesByType = map[string][]edgeCase{
+	"String": {
+		{"empty", ""},
+		{"maxLength", strings.Repeat("x", edgeCaseMaxLength)},
+		{"unicode", "Zoë Ångström 日本語 עברית 🚀 é"},
+	},
+	"Integer": {
+		{"min", strconv.Itoa(math.MinInt32)},
+		{"max", strconv.Itoa(math.MaxInt32)},
+		{"zero", "0"},
+	},
+	"Int64": {
+		{"min", strconv.FormatInt(math.MinInt64, 10)},
+		{"max", strconv.FormatInt(math.MaxInt64, 10)},
+		{"zero", "0"},
+	},
+	"Float": {
+		{"min", strconv.FormatFloat(-math.MaxFloat32, 'g', -1, 32)},
+		{"max", strconv.FormatFloat(math.MaxFloat32, 'g', -1, 32)},
+		{"smallestPositive", strconv.FormatFloat(math.SmallestNonzeroFloat32, 'g', -1, 32)},
+	},
+	"Double": {
+		{"min", strconv.FormatFloat(-math.MaxFloat64, 'g', -1, 64)},
+		{"max", strconv.FormatFloat(math.MaxFloat64, 'g', -1, 64)},
+		{"smallestPositive", strconv.FormatFloat(math.SmallestNonzeroFloat64, 'g', -1, 64)},
+	},
+	"Date": {
+		{"epoch", "1970-01-01"},
+		{"farFuture", "9999-12-31"},
+	},
+	"DateTime": {
+		{"epoch", "1970-01-01T00:00:00Z"},
+		{"farFuture", "9999-12-31T23:59:59Z"},
+	},
+}
+
+// injectEdgeCases overwrites generated field values with boundary values for their
+// type, placing case i in row i of each entity. Keys, foreign keys, constants, lists,
+// values other relationships reference and values supplied by partial input are kept;
+// entities with fewer rows than cases get the cases that fit. Returns where each value
+// went.
+func injectEdgeCases(graph *model.Graph) []EdgeCasePlacement {
+	// Relationships may point at non-unique attributes; changing those would break them
+	referenced := make(map[columnRef]bool)
+	for _, relationship := range graph.GetAllRelationships() {
+		referenced[columnRef{relationship.GetTargetEntity().GetExternalID(), relationship.GetTargetAttribute().GetName()}] = true
+	}
+
+	entities := graph.GetEntitiesList()
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].GetExternalID() < entities[j].GetExternalID()
+	})
+
+	placements := []EdgeCasePlacement{}
+	for _, entity := range entities {
+		pkName := entity.GetPrimaryKey().GetName()
+		for _, attr := range entity.GetNonRelationshipAttributes() {
+			if attr.IsUnique() || attr.GetConst() != nil || attr.GetListEncoding() != "" || referenced[columnRef{entity.GetExternalID(), attr.GetName()}] {
+				continue
+			}
+			for i, edge := range edgeCasesByType[attr.GetDataType()] {
+				if i >= entity.GetRowCount() {
+					break
+				}
+				row := entity.GetRowByIndex(i)
+				if row.IsPinned(attr.GetName()) {
+					continue
+				}
+				row.SetValue(attr.GetName(), edge.value)
+				placements = append(placements, EdgeCasePlacement{
+					Entity:    entity.GetExternalID(),
+					Row:       i + 1,
+					Key:       row.GetValue(pkName),
+					Attribute: attr.GetName(),
+					Type:      attr.GetDataType(),
+					Case:      edge.name,
+				})
+			}
+		}
+	}
+	return placements
+}
+
+// WriteEdgeCases writes the edge case placements as indented JSON, encrypted when
+// files are
+func WriteEdgeCases(path string, placements []EdgeCasePlacement, files *OutputFiles) error {
+	content, err := json.MarshalIndent(placements, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode edge cases: %w", err)
+	}
+	if err := files.write(path, append(content, '\n')); err != nil {
+		return fmt.Errorf("failed to write edge cases: %w", err)
+	}
+	return nil
+}
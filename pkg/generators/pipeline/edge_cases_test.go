@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjectEdgeCases(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Edge SOR",
+		Description: "SOR used for edge case tests",
+		Entities: map[string]parser.Entity{
+			"account": {
+				DisplayName: "Account",
+				ExternalId:  "Account",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "login", ExternalId: "login", Type: "String"},
+					{Name: "quota", ExternalId: "quota", Type: "Int64"},
+					{Name: "expires", ExternalId: "expires", Type: "Date"},
+					{Name: "enabled", ExternalId: "enabled", Type: "Boolean"},
+					{Name: "ownerId", ExternalId: "ownerId", Type: "String"},
+				},
+			},
+			"owner": {
+				DisplayName: "Owner",
+				ExternalId:  "Owner",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"account_owner": {Name: "account_owner", FromAttribute: "Account.ownerId", ToAttribute: "Owner.id"},
+		},
+	}
+
+	graphInterface, err := model.NewGraph(def, 10)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"Account": 2, "Owner": 3}))
+	require.NoError(t, NewRelationshipLinker().LinkRelationships(graph, false))
+	require.NoError(t, NewFieldGenerator().GenerateFields(graph))
+
+	account, _ := graph.GetEntity("Account")
+	account.GetRowByIndex(0).SetPinnedValue("login", "provided")
+	owners := []string{account.GetRowByIndex(0).GetValue("ownerId"), account.GetRowByIndex(1).GetValue("ownerId")}
+
+	placements := injectEdgeCases(graph)
+
+	first, second := account.GetRowByIndex(0), account.GetRowByIndex(1)
+	assert.Equal(t, "provided", first.GetValue("login"), "values from partial input are kept")
+	assert.Equal(t, strings.Repeat("x", edgeCaseMaxLength), second.GetValue("login"))
+	assert.Equal(t, "-9223372036854775808", first.GetValue("quota"))
+	assert.Equal(t, "9223372036854775807", second.GetValue("quota"))
+	assert.Equal(t, "1970-01-01", first.GetValue("expires"))
+	assert.Equal(t, "9999-12-31", second.GetValue("expires"))
+	assert.Contains(t, []string{"true", "false"}, first.GetValue("enabled"), "booleans have no edge cases")
+	assert.Equal(t, owners, []string{first.GetValue("ownerId"), second.GetValue("ownerId")}, "foreign keys are kept")
+
+	// Two rows only fit the first two cases of each type
+	var cases []string
+	for _, placement := range placements {
+		require.Equal(t, "Account", placement.Entity)
+		cases = append(cases, placement.Attribute+":"+placement.Case)
+	}
+	assert.Equal(t, []string{"login:maxLength", "quota:min", "quota:max", "expires:epoch", "expires:farFuture"}, cases)
+	assert.Equal(t, second.GetValue("id"), placements[0].Key)
+	assert.Equal(t, 2, placements[0].Row)
+}
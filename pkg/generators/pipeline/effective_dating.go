@@ -0,0 +1,152 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// dateVersions gives the rows of effective-dated entities their validity windows,
+// once keys and lookups are set. Rows sharing a key value are one record's
+// versions, dated in row order; a row without a key is a record of its own. Records
+// with a supplied window value are left as they are.
+func dateVersions(graph *model.Graph, streams *RandomStreams) error {
+	for _, entity := range graph.GetEntitiesList() {
+		dating := entity.GetEffectiveDating()
+		if dating == nil {
+			continue
+		}
+		faker := streams.stream("effective_dating:" + entity.GetExternalID())
+		if err := dateEntityVersions(entity, dating, faker); err != nil {
+			return fmt.Errorf("entity %s: %w", entity.GetExternalID(), err)
+		}
+	}
+	return nil
+}
+
+// dateEntityVersions dates the versions of each of the entity's records, drawing
+// their windows from faker
+func dateEntityVersions(entity model.EntityInterface, dating *parser.EffectiveDating, faker *gofakeit.Faker) error {
+	from, err := parser.ParseTimelineTime(dating.From)
+	if err != nil {
+		return fmt.Errorf("effectiveDating from: %w", err)
+	}
+	to, err := parser.ParseTimelineTime(dating.To)
+	if err != nil {
+		return fmt.Errorf("effectiveDating to: %w", err)
+	}
+
+	// Windows are whole days for Date attributes and whole seconds otherwise, so
+	// versions never start at the same formatted time
+	unit, layouts := time.Second, make(map[string]string)
+	for _, name := range []string{dating.ValidFrom, dating.ValidTo} {
+		attr, exists := entity.GetAttribute(name)
+		if !exists {
+			return fmt.Errorf("effectiveDating references unknown attribute '%s'", name)
+		}
+		layouts[name] = time.RFC3339
+		if attr.GetDataType() == "Date" {
+			layouts[name], unit = "2006-01-02", 24*time.Hour
+		}
+	}
+	from = from.Truncate(unit)
+	units := int64(to.Sub(from) / unit)
+
+	// Group the rows into records in the order their keys first appear
+	var records [][]*model.Row
+	byKey := make(map[string]int)
+	for i := 0; i < entity.GetRowCount(); i++ {
+		row := entity.GetRowByIndex(i)
+		key := row.GetValue(dating.Key)
+		if index, seen := byKey[key]; seen && key != "" {
+			records[index] = append(records[index], row)
+			continue
+		}
+		byKey[key] = len(records)
+		records = append(records, []*model.Row{row})
+	}
+
+	maxOverlap := max(dating.MaxOverlap, 1)
+	for _, versions := range records {
+		if supplied(versions, dating) {
+			continue
+		}
+		if int64(len(versions)) > units {
+			return fmt.Errorf("%s '%s' has %d versions, more than the %d %s between from %s and to %s",
+				dating.Key, versions[0].GetValue(dating.Key), len(versions), units, unitName(unit), dating.From, dating.To)
+		}
+
+		starts := distinctOffsets(len(versions), units, faker)
+		for i, row := range versions {
+			row.SetValue(dating.ValidFrom, from.Add(time.Duration(starts[i])*unit).Format(layouts[dating.ValidFrom]))
+			latest := i == len(versions)-1
+			if dating.Current != "" {
+				row.SetValue(dating.Current, strconv.FormatBool(latest))
+			}
+			if latest {
+				row.SetValue(dating.ValidTo, "")
+				continue
+			}
+
+			// A version ends once the next one starts, or with overlaps, before the
+			// version maxOverlap after it starts, so no more than maxOverlap are valid
+			// at any moment
+			low, high := starts[i+1], units
+			if i+maxOverlap < len(versions) {
+				high = starts[i+maxOverlap]
+			}
+			end := low + int64(faker.Uint64()%uint64(high-low+1))
+			row.SetValue(dating.ValidTo, from.Add(time.Duration(end)*unit).Format(layouts[dating.ValidTo]))
+		}
+	}
+	return nil
+}
+
+// supplied reports whether any version of a record has a window value supplied
+// externally, such as by inline data or fixtures
+func supplied(versions []*model.Row, dating *parser.EffectiveDating) bool {
+	for _, row := range versions {
+		if row.IsPinned(dating.ValidFrom) || row.IsPinned(dating.ValidTo) {
+			return true
+		}
+	}
+	return false
+}
+
+// distinctOffsets draws count distinct offsets in [0, units) from faker, in
+// ascending order
+func distinctOffsets(count int, units int64, faker *gofakeit.Faker) []int64 {
+	offsets := make([]int64, 0, count)
+	if int64(count)*2 > units {
+		// Most of the range is taken: pick from a shuffle of all of it
+		all := make([]int64, units)
+		for i := range all {
+			all[i] = int64(i)
+		}
+		faker.ShuffleAnySlice(all)
+		offsets = append(offsets, all[:count]...)
+	} else {
+		taken := make(map[int64]bool, count)
+		for len(offsets) < count {
+			if offset := int64(faker.Uint64() % uint64(units)); !taken[offset] {
+				taken[offset] = true
+				offsets = append(offsets, offset)
+			}
+		}
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	return offsets
+}
+
+// unitName names the unit windows are measured in, for errors
+func unitName(unit time.Duration) string {
+	if unit == time.Second {
+		return "seconds"
+	}
+	return "days"
+}
@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// effectiveDatedJobs returns a SOR whose Job rows are versions of each employee's job
+func effectiveDatedJobs(dating parser.EffectiveDating, dateType string) *parser.SORDefinition {
+	return &parser.SORDefinition{
+		DisplayName: "Effective Dating",
+		Entities: map[string]parser.Entity{
+			"employee": {
+				DisplayName: "Employee", ExternalId: "Employee",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				},
+			},
+			"job": {
+				DisplayName: "Job", ExternalId: "Job",
+				EffectiveDating: &dating,
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "employeeId", ExternalId: "employeeId", Type: "String"},
+					{Name: "validFrom", ExternalId: "validFrom", Type: dateType},
+					{Name: "validTo", ExternalId: "validTo", Type: dateType},
+					{Name: "isCurrent", ExternalId: "isCurrent", Type: "Boolean"},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"job_employee": {DisplayName: "Job Employee", Name: "job_employee", FromAttribute: "Job.employeeId", ToAttribute: "Employee.id"},
+		},
+	}
+}
+
+// jobVersions generates the SOR and returns each employee's job rows ordered by start
+func jobVersions(t *testing.T, def *parser.SORDefinition) map[string][]*model.Row {
+	graphInterface, err := model.NewGraph(def, 60)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	generator := NewDataGenerator(t.TempDir(), map[string]int{"Employee": 10, "Job": 60}, true)
+	require.NoError(t, generator.Generate(graph))
+
+	job, _ := graph.GetEntity("Job")
+	versions := make(map[string][]*model.Row)
+	for i := 0; i < job.GetRowCount(); i++ {
+		row := job.GetRowByIndex(i)
+		versions[row.GetValue("employeeId")] = append(versions[row.GetValue("employeeId")], row)
+	}
+	for _, rows := range versions {
+		sort.SliceStable(rows, func(i, j int) bool { return rows[i].GetValue("validFrom") < rows[j].GetValue("validFrom") })
+	}
+	return versions
+}
+
+func TestDateVersions(t *testing.T) {
+	t.Run("should give versions consecutive windows without overlaps", func(t *testing.T) {
+		dating := parser.EffectiveDating{Key: "employeeId", ValidFrom: "validFrom", ValidTo: "validTo", Current: "isCurrent",
+			From: "2020-01-01", To: "2024-12-31"}
+		versions := jobVersions(t, effectiveDatedJobs(dating, "Date"))
+		require.NotEmpty(t, versions)
+
+		for employee, rows := range versions {
+			for i, row := range rows {
+				assert.GreaterOrEqual(t, row.GetValue("validFrom"), "2020-01-01")
+				assert.Less(t, row.GetValue("validFrom"), "2024-12-31")
+				if i == len(rows)-1 {
+					assert.Empty(t, row.GetValue("validTo"), "%s's latest version is open-ended", employee)
+					assert.Equal(t, "true", row.GetValue("isCurrent"))
+					continue
+				}
+				assert.Equal(t, rows[i+1].GetValue("validFrom"), row.GetValue("validTo"), "%s's version ends as the next starts", employee)
+				assert.Equal(t, "false", row.GetValue("isCurrent"))
+			}
+		}
+	})
+
+	t.Run("should keep overlapping versions within maxOverlap", func(t *testing.T) {
+		dating := parser.EffectiveDating{Key: "employeeId", ValidFrom: "validFrom", ValidTo: "validTo",
+			From: "2020-01-01", To: "2024-12-31T00:00:00Z", MaxOverlap: 2}
+		versions := jobVersions(t, effectiveDatedJobs(dating, "DateTime"))
+
+		for employee, rows := range versions {
+			for _, at := range rows {
+				moment, valid := at.GetValue("validFrom"), 0
+				for _, row := range rows {
+					end := row.GetValue("validTo")
+					if row.GetValue("validFrom") <= moment && (end == "" || moment < end) {
+						valid++
+					}
+				}
+				assert.LessOrEqual(t, valid, 2, "%s has at most 2 versions valid at %s", employee, moment)
+			}
+		}
+	})
+
+	t.Run("should fail when a record has more versions than days", func(t *testing.T) {
+		def := effectiveDatedJobs(parser.EffectiveDating{Key: "employeeId", ValidFrom: "validFrom", ValidTo: "validTo",
+			From: "2024-01-01", To: "2024-01-04"}, "Date")
+		graphInterface, err := model.NewGraph(def, 10)
+		require.NoError(t, err)
+
+		generator := NewDataGenerator(t.TempDir(), map[string]int{"Employee": 1, "Job": 10}, false)
+		err = generator.Generate(graphInterface.(*model.Graph))
+		assert.ErrorContains(t, err, "has 10 versions, more than the 3 days between from 2024-01-01 and to 2024-01-04")
+	})
+}
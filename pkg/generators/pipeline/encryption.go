@@ -0,0 +1,190 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/SGNL-ai/fabricator/pkg/encryption"
+)
+
+// OutputFiles creates the data files of a run, encrypting them as they are
+// written when it has an encryptor and injecting write faults when configured. It
+// records which files were written, so a failed run's manifest can tell them from
+// partial ones. Writers, the manifest and the files written next to the data share
+// one, so none of them leaves plaintext behind, and name the files with its
+// layout. A nil OutputFiles writes files in the clear with the default layout and
+// records nothing.
+type OutputFiles struct {
+	layout    FileLayout
+	encryptor *encryption.Encryptor
+	faults    *faultInjector // Nil injects no faults
+	written   fileLog
+}
+
+// NewOutputFiles returns the data files of a run writing them in the clear
+func NewOutputFiles() *OutputFiles {
+	return &OutputFiles{}
+}
+
+// SetLayout configures how data files are named and placed in the output directory
+func (f *OutputFiles) SetLayout(layout FileLayout) {
+	f.layout = layout
+}
+
+// Layout returns how data files are named and placed in the output directory
+func (f *OutputFiles) Layout() FileLayout {
+	if f == nil {
+		return FileLayout{}
+	}
+	return f.layout
+}
+
+// SetEncryptor configures the encryptor of every data file written, including
+// partitions, list files, ingestion samples, the redacted copy and the reports
+// written next to the data, and of the manifest's file names; nil disables
+// encryption
+func (f *OutputFiles) SetEncryptor(encryptor *encryption.Encryptor) {
+	f.encryptor = encryptor
+}
+
+// Encrypted reports whether data files are encrypted
+func (f *OutputFiles) Encrypted() bool {
+	return f != nil && f.encryptor != nil
+}
+
+// FileName returns the name a data file is written under: with
+// encryption.Extension appended when output is encrypted
+func (f *OutputFiles) FileName(name string) string {
+	if !f.Encrypted() {
+		return name
+	}
+	return name + encryption.Extension
+}
+
+// create creates a data file whose content is encrypted as it is written when
+// output is encrypted. Closing it writes the last encrypted chunk and closes the
+// file.
+func (f *OutputFiles) create(path string) (io.WriteCloser, error) {
+	file, err := f.open(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return f.encrypt(file)
+}
+
+// reopen opens a data file written in the clear to append to it
+func (f *OutputFiles) reopen(path string) (io.WriteCloser, error) {
+	if f.Encrypted() {
+		return nil, fmt.Errorf("encrypted file %s can't be appended to", path)
+	}
+	return f.open(path, os.O_WRONLY|os.O_APPEND, 0666)
+}
+
+// write writes content to a data file in one go, like os.WriteFile with mode 0600
+func (f *OutputFiles) write(path string, content []byte) error {
+	file, err := f.open(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	writer, err := f.encrypt(file)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(content)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// encrypt wraps a created file in an encrypting writer when output is encrypted
+func (f *OutputFiles) encrypt(file *dataFile) (io.WriteCloser, error) {
+	if !f.Encrypted() {
+		return file, nil
+	}
+	writer, err := f.encryptor.NewWriter(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &encryptedFile{WriteCloser: writer, file: file}, nil
+}
+
+// encryptedFile closes the encrypting writer, then the file beneath it
+type encryptedFile struct {
+	io.WriteCloser
+	file *dataFile
+}
+
+func (f *encryptedFile) Close() error {
+	err := f.WriteCloser.Close()
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// InputFiles opens the data files validation reads, decrypting them as they are
+// read when it has a decryptor. A nil InputFiles reads files in the clear.
+type InputFiles struct {
+	decryptor *encryption.Decryptor
+}
+
+// NewInputFiles returns the data files of a run written with the passphrase of
+// decryptor, or in the clear when decryptor is nil
+func NewInputFiles(decryptor *encryption.Decryptor) *InputFiles {
+	return &InputFiles{decryptor: decryptor}
+}
+
+// Encrypted reports whether data files are decrypted as they are read
+func (f *InputFiles) Encrypted() bool {
+	return f != nil && f.decryptor != nil
+}
+
+// FileName returns the name a data file was written under: with
+// encryption.Extension appended when output was encrypted
+func (f *InputFiles) FileName(name string) string {
+	if !f.Encrypted() {
+		return name
+	}
+	return name + encryption.Extension
+}
+
+// Open opens a data file, returning a reader of its content in the clear
+func (f *InputFiles) Open(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path) // #nosec G304 - path is built from the validated directory
+	if err != nil {
+		return nil, err
+	}
+	if !f.Encrypted() {
+		return file, nil
+	}
+	reader, err := f.decryptor.NewReader(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+	}
+	return &decryptedFile{Reader: reader, file: file}, nil
+}
+
+// lint checks a data file's CSV structure, like LintCSVFile
+func (f *InputFiles) lint(path string) ([]string, error) {
+	file, err := f.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file %s: %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	return LintCSV(path, file)
+}
+
+// decryptedFile reads the plaintext of an encrypted file, and closes the file
+type decryptedFile struct {
+	io.Reader
+	file *os.File
+}
+
+func (f *decryptedFile) Close() error {
+	return f.file.Close()
+}
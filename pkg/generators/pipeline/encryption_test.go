@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/encryption"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedOutput(t *testing.T) {
+	encryptor, err := encryption.NewEncryptor("s3cret")
+	require.NoError(t, err)
+	decryptor, err := encryption.NewDecryptor("s3cret")
+	require.NoError(t, err)
+	output := NewOutputFiles()
+	output.SetEncryptor(encryptor)
+
+	graphInterface, err := model.NewGraph(partitionedDefinition(), 20)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	dir := t.TempDir()
+	generator := NewDataGenerator(dir, map[string]int{"User": 20, "Group": 4}, false)
+	generator.SetOutputFiles(output)
+	require.NoError(t, generator.Generate(graph))
+
+	manifest := NewManifest(graph, OutputFormatCSV, output)
+	require.NotNil(t, manifest.Encryption)
+	assert.Equal(t, encryption.Scheme, manifest.Encryption.Scheme)
+
+	var files []string
+	for _, entry := range manifest.Files {
+		if entry.Entity == "User" {
+			assert.Equal(t, "User", entry.File, "the partition folder keeps its name")
+			for _, partition := range entry.Partitions {
+				files = append(files, partition.File)
+			}
+			continue
+		}
+		files = append(files, entry.File)
+	}
+	assert.Contains(t, files, "Group.csv.enc")
+	assert.NoFileExists(t, filepath.Join(dir, "Group.csv"))
+
+	rows := 0
+	for _, file := range files {
+		assert.Equal(t, encryption.Extension, filepath.Ext(file))
+		encrypted, err := os.Open(filepath.Join(dir, filepath.FromSlash(file)))
+		require.NoError(t, err)
+		reader, err := decryptor.NewReader(encrypted)
+		require.NoError(t, err)
+		records, err := csv.NewReader(reader).ReadAll()
+		_ = encrypted.Close()
+		require.NoError(t, err, file)
+		rows += len(records) - 1
+	}
+	assert.Equal(t, 24, rows, "every row of both entities decrypts")
+}
+
+func TestEncryptedReports(t *testing.T) {
+	encryptor, err := encryption.NewEncryptor("s3cret")
+	require.NoError(t, err)
+	decryptor, err := encryption.NewDecryptor("s3cret")
+	require.NoError(t, err)
+	files := NewOutputFiles()
+	files.SetEncryptor(encryptor)
+
+	path := files.FileName(filepath.Join(t.TempDir(), EdgeCasesFile))
+	assert.Equal(t, encryption.Extension, filepath.Ext(path))
+	placements := []EdgeCasePlacement{{Entity: "User", Row: 1, Key: "secret-key", Attribute: "name", Type: "String", Case: "maxLength"}}
+	require.NoError(t, WriteEdgeCases(path, placements, files))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "secret-key")
+
+	encrypted, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = encrypted.Close() }()
+	reader, err := decryptor.NewReader(encrypted)
+	require.NoError(t, err)
+	var decrypted []EdgeCasePlacement
+	require.NoError(t, json.NewDecoder(reader).Decode(&decrypted))
+	assert.Equal(t, placements, decrypted)
+}
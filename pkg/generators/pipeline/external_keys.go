@@ -0,0 +1,320 @@
+package pipeline
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// ExternalReference links a foreign key attribute to key values in another SOR's CSV output
+type ExternalReference struct {
+	Relationship string     // Relationship key in the SOR definition
+	Entity       string     // External ID of the entity holding the foreign key
+	Attribute    string     // Name of the foreign key attribute
+	Directory    string     // Directory containing the other SOR's CSV files
+	TargetEntity string     // Entity part of toAttribute, selects the CSV file
+	TargetColumn string     // Column of the CSV file holding the referenced keys
+	Sampling     string     // KeySamplingReservoir or KeySamplingStride streams the file; empty loads every key
+	SampleSize   int        // Keys a reservoir holds; 0 holds one per row to assign
+	Layout       FileLayout // How the other SOR's files are named; the zero value is the default layout
+}
+
+// Methods of drawing an external relationship's keys from its streamed key file
+const (
+	KeySamplingReservoir = "reservoir" // Random keys, each equally likely, held in a reservoir
+	KeySamplingStride    = "stride"    // Keys evenly spaced through the file, in file order
+)
+
+// Path returns the CSV file holding the referenced keys
+func (r ExternalReference) Path() string {
+	return filepath.Join(r.Directory, r.Layout.entityFileBase(r.TargetEntity)+".csv")
+}
+
+// ExternalReferences collects the relationships of a definition that target another
+// SOR's output directory, sorted by relationship key
+func ExternalReferences(def *parser.SORDefinition) ([]ExternalReference, error) {
+	var refs []ExternalReference
+
+	for relID, rel := range def.Relationships {
+		if rel.ExternalDirectory == "" {
+			continue
+		}
+
+		entityExternalID, attrName, found := resolveAttributeReference(def, rel.FromAttribute)
+		if !found {
+			return nil, fmt.Errorf("relationship %s: fromAttribute '%s' does not match any entity attribute", relID, rel.FromAttribute)
+		}
+
+		dot := strings.LastIndex(rel.ToAttribute, ".")
+		if dot <= 0 || dot == len(rel.ToAttribute)-1 {
+			return nil, fmt.Errorf("relationship %s: toAttribute '%s' must be <entity>.<column>", relID, rel.ToAttribute)
+		}
+
+		ref := ExternalReference{
+			Relationship: relID,
+			Entity:       entityExternalID,
+			Attribute:    attrName,
+			Directory:    rel.ExternalDirectory,
+			TargetEntity: rel.ToAttribute[:dot],
+			TargetColumn: rel.ToAttribute[dot+1:],
+		}
+		if rel.Sampling != nil {
+			ref.Sampling, ref.SampleSize = rel.Sampling.Method, rel.Sampling.Size
+		}
+		refs = append(refs, ref)
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Relationship < refs[j].Relationship
+	})
+	return refs, nil
+}
+
+// resolveAttributeReference finds the entity and attribute named by an attributeAlias
+// or Entity.Attribute reference
+func resolveAttributeReference(def *parser.SORDefinition, reference string) (string, string, bool) {
+	for _, entity := range def.Entities {
+		for _, attr := range entity.Attributes {
+			if attr.AttributeAlias == reference || entity.ExternalId+"."+attr.ExternalId == reference {
+				return entity.ExternalId, attr.Name, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// LoadExternalKeys reads the distinct, non-empty key values of an external reference
+// in file order
+func LoadExternalKeys(ref ExternalReference) ([]string, error) {
+	var keys []string
+	seen := make(map[string]bool)
+	err := scanExternalKeys(ref, func(key string) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	})
+	return keys, err
+}
+
+// scanExternalKeys calls fn with each non-empty key value of an external reference
+// in file order, reading the file one record at a time. Keys to keep beyond the
+// call must be cloned, as they share memory with their record.
+func scanExternalKeys(ref ExternalReference, fn func(key string)) error {
+	path := ref.Path()
+	file, err := os.Open(path) // #nosec G304 - path comes from the SOR definition
+	if err != nil {
+		return fmt.Errorf("failed to open external CSV for relationship %s: %w", ref.Relationship, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	reader := csv.NewReader(file)
+	reader.ReuseRecord = true
+	headers, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read headers from %s: %w", path, err)
+	}
+
+	column := -1
+	for i, header := range headers {
+		if strings.TrimPrefix(header, string(utf8BOM)) == ref.TargetColumn {
+			column = i
+			break
+		}
+	}
+	if column < 0 {
+		return fmt.Errorf("column '%s' not found in %s (relationship %s)", ref.TargetColumn, path, ref.Relationship)
+	}
+
+	found := false
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if column >= len(record) || record[column] == "" {
+			continue
+		}
+		found = true
+		fn(record[column])
+	}
+
+	if !found {
+		return fmt.Errorf("no key values in column '%s' of %s (relationship %s)", ref.TargetColumn, path, ref.Relationship)
+	}
+	return nil
+}
+
+// SampleExternalKeys streams the key file of an external reference and returns
+// the keys for count rows, drawn by the reference's sampling method: a shuffled
+// reservoir of random keys, repeated when it holds fewer than count, or keys at
+// evenly spaced positions in the file, read in a second pass once the keys are
+// counted. Memory grows with count, not with the size of the file. Keys repeated in
+// the file are drawn like any other. Reservoirs draw from the relationship's own
+// stream of streams, so other values don't depend on them; nil streams draw at
+// random.
+func SampleExternalKeys(ref ExternalReference, count int, streams *RandomStreams) ([]string, error) {
+	if count == 0 {
+		return nil, nil
+	}
+
+	switch ref.Sampling {
+	case KeySamplingReservoir:
+		size := ref.SampleSize
+		if size == 0 || size > count {
+			size = count
+		}
+		faker := streams.stream("external:" + ref.Relationship)
+		reservoir := make([]string, 0, size)
+		seen := 0
+		err := scanExternalKeys(ref, func(key string) {
+			seen++
+			if len(reservoir) < size {
+				reservoir = append(reservoir, strings.Clone(key))
+			} else if slot := faker.Number(0, seen-1); slot < size {
+				reservoir[slot] = strings.Clone(key)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		faker.ShuffleStrings(reservoir)
+
+		keys := make([]string, count)
+		for i := range keys {
+			keys[i] = reservoir[i%len(reservoir)]
+		}
+		return keys, nil
+
+	case KeySamplingStride:
+		total := 0
+		if err := scanExternalKeys(ref, func(string) { total++ }); err != nil {
+			return nil, err
+		}
+
+		// Row i takes the key at position i*total/count, so positions only grow
+		keys := make([]string, 0, count)
+		position := 0
+		err := scanExternalKeys(ref, func(key string) {
+			var kept string
+			for len(keys) < count && len(keys)*total/count == position {
+				if kept == "" {
+					kept = strings.Clone(key)
+				}
+				keys = append(keys, kept)
+			}
+			position++
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(keys) < count {
+			return nil, fmt.Errorf("%s changed while it was read (relationship %s)", ref.Path(), ref.Relationship)
+		}
+		return keys, nil
+	}
+	return nil, fmt.Errorf("relationship %s: unknown key sampling method '%s'", ref.Relationship, ref.Sampling)
+}
+
+// linkExternalReferences assigns foreign key values loaded from other SORs' output,
+// cycling through the external keys in file order, or drawn by the reference's
+// sampling method from the streamed file
+func linkExternalReferences(graph *model.Graph, refs []ExternalReference, streams *RandomStreams) error {
+	for _, ref := range refs {
+		var entity model.EntityInterface
+		for _, candidate := range graph.GetEntitiesList() {
+			if candidate.GetExternalID() == ref.Entity {
+				entity = candidate
+				break
+			}
+		}
+		if entity == nil {
+			return fmt.Errorf("entity %s not found for relationship %s", ref.Entity, ref.Relationship)
+		}
+
+		if ref.Sampling != "" {
+			if err := linkSampledKeys(entity, ref, streams); err != nil {
+				return err
+			}
+			continue
+		}
+
+		keys, err := LoadExternalKeys(ref)
+		if err != nil {
+			return err
+		}
+
+		err = entity.ForEachRow(func(row *model.Row, rowIndex int) error {
+			// Preserve FK values supplied by partial input data
+			if row.IsPinned(ref.Attribute) {
+				return nil
+			}
+			row.SetPinnedValue(ref.Attribute, keys[rowIndex%len(keys)])
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to link relationship %s: %w", ref.Relationship, err)
+		}
+	}
+	return nil
+}
+
+// linkSampledKeys assigns an external reference's foreign keys to the rows without
+// one supplied by partial input, sampled from the streamed key file. A foreign key
+// that is unique, or unique within a scope, makes the reference one-to-one, so
+// every row needs a key of its own: a reservoir smaller than the rows, or a file
+// with fewer distinct keys than rows, is an error rather than repeated keys.
+func linkSampledKeys(entity model.EntityInterface, ref ExternalReference, streams *RandomStreams) error {
+	count := 0
+	for i := 0; i < entity.GetRowCount(); i++ {
+		if !entity.GetRowByIndex(i).IsPinned(ref.Attribute) {
+			count++
+		}
+	}
+
+	attr, _ := entity.GetAttribute(ref.Attribute)
+	unique := attr != nil && (attr.IsUnique() || attr.GetUniqueWithin() != "")
+	if unique && ref.Sampling == KeySamplingReservoir && ref.SampleSize > 0 && ref.SampleSize < count {
+		return fmt.Errorf("relationship %s: %s.%s is unique, so its %d rows need %d distinct keys but the reservoir holds %d",
+			ref.Relationship, ref.Entity, ref.Attribute, count, count, ref.SampleSize)
+	}
+
+	keys, err := SampleExternalKeys(ref, count, streams)
+	if err != nil {
+		return err
+	}
+	if unique {
+		distinct := make(map[string]bool, len(keys))
+		for _, key := range keys {
+			distinct[key] = true
+		}
+		if len(distinct) < count {
+			return fmt.Errorf("relationship %s: %s.%s is unique, so its %d rows need %d distinct keys but %s gave %d",
+				ref.Relationship, ref.Entity, ref.Attribute, count, count, ref.Path(), len(distinct))
+		}
+	}
+
+	next := 0
+	err = entity.ForEachRow(func(row *model.Row, rowIndex int) error {
+		if row.IsPinned(ref.Attribute) {
+			return nil
+		}
+		row.SetPinnedValue(ref.Attribute, keys[next])
+		next++
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to link relationship %s: %w", ref.Relationship, err)
+	}
+	return nil
+}
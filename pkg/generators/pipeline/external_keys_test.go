@@ -0,0 +1,233 @@
+package pipeline
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// externalKeysDefinition returns a PagerDuty-style SOR whose users reference Okta user IDs
+func externalKeysDefinition(oktaDir string) *parser.SORDefinition {
+	return &parser.SORDefinition{
+		DisplayName: "PagerDuty",
+		Description: "SOR referencing another SOR's users",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User",
+				ExternalId:  "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "oktaId", ExternalId: "oktaId", Type: "String"},
+					{Name: "email", ExternalId: "email", Type: "String"},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"okta_user": {
+				DisplayName:       "Okta User",
+				Name:              "okta_user",
+				FromAttribute:     "User.oktaId",
+				ToAttribute:       "Okta/User.id",
+				ExternalDirectory: oktaDir,
+			},
+		},
+	}
+}
+
+func TestExternalReferences(t *testing.T) {
+	refs, err := ExternalReferences(externalKeysDefinition("okta-output"))
+	require.NoError(t, err)
+	require.Len(t, refs, 1)
+
+	assert.Equal(t, ExternalReference{
+		Relationship: "okta_user",
+		Entity:       "User",
+		Attribute:    "oktaId",
+		Directory:    "okta-output",
+		TargetEntity: "Okta/User",
+		TargetColumn: "id",
+	}, refs[0])
+	assert.Equal(t, filepath.Join("okta-output", "User.csv"), refs[0].Path(), "namespace prefix is dropped like generated file names")
+
+	def := externalKeysDefinition("okta-output")
+	rel := def.Relationships["okta_user"]
+	rel.FromAttribute = "User.missing"
+	def.Relationships["okta_user"] = rel
+	_, err = ExternalReferences(def)
+	assert.ErrorContains(t, err, "does not match any entity attribute")
+}
+
+func TestLoadExternalKeys(t *testing.T) {
+	dir := t.TempDir()
+	ref := ExternalReference{Relationship: "okta_user", Directory: dir, TargetEntity: "User", TargetColumn: "id"}
+
+	t.Run("distinct keys in file order", func(t *testing.T) {
+		writeCSV(t, filepath.Join(dir, "User.csv"), [][]string{
+			{string(utf8BOM) + "id", "login"},
+			{"okta-2", "b"},
+			{"okta-1", "a"},
+			{"", "c"},
+			{"okta-2", "d"},
+		})
+
+		keys, err := LoadExternalKeys(ref)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"okta-2", "okta-1"}, keys)
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		writeCSV(t, filepath.Join(dir, "User.csv"), [][]string{{"login"}, {"a"}})
+
+		_, err := LoadExternalKeys(ref)
+		assert.ErrorContains(t, err, "column 'id' not found")
+	})
+
+	t.Run("no keys", func(t *testing.T) {
+		writeCSV(t, filepath.Join(dir, "User.csv"), [][]string{{"id"}})
+
+		_, err := LoadExternalKeys(ref)
+		assert.ErrorContains(t, err, "no key values")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadExternalKeys(ExternalReference{Relationship: "okta_user", Directory: t.TempDir(), TargetEntity: "User", TargetColumn: "id"})
+		assert.ErrorContains(t, err, "relationship okta_user")
+	})
+}
+
+func TestDataGenerator_ExternalReferences(t *testing.T) {
+	oktaDir := t.TempDir()
+	outputDir := t.TempDir()
+
+	writeCSV(t, filepath.Join(oktaDir, "User.csv"), [][]string{
+		{"id", "login"},
+		{"okta-1", "alice"},
+		{"okta-2", "bob"},
+		{"okta-3", "carol"},
+	})
+
+	def := externalKeysDefinition(oktaDir)
+	graphInterface, err := model.NewGraph(def, 10)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+
+	refs, err := ExternalReferences(def)
+	require.NoError(t, err)
+
+	generator := NewDataGenerator(outputDir, map[string]int{"User": 7}, false)
+	generator.SetExternalReferences(refs)
+	require.NoError(t, generator.Generate(graph))
+
+	users := readCSV(t, filepath.Join(outputDir, "User.csv"))
+	require.Len(t, users, 8)
+	assert.Equal(t, []string{"id", "oktaId", "email"}, users[0])
+
+	seen := make(map[string]bool)
+	for _, row := range users[1:] {
+		assert.Contains(t, []string{"okta-1", "okta-2", "okta-3"}, row[1], "foreign keys come from the external CSV")
+		seen[row[1]] = true
+		assert.NotEmpty(t, row[2])
+	}
+	assert.Len(t, seen, 3, "all external keys are used")
+}
+
+func TestSampleExternalKeys(t *testing.T) {
+	dir := t.TempDir()
+	rows := [][]string{{"id"}}
+	for i := 0; i < 10; i++ {
+		rows = append(rows, []string{fmt.Sprintf("okta-%d", i)})
+	}
+	writeCSV(t, filepath.Join(dir, "User.csv"), rows)
+	ref := ExternalReference{Relationship: "okta_user", Directory: dir, TargetEntity: "User", TargetColumn: "id"}
+
+	t.Run("stride takes evenly spaced keys in file order", func(t *testing.T) {
+		ref := ref
+		ref.Sampling = KeySamplingStride
+
+		keys, err := SampleExternalKeys(ref, 4, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"okta-0", "okta-2", "okta-5", "okta-7"}, keys)
+
+		keys, err = SampleExternalKeys(ref, 15, nil)
+		require.NoError(t, err)
+		assert.Len(t, keys, 15)
+		assert.Equal(t, []string{"okta-0", "okta-0", "okta-1"}, keys[:3], "keys repeat when rows outnumber them")
+		assert.Equal(t, "okta-9", keys[14])
+	})
+
+	t.Run("reservoir draws distinct keys up to its size", func(t *testing.T) {
+		ref := ref
+		ref.Sampling = KeySamplingReservoir
+
+		keys, err := SampleExternalKeys(ref, 6, nil)
+		require.NoError(t, err)
+		require.Len(t, keys, 6)
+		distinct := make(map[string]bool)
+		for _, key := range keys {
+			assert.Contains(t, rows[1:], []string{key})
+			distinct[key] = true
+		}
+		assert.Len(t, distinct, 6, "a reservoir as large as the rows gives each a different key")
+
+		ref.SampleSize = 2
+		keys, err = SampleExternalKeys(ref, 6, nil)
+		require.NoError(t, err)
+		distinct = make(map[string]bool)
+		for _, key := range keys {
+			distinct[key] = true
+		}
+		assert.Len(t, distinct, 2)
+	})
+
+	t.Run("generation assigns sampled keys", func(t *testing.T) {
+		def := externalKeysDefinition(dir)
+		relationship := def.Relationships["okta_user"]
+		relationship.Sampling = &parser.KeySampling{Method: KeySamplingStride}
+		def.Relationships["okta_user"] = relationship
+		refs, err := ExternalReferences(def)
+		require.NoError(t, err)
+		require.Equal(t, KeySamplingStride, refs[0].Sampling)
+
+		graphInterface, err := model.NewGraph(def, 5)
+		require.NoError(t, err)
+		outputDir := t.TempDir()
+		generator := NewDataGenerator(outputDir, map[string]int{"User": 5}, false)
+		generator.SetExternalReferences(refs)
+		require.NoError(t, generator.Generate(graphInterface.(*model.Graph)))
+
+		users := readCSV(t, filepath.Join(outputDir, "User.csv"))
+		var oktaIDs []string
+		for _, row := range users[1:] {
+			oktaIDs = append(oktaIDs, row[1])
+		}
+		assert.Equal(t, []string{"okta-0", "okta-2", "okta-4", "okta-6", "okta-8"}, oktaIDs)
+	})
+
+	t.Run("unique foreign keys need a key per row", func(t *testing.T) {
+		def := externalKeysDefinition(dir)
+		def.Entities["user"].Attributes[1].UniqueWithin = "email"
+		generate := func(sampling *parser.KeySampling, rows int) error {
+			relationship := def.Relationships["okta_user"]
+			relationship.Sampling = sampling
+			def.Relationships["okta_user"] = relationship
+			refs, err := ExternalReferences(def)
+			require.NoError(t, err)
+			graphInterface, err := model.NewGraph(def, rows)
+			require.NoError(t, err)
+			generator := NewDataGenerator(t.TempDir(), map[string]int{"User": rows}, false)
+			generator.SetExternalReferences(refs)
+			return generator.Generate(graphInterface.(*model.Graph))
+		}
+
+		assert.NoError(t, generate(&parser.KeySampling{Method: KeySamplingReservoir}, 5))
+		assert.ErrorContains(t, generate(&parser.KeySampling{Method: KeySamplingReservoir, Size: 3}, 5),
+			"User.oktaId is unique, so its 5 rows need 5 distinct keys but the reservoir holds 3")
+		assert.ErrorContains(t, generate(&parser.KeySampling{Method: KeySamplingStride}, 15),
+			"need 15 distinct keys")
+	})
+}
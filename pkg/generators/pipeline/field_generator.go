@@ -3,15 +3,33 @@ package pipeline
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/SGNL-ai/fabricator/pkg/console"
 	"github.com/SGNL-ai/fabricator/pkg/generators/model"
 	"github.com/brianvoe/gofakeit/v6"
+	"github.com/fatih/color"
 )
 
 // FieldGenerator handles generation of non-ID and non-relationship fields
 type FieldGenerator struct {
-	// Configuration options can be added here
+	clearlyFakePII bool        // Use obviously fake formats for PII values
+	population     *Population // Optional people whose details fill person-like entities
+	audit          *AuditLog   // Optional record of entity sub-seeds and timeline clusters
+	theme          *Theme      // Optional vocabulary naming departments, groups, projects and applications
+
+	// Vocabulary of each attribute under the theme, "" when it has none
+	themed map[model.AttributeInterface]string
+
+	// Fail when a scoped-unique attribute runs out of values instead of widening them
+	strictUniqueness bool
+
+	// How closely the parts of address-like entities' addresses agree; empty is strict
+	addressCoherence string
+
+	streams *RandomStreams  // Streams each entity's values are drawn from; nil draws them at random
+	faker   *gofakeit.Faker // Stream of the entity whose values are being generated
 }
 
 // NewFieldGenerator creates a new field generator
@@ -19,6 +37,50 @@ func NewFieldGenerator() FieldGeneratorInterface {
 	return &FieldGenerator{}
 }
 
+// SetClearlyFakePII forces PII generators and inferred email/phone values into formats
+// that cannot be mistaken for real data
+func (g *FieldGenerator) SetClearlyFakePII(enabled bool) {
+	g.clearlyFakePII = enabled
+}
+
+// SetStrictUniqueness makes field generation fail when more rows share a scope than
+// a scoped-unique attribute has distinct values, instead of appending numeric
+// suffixes to the values that would repeat
+func (g *FieldGenerator) SetStrictUniqueness(enabled bool) {
+	g.strictUniqueness = enabled
+}
+
+// SetAddressCoherence sets how closely the street, city, state, postal code and
+// country attributes of address-like entities agree: AddressCoherenceStrict,
+// AddressCoherenceLoose or AddressCoherenceOff
+func (g *FieldGenerator) SetAddressCoherence(mode string) {
+	g.addressCoherence = mode
+}
+
+// SetPopulation fills the name, email, employee ID and username attributes of
+// person-like entities from the population instead of generating them per row
+func (g *FieldGenerator) SetPopulation(population *Population) {
+	g.population = population
+}
+
+// SetTheme names departments, groups, projects and applications from the theme's
+// vocabularies instead of person names or random words
+func (g *FieldGenerator) SetTheme(theme *Theme) {
+	g.theme = theme
+	g.themed = make(map[model.AttributeInterface]string)
+}
+
+// SetRandomStreams draws each entity's values from its own stream of streams
+func (g *FieldGenerator) SetRandomStreams(streams *RandomStreams) {
+	g.streams = streams
+}
+
+// SetAuditLog records the sub-seed of each entity's field values, and the rows of
+// each timeline cluster, in audit
+func (g *FieldGenerator) SetAuditLog(audit *AuditLog) {
+	g.audit = audit
+}
+
 // GenerateFields generates values for all non-ID and non-relationship fields
 func (g *FieldGenerator) GenerateFields(graph *model.Graph) error {
 	if graph == nil {
@@ -26,17 +88,25 @@ func (g *FieldGenerator) GenerateFields(graph *model.Graph) error {
 	}
 
 	// Process each entity
-	for _, entity := range graph.GetAllEntities() {
+	for _, entity := range graph.GetEntitiesList() {
+		// Each entity's values are drawn from its own stream, so they don't depend on how
+		// many values other entities drew, or on which other entities there are
+		stream := "fields:" + entity.GetExternalID()
+		g.faker = g.streams.stream(stream)
+		g.audit.Record(AuditRecord{Decision: AuditEntitySeed, Entity: entity.GetExternalID(), Seed: g.streams.Seed(stream)})
+
 		// Show progress for current entity (will be cleared)
-		fmt.Printf("\r%-80s\r→ Generating fields for %s...", "", entity.GetName())
+		console.Progress("→ Generating fields for %s...", entity.GetName())
 
 		// Get non-ID, non-relationship attributes that need values
 		fieldsToGenerate := entity.GetNonRelationshipAttributes()
 
-		// Filter out unique attributes (already handled by ID generator)
+		// Filter out unique attributes (already handled by ID generator), lookups and
+		// network values depending on other values, set once every entity's fields are
+		// generated
 		var regularFields []model.AttributeInterface
 		for _, attr := range fieldsToGenerate {
-			if !attr.IsUnique() {
+			if !attr.IsUnique() && attr.GetLookup() == nil && !deferredGenerator(attr) {
 				regularFields = append(regularFields, attr)
 			}
 		}
@@ -46,23 +116,163 @@ func (g *FieldGenerator) GenerateFields(graph *model.Graph) error {
 			continue
 		}
 
+		// Correlated numeric attributes are sampled jointly per row
+		sampler, err := newCorrelatedSampler(entity, g.faker)
+		if err != nil {
+			return fmt.Errorf("failed to generate fields for entity %s: %w", entity.GetExternalID(), err)
+		}
+
+		// Creation timestamps follow the entity's timeline, if it declares one
+		timeline, err := newTimelineSampler(entity, g.faker)
+		if err != nil {
+			return fmt.Errorf("failed to generate fields for entity %s: %w", entity.GetExternalID(), err)
+		}
+		timeline.audit(g.audit, entity.GetExternalID())
+
+		// Profiled columns follow the statistics of the real table, if one is declared
+		profile, err := newProfileSampler(entity, func(attr model.AttributeInterface) string {
+			if attr.GetListEncoding() != "" {
+				return g.generateListValue(attr)
+			}
+			return g.generateFieldValue(attr)
+		}, g.faker)
+		if err != nil {
+			return fmt.Errorf("failed to generate fields for entity %s: %w", entity.GetExternalID(), err)
+		}
+
+		// Person-like entities take their people from the population, if one is set
+		var people map[string]string
+		if g.population != nil {
+			people = personFields(regularFields)
+		}
+
+		// Address-like entities draw each row's address parts together
+		var addresses map[string]string
+		if g.addressCoherence != AddressCoherenceOff {
+			addresses = addressFields(regularFields)
+		}
+
+		// Values supplied by partial input count towards scoped uniqueness up front
+		scoped := newScopedIndexes(regularFields)
+		for _, index := range scoped {
+			index.domain = g.valueDomainSize(index.attr)
+			if g.strictUniqueness {
+				if err := index.checkQuota(entity); err != nil {
+					return fmt.Errorf("failed to generate fields for entity %s: %w", entity.GetExternalID(), err)
+				}
+			}
+			for i := 0; i < entity.GetRowCount(); i++ {
+				row := entity.GetRowByIndex(i)
+				if row.IsPinned(index.attr.GetName()) {
+					index.claim(row, row.GetValue(index.attr.GetName()))
+				}
+			}
+		}
+
 		// Use iterator to set field values in entity rows
-		err := entity.ForEachRow(func(row *model.Row, index int) error {
+		err = entity.ForEachRow(func(row *model.Row, index int) error {
+			correlated := sampler.sample()
+			var address Address
+			if addresses != nil {
+				address = newAddress(g.addressCoherence, g.faker)
+			}
 			for _, attr := range regularFields {
+				// Preserve values supplied by partial input data
+				if row.IsPinned(attr.GetName()) {
+					continue
+				}
+				if constant := attr.GetConst(); constant != nil {
+					row.SetValue(attr.GetName(), *constant)
+					continue
+				}
+				if sequence := sequenceGenerator(attr); sequence != nil {
+					row.SetValue(attr.GetName(), sequenceValue(sequence, index))
+					continue
+				}
+				if hierarchy := hierarchicalCodeGenerator(attr); hierarchy != nil {
+					row.SetValue(attr.GetName(), hierarchicalCodeValue(hierarchy, index))
+					continue
+				}
+				if cidr := cidrGenerator(attr); cidr != nil {
+					value, err := cidrValue(cidr, index)
+					if err != nil {
+						return fmt.Errorf("row %d: attribute '%s': %w", index, attr.GetName(), err)
+					}
+					row.SetValue(attr.GetName(), value)
+					continue
+				}
+				if value, exists := timeline.value(attr.GetName(), index); exists {
+					row.SetValue(attr.GetName(), value)
+					continue
+				}
+				if value, exists := correlated[attr.GetName()]; exists {
+					row.SetValue(attr.GetName(), value)
+					continue
+				}
+				if value, exists := profile.value(attr.GetName()); exists {
+					row.SetValue(attr.GetName(), value)
+					continue
+				}
+				if field, exists := people[attr.GetName()]; exists {
+					row.SetValue(attr.GetName(), g.population.Person(index).value(field))
+					continue
+				}
+				if part, exists := addresses[attr.GetName()]; exists {
+					row.SetValue(attr.GetName(), address.value(part))
+					continue
+				}
+				if attr.GetListEncoding() != "" {
+					row.SetValue(attr.GetName(), g.generateListValue(attr))
+					continue
+				}
 				// Generate appropriate value based on attribute type and name
 				value := g.generateFieldValue(attr)
 				row.SetValue(attr.GetName(), value)
 			}
+
+			// Regenerate values already used within their scope once the row's scope
+			// values are all set
+			for _, scope := range scoped {
+				name := scope.attr.GetName()
+				if row.IsPinned(name) {
+					continue
+				}
+				value := row.GetValue(name)
+				for attempt := 0; scope.contains(row, value) && !scope.exhausted(row) && attempt < maxScopedUniqueAttempts; attempt++ {
+					value = g.generateFieldValue(scope.attr)
+				}
+				if g.strictUniqueness && scope.contains(row, value) && scope.exhausted(row) {
+					return fmt.Errorf("row %d: attribute '%s' has used all %d distinct values within %s '%s'",
+						index, name, scope.domain, scope.scope, row.GetValue(scope.scope))
+				}
+				claimed := scope.claim(row, value)
+				if claimed != value {
+					scope.widened++
+				}
+				row.SetValue(name, claimed)
+			}
 			return nil
 		})
 
 		if err != nil {
 			return fmt.Errorf("failed to generate fields for entity %s: %w", entity.GetExternalID(), err)
 		}
+		for _, scope := range scoped {
+			if scope.widened > 0 {
+				console.ClearLine()
+				color.Yellow(console.Text("⚠️  %s.%s ran out of values unique within %s; %d were given a numeric suffix (use --strict-uniqueness to fail instead)"),
+					entity.GetExternalID(), scope.attr.GetName(), scope.scope, scope.widened)
+			}
+		}
+
+		// Rows' payloads are made distinct, or repeated, once all their fields are set
+		if err := g.shapePayloads(entity, regularFields, regenerableAttributes(entity, regularFields, people, addresses)); err != nil {
+			return fmt.Errorf("failed to generate fields for entity %s: %w", entity.GetExternalID(), err)
+		}
 	}
 
 	// Clear field generation progress line
-	fmt.Printf("\r%-80s\r", "")
+	console.ClearLine()
 
 	return nil
 }
@@ -72,38 +282,99 @@ func (g *FieldGenerator) generateFieldValue(attr model.AttributeInterface) strin
 	attrName := attr.GetName()
 	dataType := attr.GetDataType()
 
+	// An explicit generator hint takes precedence over inference
+	if generator := attr.GetGenerator(); generator != nil {
+		return piiValue(generator, g.clearlyFakePII, g.faker)
+	}
+
+	// A theme's vocabulary comes ahead of the name patterns
+	if category := g.themeCategory(attr); category != "" {
+		return g.theme.value(category, g.faker)
+	}
+
 	// Generate based on field name patterns first
-	switch {
-	case contains(attrName, "email"):
-		return gofakeit.Email()
-	case contains(attrName, "name"):
-		return gofakeit.Name()
-	case contains(attrName, "phone"):
-		return gofakeit.Phone()
-	case contains(attrName, "address"):
-		return gofakeit.Address().Address
-	case contains(attrName, "status"):
-		return gofakeit.RandomString([]string{"active", "inactive", "pending"})
-	case contains(attrName, "date"), contains(attrName, "time"):
-		return gofakeit.Date().Format(time.RFC3339)
+	switch namePattern(attrName, g.clearlyFakePII) {
+	case "email":
+		if g.clearlyFakePII {
+			// example.com is reserved for documentation (RFC 2606)
+			return strings.ToLower(g.faker.Username()) + "@example.com"
+		}
+		return g.faker.Email()
+	case "phone":
+		if g.clearlyFakePII {
+			// 555-0100 through 555-0199 are reserved for fictional use
+			return fmt.Sprintf("555-01%02d", g.faker.Number(0, 99))
+		}
+		return g.faker.Phone()
+	case "name":
+		return g.faker.Name()
+	case "address":
+		return g.faker.Address().Address
+	case "status":
+		return g.faker.RandomString([]string{"active", "inactive", "pending"})
+	case "date", "time":
+		return g.faker.Date().Format(time.RFC3339)
+	}
+
+	// A default replaces the generic value for the type
+	if value := attr.GetDefault(); value != nil {
+		return *value
 	}
 
 	// Generate based on data type
 	switch dataType {
 	case "Integer", "Int64":
-		return strconv.Itoa(gofakeit.Number(1, 1000))
+		return strconv.Itoa(g.faker.Number(minGeneratedInteger, maxGeneratedInteger))
 	case "Boolean", "Bool":
-		return strconv.FormatBool(gofakeit.Bool())
+		return strconv.FormatBool(g.faker.Bool())
 	case "Date":
-		return gofakeit.Date().Format("2006-01-02")
+		return g.faker.Date().Format("2006-01-02")
 	case "DateTime":
-		return gofakeit.Date().Format(time.RFC3339)
+		return g.faker.Date().Format(time.RFC3339)
 	case "Float", "Double":
-		return fmt.Sprintf("%.2f", gofakeit.Float64Range(1.0, 100.0))
+		return fmt.Sprintf("%.2f", g.faker.Float64Range(minGeneratedFloat, maxGeneratedFloat))
 	default:
 		// Default to string
-		return gofakeit.Word()
+		return g.faker.Word()
+	}
+}
+
+// themeCategory returns the theme vocabulary of an attribute, or "" without a theme
+func (g *FieldGenerator) themeCategory(attr model.AttributeInterface) string {
+	if g.theme == nil {
+		return ""
+	}
+	category, exists := g.themed[attr]
+	if !exists {
+		category = g.theme.category(attr)
+		g.themed[attr] = category
+	}
+	return category
+}
+
+// namePattern returns the name pattern (email, phone, name, address, status, date
+// or time) that picks the kind of an attribute's values, or "" when its name
+// matches none. Clearly fake phone numbers take precedence over names.
+func namePattern(attrName string, clearlyFakePII bool) string {
+	switch {
+	case contains(attrName, "email"):
+		return "email"
+	case contains(attrName, "phone") && clearlyFakePII:
+		return "phone"
+	case contains(attrName, "name"):
+		return "name"
+	case contains(attrName, "phone"):
+		return "phone"
+	case contains(attrName, "address"):
+		return "address"
+	case contains(attrName, "status"):
+		return "status"
+	case contains(attrName, "date"):
+		return "date"
+	case contains(attrName, "time"):
+		return "time"
 	}
+	return ""
 }
 
 // contains checks if a string contains a substring (case-insensitive helper)
@@ -6,6 +6,7 @@ import (
 
 	"github.com/SGNL-ai/fabricator/pkg/generators/model"
 	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/brianvoe/gofakeit/v6"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -287,7 +288,7 @@ func TestFieldGenerator_GenerateFields(t *testing.T) {
 }
 
 func TestFieldGenerator_generateFieldValue(t *testing.T) {
-	generator := &FieldGenerator{}
+	generator := &FieldGenerator{faker: gofakeit.New(0)}
 
 	tests := []struct {
 		name             string
@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// DefaultFilenameReplacement replaces characters that are invalid in filenames
+const DefaultFilenameReplacement = "_"
+
+// invalidFilenameChars can't appear in Windows filenames; '/' also separates the
+// namespace of an external ID and never reaches a filename
+const invalidFilenameChars = `<>:"/\|?*`
+
+// reservedFilenames are device names Windows won't create a file for, whatever
+// the extension
+var reservedFilenames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// FileLayout names the files entities are written to and read from. Writers and
+// readers of the same files must share it; the zero value is the default layout.
+type FileLayout struct {
+	replacement   string // Replaces characters invalid in filenames; empty uses DefaultFilenameReplacement
+	domainFolders bool   // Put each entity's file in a subfolder named after its domain
+}
+
+// NewFileLayout returns the layout replacing characters invalid in Windows
+// filenames with replacement when entity external IDs become output filenames,
+// and with domainFolders putting entities with a domain in a subfolder named
+// after it. The replacement itself must be a valid filename fragment.
+func NewFileLayout(replacement string, domainFolders bool) (FileLayout, error) {
+	if replacement == "" {
+		return FileLayout{}, fmt.Errorf("filename replacement cannot be empty")
+	}
+	if sanitizeFilename(replacement, DefaultFilenameReplacement) != replacement {
+		return FileLayout{}, fmt.Errorf("filename replacement '%s' contains characters invalid in filenames", replacement)
+	}
+	return FileLayout{replacement: replacement, domainFolders: domainFolders}, nil
+}
+
+// sanitize makes an extension-less filename valid with the layout's replacement
+func (l FileLayout) sanitize(name string) string {
+	replacement := l.replacement
+	if replacement == "" {
+		replacement = DefaultFilenameReplacement
+	}
+	return sanitizeFilename(name, replacement)
+}
+
+// entityFileBase returns the extension-less output filename for an external ID,
+// with characters invalid on Windows replaced
+func (l FileLayout) entityFileBase(externalID string) string {
+	return l.sanitize(entityNamespaceBase(externalID))
+}
+
+// entityFilePath returns an entity's output file relative to the output directory,
+// with / separators: <domain>/<name><extension> with domain folders, otherwise
+// <name><extension>
+func (l FileLayout) entityFilePath(entity model.EntityInterface, extension string) string {
+	name := l.entityFileBase(entity.GetExternalID()) + extension
+	if l.domainFolders && entity.GetDomain() != "" {
+		return path.Join(l.sanitize(entity.GetDomain()), name)
+	}
+	return name
+}
+
+// sanitizeFilename makes an extension-less filename valid on Windows, macOS and
+// Linux: invalid and control characters become replacement, trailing dots and
+// spaces (which Windows strips) are replaced, and reserved device names get the
+// replacement appended
+func sanitizeFilename(name, replacement string) string {
+	var sanitized strings.Builder
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(invalidFilenameChars, r) {
+			sanitized.WriteString(replacement)
+			continue
+		}
+		sanitized.WriteRune(r)
+	}
+	result := sanitized.String()
+
+	trimmed := strings.TrimRight(result, ". ")
+	if trimmed != result {
+		result = trimmed + strings.Repeat(replacement, len(result)-len(trimmed))
+	}
+
+	if stem, _, _ := strings.Cut(result, "."); reservedFilenames[strings.ToUpper(stem)] {
+		result = stem + replacement + result[len(stem):]
+	}
+	return result
+}
+
+// checkFilenames fails when two entities would write to the same file on a
+// case-insensitive filesystem, which would silently overwrite one of them
+func (l FileLayout) checkFilenames(graph *model.Graph) error {
+	entities := graph.GetEntitiesList()
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].GetExternalID() < entities[j].GetExternalID()
+	})
+
+	owners := make(map[string]string, len(entities))
+	for _, entity := range entities {
+		base := l.entityFilePath(entity, "")
+		key := strings.ToLower(base)
+		if owner, exists := owners[key]; exists {
+			return fmt.Errorf("entities %s and %s both write to file %s; rename one of their external IDs",
+				owner, entity.GetExternalID(), base)
+		}
+		owners[key] = entity.GetExternalID()
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"valid name is unchanged", "User", "User"},
+		{"invalid characters are replaced", `Role:Admin*?`, "Role_Admin__"},
+		{"control characters are replaced", "Tab\tName", "Tab_Name"},
+		{"trailing dots and spaces are replaced", "Group. ", "Group__"},
+		{"reserved device name gets a suffix", "con", "con_"},
+		{"reserved name with extension gets a suffix", "NUL.backup", "NUL_.backup"},
+		{"reserved prefix in a longer name is kept", "Console", "Console"},
+		{"unicode is kept", "Zoë", "Zoë"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sanitizeFilename(tt.input, "_"))
+		})
+	}
+}
+
+func TestNewFileLayout(t *testing.T) {
+	layout, err := NewFileLayout("-", false)
+	require.NoError(t, err)
+	assert.Equal(t, "Role-Admin", layout.entityFileBase("App/Role:Admin"))
+	assert.Equal(t, "Role_Admin", FileLayout{}.entityFileBase("App/Role:Admin"), "the zero value uses the default replacement")
+
+	_, err = NewFileLayout("", false)
+	assert.Error(t, err)
+	_, err = NewFileLayout(":", false)
+	assert.Error(t, err)
+}
+
+func TestFilenameSanitizationEndToEnd(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Windows-hostile SOR",
+		Description: "Entity external IDs with characters invalid on Windows",
+		Entities: map[string]parser.Entity{
+			"role": {
+				DisplayName: "Role",
+				ExternalId:  "App/Role:Admin",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				},
+			},
+		},
+	}
+
+	t.Run("writes, validates and lists renamed files", func(t *testing.T) {
+		graphInterface, err := model.NewGraph(def, 3)
+		require.NoError(t, err)
+		graph := graphInterface.(*model.Graph)
+		dir := t.TempDir()
+
+		generator := NewDataGenerator(dir, map[string]int{"App/Role:Admin": 3}, false)
+		require.NoError(t, generator.Generate(graph))
+		assert.FileExists(t, filepath.Join(dir, "Role_Admin.csv"))
+
+		errors, err := NewValidationProcessor().ValidateExistingCSVFiles(def, dir)
+		require.NoError(t, err)
+		assert.Empty(t, errors)
+
+		manifest := NewManifest(graph, "", nil)
+		assert.Equal(t, &Manifest{
+			Format: OutputFormatCSV,
+			Files: []ManifestEntry{
+				{Entity: "App/Role:Admin", File: "Role_Admin.csv", Rows: 3, Unsanitized: "Role:Admin.csv"},
+			},
+		}, manifest)
+
+		path := filepath.Join(dir, ManifestFile)
+		require.NoError(t, WriteManifest(path, manifest))
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"unsanitized": "Role:Admin.csv"`)
+	})
+
+	t.Run("rejects entities sharing a file on case-insensitive filesystems", func(t *testing.T) {
+		colliding := &parser.SORDefinition{
+			DisplayName: def.DisplayName,
+			Description: def.Description,
+			Entities: map[string]parser.Entity{
+				"role":  def.Entities["role"],
+				"other": {DisplayName: "Other", ExternalId: "Other/role?admin", Attributes: def.Entities["role"].Attributes},
+			},
+		}
+		graphInterface, err := model.NewGraph(colliding, 3)
+		require.NoError(t, err)
+
+		err = NewDataGenerator(t.TempDir(), map[string]int{"App/Role:Admin": 3, "Other/role?admin": 3}, false).Generate(graphInterface.(*model.Graph))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "App/Role:Admin and Other/role?admin both write to file")
+	})
+}
+
+func TestDomainFolders(t *testing.T) {
+	def := userRoleDefinition("")
+	user := def.Entities["user"]
+	user.Domain = "identity"
+	def.Entities["user"] = user
+
+	layout, err := NewFileLayout(DefaultFilenameReplacement, true)
+	require.NoError(t, err)
+	files := NewOutputFiles()
+	files.SetLayout(layout)
+
+	graphInterface, err := model.NewGraph(def, 3)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	dir := t.TempDir()
+
+	generator := NewDataGenerator(dir, map[string]int{"User": 3, "Role": 2}, false)
+	generator.SetOutputFiles(files)
+	require.NoError(t, generator.Generate(graph))
+	assert.FileExists(t, filepath.Join(dir, "identity", "User.csv"))
+	assert.FileExists(t, filepath.Join(dir, "Role.csv"), "entities without a domain stay at the top level")
+
+	processor := NewValidationProcessor().(*ValidationProcessor)
+	processor.SetFileLayout(layout)
+	errors, err := processor.ValidateExistingCSVFiles(def, dir)
+	require.NoError(t, err)
+	assert.Empty(t, errors)
+	streaming := NewStreamingValidationProcessor().(*StreamingValidationProcessor)
+	streaming.SetFileLayout(layout)
+	errors, err = streaming.ValidateExistingCSVFiles(def, dir)
+	require.NoError(t, err)
+	assert.Empty(t, errors)
+
+	errors, err = NewValidationProcessor().ValidateExistingCSVFiles(def, dir)
+	require.NoError(t, err)
+	assert.NotEmpty(t, errors, "the default layout looks for the files at the top level")
+
+	assert.Equal(t, []ManifestEntry{
+		{Entity: "Role", File: "Role.csv", Rows: 2},
+		{Entity: "User", File: "identity/User.csv", Rows: 3, Domain: "identity"},
+	}, NewManifest(graph, OutputFormatCSV, files).Files)
+}
@@ -0,0 +1,76 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixedValueGeneration(t *testing.T) {
+	value := func(v string) *string { return &v }
+	def := &parser.SORDefinition{
+		DisplayName: "Fixed values SOR",
+		Description: "SOR with const and default attributes",
+		Entities: map[string]parser.Entity{
+			"account": {
+				DisplayName: "Account",
+				ExternalId:  "Account",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "sorType", ExternalId: "sorType", Type: "String", Const: value("okta")},
+					{Name: "risk", ExternalId: "risk", Type: "Integer", Default: value("0")},
+					{Name: "email", ExternalId: "email", Type: "String", Default: value("none")},
+				},
+			},
+		},
+	}
+
+	t.Run("const and default replace generated values", func(t *testing.T) {
+		graph := buildGraphWithIDs(t, def, 20)
+		require.NoError(t, NewFieldGenerator().GenerateFields(graph))
+
+		entity, _ := graph.GetEntity("Account")
+		for i := 0; i < entity.GetRowCount(); i++ {
+			row := entity.GetRowByIndex(i)
+			assert.Equal(t, "okta", row.GetValue("sorType"))
+			assert.Equal(t, "0", row.GetValue("risk"))
+			assert.Contains(t, row.GetValue("email"), "@", "name inference takes precedence over a default")
+		}
+	})
+
+	t.Run("edge cases leave constants alone", func(t *testing.T) {
+		graph := buildGraphWithIDs(t, def, 5)
+		require.NoError(t, NewFieldGenerator().GenerateFields(graph))
+		for _, placement := range injectEdgeCases(graph) {
+			assert.NotEqual(t, "sorType", placement.Attribute)
+		}
+	})
+
+	t.Run("foreign keys can't be fixed", func(t *testing.T) {
+		withRelationship := userRoleDefinition("")
+		user := withRelationship.Entities["user"]
+		user.Attributes[1].Const = value("role-1")
+		withRelationship.Entities["user"] = user
+
+		_, err := model.NewGraph(withRelationship, 5)
+		assert.ErrorContains(t, err, "is a foreign key and cannot have a const or default value")
+	})
+}
+
+// buildGraphWithIDs creates a graph and generates its primary keys
+func buildGraphWithIDs(t *testing.T, def *parser.SORDefinition, rows int) *model.Graph {
+	t.Helper()
+	graphInterface, err := model.NewGraph(def, rows)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+
+	counts := make(map[string]int)
+	for _, entity := range graph.GetEntitiesList() {
+		counts[entity.GetExternalID()] = rows
+	}
+	require.NoError(t, NewIDGenerator().GenerateIDs(graph, counts))
+	return graph
+}
@@ -0,0 +1,55 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/SGNL-ai/fabricator/pkg/config"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// loadFixtures seeds entities with their fixture rows, pinned so later pipeline
+// stages keep them verbatim and only fill in the attributes a row leaves out. The
+// ID generator then adds the rest of each entity's rows around them.
+func loadFixtures(graph *model.Graph, fixtures *config.Fixtures) error {
+	for _, entity := range graph.GetEntitiesList() {
+		rows := fixtures.Rows[entity.GetExternalID()]
+		if len(rows) == 0 {
+			continue
+		}
+		if err := addPinnedRows(entity, rows, "fixture row"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFixtureReferences fails when a fixture row sets a foreign key to a primary key
+// no row holds once IDs are generated, since generated keys can't be predicted; a
+// fixture row can reference the fixture rows of other entities
+func checkFixtureReferences(graph *model.Graph, fixtures *config.Fixtures) error {
+	for _, entity := range graph.GetEntitiesList() {
+		count := fixtures.Count(entity.GetExternalID())
+		for _, attr := range entity.GetRelationshipAttributes() {
+			relationship := graph.ForeignKeyRelationship(entity, attr.GetName())
+			if relationship == nil {
+				continue
+			}
+			target := relationship.GetTargetEntity()
+			if target.GetDerived() != nil {
+				continue // Rows are derived after fields are generated
+			}
+			if primaryKey := target.GetPrimaryKey(); primaryKey == nil ||
+				primaryKey.GetName() != relationship.GetTargetAttribute().GetName() {
+				continue
+			}
+			for i := 0; i < count && i < entity.GetRowCount(); i++ {
+				value := entity.GetRowByIndex(i).GetValue(attr.GetName())
+				if value != "" && !target.CheckKeyExists(value) {
+					return fmt.Errorf("entity %s fixture row %d references %s '%s' through '%s', which no row has",
+						entity.GetExternalID(), i+1, target.GetExternalID(), value, attr.GetName())
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/config"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixtures(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Fixtures",
+		Entities: map[string]parser.Entity{
+			"group": {
+				DisplayName: "Group", ExternalId: "Group",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "Integer", UniqueId: true},
+					{Name: "name", ExternalId: "name", Type: "String"},
+				},
+			},
+			"user": {
+				DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "email", ExternalId: "mail", Type: "String"},
+					{Name: "groupId", ExternalId: "groupId", Type: "Integer"},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"user_group": {DisplayName: "User Group", Name: "user_group", FromAttribute: "User.groupId", ToAttribute: "Group.id"},
+		},
+	}
+	generate := func(t *testing.T, fixtures *config.Fixtures) (string, error) {
+		graph, err := model.NewGraph(def, 10)
+		require.NoError(t, err)
+		dir := t.TempDir()
+		generator := NewDataGenerator(dir, map[string]int{"Group": 4, "User": 10}, false)
+		generator.SetFixtures(fixtures)
+		return dir, generator.Generate(graph.(*model.Graph))
+	}
+
+	t.Run("fixed rows are merged with generated rows", func(t *testing.T) {
+		dir, err := generate(t, &config.Fixtures{Rows: map[string][]map[string]string{
+			"Group": {{"id": "2", "name": "Administrators"}},
+			"User":  {{"id": "admin", "mail": "admin@example.com", "groupId": "2"}, {"id": "auditor"}},
+		}})
+		require.NoError(t, err)
+
+		groups := readCSV(t, filepath.Join(dir, "Group.csv"))
+		require.Len(t, groups, 5, "fixed rows count toward the row count")
+		assert.Equal(t, []string{"2", "Administrators"}, groups[1])
+		var ids []string
+		for _, group := range groups[1:] {
+			ids = append(ids, group[0])
+		}
+		assert.ElementsMatch(t, []string{"1", "2", "3", "4"}, ids, "generated keys skip the fixed ones")
+
+		users := readCSV(t, filepath.Join(dir, "User.csv"))
+		require.Len(t, users, 11)
+		assert.Equal(t, []string{"admin", "admin@example.com", "2"}, users[1])
+		assert.Equal(t, "auditor", users[2][0])
+		assert.NotEmpty(t, users[2][1], "attributes a fixed row leaves out are generated")
+		assert.Contains(t, ids, users[2][2], "foreign keys a fixed row leaves out are linked")
+		for _, user := range users[1:] {
+			assert.Contains(t, ids, user[2])
+		}
+	})
+
+	t.Run("fixtures beyond the row count are all kept", func(t *testing.T) {
+		rows := make([]map[string]string, 6)
+		for i := range rows {
+			rows[i] = map[string]string{"id": string(rune('1' + i))}
+		}
+		dir, err := generate(t, &config.Fixtures{Rows: map[string][]map[string]string{"Group": rows}})
+		require.NoError(t, err)
+		assert.Len(t, readCSV(t, filepath.Join(dir, "Group.csv")), 7)
+	})
+
+	t.Run("foreign key to a generated row", func(t *testing.T) {
+		_, err := generate(t, &config.Fixtures{Rows: map[string][]map[string]string{
+			"User": {{"id": "admin", "groupId": "99"}},
+		}})
+		assert.ErrorContains(t, err, "entity User fixture row 1 references Group '99' through 'groupId', which no row has")
+	})
+}
@@ -3,6 +3,8 @@ package pipeline
 import (
 	"fmt"
 
+	"github.com/SGNL-ai/fabricator/pkg/config"
+	"github.com/SGNL-ai/fabricator/pkg/events"
 	"github.com/SGNL-ai/fabricator/pkg/generators/model"
 )
 
@@ -44,6 +46,21 @@ type DataGenerator struct {
 	rowCounts       map[string]int
 	outputDir       string
 	autoCardinality bool
+	partialInputDir string           // Optional directory of partial CSVs to fill in
+	fixtures        *config.Fixtures // Optional exact rows generated rows are added to
+	externalRefs    []ExternalReference
+	access          *config.AccessConfiguration // Optional role and SoD distribution for assignments
+	edgeCases       bool                        // Overwrite the first rows' fields with boundary values
+	files           *OutputFiles                // Creates the writer's data files; nil writes them in the clear
+	streams         *RandomStreams              // Streams the phases draw from; nil draws them at random
+
+	// Results
+	accessTruth        *AccessGroundTruth
+	edgeCasePlacements []EdgeCasePlacement
+
+	// Observability
+	audit  *AuditLog
+	events *events.Emitter
 }
 
 // NewDataGenerator creates a new DataGenerator with all pipeline components.
@@ -62,31 +79,435 @@ func NewDataGenerator(outputDir string, rowCounts map[string]int, autoCardinalit
 	}
 }
 
+// SetPartialInput configures a directory of partial CSV files whose rows are
+// loaded before generation; only their missing columns are generated
+func (g *DataGenerator) SetPartialInput(directory string) {
+	g.partialInputDir = directory
+}
+
+// SetFixtures configures exact rows that are always written. They count toward each
+// entity's row count, so the ID generator, if it supports it, only adds the rows the
+// fixtures leave over.
+func (g *DataGenerator) SetFixtures(fixtures *config.Fixtures) {
+	g.fixtures = fixtures
+	if generator, ok := g.idGenerator.(interface{ SetTopUp(map[string]bool) }); ok {
+		topUp := make(map[string]bool, len(fixtures.Rows))
+		for entityID := range fixtures.Rows {
+			topUp[entityID] = true
+		}
+		generator.SetTopUp(topUp)
+	}
+}
+
+// SetExternalReferences configures foreign keys whose values are loaded from
+// other SORs' CSV output instead of entities in this graph
+func (g *DataGenerator) SetExternalReferences(refs []ExternalReference) {
+	g.externalRefs = refs
+}
+
+// SetAccessSimulation configures the assignment entity's user and entitlement keys
+// to follow a role and SoD violation distribution after relationships are linked
+func (g *DataGenerator) SetAccessSimulation(cfg *config.AccessConfiguration) {
+	g.access = cfg
+}
+
+// AccessGroundTruth returns the roles and SoD violations of the last generation, or
+// nil when access simulation is not configured
+func (g *DataGenerator) AccessGroundTruth() *AccessGroundTruth {
+	return g.accessTruth
+}
+
+// SetEdgeCases configures the generator to overwrite field values in the first rows
+// of each entity with boundary values for their type
+func (g *DataGenerator) SetEdgeCases(enabled bool) {
+	g.edgeCases = enabled
+}
+
+// EdgeCases returns where the last generation placed boundary values, or nil when
+// edge cases are not enabled
+func (g *DataGenerator) EdgeCases() []EdgeCasePlacement {
+	return g.edgeCasePlacements
+}
+
+// SetEventEmitter configures where pipeline progress events are sent
+func (g *DataGenerator) SetEventEmitter(emitter *events.Emitter) {
+	g.events = emitter
+}
+
+// SetOutputFormat selects how generated rows are written (OutputFormatCSV,
+// OutputFormatJSONL, OutputFormatGo or OutputFormatNeo4j)
+func (g *DataGenerator) SetOutputFormat(format string) error {
+	switch format {
+	case "", OutputFormatCSV:
+		g.csvWriter = NewCSVWriter(g.outputDir)
+	case OutputFormatJSONL:
+		g.csvWriter = NewJSONLWriter(g.outputDir)
+	case OutputFormatGo:
+		g.csvWriter = NewGoWriter(g.outputDir)
+	case OutputFormatNeo4j:
+		g.csvWriter = NewNeo4jWriter(g.outputDir)
+	default:
+		return fmt.Errorf("unsupported output format '%s' (supported: %s, %s, %s, %s)", format, OutputFormatCSV, OutputFormatJSONL, OutputFormatGo, OutputFormatNeo4j)
+	}
+	g.SetOutputFiles(g.files)
+	return nil
+}
+
+// SetOutputFiles configures how the writer, if it supports it, creates its data
+// files, e.g. encrypted; it carries over to writers of a later SetOutputFormat
+func (g *DataGenerator) SetOutputFiles(files *OutputFiles) {
+	g.files = files
+	if writer, ok := g.csvWriter.(interface{ SetOutputFiles(*OutputFiles) }); ok {
+		writer.SetOutputFiles(files)
+	}
+}
+
+// SetGoPackage configures the package clause of Go fixture files; it fails for
+// an invalid name or when the output format isn't OutputFormatGo
+func (g *DataGenerator) SetGoPackage(name string) error {
+	writer, ok := g.csvWriter.(interface{ SetPackage(string) error })
+	if !ok {
+		return fmt.Errorf("a Go package name applies only to the %s output format", OutputFormatGo)
+	}
+	return writer.SetPackage(name)
+}
+
+// SetJSONLShape configures what each line of JSON lines output holds, one of
+// JSONLShapes; it fails for an unknown shape or when the output format isn't
+// OutputFormatJSONL
+func (g *DataGenerator) SetJSONLShape(shape string) error {
+	writer, ok := g.csvWriter.(interface{ SetShape(string) error })
+	if !ok {
+		return fmt.Errorf("a JSON lines shape applies only to the %s output format", OutputFormatJSONL)
+	}
+	return writer.SetShape(shape)
+}
+
+// SetPopulation configures the field generator, if it supports it, to draw
+// person-like entities' names, emails, employee IDs and usernames from a population
+func (g *DataGenerator) SetPopulation(population *Population) {
+	if generator, ok := g.fieldGenerator.(interface{ SetPopulation(*Population) }); ok {
+		generator.SetPopulation(population)
+	}
+}
+
+// SetAddressCoherence configures the field generator, if it supports it, to draw
+// the address parts of address-like entities together (see AddressCoherenceModes)
+func (g *DataGenerator) SetAddressCoherence(mode string) {
+	if generator, ok := g.fieldGenerator.(interface{ SetAddressCoherence(string) }); ok {
+		generator.SetAddressCoherence(mode)
+	}
+}
+
+// SetTheme configures the field generator, if it supports it, to name departments,
+// groups, projects and applications from the theme's vocabularies
+func (g *DataGenerator) SetTheme(theme *Theme) {
+	if generator, ok := g.fieldGenerator.(interface{ SetTheme(*Theme) }); ok {
+		generator.SetTheme(theme)
+	}
+}
+
+// SetWriteWorkers configures how many entity files the writer writes at the same
+// time, if it supports it
+func (g *DataGenerator) SetWriteWorkers(workers int) {
+	if writer, ok := g.csvWriter.(interface{ SetWorkers(int) }); ok {
+		writer.SetWorkers(workers)
+	}
+}
+
+// SetWriteFileBuffer configures how many bytes the writer buffers per file between
+// writes to storage, if it supports it
+func (g *DataGenerator) SetWriteFileBuffer(bytes int) {
+	if writer, ok := g.csvWriter.(interface{ SetFileBufferSize(int) }); ok {
+		writer.SetFileBufferSize(bytes)
+	}
+}
+
+// SetRedactedOutput configures the writer, if it supports it, to also write a copy
+// of the files to dir with sensitive attributes masked or hashed
+func (g *DataGenerator) SetRedactedOutput(dir string) {
+	if writer, ok := g.csvWriter.(interface{ SetRedactedOutput(string) }); ok {
+		writer.SetRedactedOutput(dir)
+	}
+}
+
+// SetRandomStreams configures the streams every phase draws its randomness from,
+// and passes them to the ID and field generators if they support it
+func (g *DataGenerator) SetRandomStreams(streams *RandomStreams) {
+	g.streams = streams
+	if generator, ok := g.idGenerator.(interface{ SetRandomStreams(*RandomStreams) }); ok {
+		generator.SetRandomStreams(streams)
+	}
+	if generator, ok := g.fieldGenerator.(interface{ SetRandomStreams(*RandomStreams) }); ok {
+		generator.SetRandomStreams(streams)
+	}
+}
+
+// SetAuditLog configures where the random decisions of a run are recorded, and
+// passes it to the field generator if it supports it
+func (g *DataGenerator) SetAuditLog(audit *AuditLog) {
+	g.audit = audit
+	if generator, ok := g.fieldGenerator.(interface{ SetAuditLog(*AuditLog) }); ok {
+		generator.SetAuditLog(audit)
+	}
+}
+
+// SetClearlyFakePII configures the field generator, if it supports it, to use
+// obviously fake formats for PII values
+func (g *DataGenerator) SetClearlyFakePII(enabled bool) {
+	if generator, ok := g.fieldGenerator.(interface{ SetClearlyFakePII(bool) }); ok {
+		generator.SetClearlyFakePII(enabled)
+	}
+}
+
+// SetStrictUniqueness configures the field generator, if it supports it, to fail
+// when a scoped-unique attribute runs out of values instead of widening them
+func (g *DataGenerator) SetStrictUniqueness(enabled bool) {
+	if generator, ok := g.fieldGenerator.(interface{ SetStrictUniqueness(bool) }); ok {
+		generator.SetStrictUniqueness(enabled)
+	}
+}
+
+// SetIDFormats configures the ID generator, if it supports it, with rules picking
+// each primary key's format ahead of DefaultIDFormats
+func (g *DataGenerator) SetIDFormats(rules []IDFormatRule) {
+	if generator, ok := g.idGenerator.(interface{ SetIDFormats([]IDFormatRule) }); ok {
+		generator.SetIDFormats(rules)
+	}
+}
+
+// SetKeyRegistry configures the ID generator, if it supports it, with the keys
+// earlier runs generated: reuse × rows of each entity's rows take keys from the
+// registry, and the others get keys it doesn't hold
+func (g *DataGenerator) SetKeyRegistry(registry *KeyRegistry, reuse float64) {
+	if generator, ok := g.idGenerator.(interface{ SetKeyRegistry(*KeyRegistry, float64) }); ok {
+		generator.SetKeyRegistry(registry, reuse)
+	}
+}
+
+// SetIncludeEmptyEntities configures the ID generator and relationship linker, if
+// they support it, to accept a row count of 0 so the entity is written as a
+// header-only file and FKs referencing it are left blank
+func (g *DataGenerator) SetIncludeEmptyEntities(enabled bool) {
+	if generator, ok := g.idGenerator.(interface{ SetAllowEmpty(bool) }); ok {
+		generator.SetAllowEmpty(enabled)
+	}
+	if linker, ok := g.relationshipLinker.(interface{ SetAllowEmpty(bool) }); ok {
+		linker.SetAllowEmpty(enabled)
+	}
+}
+
+// SetThrottle configures row pacing for the CSV writer, if it supports it
+func (g *DataGenerator) SetThrottle(throttle *Throttle) {
+	if writer, ok := g.csvWriter.(interface{ SetThrottle(*Throttle) }); ok {
+		writer.SetThrottle(throttle)
+	}
+}
+
 // Generate executes the full pipeline to generate data for all entities
 func (g *DataGenerator) Generate(graph *model.Graph) error {
+	// Fail before generating anything if two entities would share an output file
+	if err := g.files.Layout().checkFilenames(graph); err != nil {
+		return err
+	}
+
+	// Step 0: Seed entities with the rows the SOR embeds and the fixtures, then with
+	// partially provided data
+	if err := loadInlineData(graph); err != nil {
+		return fmt.Errorf("inline data loading failed: %w", err)
+	}
+	if g.fixtures != nil {
+		if err := loadFixtures(graph, g.fixtures); err != nil {
+			return fmt.Errorf("fixture loading failed: %w", err)
+		}
+	}
+	if g.partialInputDir != "" {
+		started := g.events.PhaseStarted("partial_input")
+		// Keys missing from the files take the generated keys' format and seed
+		loader := &CSVLoader{layout: g.files.Layout()}
+		if generator, ok := g.idGenerator.(interface {
+			KeySource(model.EntityInterface, func(string) bool) func() string
+		}); ok {
+			loader.keys = generator.KeySource
+		}
+		if _, err := loader.LoadPartialCSVFiles(graph, g.partialInputDir); err != nil {
+			return fmt.Errorf("partial input loading failed: %w", err)
+		}
+		g.events.PhaseFinished("partial_input", started)
+	}
+	provided := make(map[string]int)
+	for _, entity := range graph.GetEntitiesList() {
+		provided[entity.GetExternalID()] = entity.GetRowCount()
+	}
+
 	// Step 1: Generate all identifier fields in topological order
+	started := g.events.PhaseStarted("ids")
 	if err := g.idGenerator.GenerateIDs(graph, g.rowCounts); err != nil {
 		return fmt.Errorf("ID generation failed: %w", err)
 	}
+	g.events.PhaseFinished("ids", started)
+	if g.fixtures != nil {
+		if err := checkFixtureReferences(graph, g.fixtures); err != nil {
+			return err
+		}
+	}
 
 	// Step 2: Establish relationship structure between entities
+	started = g.events.PhaseStarted("relationships")
 	if err := g.relationshipLinker.LinkRelationships(graph, g.autoCardinality); err != nil {
 		return fmt.Errorf("relationship linking failed: %w", err)
 	}
+	g.events.PhaseFinished("relationships", started)
+
+	// Step 2b: Assign foreign keys that reference other SORs' output
+	if len(g.externalRefs) > 0 {
+		started = g.events.PhaseStarted("external_keys")
+		if err := linkExternalReferences(graph, g.externalRefs, g.streams); err != nil {
+			return fmt.Errorf("external key linking failed: %w", err)
+		}
+		g.events.PhaseFinished("external_keys", started)
+	}
 
 	// Step 3: Fill in remaining non-relationship fields
+	started = g.events.PhaseStarted("fields")
 	if err := g.fieldGenerator.GenerateFields(graph); err != nil {
 		return fmt.Errorf("field generation failed: %w", err)
 	}
+	g.events.PhaseFinished("fields", started)
+
+	// Step 3b: Take a share of some attributes' values from other entities' attributes
+	started = g.events.PhaseStarted("shared_values")
+	shareValues(graph, g.streams)
+	g.events.PhaseFinished("shared_values", started)
+
+	// Step 3c: Place boundary values over generated fields
+	if g.edgeCases {
+		started = g.events.PhaseStarted("edge_cases")
+		g.edgeCasePlacements = injectEdgeCases(graph)
+		g.events.PhaseFinished("edge_cases", started)
+	}
+
+	// Step 3d: Point the foreign keys of relationships with a where or weights at
+	// the target rows now matching them
+	started = g.events.PhaseStarted("filtered_keys")
+	if err := retargetFilteredKeys(graph, g.autoCardinality, g.streams); err != nil {
+		return fmt.Errorf("relationship linking failed: %w", err)
+	}
+	g.events.PhaseFinished("filtered_keys", started)
+
+	// Step 3e: Redistribute entitlement assignments for access simulation. It runs
+	// once every foreign key is final, so nothing rewrites the assignments its
+	// ground truth is computed from.
+	if g.access != nil {
+		started = g.events.PhaseStarted("access")
+		truth, err := simulateAccess(graph, g.access, g.streams)
+		if err != nil {
+			return fmt.Errorf("access simulation failed: %w", err)
+		}
+		g.accessTruth = truth
+		g.events.PhaseFinished("access", started)
+	}
+	// Links are reported and explained as written, after retargeting and access
+	// simulation have rewritten them and with the target rows' fields set
+	g.emitRelationshipEvents(graph)
+	if g.audit.Enabled() {
+		g.audit.RecordCardinality(ExplainCardinality(graph, g.autoCardinality))
+	}
+
+	// Step 3f: Copy lookup attributes from the rows keys reference
+	started = g.events.PhaseStarted("lookups")
+	resolveLookups(graph)
+	g.events.PhaseFinished("lookups", started)
+
+	// Step 3g: Assign network values depending on other values, such as addresses
+	// within each row's subnet, then refresh the lookups copying them
+	started = g.events.PhaseStarted("network")
+	assigned, err := assignNetworkValues(graph, g.streams)
+	if err != nil {
+		return fmt.Errorf("network value generation failed: %w", err)
+	}
+	if assigned {
+		resolveLookups(graph)
+	}
+	g.events.PhaseFinished("network", started)
+
+	// Step 3h: Date the versions of effective-dated records, grouped by their keys
+	started = g.events.PhaseStarted("effective_dating")
+	if err := dateVersions(graph, g.streams); err != nil {
+		return fmt.Errorf("effective dating failed: %w", err)
+	}
+	g.events.PhaseFinished("effective_dating", started)
+
+	// Step 3i: Write timestamps in their attributes' time zones, then refresh the
+	// lookups copying them
+	started = g.events.PhaseStarted("time_zones")
+	if applyTimeZones(graph, g.streams) {
+		resolveLookups(graph)
+	}
+	g.events.PhaseFinished("time_zones", started)
+
+	// Step 4: Compute derived entities from the finished rows of the others
+	started = g.events.PhaseStarted("derived")
+	if err := deriveEntities(graph); err != nil {
+		return fmt.Errorf("derived entity generation failed: %w", err)
+	}
+	g.events.PhaseFinished("derived", started)
+
+	// Step 4b: Rewrite the values of attributes with a transform
+	started = g.events.PhaseStarted("transforms")
+	applyTransforms(graph)
+	g.events.PhaseFinished("transforms", started)
+
+	// Row counts are final once linking has removed duplicate junction rows
+	for _, entity := range graph.GetEntitiesList() {
+		g.events.EntityGenerated(entity.GetExternalID(), entity.GetRowCount())
+		g.audit.Record(AuditRecord{
+			Decision: AuditRows,
+			Entity:   entity.GetExternalID(),
+			Count:    entity.GetRowCount(),
+			Provided: provided[entity.GetExternalID()],
+		})
+	}
 
 	// Note: Validation is skipped in generation mode for performance
 	// Use --validate-only mode to validate existing CSV files
 	// Unique value validation is handled by AddRow during data generation
 
-	// Write CSV files
+	// Step 5: Write CSV files; writers that report progress emit each entity as it finishes
+	started = g.events.PhaseStarted("write")
+	writer, reportsProgress := g.csvWriter.(interface{ SetEventEmitter(*events.Emitter) })
+	if reportsProgress {
+		writer.SetEventEmitter(g.events)
+	}
 	if err := g.csvWriter.WriteFiles(graph); err != nil {
 		return fmt.Errorf("CSV file writing failed: %w", err)
 	}
+	if !reportsProgress {
+		for _, entity := range graph.GetEntitiesList() {
+			g.events.RowsWritten(entity.GetExternalID(), entity.GetRowCount())
+		}
+	}
+	g.events.PhaseFinished("write", started)
 
 	return nil
 }
+
+// emitRelationshipEvents reports how many foreign key values each relationship assigned
+func (g *DataGenerator) emitRelationshipEvents(graph *model.Graph) {
+	if !g.events.Enabled() {
+		return
+	}
+
+	for _, relationship := range graph.GetAllRelationships() {
+		sourceEntity := relationship.GetSourceEntity()
+		attrName := relationship.GetSourceAttribute().GetName()
+
+		assigned := 0
+		for i := 0; i < sourceEntity.GetRowCount(); i++ {
+			if sourceEntity.GetRowByIndex(i).GetValue(attrName) != "" {
+				assigned++
+			}
+		}
+		g.events.RelationshipLinked(relationship.GetID(), sourceEntity.GetExternalID(), assigned)
+	}
+}
@@ -0,0 +1,286 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/SGNL-ai/fabricator/pkg/console"
+	"github.com/SGNL-ai/fabricator/pkg/events"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/fatih/color"
+)
+
+// DefaultGoPackage is the package clause of Go fixture files
+const DefaultGoPackage = "fixtures"
+
+// GoWriter writes each entity's rows as a Go source file declaring a struct type
+// with one string field per attribute and a slice of its rows, so tests can
+// import a fabricated dataset without reading files. All files belong to one
+// package, so they are written to the output directory itself, ignoring domain
+// folders and partitions.
+type GoWriter struct {
+	outputDir  string
+	pkg        string
+	throttle   *Throttle // Optional row pacing; nil writes as fast as possible
+	fileBuffer int       // Bytes buffered per file; 0 uses DefaultWriteFileBuffer
+
+	// Directory of a copy with sensitive attributes redacted; empty writes none
+	redactedDir string
+
+	// Creates the data files, e.g. encrypted; nil writes them in the clear
+	files *OutputFiles
+
+	// Observability
+	events *events.Emitter
+}
+
+// NewGoWriter creates a new Go fixture writer
+func NewGoWriter(outputDir string) CSVWriterInterface {
+	return &GoWriter{
+		outputDir: outputDir,
+		pkg:       DefaultGoPackage,
+	}
+}
+
+// SetPackage configures the package clause of the written files
+func (w *GoWriter) SetPackage(name string) error {
+	if !token.IsIdentifier(name) || name == "_" {
+		return fmt.Errorf("invalid Go package name '%s'", name)
+	}
+	w.pkg = name
+	return nil
+}
+
+// SetThrottle configures row pacing for subsequent writes
+func (w *GoWriter) SetThrottle(throttle *Throttle) {
+	w.throttle = throttle
+}
+
+// SetEventEmitter configures where each entity's written rows and write time are reported
+func (w *GoWriter) SetEventEmitter(emitter *events.Emitter) {
+	w.events = emitter
+}
+
+// SetFileBufferSize configures how many bytes are buffered per file between writes to storage
+func (w *GoWriter) SetFileBufferSize(bytes int) {
+	w.fileBuffer = bytes
+}
+
+// SetRedactedOutput configures a second copy of the files, written to dir after
+// the full one, with sensitive attributes masked or hashed
+func (w *GoWriter) SetRedactedOutput(dir string) {
+	w.redactedDir = dir
+}
+
+// SetOutputFiles configures how the data files are created, e.g. encrypted
+func (w *GoWriter) SetOutputFiles(files *OutputFiles) {
+	w.files = files
+}
+
+// WriteFiles writes all entity data to Go source files
+func (w *GoWriter) WriteFiles(graph *model.Graph) error {
+	if err := w.files.Layout().checkGoTypeNames(graph); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(w.outputDir, 0750); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	sink := &goSink{outputDir: w.outputDir, pkg: w.pkg, fileBuffer: w.fileBuffer, files: w.files}
+	if err := writeRecords(DependencyOrder(graph), sink, w.throttle, w.events); err != nil {
+		return err
+	}
+	if w.redactedDir == "" {
+		return nil
+	}
+
+	// The redacted copy isn't paced or reported as written rows again
+	redactor, err := NewRedactor(graph)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(w.redactedDir, 0750); err != nil {
+		return fmt.Errorf("failed to create redacted output directory: %w", err)
+	}
+	redacted := redactor.wrap(&goSink{outputDir: w.redactedDir, pkg: w.pkg, fileBuffer: w.fileBuffer, files: w.files})
+	return writeRecords(DependencyOrder(graph), redacted, nil, nil)
+}
+
+// goTypeName returns the Go type declared for an entity's rows, built from its
+// file name, e.g. App/Role:Admin becomes RoleAdmin
+func (l FileLayout) goTypeName(entity model.EntityInterface) string {
+	return goIdentifier(l.entityFileBase(entity.GetExternalID()))
+}
+
+// goFilePath returns the Go file written for an entity, named after its type in
+// lower case so names can't end in _test or a build constraint such as _linux
+func (l FileLayout) goFilePath(entity model.EntityInterface) string {
+	return strings.ToLower(l.goTypeName(entity)) + ".go"
+}
+
+// checkGoTypeNames fails when two entities would declare the same identifier or
+// write the same file, e.g. User and user_ or User and a UserRows entity
+func (l FileLayout) checkGoTypeNames(graph *model.Graph) error {
+	owners := make(map[string]string)
+	claim := func(name string, entity model.EntityInterface) error {
+		if owner, taken := owners[name]; taken && owner != entity.GetExternalID() {
+			return fmt.Errorf("entities %s and %s would both declare Go %s", owner, entity.GetExternalID(), name)
+		}
+		owners[name] = entity.GetExternalID()
+		return nil
+	}
+	for _, entity := range DependencyOrder(graph) {
+		typeName := l.goTypeName(entity)
+		for _, name := range []string{"identifier " + typeName, "identifier " + typeName + "Rows", "file " + l.goFilePath(entity)} {
+			if err := claim(name, entity); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// goIdentifier turns a name into an exported Go identifier: letters and digits
+// are kept, each run of other characters starts a new capitalized word, and a
+// name that can't start an exported identifier gets an X prefix
+func goIdentifier(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	identifier := b.String()
+	if first, _ := utf8.DecodeRuneInString(identifier); !unicode.IsUpper(first) {
+		identifier = "X" + identifier
+	}
+	return identifier
+}
+
+// goFieldNames returns the struct field of each header, numbering repeats
+// (Name, Name2) when two external IDs give the same identifier
+func goFieldNames(headers []string) []string {
+	fields := make([]string, len(headers))
+	used := make(map[string]bool, len(headers))
+	for i, header := range headers {
+		base := goIdentifier(header)
+		field := base
+		for n := 2; used[field]; n++ {
+			field = base + strconv.Itoa(n)
+		}
+		used[field] = true
+		fields[i] = field
+	}
+	return fields
+}
+
+// goSink writes each entity's records to <type>.go
+type goSink struct {
+	outputDir  string
+	pkg        string
+	fileBuffer int          // Bytes buffered between writes to the file; 0 uses DefaultWriteFileBuffer
+	files      *OutputFiles // Creates the file; nil writes it in the clear
+	file       io.WriteCloser
+	writer     *bufio.Writer
+	filename   string
+	filePath   string
+	fields     []string
+	rows       int
+}
+
+func (s *goSink) begin(entity model.EntityInterface, headers []string) error {
+	s.filename = s.files.FileName(s.files.Layout().goFilePath(entity))
+	s.filePath = filepath.Join(s.outputDir, s.filename)
+	s.fields = goFieldNames(headers)
+	s.rows = 0
+
+	file, err := s.files.create(s.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", s.filePath, err)
+	}
+	s.file = file
+	s.writer = bufio.NewWriterSize(file, fileBufferSize(s.fileBuffer))
+
+	// Field types are aligned the way gofmt aligns them
+	width := 0
+	for _, field := range s.fields {
+		width = max(width, utf8.RuneCountInString(field))
+	}
+	typeName := s.files.Layout().goTypeName(entity)
+	fmt.Fprintf(s.writer, "// Code generated by fabricator. DO NOT EDIT.\n\npackage %s\n\n", s.pkg)
+	fmt.Fprintf(s.writer, "// %s is a row of the %s entity\ntype %s struct {\n", typeName, entity.GetExternalID(), typeName)
+	for i, field := range s.fields {
+		fmt.Fprintf(s.writer, "\t%s%s string // %s\n", field, strings.Repeat(" ", width-utf8.RuneCountInString(field)), strings.Join(strings.Fields(headers[i]), " "))
+	}
+	fmt.Fprintf(s.writer, "}\n\n// %sRows holds the generated %s rows\nvar %sRows = []%s{", typeName, entity.GetExternalID(), typeName, typeName)
+	return nil
+}
+
+func (s *goSink) write(record []string, flush bool) error {
+	var b strings.Builder
+	b.WriteString("\n\t{")
+	for i, field := range s.fields {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(field)
+		b.WriteString(": ")
+		b.WriteString(strconv.Quote(record[i]))
+	}
+	b.WriteString("},")
+
+	if _, err := s.writer.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to write row to %s: %w", s.filePath, err)
+	}
+	if flush {
+		if err := s.writer.Flush(); err != nil {
+			return fmt.Errorf("failed to write row to %s: %w", s.filePath, err)
+		}
+	}
+	s.rows++
+	return nil
+}
+
+func (s *goSink) end() error {
+	closing := "}\n"
+	if s.rows > 0 {
+		closing = "\n}\n"
+	}
+	_, err := s.writer.WriteString(closing)
+	if flushErr := s.writer.Flush(); err == nil {
+		err = flushErr
+	}
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	s.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.filePath, err)
+	}
+
+	console.ClearLine()
+	color.Green(console.Text("✓ Generated %s with %d rows"), s.filename, s.rows)
+	return nil
+}
+
+func (s *goSink) close() {
+	if s.file != nil {
+		_ = s.file.Close()
+		s.file = nil
+	}
+}
@@ -0,0 +1,102 @@
+package pipeline
+
+import (
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	sorparser "github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoFixtureOutput(t *testing.T) {
+	generate := func(t *testing.T, def *sorparser.SORDefinition, pkg string) (*model.Graph, string, error) {
+		t.Helper()
+		graphInterface, err := model.NewGraph(def, 12)
+		require.NoError(t, err)
+		graph := graphInterface.(*model.Graph)
+		dir := t.TempDir()
+		generator := NewDataGenerator(dir, map[string]int{"User": 12, "Group": 3, "user_": 3}, false)
+		require.NoError(t, generator.SetOutputFormat(OutputFormatGo))
+		if pkg != "" {
+			require.NoError(t, generator.SetGoPackage(pkg))
+		}
+		return graph, dir, generator.Generate(graph)
+	}
+
+	t.Run("files are gofmt-formatted Go declaring each entity's rows", func(t *testing.T) {
+		graph, dir, err := generate(t, partialInputDefinition(), "testdata")
+		require.NoError(t, err)
+
+		for _, entity := range graph.GetEntitiesList() {
+			path := filepath.Join(dir, FileLayout{}.goFilePath(entity))
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			formatted, err := format.Source(content)
+			require.NoError(t, err)
+			assert.Equal(t, string(formatted), string(content), "%s is not gofmt-formatted", path)
+
+			file, err := parser.ParseFile(token.NewFileSet(), path, content, parser.ParseComments)
+			require.NoError(t, err)
+			assert.Equal(t, "testdata", file.Name.Name)
+			assert.True(t, ast.IsGenerated(file))
+
+			typeName := FileLayout{}.goTypeName(entity)
+			assert.NotNil(t, file.Scope.Lookup(typeName), "type %s", typeName)
+			rows := file.Scope.Lookup(typeName + "Rows")
+			require.NotNil(t, rows, "var %sRows", typeName)
+			literal := rows.Decl.(*ast.ValueSpec).Values[0].(*ast.CompositeLit)
+			assert.Len(t, literal.Elts, entity.GetRowCount())
+		}
+
+		manifest := NewManifest(graph, OutputFormatGo, nil)
+		require.Len(t, manifest.Files, 2)
+		assert.Equal(t, "user.go", manifest.Files[0].File)
+	})
+
+	t.Run("entities declaring the same type fail", func(t *testing.T) {
+		def := partialInputDefinition()
+		group := def.Entities["group"]
+		group.ExternalId = "user_"
+		def.Entities["group"] = group
+		rel := def.Relationships["group_owner"]
+		rel.FromAttribute = "user_.ownerId"
+		def.Relationships["group_owner"] = rel
+
+		_, _, err := generate(t, def, "")
+		assert.ErrorContains(t, err, "would both declare Go identifier User")
+	})
+
+	t.Run("invalid package name", func(t *testing.T) {
+		generator := NewDataGenerator(t.TempDir(), nil, false)
+		require.NoError(t, generator.SetOutputFormat(OutputFormatGo))
+		assert.ErrorContains(t, generator.SetGoPackage("my-fixtures"), "invalid Go package name")
+
+		require.NoError(t, generator.SetOutputFormat(OutputFormatCSV))
+		assert.ErrorContains(t, generator.SetGoPackage("fixtures"), "applies only to the go output format")
+	})
+}
+
+func TestGoIdentifiers(t *testing.T) {
+	tests := map[string]string{
+		"id":            "Id",
+		"user_id":       "UserId",
+		"emailAddress":  "EmailAddress",
+		"Role:Admin":    "RoleAdmin",
+		"2fa-enabled":   "X2faEnabled",
+		"__":            "X",
+		"département":   "Département",
+		"name.given ok": "NameGivenOk",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, goIdentifier(name), name)
+	}
+
+	assert.Equal(t, []string{"UserId", "UserId2", "Name"}, goFieldNames([]string{"user_id", "userId", "name"}))
+}
@@ -0,0 +1,76 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// hierarchicalCodeGenerator returns the attribute's hierarchicalCode generator hint,
+// or nil if it has none
+func hierarchicalCodeGenerator(attr model.AttributeInterface) *parser.Generator {
+	if generator := attr.GetGenerator(); generator != nil && generator.Type == parser.GeneratorHierarchicalCode {
+		return generator
+	}
+	return nil
+}
+
+// hierarchicalCodeValue returns the code for the row at index. Each top-level code
+// (ORG-01) roots a tree of Depth levels with Branching children per code
+// (ORG-01-01 ... ORG-01-03, then ORG-01-01-01 ...). Rows fill one tree breadth-first
+// before starting the next, so codes are unique and every code's parent belongs to
+// an earlier row.
+func hierarchicalCodeValue(generator *parser.Generator, index int) string {
+	// Codes per tree; counting stops once a partial tree holds the index, which
+	// keeps deep or wide hierarchies from overflowing
+	perTree, levelSize := 1, 1
+	for level := 1; level < generator.Depth && perTree <= index; level++ {
+		levelSize *= generator.Branching
+		perTree += levelSize
+	}
+	tree, offset := index/perTree, index%perTree
+
+	segments := []string{fmt.Sprintf("%0*d", generator.Padding, tree+1)}
+	if offset > 0 {
+		// Find the level holding the offset and the position within that level
+		position, level, levelSize := offset-1, 1, generator.Branching
+		for position >= levelSize {
+			position -= levelSize
+			levelSize *= generator.Branching
+			level++
+		}
+
+		// The position's base-branching digits are the child number at each level
+		children := make([]string, level)
+		for i := level - 1; i >= 0; i-- {
+			children[i] = fmt.Sprintf("%0*d", generator.Padding, position%generator.Branching+1)
+			position /= generator.Branching
+		}
+		segments = append(segments, children...)
+	}
+
+	if generator.Prefix != "" {
+		segments = append([]string{generator.Prefix}, segments...)
+	}
+	return strings.Join(segments, "-")
+}
+
+// hierarchicalParentCode returns the parent of a code produced by generator, or ""
+// for top-level codes and values that aren't codes of this generator
+func hierarchicalParentCode(generator *parser.Generator, code string) string {
+	levels := code
+	if generator.Prefix != "" {
+		levels = strings.TrimPrefix(code, generator.Prefix+"-")
+		if levels == code {
+			return ""
+		}
+	}
+
+	last := strings.LastIndex(levels, "-")
+	if last < 0 {
+		return ""
+	}
+	return code[:len(code)-len(levels)+last]
+}
@@ -0,0 +1,110 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHierarchicalCodeValue(t *testing.T) {
+	orgs := &parser.Generator{Prefix: "ORG", Branching: 3, Depth: 3, Padding: 2}
+
+	var codes []string
+	for i := 0; i < 15; i++ {
+		codes = append(codes, hierarchicalCodeValue(orgs, i))
+	}
+	assert.Equal(t, []string{
+		"ORG-01",
+		"ORG-01-01", "ORG-01-02", "ORG-01-03",
+		"ORG-01-01-01", "ORG-01-01-02", "ORG-01-01-03",
+		"ORG-01-02-01", "ORG-01-02-02", "ORG-01-02-03",
+		"ORG-01-03-01", "ORG-01-03-02", "ORG-01-03-03",
+		"ORG-02", "ORG-02-01",
+	}, codes, "each tree fills breadth-first before the next top-level code")
+
+	tests := []struct {
+		name      string
+		generator parser.Generator
+		index     int
+		expected  string
+	}{
+		{name: "single level", generator: parser.Generator{Prefix: "CC", Branching: 5, Depth: 1, Padding: 3}, index: 4, expected: "CC-005"},
+		{name: "no prefix or padding", generator: parser.Generator{Branching: 2, Depth: 2}, index: 2, expected: "1-2"},
+		{name: "deep and wide", generator: parser.Generator{Prefix: "ORG", Branching: 100, Depth: 40, Padding: 2}, index: 10_101, expected: "ORG-01-01-01-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, hierarchicalCodeValue(&tt.generator, tt.index))
+		})
+	}
+}
+
+func TestHierarchicalParentCode(t *testing.T) {
+	orgs := &parser.Generator{Prefix: "ORG", Branching: 3, Depth: 3, Padding: 2}
+	assert.Equal(t, "ORG-01-03", hierarchicalParentCode(orgs, "ORG-01-03-07"))
+	assert.Equal(t, "ORG-01", hierarchicalParentCode(orgs, "ORG-01-03"))
+	assert.Equal(t, "", hierarchicalParentCode(orgs, "ORG-01"), "top-level codes have no parent")
+	assert.Equal(t, "", hierarchicalParentCode(orgs, "CC-01-02"), "values of another format have no parent")
+
+	unprefixed := &parser.Generator{Branching: 3, Depth: 3}
+	assert.Equal(t, "1-2", hierarchicalParentCode(unprefixed, "1-2-3"))
+	assert.Equal(t, "", hierarchicalParentCode(unprefixed, "1"))
+}
+
+func TestHierarchicalCodeGeneration(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "HR",
+		Description: "SOR with an org unit hierarchy",
+		Entities: map[string]parser.Entity{
+			"orgUnit": {
+				DisplayName: "OrgUnit",
+				ExternalId:  "OrgUnit",
+				Attributes: []parser.Attribute{
+					{Name: "code", ExternalId: "code", Type: "String", UniqueId: true,
+						Generator: &parser.Generator{Type: parser.GeneratorHierarchicalCode, Prefix: "ORG", Branching: 2, Depth: 3, Padding: 2}},
+					{Name: "parentCode", ExternalId: "parentCode", Type: "String"},
+					{Name: "costCenter", ExternalId: "costCenter", Type: "String",
+						Generator: &parser.Generator{Type: parser.GeneratorHierarchicalCode, Prefix: "CC", Branching: 4, Depth: 2, Padding: 3}},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"parent": {Name: "parent", FromAttribute: "OrgUnit.parentCode", ToAttribute: "OrgUnit.code"},
+		},
+	}
+
+	graphInterface, err := model.NewGraph(def, 10)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+
+	require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"OrgUnit": 10}))
+	require.NoError(t, NewRelationshipLinker().LinkRelationships(graph, false))
+	require.NoError(t, NewFieldGenerator().GenerateFields(graph))
+
+	orgUnit, _ := graph.GetEntity("OrgUnit")
+	require.Equal(t, 10, orgUnit.GetRowCount())
+
+	expected := []struct{ code, parent, costCenter string }{
+		{"ORG-01", "", "CC-001"},
+		{"ORG-01-01", "ORG-01", "CC-001-001"},
+		{"ORG-01-02", "ORG-01", "CC-001-002"},
+		{"ORG-01-01-01", "ORG-01-01", "CC-001-003"},
+		{"ORG-01-01-02", "ORG-01-01", "CC-001-004"},
+		{"ORG-01-02-01", "ORG-01-02", "CC-002"},
+		{"ORG-01-02-02", "ORG-01-02", "CC-002-001"},
+		{"ORG-02", "", "CC-002-002"},
+		{"ORG-02-01", "ORG-02", "CC-002-003"},
+		{"ORG-02-02", "ORG-02", "CC-002-004"},
+	}
+	for i, want := range expected {
+		row := orgUnit.GetRowByIndex(i)
+		assert.Equal(t, want.code, row.GetValue("code"), "row %d code", i)
+		assert.Equal(t, want.parent, row.GetValue("parentCode"), "row %d parent", i)
+		assert.Equal(t, want.costCenter, row.GetValue("costCenter"), "row %d cost center", i)
+	}
+
+	assert.Empty(t, NewValidation().ValidateRelationships(graph), "every parent code exists")
+}
@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// ID formats a primary key's values can be generated in
+const (
+	IDFormatUUID     = "uuid"     // Random UUIDs
+	IDFormatSequence = "sequence" // Row numbers from 1
+)
+
+// idFormatTypePrefix marks a rule pattern that matches the attribute's type instead
+// of its external ID
+const idFormatTypePrefix = "type:"
+
+// IDFormatRule picks the format of primary keys matching Pattern: either
+// "type:<Type>" for the attribute's type, or a glob over the attribute's external
+// ID, compared case-insensitively
+type IDFormatRule struct {
+	Pattern string
+	Format  string
+}
+
+// DefaultIDFormats generates integer primary keys as sequences, and string primary
+// keys named like GUIDs or UUIDs as UUIDs. Other primary keys are UUIDs.
+var DefaultIDFormats = []IDFormatRule{
+	{Pattern: "type:Integer", Format: IDFormatSequence},
+	{Pattern: "type:Int64", Format: IDFormatSequence},
+	{Pattern: "*guid", Format: IDFormatUUID},
+	{Pattern: "*uuid", Format: IDFormatUUID},
+}
+
+// ParseIDFormats parses ID format rules of the form "type:Integer=sequence,*Guid=uuid".
+// Rules keep their order, as the first matching rule wins.
+func ParseIDFormats(spec string) ([]IDFormatRule, error) {
+	var rules []IDFormatRule
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		pattern, format, found := strings.Cut(pair, "=")
+		pattern, format = strings.TrimSpace(pattern), strings.TrimSpace(format)
+		if !found || pattern == "" || pattern == idFormatTypePrefix {
+			return nil, fmt.Errorf("invalid ID format rule '%s': expected <pattern>=<format>", pair)
+		}
+		if format != IDFormatUUID && format != IDFormatSequence {
+			return nil, fmt.Errorf("invalid ID format '%s' for '%s' (supported: %s, %s)", format, pattern, IDFormatUUID, IDFormatSequence)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid ID format pattern '%s': %w", pattern, err)
+		}
+		rules = append(rules, IDFormatRule{Pattern: pattern, Format: format})
+	}
+	return rules, nil
+}
+
+// matches returns whether the rule applies to attr
+func (r IDFormatRule) matches(attr model.AttributeInterface) bool {
+	if dataType, isType := strings.CutPrefix(r.Pattern, idFormatTypePrefix); isType {
+		return strings.EqualFold(dataType, attr.GetDataType())
+	}
+	matched, _ := path.Match(strings.ToLower(r.Pattern), strings.ToLower(attr.GetExternalID()))
+	return matched
+}
+
+// idFormat returns the format of the first rule matching the primary key, checking
+// rules before DefaultIDFormats, or IDFormatUUID when none does
+func idFormat(primaryKey model.AttributeInterface, rules []IDFormatRule) string {
+	for _, ruleSet := range [][]IDFormatRule{rules, DefaultIDFormats} {
+		for _, rule := range ruleSet {
+			if rule.matches(primaryKey) {
+				return rule.Format
+			}
+		}
+	}
+	return IDFormatUUID
+}
+
+// idSequenceValue returns the sequential ID of the row at index
+func idSequenceValue(index int) string {
+	return strconv.Itoa(index + 1)
+}
@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDFormats(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "ID Formats",
+		Entities: map[string]parser.Entity{
+			"account": {DisplayName: "Account", ExternalId: "Account",
+				Attributes: []parser.Attribute{{Name: "id", ExternalId: "id", Type: "Integer", UniqueId: true}}},
+			"device": {DisplayName: "Device", ExternalId: "Device",
+				Attributes: []parser.Attribute{{Name: "deviceGuid", ExternalId: "deviceGuid", Type: "String", UniqueId: true}}},
+			"ticket": {DisplayName: "Ticket", ExternalId: "Ticket",
+				Attributes: []parser.Attribute{{Name: "ticketNumber", ExternalId: "ticketNumber", Type: "String", UniqueId: true}}},
+		},
+	}
+	generate := func(t *testing.T, rules []IDFormatRule) map[string][]string {
+		t.Helper()
+		graphInterface, err := model.NewGraph(def, 3)
+		require.NoError(t, err)
+		graph := graphInterface.(*model.Graph)
+
+		generator := NewIDGenerator().(*IDGenerator)
+		generator.SetIDFormats(rules)
+		require.NoError(t, generator.GenerateIDs(graph, map[string]int{"Account": 3, "Device": 3, "Ticket": 3}))
+
+		ids := make(map[string][]string)
+		for _, entity := range graph.GetEntitiesList() {
+			for i := 0; i < entity.GetRowCount(); i++ {
+				ids[entity.GetExternalID()] = append(ids[entity.GetExternalID()], entity.GetRowByIndex(i).GetValue(entity.GetPrimaryKey().GetName()))
+			}
+		}
+		return ids
+	}
+
+	t.Run("defaults follow the type and GUID names", func(t *testing.T) {
+		ids := generate(t, nil)
+		assert.Equal(t, []string{"1", "2", "3"}, ids["Account"])
+		for _, entity := range []string{"Device", "Ticket"} {
+			for _, id := range ids[entity] {
+				_, err := uuid.Parse(id)
+				assert.NoError(t, err, "%s ID %s", entity, id)
+			}
+		}
+	})
+
+	t.Run("configured rules come before the defaults", func(t *testing.T) {
+		rules, err := ParseIDFormats("*number=sequence, type:Integer=uuid")
+		require.NoError(t, err)
+		ids := generate(t, rules)
+		assert.Equal(t, []string{"1", "2", "3"}, ids["Ticket"])
+		_, err = uuid.Parse(ids["Account"][0])
+		assert.NoError(t, err)
+	})
+}
+
+func TestParseIDFormats(t *testing.T) {
+	rules, err := ParseIDFormats("type:Int64=sequence,*Key=uuid,")
+	require.NoError(t, err)
+	assert.Equal(t, []IDFormatRule{
+		{Pattern: "type:Int64", Format: IDFormatSequence},
+		{Pattern: "*Key", Format: IDFormatUUID},
+	}, rules)
+
+	for spec, message := range map[string]string{
+		"*Id":          "expected <pattern>=<format>",
+		"type:=uuid":   "expected <pattern>=<format>",
+		"*Id=number":   "invalid ID format 'number'",
+		"[Id=sequence": "invalid ID format pattern",
+		"=sequence":    "expected <pattern>=<format>",
+	} {
+		_, err := ParseIDFormats(spec)
+		assert.ErrorContains(t, err, message, spec)
+	}
+}
@@ -3,13 +3,20 @@ package pipeline
 import (
 	"fmt"
 
+	"github.com/SGNL-ai/fabricator/pkg/console"
 	"github.com/SGNL-ai/fabricator/pkg/generators/model"
-	"github.com/google/uuid"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/brianvoe/gofakeit/v6"
 )
 
 // IDGenerator handles the generation of entity IDs in topological order
 type IDGenerator struct {
-	// Configuration options can be added here
+	allowEmpty bool            // Entities with a row count of 0 are left empty instead of rejected
+	formats    []IDFormatRule  // Primary key formats, checked before DefaultIDFormats
+	topUp      map[string]bool // Entities whose existing rows count toward their row count
+	keys       *KeyRegistry    // Keys earlier runs generated, never generated anew
+	keyReuse   float64         // Share of each entity's rows taking keys from keys
+	streams    *RandomStreams  // Streams random keys are drawn from; nil draws them at random
 }
 
 // NewIDGenerator creates a new ID generator
@@ -17,6 +24,37 @@ func NewIDGenerator() IDGeneratorInterface {
 	return &IDGenerator{}
 }
 
+// SetAllowEmpty configures whether a row count of 0 leaves the entity empty
+// (written as a header-only file) instead of failing generation
+func (g *IDGenerator) SetAllowEmpty(allow bool) {
+	g.allowEmpty = allow
+}
+
+// SetIDFormats configures the rules picking each primary key's format; they are
+// checked before DefaultIDFormats
+func (g *IDGenerator) SetIDFormats(rules []IDFormatRule) {
+	g.formats = rules
+}
+
+// SetTopUp configures entities, by external ID, whose existing rows (e.g. fixtures)
+// count toward their row count: they get only the rows left over instead of none
+func (g *IDGenerator) SetTopUp(entityIDs map[string]bool) {
+	g.topUp = entityIDs
+}
+
+// SetKeyRegistry configures the keys earlier runs generated: reuse × rows of each
+// entity's rows take keys drawn from the registry, and the other rows get keys the
+// registry doesn't hold
+func (g *IDGenerator) SetKeyRegistry(registry *KeyRegistry, reuse float64) {
+	g.keys = registry
+	g.keyReuse = reuse
+}
+
+// SetRandomStreams draws each entity's random keys from its own stream of streams
+func (g *IDGenerator) SetRandomStreams(streams *RandomStreams) {
+	g.streams = streams
+}
+
 // GenerateIDs generates unique IDs for all entities in topological order.
 // rowCounts maps entity external_id to the number of rows to generate.
 func (g *IDGenerator) GenerateIDs(graph *model.Graph, rowCounts map[string]int) error {
@@ -29,13 +67,24 @@ func (g *IDGenerator) GenerateIDs(graph *model.Graph, rowCounts map[string]int)
 	}
 
 	// Generate IDs for each entity
-	for _, entity := range graph.GetAllEntities() {
+	for _, entity := range graph.GetEntitiesList() {
 		// Find the unique ID attribute (primary key)
 		primaryKey := entity.GetPrimaryKey()
 		if primaryKey == nil {
 			continue // Skip entities without primary keys
 		}
 
+		// Entities already populated (e.g. from partial input CSVs) keep their rows
+		existing := entity.GetRowCount()
+		if existing > 0 && !g.topUp[entity.GetExternalID()] {
+			continue
+		}
+
+		// Derived entities get their rows once the others are generated
+		if entity.GetDerived() != nil {
+			continue
+		}
+
 		// Get row count for this entity
 		entityID := entity.GetExternalID()
 		count, exists := rowCounts[entityID]
@@ -43,27 +92,101 @@ func (g *IDGenerator) GenerateIDs(graph *model.Graph, rowCounts map[string]int)
 			return fmt.Errorf("no row count specified for entity %s", entityID)
 		}
 
+		if count == 0 && g.allowEmpty {
+			continue
+		}
+
 		if count <= 0 {
 			return fmt.Errorf("row count for entity %s must be greater than 0, got %d", entityID, count)
 		}
 
+		// Fixtures can fill the whole count
+		if existing >= count {
+			continue
+		}
+
 		// Show progress for current entity (no newline, will be overwritten)
-		fmt.Printf("\r%-80s\r→ Generating %s (%d rows)...", "", entity.GetName(), count)
+		console.Progress("→ Generating %s (%d rows)...", entity.GetName(), count)
 
-		// Generate the specified number of rows with unique IDs
-		for i := 0; i < count; i++ {
-			// Create row with just the primary key
-			rowData := map[string]string{
-				primaryKey.GetName(): uuid.New().String(),
-			}
+		// Primary keys with a sequence or hierarchicalCode generator hint use those
+		// values; others take the format of their type or external ID
+		sequence := sequenceGenerator(primaryKey)
+		hierarchy := hierarchicalCodeGenerator(primaryKey)
+		format := idFormat(primaryKey, g.formats)
+
+		// Each entity's random keys are drawn from its own stream, so they don't change
+		// when entities are added to or removed from the SOR
+		faker := g.streams.stream("ids:" + entityID)
 
-			// Add row to entity (AddRow will validate uniqueness)
-			err := entity.AddRow(model.NewRow(rowData))
-			if err != nil {
-				return fmt.Errorf("failed to add row to entity %s: %w", entity.GetExternalID(), err)
+		// Generate the rows the existing ones leave over, skipping keys they hold, and
+		// add them in one batch; some can take keys retired by earlier runs
+		rows := make([]*model.Row, 0, count-existing)
+		added := existing
+		for _, id := range g.keys.reusable(entityID, g.keyReuse, count-existing, faker) {
+			if existing > 0 && entity.CheckKeyExists(id) {
+				continue
+			}
+			added++
+			rows = append(rows, model.NewRow(map[string]string{
+				primaryKey.GetName(): id,
+			}))
+		}
+		for i := 0; added < count; i++ {
+			id := keyValue(sequence, hierarchy, format, faker, i)
+			if (existing > 0 && entity.CheckKeyExists(id)) || g.keys.Contains(entityID, id) {
+				continue
 			}
+			added++
+
+			// Create row with just the primary key
+			rows = append(rows, model.NewRow(map[string]string{
+				primaryKey.GetName(): id,
+			}))
+		}
+
+		// Add rows to entity (AddRows will validate uniqueness)
+		if err := entity.AddRows(rows); err != nil {
+			return fmt.Errorf("failed to add row to entity %s: %w", entity.GetExternalID(), err)
 		}
 	}
 
 	return nil
 }
+
+// KeySource returns a function giving entity's primary keys one at a time, for rows
+// loaded without one such as partial input rows. Keys take the format GenerateIDs
+// gives them but are drawn from a stream of their own, so filling them in doesn't
+// change the keys generated for other rows; keys taken reports as held are skipped.
+func (g *IDGenerator) KeySource(entity model.EntityInterface, taken func(string) bool) func() string {
+	primaryKey := entity.GetPrimaryKey()
+	entityID := entity.GetExternalID()
+	sequence := sequenceGenerator(primaryKey)
+	hierarchy := hierarchicalCodeGenerator(primaryKey)
+	format := idFormat(primaryKey, g.formats)
+	faker := g.streams.stream("partial-ids:" + entityID)
+
+	i := 0
+	return func() string {
+		for ; ; i++ {
+			id := keyValue(sequence, hierarchy, format, faker, i)
+			if !taken(id) && !g.keys.Contains(entityID, id) {
+				i++
+				return id
+			}
+		}
+	}
+}
+
+// keyValue returns the ith key of a primary key with the given generator hints and
+// format, drawing random keys from faker
+func keyValue(sequence, hierarchy *parser.Generator, format string, faker *gofakeit.Faker, i int) string {
+	switch {
+	case sequence != nil:
+		return sequenceValue(sequence, i)
+	case hierarchy != nil:
+		return hierarchicalCodeValue(hierarchy, i)
+	case format == IDFormatSequence:
+		return idSequenceValue(i)
+	}
+	return faker.UUID()
+}
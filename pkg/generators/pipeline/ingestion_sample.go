@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// IngestionSamplesDir is the subdirectory of the output directory holding sample
+// ingestion payloads
+const IngestionSamplesDir = "ingestion-samples"
+
+// IngestionSample is one page of an entity's objects shaped like the response an
+// SGNL ingestion adapter returns: attributes keyed by external ID with values of
+// their declared type
+type IngestionSample struct {
+	Entity     string           `json:"entity"` // Entity external ID
+	Objects    []map[string]any `json:"objects"`
+	NextCursor string           `json:"nextCursor"` // Always empty: samples are a single page
+}
+
+// NewIngestionSample builds a payload from the first rows of entity
+func NewIngestionSample(entity model.EntityInterface, rows int) *IngestionSample {
+	sample := &IngestionSample{Entity: entity.GetExternalID(), Objects: []map[string]any{}}
+	attributes := entity.GetAttributes()
+	for i := 0; i < min(rows, entity.GetRowCount()); i++ {
+		row := entity.GetRowByIndex(i)
+		object := make(map[string]any, len(attributes))
+		for _, attr := range attributes {
+			// Adapters leave out attributes without a value
+			value := row.GetValue(attr.GetName())
+			if value == "" {
+				continue
+			}
+			object[attr.GetExternalID()] = ingestionValue(attr.GetDataType(), value)
+		}
+		sample.Objects = append(sample.Objects, object)
+	}
+	return sample
+}
+
+// ingestionValue converts a generated value to the JSON type an adapter sends for
+// the attribute type. Values that don't parse (e.g. edge cases) stay strings so
+// mapping problems remain visible.
+func ingestionValue(dataType, value string) any {
+	switch dataType {
+	case "Integer", "Int64":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "Float", "Double":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "Boolean", "Bool":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// WriteIngestionSamples writes a payload with up to rows objects for every entity
+// of the graph under dir, named like the entity's data file and created by files.
+// Returns the number of files written.
+func WriteIngestionSamples(graph *model.Graph, dir string, rows int, files *OutputFiles) (int, error) {
+	written := 0
+	for _, entity := range DependencyOrder(graph) {
+		path := filepath.Join(dir, files.FileName(files.Layout().entityFilePath(entity, ".json")))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			return written, fmt.Errorf("failed to create ingestion sample directory: %w", err)
+		}
+
+		content, err := json.MarshalIndent(NewIngestionSample(entity, rows), "", "  ")
+		if err != nil {
+			return written, fmt.Errorf("failed to encode ingestion sample for %s: %w", entity.GetExternalID(), err)
+		}
+		if err := files.write(path, append(content, '\n')); err != nil {
+			return written, fmt.Errorf("failed to write ingestion sample for %s: %w", entity.GetExternalID(), err)
+		}
+		written++
+	}
+	return written, nil
+}
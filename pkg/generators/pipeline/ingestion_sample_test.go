@@ -0,0 +1,74 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestionSamples(t *testing.T) {
+	value := func(v string) *string { return &v }
+	def := &parser.SORDefinition{
+		DisplayName: "Ingestion SOR",
+		Description: "SOR with typed attributes",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User",
+				ExternalId:  "Okta/User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "userId", Type: "String", UniqueId: true},
+					{Name: "logins", ExternalId: "loginCount", Type: "Integer", Const: value("42")},
+					{Name: "score", ExternalId: "riskScore", Type: "Float", Const: value("0.5")},
+					{Name: "active", ExternalId: "isActive", Type: "Boolean", Const: value("true")},
+					{Name: "joined", ExternalId: "joinedOn", Type: "Date", Const: value("2024-01-02")},
+					{Name: "nickname", ExternalId: "nickname", Type: "String", Const: value("")},
+				},
+			},
+		},
+	}
+
+	t.Run("objects use external IDs and typed values", func(t *testing.T) {
+		graph := buildGraphWithIDs(t, def, 5)
+		require.NoError(t, NewFieldGenerator().GenerateFields(graph))
+		entity, _ := graph.GetEntity("User")
+
+		sample := NewIngestionSample(entity, 2)
+		assert.Equal(t, "Okta/User", sample.Entity)
+		require.Len(t, sample.Objects, 2)
+
+		object := sample.Objects[0]
+		assert.Equal(t, entity.GetRowByIndex(0).GetValue("id"), object["userId"])
+		assert.Equal(t, int64(42), object["loginCount"])
+		assert.Equal(t, 0.5, object["riskScore"])
+		assert.Equal(t, true, object["isActive"])
+		assert.Equal(t, "2024-01-02", object["joinedOn"])
+		assert.NotContains(t, object, "nickname", "empty values are omitted")
+	})
+
+	t.Run("unparseable values stay strings", func(t *testing.T) {
+		assert.Equal(t, "", ingestionValue("Integer", ""))
+		assert.Equal(t, "9999999999999999999999", ingestionValue("Int64", "9999999999999999999999"))
+		assert.Equal(t, "yes", ingestionValue("Boolean", "yes"))
+	})
+
+	t.Run("one file per entity under the samples directory", func(t *testing.T) {
+		graph := buildGraphWithIDs(t, def, 5)
+		require.NoError(t, NewFieldGenerator().GenerateFields(graph))
+
+		dir := filepath.Join(t.TempDir(), IngestionSamplesDir)
+		written, err := WriteIngestionSamples(graph, dir, 10, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, written)
+
+		content, err := os.ReadFile(filepath.Join(dir, "User.json"))
+		require.NoError(t, err)
+		var sample IngestionSample
+		require.NoError(t, json.Unmarshal(content, &sample))
+		assert.Len(t, sample.Objects, 5, "samples are capped at the entity's row count")
+	})
+}
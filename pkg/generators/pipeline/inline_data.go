@@ -0,0 +1,46 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// loadInlineData seeds the entities whose SOR embeds rows with those rows, pinned
+// like partial input so later pipeline stages keep them and only fill in the
+// attributes a row leaves out. The ID generator then skips these entities, whatever
+// their configured row count.
+func loadInlineData(graph *model.Graph) error {
+	for _, entity := range graph.GetEntitiesList() {
+		data := entity.GetInlineData()
+		if len(data) == 0 || entity.GetRowCount() > 0 {
+			continue
+		}
+		if err := addPinnedRows(entity, data, "data row"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addPinnedRows adds rows keyed by attribute name or externalId to entity, pinning
+// their values. label names a row in errors, e.g. "data row".
+func addPinnedRows(entity model.EntityInterface, rows []map[string]string, label string) error {
+	for i, values := range rows {
+		rowData := make(map[string]string, len(entity.GetAttributes()))
+		for key, value := range values {
+			attr, exists := entity.GetAttributeByExternalID(key)
+			if !exists {
+				attr, exists = entity.GetAttribute(key)
+			}
+			if !exists {
+				return fmt.Errorf("entity %s %s %d sets unknown attribute '%s'", entity.GetExternalID(), label, i+1, key)
+			}
+			rowData[attr.GetName()] = value
+		}
+		if err := entity.AddRow(model.NewPinnedRow(rowData)); err != nil {
+			return fmt.Errorf("invalid %s %d of entity %s: %w", label, i+1, entity.GetExternalID(), err)
+		}
+	}
+	return nil
+}
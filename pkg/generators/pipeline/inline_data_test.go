@@ -0,0 +1,61 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInlineData(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Inline",
+		Entities: map[string]parser.Entity{
+			"tier": {
+				DisplayName: "Tier", ExternalId: "Tier",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "name", ExternalId: "displayName", Type: "String"},
+					{Name: "rank", ExternalId: "rank", Type: "Integer"},
+				},
+				Data: []map[string]string{
+					{"id": "1", "displayName": "Bronze", "rank": "1"},
+					{"id": "2", "name": "Silver"},
+				},
+			},
+			"user": {
+				DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "tierId", ExternalId: "tierId", Type: "String"},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"user_tier": {DisplayName: "User Tier", Name: "user_tier", FromAttribute: "User.tierId", ToAttribute: "Tier.id"},
+		},
+	}
+	graph, err := model.NewGraph(def, 10)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	generator := NewDataGenerator(dir, map[string]int{"Tier": 50, "User": 10}, false)
+	require.NoError(t, generator.Generate(graph.(*model.Graph)))
+
+	tiers := readCSV(t, filepath.Join(dir, "Tier.csv"))
+	require.Len(t, tiers, 3, "the embedded rows replace the configured count")
+	assert.Equal(t, []string{"id", "displayName", "rank"}, tiers[0])
+	assert.Equal(t, []string{"1", "Bronze", "1"}, tiers[1])
+	assert.Equal(t, "2", tiers[2][0])
+	assert.Equal(t, "Silver", tiers[2][1], "values can be keyed by attribute name")
+	assert.NotEmpty(t, tiers[2][2], "attributes a row leaves out are generated")
+
+	users := readCSV(t, filepath.Join(dir, "User.csv"))
+	require.Len(t, users, 11)
+	for _, user := range users[1:] {
+		assert.Contains(t, []string{"1", "2"}, user[1], "foreign keys reference the embedded rows")
+	}
+}
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInverseRelationshipPairs(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Inverse SOR",
+		Description: "SOR declaring both directions of a relationship",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User",
+				ExternalId:  "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "groupId", ExternalId: "groupId", Type: "String"},
+				},
+			},
+			"group": {
+				DisplayName: "Group",
+				ExternalId:  "Group",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"group_users": {Name: "group_users", FromAttribute: "Group.id", ToAttribute: "User.groupId"},
+			"user_group":  {Name: "user_group", FromAttribute: "User.groupId", ToAttribute: "Group.id"},
+		},
+	}
+
+	t.Run("every target is referenced so both directions hold", func(t *testing.T) {
+		graph := buildGraphWithIDs(t, def, 20)
+		group, _ := graph.GetEntity("Group")
+		require.NoError(t, group.RemoveRow(0)) // Fewer groups than users
+		require.NoError(t, NewRelationshipLinker().LinkRelationships(graph, true))
+
+		user, _ := graph.GetEntity("User")
+		referenced := make(map[string]bool)
+		for i := 0; i < user.GetRowCount(); i++ {
+			referenced[user.GetRowByIndex(i).GetValue("groupId")] = true
+		}
+		for i := 0; i < group.GetRowCount(); i++ {
+			assert.True(t, referenced[group.GetRowByIndex(i).GetValue("id")], "group %d has no users", i)
+		}
+
+		relationship, _ := graph.GetRelationship("user_group")
+		assert.Empty(t, validateRelationship(relationship))
+	})
+
+	t.Run("validation reports targets the inverse can't reach", func(t *testing.T) {
+		graph := buildGraphWithIDs(t, def, 3)
+		require.NoError(t, NewRelationshipLinker().LinkRelationships(graph, false))
+
+		user, _ := graph.GetEntity("User")
+		user.GetRowByIndex(2).SetValue("groupId", user.GetRowByIndex(1).GetValue("groupId"))
+
+		relationship, _ := graph.GetRelationship("user_group")
+		issues := validateRelationship(relationship)
+		require.Len(t, issues, 1)
+		assert.Contains(t, issues[0], "inverse relationship group_users requires")
+	})
+}
@@ -0,0 +1,359 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/console"
+	"github.com/SGNL-ai/fabricator/pkg/events"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/fatih/color"
+)
+
+// Supported output formats for generated rows
+const (
+	OutputFormatCSV   = "csv"
+	OutputFormatJSONL = "jsonl"
+	OutputFormatGo    = "go"
+	OutputFormatNeo4j = "neo4j"
+)
+
+// Shapes of the lines of JSON lines output
+const (
+	JSONLShapeMessage = "message" // A RowMessage with the row's values as strings
+	JSONLShapeRow     = "row"     // The row itself, with values of their attribute's type
+)
+
+// JSONLShapes lists the accepted shapes of JSON lines output
+var JSONLShapes = []string{JSONLShapeMessage, JSONLShapeRow}
+
+// RowMessage is a single generated row in JSON lines output, shaped like a
+// message for a streaming platform: the topic is the entity and the key is its primary key
+type RowMessage struct {
+	Topic string            `json:"topic"`
+	Key   string            `json:"key"`
+	Value map[string]string `json:"value"`
+}
+
+// JSONLWriter writes each entity's rows as JSON messages, one file per entity.
+// Entities are written in dependency order so a consumer replaying the files
+// in write order never sees a foreign key before the row it references; for that
+// reason files are written one at a time.
+type JSONLWriter struct {
+	outputDir  string
+	throttle   *Throttle // Optional row pacing; nil writes as fast as possible
+	fileBuffer int       // Bytes buffered per file; 0 uses DefaultWriteFileBuffer
+	shape      string    // JSONLShapeMessage or JSONLShapeRow; empty writes messages
+
+	// Directory of a copy with sensitive attributes redacted; empty writes none
+	redactedDir string
+
+	// Creates the data files, e.g. encrypted; nil writes them in the clear
+	files *OutputFiles
+
+	// Observability
+	events *events.Emitter
+}
+
+// NewJSONLWriter creates a new JSON lines writer
+func NewJSONLWriter(outputDir string) CSVWriterInterface {
+	return &JSONLWriter{
+		outputDir: outputDir,
+	}
+}
+
+// SetShape configures what each line holds, one of JSONLShapes
+func (w *JSONLWriter) SetShape(shape string) error {
+	if !slices.Contains(JSONLShapes, shape) {
+		return fmt.Errorf("unsupported JSON lines shape '%s' (supported: %s)", shape, strings.Join(JSONLShapes, ", "))
+	}
+	w.shape = shape
+	return nil
+}
+
+// SetThrottle configures row pacing for subsequent writes
+func (w *JSONLWriter) SetThrottle(throttle *Throttle) {
+	w.throttle = throttle
+}
+
+// SetEventEmitter configures where each entity's written rows and write time are reported
+func (w *JSONLWriter) SetEventEmitter(emitter *events.Emitter) {
+	w.events = emitter
+}
+
+// SetFileBufferSize configures how many bytes are buffered per file between writes to storage
+func (w *JSONLWriter) SetFileBufferSize(bytes int) {
+	w.fileBuffer = bytes
+}
+
+// SetRedactedOutput configures a second copy of the files, written to dir after
+// the full one, with sensitive attributes masked or hashed
+func (w *JSONLWriter) SetRedactedOutput(dir string) {
+	w.redactedDir = dir
+}
+
+// SetOutputFiles configures how the data files are created, e.g. encrypted
+func (w *JSONLWriter) SetOutputFiles(files *OutputFiles) {
+	w.files = files
+}
+
+// WriteFiles writes all entity data to JSON lines files
+func (w *JSONLWriter) WriteFiles(graph *model.Graph) error {
+	if err := os.MkdirAll(w.outputDir, 0750); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	if err := writeRecords(DependencyOrder(graph), w.sink(w.outputDir), w.throttle, w.events); err != nil {
+		return err
+	}
+	if w.redactedDir == "" {
+		return nil
+	}
+
+	// The redacted copy isn't paced or reported as written rows again
+	redactor, err := NewRedactor(graph)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(w.redactedDir, 0750); err != nil {
+		return fmt.Errorf("failed to create redacted output directory: %w", err)
+	}
+	redacted := redactor.wrap(w.sink(w.redactedDir))
+	return writeRecords(DependencyOrder(graph), redacted, nil, nil)
+}
+
+// sink returns a sink writing entities' files, or their partitions' files, to outputDir
+func (w *JSONLWriter) sink(outputDir string) recordSink {
+	return newPartitionSink(&jsonlSink{outputDir: outputDir, fileBuffer: w.fileBuffer, files: w.files, shape: w.shape}, func(file string) recordSink {
+		return &jsonlSink{outputDir: outputDir, fileBuffer: w.fileBuffer, files: w.files, shape: w.shape, path: file}
+	}, ".jsonl", w.files.Layout(), !w.files.Encrypted())
+}
+
+// jsonlSink writes each entity's records to <entity>.jsonl
+type jsonlSink struct {
+	outputDir  string
+	fileBuffer int          // Bytes buffered between writes to the file; 0 uses DefaultWriteFileBuffer
+	files      *OutputFiles // Creates the file; nil writes it in the clear
+	shape      string       // JSONLShapeMessage or JSONLShapeRow; empty writes messages
+	path       string       // File written instead of the entity's own, e.g. one of its partitions
+	file       io.WriteCloser
+	writer     *bufio.Writer
+	encoder    *json.Encoder
+	filename   string
+	filePath   string
+	topic      string
+	headers    []string
+	attributes []model.AttributeInterface // Types of the record's values, for rows
+	keyColumn  int
+	rows       int
+}
+
+func (s *jsonlSink) begin(entity model.EntityInterface, headers []string) error {
+	s.topic = entity.GetExternalID()
+	s.filename = s.path
+	if s.filename == "" {
+		s.filename = s.files.Layout().entityFilePath(entity, ".jsonl")
+	}
+	s.filename = s.files.FileName(s.filename)
+	s.filePath = filepath.Join(s.outputDir, s.filename)
+	if err := os.MkdirAll(filepath.Dir(s.filePath), 0750); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", s.filePath, err)
+	}
+	s.headers = headers
+	s.attributes = entity.GetAttributes()
+	s.rows = 0
+
+	file, err := s.files.create(s.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", s.filePath, err)
+	}
+	s.file = file
+	s.writer = bufio.NewWriterSize(file, fileBufferSize(s.fileBuffer))
+	s.encoder = json.NewEncoder(s.writer)
+
+	// Locate the primary key column for message keys
+	s.keyColumn = -1
+	if pk := entity.GetPrimaryKey(); pk != nil {
+		for i, header := range headers {
+			if header == pk.GetExternalID() {
+				s.keyColumn = i
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (s *jsonlSink) write(record []string, flush bool) error {
+	if s.shape == JSONLShapeRow {
+		if err := s.writeRow(record); err != nil {
+			return fmt.Errorf("failed to write row to %s: %w", s.filePath, err)
+		}
+	} else {
+		message := RowMessage{Topic: s.topic, Value: make(map[string]string, len(record))}
+		for i, header := range s.headers {
+			message.Value[header] = record[i]
+		}
+		if s.keyColumn >= 0 {
+			message.Key = record[s.keyColumn]
+		}
+		if err := s.encoder.Encode(message); err != nil {
+			return fmt.Errorf("failed to write row to %s: %w", s.filePath, err)
+		}
+	}
+	if flush {
+		if err := s.writer.Flush(); err != nil {
+			return fmt.Errorf("failed to write row to %s: %w", s.filePath, err)
+		}
+	}
+	s.rows++
+	return nil
+}
+
+// writeRow writes a record as one JSON object whose keys follow the header order,
+// so every line of a file lists the same keys in the same order
+func (s *jsonlSink) writeRow(record []string) error {
+	line := []byte{'{'}
+	for i, header := range s.headers {
+		if i > 0 {
+			line = append(line, ',')
+		}
+		key, _ := json.Marshal(header)
+		value, err := json.Marshal(rowValue(s.attributes[i], record[i]))
+		if err != nil {
+			// Numbers JSON can't hold, such as NaN, stay strings
+			value, _ = json.Marshal(record[i])
+		}
+		line = append(append(append(line, key...), ':'), value...)
+	}
+	_, err := s.writer.Write(append(line, '}', '\n'))
+	return err
+}
+
+// rowValue converts a generated value to JSON of its attribute's type: numbers and
+// booleans unquoted (see ingestionValue), JSON and rows-encoded lists as arrays of
+// such values, and no value as null
+func rowValue(attr model.AttributeInterface, value string) any {
+	if value == "" {
+		return nil
+	}
+	encoding := attr.GetListEncoding()
+	if encoding == parser.ListEncodingJSON || encoding == parser.ListEncodingRows {
+		if values, err := decodeJSONList(value); err == nil {
+			list := make([]any, len(values))
+			for i, v := range values {
+				list[i] = ingestionValue(attr.GetDataType(), v)
+			}
+			return list
+		}
+	}
+	return ingestionValue(attr.GetDataType(), value)
+}
+
+func (s *jsonlSink) end() error {
+	// A suspended file is already written out
+	if s.file != nil {
+		if err := s.suspend(); err != nil {
+			return err
+		}
+	}
+
+	console.ClearLine()
+	color.Green(console.Text("✓ Generated %s with %d rows"), s.filename, s.rows)
+	return nil
+}
+
+// suspend writes out the buffered records and closes the file
+func (s *jsonlSink) suspend() error {
+	err := s.writer.Flush()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	s.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.filePath, err)
+	}
+	return nil
+}
+
+// resume reopens a suspended file to append the entity's further records
+func (s *jsonlSink) resume() error {
+	file, err := s.files.reopen(s.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to reopen file %s: %w", s.filePath, err)
+	}
+	s.file = file
+	s.writer = bufio.NewWriterSize(file, fileBufferSize(s.fileBuffer))
+	s.encoder = json.NewEncoder(s.writer)
+	return nil
+}
+
+func (s *jsonlSink) close() {
+	if s.file != nil {
+		_ = s.file.Close()
+		s.file = nil
+	}
+}
+
+// DependencyOrder returns entities ordered so that every entity comes after the
+// entities its foreign keys reference. Ties are broken by the order the graph's
+// SetEntityOrder configured, or by external ID without one, and entities caught in a
+// reference cycle are appended in that order.
+func DependencyOrder(graph *model.Graph) []model.EntityInterface {
+	entities := graph.GetEntitiesList()
+	if !graph.HasEntityOrder() {
+		sort.Slice(entities, func(i, j int) bool {
+			return entities[i].GetExternalID() < entities[j].GetExternalID()
+		})
+	}
+
+	// Count unresolved references per entity (source entities hold the foreign keys)
+	pending := make(map[string]int, len(entities))
+	dependents := make(map[string][]string)
+	for _, relationship := range graph.GetAllRelationships() {
+		source := relationship.GetSourceEntity().GetExternalID()
+		target := relationship.GetTargetEntity().GetExternalID()
+		if source == target {
+			continue
+		}
+		pending[source]++
+		dependents[target] = append(dependents[target], source)
+	}
+
+	ordered := make([]model.EntityInterface, 0, len(entities))
+	done := make(map[string]bool, len(entities))
+	for len(ordered) < len(entities) {
+		progressed := false
+		for _, entity := range entities {
+			id := entity.GetExternalID()
+			if done[id] || pending[id] > 0 {
+				continue
+			}
+			done[id] = true
+			ordered = append(ordered, entity)
+			for _, dependent := range dependents[id] {
+				pending[dependent]--
+			}
+			progressed = true
+		}
+
+		// Break a cycle by releasing the first remaining entity
+		if !progressed {
+			for _, entity := range entities {
+				if id := entity.GetExternalID(); !done[id] {
+					pending[id] = 0
+					break
+				}
+			}
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,220 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/events"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readJSONL(t *testing.T, path string) []RowMessage {
+	t.Helper()
+	file, err := os.Open(path) // #nosec G304 - test file path
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	var messages []RowMessage
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var message RowMessage
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &message))
+		messages = append(messages, message)
+	}
+	require.NoError(t, scanner.Err())
+	return messages
+}
+
+func TestJSONLWriter_WriteFiles(t *testing.T) {
+	outputDir := t.TempDir()
+
+	graphInterface, err := model.NewGraph(partialInputDefinition(), 10)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+
+	generator := NewDataGenerator(outputDir, map[string]int{"User": 3, "Group": 2}, false)
+	require.NoError(t, generator.SetOutputFormat(OutputFormatJSONL))
+	require.NoError(t, generator.Generate(graph))
+
+	users := readJSONL(t, filepath.Join(outputDir, "User.jsonl"))
+	require.Len(t, users, 3)
+	userIDs := make(map[string]bool)
+	for _, message := range users {
+		assert.Equal(t, "User", message.Topic)
+		assert.Equal(t, message.Value["id"], message.Key, "message key is the primary key")
+		assert.Contains(t, message.Value, "email")
+		userIDs[message.Key] = true
+	}
+
+	groups := readJSONL(t, filepath.Join(outputDir, "Group.jsonl"))
+	require.Len(t, groups, 2)
+	for _, message := range groups {
+		assert.Equal(t, "Group", message.Topic)
+		assert.True(t, userIDs[message.Value["ownerId"]], "foreign keys reference written users")
+	}
+
+	_, err = os.Stat(filepath.Join(outputDir, "User.csv"))
+	assert.True(t, os.IsNotExist(err), "no CSV files are written in JSONL mode")
+}
+
+func TestJSONLWriter_RowShape(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Typed SOR",
+		Entities: map[string]parser.Entity{
+			"account": {
+				DisplayName: "Account", ExternalId: "Account",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "logins", ExternalId: "logins", Type: "Integer"},
+					{Name: "score", ExternalId: "score", Type: "Float"},
+					{Name: "active", ExternalId: "active", Type: "Boolean"},
+					{Name: "ports", ExternalId: "ports", Type: "Integer", List: true, ListEncoding: parser.ListEncodingJSON},
+					{Name: "note", ExternalId: "note", Type: "String"},
+				},
+			},
+		},
+	}
+	outputDir := t.TempDir()
+	graphInterface, err := model.NewGraph(def, 3)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	generator := NewDataGenerator(outputDir, map[string]int{"Account": 3}, false)
+	require.NoError(t, generator.SetOutputFormat(OutputFormatJSONL))
+	require.NoError(t, generator.SetJSONLShape(JSONLShapeRow))
+	require.NoError(t, generator.Generate(graph))
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "Account.jsonl")) // #nosec G304 - test file path
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	require.Len(t, lines, 3)
+	for _, line := range lines {
+		assert.True(t, strings.HasPrefix(line, `{"id":`), "keys follow the attribute order")
+		var row map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &row))
+		assert.IsType(t, "", row["id"])
+		assert.IsType(t, float64(0), row["logins"])
+		assert.IsType(t, float64(0), row["score"])
+		assert.IsType(t, true, row["active"])
+		require.IsType(t, []any{}, row["ports"])
+		for _, port := range row["ports"].([]any) {
+			assert.IsType(t, float64(0), port)
+		}
+	}
+
+	account, _ := graph.GetEntity("Account")
+	attrs := account.GetAttributes()
+	assert.Nil(t, rowValue(attrs[5], ""), "no value is null")
+	assert.Equal(t, "n/a", rowValue(attrs[1], "n/a"), "a value that doesn't parse stays a string")
+
+	csvGenerator := NewDataGenerator(outputDir, nil, false)
+	assert.EqualError(t, csvGenerator.SetJSONLShape(JSONLShapeRow), "a JSON lines shape applies only to the jsonl output format")
+	assert.EqualError(t, generator.SetJSONLShape("table"), "unsupported JSON lines shape 'table' (supported: message, row)")
+}
+
+func TestDataGenerator_SetOutputFormat(t *testing.T) {
+	generator := NewDataGenerator(t.TempDir(), nil, false)
+	assert.NoError(t, generator.SetOutputFormat(""))
+	assert.NoError(t, generator.SetOutputFormat(OutputFormatCSV))
+	assert.NoError(t, generator.SetOutputFormat(OutputFormatJSONL))
+	assert.Error(t, generator.SetOutputFormat("parquet"))
+}
+
+func TestDependencyOrder(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Order SOR",
+		Description: "SOR used for dependency order tests",
+		Entities: map[string]parser.Entity{
+			"assignment": {
+				DisplayName: "Assignment",
+				ExternalId:  "Assignment",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "groupId", ExternalId: "groupId", Type: "String"},
+					{Name: "userId", ExternalId: "userId", Type: "String"},
+				},
+			},
+			"group": {
+				DisplayName: "Group",
+				ExternalId:  "Group",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "ownerId", ExternalId: "ownerId", Type: "String"},
+				},
+			},
+			"user": {
+				DisplayName: "User",
+				ExternalId:  "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"assignment_group": {Name: "assignment_group", FromAttribute: "Assignment.groupId", ToAttribute: "Group.id"},
+			"assignment_user":  {Name: "assignment_user", FromAttribute: "Assignment.userId", ToAttribute: "User.id"},
+			"group_owner":      {Name: "group_owner", FromAttribute: "Group.ownerId", ToAttribute: "User.id"},
+		},
+	}
+
+	graphInterface, err := model.NewGraph(def, 10)
+	require.NoError(t, err)
+
+	var order []string
+	for _, entity := range DependencyOrder(graphInterface.(*model.Graph)) {
+		order = append(order, entity.GetExternalID())
+	}
+	assert.Equal(t, []string{"User", "Group", "Assignment"}, order)
+}
+
+func TestJSONLWriter_EntityOrder(t *testing.T) {
+	write := func(t *testing.T, order []string) ([]string, []string) {
+		graphInterface, err := model.NewGraph(cacheTestDefinition(), 10)
+		require.NoError(t, err)
+		graph := graphInterface.(*model.Graph)
+		if order != nil {
+			require.NoError(t, graph.SetEntityOrder(order))
+		}
+
+		var log bytes.Buffer
+		generator := NewDataGenerator(t.TempDir(), map[string]int{"Group": 2, "User": 3, "Application": 1}, false)
+		require.NoError(t, generator.SetOutputFormat(OutputFormatJSONL))
+		emitter := events.NewEmitter(events.NewJSONLinesSink(&log))
+		generator.SetEventEmitter(emitter)
+		require.NoError(t, generator.Generate(graph))
+		require.NoError(t, emitter.Close())
+
+		var written []string
+		for _, line := range strings.Split(strings.TrimSpace(log.String()), "\n") {
+			var event events.Event
+			require.NoError(t, json.Unmarshal([]byte(line), &event))
+			if event.Type == events.RowsWritten {
+				written = append(written, event.Entity)
+			}
+		}
+		var manifest []string
+		for _, file := range NewManifest(graph, OutputFormatJSONL, nil).Files {
+			manifest = append(manifest, file.File)
+		}
+		return written, manifest
+	}
+
+	t.Run("External ID order without an entity order", func(t *testing.T) {
+		written, manifest := write(t, nil)
+		assert.Equal(t, []string{"Application", "Group", "User"}, written)
+		assert.Equal(t, []string{"Application.jsonl", "Group.jsonl", "User.jsonl"}, manifest)
+	})
+
+	t.Run("Entity order breaks ties between independent entities", func(t *testing.T) {
+		written, manifest := write(t, []string{"Group", "User"})
+		assert.Equal(t, []string{"Group", "User", "Application"}, written)
+		assert.Equal(t, []string{"Group.jsonl", "User.jsonl", "Application.jsonl"}, manifest)
+	})
+}
@@ -0,0 +1,44 @@
+package pipeline
+
+import "github.com/SGNL-ai/fabricator/pkg/generators/model"
+
+// junctionPairRelationship returns the last of the entity's relationships whose
+// foreign key pairs with an earlier one's, referencing the same attribute, or nil
+// when the entity declares no junction options
+func junctionPairRelationship(entity model.EntityInterface, relationships []model.RelationshipInterface) model.RelationshipInterface {
+	if entity.GetJunction() == nil {
+		return nil
+	}
+	var pair model.RelationshipInterface
+	seen := make(map[string]bool)
+	for _, relationship := range relationships {
+		target := relationship.GetTargetEntity().GetID() + "." + relationship.GetTargetAttribute().GetName()
+		if seen[target] {
+			pair = relationship
+		}
+		seen[target] = true
+	}
+	return pair
+}
+
+// repairJunctionPair moves the row's pair foreign key to the first target key,
+// after the row's own position, that neither pairs a key with itself nor repeats a
+// registered pair. It returns false, leaving the row as it was, when none does.
+func repairJunctionPair(entity model.EntityInterface, relationship model.RelationshipInterface, row *model.Row, rowIndex int) bool {
+	attrName := relationship.GetSourceAttribute().GetName()
+	if row.IsPinned(attrName) {
+		return false
+	}
+	target := relationship.GetTargetEntity()
+	targetName := relationship.GetTargetAttribute().GetName()
+	original := row.GetValue(attrName)
+	count := target.GetRowCount()
+	for offset := 1; offset <= count; offset++ {
+		row.SetValue(attrName, target.GetRowByIndex((rowIndex+offset)%count).GetValue(targetName))
+		if !entity.IsSelfPair(row) && !entity.IsCompositeKeyRegistered(row) {
+			return true
+		}
+	}
+	row.SetValue(attrName, original)
+	return false
+}
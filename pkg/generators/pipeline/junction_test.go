@@ -0,0 +1,97 @@
+package pipeline
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// peersDefinition builds users and a user_peers junction table with two keys into User
+func peersDefinition(junction *parser.Junction) *parser.SORDefinition {
+	return &parser.SORDefinition{
+		DisplayName: "Peers",
+		Entities: map[string]parser.Entity{
+			"user": {DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{{Name: "id", ExternalId: "id", Type: "String", UniqueId: true}}},
+			"peers": {DisplayName: "UserPeer", ExternalId: "UserPeer",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "userId", ExternalId: "userId", Type: "String"},
+					{Name: "peerId", ExternalId: "peerId", Type: "String"},
+				},
+				Junction: junction},
+		},
+		Relationships: map[string]parser.Relationship{
+			"peer_user": {Name: "peer_user", FromAttribute: "UserPeer.userId", ToAttribute: "User.id"},
+			"peer_peer": {Name: "peer_peer", FromAttribute: "UserPeer.peerId", ToAttribute: "User.id"},
+		},
+	}
+}
+
+func TestJunctionPairs(t *testing.T) {
+	link := func(t *testing.T, junction *parser.Junction, autoCardinality bool) [][2]string {
+		t.Helper()
+		graphInterface, err := model.NewGraph(peersDefinition(junction), 30)
+		require.NoError(t, err)
+		graph := graphInterface.(*model.Graph)
+		require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"User": 6, "UserPeer": 30}))
+		require.NoError(t, NewRelationshipLinker().LinkRelationships(graph, autoCardinality))
+
+		peers, _ := graph.GetEntity("UserPeer")
+		var pairs [][2]string
+		for i := 0; i < peers.GetRowCount(); i++ {
+			row := peers.GetRowByIndex(i)
+			pairs = append(pairs, [2]string{row.GetValue("userId"), row.GetValue("peerId")})
+		}
+		return pairs
+	}
+
+	t.Run("round robin pairs each user with itself by default", func(t *testing.T) {
+		pairs := link(t, nil, false)
+		require.Len(t, pairs, 6, "repeated pairs are dropped")
+		for _, pair := range pairs {
+			assert.Equal(t, pair[0], pair[1])
+		}
+	})
+
+	for _, autoCardinality := range []bool{false, true} {
+		t.Run(fmt.Sprintf("unordered pairs without self-pairs (auto cardinality %t)", autoCardinality), func(t *testing.T) {
+			pairs := link(t, &parser.Junction{Unordered: true, NoSelfPairs: true}, autoCardinality)
+			// Six users form 15 unordered pairs of distinct users
+			assert.Len(t, pairs, 15)
+			seen := make(map[[2]string]bool)
+			for _, pair := range pairs {
+				assert.NotEqual(t, pair[0], pair[1], "self-pair")
+				assert.False(t, seen[pair] || seen[[2]string{pair[1], pair[0]}], "pair %v repeats", pair)
+				seen[pair] = true
+			}
+		})
+	}
+
+	t.Run("ordered pairs may mirror each other", func(t *testing.T) {
+		pairs := link(t, &parser.Junction{NoSelfPairs: true}, false)
+		assert.Len(t, pairs, 30)
+		mirrored := false
+		seen := make(map[[2]string]bool)
+		for _, pair := range pairs {
+			assert.NotEqual(t, pair[0], pair[1], "self-pair")
+			mirrored = mirrored || seen[[2]string{pair[1], pair[0]}]
+			seen[pair] = true
+		}
+		assert.True(t, mirrored)
+	})
+
+	t.Run("junction options need paired keys", func(t *testing.T) {
+		def := peersDefinition(nil)
+		user := def.Entities["user"]
+		user.Junction = &parser.Junction{Unordered: true}
+		def.Entities["user"] = user
+		_, err := model.NewGraph(def, 10)
+		require.ErrorIs(t, err, model.ErrInvalidEntity)
+		assert.ErrorContains(t, err, "entity User has junction options but no two foreign keys referencing the same attribute")
+	})
+}
@@ -0,0 +1,249 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// keyRegistryVersion is the format version of the key registry file. Version 1
+// files, listing keys as JSON strings, are still read.
+const keyRegistryVersion = 2
+
+// KeyRegistry remembers the primary keys earlier runs generated, per entity, so a
+// series of runs producing snapshots of the same SOR can retire keys for good or
+// bring some of them back. Keys in the registry are never generated anew; a share
+// of each entity's rows can instead take keys from it (see IDGenerator.SetKeyRegistry).
+//
+// Keys are held sorted, searched by binary search, and written sorted with each
+// key's prefix shared with the one before it left out and the result compressed,
+// so a registry of millions of keys stays a fraction of their size on disk.
+type KeyRegistry struct {
+	Version int
+
+	entities map[string][]string // Sorted keys per entity external ID
+}
+
+// keyRegistryFile is the JSON form of a key registry
+type keyRegistryFile struct {
+	Version  int                        `json:"version"`
+	Entities map[string]json.RawMessage `json:"entities"` // A keySet per entity; a list of keys in version 1
+}
+
+// keySet is the JSON form of an entity's keys
+type keySet struct {
+	Count int    `json:"count"`
+	Keys  []byte `json:"keys"` // Front-coded, compressed sorted keys (see encodeKeys); base64 in JSON
+}
+
+// NewKeyRegistry creates an empty key registry
+func NewKeyRegistry() *KeyRegistry {
+	return &KeyRegistry{
+		Version:  keyRegistryVersion,
+		entities: make(map[string][]string),
+	}
+}
+
+// LoadKeyRegistry reads a key registry file; a file that doesn't exist yet gives
+// an empty registry, as for the first snapshot of a series
+func LoadKeyRegistry(path string) (*KeyRegistry, error) {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewKeyRegistry(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key registry: %w", err)
+	}
+	var file keyRegistryFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse key registry %s: %w", path, err)
+	}
+	if file.Version != keyRegistryVersion && file.Version != 1 {
+		return nil, fmt.Errorf("unsupported key registry version %d in %s (expected %d)", file.Version, path, keyRegistryVersion)
+	}
+
+	registry := NewKeyRegistry()
+	for entityID, raw := range file.Entities {
+		var keys []string
+		if file.Version == 1 {
+			err = json.Unmarshal(raw, &keys)
+			slices.Sort(keys)
+			keys = slices.Compact(keys)
+		} else {
+			var set keySet
+			if err = json.Unmarshal(raw, &set); err == nil {
+				keys, err = decodeKeys(set.Keys, set.Count)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key registry %s: entity %s: %w", path, entityID, err)
+		}
+		registry.entities[entityID] = keys
+	}
+	return registry, nil
+}
+
+// WriteKeyRegistry writes the key registry as JSON, each entity's keys encoded by
+// encodeKeys
+func WriteKeyRegistry(path string, registry *KeyRegistry) error {
+	file := keyRegistryFile{Version: registry.Version, Entities: make(map[string]json.RawMessage, len(registry.entities))}
+	for entityID, keys := range registry.entities {
+		encoded, err := encodeKeys(keys)
+		if err != nil {
+			return fmt.Errorf("failed to encode key registry: %w", err)
+		}
+		if file.Entities[entityID], err = json.Marshal(keySet{Count: len(keys), Keys: encoded}); err != nil {
+			return fmt.Errorf("failed to encode key registry: %w", err)
+		}
+	}
+	content, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to encode key registry: %w", err)
+	}
+	if err := os.WriteFile(path, append(content, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write key registry: %w", err)
+	}
+	return nil
+}
+
+// encodeKeys front-codes sorted keys, writing for each the length of the prefix it
+// shares with the key before it, the length of the rest, and the rest, then
+// compresses them with DEFLATE
+func encodeKeys(keys []string) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer, err := flate.NewWriter(&buffer, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	varint := make([]byte, binary.MaxVarintLen64)
+	previous := ""
+	for _, key := range keys {
+		shared := 0
+		for shared < len(previous) && shared < len(key) && previous[shared] == key[shared] {
+			shared++
+		}
+		_, _ = writer.Write(varint[:binary.PutUvarint(varint, uint64(shared))])
+		_, _ = writer.Write(varint[:binary.PutUvarint(varint, uint64(len(key)-shared))])
+		if _, err := writer.Write([]byte(key[shared:])); err != nil {
+			return nil, err
+		}
+		previous = key
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// decodeKeys reads count keys written by encodeKeys, failing unless they are
+// sorted and distinct
+func decodeKeys(data []byte, count int) ([]string, error) {
+	reader := bufio.NewReader(flate.NewReader(bytes.NewReader(data)))
+	keys := make([]string, 0, count)
+	previous := ""
+	for range count {
+		shared, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return nil, fmt.Errorf("truncated keys: %w", err)
+		}
+		rest, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return nil, fmt.Errorf("truncated keys: %w", err)
+		}
+		if shared > uint64(len(previous)) || rest > uint64(len(data))*maxDeflateRatio {
+			return nil, fmt.Errorf("corrupt keys")
+		}
+		suffix := make([]byte, rest)
+		if _, err := io.ReadFull(reader, suffix); err != nil {
+			return nil, fmt.Errorf("truncated keys: %w", err)
+		}
+		key := previous[:shared] + string(suffix)
+		if len(keys) > 0 && key <= previous {
+			return nil, fmt.Errorf("keys are not sorted")
+		}
+		keys = append(keys, key)
+		previous = key
+	}
+	return keys, nil
+}
+
+// maxDeflateRatio bounds how many bytes DEFLATE can expand one byte of input to,
+// so a corrupt length can't allocate more than the file could hold
+const maxDeflateRatio = 1032
+
+// Contains reports whether an earlier run generated key for the entity
+func (r *KeyRegistry) Contains(entityID, key string) bool {
+	if r == nil {
+		return false
+	}
+	_, found := slices.BinarySearch(r.entities[entityID], key)
+	return found
+}
+
+// Size returns the number of keys the registry holds
+func (r *KeyRegistry) Size() int {
+	if r == nil {
+		return 0
+	}
+	size := 0
+	for _, keys := range r.entities {
+		size += len(keys)
+	}
+	return size
+}
+
+// Keys returns the entity's registered keys, sorted
+func (r *KeyRegistry) Keys(entityID string) []string {
+	if r == nil {
+		return nil
+	}
+	return r.entities[entityID]
+}
+
+// Record adds the primary keys of every entity's rows to the registry and returns
+// how many it didn't hold yet
+func (r *KeyRegistry) Record(graph *model.Graph) int {
+	added := 0
+	for _, entity := range graph.GetEntitiesList() {
+		primaryKey := entity.GetPrimaryKey()
+		if primaryKey == nil {
+			continue
+		}
+		entityID := entity.GetExternalID()
+		var keys []string
+		for i := 0; i < entity.GetRowCount(); i++ {
+			key := entity.GetRowByIndex(i).GetValue(primaryKey.GetName())
+			if key != "" && !r.Contains(entityID, key) {
+				keys = append(keys, key)
+			}
+		}
+		r.entities[entityID] = append(r.entities[entityID], keys...)
+		slices.Sort(r.entities[entityID])
+		added += len(keys)
+	}
+	return added
+}
+
+// reusable draws share × rows of the entity's registered keys, at most all of them,
+// in an order drawn from faker
+func (r *KeyRegistry) reusable(entityID string, share float64, rows int, faker *gofakeit.Faker) []string {
+	if r == nil || share <= 0 {
+		return nil
+	}
+	keys := slices.Clone(r.entities[entityID])
+	faker.ShuffleStrings(keys)
+	return keys[:min(len(keys), int(math.Round(share*float64(rows))))]
+}
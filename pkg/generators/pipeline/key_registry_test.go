@@ -0,0 +1,101 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyRegistry(t *testing.T) {
+	generate := func(t *testing.T, registry *KeyRegistry, reuse float64) *model.Graph {
+		t.Helper()
+		graphInterface, err := model.NewGraph(partialInputDefinition(), 10)
+		require.NoError(t, err)
+		graph := graphInterface.(*model.Graph)
+		generator := NewIDGenerator().(*IDGenerator)
+		generator.SetKeyRegistry(registry, reuse)
+		require.NoError(t, generator.GenerateIDs(graph, map[string]int{"User": 10, "Group": 4}))
+		return graph
+	}
+	keys := func(graph *model.Graph, entityID string) []string {
+		entity, _ := graph.GetEntity(entityID)
+		var keys []string
+		for i := 0; i < entity.GetRowCount(); i++ {
+			keys = append(keys, entity.GetRowByIndex(i).GetValue(entity.GetPrimaryKey().GetName()))
+		}
+		return keys
+	}
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	registry, err := LoadKeyRegistry(path)
+	require.NoError(t, err, "a missing registry starts empty")
+	assert.Zero(t, registry.Size())
+
+	first := generate(t, registry, 0)
+	assert.Equal(t, 14, registry.Record(first))
+	assert.Zero(t, registry.Record(first), "keys are recorded once")
+	require.NoError(t, WriteKeyRegistry(path, registry))
+
+	registry, err = LoadKeyRegistry(path)
+	require.NoError(t, err)
+	assert.Equal(t, 14, registry.Size())
+	assert.ElementsMatch(t, keys(first, "User"), registry.Keys("User"))
+	assert.True(t, slices.IsSorted(registry.Keys("User")))
+
+	t.Run("registered keys are avoided", func(t *testing.T) {
+		second := generate(t, registry, 0)
+		for _, key := range keys(second, "User") {
+			assert.False(t, registry.Contains("User", key), "key %s was used before", key)
+		}
+	})
+
+	t.Run("a share of rows reuses registered keys", func(t *testing.T) {
+		second := generate(t, registry, 0.5)
+		reused := 0
+		for _, key := range keys(second, "User") {
+			if registry.Contains("User", key) {
+				reused++
+			}
+		}
+		assert.Equal(t, 5, reused)
+		assert.Len(t, keys(second, "User"), 10)
+	})
+
+	t.Run("reads version 1 files listing keys", func(t *testing.T) {
+		old := filepath.Join(t.TempDir(), "keys.json")
+		require.NoError(t, os.WriteFile(old, []byte(`{"version":1,"entities":{"User":["user-2","user-1","user-2"]}}`), 0600))
+		registry, err := LoadKeyRegistry(old)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"user-1", "user-2"}, registry.Keys("User"))
+		assert.True(t, registry.Contains("User", "user-2"))
+	})
+
+	t.Run("front-codes keys sharing prefixes", func(t *testing.T) {
+		var sequential []string
+		for i := range 10000 {
+			sequential = append(sequential, fmt.Sprintf("employee-%06d", i))
+		}
+		encoded, err := encodeKeys(sequential)
+		require.NoError(t, err)
+		assert.Less(t, len(encoded), 10000*len("employee-000000")/10)
+		decoded, err := decodeKeys(encoded, len(sequential))
+		require.NoError(t, err)
+		assert.Equal(t, sequential, decoded)
+
+		_, err = decodeKeys(encoded, len(sequential)+1)
+		assert.ErrorContains(t, err, "truncated keys")
+	})
+
+	t.Run("rejects other versions", func(t *testing.T) {
+		other := filepath.Join(t.TempDir(), "keys.json")
+		require.NoError(t, WriteKeyRegistry(other, &KeyRegistry{Version: 9}))
+		_, err := LoadKeyRegistry(other)
+		assert.ErrorContains(t, err, "unsupported key registry version 9")
+	})
+}
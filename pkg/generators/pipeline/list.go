@@ -0,0 +1,154 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/console"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/fatih/color"
+)
+
+// maxListValues is the most values generated for one row of a list attribute
+const maxListValues = 3
+
+// generateListValue generates one to maxListValues values for a list attribute and
+// encodes them as the attribute's list encoding
+func (g *FieldGenerator) generateListValue(attr model.AttributeInterface) string {
+	values := make([]string, g.faker.Number(1, maxListValues))
+	for i := range values {
+		values[i] = g.generateFieldValue(attr)
+	}
+	return encodeList(values, attr.GetDataType(), attr.GetListEncoding())
+}
+
+// encodeList encodes list values for a column. Rows-encoded lists are held as JSON
+// arrays until the CSV writer spreads them over their own file.
+func encodeList(values []string, dataType, encoding string) string {
+	if encoding == parser.ListEncodingSemicolon {
+		escaped := make([]string, len(values))
+		for i, value := range values {
+			escaped[i] = strings.NewReplacer(`\`, `\\`, ";", `\;`).Replace(value)
+		}
+		return strings.Join(escaped, ";")
+	}
+
+	// Numbers and booleans are written as JSON numbers and booleans
+	elements := make([]json.RawMessage, len(values))
+	for i, value := range values {
+		switch dataType {
+		case "Integer", "Int64", "Float", "Double", "Boolean", "Bool":
+			elements[i] = json.RawMessage(value)
+		default:
+			quoted, _ := json.Marshal(value)
+			elements[i] = quoted
+		}
+	}
+	encoded, _ := json.Marshal(elements)
+	return string(encoded)
+}
+
+// decodeJSONList returns the values of a JSON array encoded by encodeList, with
+// strings unquoted and other values as written
+func decodeJSONList(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var elements []json.RawMessage
+	if err := json.Unmarshal([]byte(value), &elements); err != nil {
+		return nil, err
+	}
+	values := make([]string, len(elements))
+	for i, element := range elements {
+		if err := json.Unmarshal(element, &values[i]); err != nil {
+			values[i] = string(element)
+		}
+	}
+	return values, nil
+}
+
+// rowsEncodedAttributes returns the attributes of an entity whose lists are written
+// to their own file
+func rowsEncodedAttributes(entity model.EntityInterface) []model.AttributeInterface {
+	var attributes []model.AttributeInterface
+	for _, attr := range entity.GetAttributes() {
+		if attr.GetListEncoding() == parser.ListEncodingRows {
+			attributes = append(attributes, attr)
+		}
+	}
+	return attributes
+}
+
+// listFilePath returns the path within the output directory of the file holding a
+// rows-encoded attribute's values: <entity>.<attribute>.csv next to the entity's file
+func (l FileLayout) listFilePath(entity model.EntityInterface, attr model.AttributeInterface) string {
+	return l.entityFilePath(entity, "."+l.sanitize(attr.GetExternalID())+".csv")
+}
+
+// writeListFiles writes one CSV file per rows-encoded attribute, created by files,
+// with a row for each value: the owning row's primary key, then the value. Values
+// are redacted by redactor unless it is nil.
+func writeListFiles(graph *model.Graph, outputDir string, fileBuffer int, files *OutputFiles, redactor *Redactor) error {
+	for _, entity := range graph.GetEntitiesList() {
+		pk := entity.GetPrimaryKey()
+		for _, attr := range rowsEncodedAttributes(entity) {
+			filename := files.FileName(files.Layout().listFilePath(entity, attr))
+			filePath := filepath.Join(outputDir, filename)
+			rows, err := writeListFile(entity, pk, attr, filePath, fileBuffer, files, redactor)
+			if err != nil {
+				return err
+			}
+			console.ClearLine()
+			color.Green(console.Text("✓ Generated %s with %d rows"), filename, rows)
+		}
+	}
+	return nil
+}
+
+// writeListFile writes one rows-encoded attribute's values and returns the rows written
+func writeListFile(entity model.EntityInterface, pk, attr model.AttributeInterface, filePath string, fileBuffer int, files *OutputFiles, redactor *Redactor) (int, error) {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0750); err != nil {
+		return 0, fmt.Errorf("failed to create directory for %s: %w", filePath, err)
+	}
+	file, err := files.create(filePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create file %s: %w", filePath, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	buffer := bufio.NewWriterSize(file, fileBufferSize(fileBuffer))
+	writer := csv.NewWriter(buffer)
+	_ = writer.Write([]string{pk.GetExternalID(), attr.GetExternalID()})
+
+	rows := 0
+	for i := 0; i < entity.GetRowCount(); i++ {
+		row := entity.GetRowByIndex(i)
+		values, err := decodeJSONList(row.GetValue(attr.GetName()))
+		if err != nil {
+			return 0, fmt.Errorf("entity %s row %d: %s is not a list: %w", entity.GetExternalID(), i+1, attr.GetName(), err)
+		}
+		key := redactor.redactValue(entity, pk, row.GetValue(pk.GetName()))
+		for _, value := range values {
+			_ = writer.Write([]string{key, redactor.redactValue(entity, attr, value)})
+			rows++
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", filePath, err)
+	}
+	if err := buffer.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", filePath, err)
+	}
+	if err := file.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", filePath, err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,97 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeList(t *testing.T) {
+	assert.Equal(t, `["a","b \"c\""]`, encodeList([]string{"a", `b "c"`}, "String", parser.ListEncodingJSON))
+	assert.Equal(t, `[1,-2]`, encodeList([]string{"1", "-2"}, "Integer", parser.ListEncodingJSON))
+	assert.Equal(t, `[true]`, encodeList([]string{"true"}, "Boolean", parser.ListEncodingRows))
+	assert.Equal(t, `a\;b;c\\d;e`, encodeList([]string{"a;b", `c\d`, "e"}, "String", parser.ListEncodingSemicolon))
+
+	values, err := decodeJSONList(`["a,b",2.5,false]`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a,b", "2.5", "false"}, values)
+
+	values, err = decodeJSONList("")
+	require.NoError(t, err)
+	assert.Empty(t, values)
+
+	_, err = decodeJSONList("a;b")
+	assert.Error(t, err)
+}
+
+func TestListEncodings(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Lists",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "status", ExternalId: "status", Type: "String", List: true, ListEncoding: parser.ListEncodingJSON},
+					{Name: "scores", ExternalId: "scores", Type: "Integer", List: true, ListEncoding: parser.ListEncodingSemicolon},
+					{Name: "objectClass", ExternalId: "objectClass", Type: "String", List: true, ListEncoding: parser.ListEncodingRows},
+					{Name: "title", ExternalId: "title", Type: "String", List: true},
+				},
+			},
+		},
+	}
+	graphInterface, err := model.NewGraph(def, 20)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"User": 20}))
+	require.NoError(t, NewFieldGenerator().GenerateFields(graph))
+
+	user, _ := graph.GetEntity("User")
+	listed := 0
+	for i := 0; i < user.GetRowCount(); i++ {
+		row := user.GetRowByIndex(i)
+
+		statuses, err := decodeJSONList(row.GetValue("status"))
+		require.NoError(t, err)
+		assert.NotEmpty(t, statuses)
+		assert.LessOrEqual(t, len(statuses), maxListValues)
+		for _, status := range statuses {
+			assert.Contains(t, []string{"active", "inactive", "pending"}, status)
+		}
+
+		assert.Regexp(t, `^-?\d+(;-?\d+){0,2}$`, row.GetValue("scores"))
+		assert.NotContains(t, row.GetValue("title"), "[", "lists without an encoding hold one value")
+
+		classes, err := decodeJSONList(row.GetValue("objectClass"))
+		require.NoError(t, err)
+		listed += len(classes)
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, NewCSVWriter(dir).WriteFiles(graph))
+
+	t.Run("rows-encoded columns move to their own file", func(t *testing.T) {
+		content, err := os.ReadFile(filepath.Join(dir, "User.csv"))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(content), "id,status,scores,title\n"))
+
+		content, err = os.ReadFile(filepath.Join(dir, "User.objectClass.csv"))
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		assert.Equal(t, "id,objectClass", lines[0])
+		assert.Len(t, lines, listed+1)
+		assert.True(t, strings.HasPrefix(lines[1], user.GetRowByIndex(0).GetValue("id")+","))
+	})
+
+	t.Run("the manifest lists the list files", func(t *testing.T) {
+		manifest := NewManifest(graph, OutputFormatCSV, nil)
+		assert.Equal(t, map[string]string{"objectClass": "User.objectClass.csv"}, manifest.Files[0].ListFiles)
+		assert.Empty(t, NewManifest(graph, OutputFormatJSONL, nil).Files[0].ListFiles, "JSON lines keep lists in the row")
+	})
+}
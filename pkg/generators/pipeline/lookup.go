@@ -0,0 +1,63 @@
+package pipeline
+
+import (
+	"sort"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// lookupColumn is a lookup attribute with the relationship it copies through
+type lookupColumn struct {
+	entity       model.EntityInterface
+	attr         model.AttributeInterface
+	relationship model.RelationshipInterface
+	depth        int // Lookups followed to reach a generated attribute
+}
+
+// resolveLookups sets each lookup attribute to the value the referenced row holds
+// in the looked-up attribute, once relationships are linked and fields generated.
+// Lookups of lookups are resolved after the attributes they copy. Values supplied
+// by partial input are kept, and rows with an empty or dangling foreign key get an
+// empty value.
+func resolveLookups(graph *model.Graph) {
+	var columns []lookupColumn
+	for _, entity := range graph.GetEntitiesList() {
+		for _, attr := range entity.GetAttributes() {
+			if relationship := graph.LookupRelationship(entity, attr); relationship != nil {
+				columns = append(columns, lookupColumn{entity, attr, relationship, lookupDepth(graph, entity, attr)})
+			}
+		}
+	}
+	sort.SliceStable(columns, func(i, j int) bool { return columns[i].depth < columns[j].depth })
+
+	traversal := graph.NewTraversal()
+	for _, column := range columns {
+		name := column.attr.GetName()
+		copied := column.attr.GetLookup().Attribute
+		foreignKey := column.relationship.GetSourceAttribute().GetName()
+		for i := 0; i < column.entity.GetRowCount(); i++ {
+			row := column.entity.GetRowByIndex(i)
+			if row.IsPinned(name) {
+				continue
+			}
+			value := ""
+			if parent, found := traversal.GetParentRow(column.entity, row, foreignKey); found {
+				value = parent.GetValue(copied)
+			}
+			row.SetValue(name, value)
+		}
+	}
+}
+
+// lookupDepth counts the lookups followed from attr to a generated attribute; the
+// graph has checked that they end
+func lookupDepth(graph *model.Graph, entity model.EntityInterface, attr model.AttributeInterface) int {
+	depth := 0
+	for attr.GetLookup() != nil {
+		relationship := graph.LookupRelationship(entity, attr)
+		entity = relationship.GetTargetEntity()
+		attr, _ = entity.GetAttribute(attr.GetLookup().Attribute)
+		depth++
+	}
+	return depth
+}
@@ -0,0 +1,110 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ticketsDefinition builds users, tickets assigned to them that copy their email,
+// and comments on tickets that copy the ticket's copy
+func ticketsDefinition() *parser.SORDefinition {
+	return &parser.SORDefinition{
+		DisplayName: "Tickets",
+		Entities: map[string]parser.Entity{
+			"user": {DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "email", ExternalId: "email", Type: "String"},
+				}},
+			"ticket": {DisplayName: "Ticket", ExternalId: "Ticket",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "assigneeId", ExternalId: "assigneeId", Type: "String"},
+					{Name: "assigneeEmail", ExternalId: "assigneeEmail", Type: "String",
+						Lookup: &parser.Lookup{ForeignKey: "assigneeId", Attribute: "email"}},
+				}},
+			"comment": {DisplayName: "Comment", ExternalId: "Comment",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "ticketId", ExternalId: "ticketId", Type: "String"},
+					{Name: "notify", ExternalId: "notify", Type: "String",
+						Lookup: &parser.Lookup{ForeignKey: "ticketId", Attribute: "assigneeEmail"}},
+				}},
+		},
+		Relationships: map[string]parser.Relationship{
+			"ticket_assignee": {Name: "ticket_assignee", FromAttribute: "Ticket.assigneeId", ToAttribute: "User.id"},
+			"comment_ticket":  {Name: "comment_ticket", FromAttribute: "Comment.ticketId", ToAttribute: "Ticket.id"},
+		},
+	}
+}
+
+func TestLookups(t *testing.T) {
+	t.Run("lookups copy the referenced row's value, through other lookups", func(t *testing.T) {
+		graph := buildGraphWithIDs(t, ticketsDefinition(), 10)
+		require.NoError(t, NewRelationshipLinker().LinkRelationships(graph, false))
+		require.NoError(t, NewFieldGenerator().GenerateFields(graph))
+		resolveLookups(graph)
+
+		users, _ := graph.GetEntity("User")
+		emails := make(map[string]string)
+		for i := 0; i < users.GetRowCount(); i++ {
+			row := users.GetRowByIndex(i)
+			emails[row.GetValue("id")] = row.GetValue("email")
+		}
+		tickets, _ := graph.GetEntity("Ticket")
+		assignees := make(map[string]string)
+		for i := 0; i < tickets.GetRowCount(); i++ {
+			row := tickets.GetRowByIndex(i)
+			require.NotEmpty(t, row.GetValue("assigneeEmail"))
+			assert.Equal(t, emails[row.GetValue("assigneeId")], row.GetValue("assigneeEmail"))
+			assignees[row.GetValue("id")] = row.GetValue("assigneeEmail")
+		}
+		comments, _ := graph.GetEntity("Comment")
+		for i := 0; i < comments.GetRowCount(); i++ {
+			row := comments.GetRowByIndex(i)
+			require.NotEmpty(t, row.GetValue("notify"))
+			assert.Equal(t, assignees[row.GetValue("ticketId")], row.GetValue("notify"))
+		}
+	})
+
+	tests := []struct {
+		name    string
+		modify  func(def *parser.SORDefinition)
+		wantErr string
+	}{
+		{name: "through an attribute that isn't a foreign key",
+			modify: func(def *parser.SORDefinition) {
+				delete(def.Relationships, "comment_ticket")
+			},
+			wantErr: "entity Comment attribute 'notify' looks up through 'ticketId', which is not a foreign key"},
+		{name: "of an unknown attribute",
+			modify: func(def *parser.SORDefinition) {
+				def.Entities["ticket"].Attributes[2].Lookup = &parser.Lookup{ForeignKey: "assigneeId", Attribute: "mail"}
+			},
+			wantErr: "entity Ticket attribute 'assigneeEmail' looks up unknown attribute 'mail' of User"},
+		{name: "in a cycle",
+			modify: func(def *parser.SORDefinition) {
+				user := def.Entities["user"]
+				user.Attributes = append(user.Attributes,
+					parser.Attribute{Name: "managerId", ExternalId: "managerId", Type: "String"},
+					parser.Attribute{Name: "managerEmail", ExternalId: "managerEmail", Type: "String",
+						Lookup: &parser.Lookup{ForeignKey: "managerId", Attribute: "managerEmail"}})
+				def.Entities["user"] = user
+				def.Relationships["user_manager"] = parser.Relationship{Name: "user_manager", FromAttribute: "User.managerId", ToAttribute: "User.id"}
+			},
+			wantErr: "entity User attribute 'managerEmail' is part of a cycle of lookups"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := ticketsDefinition()
+			tt.modify(def)
+			_, err := model.NewGraph(def, 10)
+			require.ErrorIs(t, err, model.ErrInvalidEntity)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
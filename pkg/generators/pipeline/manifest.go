@@ -0,0 +1,296 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/SGNL-ai/fabricator/pkg/encryption"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// ManifestFile is the name of the manifest describing the files in the output directory
+const ManifestFile = "manifest.json"
+
+// Manifest lists the files a run wrote so consumers don't need to derive filenames
+// from external IDs
+type Manifest struct {
+	Format string          `json:"format"` // OutputFormatCSV, OutputFormatJSONL, OutputFormatGo or OutputFormatNeo4j
+	Files  []ManifestEntry `json:"files"`  // In dependency order
+
+	// Edges lists the edge files of the Neo4j output format, one per relationship
+	Edges []ManifestEdge `json:"edges,omitempty"`
+
+	// Encryption describes how the files were encrypted; nil when they weren't
+	Encryption *ManifestEncryption `json:"encryption,omitempty"`
+
+	// Incomplete is set when the run failed while writing, with the error that
+	// stopped it; each entry's Status then says whether its files were written
+	Incomplete bool   `json:"incomplete,omitempty"`
+	Error      string `json:"error,omitempty"`
+
+	files *OutputFiles // Writes the listed files, recording which were written
+}
+
+// Statuses of the entries of an incomplete manifest
+const (
+	FileStatusWritten = "written" // Every file of the entity was written in full
+	FileStatusPartial = "partial" // Some file of the entity was cut short or not started
+	FileStatusMissing = "missing" // No file of the entity was written
+)
+
+// ManifestEncryption records how output files were encrypted, so consumers know
+// to decrypt them; the salt and nonces are in each file's header
+type ManifestEncryption struct {
+	Scheme    string `json:"scheme"`    // encryption.Scheme
+	KDF       string `json:"kdf"`       // Derivation of the key from the passphrase
+	ChunkSize int    `json:"chunkSize"` // Plaintext bytes per sealed chunk
+	Extension string `json:"extension"` // Appended to every file name listed
+}
+
+// ManifestEntry describes the file written for one entity
+type ManifestEntry struct {
+	Entity string `json:"entity"` // Entity external ID
+	File   string `json:"file"`   // Path within the output directory, with / separators
+	Rows   int    `json:"rows"`
+	Domain string `json:"domain,omitempty"` // Logical group of the entity
+
+	// ListFiles holds the files of the entity's rows-encoded list attributes (CSV
+	// output), keyed by attribute external ID
+	ListFiles map[string]string `json:"listFiles,omitempty"`
+
+	// Unsanitized is the filename the external ID would give without replacing
+	// characters invalid on Windows; empty when no replacement was needed
+	Unsanitized string `json:"unsanitized,omitempty"`
+
+	// Partitions holds the files of a partitioned entity, whose File is then the
+	// folder holding them, in the order their values first appear
+	Partitions []ManifestPartition `json:"partitions,omitempty"`
+
+	// Status is FileStatusWritten, FileStatusPartial or FileStatusMissing in an
+	// incomplete manifest; empty otherwise
+	Status string `json:"status,omitempty"`
+}
+
+// ManifestEdge describes the edge file written for one relationship
+type ManifestEdge struct {
+	Relationship string `json:"relationship"` // Relationship ID
+	Type         string `json:"type"`         // Relationship type of the edges
+	From         string `json:"from"`         // External ID of the entity the edges start at
+	To           string `json:"to"`           // External ID of the entity the edges end at
+	File         string `json:"file"`         // Path within the output directory, with / separators
+	Rows         int    `json:"rows"`         // Edges written, 0 before the edge file is
+}
+
+// ManifestPartition describes the file written for one value of a partitioned
+// entity's partition attribute
+type ManifestPartition struct {
+	Value string `json:"value"` // Empty for rows without a value
+	File  string `json:"file"`  // Path within the output directory, with / separators
+	Rows  int    `json:"rows"`
+}
+
+// NewManifest describes the files written by files for a generated graph in format
+func NewManifest(graph *model.Graph, format string, files *OutputFiles) *Manifest {
+	if format == "" {
+		format = OutputFormatCSV
+	}
+	extension := "." + format
+	if format == OutputFormatNeo4j {
+		extension = ".csv"
+	}
+
+	manifest := &Manifest{Format: format, Files: []ManifestEntry{}, files: files}
+	if files.Encrypted() {
+		manifest.Encryption = &ManifestEncryption{
+			Scheme:    encryption.Scheme,
+			KDF:       encryption.KDF,
+			ChunkSize: encryption.ChunkSize,
+			Extension: encryption.Extension,
+		}
+	}
+	layout := files.Layout()
+	for _, entity := range DependencyOrder(graph) {
+		entry := ManifestEntry{
+			Entity: entity.GetExternalID(),
+			File:   files.FileName(layout.entityFilePath(entity, extension)),
+			Rows:   entity.GetRowCount(),
+			Domain: entity.GetDomain(),
+		}
+		if format == OutputFormatCSV || format == OutputFormatNeo4j {
+			for _, attr := range rowsEncodedAttributes(entity) {
+				if entry.ListFiles == nil {
+					entry.ListFiles = make(map[string]string)
+				}
+				entry.ListFiles[attr.GetExternalID()] = files.FileName(layout.listFilePath(entity, attr))
+			}
+		}
+		if format == OutputFormatGo {
+			// Go fixtures are one package: no domain folders or partitions
+			entry.File = files.FileName(layout.goFilePath(entity))
+			manifest.Files = append(manifest.Files, entry)
+			continue
+		}
+		fileExtension := files.FileName(extension)
+		if attr, _ := partitionAttribute(entity); attr != nil {
+			entry.File, fileExtension = layout.entityFilePath(entity, ""), ""
+			values, rows := entityPartitions(entity, attr)
+			for _, value := range values {
+				entry.Partitions = append(entry.Partitions, ManifestPartition{
+					Value: value,
+					File:  files.FileName(layout.partitionFilePath(entity, attr, value, extension)),
+					Rows:  rows[value],
+				})
+			}
+		}
+		if unsanitized := entityNamespaceBase(entity.GetExternalID()); unsanitized != layout.entityFileBase(entity.GetExternalID()) {
+			entry.Unsanitized = path.Join(path.Dir(entry.File), unsanitized+fileExtension)
+		}
+		manifest.Files = append(manifest.Files, entry)
+	}
+	if format == OutputFormatNeo4j {
+		for _, relationship := range neo4jEdgeRelationships(graph) {
+			manifest.Edges = append(manifest.Edges, ManifestEdge{
+				Relationship: relationship.GetID(),
+				Type:         edgeType(relationship),
+				From:         relationship.GetSourceEntity().GetExternalID(),
+				To:           relationship.GetTargetEntity().GetExternalID(),
+				File:         files.FileName(layout.edgeFilePath(relationship)),
+				Rows:         files.edgeRows(relationship.GetID()),
+			})
+		}
+	}
+	return manifest
+}
+
+// DataFiles returns every data file the manifest lists: entity files, partition
+// files, list files and edge files, with / separators
+func (m *Manifest) DataFiles() []string {
+	var files []string
+	for _, entry := range m.Files {
+		files = append(files, entry.DataFiles()...)
+	}
+	for _, edge := range m.Edges {
+		files = append(files, edge.File)
+	}
+	return files
+}
+
+// DataFiles returns the data files written for the entity
+func (e ManifestEntry) DataFiles() []string {
+	var files []string
+	if len(e.Partitions) == 0 {
+		files = append(files, e.File)
+	}
+	for _, partition := range e.Partitions {
+		files = append(files, partition.File)
+	}
+	attrs := make([]string, 0, len(e.ListFiles))
+	for attr := range e.ListFiles {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+	for _, attr := range attrs {
+		files = append(files, e.ListFiles[attr])
+	}
+	return files
+}
+
+// MarkIncomplete records that the run writing the manifest's files to dir failed
+// with cause, and sets each entry's status from the files its OutputFiles wrote,
+// so consumers don't mistake the partial output for a complete one
+func (m *Manifest) MarkIncomplete(dir string, cause error) {
+	m.Incomplete = true
+	m.Error = cause.Error()
+	for i := range m.Files {
+		written, partial := 0, false
+		entryFiles := m.Files[i].DataFiles()
+		for _, file := range entryFiles {
+			switch m.files.state(filepath.Join(dir, filepath.FromSlash(file))) {
+			case fileStateWritten:
+				written++
+			case fileStatePartial:
+				partial = true
+			}
+		}
+		switch {
+		case written == len(entryFiles):
+			m.Files[i].Status = FileStatusWritten
+		case written == 0 && !partial:
+			m.Files[i].Status = FileStatusMissing
+		default:
+			m.Files[i].Status = FileStatusPartial
+		}
+	}
+}
+
+// MergeManifest adds to current the entries of previous, the manifest of an
+// earlier run into the same directory, for entities current doesn't list and whose
+// files are still in dir. It returns the entities both list, whose files current
+// replaced. Manifests of different formats or encryption can't be merged.
+func MergeManifest(current, previous *Manifest, dir string) ([]string, error) {
+	if err := CheckMergeable(previous, current.Format, current.Encryption != nil, dir); err != nil {
+		return nil, err
+	}
+
+	listed := make(map[string]bool, len(current.Files))
+	for _, entry := range current.Files {
+		listed[entry.Entity] = true
+	}
+	var replaced []string
+	for _, entry := range previous.Files {
+		if listed[entry.Entity] {
+			replaced = append(replaced, entry.Entity)
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(entry.File))); err != nil {
+			continue
+		}
+		current.Files = append(current.Files, entry)
+	}
+	return replaced, nil
+}
+
+// CheckMergeable fails when output in format, encrypted or not, can't be merged
+// with the output previous lists in dir, so a merge can be refused before any
+// file is written
+func CheckMergeable(previous *Manifest, format string, encrypted bool, dir string) error {
+	if format == "" {
+		format = OutputFormatCSV
+	}
+	if previous.Format != format {
+		return fmt.Errorf("cannot merge %s output into %s, which holds %s output", format, dir, previous.Format)
+	}
+	if (previous.Encryption != nil) != encrypted {
+		return fmt.Errorf("cannot merge encrypted and unencrypted output in %s", dir)
+	}
+	return nil
+}
+
+// ReadManifest reads a manifest written by WriteManifest
+func ReadManifest(path string) (*Manifest, error) {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	return &manifest, nil
+}
+
+// WriteManifest writes the manifest as indented JSON
+func WriteManifest(path string, manifest *Manifest) error {
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(path, append(content, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
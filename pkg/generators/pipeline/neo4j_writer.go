@@ -0,0 +1,207 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/SGNL-ai/fabricator/pkg/console"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/fatih/color"
+)
+
+// Neo4jEdgesDir is the folder of the output directory holding the edge files of
+// the Neo4j output format
+const Neo4jEdgesDir = "edges"
+
+// Neo4jWriter writes the files of Neo4j's bulk importer (neo4j-admin database
+// import): a node CSV per entity, laid out like CSV output but with the primary
+// key's header marked as the node ID, and an edge CSV per relationship linking
+// the primary keys of the rows a foreign key connects.
+type Neo4jWriter struct {
+	*CSVWriter
+}
+
+// NewNeo4jWriter creates a new Neo4j bulk import writer
+func NewNeo4jWriter(outputDir string) CSVWriterInterface {
+	writer := &CSVWriter{outputDir: outputDir}
+	writer.wrapSink = func(sink recordSink) recordSink {
+		return &neo4jNodeSink{recordSink: sink, layout: writer.files.Layout()}
+	}
+	return &Neo4jWriter{CSVWriter: writer}
+}
+
+// WriteFiles writes the node files of all entities, then the edge files of all
+// relationships
+func (w *Neo4jWriter) WriteFiles(graph *model.Graph) error {
+	if err := w.CSVWriter.WriteFiles(graph); err != nil {
+		return err
+	}
+	var redactor *Redactor
+	if w.redactedDir != "" {
+		var err error
+		if redactor, err = NewRedactor(graph); err != nil {
+			return err
+		}
+	}
+
+	// Each relationship's edges are found once, for both copies and the manifest
+	traversal := graph.NewTraversal()
+	for _, relationship := range neo4jEdgeRelationships(graph) {
+		edges := neo4jEdges(traversal, relationship)
+		if err := w.writeEdgeFile(relationship, edges, w.outputDir, nil); err != nil {
+			return err
+		}
+		if redactor != nil {
+			if err := w.writeEdgeFile(relationship, edges, w.redactedDir, redactor); err != nil {
+				return err
+			}
+		}
+		w.files.recordEdges(relationship.GetID(), len(edges))
+	}
+	return nil
+}
+
+// neo4jIDSpace returns the ID space of an entity's nodes, which edge files name
+// to say which entity's IDs they reference
+func (l FileLayout) neo4jIDSpace(entity model.EntityInterface) string {
+	return l.entityFileBase(entity.GetExternalID())
+}
+
+// neo4jNodeSink marks the primary key's header of each entity as its node ID, e.g.
+// id:ID(User); entities without a primary key are written as they are
+type neo4jNodeSink struct {
+	recordSink
+	layout FileLayout
+}
+
+func (s *neo4jNodeSink) begin(entity model.EntityInterface, headers []string) error {
+	pk := entity.GetPrimaryKey()
+	if pk == nil {
+		return s.recordSink.begin(entity, headers)
+	}
+	annotated := make([]string, len(headers))
+	copy(annotated, headers)
+	for i, attr := range entity.GetAttributes() {
+		if attr.GetName() == pk.GetName() {
+			annotated[i] = fmt.Sprintf("%s:ID(%s)", headers[i], s.layout.neo4jIDSpace(entity))
+		}
+	}
+	return s.recordSink.begin(entity, annotated)
+}
+
+// neo4jEdgeRelationships returns the relationships written as edge files: those
+// whose entities both have a primary key for the edges to reference
+func neo4jEdgeRelationships(graph *model.Graph) []model.RelationshipInterface {
+	var relationships []model.RelationshipInterface
+	for _, relationship := range graph.GetAllRelationships() {
+		if relationship.GetSourceEntity().GetPrimaryKey() != nil && relationship.GetTargetEntity().GetPrimaryKey() != nil {
+			relationships = append(relationships, relationship)
+		}
+	}
+	return relationships
+}
+
+// edgeFilePath returns the edge file of a relationship, with / separators
+func (l FileLayout) edgeFilePath(relationship model.RelationshipInterface) string {
+	return path.Join(Neo4jEdgesDir, l.sanitize(relationship.GetID())+".csv")
+}
+
+// edgeType returns the relationship type of a relationship's edges: its name, or
+// its ID when it has none
+func edgeType(relationship model.RelationshipInterface) string {
+	if relationship.GetName() != "" {
+		return relationship.GetName()
+	}
+	return relationship.GetID()
+}
+
+// recordEdges records the edges written for a relationship; files without an
+// OutputFiles aren't recorded
+func (f *OutputFiles) recordEdges(relationship string, rows int) {
+	if f == nil {
+		return
+	}
+	f.written.mu.Lock()
+	defer f.written.mu.Unlock()
+	if f.written.edges == nil {
+		f.written.edges = make(map[string]int)
+	}
+	f.written.edges[relationship] = rows
+}
+
+// edgeRows returns the edges recorded for a relationship, 0 when its edge file
+// wasn't written
+func (f *OutputFiles) edgeRows(relationship string) int {
+	if f == nil {
+		return 0
+	}
+	f.written.mu.Lock()
+	defer f.written.mu.Unlock()
+	return f.written.edges[relationship]
+}
+
+// neo4jEdges returns the edges of a relationship as pairs of source and target
+// primary keys, one for each source row whose foreign key matches a target row,
+// following the keys with traversal
+func neo4jEdges(traversal *model.Traversal, relationship model.RelationshipInterface) [][2]string {
+	sourceKey := relationship.GetSourceEntity().GetPrimaryKey().GetName()
+	targetKey := relationship.GetTargetEntity().GetPrimaryKey().GetName()
+	var edges [][2]string
+	_ = traversal.ForEachJoinedRow(relationship, func(child, parent *model.Row) error {
+		edges = append(edges, [2]string{child.GetValue(sourceKey), parent.GetValue(targetKey)})
+		return nil
+	})
+	return edges
+}
+
+// writeEdgeFile writes one relationship's edges to outputDir's edges folder, with
+// keys redacted by redactor unless it is nil
+func (w *Neo4jWriter) writeEdgeFile(relationship model.RelationshipInterface, edges [][2]string, outputDir string, redactor *Redactor) error {
+	files := w.files
+	filename := files.FileName(files.Layout().edgeFilePath(relationship))
+	filePath := filepath.Join(outputDir, filepath.FromSlash(filename))
+	if err := os.MkdirAll(filepath.Dir(filePath), 0750); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", filePath, err)
+	}
+	file, err := files.create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", filePath, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	source, target := relationship.GetSourceEntity(), relationship.GetTargetEntity()
+	buffer := bufio.NewWriterSize(file, fileBufferSize(w.fileBuffer))
+	writer := csv.NewWriter(buffer)
+	_ = writer.Write([]string{
+		fmt.Sprintf(":START_ID(%s)", files.Layout().neo4jIDSpace(source)),
+		fmt.Sprintf(":END_ID(%s)", files.Layout().neo4jIDSpace(target)),
+		":TYPE",
+	})
+
+	kind := edgeType(relationship)
+	for _, edge := range edges {
+		_ = writer.Write([]string{
+			redactor.redactValue(source, source.GetPrimaryKey(), edge[0]),
+			redactor.redactValue(target, target.GetPrimaryKey(), edge[1]),
+			kind,
+		})
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filePath, err)
+	}
+	if err := buffer.Flush(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filePath, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filePath, err)
+	}
+	console.ClearLine()
+	color.Green(console.Text("✓ Generated %s with %d rows"), filename, len(edges))
+	return nil
+}
@@ -0,0 +1,63 @@
+package pipeline
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNeo4jOutput(t *testing.T) {
+	readCSV := func(t *testing.T, path string) [][]string {
+		t.Helper()
+		file, err := os.Open(path) // #nosec G304 - test file
+		require.NoError(t, err)
+		defer func() { _ = file.Close() }()
+		records, err := csv.NewReader(file).ReadAll()
+		require.NoError(t, err)
+		return records
+	}
+
+	graphInterface, err := model.NewGraph(partialInputDefinition(), 10)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	dir := t.TempDir()
+	generator := NewDataGenerator(dir, map[string]int{"User": 10, "Group": 4}, false)
+	files := NewOutputFiles()
+	generator.SetOutputFiles(files)
+	require.NoError(t, generator.SetOutputFormat(OutputFormatNeo4j))
+	require.NoError(t, generator.Generate(graph))
+
+	users := readCSV(t, filepath.Join(dir, "User.csv"))
+	assert.Equal(t, []string{"id:ID(User)", "email", "title"}, users[0])
+	assert.Len(t, users, 11)
+	userIDs := make(map[string]bool)
+	for _, record := range users[1:] {
+		userIDs[record[0]] = true
+	}
+
+	groups := readCSV(t, filepath.Join(dir, "Group.csv"))
+	assert.Equal(t, []string{"id:ID(Group)", "ownerId"}, groups[0])
+
+	edges := readCSV(t, filepath.Join(dir, "edges", "group_owner.csv"))
+	assert.Equal(t, []string{":START_ID(Group)", ":END_ID(User)", ":TYPE"}, edges[0])
+	require.Len(t, edges, 5, "every group's owner is an edge")
+	for i, edge := range edges[1:] {
+		assert.Equal(t, groups[i+1][0], edge[0])
+		assert.Equal(t, groups[i+1][1], edge[1])
+		assert.True(t, userIDs[edge[1]])
+		assert.Equal(t, "group_owner", edge[2])
+	}
+
+	// The manifest counts the edges the writer found
+	manifest := NewManifest(graph, OutputFormatNeo4j, files)
+	assert.Equal(t, "User.csv", manifest.Files[0].File)
+	assert.Equal(t, []ManifestEdge{
+		{Relationship: "group_owner", Type: "group_owner", From: "Group", To: "User", File: "edges/group_owner.csv", Rows: 4},
+	}, manifest.Edges)
+	assert.Contains(t, manifest.DataFiles(), "edges/group_owner.csv")
+}
@@ -0,0 +1,226 @@
+package pipeline
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// maxHostnameLabel is the longest a DNS label may be
+const maxHostnameLabel = 63
+
+// cidrGenerator returns the attribute's cidr generator hint, or nil if it has none
+func cidrGenerator(attr model.AttributeInterface) *parser.Generator {
+	if generator := attr.GetGenerator(); generator != nil && generator.Type == parser.GeneratorCIDR {
+		return generator
+	}
+	return nil
+}
+
+// deferredGenerator reports whether the attribute's values are assigned by
+// assignNetworkValues rather than with the other fields
+func deferredGenerator(attr model.AttributeInterface) bool {
+	generator := attr.GetGenerator()
+	return generator != nil && generator.Deferred()
+}
+
+// cidrValue returns the block of the row at index: rows take consecutive blocks of
+// the generator's prefix length within its network, so no two blocks overlap
+func cidrValue(generator *parser.Generator, index int) (string, error) {
+	_, network, err := net.ParseCIDR(generator.Network)
+	if err != nil {
+		return "", fmt.Errorf("invalid cidr network: %w", err)
+	}
+	bits, _ := network.Mask.Size()
+	if blocks := uint64(1) << (generator.PrefixLength - bits); uint64(index) >= blocks {
+		return "", fmt.Errorf("network %s holds only %d /%d blocks", generator.Network, blocks, generator.PrefixLength)
+	}
+	start := binary.BigEndian.Uint32(network.IP.To4()) + uint32(index)<<(32-generator.PrefixLength)
+	return fmt.Sprintf("%s/%d", ipv4String(start), generator.PrefixLength), nil
+}
+
+// macValue returns a MAC address starting with a vendor's OUI, given by name or as
+// aa:bb:cc
+func macValue(vendor string, faker *gofakeit.Faker) string {
+	oui, known := parser.MACVendors[strings.ToLower(vendor)]
+	if !known {
+		oui = strings.ToLower(vendor)
+	}
+	return fmt.Sprintf("%s:%02x:%02x:%02x", oui, faker.Uint8(), faker.Uint8(), faker.Uint8())
+}
+
+// hostnameValue returns a hostname made from source, such as a device name, or a
+// random one drawn from faker when source is empty, followed by the generator's domain
+func hostnameValue(generator *parser.Generator, source string, faker *gofakeit.Faker) string {
+	label := hostnameLabel(source)
+	if generator.From == "" {
+		label = fmt.Sprintf("%s-%02d", hostnameLabel(faker.Word()), faker.Number(1, 99))
+	}
+	if label == "" || generator.Domain == "" {
+		return label
+	}
+	return label + "." + strings.ToLower(generator.Domain)
+}
+
+// hostnameLabel turns a value into a DNS label: lowercase letters and digits, with
+// a hyphen for each run of other characters, at most 63 characters long
+func hostnameLabel(value string) string {
+	var label strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(value) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if hyphen && label.Len() > 0 {
+				label.WriteByte('-')
+			}
+			label.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+	trimmed := label.String()
+	if len(trimmed) > maxHostnameLabel {
+		trimmed = strings.TrimRight(trimmed[:maxHostnameLabel], "-")
+	}
+	return trimmed
+}
+
+// assignNetworkValues sets the attributes whose generators depend on other values,
+// once every entity's fields and lookups are set: ipv4 addresses within a subnet,
+// distinct within each block, then hostnames derived from another attribute. Values
+// supplied externally are kept and count as taken. It reports whether any entity
+// has such attributes, in which case lookups copying them need resolving again.
+func assignNetworkValues(graph *model.Graph, streams *RandomStreams) (bool, error) {
+	assigned := false
+	for _, entity := range graph.GetEntitiesList() {
+		var addresses, hostnames []model.AttributeInterface
+		for _, attr := range entity.GetAttributes() {
+			if !deferredGenerator(attr) {
+				continue
+			}
+			if attr.GetGenerator().Type == parser.GeneratorIPv4 {
+				addresses = append(addresses, attr)
+			} else {
+				hostnames = append(hostnames, attr)
+			}
+		}
+		if len(addresses) == 0 && len(hostnames) == 0 {
+			continue
+		}
+		assigned = true
+		faker := streams.stream("network:" + entity.GetExternalID())
+
+		for _, attr := range addresses {
+			if err := assignAddresses(entity, attr, faker); err != nil {
+				return assigned, fmt.Errorf("entity %s: %w", entity.GetExternalID(), err)
+			}
+		}
+		for _, attr := range hostnames {
+			name, from := attr.GetName(), attr.GetGenerator().From
+			for i := 0; i < entity.GetRowCount(); i++ {
+				if row := entity.GetRowByIndex(i); !row.IsPinned(name) {
+					row.SetValue(name, hostnameValue(attr.GetGenerator(), row.GetValue(from), faker))
+				}
+			}
+		}
+	}
+	return assigned, nil
+}
+
+// assignAddresses gives each row an address of its subnet not taken by another row
+func assignAddresses(entity model.EntityInterface, attr model.AttributeInterface, faker *gofakeit.Faker) error {
+	name, subnet := attr.GetName(), attr.GetGenerator().Subnet
+	subnetOf := func(row *model.Row) string {
+		if parser.IsIPv4CIDR(subnet) {
+			return subnet
+		}
+		return row.GetValue(subnet)
+	}
+
+	// taken holds the addresses used in each block, starting with supplied ones
+	taken := make(map[string]map[uint32]bool)
+	blockOf := func(row *model.Row) (*net.IPNet, error) {
+		value := subnetOf(row)
+		_, block, err := net.ParseCIDR(value)
+		if err != nil || block.IP.To4() == nil {
+			return nil, fmt.Errorf("attribute '%s' subnet '%s' holds '%s', which is not an IPv4 CIDR block", name, subnet, value)
+		}
+		if taken[block.String()] == nil {
+			taken[block.String()] = make(map[uint32]bool)
+		}
+		return block, nil
+	}
+	for i := 0; i < entity.GetRowCount(); i++ {
+		row := entity.GetRowByIndex(i)
+		if !row.IsPinned(name) || subnetOf(row) == "" {
+			continue
+		}
+		block, err := blockOf(row)
+		if err != nil {
+			return err
+		}
+		if ip := net.ParseIP(row.GetValue(name)).To4(); ip != nil {
+			taken[block.String()][binary.BigEndian.Uint32(ip)] = true
+		}
+	}
+
+	for i := 0; i < entity.GetRowCount(); i++ {
+		row := entity.GetRowByIndex(i)
+		if row.IsPinned(name) {
+			continue
+		}
+		if subnetOf(row) == "" {
+			row.SetValue(name, "")
+			continue
+		}
+		block, err := blockOf(row)
+		if err != nil {
+			return err
+		}
+		address, free := freeAddress(block, taken[block.String()], faker)
+		if !free {
+			return fmt.Errorf("attribute '%s' has no free address left in %s", name, block)
+		}
+		row.SetValue(name, address)
+	}
+	return nil
+}
+
+// freeAddress takes a random host address of block that isn't taken yet; blocks
+// larger than /31 leave out their network and broadcast addresses
+func freeAddress(block *net.IPNet, taken map[uint32]bool, faker *gofakeit.Faker) (string, bool) {
+	ones, _ := block.Mask.Size()
+	first, hosts := binary.BigEndian.Uint32(block.IP.To4()), uint64(1)<<(32-ones)
+	if hosts > 2 {
+		first, hosts = first+1, hosts-2
+	}
+
+	// Random picks find a free address quickly until the block fills up; a scan
+	// from a random offset finds the last ones
+	offset := uint64(faker.Uint32()) % hosts
+	for attempt := 0; attempt < 8; attempt++ {
+		if candidate := first + uint32(uint64(faker.Uint32())%hosts); !taken[candidate] {
+			taken[candidate] = true
+			return ipv4String(candidate), true
+		}
+	}
+	for i := uint64(0); i < hosts; i++ {
+		if candidate := first + uint32((offset+i)%hosts); !taken[candidate] {
+			taken[candidate] = true
+			return ipv4String(candidate), true
+		}
+	}
+	return "", false
+}
+
+// ipv4String formats an IPv4 address held as a number
+func ipv4String(address uint32) string {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, address)
+	return ip.String()
+}
@@ -0,0 +1,138 @@
+package pipeline
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCIDRValue(t *testing.T) {
+	generator := &parser.Generator{Type: parser.GeneratorCIDR, Network: "10.20.0.0/16", PrefixLength: 24}
+	for index, want := range []string{"10.20.0.0/24", "10.20.1.0/24", "10.20.2.0/24"} {
+		value, err := cidrValue(generator, index)
+		require.NoError(t, err)
+		assert.Equal(t, want, value)
+	}
+	last, err := cidrValue(generator, 255)
+	require.NoError(t, err)
+	assert.Equal(t, "10.20.255.0/24", last)
+
+	_, err = cidrValue(generator, 256)
+	assert.EqualError(t, err, "network 10.20.0.0/16 holds only 256 /24 blocks")
+}
+
+func TestHostnameLabel(t *testing.T) {
+	assert.Equal(t, "core-switch-01", hostnameLabel("Core Switch #01"))
+	assert.Equal(t, "db-primary", hostnameLabel("  DB_primary!! "))
+	assert.Equal(t, "", hostnameLabel("---"))
+	assert.Len(t, hostnameLabel(strings.Repeat("a", 70)), maxHostnameLabel)
+}
+
+func TestAssignNetworkValues(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Network",
+		Entities: map[string]parser.Entity{
+			"site": {
+				DisplayName: "Site", ExternalId: "Site",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "block", ExternalId: "block", Type: "String",
+						Generator: &parser.Generator{Type: parser.GeneratorCIDR, Network: "10.20.0.0/16", PrefixLength: 28}},
+				},
+			},
+			"device": {
+				DisplayName: "Device", ExternalId: "Device",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "siteId", ExternalId: "siteId", Type: "String"},
+					{Name: "siteBlock", ExternalId: "siteBlock", Type: "String",
+						Lookup: &parser.Lookup{ForeignKey: "siteId", Attribute: "block"}},
+					{Name: "name", ExternalId: "name", Type: "String"},
+					{Name: "ip", ExternalId: "ip", Type: "String",
+						Generator: &parser.Generator{Type: parser.GeneratorIPv4, Subnet: "siteBlock"}},
+					{Name: "hostname", ExternalId: "hostname", Type: "String",
+						Generator: &parser.Generator{Type: parser.GeneratorHostname, From: "name", Domain: "corp.example.com"}},
+					{Name: "mac", ExternalId: "mac", Type: "String",
+						Generator: &parser.Generator{Type: parser.GeneratorMAC, Vendor: "cisco"}},
+				},
+			},
+			"nic": {
+				DisplayName: "NIC", ExternalId: "NIC",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "deviceId", ExternalId: "deviceId", Type: "String"},
+					{Name: "deviceIp", ExternalId: "deviceIp", Type: "String",
+						Lookup: &parser.Lookup{ForeignKey: "deviceId", Attribute: "ip"}},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"device_site": {DisplayName: "Device Site", Name: "device_site", FromAttribute: "Device.siteId", ToAttribute: "Site.id"},
+			"nic_device":  {DisplayName: "NIC Device", Name: "nic_device", FromAttribute: "NIC.deviceId", ToAttribute: "Device.id"},
+		},
+	}
+	graphInterface, err := model.NewGraph(def, 40)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+
+	// Each /28 block has 14 host addresses for the 40 devices spread over 4 sites
+	generator := NewDataGenerator(t.TempDir(), map[string]int{"Site": 4, "Device": 40, "NIC": 10}, false)
+	require.NoError(t, generator.Generate(graph))
+
+	site, _ := graph.GetEntity("Site")
+	blocks := make(map[string]bool)
+	for i := 0; i < site.GetRowCount(); i++ {
+		blocks[site.GetRowByIndex(i).GetValue("block")] = true
+	}
+	assert.Len(t, blocks, 4, "every site has a block of its own")
+
+	device, _ := graph.GetEntity("Device")
+	addresses := make(map[string]string)
+	for i := 0; i < device.GetRowCount(); i++ {
+		row := device.GetRowByIndex(i)
+		_, block, err := net.ParseCIDR(row.GetValue("siteBlock"))
+		require.NoError(t, err)
+		ip := net.ParseIP(row.GetValue("ip"))
+		require.NotNil(t, ip, "row %d", i)
+		assert.True(t, block.Contains(ip), "%s is within %s", ip, block)
+		assert.False(t, ip.Equal(block.IP), "the network address isn't a host")
+		assert.NotContains(t, addresses, ip.String(), "addresses are distinct")
+		addresses[ip.String()] = row.GetValue("id")
+
+		assert.Equal(t, hostnameLabel(row.GetValue("name"))+".corp.example.com", row.GetValue("hostname"))
+		assert.Regexp(t, "^00:00:0c(:[0-9a-f]{2}){3}$", row.GetValue("mac"))
+	}
+
+	nic, _ := graph.GetEntity("NIC")
+	for i := 0; i < nic.GetRowCount(); i++ {
+		row := nic.GetRowByIndex(i)
+		assert.Equal(t, row.GetValue("deviceId"), addresses[row.GetValue("deviceIp")], "lookups copy the assigned address")
+	}
+}
+
+func TestAssignAddressesRunsOut(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Network",
+		Entities: map[string]parser.Entity{
+			"device": {
+				DisplayName: "Device", ExternalId: "Device",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "ip", ExternalId: "ip", Type: "String",
+						Generator: &parser.Generator{Type: parser.GeneratorIPv4, Subnet: "192.168.1.0/30"}},
+				},
+			},
+		},
+	}
+	graphInterface, err := model.NewGraph(def, 3)
+	require.NoError(t, err)
+
+	generator := NewDataGenerator(t.TempDir(), map[string]int{"Device": 3}, false)
+	err = generator.Generate(graphInterface.(*model.Graph))
+	assert.ErrorContains(t, err, "attribute 'ip' has no free address left in 192.168.1.0/30")
+}
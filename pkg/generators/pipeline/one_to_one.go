@@ -0,0 +1,52 @@
+package pipeline
+
+import (
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// oneToOneTargets hands out each target key of a one-to-one relationship at most
+// once, in target row order. Keys already taken by pinned source rows from partial
+// input are skipped, so no two source rows reference the same target row, and so
+// are rows not matching the where, if any.
+type oneToOneTargets struct {
+	target    model.EntityInterface
+	attrName  string
+	where     map[string]string
+	used      map[string]bool
+	next      int
+	unmatched int // Source rows left without a target key
+}
+
+// newOneToOneTargets collects the target keys the source entity's pinned rows use
+func newOneToOneTargets(source model.EntityInterface, relationship model.RelationshipInterface, where map[string]string) *oneToOneTargets {
+	targets := &oneToOneTargets{
+		target:   relationship.GetTargetEntity(),
+		attrName: relationship.GetTargetAttribute().GetName(),
+		where:    where,
+		used:     make(map[string]bool),
+	}
+	sourceName := relationship.GetSourceAttribute().GetName()
+	_ = source.ForEachRow(func(row *model.Row, _ int) error {
+		if value := row.GetValue(sourceName); value != "" && row.IsPinned(sourceName) {
+			targets.used[value] = true
+		}
+		return nil
+	})
+	return targets
+}
+
+// take returns the next unused target key, or false once every key is used
+func (t *oneToOneTargets) take() (string, bool) {
+	for t.next < t.target.GetRowCount() {
+		row := t.target.GetRowByIndex(t.next)
+		t.next++
+		value := row.GetValue(t.attrName)
+		if value == "" || t.used[value] || !matchesWhere(row, t.where) {
+			continue
+		}
+		t.used[value] = true
+		return value, true
+	}
+	t.unmatched++
+	return "", false
+}
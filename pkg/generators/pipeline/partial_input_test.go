@@ -0,0 +1,220 @@
+package pipeline
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func partialInputDefinition() *parser.SORDefinition {
+	return &parser.SORDefinition{
+		DisplayName: "Partial SOR",
+		Description: "SOR used for partial input tests",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User",
+				ExternalId:  "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "email", ExternalId: "email", Type: "String"},
+					{Name: "title", ExternalId: "title", Type: "String"},
+				},
+			},
+			"group": {
+				DisplayName: "Group",
+				ExternalId:  "Group",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "ownerId", ExternalId: "ownerId", Type: "String"},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"group_owner": {
+				DisplayName:   "Group Owner",
+				Name:          "group_owner",
+				FromAttribute: "Group.ownerId",
+				ToAttribute:   "User.id",
+			},
+		},
+	}
+}
+
+func writeCSV(t *testing.T, path string, records [][]string) {
+	t.Helper()
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+	writer := csv.NewWriter(file)
+	require.NoError(t, writer.WriteAll(records))
+}
+
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+	records, err := csv.NewReader(file).ReadAll()
+	require.NoError(t, err)
+	return records
+}
+
+func TestCSVLoader_LoadPartialCSVFiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		records     [][]string
+		wantErr     bool
+		expectedRow int
+	}{
+		{
+			name:        "Loads provided columns",
+			records:     [][]string{{"id", "email"}, {"u1", "a@example.com"}, {"u2", "b@example.com"}},
+			expectedRow: 2,
+		},
+		{
+			name:        "Generates missing primary keys",
+			records:     [][]string{{"email"}, {"a@example.com"}},
+			expectedRow: 1,
+		},
+		{
+			name:    "Rejects unknown columns",
+			records: [][]string{{"id", "nickname"}, {"u1", "ace"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputDir := t.TempDir()
+			writeCSV(t, filepath.Join(inputDir, "User.csv"), tt.records)
+
+			graphInterface, err := model.NewGraph(partialInputDefinition(), 10)
+			require.NoError(t, err)
+			graph := graphInterface.(*model.Graph)
+
+			loaded, err := NewCSVLoader().(PartialCSVLoader).LoadPartialCSVFiles(graph, inputDir)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedRow, loaded["User"])
+			_, hasGroup := loaded["Group"]
+			assert.False(t, hasGroup, "entities without a partial CSV should not be loaded")
+
+			user, _ := graph.GetEntity("User")
+			require.Equal(t, tt.expectedRow, user.GetRowCount())
+			row := user.GetRowByIndex(0)
+			assert.NotEmpty(t, row.GetValue("id"))
+			assert.True(t, row.IsPinned("email"))
+			assert.False(t, row.IsPinned("title"))
+		})
+	}
+
+	t.Run("Keeps values as given", func(t *testing.T) {
+		inputDir := t.TempDir()
+		writeCSV(t, filepath.Join(inputDir, "User.csv"), [][]string{{"id", "email"}, {"007", " a@example.com "}})
+
+		graphInterface, err := model.NewGraph(partialInputDefinition(), 10)
+		require.NoError(t, err)
+		graph := graphInterface.(*model.Graph)
+
+		_, err = NewCSVLoader().(PartialCSVLoader).LoadPartialCSVFiles(graph, inputDir)
+		require.NoError(t, err)
+
+		user, _ := graph.GetEntity("User")
+		row := user.GetRowByIndex(0)
+		assert.Equal(t, "007", row.GetValue("id"))
+		assert.Equal(t, " a@example.com ", row.GetValue("email"))
+	})
+
+	t.Run("Missing directory", func(t *testing.T) {
+		graphInterface, err := model.NewGraph(partialInputDefinition(), 10)
+		require.NoError(t, err)
+
+		_, err = NewCSVLoader().(PartialCSVLoader).LoadPartialCSVFiles(graphInterface.(*model.Graph), filepath.Join(t.TempDir(), "missing"))
+		assert.Error(t, err)
+	})
+}
+
+func TestDataGenerator_PartialInput(t *testing.T) {
+	inputDir := t.TempDir()
+	outputDir := t.TempDir()
+
+	writeCSV(t, filepath.Join(inputDir, "User.csv"), [][]string{
+		{"id", "email"},
+		{"u1", "alice@example.com"},
+		{"u2", ""},
+		{"u3", "carol@example.com"},
+	})
+
+	graphInterface, err := model.NewGraph(partialInputDefinition(), 10)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+
+	generator := NewDataGenerator(outputDir, map[string]int{"User": 50, "Group": 5}, false)
+	generator.SetPartialInput(inputDir)
+	require.NoError(t, generator.Generate(graph))
+
+	users := readCSV(t, filepath.Join(outputDir, "User.csv"))
+	require.Len(t, users, 4, "partial entity keeps its provided row count")
+	assert.Equal(t, []string{"id", "email", "title"}, users[0])
+
+	assert.Equal(t, "u1", users[1][0])
+	assert.Equal(t, "alice@example.com", users[1][1], "provided values are preserved")
+	assert.NotEmpty(t, users[2][1], "empty provided cells are filled in")
+	assert.Equal(t, "carol@example.com", users[3][1])
+	for _, row := range users[1:] {
+		assert.NotEmpty(t, row[2], "missing columns are generated")
+	}
+
+	groups := readCSV(t, filepath.Join(outputDir, "Group.csv"))
+	require.Len(t, groups, 6, "entities without partial input use configured counts")
+	userIDs := map[string]bool{"u1": true, "u2": true, "u3": true}
+	for _, row := range groups[1:] {
+		assert.True(t, userIDs[row[1]], "generated FKs reference provided keys")
+	}
+}
+
+func TestDataGenerator_PartialInputMissingKeys(t *testing.T) {
+	generate := func(t *testing.T, rules []IDFormatRule) [][]string {
+		inputDir := t.TempDir()
+		outputDir := t.TempDir()
+		writeCSV(t, filepath.Join(inputDir, "User.csv"), [][]string{
+			{"id", "email"},
+			{"", "alice@example.com"},
+			{"", "bob@example.com"},
+			{"1", "carol@example.com"},
+		})
+
+		graphInterface, err := model.NewGraph(partialInputDefinition(), 10)
+		require.NoError(t, err)
+
+		generator := NewDataGenerator(outputDir, map[string]int{"User": 50, "Group": 5}, false)
+		generator.SetPartialInput(inputDir)
+		generator.SetRandomStreams(NewRandomStreams(42, nil))
+		generator.SetIDFormats(rules)
+		require.NoError(t, generator.Generate(graphInterface.(*model.Graph)))
+		return readCSV(t, filepath.Join(outputDir, "User.csv"))
+	}
+
+	t.Run("Seeded", func(t *testing.T) {
+		first := generate(t, nil)
+		assert.Equal(t, first, generate(t, nil), "the same seed fills in the same keys")
+		assert.NotEqual(t, first[1][0], first[2][0])
+	})
+
+	t.Run("ID format", func(t *testing.T) {
+		users := generate(t, []IDFormatRule{{Pattern: "id", Format: IDFormatSequence}})
+		assert.Equal(t, "2", users[1][0], "keys the file provides are skipped")
+		assert.Equal(t, "3", users[2][0])
+		assert.Equal(t, "1", users[3][0])
+	})
+}
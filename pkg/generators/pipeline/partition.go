@@ -0,0 +1,224 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// defaultPartition names the partition of rows whose partition attribute is empty,
+// as Hive and Spark do
+const defaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
+// maxOpenPartitions is the number of partition files a partitionSink keeps open at
+// once; the least recently written is closed to make room, and reopened to append
+// when rows of its value follow
+const maxOpenPartitions = 128
+
+// partitionAttribute returns an entity's partition attribute and its index among
+// the entity's attributes, the columns of its records, or nil and -1 when the
+// entity isn't partitioned
+func partitionAttribute(entity model.EntityInterface) (model.AttributeInterface, int) {
+	if entity.GetPartitionBy() == "" {
+		return nil, -1
+	}
+	for i, attr := range entity.GetAttributes() {
+		if attr.GetName() == entity.GetPartitionBy() {
+			return attr, i
+		}
+	}
+	return nil, -1
+}
+
+// partitionFilePath returns the file holding one partition of an entity, relative to
+// the output directory with / separators: <entity>/<attribute>=<value><extension>
+// next to where the entity's own file would be
+func (l FileLayout) partitionFilePath(entity model.EntityInterface, attr model.AttributeInterface, value, extension string) string {
+	if value == "" {
+		value = defaultPartition
+	}
+	name := l.sanitize(attr.GetExternalID()+"="+value) + extension
+	return path.Join(l.entityFilePath(entity, ""), name)
+}
+
+// entityPartitions returns the values of a partitioned entity's partition attribute
+// in the order they first appear, with the number of rows holding each
+func entityPartitions(entity model.EntityInterface, attr model.AttributeInterface) ([]string, map[string]int) {
+	var values []string
+	rows := make(map[string]int)
+	for i := 0; i < entity.GetRowCount(); i++ {
+		value := entity.GetRowByIndex(i).GetValue(attr.GetName())
+		if rows[value] == 0 {
+			values = append(values, value)
+		}
+		rows[value]++
+	}
+	return values, rows
+}
+
+// entityDataFiles returns the files in directory holding an entity's records: its
+// own file, or the files of its partitions in name order. Returns nil when there
+// are none.
+func (l FileLayout) entityDataFiles(directory string, entity model.EntityInterface, extension string) []string {
+	attr, _ := partitionAttribute(entity)
+	if attr == nil {
+		file := filepath.Join(directory, l.entityFilePath(entity, extension))
+		if _, err := os.Stat(file); err != nil {
+			return nil
+		}
+		return []string{file}
+	}
+
+	folder := filepath.Join(directory, l.entityFilePath(entity, ""))
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil
+	}
+	prefix := l.sanitize(attr.GetExternalID() + "=")
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), prefix) && strings.HasSuffix(entry.Name(), extension) {
+			files = append(files, filepath.Join(folder, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// resumableSink is a recordSink that can close its file in the middle of an
+// entity and reopen it later to append the rest of the entity's records
+type resumableSink interface {
+	recordSink
+	// suspend writes out buffered records and closes the file
+	suspend() error
+	// resume reopens the file to append to it
+	resume() error
+}
+
+// partitionSink writes each partitioned entity's records to one file per value of
+// its partition attribute, through a sink per file, and passes other entities'
+// records to a sink for their own file. At most maxOpen partition files are open at
+// once when the partitions' sinks are resumable.
+type partitionSink struct {
+	whole     recordSink                   // Sink writing unpartitioned entities
+	partition func(file string) recordSink // Sink writing only the given file
+	extension string
+	layout    FileLayout // Names the partitions' files
+	maxOpen   int        // Partition files open at once; 0 leaves them all open
+
+	entity  model.EntityInterface
+	headers []string
+	attr    model.AttributeInterface // Nil while writing an unpartitioned entity
+	column  int
+	files   map[string]recordSink // Sinks of the partitions seen so far, by value
+	order   []string              // Partition values in the order first seen
+	paths   map[string]string     // Lower-case file of each partition → its value
+	open    map[string]uint64     // Values of the open partitions → when last written
+	writes  uint64
+}
+
+// newPartitionSink wraps the sink of a writer whose sinks can also be made to
+// write a given file, named with layout. Partition files are closed and reopened
+// to stay under maxOpenPartitions unless resumable is false, e.g. for encrypted
+// files, which can't be appended to.
+func newPartitionSink(whole recordSink, partition func(file string) recordSink, extension string, layout FileLayout, resumable bool) *partitionSink {
+	sink := &partitionSink{whole: whole, partition: partition, extension: extension, layout: layout}
+	if resumable {
+		sink.maxOpen = maxOpenPartitions
+	}
+	return sink
+}
+
+func (s *partitionSink) begin(entity model.EntityInterface, headers []string) error {
+	s.entity, s.headers = entity, headers
+	s.attr, s.column = partitionAttribute(entity)
+	if s.attr == nil {
+		return s.whole.begin(entity, headers)
+	}
+	s.files, s.order = make(map[string]recordSink), nil
+	s.paths, s.open = make(map[string]string), make(map[string]uint64)
+	return nil
+}
+
+func (s *partitionSink) write(record []string, flush bool) error {
+	if s.attr == nil {
+		return s.whole.write(record, flush)
+	}
+	value := record[s.column]
+	sink, exists := s.files[value]
+	_, open := s.open[value]
+	if !open {
+		if err := s.makeRoom(); err != nil {
+			return err
+		}
+	}
+	switch {
+	case !exists:
+		// Values differing only in characters the layout replaces, or in case on
+		// case-insensitive file systems, would share a file
+		file := s.layout.partitionFilePath(s.entity, s.attr, value, s.extension)
+		if other, exists := s.paths[strings.ToLower(file)]; exists {
+			return fmt.Errorf("entity %s: partition values '%s' and '%s' of %s both write %s",
+				s.entity.GetExternalID(), other, value, s.attr.GetName(), file)
+		}
+		s.paths[strings.ToLower(file)] = value
+		sink = s.partition(file)
+		s.files[value] = sink
+		s.order = append(s.order, value)
+		if err := sink.begin(s.entity, s.headers); err != nil {
+			return err
+		}
+	case !open:
+		if err := sink.(resumableSink).resume(); err != nil {
+			return err
+		}
+	}
+	s.writes++
+	s.open[value] = s.writes
+	return sink.write(record, flush)
+}
+
+// makeRoom closes the least recently written partition file when maxOpen are open
+func (s *partitionSink) makeRoom() error {
+	if s.maxOpen <= 0 || len(s.open) < s.maxOpen {
+		return nil
+	}
+	var oldest string
+	first := true
+	for value, written := range s.open {
+		if first || written < s.open[oldest] {
+			oldest, first = value, false
+		}
+	}
+	resumable, ok := s.files[oldest].(resumableSink)
+	if !ok {
+		return nil
+	}
+	delete(s.open, oldest)
+	return resumable.suspend()
+}
+
+func (s *partitionSink) end() error {
+	if s.attr == nil {
+		return s.whole.end()
+	}
+	for _, value := range s.order {
+		if err := s.files[value].end(); err != nil {
+			return err
+		}
+	}
+	s.files, s.open = nil, nil
+	return nil
+}
+
+func (s *partitionSink) close() {
+	s.whole.close()
+	for _, sink := range s.files {
+		sink.close()
+	}
+}
@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// partitionedDefinition is partialInputDefinition with users partitioned by
+// status, whose values are active, inactive or pending
+func partitionedDefinition() *parser.SORDefinition {
+	def := partialInputDefinition()
+	user := def.Entities["user"]
+	user.Attributes[2] = parser.Attribute{Name: "status", ExternalId: "status", Type: "String"}
+	user.PartitionBy = "status"
+	def.Entities["user"] = user
+	return def
+}
+
+func TestPartitionedOutput(t *testing.T) {
+	generate := func(t *testing.T, format string) (*model.Graph, string) {
+		t.Helper()
+		graphInterface, err := model.NewGraph(partitionedDefinition(), 30)
+		require.NoError(t, err)
+		graph := graphInterface.(*model.Graph)
+		dir := t.TempDir()
+		generator := NewDataGenerator(dir, map[string]int{"User": 30, "Group": 5}, false)
+		require.NoError(t, generator.SetOutputFormat(format))
+		require.NoError(t, generator.Generate(graph))
+		return graph, dir
+	}
+
+	t.Run("CSV partitions hold every row once and validate", func(t *testing.T) {
+		graph, dir := generate(t, OutputFormatCSV)
+		assert.NoFileExists(t, filepath.Join(dir, "User.csv"))
+		assert.FileExists(t, filepath.Join(dir, "Group.csv"), "unpartitioned entities keep their file")
+
+		user, _ := graph.GetEntity("User")
+		manifest := NewManifest(graph, OutputFormatCSV, nil)
+		var entry ManifestEntry
+		for _, file := range manifest.Files {
+			if file.Entity == "User" {
+				entry = file
+			}
+		}
+		assert.Equal(t, "User", entry.File)
+		require.NotEmpty(t, entry.Partitions)
+
+		total := 0
+		for _, partition := range entry.Partitions {
+			assert.Equal(t, "User/status="+partition.Value+".csv", partition.File)
+			rows := readCSV(t, filepath.Join(dir, filepath.FromSlash(partition.File)))
+			require.Len(t, rows, partition.Rows+1, "header and rows")
+			for _, row := range rows[1:] {
+				assert.Equal(t, partition.Value, row[2])
+			}
+			total += partition.Rows
+		}
+		assert.Equal(t, user.GetRowCount(), total)
+		assert.Len(t, FileLayout{}.entityDataFiles(dir, user, ".csv"), len(entry.Partitions))
+
+		errors, err := NewValidationProcessor().ValidateExistingCSVFiles(partitionedDefinition(), dir)
+		require.NoError(t, err)
+		assert.Empty(t, errors)
+	})
+
+	t.Run("JSONL partitions", func(t *testing.T) {
+		graph, dir := generate(t, OutputFormatJSONL)
+		user, _ := graph.GetEntity("User")
+		files := FileLayout{}.entityDataFiles(dir, user, ".jsonl")
+		require.NotEmpty(t, files)
+
+		total := 0
+		for _, file := range files {
+			value := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), "status="), ".jsonl")
+			for _, message := range readJSONL(t, file) {
+				assert.Equal(t, value, message.Value["status"])
+				total++
+			}
+		}
+		assert.Equal(t, user.GetRowCount(), total)
+	})
+}
+
+func TestPartitionSink(t *testing.T) {
+	users := func(t *testing.T, statuses ...string) model.EntityInterface {
+		t.Helper()
+		graphInterface, err := model.NewGraph(partitionedDefinition(), 0)
+		require.NoError(t, err)
+		user, _ := graphInterface.GetEntity("User")
+		for i, status := range statuses {
+			require.NoError(t, user.AddRow(model.NewRow(map[string]string{"id": fmt.Sprint("user-", i), "status": status})))
+		}
+		return user
+	}
+	sink := func(dir string) *partitionSink {
+		return newPartitionSink(&csvSink{outputDir: dir}, func(file string) recordSink {
+			return &csvSink{outputDir: dir, path: file}
+		}, ".csv", FileLayout{}, true)
+	}
+
+	t.Run("should reopen partitions closed to stay under the open file limit", func(t *testing.T) {
+		dir := t.TempDir()
+		partitions := sink(dir)
+		partitions.maxOpen = 2
+		user := users(t, "a", "b", "c", "a", "b", "c", "a")
+		require.NoError(t, writeRecords([]model.EntityInterface{user}, partitions, nil, nil))
+
+		for value, ids := range map[string][]string{"a": {"user-0", "user-3", "user-6"}, "b": {"user-1", "user-4"}, "c": {"user-2", "user-5"}} {
+			rows := readCSV(t, filepath.Join(dir, "User", "status="+value+".csv"))
+			require.Len(t, rows, len(ids)+1, "one header and every row of %s", value)
+			for i, id := range ids {
+				assert.Equal(t, id, rows[i+1][0])
+			}
+		}
+	})
+
+	t.Run("should reject values sharing a file", func(t *testing.T) {
+		user := users(t, "EMEA", "emea")
+		err := writeRecords([]model.EntityInterface{user}, sink(t.TempDir()), nil, nil)
+		assert.ErrorContains(t, err, "partition values 'EMEA' and 'emea' of status both write User/status=emea.csv")
+	})
+}
@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// maxDistinctPayloadAttempts bounds how often a row's payload is regenerated while
+// it repeats another row's
+const maxDistinctPayloadAttempts = 100
+
+// shapePayloads makes the payloads of an entity's rows, its generated non-key
+// columns, distinct or repeated as the entity's payloads declare
+func (g *FieldGenerator) shapePayloads(entity model.EntityInterface, payload, regenerable []model.AttributeInterface) error {
+	payloads := entity.GetPayloads()
+	switch {
+	case payloads == nil || len(payload) == 0:
+		return nil
+	case payloads.Distinct:
+		return g.distinctPayloads(entity, payload, regenerable)
+	case payloads.DuplicateShare > 0:
+		g.duplicatePayloads(entity, payload, payloads.DuplicateShare)
+	}
+	return nil
+}
+
+// distinctPayloads regenerates the regenerable columns of rows whose payload repeats
+// an earlier row's
+func (g *FieldGenerator) distinctPayloads(entity model.EntityInterface, payload, regenerable []model.AttributeInterface) error {
+	seen := make(map[string]bool, entity.GetRowCount())
+	return entity.ForEachRow(func(row *model.Row, index int) error {
+		key := payloadKey(row, payload)
+		for attempt := 0; seen[key] && attempt < maxDistinctPayloadAttempts; attempt++ {
+			regenerated := false
+			for _, attr := range regenerable {
+				if !row.IsPinned(attr.GetName()) {
+					row.SetValue(attr.GetName(), g.generateFieldValue(attr))
+					regenerated = true
+				}
+			}
+			if !regenerated {
+				break
+			}
+			key = payloadKey(row, payload)
+		}
+		if seen[key] {
+			return fmt.Errorf("row %d of entity %s repeats another row's payload, and regenerating its columns didn't make it distinct",
+				index, entity.GetExternalID())
+		}
+		seen[key] = true
+		return nil
+	})
+}
+
+// duplicatePayloads makes the given share of rows copy the payload of another row
+// that isn't itself a copy. Values provided by partial input are kept.
+func (g *FieldGenerator) duplicatePayloads(entity model.EntityInterface, payload []model.AttributeInterface, share float64) {
+	rowCount := entity.GetRowCount()
+	copies := min(int(math.Round(share*float64(rowCount))), rowCount-1)
+	if copies <= 0 {
+		return
+	}
+
+	order := make([]int, rowCount)
+	for i := range order {
+		order[i] = i
+	}
+	g.faker.ShuffleInts(order)
+	originals := order[copies:]
+	for _, index := range order[:copies] {
+		original := originals[g.faker.Number(0, len(originals)-1)]
+		source, target := entity.GetRowByIndex(original), entity.GetRowByIndex(index)
+		for _, attr := range payload {
+			if !target.IsPinned(attr.GetName()) {
+				target.SetValue(attr.GetName(), source.GetValue(attr.GetName()))
+			}
+		}
+		g.audit.Record(AuditRecord{Decision: AuditDuplicatePayload, Entity: entity.GetExternalID(), Rows: []int{index, original}})
+	}
+}
+
+// regenerableAttributes returns the payload attributes whose values are drawn on
+// their own per row, so redrawing them keeps constants, sequences, timelines,
+// effective dating, correlations, scoped uniqueness, lists, population people and coherent addresses
+// intact
+func regenerableAttributes(entity model.EntityInterface, payload []model.AttributeInterface, people, addresses map[string]string) []model.AttributeInterface {
+	shaped := make(map[string]bool)
+	for _, correlation := range entity.GetCorrelations() {
+		for _, name := range correlation.Attributes {
+			shaped[name] = true
+		}
+	}
+	if timeline := entity.GetTimeline(); timeline != nil {
+		shaped[timeline.Attribute] = true
+	}
+	if dating := entity.GetEffectiveDating(); dating != nil {
+		shaped[dating.ValidFrom], shaped[dating.ValidTo], shaped[dating.Current] = true, true, true
+	}
+
+	var regenerable []model.AttributeInterface
+	for _, attr := range payload {
+		_, person := people[attr.GetName()]
+		_, address := addresses[attr.GetName()]
+		if person || address || shaped[attr.GetName()] ||
+			attr.GetConst() != nil || attr.GetUniqueWithin() != "" || attr.GetListEncoding() != "" ||
+			sequenceGenerator(attr) != nil || hierarchicalCodeGenerator(attr) != nil || cidrGenerator(attr) != nil {
+			continue
+		}
+		regenerable = append(regenerable, attr)
+	}
+	return regenerable
+}
+
+// payloadKey joins a row's payload values into a comparable key
+func payloadKey(row *model.Row, payload []model.AttributeInterface) string {
+	values := make([]string, len(payload))
+	for i, attr := range payload {
+		values[i] = row.GetValue(attr.GetName())
+	}
+	return strings.Join(values, "\x1f")
+}
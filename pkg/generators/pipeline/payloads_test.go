@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloads(t *testing.T) {
+	// status and enabled allow only six distinct payloads
+	definition := func(payloads *parser.Payloads) *parser.SORDefinition {
+		return &parser.SORDefinition{
+			DisplayName: "Payloads SOR",
+			Entities: map[string]parser.Entity{
+				"user": {
+					DisplayName: "User",
+					ExternalId:  "User",
+					Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+						{Name: "status", ExternalId: "status", Type: "String"},
+						{Name: "enabled", ExternalId: "enabled", Type: "Boolean"},
+					},
+					Payloads: payloads,
+				},
+			},
+		}
+	}
+	payloadsOf := func(t *testing.T, def *parser.SORDefinition, rows int) ([]string, error) {
+		t.Helper()
+		graph := buildGraphWithIDs(t, def, rows)
+		if err := NewFieldGenerator().GenerateFields(graph); err != nil {
+			return nil, err
+		}
+		entity, _ := graph.GetEntity("User")
+		var payloads []string
+		for i := 0; i < entity.GetRowCount(); i++ {
+			row := entity.GetRowByIndex(i)
+			payloads = append(payloads, row.GetValue("status")+"/"+row.GetValue("enabled"))
+		}
+		return payloads, nil
+	}
+
+	t.Run("distinct payloads differ", func(t *testing.T) {
+		for attempt := 0; attempt < 10; attempt++ {
+			payloads, err := payloadsOf(t, definition(&parser.Payloads{Distinct: true}), 6)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []string{
+				"active/true", "active/false", "inactive/true", "inactive/false", "pending/true", "pending/false",
+			}, payloads)
+		}
+	})
+
+	t.Run("distinct payloads fail when too few exist", func(t *testing.T) {
+		_, err := payloadsOf(t, definition(&parser.Payloads{Distinct: true}), 7)
+		assert.ErrorContains(t, err, "repeats another row's payload")
+	})
+
+	t.Run("a duplicate share copies payloads onto other rows", func(t *testing.T) {
+		def := definition(&parser.Payloads{DuplicateShare: 0.25})
+		def.Entities["user"].Attributes[1].Name = "nickname" // Names vary more than statuses
+		graph := buildGraphWithIDs(t, def, 40)
+		audit := NewAuditLog()
+		generator := NewFieldGenerator().(*FieldGenerator)
+		generator.SetAuditLog(audit)
+		require.NoError(t, generator.GenerateFields(graph))
+
+		var copies [][]int
+		for _, record := range audit.Records() {
+			if record.Decision == AuditDuplicatePayload {
+				copies = append(copies, record.Rows)
+			}
+		}
+		require.Len(t, copies, 10)
+
+		entity, _ := graph.GetEntity("User")
+		copied := make(map[int]bool)
+		for _, pair := range copies {
+			copied[pair[0]] = true
+		}
+		for _, pair := range copies {
+			duplicate, original := entity.GetRowByIndex(pair[0]), entity.GetRowByIndex(pair[1])
+			assert.False(t, copied[pair[1]], "row %d copies a copy", pair[0])
+			assert.NotEqual(t, original.GetValue("id"), duplicate.GetValue("id"))
+			assert.Equal(t, original.GetValue("nickname"), duplicate.GetValue("nickname"))
+			assert.Equal(t, original.GetValue("enabled"), duplicate.GetValue("enabled"))
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package pipeline
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// ibanFormats maps countries with all-numeric BBANs to their BBAN length
+var ibanFormats = []struct {
+	country    string
+	bbanLength int
+}{
+	{"BE", 12},
+	{"DE", 18},
+	{"ES", 20},
+	{"PL", 24},
+}
+
+// piiValue generates a value for a built-in PII generator. With clearlyFake set, values
+// use reserved or invalid formats (documentation IP ranges, failing checksums, never-issued
+// prefixes) so they cannot be mistaken for real data.
+func piiValue(generator *parser.Generator, clearlyFake bool, faker *gofakeit.Faker) string {
+	switch generator.Type {
+	case parser.GeneratorSSN:
+		return ssnValue(clearlyFake, faker)
+	case parser.GeneratorIBAN:
+		return ibanValue(clearlyFake, faker)
+	case parser.GeneratorCreditCard:
+		return creditCardValue(clearlyFake, faker)
+	case parser.GeneratorNationalID:
+		return nationalIDValue(generator.Locale, clearlyFake, faker)
+	case parser.GeneratorIPv4:
+		if clearlyFake {
+			// RFC 5737 documentation ranges
+			block := faker.RandomString([]string{"192.0.2", "198.51.100", "203.0.113"})
+			return fmt.Sprintf("%s.%d", block, faker.Number(1, 254))
+		}
+		return faker.IPv4Address()
+	case parser.GeneratorIPv6:
+		if clearlyFake {
+			// RFC 3849 documentation prefix
+			return fmt.Sprintf("2001:db8:%x:%x:%x:%x:%x:%x", faker.Uint16(), faker.Uint16(),
+				faker.Uint16(), faker.Uint16(), faker.Uint16(), faker.Uint16())
+		}
+		return faker.IPv6Address()
+	case parser.GeneratorMAC:
+		if clearlyFake {
+			// Locally administered, never assigned to a vendor
+			return fmt.Sprintf("02:00:00:%02x:%02x:%02x", faker.Uint8(), faker.Uint8(), faker.Uint8())
+		}
+		if generator.Vendor != "" {
+			return macValue(generator.Vendor, faker)
+		}
+		return faker.MacAddress()
+	case parser.GeneratorHostname:
+		return hostnameValue(generator, "", faker)
+	}
+	return faker.Word()
+}
+
+// ssnValue returns a US social security number; fake numbers use the never-issued area 000
+func ssnValue(clearlyFake bool, faker *gofakeit.Faker) string {
+	area := 0
+	if !clearlyFake {
+		area = faker.Number(1, 899)
+		if area == 666 {
+			area = 665
+		}
+	}
+	return fmt.Sprintf("%03d-%02d-%04d", area, faker.Number(1, 99), faker.Number(1, 9999))
+}
+
+// ibanValue returns an IBAN with valid mod-97 check digits; fake IBANs use the
+// non-existent country XX and check digits 00, which are never valid
+func ibanValue(clearlyFake bool, faker *gofakeit.Faker) string {
+	format := ibanFormats[faker.Number(0, len(ibanFormats)-1)]
+	bban := randomDigits(format.bbanLength, faker)
+	if clearlyFake {
+		return "XX00" + bban
+	}
+	return format.country + ibanCheckDigits(format.country, bban) + bban
+}
+
+// ibanCheckDigits computes the ISO 13616 check digits for a numeric BBAN
+func ibanCheckDigits(country, bban string) string {
+	var rearranged strings.Builder
+	rearranged.WriteString(bban)
+	for _, letter := range country + "00" {
+		if letter >= 'A' && letter <= 'Z' {
+			rearranged.WriteString(strconv.Itoa(int(letter-'A') + 10))
+		} else {
+			rearranged.WriteRune(letter)
+		}
+	}
+	number, _ := new(big.Int).SetString(rearranged.String(), 10)
+	remainder := new(big.Int).Mod(number, big.NewInt(97)).Int64()
+	return fmt.Sprintf("%02d", 98-remainder)
+}
+
+// creditCardValue returns a Visa, Mastercard or Amex number with a valid Luhn check digit;
+// fake numbers use the unassigned issuer prefix 0000 and fail the Luhn check
+func creditCardValue(clearlyFake bool, faker *gofakeit.Faker) string {
+	if clearlyFake {
+		payload := "0000" + randomDigits(11, faker)
+		return payload + strconv.Itoa((luhnCheckDigit(payload)+1)%10)
+	}
+
+	var payload string
+	switch faker.Number(0, 2) {
+	case 0:
+		payload = "4" + randomDigits(14, faker)
+	case 1:
+		payload = strconv.Itoa(faker.Number(51, 55)) + randomDigits(13, faker)
+	default:
+		payload = faker.RandomString([]string{"34", "37"}) + randomDigits(12, faker)
+	}
+	return payload + strconv.Itoa(luhnCheckDigit(payload))
+}
+
+// luhnCheckDigit returns the digit that makes payload+digit pass the Luhn check
+func luhnCheckDigit(payload string) int {
+	sum := 0
+	double := true // The check digit is appended, so the rightmost payload digit is doubled
+	for i := len(payload) - 1; i >= 0; i-- {
+		digit := int(payload[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return (10 - sum%10) % 10
+}
+
+// nationalIDValue returns a national identifier in the format of the given locale
+func nationalIDValue(locale string, clearlyFake bool, faker *gofakeit.Faker) string {
+	switch locale {
+	case "GB":
+		// National Insurance number; QQ is the prefix reserved for examples
+		prefix := "QQ"
+		if !clearlyFake {
+			prefix = faker.RandomString([]string{"AB", "CE", "HJ", "JK", "LM", "NP", "PR", "SW", "TY", "WZ"})
+		}
+		return prefix + randomDigits(6, faker) + faker.RandomString([]string{"A", "B", "C", "D"})
+	case "CA":
+		// Social Insurance Number; 0 is never assigned as the first digit
+		first := "0"
+		if !clearlyFake {
+			first = faker.RandomString([]string{"1", "2", "3", "4", "5", "6", "7", "9"})
+		}
+		payload := first + randomDigits(7, faker)
+		sin := payload + strconv.Itoa(luhnCheckDigit(payload))
+		return sin[:3] + "-" + sin[3:6] + "-" + sin[6:]
+	case "IN":
+		// Aadhaar number; real numbers never start with 0 or 1
+		first := "0"
+		if !clearlyFake {
+			first = strconv.Itoa(faker.Number(2, 9))
+		}
+		aadhaar := first + randomDigits(11, faker)
+		return aadhaar[:4] + " " + aadhaar[4:8] + " " + aadhaar[8:]
+	default:
+		return ssnValue(clearlyFake, faker)
+	}
+}
+
+// randomDigits returns n random decimal digits
+func randomDigits(n int, faker *gofakeit.Faker) string {
+	return faker.Numerify(strings.Repeat("#", n))
+}
@@ -0,0 +1,194 @@
+package pipeline
+
+import (
+	"math/big"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// luhnValid reports whether a digit string passes the Luhn check
+func luhnValid(number string) bool {
+	return luhnCheckDigit(number[:len(number)-1]) == int(number[len(number)-1]-'0')
+}
+
+// ibanValid reports whether an IBAN's check digits are correct (remainder 1 mod 97)
+func ibanValid(iban string) bool {
+	rearranged := iban[4:] + iban[:4]
+	var digits strings.Builder
+	for _, c := range rearranged {
+		if c >= 'A' && c <= 'Z' {
+			digits.WriteString(big.NewInt(int64(c-'A') + 10).String())
+		} else {
+			digits.WriteRune(c)
+		}
+	}
+	number, ok := new(big.Int).SetString(digits.String(), 10)
+	return ok && new(big.Int).Mod(number, big.NewInt(97)).Int64() == 1
+}
+
+func TestLuhnCheckDigit(t *testing.T) {
+	// Well-known test card numbers
+	assert.True(t, luhnValid("4111111111111111"))
+	assert.True(t, luhnValid("378282246310005"))
+	assert.False(t, luhnValid("4111111111111112"))
+}
+
+func TestIBANCheckDigits(t *testing.T) {
+	// Published example IBAN
+	assert.Equal(t, "89", ibanCheckDigits("DE", "370400440532013000"))
+}
+
+func TestPIIValue(t *testing.T) {
+	tests := []struct {
+		generator parser.Generator
+		real      func(t *testing.T, value string)
+		fake      func(t *testing.T, value string)
+	}{
+		{
+			generator: parser.Generator{Type: parser.GeneratorSSN},
+			real: func(t *testing.T, value string) {
+				assert.Regexp(t, `^\d{3}-\d{2}-\d{4}$`, value)
+				assert.False(t, strings.HasPrefix(value, "000") || strings.HasPrefix(value, "666"))
+			},
+			fake: func(t *testing.T, value string) {
+				assert.Regexp(t, `^000-\d{2}-\d{4}$`, value)
+			},
+		},
+		{
+			generator: parser.Generator{Type: parser.GeneratorCreditCard},
+			real: func(t *testing.T, value string) {
+				assert.Regexp(t, `^(4\d{15}|5[1-5]\d{14}|3[47]\d{13})$`, value)
+				assert.True(t, luhnValid(value), "card %s should pass the Luhn check", value)
+			},
+			fake: func(t *testing.T, value string) {
+				assert.Regexp(t, `^0000\d{12}$`, value)
+				assert.False(t, luhnValid(value), "fake card %s should fail the Luhn check", value)
+			},
+		},
+		{
+			generator: parser.Generator{Type: parser.GeneratorIBAN},
+			real: func(t *testing.T, value string) {
+				assert.Regexp(t, `^(BE|DE|ES|PL)\d{14,26}$`, value)
+				assert.True(t, ibanValid(value), "IBAN %s should have valid check digits", value)
+			},
+			fake: func(t *testing.T, value string) {
+				assert.Regexp(t, `^XX00\d+$`, value)
+			},
+		},
+		{
+			generator: parser.Generator{Type: parser.GeneratorNationalID, Locale: "GB"},
+			real: func(t *testing.T, value string) {
+				assert.Regexp(t, `^[A-Z]{2}\d{6}[A-D]$`, value)
+				assert.False(t, strings.HasPrefix(value, "QQ"))
+			},
+			fake: func(t *testing.T, value string) {
+				assert.Regexp(t, `^QQ\d{6}[A-D]$`, value)
+			},
+		},
+		{
+			generator: parser.Generator{Type: parser.GeneratorNationalID, Locale: "CA"},
+			real: func(t *testing.T, value string) {
+				assert.Regexp(t, `^[1-79]\d{2}-\d{3}-\d{3}$`, value)
+				assert.True(t, luhnValid(strings.ReplaceAll(value, "-", "")))
+			},
+			fake: func(t *testing.T, value string) {
+				assert.Regexp(t, `^0\d{2}-\d{3}-\d{3}$`, value)
+			},
+		},
+		{
+			generator: parser.Generator{Type: parser.GeneratorNationalID, Locale: "IN"},
+			real: func(t *testing.T, value string) {
+				assert.Regexp(t, `^[2-9]\d{3} \d{4} \d{4}$`, value)
+			},
+			fake: func(t *testing.T, value string) {
+				assert.Regexp(t, `^0\d{3} \d{4} \d{4}$`, value)
+			},
+		},
+		{
+			generator: parser.Generator{Type: parser.GeneratorIPv4},
+			real: func(t *testing.T, value string) {
+				ip := net.ParseIP(value)
+				require.NotNil(t, ip)
+				assert.NotNil(t, ip.To4())
+			},
+			fake: func(t *testing.T, value string) {
+				assert.Regexp(t, `^(192\.0\.2|198\.51\.100|203\.0\.113)\.\d{1,3}$`, value)
+			},
+		},
+		{
+			generator: parser.Generator{Type: parser.GeneratorIPv6},
+			real: func(t *testing.T, value string) {
+				assert.NotNil(t, net.ParseIP(value))
+			},
+			fake: func(t *testing.T, value string) {
+				_, documentation, _ := net.ParseCIDR("2001:db8::/32")
+				assert.True(t, documentation.Contains(net.ParseIP(value)), "%s should be in 2001:db8::/32", value)
+			},
+		},
+		{
+			generator: parser.Generator{Type: parser.GeneratorMAC},
+			real: func(t *testing.T, value string) {
+				_, err := net.ParseMAC(value)
+				assert.NoError(t, err)
+			},
+			fake: func(t *testing.T, value string) {
+				assert.Regexp(t, regexp.MustCompile(`^02:00:00:[0-9a-f]{2}:[0-9a-f]{2}:[0-9a-f]{2}$`), value)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		name := tt.generator.Type + tt.generator.Locale
+		t.Run(name, func(t *testing.T) {
+			faker := gofakeit.New(0)
+			for i := 0; i < 50; i++ {
+				tt.real(t, piiValue(&tt.generator, false, faker))
+				tt.fake(t, piiValue(&tt.generator, true, faker))
+			}
+		})
+	}
+}
+
+func TestFieldGenerator_GeneratorHints(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "PII SOR",
+		Description: "SOR with generator hints",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User",
+				ExternalId:  "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "name", ExternalId: "name", Type: "String", Generator: &parser.Generator{Type: parser.GeneratorSSN}},
+					{Name: "email", ExternalId: "email", Type: "String"},
+					{Name: "phone", ExternalId: "phone", Type: "String"},
+				},
+			},
+		},
+	}
+
+	graphInterface, err := model.NewGraph(def, 10)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"User": 10}))
+
+	generator := &FieldGenerator{}
+	generator.SetClearlyFakePII(true)
+	require.NoError(t, generator.GenerateFields(graph))
+
+	user, _ := graph.GetEntity("User")
+	for i := 0; i < user.GetRowCount(); i++ {
+		row := user.GetRowByIndex(i)
+		assert.Regexp(t, `^000-\d{2}-\d{4}$`, row.GetValue("name"), "generator hint takes precedence over the name pattern")
+		assert.True(t, strings.HasSuffix(row.GetValue("email"), "@example.com"))
+		assert.Regexp(t, `^555-01\d{2}$`, row.GetValue("phone"))
+	}
+}
@@ -0,0 +1,176 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// MaxPopulation is the largest population with distinct six-digit employee IDs
+const MaxPopulation = 900000
+
+// Person attributes a population can fill in
+const (
+	personName       = "name"
+	personFirstName  = "firstName"
+	personLastName   = "lastName"
+	personEmail      = "email"
+	personEmployeeID = "employeeId"
+	personUsername   = "username"
+)
+
+// personAttributes maps normalized attribute names (see personAttributeName) to the
+// person field they hold
+var personAttributes = map[string]string{
+	"name":              personName,
+	"displayname":       personName,
+	"fullname":          personName,
+	"firstname":         personFirstName,
+	"givenname":         personFirstName,
+	"lastname":          personLastName,
+	"surname":           personLastName,
+	"familyname":        personLastName,
+	"email":             personEmail,
+	"emailaddress":      personEmail,
+	"mail":              personEmail,
+	"userprincipalname": personEmail,
+	"employeeid":        personEmployeeID,
+	"employeenumber":    personEmployeeID,
+	"username":          personUsername,
+	"login":             personUsername,
+	"samaccountname":    personUsername,
+}
+
+// Person is one synthetic human of a population
+type Person struct {
+	FirstName  string
+	LastName   string
+	Email      string
+	EmployeeID string
+	Username   string
+}
+
+// Population is a fixed set of synthetic people derived from a seed. Row i of every
+// person-like entity is person i (wrapping around), so the same people appear across
+// entities, and across SORs generated with the same seed and population size.
+type Population struct {
+	people []Person
+}
+
+// NewPopulation derives size people from seed. It draws from its own random source,
+// so the people don't depend on the SOR or on what else is generated. With
+// clearlyFake set, emails use the reserved example.com domain.
+func NewPopulation(size int, seed int64, clearlyFake bool) (*Population, error) {
+	if size < 1 || size > MaxPopulation {
+		return nil, fmt.Errorf("population size must be between 1 and %d, got %d", MaxPopulation, size)
+	}
+
+	faker := gofakeit.New(seed)
+	domain := "example.com"
+	if !clearlyFake {
+		domain = faker.DomainName()
+	}
+
+	population := &Population{people: make([]Person, 0, size)}
+	employeeIDs := make(map[int]bool, size)
+	usernames := make(map[string]bool, size)
+	for len(population.people) < size {
+		first, last := faker.FirstName(), faker.LastName()
+
+		// Employee IDs and usernames identify people, so both stay distinct
+		employeeID := faker.Number(100000, 999999)
+		for employeeIDs[employeeID] {
+			employeeID = faker.Number(100000, 999999)
+		}
+		employeeIDs[employeeID] = true
+
+		base := strings.ToLower(personUsernamePart(first) + "." + personUsernamePart(last))
+		username := base
+		for suffix := 2; usernames[username]; suffix++ {
+			username = fmt.Sprintf("%s%d", base, suffix)
+		}
+		usernames[username] = true
+
+		population.people = append(population.people, Person{
+			FirstName:  first,
+			LastName:   last,
+			Email:      username + "@" + domain,
+			EmployeeID: fmt.Sprintf("E%06d", employeeID),
+			Username:   username,
+		})
+	}
+	return population, nil
+}
+
+// Size returns the number of people in the population
+func (p *Population) Size() int {
+	return len(p.people)
+}
+
+// Person returns the person for a row index
+func (p *Population) Person(index int) Person {
+	return p.people[index%len(p.people)]
+}
+
+// personFields returns the person field each attribute of a person-like entity
+// holds, keyed by attribute name. An entity is person-like when it has a person
+// attribute besides a plain name, so groups and roles keep their generated names.
+// Attributes with a generator hint or a list encoding are left to generate.
+func personFields(attributes []model.AttributeInterface) map[string]string {
+	fields := make(map[string]string)
+	personLike := false
+	for _, attr := range attributes {
+		if attr.GetGenerator() != nil || attr.GetListEncoding() != "" {
+			continue
+		}
+		if field, exists := personAttributes[personAttributeName(attr.GetName())]; exists {
+			fields[attr.GetName()] = field
+			personLike = personLike || field != personName
+		}
+	}
+	if !personLike {
+		return nil
+	}
+	return fields
+}
+
+// personAttributeName normalizes an attribute name for personAttributes, keeping
+// the last segment of nested names such as profile__email or profile.email
+func personAttributeName(name string) string {
+	if i := strings.LastIndex(name, "__"); i >= 0 {
+		name = name[i+2:]
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+}
+
+// value returns the person's value for a person field
+func (person Person) value(field string) string {
+	switch field {
+	case personFirstName:
+		return person.FirstName
+	case personLastName:
+		return person.LastName
+	case personEmail:
+		return person.Email
+	case personEmployeeID:
+		return person.EmployeeID
+	case personUsername:
+		return person.Username
+	}
+	return person.FirstName + " " + person.LastName
+}
+
+// personUsernamePart keeps the letters of a name for use in usernames and emails
+func personUsernamePart(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			return r
+		}
+		return -1
+	}, name)
+}
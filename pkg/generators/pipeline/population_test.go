@@ -0,0 +1,116 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPopulation(t *testing.T) {
+	population, err := NewPopulation(200, 42, false)
+	require.NoError(t, err)
+	assert.Equal(t, 200, population.Size())
+
+	again, err := NewPopulation(200, 42, false)
+	require.NoError(t, err)
+	assert.Equal(t, population, again, "the same seed gives the same people")
+
+	other, err := NewPopulation(200, 7, false)
+	require.NoError(t, err)
+	assert.NotEqual(t, population.Person(0), other.Person(0))
+
+	employeeIDs := make(map[string]bool)
+	usernames := make(map[string]bool)
+	for i := 0; i < population.Size(); i++ {
+		person := population.Person(i)
+		assert.Regexp(t, `^E\d{6}$`, person.EmployeeID)
+		assert.True(t, strings.HasPrefix(person.Email, person.Username+"@"))
+		employeeIDs[person.EmployeeID] = true
+		usernames[person.Username] = true
+	}
+	assert.Len(t, employeeIDs, 200)
+	assert.Len(t, usernames, 200)
+	assert.Equal(t, population.Person(3), population.Person(203), "rows past the population wrap around")
+
+	t.Run("clearly fake emails use example.com", func(t *testing.T) {
+		population, err := NewPopulation(10, 42, true)
+		require.NoError(t, err)
+		assert.True(t, strings.HasSuffix(population.Person(0).Email, "@example.com"))
+	})
+
+	t.Run("size is bounded", func(t *testing.T) {
+		_, err := NewPopulation(0, 42, false)
+		assert.ErrorContains(t, err, "population size must be between 1 and 900000")
+		_, err = NewPopulation(MaxPopulation+1, 42, false)
+		assert.Error(t, err)
+	})
+}
+
+func TestFieldGenerator_Population(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Population",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "displayName", ExternalId: "displayName", Type: "String"},
+					{Name: "profile__email", ExternalId: "profile__email", Type: "String"},
+					{Name: "login", ExternalId: "login", Type: "String"},
+				},
+			},
+			"worker": {
+				DisplayName: "Worker", ExternalId: "Worker",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "first_name", ExternalId: "first_name", Type: "String"},
+					{Name: "Mail", ExternalId: "Mail", Type: "String"},
+					{Name: "employeeNumber", ExternalId: "employeeNumber", Type: "String"},
+				},
+			},
+			"group": {
+				DisplayName: "Group", ExternalId: "Group",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "name", ExternalId: "name", Type: "String"},
+				},
+			},
+		},
+	}
+	graphInterface, err := model.NewGraph(def, 30)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"User": 30, "Worker": 25, "Group": 5}))
+
+	population, err := NewPopulation(20, 42, false)
+	require.NoError(t, err)
+	generator := &FieldGenerator{}
+	generator.SetPopulation(population)
+	require.NoError(t, generator.GenerateFields(graph))
+
+	user, _ := graph.GetEntity("User")
+	worker, _ := graph.GetEntity("Worker")
+	for i := 0; i < 25; i++ {
+		person := population.Person(i)
+		userRow, workerRow := user.GetRowByIndex(i), worker.GetRowByIndex(i)
+		assert.Equal(t, person.FirstName+" "+person.LastName, userRow.GetValue("displayName"))
+		assert.Equal(t, person.Email, userRow.GetValue("profile__email"))
+		assert.Equal(t, person.Username, userRow.GetValue("login"))
+		assert.Equal(t, person.FirstName, workerRow.GetValue("first_name"))
+		assert.Equal(t, person.Email, workerRow.GetValue("Mail"), "the same person appears in both entities")
+		assert.Equal(t, person.EmployeeID, workerRow.GetValue("employeeNumber"))
+	}
+
+	names := make(map[string]bool)
+	for i := 0; i < population.Size(); i++ {
+		names[population.Person(i).FirstName+" "+population.Person(i).LastName] = true
+	}
+	group, _ := graph.GetEntity("Group")
+	for i := 0; i < group.GetRowCount(); i++ {
+		assert.False(t, names[group.GetRowByIndex(i).GetValue("name")], "groups aren't person-like")
+	}
+}
@@ -0,0 +1,159 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// maxProfileTailAttempts bounds the values drawn while filling a column's tail of
+// distinct values, for columns whose generator can't produce that many
+const maxProfileTailAttempts = 10
+
+// profileSampler draws values for the columns an entity's statistical profile
+// describes: empty at the column's null rate, otherwise one of its top values as
+// often as the profiled rows held it, or else one of the column's remaining
+// distinct values
+type profileSampler struct {
+	columns  map[string]*profiledColumn // Keyed by attribute name
+	rows     int                        // Rows of the entity being generated, capping tail sizes
+	generate func(model.AttributeInterface) string
+	faker    *gofakeit.Faker
+}
+
+// profiledColumn is the sampling state of one profiled column
+type profiledColumn struct {
+	attr       model.AttributeInterface
+	nullRate   float64
+	top        []string
+	cumulative []int           // Running totals of the top values' counts
+	topShare   float64         // Share of non-null values that are top values
+	tailSize   int             // Distinct values besides the top ones; 0 when unknown
+	tail       []string        // The tail values, drawn on first use when tailSize is known
+	topValues  map[string]bool // Values the tail must not repeat
+	min, max   *float64        // Numeric range of the tail values, if profiled
+}
+
+// newProfileSampler builds a sampler for the entity's profile, drawing from faker
+// and generating tail values with generate. Returns nil when the entity has no
+// profile.
+func newProfileSampler(entity model.EntityInterface, generate func(model.AttributeInterface) string, faker *gofakeit.Faker) (*profileSampler, error) {
+	profile := entity.GetProfile()
+	if profile == nil {
+		return nil, nil
+	}
+
+	sampler := &profileSampler{columns: make(map[string]*profiledColumn, len(profile.Columns)), rows: entity.GetRowCount(), generate: generate, faker: faker}
+	for key, column := range profile.Columns {
+		attr, exists := entity.GetAttribute(key)
+		if !exists {
+			attr, exists = entity.GetAttributeByExternalID(key)
+		}
+		if !exists {
+			return nil, fmt.Errorf("profile column '%s' does not match any attribute", key)
+		}
+
+		profiled := &profiledColumn{
+			attr:      attr,
+			nullRate:  column.NullRate,
+			topValues: make(map[string]bool, len(column.TopValues)),
+			min:       column.Min,
+			max:       column.Max,
+		}
+		total := 0
+		for _, top := range column.TopValues {
+			total += top.Count
+			profiled.top = append(profiled.top, top.Value)
+			profiled.cumulative = append(profiled.cumulative, total)
+			profiled.topValues[top.Value] = true
+		}
+		if total > 0 {
+			profiled.topShare = math.Min(1, float64(total)/(float64(profile.Rows)*(1-column.NullRate)))
+		}
+		if column.Distinct > 0 {
+			profiled.tailSize = column.Distinct - len(column.TopValues)
+			if profiled.tailSize == 0 && total > 0 {
+				profiled.topShare = 1 // Every value is a top value
+			}
+		}
+		sampler.columns[attr.GetName()] = profiled
+	}
+	return sampler, nil
+}
+
+// value returns the next value of a profiled attribute, and whether it is profiled
+func (s *profileSampler) value(name string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	column, exists := s.columns[name]
+	if !exists {
+		return "", false
+	}
+
+	if column.nullRate > 0 && s.faker.Float64Range(0, 1) < column.nullRate {
+		return "", true
+	}
+	if len(column.top) > 0 && s.faker.Float64Range(0, 1) < column.topShare {
+		total := column.cumulative[len(column.cumulative)-1]
+		pick := s.faker.Number(1, total)
+		return column.top[sort.SearchInts(column.cumulative, pick)], true
+	}
+	if column.tailSize == 0 {
+		return s.tailValue(column), true
+	}
+
+	// A known number of distinct values: draw them once, up to one per row, then
+	// pick among them
+	if column.tail == nil {
+		size := min(column.tailSize, s.rows)
+		seen := make(map[string]bool, size)
+		for attempt := 0; len(column.tail) < size && attempt < maxProfileTailAttempts*size; attempt++ {
+			value := s.tailValue(column)
+			if !seen[value] {
+				seen[value] = true
+				column.tail = append(column.tail, value)
+			}
+		}
+		if len(column.tail) == 0 {
+			column.tail = append(column.tail, s.tailValue(column))
+		}
+	}
+	return column.tail[s.faker.Number(0, len(column.tail)-1)], true
+}
+
+// tailValue draws a value outside the column's top values: from its numeric range
+// when the profile gives one, otherwise from the attribute's usual generator
+func (s *profileSampler) tailValue(column *profiledColumn) string {
+	var value string
+	for attempt := 0; attempt < maxProfileTailAttempts; attempt++ {
+		if column.min != nil && column.max != nil {
+			value = numericInRange(column.attr.GetDataType(), *column.min, *column.max, s.faker)
+		} else {
+			value = s.generate(column.attr)
+		}
+		if !column.topValues[value] {
+			break
+		}
+	}
+	return value
+}
+
+// numericInRange draws a number of the data type between low and high inclusive
+// from faker
+func numericInRange(dataType string, low, high float64, faker *gofakeit.Faker) string {
+	switch dataType {
+	case "Integer", "Int64":
+		value := int64(low) + int64(faker.Float64Range(0, 1)*(high-low+1))
+		if value > int64(high) {
+			value = int64(high)
+		}
+		return strconv.FormatInt(value, 10)
+	default:
+		return fmt.Sprintf("%.2f", faker.Float64Range(low, high))
+	}
+}
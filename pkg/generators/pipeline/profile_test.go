@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldGenerator_Profile(t *testing.T) {
+	profilePath := filepath.Join(t.TempDir(), "user.json")
+	require.NoError(t, os.WriteFile(profilePath, []byte(`{"rows": 1000, "columns": {
+		"userStatus": {"nullRate": 0.2, "distinct": 3, "topValues": [{"value": "active", "count": 600}, {"value": "suspended", "count": 200}]},
+		"department": {"distinct": 5, "topValues": [{"value": "Engineering", "count": 500}]},
+		"age": {"nullRate": 0.1, "min": 18, "max": 65}}}`), 0600))
+
+	def := &parser.SORDefinition{
+		DisplayName: "Profile SOR",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User",
+				ExternalId:  "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "status", ExternalId: "userStatus", Type: "String"},
+					{Name: "department", ExternalId: "department", Type: "String"},
+					{Name: "age", ExternalId: "age", Type: "Integer"},
+					{Name: "title", ExternalId: "title", Type: "String"},
+				},
+				Profile: profilePath,
+			},
+		},
+	}
+	const rows = 4000
+	graphInterface, err := model.NewGraph(def, rows)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"User": rows}))
+	generator := &FieldGenerator{}
+	generator.SetRandomStreams(NewRandomStreams(42, nil))
+	require.NoError(t, generator.GenerateFields(graph))
+
+	user, _ := graph.GetEntity("User")
+	counts := func(attribute string) map[string]int {
+		values := map[string]int{}
+		for i := 0; i < user.GetRowCount(); i++ {
+			values[user.GetRowByIndex(i).GetValue(attribute)]++
+		}
+		return values
+	}
+	share := func(count int) float64 { return float64(count) / rows }
+
+	status := counts("status")
+	assert.Len(t, status, 3, "null plus the two top values, which are all the distinct values")
+	assert.InDelta(t, 0.2, share(status[""]), 0.03)
+	assert.InDelta(t, 0.6, share(status["active"]), 0.03)
+	assert.InDelta(t, 0.2, share(status["suspended"]), 0.03)
+
+	department := counts("department")
+	assert.Len(t, department, 5, "the top value plus four tail values")
+	assert.InDelta(t, 0.5, share(department["Engineering"]), 0.03)
+	assert.Zero(t, department[""])
+
+	age := counts("age")
+	assert.InDelta(t, 0.1, share(age[""]), 0.03)
+	for value := range age {
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		require.NoError(t, err)
+		assert.True(t, n >= 18 && n <= 65, "age %d is within the profiled range", n)
+	}
+
+	assert.Zero(t, counts("title")[""], "unprofiled columns are generated as usual")
+}
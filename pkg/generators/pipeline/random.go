@@ -0,0 +1,76 @@
+package pipeline
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// RandomSource creates the random source of a stream from the stream's seed.
+// Property-based tests can pass one they control, such as a recorded or
+// adversarial sequence, to replay and shrink failing runs.
+type RandomSource func(seed int64) rand.Source64
+
+// RandomStreams gives each entity its own stream of each generation phase's
+// randomness: IDs, field values, lists, PII, relationship links and the shared
+// population's seed when none is given. Each stream is seeded from the run's seed
+// and the stream's name, such as the entity's external ID, so an entity's values
+// depend neither on the order entities are generated in nor on which other
+// entities the SOR declares. Streams are fakers of their own rather than gofakeit's
+// global one, so generation shares no random state with other code, and keys filled
+// in for partial input rows (see IDGenerator.KeySource) have a stream of their own.
+//
+// A nil *RandomStreams draws every stream at random.
+type RandomStreams struct {
+	seed   int64
+	source RandomSource // Optional; gofakeit's default source when nil
+}
+
+// NewRandomStreams returns the streams of a run with seed, whose values source
+// draws when it isn't nil. Without either, a seed is drawn at random.
+func NewRandomStreams(seed int64, source RandomSource) *RandomStreams {
+	if seed == 0 && source == nil {
+		seed = nonZeroSeed(rand.Int63()) // #nosec G404 - generated data doesn't need cryptographic randomness
+	}
+	return &RandomStreams{seed: seed, source: source}
+}
+
+// Seed returns the seed of the named stream, for randomness seeded apart from the
+// streams such as the shared population's; 0, a random seed, when s is nil
+func (s *RandomStreams) Seed(name string) int64 {
+	if s == nil {
+		return 0
+	}
+	return streamSeed(s.seed, name)
+}
+
+// stream returns a faker drawing the named stream's values. Fakers aren't safe for
+// concurrent use, so each caller takes its own.
+func (s *RandomStreams) stream(name string) *gofakeit.Faker {
+	switch {
+	case s == nil:
+		return gofakeit.New(0)
+	case s.source != nil:
+		return gofakeit.NewCustom(s.source(s.Seed(name)))
+	}
+	return gofakeit.New(s.Seed(name))
+}
+
+// streamSeed derives the seed of a named stream from a base seed
+func streamSeed(base int64, name string) int64 {
+	hash := fnv.New64a()
+	_ = binary.Write(hash, binary.BigEndian, base)
+	_, _ = hash.Write([]byte(name))
+	return nonZeroSeed(int64(hash.Sum64()))
+}
+
+// nonZeroSeed returns seed, or 1 in place of 0, as gofakeit.New treats 0 as a
+// request for a random seed
+func nonZeroSeed(seed int64) int64 {
+	if seed != 0 {
+		return seed
+	}
+	return 1
+}
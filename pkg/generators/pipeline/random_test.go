@@ -0,0 +1,143 @@
+package pipeline
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sequenceSource is a controlled random source: a splitmix64 sequence from a
+// starting state, or the same value forever when constant is set
+type sequenceSource struct {
+	state    uint64
+	constant bool
+}
+
+func (s *sequenceSource) Uint64() uint64 {
+	if s.constant {
+		return s.state
+	}
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func (s *sequenceSource) Int63() int64 { return int64(s.Uint64() >> 1) }
+
+func (s *sequenceSource) Seed(seed int64) { s.state = uint64(seed) }
+
+// sequenceSources creates each stream's source from its seed, or sources repeating
+// one value forever when constant is set
+func sequenceSources(constant bool) RandomSource {
+	return func(seed int64) rand.Source64 {
+		return &sequenceSource{state: uint64(seed), constant: constant}
+	}
+}
+
+func TestRandomSource(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Random",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "email", ExternalId: "email", Type: "String"},
+					{Name: "age", ExternalId: "age", Type: "Integer"},
+				},
+			},
+		},
+	}
+	generate := func(t *testing.T, streams *RandomStreams) ([][]string, error) {
+		t.Helper()
+		graphInterface, err := model.NewGraph(def, 10)
+		require.NoError(t, err)
+		graph := graphInterface.(*model.Graph)
+		ids, fields := &IDGenerator{}, &FieldGenerator{}
+		ids.SetRandomStreams(streams)
+		fields.SetRandomStreams(streams)
+		if err := ids.GenerateIDs(graph, map[string]int{"User": 10}); err != nil {
+			return nil, err
+		}
+		require.NoError(t, fields.GenerateFields(graph))
+		user, _ := graph.GetEntity("User")
+		return user.ToCSV().Rows, nil
+	}
+
+	first, err := generate(t, NewRandomStreams(1, sequenceSources(false)))
+	require.NoError(t, err)
+
+	again, err := generate(t, NewRandomStreams(1, sequenceSources(false)))
+	require.NoError(t, err)
+	assert.Equal(t, first, again, "the same sequences generate the same data")
+
+	other, err := generate(t, NewRandomStreams(2, sequenceSources(false)))
+	require.NoError(t, err)
+	assert.NotEqual(t, first, other)
+
+	t.Run("a degenerate source fails instead of hanging", func(t *testing.T) {
+		_, err := generate(t, NewRandomStreams(7, sequenceSources(true)))
+		assert.ErrorContains(t, err, "duplicate value")
+	})
+}
+
+func TestRandomStreams(t *testing.T) {
+	entity := func(name string) parser.Entity {
+		return parser.Entity{
+			DisplayName: name, ExternalId: name,
+			Attributes: []parser.Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "email", ExternalId: "email", Type: "String"},
+				{Name: "age", ExternalId: "age", Type: "Integer"},
+			},
+		}
+	}
+	generate := func(t *testing.T, names ...string) map[string][][]string {
+		t.Helper()
+		def := &parser.SORDefinition{DisplayName: "Streams", Entities: map[string]parser.Entity{}}
+		rowCounts := make(map[string]int)
+		for _, name := range names {
+			def.Entities[name] = entity(name)
+			rowCounts[name] = 5
+		}
+		graphInterface, err := model.NewGraph(def, 5)
+		require.NoError(t, err)
+		graph := graphInterface.(*model.Graph)
+		ids, fields := &IDGenerator{}, &FieldGenerator{}
+		ids.SetRandomStreams(NewRandomStreams(1, nil))
+		fields.SetRandomStreams(NewRandomStreams(1, nil))
+		require.NoError(t, ids.GenerateIDs(graph, rowCounts))
+		require.NoError(t, fields.GenerateFields(graph))
+		rows := make(map[string][][]string)
+		for _, name := range names {
+			entity, _ := graph.GetEntity(name)
+			rows[name] = entity.ToCSV().Rows
+		}
+		return rows
+	}
+
+	before := generate(t, "User", "Role")
+	after := generate(t, "Account", "User", "Group", "Role")
+	assert.Equal(t, before["User"], after["User"], "adding entities doesn't change another entity's values")
+	assert.Equal(t, before["Role"], after["Role"])
+	assert.NotEqual(t, after["User"], after["Role"], "each entity has a stream of its own")
+
+	assert.Equal(t, streamSeed(1, "User"), streamSeed(1, "User"))
+	assert.NotEqual(t, streamSeed(1, "User"), streamSeed(2, "User"))
+	assert.NotEqual(t, streamSeed(1, "User"), streamSeed(1, "Role"))
+
+	t.Run("streams draw apart from gofakeit's global source", func(t *testing.T) {
+		gofakeit.Seed(1)
+		want := gofakeit.Int64()
+		gofakeit.Seed(1)
+		generate(t, "User")
+		assert.Equal(t, want, gofakeit.Int64(), "generation neither reseeds nor draws from the global source")
+	})
+}
@@ -0,0 +1,176 @@
+package pipeline
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// RedactedDirSuffix is appended to the output directory's name to get the sibling
+// directory of the redacted copy, e.g. output → output-redacted
+const RedactedDirSuffix = "-redacted"
+
+// RedactedMask replaces the values of sensitive attributes that aren't keys
+const RedactedMask = "REDACTED"
+
+// redactedHashLength is the number of hex digits kept of a redacted key's hash
+const redactedHashLength = 16
+
+// RedactedDir returns the directory of the redacted copy of outputDir
+func RedactedDir(outputDir string) string {
+	return filepath.Clean(outputDir) + RedactedDirSuffix
+}
+
+// Redactor replaces the values of sensitive attributes for the redacted copy of a
+// dataset. Values are masked, except those of keys: a sensitive unique ID or
+// relationship attribute, and every attribute joined to it, is replaced by a salted
+// hash so the redacted files still join on the same relationships. The salt is
+// random per Redactor, so hashes differ between runs and can't be reversed by
+// hashing guessed values.
+type Redactor struct {
+	salt   []byte
+	hashed map[columnRef]bool
+	masked map[columnRef]bool
+}
+
+// NewRedactor finds the attributes of graph to mask and to hash
+func NewRedactor(graph *model.Graph) (*Redactor, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to create redaction salt: %w", err)
+	}
+	r := &Redactor{salt: salt, hashed: make(map[columnRef]bool), masked: make(map[columnRef]bool)}
+
+	relationships := graph.GetAllRelationships()
+	joined := make(map[columnRef]bool)
+	for _, relationship := range relationships {
+		joined[relationshipSource(relationship)] = true
+		joined[relationshipTarget(relationship)] = true
+	}
+
+	for _, entity := range graph.GetEntitiesList() {
+		for _, attr := range entity.GetAttributes() {
+			if !attr.IsSensitive() {
+				continue
+			}
+			ref := columnRef{entity.GetExternalID(), attr.GetName()}
+			if attr.IsUnique() || joined[ref] {
+				r.hashed[ref] = true
+			} else {
+				r.masked[ref] = true
+			}
+		}
+	}
+
+	// Hash both ends of every relationship with a hashed end, until no more change
+	for changed := true; changed; {
+		changed = false
+		for _, relationship := range relationships {
+			source, target := relationshipSource(relationship), relationshipTarget(relationship)
+			if r.hashed[source] != r.hashed[target] {
+				r.hashed[source], r.hashed[target] = true, true
+				changed = true
+			}
+		}
+	}
+	return r, nil
+}
+
+// relationshipSource returns the column holding a relationship's foreign keys
+func relationshipSource(relationship model.RelationshipInterface) columnRef {
+	return columnRef{relationship.GetSourceEntity().GetExternalID(), relationship.GetSourceAttribute().GetName()}
+}
+
+// relationshipTarget returns the column a relationship's foreign keys reference
+func relationshipTarget(relationship model.RelationshipInterface) columnRef {
+	return columnRef{relationship.GetTargetEntity().GetExternalID(), relationship.GetTargetAttribute().GetName()}
+}
+
+// redacts returns whether the redactor changes any of an entity's values
+func (r *Redactor) redacts(entity model.EntityInterface) bool {
+	if r == nil {
+		return false
+	}
+	for _, attr := range entity.GetAttributes() {
+		ref := columnRef{entity.GetExternalID(), attr.GetName()}
+		if r.hashed[ref] || r.masked[ref] {
+			return true
+		}
+	}
+	return false
+}
+
+// redact returns the redacted column value of an attribute. JSON and rows-encoded
+// lists are redacted value by value so they stay valid lists.
+func (r *Redactor) redact(entity model.EntityInterface, attr model.AttributeInterface, value string) string {
+	if r == nil || value == "" {
+		return value
+	}
+	encoding := attr.GetListEncoding()
+	if encoding != parser.ListEncodingJSON && encoding != parser.ListEncodingRows {
+		return r.redactValue(entity, attr, value)
+	}
+	values, err := decodeJSONList(value)
+	if err != nil {
+		return r.redactValue(entity, attr, value)
+	}
+	for i := range values {
+		values[i] = r.redactValue(entity, attr, values[i])
+	}
+	return encodeList(values, "String", encoding)
+}
+
+// redactValue returns the redacted form of one value of an attribute: a hash for
+// keys, the mask for other sensitive attributes, or the value itself
+func (r *Redactor) redactValue(entity model.EntityInterface, attr model.AttributeInterface, value string) string {
+	if r == nil || value == "" {
+		return value
+	}
+	ref := columnRef{entity.GetExternalID(), attr.GetName()}
+	switch {
+	case r.hashed[ref]:
+		sum := sha256.Sum256(append(append([]byte{}, r.salt...), value...))
+		return hex.EncodeToString(sum[:])[:redactedHashLength]
+	case r.masked[ref]:
+		return RedactedMask
+	}
+	return value
+}
+
+// wrap returns a sink that redacts records before passing them to sink
+func (r *Redactor) wrap(sink recordSink) recordSink {
+	return &redactingSink{recordSink: sink, redactor: r}
+}
+
+// redactingSink redacts each record of an entity, laid out as produceRecords sends
+// them, before writing it to the wrapped sink
+type redactingSink struct {
+	recordSink
+	redactor   *Redactor
+	entity     model.EntityInterface
+	attributes []model.AttributeInterface // Nil when the entity has nothing to redact
+}
+
+func (s *redactingSink) begin(entity model.EntityInterface, headers []string) error {
+	s.entity, s.attributes = entity, nil
+	if s.redactor.redacts(entity) {
+		s.attributes = entity.GetAttributes()
+	}
+	return s.recordSink.begin(entity, headers)
+}
+
+func (s *redactingSink) write(record []string, flush bool) error {
+	if s.attributes == nil {
+		return s.recordSink.write(record, flush)
+	}
+	redacted := make([]string, len(record))
+	for i, value := range record {
+		redacted[i] = s.redactor.redact(s.entity, s.attributes[i], value)
+	}
+	return s.recordSink.write(redacted, flush)
+}
@@ -0,0 +1,102 @@
+package pipeline
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactedOutput(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Redaction",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true, Sensitive: true},
+					{Name: "ssn", ExternalId: "ssn", Type: "String", Sensitive: true},
+					{Name: "aliases", ExternalId: "aliases", Type: "String", List: true, ListEncoding: parser.ListEncodingJSON, Sensitive: true},
+					{Name: "department", ExternalId: "department", Type: "String"},
+				},
+			},
+			"membership": {
+				DisplayName: "Membership", ExternalId: "Membership",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "userId", ExternalId: "userId", Type: "String"},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"member": {DisplayName: "Member", Name: "member", FromAttribute: "Membership.userId", ToAttribute: "User.id"},
+		},
+	}
+	graphInterface, err := model.NewGraph(def, 10)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"User": 10, "Membership": 20}))
+	require.NoError(t, NewRelationshipLinker().LinkRelationships(graph, false))
+	require.NoError(t, NewFieldGenerator().GenerateFields(graph))
+
+	dir := t.TempDir()
+	outputDir, redactedDir := filepath.Join(dir, "output"), RedactedDir(filepath.Join(dir, "output"))
+	assert.Equal(t, filepath.Join(dir, "output-redacted"), redactedDir)
+	writer := NewCSVWriter(outputDir).(*CSVWriter)
+	writer.SetRedactedOutput(redactedDir)
+	require.NoError(t, writer.WriteFiles(graph))
+
+	read := func(t *testing.T, path string) [][]string {
+		t.Helper()
+		file, err := os.Open(path) // #nosec G304 - test file in a temp directory
+		require.NoError(t, err)
+		defer func() { _ = file.Close() }()
+		records, err := csv.NewReader(file).ReadAll()
+		require.NoError(t, err)
+		return records
+	}
+
+	fullUsers, redactedUsers := read(t, filepath.Join(outputDir, "User.csv")), read(t, filepath.Join(redactedDir, "User.csv"))
+	require.Len(t, redactedUsers, len(fullUsers))
+	assert.Equal(t, fullUsers[0], redactedUsers[0])
+	redactedIDs := make(map[string]bool)
+	for i := 1; i < len(fullUsers); i++ {
+		full, redacted := fullUsers[i], redactedUsers[i]
+		assert.Regexp(t, `^[0-9a-f]{16}$`, redacted[0], "sensitive keys are hashed")
+		assert.NotEqual(t, full[0], redacted[0])
+		assert.Equal(t, RedactedMask, redacted[1])
+		aliases, err := decodeJSONList(redacted[2])
+		require.NoError(t, err, "redacted lists stay valid")
+		for _, alias := range aliases {
+			assert.Equal(t, RedactedMask, alias)
+		}
+		assert.Equal(t, full[3], redacted[3], "other attributes are copied")
+		redactedIDs[redacted[0]] = true
+	}
+
+	t.Run("foreign keys to a hashed key are hashed the same way", func(t *testing.T) {
+		fullMemberships := read(t, filepath.Join(outputDir, "Membership.csv"))
+		redactedMemberships := read(t, filepath.Join(redactedDir, "Membership.csv"))
+		for i := 1; i < len(redactedMemberships); i++ {
+			assert.Equal(t, fullMemberships[i][0], redactedMemberships[i][0])
+			assert.True(t, redactedIDs[redactedMemberships[i][1]], "row %d still joins to a user", i)
+		}
+	})
+
+	t.Run("each redactor uses its own salt", func(t *testing.T) {
+		user, _ := graph.GetEntity("User")
+		first, err := NewRedactor(graph)
+		require.NoError(t, err)
+		second, err := NewRedactor(graph)
+		require.NoError(t, err)
+		pk := user.GetPrimaryKey()
+		assert.Equal(t, first.redactValue(user, pk, "u-1"), first.redactValue(user, pk, "u-1"))
+		assert.NotEqual(t, first.redactValue(user, pk, "u-1"), second.redactValue(user, pk, "u-1"))
+		assert.Empty(t, first.redactValue(user, pk, ""))
+	})
+}
@@ -1,23 +1,31 @@
 package pipeline
 import (
 	"fmt"
+	"github.com/SGNL-ai/fabricator/pkg/console"
 	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/fatih/color"
 )
 // RelationshipLinker handles establishing relationships between entities
 type RelationshipLinker struct {
-	// Configuration options can be added here
+	allowEmpty bool // FKs to an entity with no rows are left blank instead of failing
 }
 // NewRelationshipLinker creates a new relationship linker
 func NewRelationshipLinker() RelationshipLinkerInterface {
 	return &RelationshipLinker{}
 }
+// SetAllowEmpty configures whether relationships to an entity with no rows leave
+// the FK blank (the target was configured with 0 rows) instead of failing
+func (l *RelationshipLinker) SetAllowEmpty(allow bool) {
+	l.allowEmpty = allow
+}
 // LinkRelationships establishes relationships between entities
 func (l *RelationshipLinker) LinkRelationships(graph *model.Graph, autoCardinality bool) error {
 	if graph == nil {
 		return fmt.Errorf("graph cannot be nil")
 	}
 	// Process entities in order for optimal FK assignment
-	for _, entity := range graph.GetAllEntities() {
+	for _, entity := range graph.GetEntitiesList() {
 		// Get relationships where this entity is the source (has FK attributes)
 		entityRelationships := graph.GetRelationshipsForEntity(entity.GetID())
 		// Filter to only relationships where this entity is the source
@@ -31,36 +39,72 @@ func (l *RelationshipLinker) LinkRelationships(graph *model.Graph, autoCardinali
 			continue // No FK relationships for this entity
 		}
 		// Show progress for current entity relationships
-		fmt.Printf("\r%-80s\r→ Linking %s relationships...", "", entity.GetName())
+		console.Progress("→ Linking %s relationships...", entity.GetName())
+		// Self-join junction tables redraw pairs their junction options rule out
+		pairRelationship := junctionPairRelationship(entity, sourceRelationships)
 		// Process FK relationships for this entity
 		for i, relationship := range sourceRelationships {
 			isLastRelationship := (i == len(sourceRelationships)-1)
 			// Detect same_as relationships (both source and target attributes are unique/PKs)
 			// These represent bidirectional (0..1)-to-(0..1) identity mappings
 			isSameAs := relationship.GetSourceAttribute().IsUnique() && relationship.GetTargetAttribute().IsUnique()
-			// For same_as relationships, only assign up to min(source, target) rows
-			// Excess rows in larger entity remain unassigned (valid for optional same_as)
 			targetRowCount := relationship.GetTargetEntity().GetRowCount()
+			// An entity configured with 0 rows leaves the FKs referencing it blank
+			if targetRowCount == 0 && l.allowEmpty {
+				continue
+			}
+			// A self-referential relationship to a hierarchical code links each row to
+			// its parent code, leaving top-level codes without a parent
+			var hierarchy *parser.Generator
+			if relationship.GetTargetEntity().GetID() == entity.GetID() {
+				hierarchy = hierarchicalCodeGenerator(relationship.GetTargetAttribute())
+			}
+			// For same_as relationships, each target key is assigned to at most one row
+			// Excess rows in larger entity remain unassigned (valid for optional same_as)
+			var oneToOne *oneToOneTargets
+			if isSameAs && hierarchy == nil {
+				// Target rows' other fields aren't generated yet, so a where is applied
+				// once they are (see retargetFilteredKeys)
+				oneToOne = newOneToOneTargets(entity, relationship, nil)
+			}
 			// Process all rows for this relationship
 			err := entity.ForEachRow(func(row *model.Row, rowIndex int) error {
-				// For same_as relationships with source > target, skip excess rows
-				if isSameAs && rowIndex >= targetRowCount {
-					return nil // Skip - no corresponding target row exists
+				// Preserve FK values supplied by partial input data
+				if row.IsPinned(relationship.GetSourceAttribute().GetName()) {
+					return nil
 				}
-				// For same_as relationships, always use round-robin (1:1 sequential mapping)
-				// Power-law clustering doesn't make sense for identity relationships
-				useAutoCardinality := autoCardinality && !isSameAs
-				// Ask relationship to provide target PK value for this source row
-				targetValue, err := relationship.GetTargetValueForSourceRow(rowIndex, useAutoCardinality)
-				if err != nil {
-					return fmt.Errorf("failed to get target value for row %d: %w", rowIndex, err)
+				if hierarchy != nil {
+					code := row.GetValue(relationship.GetTargetAttribute().GetName())
+					row.SetValue(relationship.GetSourceAttribute().GetName(), hierarchicalParentCode(hierarchy, code))
+					return nil
+				}
+				var targetValue string
+				if oneToOne != nil {
+					// For same_as relationships, take the next target key no row uses yet
+					// Rows beyond the unused keys are skipped - no corresponding target row exists
+					value, ok := oneToOne.take()
+					if !ok {
+						return nil
+					}
+					targetValue = value
+				} else {
+					// Ask relationship to provide target PK value for this source row
+					value, err := relationship.GetTargetValueForSourceRow(rowIndex, autoCardinality)
+					if err != nil {
+						return fmt.Errorf("failed to get target value for row %d: %w", rowIndex, err)
+					}
+					targetValue = value
 				}
 				// Set the FK value in the source row
 				row.SetValue(relationship.GetSourceAttribute().GetName(), targetValue)
 				// If this is the last FK for a junction table, check for duplicates
 				if isLastRelationship && len(sourceRelationships) > 1 {
-					// Check BEFORE registering - is this composite key already seen?
-					if entity.IsCompositeKeyRegistered(row) {
+					// Redraw a pair the junction options rule out before dropping the row
+					if pairRelationship != nil && (entity.IsSelfPair(row) || entity.IsCompositeKeyRegistered(row)) {
+						repairJunctionPair(entity, pairRelationship, row, rowIndex)
+					}
+					// Check BEFORE registering - is this composite key already seen, or a self-pair?
+					if entity.IsSelfPair(row) || entity.IsCompositeKeyRegistered(row) {
 						// DEBUG: Log duplicate detection
 						// Duplicate - signal ForEachRow to remove this row
 						return model.ErrSkipRow
@@ -73,11 +117,16 @@ func (l *RelationshipLinker) LinkRelationships(graph *model.Graph, autoCardinali
 			if err != nil {
 				return fmt.Errorf("failed to link relationship %s: %w", relationship.GetID(), err)
 			}
+			if oneToOne != nil && oneToOne.unmatched > 0 {
+				console.ClearLine()
+				color.Yellow(console.Text("⚠️  %s is one-to-one but %s has %d more rows than unused %s keys; they are left unlinked"),
+					relationship.GetID(), entity.GetExternalID(), oneToOne.unmatched, relationship.GetTargetEntity().GetExternalID())
+			}
 			// Note: Duplicate removal now handled inline via ErrSkipRow
 			// Eliminates O(n×m) RemoveRow calls and ~4s of slice copying for large datasets
 		}
 	}
 	// Clear relationship linking progress line
-	fmt.Printf("\r%-80s\r", "")
+	console.ClearLine()
 	return nil
 }
@@ -453,3 +453,48 @@ func TestRelationshipLinker_LinkRelationships(t *testing.T) {
 		})
 	}
 }
+
+func TestRelationshipLinker_OneToOneUsesEachTargetOnce(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "One to one",
+		Entities: map[string]parser.Entity{
+			"account": {DisplayName: "Account", ExternalId: "Account",
+				Attributes: []parser.Attribute{{Name: "id", ExternalId: "id", Type: "String", UniqueId: true}}},
+			"profile": {DisplayName: "Profile", ExternalId: "Profile",
+				Attributes: []parser.Attribute{
+					{Name: "accountId", ExternalId: "accountId", Type: "String", UniqueId: true},
+					{Name: "bio", ExternalId: "bio", Type: "String"},
+				}},
+		},
+		Relationships: map[string]parser.Relationship{
+			"profile_account": {DisplayName: "Profile Account", Name: "profile_account",
+				FromAttribute: "Profile.accountId", ToAttribute: "Account.id"},
+		},
+	}
+	graphInterface, err := model.NewGraph(def, 10)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	require.True(t, graph.GetAllRelationships()[0].IsOneToOne())
+
+	account, _ := graph.GetEntity("Account")
+	profile, _ := graph.GetEntity("Profile")
+	for _, id := range []string{"a0", "a1", "a2"} {
+		require.NoError(t, account.AddRow(model.NewRow(map[string]string{"id": id})))
+	}
+	// Partial input already links the first profile to the second account; the
+	// others start with generated keys
+	require.NoError(t, profile.AddRow(model.NewPinnedRow(map[string]string{"accountId": "a1"})))
+	for _, id := range []string{"p1", "p2", "p3"} {
+		require.NoError(t, profile.AddRow(model.NewRow(map[string]string{"accountId": id})))
+	}
+
+	require.NoError(t, NewRelationshipLinker().LinkRelationships(graph, true))
+
+	var linked []string
+	require.NoError(t, profile.ForEachRow(func(row *model.Row, _ int) error {
+		linked = append(linked, row.GetValue("accountId"))
+		return nil
+	}))
+	assert.Equal(t, []string{"a1", "a0", "a2", "p3"}, linked,
+		"each account is referenced once; the profile beyond them is left unlinked")
+}
@@ -0,0 +1,144 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// RelationshipValidation controls how referential integrity issues for a relationship are reported
+type RelationshipValidation string
+
+// Relationship validation levels, from most to least strict
+const (
+	RelationshipValidationError RelationshipValidation = "error" // Issues are validation errors (default)
+	RelationshipValidationWarn  RelationshipValidation = "warn"  // Issues are reported as warnings
+	RelationshipValidationSkip  RelationshipValidation = "skip"  // The relationship is not checked
+)
+
+// ValidationReport separates validation errors from issues downgraded to warnings
+type ValidationReport struct {
+	Errors   []string
+	Warnings []string
+
+	// Checks tallies, per check an error budget can cover, the values checked and
+	// the issues reported as errors
+	Checks map[string]*CheckTally
+}
+
+// CheckTally counts the values a check examined and the issues it found
+type CheckTally struct {
+	Checked int
+	Failed  int      // Issues found, including those not listed
+	issues  []string // Listed issues, which are also in Errors
+}
+
+// add routes issues according to a relationship validation level
+func (r *ValidationReport) add(level RelationshipValidation, issues []string) {
+	switch level {
+	case RelationshipValidationSkip:
+	case RelationshipValidationWarn:
+		r.Warnings = append(r.Warnings, issues...)
+	default:
+		r.Errors = append(r.Errors, issues...)
+	}
+}
+
+// addCheck routes a check's issues like add, tallying those reported as errors so
+// a tolerance can later accept them. failed can exceed len(issues) when not every
+// issue is listed.
+func (r *ValidationReport) addCheck(check string, level RelationshipValidation, checked, failed int, issues []string) {
+	r.add(level, issues)
+	if level == RelationshipValidationSkip || level == RelationshipValidationWarn {
+		return
+	}
+	if r.Checks == nil {
+		r.Checks = make(map[string]*CheckTally)
+	}
+	tally, exists := r.Checks[check]
+	if !exists {
+		tally = &CheckTally{}
+		r.Checks[check] = tally
+	}
+	tally.Checked += checked
+	tally.Failed += failed
+	tally.issues = append(tally.issues, issues...)
+}
+
+// ParseRelationshipValidation converts a YAML value into a validation level; empty means error
+func ParseRelationshipValidation(value string) (RelationshipValidation, error) {
+	switch RelationshipValidation(value) {
+	case "", RelationshipValidationError:
+		return RelationshipValidationError, nil
+	case RelationshipValidationWarn, RelationshipValidationSkip:
+		return RelationshipValidation(value), nil
+	}
+	return "", fmt.Errorf("invalid relationship validation '%s' (expected skip, warn or error)", value)
+}
+
+// relationshipValidationLevels maps relationship IDs to their configured validation level
+func relationshipValidationLevels(def *parser.SORDefinition) (map[string]RelationshipValidation, error) {
+	levels := make(map[string]RelationshipValidation, len(def.Relationships))
+	for id, relationship := range def.Relationships {
+		level, err := ParseRelationshipValidation(relationship.Validation)
+		if err != nil {
+			return nil, fmt.Errorf("relationship %s: %w", id, err)
+		}
+		levels[id] = level
+	}
+	return levels, nil
+}
+
+// foldInverseLevels gives each relationship that absorbed its inverse (see
+// model.RelationshipInterface.GetInverseID) the level set on either of the pair,
+// the strictest when both set one, as the folded relationship's ID is no longer in
+// the graph to be looked up
+func foldInverseLevels(def *parser.SORDefinition, graph *model.Graph, levels map[string]RelationshipValidation) {
+	for _, relationship := range graph.GetAllRelationships() {
+		inverseID := relationship.GetInverseID()
+		if inverseID == "" || def.Relationships[inverseID].Validation == "" {
+			continue
+		}
+		id := relationship.GetID()
+		if def.Relationships[id].Validation == "" || strictness(levels[inverseID]) > strictness(levels[id]) {
+			levels[id] = levels[inverseID]
+		}
+	}
+}
+
+// strictness orders validation levels from skip to error
+func strictness(level RelationshipValidation) int {
+	switch level {
+	case RelationshipValidationSkip:
+		return 0
+	case RelationshipValidationWarn:
+		return 1
+	}
+	return 2
+}
+
+// foreignKeyValidationLevel returns the strictest level among the relationships
+// that use an entity attribute as their foreign key (error when none do)
+func foreignKeyValidationLevel(graph *model.Graph, levels map[string]RelationshipValidation,
+	entity model.EntityInterface, attrName string) RelationshipValidation {
+	found := false
+	strictest := RelationshipValidationSkip
+	for _, relationship := range graph.GetRelationshipsForEntity(entity.GetID()) {
+		if relationship.GetSourceEntity().GetID() != entity.GetID() ||
+			relationship.GetSourceAttribute().GetName() != attrName {
+			continue
+		}
+		found = true
+		switch levels[relationship.GetID()] {
+		case RelationshipValidationError, "":
+			return RelationshipValidationError
+		case RelationshipValidationWarn:
+			strictest = RelationshipValidationWarn
+		}
+	}
+	if !found {
+		return RelationshipValidationError
+	}
+	return strictest
+}
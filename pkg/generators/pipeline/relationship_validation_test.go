@@ -0,0 +1,141 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// userRoleDefinition returns a User → Role definition whose relationship uses the given validation level
+func userRoleDefinition(validation string) *parser.SORDefinition {
+	return &parser.SORDefinition{
+		DisplayName: "Test SOR",
+		Description: "Test Description",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User",
+				ExternalId:  "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "roleId", ExternalId: "roleId", Type: "String"},
+				},
+			},
+			"role": {
+				DisplayName: "Role",
+				ExternalId:  "Role",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"user_role": {
+				DisplayName:   "User Role",
+				Name:          "user_role",
+				FromAttribute: "User.roleId",
+				ToAttribute:   "Role.id",
+				Validation:    validation,
+			},
+		},
+	}
+}
+
+func TestValidateExistingCSVFilesReport_RelationshipLevels(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "User.csv"), []byte("id,roleId\nuser-1,role-1\nuser-2,role-999\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "Role.csv"), []byte("id\nrole-1\n"), 0600))
+
+	tests := []struct {
+		name         string
+		validation   string
+		wantErrors   bool
+		wantWarnings bool
+	}{
+		{name: "default reports errors", validation: "", wantErrors: true},
+		{name: "error reports errors", validation: "error", wantErrors: true},
+		{name: "warn reports warnings", validation: "warn", wantWarnings: true},
+		{name: "skip reports nothing", validation: "skip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := NewValidationProcessor()
+			report, err := processor.ValidateExistingCSVFilesReport(userRoleDefinition(tt.validation), tempDir)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantErrors, len(report.Errors) > 0, "errors: %v", report.Errors)
+			assert.Equal(t, tt.wantWarnings, len(report.Warnings) > 0, "warnings: %v", report.Warnings)
+			for _, issue := range append(report.Errors, report.Warnings...) {
+				assert.Contains(t, issue, "role-999")
+			}
+
+			// ValidateExistingCSVFiles only returns errors
+			errors, err := processor.ValidateExistingCSVFiles(userRoleDefinition(tt.validation), tempDir)
+			require.NoError(t, err)
+			assert.Equal(t, report.Errors, errors)
+		})
+	}
+}
+
+func TestValidateExistingCSVFilesReport_InverseRelationshipLevel(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "User.csv"), []byte("id,roleId\nuser-1,role-1\nuser-2,role-999\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "Role.csv"), []byte("id\nrole-1\n"), 0600))
+
+	// role_users is folded into user_role, the direction holding the foreign key
+	def := userRoleDefinition("")
+	def.Relationships["role_users"] = parser.Relationship{
+		DisplayName: "Role Users", Name: "role_users", FromAttribute: "Role.id", ToAttribute: "User.roleId", Validation: "warn",
+	}
+
+	for name, processor := range map[string]interface {
+		ValidateExistingCSVFilesReport(*parser.SORDefinition, string) (*ValidationReport, error)
+	}{"loaded": NewValidationProcessor(), "streaming": NewStreamingValidationProcessor()} {
+		t.Run(name, func(t *testing.T) {
+			report, err := processor.ValidateExistingCSVFilesReport(def, tempDir)
+			require.NoError(t, err)
+			assert.Empty(t, report.Errors)
+			require.NotEmpty(t, report.Warnings)
+			assert.Contains(t, report.Warnings[0], "role-999")
+		})
+	}
+}
+
+func TestValidateExistingCSVFilesReport_SkipKeepsStructuralErrors(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "User.csv"), []byte("id,roleId\nuser-1,role-999\nuser-1,role-1\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "Role.csv"), []byte("id\nrole-1\n"), 0600))
+
+	report, err := NewValidationProcessor().ValidateExistingCSVFilesReport(userRoleDefinition("skip"), tempDir)
+	require.NoError(t, err)
+
+	// Duplicate primary keys are not relationship issues and are still errors
+	require.NotEmpty(t, report.Errors)
+	for _, issue := range report.Errors {
+		assert.NotContains(t, issue, "role-999")
+	}
+	assert.Empty(t, report.Warnings)
+}
+
+func TestParseRelationshipValidation(t *testing.T) {
+	for value, want := range map[string]RelationshipValidation{
+		"":      RelationshipValidationError,
+		"error": RelationshipValidationError,
+		"warn":  RelationshipValidationWarn,
+		"skip":  RelationshipValidationSkip,
+	} {
+		got, err := ParseRelationshipValidation(value)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := ParseRelationshipValidation("ignore")
+	assert.ErrorContains(t, err, "invalid relationship validation")
+
+	_, err = NewValidationProcessor().ValidateExistingCSVFilesReport(userRoleDefinition("ignore"), t.TempDir())
+	assert.ErrorContains(t, err, "user_role")
+}
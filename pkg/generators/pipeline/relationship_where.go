@@ -0,0 +1,174 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// retargetFilteredKeys points the foreign keys of relationships with a where at the
+// target rows matching it, and those of relationships with weights at target rows
+// in proportion to their weights. Keys are linked before the target rows' other
+// fields are generated, so those of filtered and weighted relationships are
+// assigned again once they are, with the relationship's usual distribution over
+// the matching rows, or one following the weights. Junction rows whose new pair is
+// already taken try the next matching rows, and are dropped when none is free.
+func retargetFilteredKeys(graph *model.Graph, autoCardinality bool, streams *RandomStreams) error {
+	relationships := append([]model.RelationshipInterface(nil), graph.GetAllRelationships()...)
+	sort.Slice(relationships, func(i, j int) bool {
+		return relationships[i].GetID() < relationships[j].GetID()
+	})
+
+	for _, relationship := range relationships {
+		where, weights := relationship.GetWhere(), relationship.GetWeights()
+		source, target := relationship.GetSourceEntity(), relationship.GetTargetEntity()
+		// Entities configured with 0 rows leave their foreign keys blank
+		if (len(where) == 0 && len(weights) == 0) || target.GetRowCount() == 0 {
+			continue
+		}
+		if source.GetID() == target.GetID() && hierarchicalCodeGenerator(relationship.GetTargetAttribute()) != nil {
+			continue
+		}
+
+		targetName := relationship.GetTargetAttribute().GetName()
+		var matching []string
+		var weighted *weightedTargets
+		if len(weights) > 0 {
+			// Weighted draws come from a stream of the relationship's own
+			weighted = &weightedTargets{}
+			if autoCardinality {
+				weighted.faker = streams.stream("weights:" + relationship.GetID())
+			}
+		}
+		for i := 0; i < target.GetRowCount(); i++ {
+			row := target.GetRowByIndex(i)
+			value := row.GetValue(targetName)
+			if value == "" || !matchesWhere(row, where) {
+				continue
+			}
+			if weighted != nil {
+				weight := rowWeight(row, weights)
+				if weight <= 0 {
+					continue
+				}
+				weighted.add(weight)
+			}
+			matching = append(matching, value)
+		}
+		switch {
+		case len(matching) == 0 && weighted != nil:
+			return fmt.Errorf("relationship %s: no %s rows have a weight above 0", relationship.GetID(), target.GetExternalID())
+		case len(matching) == 0:
+			return fmt.Errorf("relationship %s: no %s rows match where %s", relationship.GetID(),
+				target.GetExternalID(), describeWhere(where))
+		}
+
+		if err := retargetRelationship(source, relationship, matching, weighted, autoCardinality); err != nil {
+			return fmt.Errorf("relationship %s: %w", relationship.GetID(), err)
+		}
+	}
+	return nil
+}
+
+// retargetRelationship assigns the source rows' foreign keys among the matching
+// target values, in proportion to their weights when weighted isn't nil, keeping
+// those supplied by partial input
+func retargetRelationship(source model.EntityInterface, relationship model.RelationshipInterface,
+	matching []string, weighted *weightedTargets, autoCardinality bool) error {
+	sourceName := relationship.GetSourceAttribute().GetName()
+	sourceRowCount := source.GetRowCount()
+
+	var oneToOne *oneToOneTargets
+	if relationship.GetSourceAttribute().IsUnique() && relationship.GetTargetAttribute().IsUnique() {
+		oneToOne = newOneToOneTargets(source, relationship, relationship.GetWhere())
+	}
+
+	// Junction rows keep their pairs distinct
+	keys := source.GetRelationshipAttributes()
+	var pairs map[string]bool
+	if len(keys) > 1 {
+		pairs = make(map[string]bool, source.GetRowCount())
+	}
+	pair := func(row *model.Row) string {
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			values[i] = row.GetValue(key.GetName())
+		}
+		return strings.Join(values, "\x00")
+	}
+
+	return source.ForEachRow(func(row *model.Row, rowIndex int) error {
+		if row.IsPinned(sourceName) {
+			if pairs != nil {
+				pairs[pair(row)] = true
+			}
+			return nil
+		}
+		if oneToOne != nil {
+			value, _ := oneToOne.take()
+			row.SetValue(sourceName, value)
+			return nil
+		}
+
+		var index int
+		if weighted != nil {
+			index = weighted.index(rowIndex, sourceRowCount, autoCardinality)
+		} else {
+			index = relationship.SelectTargetIndex(rowIndex, len(matching), autoCardinality)
+		}
+		if pairs == nil {
+			row.SetValue(sourceName, matching[index])
+			return nil
+		}
+		for offset := 0; offset < len(matching); offset++ {
+			row.SetValue(sourceName, matching[(index+offset)%len(matching)])
+			if key := pair(row); !pairs[key] && !source.IsSelfPair(row) {
+				pairs[key] = true
+				return nil
+			}
+		}
+		return model.ErrSkipRow
+	})
+}
+
+// matchesWhere reports whether a row holds every value of a where, by attribute name
+func matchesWhere(row *model.Row, where map[string]string) bool {
+	for name, value := range where {
+		if row.GetValue(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// whereColumns resolves a where to the positions of its attributes among columns
+// (attribute names); an attribute without a column is at -1, matching no record
+func whereColumns(where map[string]string, columns []string) map[int]string {
+	resolved := make(map[int]string, len(where))
+	for name, value := range where {
+		resolved[columnIndex(columns, name)] = value
+	}
+	return resolved
+}
+
+// matchesColumns reports whether a record holds the values of a resolved where
+func matchesColumns(record []string, where map[int]string) bool {
+	for column, value := range where {
+		if column < 0 || column >= len(record) || record[column] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// describeWhere formats a where for messages, e.g. "status=active, type=human"
+func describeWhere(where map[string]string) string {
+	conditions := make([]string, 0, len(where))
+	for name, value := range where {
+		conditions = append(conditions, name+"="+value)
+	}
+	sort.Strings(conditions)
+	return strings.Join(conditions, ", ")
+}
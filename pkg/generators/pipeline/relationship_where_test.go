@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// activeAssignees returns a SOR whose tickets are only assigned to active users, and
+// whose watchers pair active users with tickets
+func activeAssignees() *parser.SORDefinition {
+	active := map[string]string{"status": "active"}
+	return &parser.SORDefinition{
+		DisplayName: "Active Assignees",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "status", ExternalId: "status", Type: "String"},
+				},
+			},
+			"ticket": {
+				DisplayName: "Ticket", ExternalId: "Ticket",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "assigneeId", ExternalId: "assigneeId", Type: "String"},
+				},
+			},
+			"watcher": {
+				DisplayName: "Watcher", ExternalId: "Watcher",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "userId", ExternalId: "userId", Type: "String"},
+					{Name: "ticketId", ExternalId: "ticketId", Type: "String"},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"ticket_assignee": {DisplayName: "Ticket Assignee", Name: "ticket_assignee",
+				FromAttribute: "Ticket.assigneeId", ToAttribute: "User.id", Where: active},
+			"watcher_user": {DisplayName: "Watcher User", Name: "watcher_user",
+				FromAttribute: "Watcher.userId", ToAttribute: "User.id", Where: active},
+			"watcher_ticket": {DisplayName: "Watcher Ticket", Name: "watcher_ticket",
+				FromAttribute: "Watcher.ticketId", ToAttribute: "Ticket.id"},
+		},
+	}
+}
+
+func TestRetargetFilteredKeys(t *testing.T) {
+	t.Run("should only reference target rows matching the where", func(t *testing.T) {
+		graphInterface, err := model.NewGraph(activeAssignees(), 100)
+		require.NoError(t, err)
+		graph := graphInterface.(*model.Graph)
+		dir := t.TempDir()
+		generator := NewDataGenerator(dir, map[string]int{"User": 30, "Ticket": 100, "Watcher": 60}, true)
+		require.NoError(t, generator.Generate(graph))
+
+		users, _ := graph.GetEntity("User")
+		status := make(map[string]string)
+		for i := 0; i < users.GetRowCount(); i++ {
+			row := users.GetRowByIndex(i)
+			status[row.GetValue("id")] = row.GetValue("status")
+		}
+		tickets, _ := graph.GetEntity("Ticket")
+		for i := 0; i < tickets.GetRowCount(); i++ {
+			assignee := tickets.GetRowByIndex(i).GetValue("assigneeId")
+			assert.Equal(t, "active", status[assignee], "ticket %d is assigned to %s", i, assignee)
+		}
+
+		watchers, _ := graph.GetEntity("Watcher")
+		require.NotZero(t, watchers.GetRowCount())
+		pairs := make(map[[2]string]bool)
+		for i := 0; i < watchers.GetRowCount(); i++ {
+			row := watchers.GetRowByIndex(i)
+			assert.Equal(t, "active", status[row.GetValue("userId")])
+			pair := [2]string{row.GetValue("userId"), row.GetValue("ticketId")}
+			assert.False(t, pairs[pair], "watcher pair %v is repeated", pair)
+			pairs[pair] = true
+		}
+
+		// The files pass validation with the where enforced
+		report, err := NewValidationProcessor().ValidateExistingCSVFilesReport(activeAssignees(), dir)
+		require.NoError(t, err)
+		assert.Empty(t, report.Errors)
+	})
+
+	t.Run("should fail when no target row matches", func(t *testing.T) {
+		def := activeAssignees()
+		relationship := def.Relationships["ticket_assignee"]
+		relationship.Where = map[string]string{"status": "retired"}
+		def.Relationships["ticket_assignee"] = relationship
+
+		graphInterface, err := model.NewGraph(def, 10)
+		require.NoError(t, err)
+		generator := NewDataGenerator(t.TempDir(), map[string]int{"User": 5, "Ticket": 10, "Watcher": 5}, false)
+		err = generator.Generate(graphInterface.(*model.Graph))
+		assert.ErrorContains(t, err, "relationship ticket_assignee: no User rows match where status=retired")
+	})
+}
+
+func TestValidateRelationshipWhere(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"User.csv":    "id,status\nuser-1,active\nuser-2,inactive\n",
+		"Ticket.csv":  "id,assigneeId\nticket-1,user-1\nticket-2,user-2\n",
+		"Watcher.csv": "id,userId,ticketId\nwatcher-1,user-1,ticket-2\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	processors := map[string]func() ValidationProcessorInterface{
+		"in memory": NewValidationProcessor,
+		"streaming": NewStreamingValidationProcessor,
+	}
+	for name, newProcessor := range processors {
+		t.Run(name, func(t *testing.T) {
+			report, err := newProcessor().ValidateExistingCSVFilesReport(activeAssignees(), dir)
+			require.NoError(t, err)
+			require.Len(t, report.Errors, 1)
+			assert.Contains(t, report.Errors[0], "foreign key 'user-2' in Ticket")
+			assert.Contains(t, report.Errors[0], "does not exist in User.id where status=active")
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// sequenceGenerator returns the attribute's sequence generator hint, or nil if it has none
+func sequenceGenerator(attr model.AttributeInterface) *parser.Generator {
+	if generator := attr.GetGenerator(); generator != nil && generator.Type == parser.GeneratorSequence {
+		return generator
+	}
+	return nil
+}
+
+// sequenceValue returns the value of a sequence for the row at index; values increase
+// monotonically with the row index, so they are unique within the entity
+func sequenceValue(generator *parser.Generator, index int) string {
+	return fmt.Sprintf("%0*d", generator.Padding, generator.Start+int64(index)*generator.Step)
+}
@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequenceValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator parser.Generator
+		index     int
+		expected  string
+	}{
+		{name: "first value", generator: parser.Generator{Start: 1, Step: 1}, index: 0, expected: "1"},
+		{name: "step", generator: parser.Generator{Start: 1000, Step: 10}, index: 3, expected: "1030"},
+		{name: "padding", generator: parser.Generator{Start: 1, Step: 1, Padding: 6}, index: 41, expected: "000042"},
+		{name: "padding narrower than value", generator: parser.Generator{Start: 123456, Step: 1, Padding: 3}, index: 0, expected: "123456"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sequenceValue(&tt.generator, tt.index))
+		})
+	}
+}
+
+func TestSequenceGeneration(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Billing",
+		Description: "SOR with sequence attributes",
+		Entities: map[string]parser.Entity{
+			"invoice": {
+				DisplayName: "Invoice",
+				ExternalId:  "Invoice",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "Integer", UniqueId: true,
+						Generator: &parser.Generator{Type: parser.GeneratorSequence, Start: 1, Step: 1}},
+					{Name: "number", ExternalId: "number", Type: "String",
+						Generator: &parser.Generator{Type: parser.GeneratorSequence, Start: 5000, Step: 5, Padding: 8}},
+				},
+			},
+		},
+	}
+
+	graphInterface, err := model.NewGraph(def, 20)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+
+	require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"Invoice": 20}))
+	require.NoError(t, NewFieldGenerator().GenerateFields(graph))
+
+	invoice, _ := graph.GetEntity("Invoice")
+	require.Equal(t, 20, invoice.GetRowCount())
+	for i := 0; i < invoice.GetRowCount(); i++ {
+		row := invoice.GetRowByIndex(i)
+		assert.Equal(t, strconv.Itoa(i+1), row.GetValue("id"), "primary key sequence")
+		assert.Len(t, row.GetValue("number"), 8)
+		number, err := strconv.Atoi(row.GetValue("number"))
+		require.NoError(t, err)
+		assert.Equal(t, 5000+5*i, number, "attribute sequence increases by step per row")
+	}
+}
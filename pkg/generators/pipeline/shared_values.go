@@ -0,0 +1,92 @@
+package pipeline
+
+import (
+	"math"
+	"slices"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// shareValues sets overlap × rows of each attribute sharing values to values of the
+// attribute it shares with, so joins on the two columns match that share of rows.
+// The rows are picked at random; the source's distinct values are handed out in
+// random order, repeating once all are used unless the attribute is unique, which
+// also never takes a value another of its rows keeps. Values supplied externally
+// are kept, and an attribute whose source entity isn't generated keeps its own.
+func shareValues(graph *model.Graph, streams *RandomStreams) {
+	for _, entity := range graph.GetEntitiesList() {
+		for _, attr := range entity.GetAttributes() {
+			sourceEntity, source := graph.SharedValuesSource(attr)
+			if source == nil {
+				continue
+			}
+			name := attr.GetName()
+			faker := streams.stream("shared:" + entity.GetExternalID() + "." + name)
+
+			var picked []*model.Row
+			wanted := int(math.Round(attr.GetSharedValues().Overlap * float64(entity.GetRowCount())))
+			for _, i := range shuffledIndexes(entity.GetRowCount(), faker) {
+				if row := entity.GetRowByIndex(i); len(picked) < wanted && !row.IsPinned(name) {
+					picked = append(picked, row)
+				}
+			}
+
+			values := sharedValues(sourceEntity, source.GetName())
+			if len(values) == 0 {
+				continue
+			}
+			faker.ShuffleStrings(values)
+
+			// A unique attribute takes each value once, and none that another of its
+			// rows keeps; picked rows left without a value keep theirs, so fewer rows
+			// are picked until the values left suffice
+			if attr.IsUnique() || attr.GetUniqueWithin() != "" {
+				picked = picked[:min(len(picked), len(values))]
+				for {
+					kept := keptValues(entity, name, picked)
+					usable := slices.DeleteFunc(slices.Clone(values), func(value string) bool { return kept[value] })
+					if len(usable) >= len(picked) {
+						values = usable
+						break
+					}
+					picked = picked[:len(usable)]
+				}
+			}
+
+			for i, row := range picked {
+				row.SetValue(name, values[i%len(values)])
+			}
+		}
+	}
+}
+
+// sharedValues returns the distinct non-empty values of an entity's attribute, in
+// row order
+func sharedValues(entity model.EntityInterface, name string) []string {
+	seen := make(map[string]bool)
+	var values []string
+	for i := 0; i < entity.GetRowCount(); i++ {
+		value := entity.GetRowByIndex(i).GetValue(name)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		values = append(values, value)
+	}
+	return values
+}
+
+// keptValues returns the values of an entity's attribute in the rows not picked
+func keptValues(entity model.EntityInterface, name string, picked []*model.Row) map[string]bool {
+	replaced := make(map[*model.Row]bool, len(picked))
+	for _, row := range picked {
+		replaced[row] = true
+	}
+	kept := make(map[string]bool)
+	for i := 0; i < entity.GetRowCount(); i++ {
+		if row := entity.GetRowByIndex(i); !replaced[row] {
+			kept[row.GetValue(name)] = true
+		}
+	}
+	return kept
+}
@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareValues(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Shared Values",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "email", ExternalId: "email", Type: "String"},
+					{Name: "badge", ExternalId: "badge", Type: "String",
+						Generator: &parser.Generator{Type: parser.GeneratorSequence, Start: 1, Step: 1}},
+				},
+			},
+			"asset": {
+				DisplayName: "Asset", ExternalId: "Asset",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "ownerEmail", ExternalId: "ownerEmail", Type: "String",
+						SharedValues: &parser.SharedValues{From: "User.email", Overlap: 0.6}},
+					{Name: "badge", ExternalId: "badge", Type: "String", UniqueWithin: "id",
+						SharedValues: &parser.SharedValues{From: "User.badge", Overlap: 1}},
+				},
+			},
+		},
+	}
+	graphInterface, err := model.NewGraph(def, 20)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+
+	generator := NewDataGenerator(t.TempDir(), map[string]int{"User": 4, "Asset": 20}, false)
+	require.NoError(t, generator.Generate(graph))
+
+	user, _ := graph.GetEntity("User")
+	emails, badges := make(map[string]bool), make(map[string]bool)
+	for i := 0; i < user.GetRowCount(); i++ {
+		emails[user.GetRowByIndex(i).GetValue("email")] = true
+		badges[user.GetRowByIndex(i).GetValue("badge")] = true
+	}
+
+	asset, _ := graph.GetEntity("Asset")
+	matched, sharedBadges := 0, make(map[string]bool)
+	for i := 0; i < asset.GetRowCount(); i++ {
+		row := asset.GetRowByIndex(i)
+		if emails[row.GetValue("ownerEmail")] {
+			matched++
+		}
+		if badge := row.GetValue("badge"); badges[badge] {
+			assert.False(t, sharedBadges[badge], "unique values are shared once")
+			sharedBadges[badge] = true
+		}
+	}
+	assert.Equal(t, 12, matched, "60% of the assets join to a user")
+	assert.Len(t, sharedBadges, 4, "a unique attribute takes each source value once")
+}
@@ -0,0 +1,125 @@
+package pipeline
+
+import (
+	"sync"
+	"time"
+
+	"github.com/SGNL-ai/fabricator/pkg/events"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// DefaultWriteWorkers is the number of entity files written at the same time
+const DefaultWriteWorkers = 1
+
+// DefaultWriteFileBuffer is the number of bytes buffered per output file between
+// writes to storage. Large writes keep network filesystems from paying a round
+// trip per few rows.
+const DefaultWriteFileBuffer = 1 << 20
+
+// MinWriteFileBuffer is the smallest per-file buffer accepted, the size the
+// standard library's writers already buffer
+const MinWriteFileBuffer = 4096
+
+// recordSink receives entities' rows from writeRecords, one entity at a time
+type recordSink interface {
+	// begin starts writing an entity whose records have the given column headers
+	begin(entity model.EntityInterface, headers []string) error
+	// write writes one record; flush asks for it to reach the destination immediately
+	write(record []string, flush bool) error
+	// end finishes the current entity
+	end() error
+	// close releases an entity left open by an error; it is a no-op otherwise
+	close()
+}
+
+// writeRecords writes entities' rows through the sink one entity at a time, each
+// row as a record in attribute order, the same layout as Entity.ToCSV, rather than a
+// copy of the whole entity's table. Each finished entity is reported to emitter with
+// its row count and write time.
+func writeRecords(entities []model.EntityInterface, sink recordSink, throttle *Throttle, emitter *events.Emitter) error {
+	defer sink.close()
+
+	for _, entity := range entities {
+		externalID := entity.GetExternalID()
+		throttled := throttle.Enabled(externalID)
+		started := time.Now()
+
+		attributes := entity.GetAttributes()
+		headers := make([]string, 0, len(attributes))
+		for _, attr := range attributes {
+			headers = append(headers, attr.GetExternalID())
+		}
+		if err := sink.begin(entity, headers); err != nil {
+			return err
+		}
+
+		rows := entity.GetRowCount()
+		for i := 0; i < rows; i++ {
+			row := entity.GetRowByIndex(i)
+			record := make([]string, 0, len(attributes))
+			for _, attr := range attributes {
+				record = append(record, row.GetValue(attr.GetName()))
+			}
+			throttle.Wait(externalID)
+			// Flush each row when throttled so readers see them arrive
+			if err := sink.write(record, throttled); err != nil {
+				return err
+			}
+		}
+
+		if err := sink.end(); err != nil {
+			return err
+		}
+		emitter.EntityWritten(externalID, rows, time.Since(started))
+	}
+	return nil
+}
+
+// writeRecordsParallel writes entities through up to workers sinks at a time, each
+// writing one entity as writeRecords does. Creating and filling a file on network
+// storage is mostly waiting, so writing several at once hides that latency.
+// Returns the first error; no further entities are started after one fails.
+func writeRecordsParallel(entities []model.EntityInterface, newSink func() recordSink, workers int, throttle *Throttle, emitter *events.Emitter) error {
+	if workers <= 1 {
+		return writeRecords(entities, newSink(), throttle, emitter)
+	}
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+
+	queue := make(chan model.EntityInterface)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sink := newSink()
+			for entity := range queue {
+				if failed() {
+					continue
+				}
+				if err := writeRecords([]model.EntityInterface{entity}, sink, throttle, emitter); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, entity := range entities {
+		queue <- entity
+	}
+	close(queue)
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink records what writeRecords delivers, optionally failing after a number of records
+type recordingSink struct {
+	events    []string
+	records   map[string][][]string
+	current   string
+	failAfter int
+	written   int
+	closed    bool
+}
+
+func (s *recordingSink) begin(entity model.EntityInterface, headers []string) error {
+	s.current = entity.GetExternalID()
+	s.events = append(s.events, "begin "+s.current)
+	if s.records == nil {
+		s.records = make(map[string][][]string)
+	}
+	s.records[s.current] = [][]string{headers}
+	return nil
+}
+
+func (s *recordingSink) write(record []string, flush bool) error {
+	s.written++
+	if s.failAfter > 0 && s.written > s.failAfter {
+		return errors.New("sink unavailable")
+	}
+	s.records[s.current] = append(s.records[s.current], record)
+	return nil
+}
+
+func (s *recordingSink) end() error {
+	s.events = append(s.events, "end "+s.current)
+	return nil
+}
+
+func (s *recordingSink) close() {
+	s.closed = true
+}
+
+func streamTestGraph(t *testing.T) *model.Graph {
+	t.Helper()
+	graphInterface, err := model.NewGraph(partialInputDefinition(), 10)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"User": 25, "Group": 10}))
+	require.NoError(t, NewRelationshipLinker().LinkRelationships(graph, false))
+	require.NoError(t, NewFieldGenerator().GenerateFields(graph))
+	return graph
+}
+
+func TestWriteRecords(t *testing.T) {
+	graph := streamTestGraph(t)
+	entities := DependencyOrder(graph)
+
+	sink := &recordingSink{}
+	require.NoError(t, writeRecords(entities, sink, nil, nil))
+
+	assert.Equal(t, []string{"begin User", "end User", "begin Group", "end Group"}, sink.events,
+		"entities are written one at a time in order")
+	for _, entity := range entities {
+		assert.Equal(t, entity.ToCSV().Rows, sink.records[entity.GetExternalID()][1:],
+			"records match the entity's CSV layout")
+		assert.Equal(t, entity.ToCSV().Headers, sink.records[entity.GetExternalID()][0])
+	}
+	assert.True(t, sink.closed)
+}
+
+func TestWriteRecords_SinkError(t *testing.T) {
+	graph := streamTestGraph(t)
+
+	sink := &recordingSink{failAfter: 3}
+	err := writeRecords(DependencyOrder(graph), sink, nil, nil)
+	assert.ErrorContains(t, err, "sink unavailable")
+	assert.True(t, sink.closed, "an entity left open by the error is released")
+	assert.Len(t, sink.records["User"], 4, "headers and the records before the failure")
+}
+
+func TestWriteRecordsParallel(t *testing.T) {
+	graph := streamTestGraph(t)
+	entities := DependencyOrder(graph)
+
+	var mu sync.Mutex
+	var sinks []*recordingSink
+	newSink := func(failAfter int) func() recordSink {
+		return func() recordSink {
+			mu.Lock()
+			defer mu.Unlock()
+			sink := &recordingSink{failAfter: failAfter}
+			sinks = append(sinks, sink)
+			return sink
+		}
+	}
+
+	for _, workers := range []int{1, 2, 8} {
+		sinks = nil
+		require.NoError(t, writeRecordsParallel(entities, newSink(0), workers, nil, nil))
+
+		// Each entity is written whole by one worker
+		written := make(map[string][][]string)
+		for _, sink := range sinks {
+			for entity, records := range sink.records {
+				assert.NotContains(t, written, entity, "entity written twice (workers %d)", workers)
+				written[entity] = records
+			}
+		}
+		for _, entity := range entities {
+			assert.Equal(t, entity.ToCSV().Rows, written[entity.GetExternalID()][1:],
+				"records match the entity's CSV layout (workers %d)", workers)
+		}
+	}
+
+	sinks = nil
+	err := writeRecordsParallel(entities, newSink(3), 2, nil, nil)
+	assert.ErrorContains(t, err, "sink unavailable")
+	for _, sink := range sinks {
+		if len(sink.events) > 0 {
+			assert.True(t, sink.closed, "entities left open by the error are released")
+		}
+	}
+}
@@ -0,0 +1,514 @@
+package pipeline
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/SGNL-ai/fabricator/pkg/config"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// maxStreamingIssues caps how many issues one check (an entity's rows or a
+// relationship's foreign keys) reports in streaming mode
+const maxStreamingIssues = 100
+
+// StreamingValidationProcessor validates CSV files without loading them into the
+// model. Each file is read row by row and only key indexes are kept in memory:
+// primary keys, the values relationships point at and the pairs checked by
+// uniqueWithin. Indexes hold 64-bit hashes rather than the values themselves, so
+// memory grows with the number of keys, not the size of the files.
+//
+// A hash collision can hide a duplicate or a dangling foreign key; with n keys the
+// chance is about n²/2⁶⁵, negligible even for billions of rows.
+type StreamingValidationProcessor struct {
+	seed           maphash.Seed
+	workers        int         // Entity files read at the same time; 0 uses DefaultValidationWorkers
+	strictCoercion bool        // Report every value coerced to its attribute's type as an error
+	layout         FileLayout  // How the files were named and placed when written
+	files          *InputFiles // Opens the files, decrypting them; nil reads them in the clear
+}
+
+// NewStreamingValidationProcessor creates a validation processor for datasets too
+// large to load into memory
+func NewStreamingValidationProcessor() ValidationProcessorInterface {
+	return &StreamingValidationProcessor{seed: maphash.MakeSeed()}
+}
+
+// SetWorkers configures how many entity files each pass reads at the same time
+func (p *StreamingValidationProcessor) SetWorkers(workers int) {
+	p.workers = workers
+}
+
+// SetFileLayout configures how the files were named and placed when they were
+// written; the default layout is used otherwise
+func (p *StreamingValidationProcessor) SetFileLayout(layout FileLayout) {
+	p.layout = layout
+}
+
+// SetInputFiles configures how the files are opened, e.g. decrypted; they are read
+// in the clear otherwise
+func (p *StreamingValidationProcessor) SetInputFiles(files *InputFiles) {
+	p.files = files
+}
+
+// SetStrictCoercion makes the first pass report every value it coerces
+func (p *StreamingValidationProcessor) SetStrictCoercion(strict bool) {
+	p.strictCoercion = strict
+}
+
+// keyIndex is a set of hashed values
+type keyIndex map[uint64]struct{}
+
+func (k keyIndex) contains(key uint64) bool {
+	_, exists := k[key]
+	return exists
+}
+
+// filteredIndex holds the values of the target rows matching a relationship's where
+type filteredIndex struct {
+	relationship model.RelationshipInterface
+	index        keyIndex
+}
+
+// columnRef identifies an attribute of an entity
+type columnRef struct {
+	entity string // Entity external ID
+	attr   string // Attribute name
+}
+
+// cappedIssues collects up to maxStreamingIssues messages and counts the rest
+type cappedIssues struct {
+	issues  []string
+	dropped int
+}
+
+func (c *cappedIssues) add(format string, args ...interface{}) {
+	if len(c.issues) >= maxStreamingIssues {
+		c.dropped++
+		return
+	}
+	c.issues = append(c.issues, fmt.Sprintf(format, args...))
+}
+
+// count returns the number of issues found, listed or not
+func (c *cappedIssues) count() int {
+	return len(c.issues) + c.dropped
+}
+
+// list returns the collected issues, followed by a count of those not shown
+func (c *cappedIssues) list(subject string) []string {
+	if c.dropped == 0 {
+		return c.issues
+	}
+	return append(c.issues, fmt.Sprintf("%s: %d more issues not shown", subject, c.dropped))
+}
+
+// ValidateExistingCSVFiles validates existing CSV files, returning only errors
+func (p *StreamingValidationProcessor) ValidateExistingCSVFiles(def *parser.SORDefinition, directory string) ([]string, error) {
+	report, err := p.ValidateExistingCSVFilesReport(def, directory)
+	if err != nil {
+		return nil, err
+	}
+	return report.Errors, nil
+}
+
+// ValidateExistingCSVFilesReport runs the same checks as ValidationProcessor in two
+// passes over the files: the first checks structure, primary keys and scoped
+// uniqueness while indexing the values relationships reference; the second checks
+// each file's foreign keys against those indexes. Files are read in parallel within
+// a pass: each index belongs to one entity, so only that entity's worker fills it.
+func (p *StreamingValidationProcessor) ValidateExistingCSVFilesReport(def *parser.SORDefinition, directory string) (*ValidationReport, error) {
+	report := &ValidationReport{}
+
+	levels, err := relationshipValidationLevels(def)
+	if err != nil {
+		return nil, err
+	}
+
+	graphInterface, err := model.NewGraph(def, 0)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("failed to create graph: %v", err))
+		return report, nil
+	}
+
+	graph, ok := graphInterface.(*model.Graph)
+	if !ok {
+		report.Errors = append(report.Errors, "failed to convert graph to concrete type")
+		return report, nil
+	}
+	foldInverseLevels(def, graph, levels)
+
+	if _, err := os.Stat(directory); os.IsNotExist(err) {
+		report.Errors = append(report.Errors, fmt.Sprintf("directory %s does not exist", directory))
+		return report, nil
+	}
+
+	// Values are compared in their attribute type's form
+	coercion := newValueCoercion(graph)
+
+	// Only index the values that checked relationships reference; relationships with
+	// a where get an index of their own, of the matching rows' values
+	var relationships []model.RelationshipInterface
+	indexes := make(map[columnRef]keyIndex)
+	filtered := make(map[string]*filteredIndex)
+	for _, relationship := range graph.GetAllRelationships() {
+		if levels[relationship.GetID()] == RelationshipValidationSkip {
+			continue
+		}
+		relationships = append(relationships, relationship)
+		if len(relationship.GetWhere()) > 0 {
+			filtered[relationship.GetID()] = &filteredIndex{relationship, keyIndex{}}
+			continue
+		}
+		target := columnRef{relationship.GetTargetEntity().GetExternalID(), relationship.GetTargetAttribute().GetName()}
+		indexes[target] = keyIndex{}
+	}
+	sort.Slice(relationships, func(i, j int) bool {
+		return relationships[i].GetID() < relationships[j].GetID()
+	})
+
+	entities := graph.GetEntitiesList()
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].GetExternalID() < entities[j].GetExternalID()
+	})
+
+	// Pass 1: structure, keys and scoped uniqueness; build the relationship indexes
+	passes := make([]entityPass, len(entities))
+	forEachEntity(entities, p.workers, "indexed", func(i int, entity model.EntityInterface) {
+		pass := &passes[i]
+		if entity.GetPartitionBy() != "" {
+			pass.errors = append(pass.errors, fmt.Sprintf(
+				"entity %s is partitioned by %s, which streaming validation doesn't support; validate without --streaming-validation",
+				entity.GetID(), entity.GetPartitionBy()))
+			return
+		}
+		csvPath := filepath.Join(directory, p.layout.entityFilePath(entity, p.files.FileName(".csv")))
+		if _, err := os.Stat(csvPath); os.IsNotExist(err) {
+			pass.errors = append(pass.errors, fmt.Sprintf("CSV file not found for entity %s: %s", entity.GetID(), csvPath))
+			return
+		}
+
+		structureIssues, err := p.files.lint(csvPath)
+		if err != nil {
+			pass.errors = append(pass.errors, err.Error())
+			return
+		}
+		for _, issue := range structureIssues {
+			pass.errors = append(pass.errors, "CSV structure: "+issue)
+		}
+
+		scan, err := p.scanEntity(entity, csvPath, coercion, indexes, filtered)
+		pass.scan = scan
+		pass.errors = append(pass.errors, scan.issues.list("entity "+entity.GetExternalID())...)
+		if err != nil {
+			pass.loadError = fmt.Sprintf("failed to load CSV for entity %s: %v", entity.GetID(), err)
+			return
+		}
+		pass.scanned = true
+	})
+	for i, pass := range passes {
+		report.Errors = append(report.Errors, pass.errors...)
+		if pass.scan != nil {
+			report.addCheck(config.ValidationCheckUniqueWithin, RelationshipValidationError, pass.scan.scopedChecked,
+				pass.scan.scoped.count(), pass.scan.scoped.list("entity "+entities[i].GetExternalID()))
+		}
+		if pass.loadError != "" {
+			report.Errors = append(report.Errors, pass.loadError)
+		}
+	}
+
+	// Pass 2: foreign keys, one read per source file; the indexes are only read
+	outgoing := make([][]model.RelationshipInterface, len(entities))
+	checks := make([][]foreignKeyCheck, len(entities))
+	loadErrors := make([]string, len(entities))
+	forEachEntity(entities, p.workers, "checked foreign keys of", func(i int, entity model.EntityInterface) {
+		if !passes[i].scanned {
+			return
+		}
+		for _, relationship := range relationships {
+			if relationship.GetSourceEntity().GetID() == entity.GetID() {
+				outgoing[i] = append(outgoing[i], relationship)
+			}
+		}
+		if len(outgoing[i]) == 0 {
+			return
+		}
+
+		csvPath := filepath.Join(directory, p.layout.entityFilePath(entity, p.files.FileName(".csv")))
+		entityChecks, err := p.checkForeignKeys(entity, csvPath, coercion, outgoing[i], indexes, filtered)
+		if err != nil {
+			loadErrors[i] = fmt.Sprintf("failed to load CSV for entity %s: %v", entity.GetID(), err)
+			return
+		}
+		checks[i] = entityChecks
+	})
+	for i := range entities {
+		if loadErrors[i] != "" {
+			report.Errors = append(report.Errors, loadErrors[i])
+			continue
+		}
+		for j, check := range checks[i] {
+			relationship := outgoing[i][j]
+			report.addCheck(config.ValidationCheckForeignKeys, levels[relationship.GetID()], check.checked,
+				check.orphans.count(), check.orphans.list("relationship "+relationship.GetID()))
+		}
+	}
+
+	return report, nil
+}
+
+// entityPass is the outcome of the first pass over one entity's file
+type entityPass struct {
+	errors    []string
+	scan      *entityScan // Nil if the file is missing or unreadable
+	loadError string      // Why the file couldn't be read through
+	scanned   bool        // The file was read through, so its foreign keys can be checked
+}
+
+// csvRows reads a CSV file, opened with files, one record at a time; the record and
+// values passed to fn are reused between rows. Columns are resolved to attribute
+// names (external ID first, then name); unknown columns map to "". The record holds
+// the values as written and values their form for comparisons (see valueCoercion),
+// calling coerced, when not nil, for each value whose form differs. Row numbers
+// start at 1 for the first data row.
+func csvRows(files *InputFiles, entity model.EntityInterface, csvPath string, coercion *valueCoercion, coerced func(row int, column, from, to string),
+	fn func(columns, record, values []string, row int)) error {
+	file, err := files.Open(csvPath)
+	if err != nil {
+		return fmt.Errorf("failed to open CSV file %s: %w", csvPath, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // Column counts are checked per row
+	reader.ReuseRecord = true
+
+	headers, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("CSV file %s is empty", csvPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read CSV file %s: %w", csvPath, err)
+	}
+
+	attributes := columnAttributes(entity, headers)
+	columns := make([]string, len(headers))
+	for i, attr := range attributes {
+		if attr != nil {
+			columns[i] = attr.GetName()
+		}
+	}
+
+	var values []string
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV file %s: %w", csvPath, err)
+		}
+		values = coercion.normalizeRecord(attributes, record, values, func(column int, to string) {
+			if coerced != nil {
+				coerced(row, columns[column], record[column], to)
+			}
+		})
+		fn(columns, record, values, row)
+	}
+}
+
+// columnIndex returns the position of an attribute's column, or -1 if absent
+func columnIndex(columns []string, attrName string) int {
+	for i, column := range columns {
+		if column == attrName {
+			return i
+		}
+	}
+	return -1
+}
+
+// entityScan is the outcome of scanning one entity's file
+type entityScan struct {
+	issues        cappedIssues // Malformed rows and primary key problems
+	scoped        cappedIssues // uniqueWithin violations
+	scopedChecked int          // Non-empty values checked for uniqueWithin
+}
+
+// scanEntity checks an entity's file for malformed rows, missing or duplicate
+// primary keys and uniqueWithin violations, adding referenced values to indexes,
+// and those of rows matching a relationship's where to its filtered index
+func (p *StreamingValidationProcessor) scanEntity(entity model.EntityInterface, csvPath string, coercion *valueCoercion, indexes map[columnRef]keyIndex,
+	filtered map[string]*filteredIndex) (*entityScan, error) {
+	scan := &entityScan{}
+	issues := &scan.issues
+	entityID := entity.GetExternalID()
+
+	type indexedColumn struct {
+		column int
+		index  keyIndex
+		where  map[int]string // Values a record must hold to be indexed
+	}
+	type scopedColumn struct {
+		attr, scope     string
+		column, scopeAt int
+		firstSeen       map[[2]uint64]int // (scope hash, value hash) → first row
+	}
+
+	var pkName string
+	pkColumn := -1
+	pkIndex := keyIndex{}
+	var indexed []indexedColumn
+	var scoped []scopedColumn
+	width := -1
+
+	var coerced func(row int, column, from, to string)
+	if p.strictCoercion {
+		coerced = func(row int, column, from, to string) {
+			issues.add("%s", coercionIssue(entityID, row, column, from, to))
+		}
+	}
+
+	err := csvRows(p.files, entity, csvPath, coercion, coerced, func(columns, record, values []string, row int) {
+		if width < 0 {
+			width = len(columns)
+			if pk := entity.GetPrimaryKey(); pk != nil {
+				pkName = pk.GetName()
+				pkColumn = columnIndex(columns, pkName)
+				if pkColumn < 0 {
+					issues.add("entity %s: CSV file %s has no column for primary key '%s'", entityID, csvPath, pkName)
+				}
+				// Relationships to the primary key share its index
+				if shared, exists := indexes[columnRef{entityID, pkName}]; exists {
+					pkIndex = shared
+				}
+			}
+			for ref, index := range indexes {
+				if ref.entity == entityID && ref.attr != pkName {
+					if column := columnIndex(columns, ref.attr); column >= 0 {
+						indexed = append(indexed, indexedColumn{column, index, nil})
+					}
+				}
+			}
+			for _, filter := range filtered {
+				relationship := filter.relationship
+				if relationship.GetTargetEntity().GetExternalID() != entityID {
+					continue
+				}
+				if column := columnIndex(columns, relationship.GetTargetAttribute().GetName()); column >= 0 {
+					indexed = append(indexed, indexedColumn{column, filter.index, whereColumns(relationship.GetWhere(), columns)})
+				}
+			}
+			for _, attr := range entity.GetAttributes() {
+				if attr.GetUniqueWithin() == "" {
+					continue
+				}
+				column, scopeAt := columnIndex(columns, attr.GetName()), columnIndex(columns, attr.GetUniqueWithin())
+				if column >= 0 {
+					scoped = append(scoped, scopedColumn{attr.GetName(), attr.GetUniqueWithin(), column, scopeAt, make(map[[2]uint64]int)})
+				}
+			}
+		}
+
+		if len(record) != width {
+			issues.add("CSV file %s row %d has %d columns, expected %d", csvPath, row, len(record), width)
+			return
+		}
+
+		if pkColumn >= 0 {
+			value := record[pkColumn]
+			if value == "" {
+				issues.add("entity %s: row %d: missing required primary key value for attribute '%s'", entityID, row, pkName)
+			} else if key := p.hash(values[pkColumn]); pkIndex.contains(key) {
+				issues.add("entity %s: row %d: duplicate value '%s' for unique attribute '%s'", entityID, row, value, pkName)
+			} else {
+				pkIndex[key] = struct{}{}
+			}
+		}
+
+		for _, column := range indexed {
+			if value := record[column.column]; value != "" && matchesColumns(record, column.where) {
+				column.index[p.hash(values[column.column])] = struct{}{}
+			}
+		}
+
+		for _, column := range scoped {
+			value := record[column.column]
+			if value == "" {
+				continue
+			}
+			scan.scopedChecked++
+			scopeValue, scopeKey := "", ""
+			if column.scopeAt >= 0 {
+				scopeValue, scopeKey = record[column.scopeAt], values[column.scopeAt]
+			}
+			key := [2]uint64{p.hash(scopeKey), p.hash(values[column.column])}
+			if first, exists := column.firstSeen[key]; exists {
+				scan.scoped.add("entity %s: row %d: %s '%s' is not unique within %s '%s' (first used in row %d)",
+					entityID, row, column.attr, value, column.scope, scopeValue, first)
+				continue
+			}
+			column.firstSeen[key] = row
+		}
+	})
+	return scan, err
+}
+
+// foreignKeyCheck is the outcome of checking one relationship's foreign keys
+type foreignKeyCheck struct {
+	orphans cappedIssues
+	checked int // Non-empty foreign key values checked
+}
+
+// checkForeignKeys reports, per relationship, the non-empty foreign key values of
+// an entity's file that are missing from the relationship's target index
+func (p *StreamingValidationProcessor) checkForeignKeys(entity model.EntityInterface, csvPath string, coercion *valueCoercion,
+	relationships []model.RelationshipInterface, indexes map[columnRef]keyIndex, filtered map[string]*filteredIndex) ([]foreignKeyCheck, error) {
+	checks := make([]foreignKeyCheck, len(relationships))
+	var sourceColumns []int
+
+	// Coercions were reported in the first pass
+	err := csvRows(p.files, entity, csvPath, coercion, nil, func(columns, record, values []string, row int) {
+		if sourceColumns == nil {
+			sourceColumns = make([]int, len(relationships))
+			for i, relationship := range relationships {
+				sourceColumns[i] = columnIndex(columns, relationship.GetSourceAttribute().GetName())
+			}
+		}
+
+		// Malformed rows were reported in the first pass
+		if len(record) != len(columns) {
+			return
+		}
+
+		for i, relationship := range relationships {
+			column := sourceColumns[i]
+			if column < 0 || record[column] == "" {
+				continue
+			}
+			checks[i].checked++
+			target := columnRef{relationship.GetTargetEntity().GetExternalID(), relationship.GetTargetAttribute().GetName()}
+			index, matching := indexes[target], ""
+			if filter, exists := filtered[relationship.GetID()]; exists {
+				index, matching = filter.index, " where "+describeWhere(relationship.GetWhere())
+			}
+			if !index.contains(p.hash(values[column])) {
+				checks[i].orphans.add("relationship %s: foreign key '%s' in %s (row %d) does not exist in %s.%s%s",
+					relationship.GetID(), record[column], entity.GetExternalID(), row, target.entity, target.attr, matching)
+			}
+		}
+	})
+	return checks, err
+}
+
+// hash returns the index key for a value
+func (p *StreamingValidationProcessor) hash(value string) uint64 {
+	return maphash.String(p.seed, value)
+}
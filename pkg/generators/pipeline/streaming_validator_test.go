@@ -0,0 +1,114 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamingValidationProcessor(t *testing.T) {
+	t.Run("valid files report nothing", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "User.csv"), []byte("id,roleId\nuser-1,role-1\nuser-2,\n"), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "Role.csv"), []byte("id\nrole-1\n"), 0600))
+
+		report, err := NewStreamingValidationProcessor().ValidateExistingCSVFilesReport(userRoleDefinition(""), dir)
+		require.NoError(t, err)
+		assert.Empty(t, report.Errors)
+		assert.Empty(t, report.Warnings)
+	})
+
+	t.Run("reports duplicate keys and dangling foreign keys", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "User.csv"), []byte("id,roleId\nuser-1,role-1\nuser-1,role-999\n,role-1\n"), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "Role.csv"), []byte("id\nrole-1\n"), 0600))
+
+		errors, err := NewStreamingValidationProcessor().ValidateExistingCSVFiles(userRoleDefinition(""), dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"entity User: row 2: duplicate value 'user-1' for unique attribute 'id'",
+			"entity User: row 3: missing required primary key value for attribute 'id'",
+			"relationship user_role: foreign key 'role-999' in User (row 2) does not exist in Role.id",
+		}, errors)
+	})
+
+	t.Run("follows relationship validation levels", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "User.csv"), []byte("id,roleId\nuser-1,role-999\n"), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "Role.csv"), []byte("id\nrole-1\n"), 0600))
+
+		processor := NewStreamingValidationProcessor()
+		report, err := processor.ValidateExistingCSVFilesReport(userRoleDefinition("warn"), dir)
+		require.NoError(t, err)
+		assert.Empty(t, report.Errors)
+		require.Len(t, report.Warnings, 1)
+		assert.Contains(t, report.Warnings[0], "role-999")
+
+		report, err = processor.ValidateExistingCSVFilesReport(userRoleDefinition("skip"), dir)
+		require.NoError(t, err)
+		assert.Empty(t, report.Errors)
+		assert.Empty(t, report.Warnings)
+	})
+
+	t.Run("reports missing files and malformed rows", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "User.csv"), []byte("id,roleId\nuser-1,role-1,extra\n"), 0600))
+
+		errors, err := NewStreamingValidationProcessor().ValidateExistingCSVFiles(userRoleDefinition(""), dir)
+		require.NoError(t, err)
+		joined := strings.Join(errors, "\n")
+		assert.Contains(t, joined, "CSV file not found for entity Role")
+		assert.Contains(t, joined, "CSV structure: ")
+		assert.Contains(t, joined, "row 1 has 3 columns, expected 2")
+		assert.NotContains(t, joined, "foreign key", "malformed rows aren't checked for foreign keys")
+	})
+
+	t.Run("caps issues per relationship", func(t *testing.T) {
+		dir := t.TempDir()
+		var users strings.Builder
+		users.WriteString("id,roleId\n")
+		for i := 0; i < maxStreamingIssues+5; i++ {
+			fmt.Fprintf(&users, "user-%d,missing-%d\n", i, i)
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "User.csv"), []byte(users.String()), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "Role.csv"), []byte("id\nrole-1\n"), 0600))
+
+		errors, err := NewStreamingValidationProcessor().ValidateExistingCSVFiles(userRoleDefinition(""), dir)
+		require.NoError(t, err)
+		require.Len(t, errors, maxStreamingIssues+1)
+		assert.Equal(t, "relationship user_role: 5 more issues not shown", errors[maxStreamingIssues])
+	})
+
+	t.Run("checks uniqueWithin scopes", func(t *testing.T) {
+		dir := t.TempDir()
+		def := &parser.SORDefinition{
+			DisplayName: "Tenants",
+			Description: "SOR with emails unique per tenant",
+			Entities: map[string]parser.Entity{
+				"account": {
+					DisplayName: "Account",
+					ExternalId:  "Account",
+					Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+						{Name: "tenant", ExternalId: "tenant", Type: "String"},
+						{Name: "email", ExternalId: "email", Type: "String", UniqueWithin: "tenant"},
+					},
+				},
+			},
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "Account.csv"),
+			[]byte("id,tenant,email\na1,t1,x@example.com\na2,t2,x@example.com\na3,t1,x@example.com\n"), 0600))
+
+		errors, err := NewStreamingValidationProcessor().ValidateExistingCSVFiles(def, dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"entity Account: row 3: email 'x@example.com' is not unique within tenant 't1' (first used in row 1)",
+		}, errors)
+	})
+}
@@ -0,0 +1,60 @@
+package pipeline
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// rowWeight returns the product of the weights whose conditions a target row
+// satisfies, 1 when it satisfies none
+func rowWeight(row *model.Row, weights []model.TargetWeight) float64 {
+	weight := 1.0
+	for _, rule := range weights {
+		value := row.GetValue(rule.Attribute)
+		var holds bool
+		if rule.Op == "contains" {
+			holds = strings.Contains(value, rule.Value)
+		} else {
+			holds = value != "" && compareHolds(compareValues(value, rule.Value), rule.Op)
+		}
+		if holds {
+			weight *= rule.Weight
+		}
+	}
+	return weight
+}
+
+// weightedTargets chooses among a relationship's target rows in proportion to
+// their weights
+type weightedTargets struct {
+	cumulative []float64       // Running total of the weights, one per target row
+	faker      *gofakeit.Faker // Draws rows with auto-cardinality
+}
+
+// add appends a target row with a positive weight
+func (w *weightedTargets) add(weight float64) {
+	total := weight
+	if n := len(w.cumulative); n > 0 {
+		total += w.cumulative[n-1]
+	}
+	w.cumulative = append(w.cumulative, total)
+}
+
+// index returns the target row of a source row. With auto-cardinality rows are
+// drawn at random, so references cluster as in real data; without, the source
+// rows are split into shares in proportion to the weights, the way round-robin
+// gives every target row the same share.
+func (w *weightedTargets) index(sourceRowIndex, sourceRowCount int, autoCardinality bool) int {
+	total := w.cumulative[len(w.cumulative)-1]
+	var point float64
+	if autoCardinality {
+		point = w.faker.Float64Range(0, total)
+	} else {
+		point = (float64(sourceRowIndex) + 0.5) / float64(max(sourceRowCount, 1)) * total
+	}
+	index := sort.Search(len(w.cumulative), func(i int) bool { return w.cumulative[i] > point })
+	return min(index, len(w.cumulative)-1)
+}
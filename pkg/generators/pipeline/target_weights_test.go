@@ -0,0 +1,92 @@
+package pipeline
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeightedRelationship(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Weighted Memberships",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "title", ExternalId: "title", Type: "String"},
+				},
+			},
+			"group": {
+				DisplayName: "Group", ExternalId: "Group",
+				Attributes: []parser.Attribute{{Name: "id", ExternalId: "id", Type: "String", UniqueId: true}},
+			},
+			"user_group": {
+				DisplayName: "UserGroup", ExternalId: "UserGroup",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "userId", ExternalId: "userId", Type: "String"},
+					{Name: "groupId", ExternalId: "groupId", Type: "String"},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"member_user": {DisplayName: "Member User", Name: "member_user", FromAttribute: "UserGroup.userId", ToAttribute: "User.id",
+				Weights: []parser.TargetWeight{
+					{When: "title contains Manager", Weight: 3},
+					{When: "title == Contractor", Weight: 0},
+				}},
+			"member_group": {DisplayName: "Member Group", Name: "member_group", FromAttribute: "UserGroup.groupId", ToAttribute: "Group.id"},
+		},
+	}
+
+	for _, autoCardinality := range []bool{false, true} {
+		t.Run(fmt.Sprintf("auto-cardinality %v", autoCardinality), func(t *testing.T) {
+			graphInterface, err := model.NewGraph(def, 10)
+			require.NoError(t, err)
+			graph := graphInterface.(*model.Graph)
+			users, _ := graph.GetEntity("User")
+			titles := map[string]string{"u0": "Engineering Manager", "u1": "Sales Manager", "u9": "Contractor"}
+			for i := 0; i < 10; i++ {
+				id := fmt.Sprintf("u%d", i)
+				title, exists := titles[id]
+				if !exists {
+					title = "Engineer"
+				}
+				require.NoError(t, users.AddRow(model.NewPinnedRow(map[string]string{"id": id, "title": title})))
+			}
+
+			generator := NewDataGenerator(t.TempDir(), map[string]int{"User": 10, "Group": 100, "UserGroup": 280}, autoCardinality)
+			require.NoError(t, generator.Generate(graph))
+
+			memberships := make(map[string]int)
+			userGroups, _ := graph.GetEntity("UserGroup")
+			for i := 0; i < userGroups.GetRowCount(); i++ {
+				memberships[userGroups.GetRowByIndex(i).GetValue("userId")]++
+			}
+			assert.Zero(t, memberships["u9"], "rows weighing 0 aren't referenced")
+			managers := float64(memberships["u0"]+memberships["u1"]) / 2
+			engineers := float64(0)
+			for i := 2; i < 9; i++ {
+				engineers += float64(memberships[fmt.Sprintf("u%d", i)]) / 7
+			}
+			if autoCardinality {
+				// Random draws over the few junction rows left after clustering vary widely
+				assert.Greater(t, managers, 1.5*engineers, "managers %v, engineers %v", managers, engineers)
+				return
+			}
+			assert.InDelta(t, 3, managers/engineers, 0.5, "managers %v, engineers %v", managers, engineers)
+		})
+	}
+
+	t.Run("should describe the distribution", func(t *testing.T) {
+		graph, err := model.NewGraph(def, 10)
+		require.NoError(t, err)
+		relationship, _ := graph.(*model.Graph).GetRelationship("member_user")
+		assert.Contains(t, relationship.DescribeDistribution(true), "weights")
+	})
+}
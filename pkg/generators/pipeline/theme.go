@@ -0,0 +1,213 @@
+package pipeline
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/brianvoe/gofakeit/v6"
+	"gopkg.in/yaml.v3"
+)
+
+// ThemeRandom picks one of the built-in themes, drawn from the run's seed
+const ThemeRandom = "random"
+
+// Vocabularies of a theme
+const (
+	themeDepartments  = "departments"
+	themeGroups       = "groups"
+	themeProjects     = "projects"
+	themeApplications = "applications"
+)
+
+//go:embed themes/*.yaml
+var builtinThemes embed.FS
+
+// themeAttributes maps normalized attribute names (see personAttributeName) to the
+// vocabulary they take in any entity
+var themeAttributes = map[string]string{
+	"department":      themeDepartments,
+	"departmentname":  themeDepartments,
+	"dept":            themeDepartments,
+	"division":        themeDepartments,
+	"group":           themeGroups,
+	"groupname":       themeGroups,
+	"team":            themeGroups,
+	"teamname":        themeGroups,
+	"project":         themeProjects,
+	"projectname":     themeProjects,
+	"application":     themeApplications,
+	"applicationname": themeApplications,
+	"app":             themeApplications,
+	"appname":         themeApplications,
+}
+
+// themeEntities maps normalized entity names, singular, to the vocabulary naming
+// their rows
+var themeEntities = map[string]string{
+	"department":  themeDepartments,
+	"division":    themeDepartments,
+	"group":       themeGroups,
+	"team":        themeGroups,
+	"project":     themeProjects,
+	"application": themeApplications,
+	"app":         themeApplications,
+}
+
+// themeNameAttributes are the normalized names of attributes naming an entity's rows
+var themeNameAttributes = map[string]bool{
+	"name":        true,
+	"displayname": true,
+	"title":       true,
+	"label":       true,
+}
+
+// Theme is a vocabulary for a vertical that names departments, groups, projects and
+// applications, so demo datasets read as one organization
+type Theme struct {
+	Name         string   `yaml:"name"`
+	Extends      string   `yaml:"extends,omitempty"` // Built-in theme whose vocabulary this one adds to
+	Departments  []string `yaml:"departments,omitempty"`
+	Groups       []string `yaml:"groups,omitempty"`
+	Projects     []string `yaml:"projects,omitempty"`
+	Applications []string `yaml:"applications,omitempty"`
+}
+
+// ThemeNames returns the names of the built-in themes, sorted
+func ThemeNames() []string {
+	entries, _ := builtinThemes.ReadDir("themes")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadTheme returns a built-in theme by name, one drawn from the "theme" stream of
+// streams for ThemeRandom, or the theme in a YAML file:
+//
+//	name: biotech
+//	extends: healthcare      # optional: add to a built-in theme's vocabulary
+//	departments: [Genomics, Bioinformatics]
+//	groups: [Sequencing Leads]
+//	projects: [CRISPR Screening]
+//	applications: [LIMS]
+//
+// Vocabularies a theme leaves empty keep their usual generated values.
+func LoadTheme(spec string, streams *RandomStreams) (*Theme, error) {
+	names := ThemeNames()
+	if spec == ThemeRandom {
+		spec = streams.stream("theme").RandomString(names)
+	}
+	if slices.Contains(names, spec) {
+		return builtinTheme(spec)
+	}
+
+	data, err := os.ReadFile(spec) // #nosec G304 - path is from CLI argument, user-controlled
+	if err != nil {
+		return nil, fmt.Errorf("theme '%s' is neither a built-in theme (%s, %s) nor a readable file: %w",
+			spec, strings.Join(names, ", "), ThemeRandom, err)
+	}
+	theme, err := parseTheme(data, spec)
+	if err != nil {
+		return nil, err
+	}
+	if theme.Extends != "" {
+		if !slices.Contains(names, theme.Extends) {
+			return nil, fmt.Errorf("theme %s extends unknown theme '%s' (built-in themes: %s)",
+				spec, theme.Extends, strings.Join(names, ", "))
+		}
+		base, err := builtinTheme(theme.Extends)
+		if err != nil {
+			return nil, err
+		}
+		theme.Departments = append(base.Departments, theme.Departments...)
+		theme.Groups = append(base.Groups, theme.Groups...)
+		theme.Projects = append(base.Projects, theme.Projects...)
+		theme.Applications = append(base.Applications, theme.Applications...)
+	}
+	return theme, nil
+}
+
+// builtinTheme loads a built-in theme by name
+func builtinTheme(name string) (*Theme, error) {
+	data, err := builtinThemes.ReadFile("themes/" + name + ".yaml")
+	if err != nil {
+		return nil, err
+	}
+	return parseTheme(data, name)
+}
+
+// parseTheme parses a theme, named after source when it has no name
+func parseTheme(data []byte, source string) (*Theme, error) {
+	var theme Theme
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&theme); err != nil {
+		return nil, fmt.Errorf("invalid theme %s: %w", source, err)
+	}
+	if theme.Name == "" {
+		theme.Name = source
+	}
+	return &theme, nil
+}
+
+// vocabulary returns the words of one of the theme's vocabularies
+func (t *Theme) vocabulary(name string) []string {
+	switch name {
+	case themeDepartments:
+		return t.Departments
+	case themeGroups:
+		return t.Groups
+	case themeProjects:
+		return t.Projects
+	case themeApplications:
+		return t.Applications
+	}
+	return nil
+}
+
+// category returns the vocabulary an attribute takes its values from: the one its
+// name is a kind of (department, team, appName...) or, for the name attributes of an
+// entity such as a group or project, the one naming the entity's rows. It returns ""
+// for attributes that aren't strings or whose vocabulary the theme leaves empty.
+func (t *Theme) category(attr model.AttributeInterface) string {
+	if t == nil || attr.GetDataType() != "String" || attr.GetUniqueWithin() != "" {
+		return ""
+	}
+
+	name := personAttributeName(attr.GetName())
+	category, exists := themeAttributes[name]
+	if !exists && themeNameAttributes[name] && attr.GetParentEntity() != nil {
+		category = themeEntities[themeEntityName(attr.GetParentEntity().GetExternalID())]
+	}
+	if len(t.vocabulary(category)) == 0 {
+		return ""
+	}
+	return category
+}
+
+// themeEntityName normalizes an entity external ID for themeEntities, keeping its
+// last word, singular, so UserGroups and Okta_Applications match
+func themeEntityName(externalID string) string {
+	name := externalID
+	for i := len(externalID) - 1; i > 0; i-- {
+		if c := externalID[i]; c == '_' || c == '-' || c == '.' || (c >= 'A' && c <= 'Z') {
+			name = externalID[i:]
+			break
+		}
+	}
+	name = strings.ToLower(strings.Trim(name, "_-."))
+	return strings.TrimSuffix(name, "s")
+}
+
+// value draws a word of one of the theme's vocabularies from faker
+func (t *Theme) value(category string, faker *gofakeit.Faker) string {
+	return faker.RandomString(t.vocabulary(category))
+}
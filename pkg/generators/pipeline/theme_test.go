@@ -0,0 +1,126 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTheme(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "theme.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	t.Run("built-in themes", func(t *testing.T) {
+		assert.Equal(t, []string{"finance", "gaming", "healthcare"}, ThemeNames())
+		for _, name := range ThemeNames() {
+			theme, err := LoadTheme(name, nil)
+			require.NoError(t, err)
+			assert.Equal(t, name, theme.Name)
+			for _, category := range []string{themeDepartments, themeGroups, themeProjects, themeApplications} {
+				assert.NotEmpty(t, theme.vocabulary(category), "%s %s", name, category)
+			}
+		}
+	})
+
+	t.Run("random theme follows the seed", func(t *testing.T) {
+		first, err := LoadTheme(ThemeRandom, NewRandomStreams(42, nil))
+		require.NoError(t, err)
+		again, err := LoadTheme(ThemeRandom, NewRandomStreams(42, nil))
+		require.NoError(t, err)
+		assert.Contains(t, ThemeNames(), first.Name)
+		assert.Equal(t, first.Name, again.Name)
+	})
+
+	t.Run("theme file extending a built-in theme", func(t *testing.T) {
+		theme, err := LoadTheme(write(t, "name: biotech\nextends: healthcare\ndepartments: [Genomics]\n"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "biotech", theme.Name)
+		assert.Contains(t, theme.Departments, "Genomics")
+		assert.Contains(t, theme.Departments, "Cardiology")
+		assert.NotEmpty(t, theme.Groups)
+	})
+
+	tests := []struct {
+		name    string
+		spec    func(t *testing.T) string
+		wantErr string
+	}{
+		{name: "unknown theme", spec: func(*testing.T) string { return "retail" },
+			wantErr: "theme 'retail' is neither a built-in theme (finance, gaming, healthcare, random) nor a readable file"},
+		{name: "unknown field", spec: func(t *testing.T) string { return write(t, "name: x\nteams: [A]\n") },
+			wantErr: "field teams not found"},
+		{name: "unknown base", spec: func(t *testing.T) string { return write(t, "name: x\nextends: retail\n") },
+			wantErr: "extends unknown theme 'retail'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadTheme(tt.spec(t), nil)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestFieldGenerator_Theme(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Theme",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "displayName", ExternalId: "displayName", Type: "String"},
+					{Name: "department", ExternalId: "department", Type: "String"},
+					{Name: "profile__team", ExternalId: "profile__team", Type: "String"},
+					{Name: "project", ExternalId: "project", Type: "String", Generator: &parser.Generator{Type: parser.GeneratorSSN}},
+				},
+			},
+			"group": {
+				DisplayName: "Group", ExternalId: "OktaGroups",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "name", ExternalId: "name", Type: "String"},
+					{Name: "description", ExternalId: "description", Type: "String"},
+				},
+			},
+			"application": {
+				DisplayName: "Application", ExternalId: "Application",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "label", ExternalId: "label", Type: "String"},
+				},
+			},
+		},
+	}
+	graphInterface, err := model.NewGraph(def, 20)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"User": 20, "OktaGroups": 20, "Application": 20}))
+
+	theme, err := LoadTheme("finance", nil)
+	require.NoError(t, err)
+	generator := &FieldGenerator{}
+	generator.SetTheme(theme)
+	require.NoError(t, generator.GenerateFields(graph))
+
+	user, _ := graph.GetEntity("User")
+	group, _ := graph.GetEntity("Group")
+	application, _ := graph.GetEntity("Application")
+	for i := 0; i < 20; i++ {
+		userRow := user.GetRowByIndex(i)
+		assert.Contains(t, theme.Departments, userRow.GetValue("department"))
+		assert.Contains(t, theme.Groups, userRow.GetValue("profile__team"))
+		assert.NotContains(t, theme.Groups, userRow.GetValue("displayName"), "users aren't named from the theme")
+		assert.NotContains(t, theme.Projects, userRow.GetValue("project"), "generator hints take precedence")
+		assert.Contains(t, theme.Groups, group.GetRowByIndex(i).GetValue("name"))
+		assert.NotContains(t, theme.Groups, group.GetRowByIndex(i).GetValue("description"))
+		assert.Contains(t, theme.Applications, application.GetRowByIndex(i).GetValue("label"))
+	}
+}
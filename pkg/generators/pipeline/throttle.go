@@ -0,0 +1,100 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Throttle paces row output so data arrives gradually instead of all at once.
+// A nil *Throttle never waits. Entities may be paced from different goroutines.
+type Throttle struct {
+	rowsPerSecond float64            // Default rate for every entity; 0 means unlimited
+	overrides     map[string]float64 // Per-entity rates keyed by external ID; 0 means unlimited
+	mu            sync.Mutex         // Guards next
+	next          map[string]time.Time
+
+	// Clock hooks, replaced in tests
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// NewThrottle creates a throttle with a default rate and optional per-entity overrides.
+// Returns nil when no rate is configured anywhere.
+func NewThrottle(rowsPerSecond float64, overrides map[string]float64) *Throttle {
+	if rowsPerSecond <= 0 && len(overrides) == 0 {
+		return nil
+	}
+	return &Throttle{
+		rowsPerSecond: rowsPerSecond,
+		overrides:     overrides,
+		next:          make(map[string]time.Time),
+		now:           time.Now,
+		sleep:         time.Sleep,
+	}
+}
+
+// Enabled reports whether rows for the entity are paced at all
+func (t *Throttle) Enabled(entityExternalID string) bool {
+	return t.rate(entityExternalID) > 0
+}
+
+// Wait blocks until the next row for the entity may be emitted
+func (t *Throttle) Wait(entityExternalID string) {
+	rate := t.rate(entityExternalID)
+	if rate <= 0 {
+		return
+	}
+
+	// Reserve the slot under the lock, then sleep without holding it
+	t.mu.Lock()
+	now := t.now()
+	next, scheduled := t.next[entityExternalID]
+	if !scheduled || !next.After(now) {
+		next = now
+	}
+	t.next[entityExternalID] = next.Add(time.Duration(float64(time.Second) / rate))
+	t.mu.Unlock()
+
+	if next.After(now) {
+		t.sleep(next.Sub(now))
+	}
+}
+
+// rate returns the effective rows per second for an entity
+func (t *Throttle) rate(entityExternalID string) float64 {
+	if t == nil {
+		return 0
+	}
+	if rate, exists := t.overrides[entityExternalID]; exists {
+		return rate
+	}
+	return t.rowsPerSecond
+}
+
+// ParseRateOverrides parses per-entity rates of the form "User=10,Group=2.5".
+// A rate of 0 disables throttling for that entity.
+func ParseRateOverrides(spec string) (map[string]float64, error) {
+	overrides := make(map[string]float64)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		entity, value, found := strings.Cut(pair, "=")
+		entity = strings.TrimSpace(entity)
+		if !found || entity == "" {
+			return nil, fmt.Errorf("invalid rate override '%s': expected <entity>=<rows per second>", pair)
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("invalid rate for entity '%s': must be a non-negative number", entity)
+		}
+		overrides[entity] = rate
+	}
+	return overrides, nil
+}
@@ -0,0 +1,123 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock advances only when the throttle sleeps
+type fakeClock struct {
+	current time.Time
+	slept   time.Duration
+}
+
+func (c *fakeClock) now() time.Time { return c.current }
+
+func (c *fakeClock) sleep(d time.Duration) {
+	c.current = c.current.Add(d)
+	c.slept += d
+}
+
+func newTestThrottle(rowsPerSecond float64, overrides map[string]float64) (*Throttle, *fakeClock) {
+	clock := &fakeClock{current: time.Unix(0, 0)}
+	throttle := NewThrottle(rowsPerSecond, overrides)
+	if throttle != nil {
+		throttle.now = clock.now
+		throttle.sleep = clock.sleep
+	}
+	return throttle, clock
+}
+
+func TestThrottle_Wait(t *testing.T) {
+	tests := []struct {
+		name          string
+		rowsPerSecond float64
+		overrides     map[string]float64
+		entity        string
+		rows          int
+		expectedSlept time.Duration
+	}{
+		{name: "Default rate paces rows", rowsPerSecond: 10, entity: "User", rows: 5, expectedSlept: 400 * time.Millisecond},
+		{name: "Override replaces default rate", rowsPerSecond: 10, overrides: map[string]float64{"User": 2}, entity: "User", rows: 3, expectedSlept: time.Second},
+		{name: "Zero override disables throttling", rowsPerSecond: 10, overrides: map[string]float64{"User": 0}, entity: "User", rows: 5},
+		{name: "Override applies only to its entity", overrides: map[string]float64{"User": 2}, entity: "Group", rows: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle, clock := newTestThrottle(tt.rowsPerSecond, tt.overrides)
+			for i := 0; i < tt.rows; i++ {
+				throttle.Wait(tt.entity)
+			}
+			assert.Equal(t, tt.expectedSlept, clock.slept)
+			assert.Equal(t, tt.expectedSlept > 0, throttle.Enabled(tt.entity))
+		})
+	}
+
+	t.Run("Slow consumers do not accumulate a burst", func(t *testing.T) {
+		throttle, clock := newTestThrottle(1, nil)
+		throttle.Wait("User")
+		clock.current = clock.current.Add(10 * time.Second)
+		throttle.Wait("User")
+		throttle.Wait("User")
+		assert.Equal(t, time.Second, clock.slept)
+	})
+
+	t.Run("Nil throttle never waits", func(t *testing.T) {
+		throttle := NewThrottle(0, nil)
+		assert.Nil(t, throttle)
+		assert.False(t, throttle.Enabled("User"))
+		throttle.Wait("User")
+	})
+}
+
+func TestParseRateOverrides(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		expected map[string]float64
+		wantErr  bool
+	}{
+		{name: "Empty", spec: "", expected: map[string]float64{}},
+		{name: "Multiple entities", spec: "User=10, Group=2.5", expected: map[string]float64{"User": 10, "Group": 2.5}},
+		{name: "Zero disables", spec: "User=0", expected: map[string]float64{"User": 0}},
+		{name: "Missing rate", spec: "User", wantErr: true},
+		{name: "Missing entity", spec: "=5", wantErr: true},
+		{name: "Negative rate", spec: "User=-1", wantErr: true},
+		{name: "Non-numeric rate", spec: "User=fast", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overrides, err := ParseRateOverrides(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, overrides)
+		})
+	}
+}
+
+func TestCSVWriter_Throttled(t *testing.T) {
+	outputDir := t.TempDir()
+	graphInterface, err := model.NewGraph(partialInputDefinition(), 10)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"User": 4, "Group": 4}))
+
+	throttle, clock := newTestThrottle(0, map[string]float64{"User": 4})
+	writer := &CSVWriter{outputDir: outputDir}
+	writer.SetThrottle(throttle)
+	require.NoError(t, writer.WriteFiles(graph))
+
+	assert.Equal(t, 750*time.Millisecond, clock.slept, "only the throttled entity waits between rows")
+	assert.Len(t, readCSV(t, filepath.Join(outputDir, "User.csv")), 5)
+	assert.Len(t, readCSV(t, filepath.Join(outputDir, "Group.csv")), 5)
+}
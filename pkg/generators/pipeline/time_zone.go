@@ -0,0 +1,96 @@
+package pipeline
+
+import (
+	"time"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// applyTimeZones rewrites the RFC3339 values of DateTime attributes with a time
+// zone in that zone, and sets each row's zone in the attributes named as their
+// columns. A row's random-zone attributes all share one zone drawn from
+// parser.TimeZoneNames. Values supplied externally, values that aren't RFC3339
+// timestamps, and values past year 9999 in the zone are kept as they are. It
+// reports whether any entity has such attributes, in which case lookups copying
+// them need resolving again.
+func applyTimeZones(graph *model.Graph, streams *RandomStreams) bool {
+	var locations []*time.Location
+	applied := false
+
+	for _, entity := range graph.GetEntitiesList() {
+		var zoned []model.AttributeInterface
+		random := false
+		for _, attr := range entity.GetAttributes() {
+			if zone := attr.GetTimeZone(); zone != nil {
+				zoned = append(zoned, attr)
+				random = random || zone.Mode == parser.TimeZoneRandom
+			}
+		}
+		if len(zoned) == 0 {
+			continue
+		}
+		applied = true
+
+		var faker *gofakeit.Faker
+		if random {
+			if locations == nil {
+				locations = loadTimeZones()
+			}
+			faker = streams.stream("time_zones:" + entity.GetExternalID())
+		}
+
+		for i := 0; i < entity.GetRowCount(); i++ {
+			row := entity.GetRowByIndex(i)
+			var rowZone *time.Location
+			if random {
+				rowZone = locations[faker.Number(0, len(locations)-1)]
+			}
+
+			for _, attr := range zoned {
+				zone := attr.GetTimeZone()
+				location := zone.Location()
+				if location == nil {
+					location = rowZone
+				}
+
+				name := attr.GetName()
+				if value := row.GetValue(name); value != "" && !row.IsPinned(name) {
+					// RFC3339 has no years past 9999, which the far-future edge case reaches east of UTC
+					if at, err := time.Parse(time.RFC3339, value); err == nil && inZone(at, location).Year() <= 9999 {
+						row.SetValue(name, inZone(at, location).Format(time.RFC3339))
+					}
+				}
+				if zone.Column != "" && !row.IsPinned(zone.Column) {
+					row.SetValue(zone.Column, location.String())
+				}
+			}
+		}
+	}
+	return applied
+}
+
+// inZone returns at in location. Before standard time, zones kept local mean time,
+// whose offsets have seconds RFC3339 can't write, so the offset is rounded to the
+// minute, keeping the written value the same instant.
+func inZone(at time.Time, location *time.Location) time.Time {
+	local := at.In(location)
+	name, offset := local.Zone()
+	if offset%60 == 0 {
+		return local
+	}
+	return at.In(time.FixedZone(name, int((time.Duration(offset) * time.Second).Round(time.Minute).Seconds())))
+}
+
+// loadTimeZones returns the locations of parser.TimeZoneNames, embedded in the
+// binary by the parser
+func loadTimeZones() []*time.Location {
+	locations := make([]*time.Location, 0, len(parser.TimeZoneNames))
+	for _, name := range parser.TimeZoneNames {
+		if location, err := time.LoadLocation(name); err == nil {
+			locations = append(locations, location)
+		}
+	}
+	return locations
+}
@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyTimeZones(t *testing.T) {
+	random := &parser.TimeZone{Mode: parser.TimeZoneRandom, Column: "timeZone"}
+	def := &parser.SORDefinition{
+		DisplayName: "Time Zones",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "createdAt", ExternalId: "createdAt", Type: "DateTime", TimeZone: random},
+					{Name: "lastLogin", ExternalId: "lastLogin", Type: "DateTime", TimeZone: random},
+					{Name: "timeZone", ExternalId: "timeZone", Type: "String"},
+					{Name: "exportedAt", ExternalId: "exportedAt", Type: "DateTime",
+						TimeZone: &parser.TimeZone{Mode: parser.TimeZoneOffset, Offset: "-03:30", Column: "exportZone"}},
+					{Name: "exportZone", ExternalId: "exportZone", Type: "String"},
+					{Name: "syncedAt", ExternalId: "syncedAt", Type: "DateTime", TimeZone: &parser.TimeZone{Mode: parser.TimeZoneUTC}},
+				},
+			},
+		},
+	}
+	graphInterface, err := model.NewGraph(def, 40)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	user, _ := graph.GetEntity("User")
+	require.NoError(t, user.AddRow(model.NewPinnedRow(map[string]string{
+		"id": "given", "createdAt": "2024-03-01T09:30:00+01:00", "timeZone": "Europe/Paris"})))
+
+	generator := NewDataGenerator(t.TempDir(), map[string]int{"User": 40}, false)
+	generator.idGenerator.(*IDGenerator).SetTopUp(map[string]bool{"User": true})
+	require.NoError(t, generator.Generate(graph))
+
+	zones := make(map[string]bool)
+	for i := 0; i < user.GetRowCount(); i++ {
+		row := user.GetRowByIndex(i)
+		if row.GetValue("id") == "given" {
+			assert.Equal(t, "2024-03-01T09:30:00+01:00", row.GetValue("createdAt"), "supplied values are kept")
+			assert.Equal(t, "Europe/Paris", row.GetValue("timeZone"))
+			continue
+		}
+
+		zone := row.GetValue("timeZone")
+		assert.Contains(t, parser.TimeZoneNames, zone)
+		zones[zone] = true
+		location, err := time.LoadLocation(zone)
+		require.NoError(t, err)
+		for _, name := range []string{"createdAt", "lastLogin"} {
+			at, err := time.Parse(time.RFC3339, row.GetValue(name))
+			require.NoError(t, err)
+			_, want := at.In(location).Zone()
+			_, got := at.Zone()
+			assert.InDelta(t, want, got, 30, "%s %s is in %s", name, row.GetValue(name), zone)
+		}
+
+		assert.True(t, strings.HasSuffix(row.GetValue("exportedAt"), "-03:30"), row.GetValue("exportedAt"))
+		assert.Equal(t, "-03:30", row.GetValue("exportZone"))
+		assert.True(t, strings.HasSuffix(row.GetValue("syncedAt"), "Z"), row.GetValue("syncedAt"))
+	}
+	assert.Greater(t, len(zones), 1, "zones are drawn per row")
+
+	t.Run("should round local mean time offsets to the minute", func(t *testing.T) {
+		kolkata, err := time.LoadLocation("Asia/Kolkata")
+		require.NoError(t, err)
+		at := time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+		written := inZone(at, kolkata).Format(time.RFC3339)
+		assert.Equal(t, "1900-01-01T05:21:00+05:21", written)
+		parsed, err := time.Parse(time.RFC3339, written)
+		require.NoError(t, err)
+		assert.True(t, parsed.Equal(at), "the written value is the same instant")
+	})
+}
@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// timelineTailDecay controls how quickly the long tail thins out: the tail's
+// density falls by e^-timelineTailDecay between from and to
+const timelineTailDecay = 3.0
+
+// timelineSampler holds precomputed creation times for an entity's rows, so each
+// cluster receives exactly its share of the rows
+type timelineSampler struct {
+	attribute string
+	layout    string
+	times     []time.Time // Creation time per row index
+	clusters  []int       // Cluster per row index, -1 for the long tail
+	starts    []string    // Start of each cluster, as declared
+}
+
+// timelineRow is a row's creation time and the cluster it was drawn from
+type timelineRow struct {
+	at      time.Time
+	cluster int
+}
+
+// newTimelineSampler draws creation times for every row of the entity's timeline
+// from faker. Returns nil when the entity declares no timeline.
+func newTimelineSampler(entity model.EntityInterface, faker *gofakeit.Faker) (*timelineSampler, error) {
+	timeline := entity.GetTimeline()
+	if timeline == nil {
+		return nil, nil
+	}
+
+	attr, exists := entity.GetAttribute(timeline.Attribute)
+	if !exists {
+		return nil, fmt.Errorf("timeline references unknown attribute '%s'", timeline.Attribute)
+	}
+	layout := time.RFC3339
+	if attr.GetDataType() == "Date" {
+		layout = "2006-01-02"
+	}
+
+	from, err := parser.ParseTimelineTime(timeline.From)
+	if err != nil {
+		return nil, fmt.Errorf("timeline from: %w", err)
+	}
+	to, err := parser.ParseTimelineTime(timeline.To)
+	if err != nil {
+		return nil, fmt.Errorf("timeline to: %w", err)
+	}
+
+	// Each cluster gets its rounded share of the rows; rounding cumulative shares
+	// keeps the total from drifting past the row count
+	rowCount := entity.GetRowCount()
+	rows := make([]timelineRow, 0, rowCount)
+	cumulative := 0.0
+	var starts []string
+	for i, cluster := range timeline.Clusters {
+		at, err := parser.ParseTimelineTime(cluster.At)
+		if err != nil {
+			return nil, fmt.Errorf("timeline cluster at: %w", err)
+		}
+		window := at.AddDate(0, 0, cluster.WindowDays).Sub(at)
+
+		cumulative += cluster.Share
+		end := min(int(math.Round(cumulative*float64(rowCount))), rowCount)
+		for len(rows) < end {
+			rows = append(rows, timelineRow{at: at.Add(time.Duration(faker.Float64Range(0, 1) * float64(window))), cluster: i})
+		}
+		starts = append(starts, cluster.At)
+	}
+
+	// The remaining rows form the long tail, densest at from
+	span := to.Sub(from)
+	for len(rows) < rowCount {
+		u := faker.Float64Range(0, 1)
+		x := -math.Log(1-u*(1-math.Exp(-timelineTailDecay))) / timelineTailDecay
+		rows = append(rows, timelineRow{at: from.Add(time.Duration(x * float64(span))), cluster: -1})
+	}
+
+	// Spread cluster members across the rows rather than front-loading them
+	faker.ShuffleAnySlice(rows)
+
+	sampler := &timelineSampler{attribute: attr.GetName(), layout: layout, starts: starts}
+	for _, row := range rows {
+		sampler.times = append(sampler.times, row.at)
+		sampler.clusters = append(sampler.clusters, row.cluster)
+	}
+	return sampler, nil
+}
+
+// audit records which rows each cluster was assigned, in cluster order
+func (s *timelineSampler) audit(log *AuditLog, entity string) {
+	if s == nil || !log.Enabled() {
+		return
+	}
+	members := make([][]int, len(s.starts))
+	for index, cluster := range s.clusters {
+		if cluster >= 0 {
+			members[cluster] = append(members[cluster], index)
+		}
+	}
+	for cluster, start := range s.starts {
+		log.Record(AuditRecord{
+			Decision:  AuditTimelineCluster,
+			Entity:    entity,
+			Attribute: s.attribute,
+			Value:     start,
+			Count:     len(members[cluster]),
+			Rows:      members[cluster],
+		})
+	}
+}
+
+// value returns the formatted creation time of the row at index, and whether the
+// sampler shapes the named attribute
+func (s *timelineSampler) value(attribute string, index int) (string, bool) {
+	if s == nil || attribute != s.attribute || index >= len(s.times) {
+		return "", false
+	}
+	return s.times[index].UTC().Format(s.layout), true
+}
@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimelineGeneration(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Timeline SOR",
+		Description: "SOR with clustered creation times",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User",
+				ExternalId:  "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "createdAt", ExternalId: "createdAt", Type: "DateTime"},
+					{Name: "joined", ExternalId: "joined", Type: "Date"},
+				},
+				Timeline: &parser.Timeline{
+					Attribute: "createdAt",
+					From:      "2023-01-01",
+					To:        "2024-12-31",
+					Clusters:  []parser.TimelineCluster{{At: "2023-03-01", WindowDays: 30, Share: 0.7}},
+				},
+			},
+		},
+	}
+
+	t.Run("clusters get their share and the tail stays in range", func(t *testing.T) {
+		graph := buildGraphWithIDs(t, def, 200)
+		require.NoError(t, NewFieldGenerator().GenerateFields(graph))
+
+		from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+		windowStart := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+		windowEnd := windowStart.AddDate(0, 0, 30)
+
+		entity, _ := graph.GetEntity("User")
+		inWindow, firstHalf, secondHalf := 0, 0, 0
+		for i := 0; i < entity.GetRowCount(); i++ {
+			created, err := time.Parse(time.RFC3339, entity.GetRowByIndex(i).GetValue("createdAt"))
+			require.NoError(t, err)
+			assert.False(t, created.Before(from) || created.After(to), "createdAt %s outside the timeline", created)
+
+			switch {
+			case !created.Before(windowStart) && created.Before(windowEnd):
+				inWindow++
+			case created.Before(from.Add(to.Sub(from) / 2)):
+				firstHalf++
+			default:
+				secondHalf++
+			}
+		}
+		assert.GreaterOrEqual(t, inWindow, 140, "at least the cluster's 70%% of rows fall in the window")
+		assert.Greater(t, firstHalf, secondHalf, "the tail thins out towards the end of the range")
+	})
+
+	t.Run("other date attributes stay uniform", func(t *testing.T) {
+		graph := buildGraphWithIDs(t, def, 5)
+		require.NoError(t, NewFieldGenerator().GenerateFields(graph))
+
+		entity, _ := graph.GetEntity("User")
+		_, err := time.Parse("2006-01-02", entity.GetRowByIndex(0).GetValue("joined"))
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/SGNL-ai/fabricator/pkg/config"
+)
+
+// ToleranceResult is the outcome of one check measured against its error budget
+type ToleranceResult struct {
+	Check     string
+	Checked   int
+	Failed    int
+	Tolerance config.Tolerance
+	Within    bool
+}
+
+// String describes the result, e.g. "orphanedForeignKeys: 3 of 5000 (0.06%) within tolerance 0.1%"
+func (t ToleranceResult) String() string {
+	rate := 0.0
+	if t.Checked > 0 {
+		rate = 100 * float64(t.Failed) / float64(t.Checked)
+	}
+	verdict := "within"
+	if !t.Within {
+		verdict = "exceeds"
+	}
+	return fmt.Sprintf("%s: %d of %d (%s%%) %s tolerance %s",
+		t.Check, t.Failed, t.Checked, strconv.FormatFloat(rate, 'g', 3, 64), verdict, t.Tolerance)
+}
+
+// ApplyTolerances measures each tolerated check against its budget. Issues of
+// checks within budget become warnings; those of checks over budget stay errors.
+// Results are returned in check name order.
+func (r *ValidationReport) ApplyTolerances(tolerances map[string]config.Tolerance) []ToleranceResult {
+	checks := make([]string, 0, len(tolerances))
+	for check := range tolerances {
+		checks = append(checks, check)
+	}
+	sort.Strings(checks)
+
+	results := make([]ToleranceResult, 0, len(checks))
+	accepted := make(map[string]int)
+	for _, check := range checks {
+		tally := r.Checks[check]
+		if tally == nil {
+			tally = &CheckTally{}
+		}
+		result := ToleranceResult{
+			Check:     check,
+			Checked:   tally.Checked,
+			Failed:    tally.Failed,
+			Tolerance: tolerances[check],
+			Within:    tolerances[check].Allows(tally.Failed, tally.Checked),
+		}
+		results = append(results, result)
+		if result.Within {
+			for _, issue := range tally.issues {
+				accepted[issue]++
+			}
+		}
+	}
+	if len(accepted) == 0 {
+		return results
+	}
+
+	// Move accepted issues to the warnings, keeping the order of both lists
+	errors := make([]string, 0, len(r.Errors))
+	for _, issue := range r.Errors {
+		if accepted[issue] > 0 {
+			accepted[issue]--
+			r.Warnings = append(r.Warnings, issue)
+			continue
+		}
+		errors = append(errors, issue)
+	}
+	r.Errors = errors
+	return results
+}
@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyTolerances(t *testing.T) {
+	// One of four foreign key values dangles
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "User.csv"),
+		[]byte("id,roleId\nuser-1,role-1\nuser-2,role-1\nuser-3,role-999\nuser-4,role-1\nuser-5,\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Role.csv"), []byte("id\nrole-1\n"), 0600))
+
+	processors := map[string]ValidationProcessorInterface{
+		"in memory": NewValidationProcessor(),
+		"streaming": NewStreamingValidationProcessor(),
+	}
+	for name, processor := range processors {
+		t.Run(name, func(t *testing.T) {
+			validate := func(t *testing.T, tolerance string) (*ValidationReport, []ToleranceResult) {
+				t.Helper()
+				report, err := processor.ValidateExistingCSVFilesReport(userRoleDefinition(""), dir)
+				require.NoError(t, err)
+				require.NotEmpty(t, report.Errors)
+
+				path := filepath.Join(t.TempDir(), "tolerances.yaml")
+				require.NoError(t, os.WriteFile(path, []byte("tolerances:\n  orphanedForeignKeys: "+tolerance+"\n"), 0600))
+				cfg, err := config.LoadValidationConfiguration(path)
+				require.NoError(t, err)
+				return report, report.ApplyTolerances(cfg.Tolerances)
+			}
+
+			t.Run("issues within tolerance become warnings", func(t *testing.T) {
+				report, results := validate(t, "25%")
+				assert.Empty(t, report.Errors)
+				assert.NotEmpty(t, report.Warnings)
+				require.Len(t, results, 1)
+				assert.Equal(t, "orphanedForeignKeys: 1 of 4 (25%) within tolerance 25%", results[0].String())
+			})
+
+			t.Run("issues beyond tolerance stay errors", func(t *testing.T) {
+				report, results := validate(t, "0")
+				assert.NotEmpty(t, report.Errors)
+				assert.Empty(t, report.Warnings)
+				require.Len(t, results, 1)
+				assert.False(t, results[0].Within)
+				assert.Equal(t, "orphanedForeignKeys: 1 of 4 (25%) exceeds tolerance 0", results[0].String())
+			})
+		})
+	}
+
+	t.Run("untolerated issues stay errors", func(t *testing.T) {
+		report := &ValidationReport{}
+		report.Errors = append(report.Errors, "entity User: row 2: duplicate value 'user-1' for unique attribute 'id'")
+		report.addCheck(config.ValidationCheckUniqueWithin, RelationshipValidationError, 10, 1, []string{"scoped issue"})
+
+		results := report.ApplyTolerances(map[string]config.Tolerance{
+			config.ValidationCheckUniqueWithin: {Count: 1},
+			config.ValidationCheckForeignKeys:  {Share: 0.001, Percent: true},
+		})
+		assert.Equal(t, []string{"entity User: row 2: duplicate value 'user-1' for unique attribute 'id'"}, report.Errors)
+		assert.Equal(t, []string{"scoped issue"}, report.Warnings)
+		require.Len(t, results, 2)
+		assert.Equal(t, "orphanedForeignKeys: 0 of 0 (0%) within tolerance 0.1%", results[0].String())
+		assert.True(t, results[1].Within)
+	})
+}
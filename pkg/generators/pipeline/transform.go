@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// applyTransforms rewrites the values of attributes with a transform once every
+// row is finished, so each output format writes the same values. Values supplied
+// externally are kept as they are. Foreign keys, lookups and attributes sharing
+// values without a transform of their own follow the values they copied, so they
+// keep matching.
+func applyTransforms(graph *model.Graph) {
+	entities, copied := valueSources(graph)
+
+	// rewritten maps each processed attribute's old values to their new ones
+	rewritten := make(map[model.AttributeInterface]map[string]string)
+	var rewrite func(attr model.AttributeInterface) map[string]string
+	rewrite = func(attr model.AttributeInterface) map[string]string {
+		if changes, done := rewritten[attr]; done {
+			return changes
+		}
+		rewritten[attr] = nil // A cycle of copies rewrites nothing
+
+		transform := attr.GetTransform()
+		var changes map[string]string
+		switch {
+		case transform != nil:
+			changes = make(map[string]string)
+		case copied[attr] != nil:
+			changes = rewrite(copied[attr])
+		}
+		if len(changes) == 0 && transform == nil {
+			return nil
+		}
+
+		entity, name := entities[attr], attr.GetName()
+		for i := 0; i < entity.GetRowCount(); i++ {
+			row := entity.GetRowByIndex(i)
+			value := row.GetValue(name)
+			if value == "" {
+				continue
+			}
+			if transform == nil {
+				if changed, exists := changes[value]; exists {
+					row.SetValue(name, changed)
+				}
+				continue
+			}
+			if row.IsPinned(name) {
+				continue
+			}
+			if changed := transformValue(transform, value); changed != value {
+				changes[value] = changed
+				row.SetValue(name, changed)
+			}
+		}
+		rewritten[attr] = changes
+		return changes
+	}
+
+	for _, entity := range graph.GetEntitiesList() {
+		for _, attr := range entity.GetAttributes() {
+			rewrite(attr)
+		}
+	}
+}
+
+// valueSources returns the entity of each attribute, and maps each foreign key,
+// lookup and attribute sharing values to the attribute its values come from
+func valueSources(graph *model.Graph) (map[model.AttributeInterface]model.EntityInterface, map[model.AttributeInterface]model.AttributeInterface) {
+	entities := make(map[model.AttributeInterface]model.EntityInterface)
+	for _, entity := range graph.GetEntitiesList() {
+		for _, attr := range entity.GetAttributes() {
+			entities[attr] = entity
+		}
+	}
+
+	copied := make(map[model.AttributeInterface]model.AttributeInterface)
+	for _, relationship := range graph.GetAllRelationships() {
+		copied[relationship.GetSourceAttribute()] = relationship.GetTargetAttribute()
+	}
+	for attr, entity := range entities {
+		if _, source := graph.SharedValuesSource(attr); source != nil {
+			copied[attr] = source
+		}
+		if relationship := graph.LookupRelationship(entity, attr); relationship != nil {
+			if source, exists := relationship.GetTargetEntity().GetAttribute(attr.GetLookup().Attribute); exists {
+				copied[attr] = source
+			}
+		}
+	}
+	return entities, copied
+}
+
+// transformValue applies a transform to one non-empty value
+func transformValue(transform *parser.Transform, value string) string {
+	if transform.Trim {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return value
+		}
+	}
+	switch transform.Case {
+	case parser.TransformCaseUpper:
+		value = strings.ToUpper(value)
+	case parser.TransformCaseLower:
+		value = strings.ToLower(value)
+	}
+	if pad := transform.PadWidth - utf8.RuneCountInString(value); pad > 0 {
+		digits, sign := strings.CutPrefix(value, "-")
+		value = strings.Repeat("0", pad) + digits
+		if sign {
+			value = "-" + value
+		}
+	}
+	return transform.Prefix + value + transform.Suffix
+}
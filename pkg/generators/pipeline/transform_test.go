@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformValue(t *testing.T) {
+	tests := []struct {
+		transform parser.Transform
+		value     string
+		want      string
+	}{
+		{parser.Transform{Case: parser.TransformCaseUpper}, "us", "US"},
+		{parser.Transform{Case: parser.TransformCaseLower, Trim: true}, "  Alice@Example.COM ", "alice@example.com"},
+		{parser.Transform{PadWidth: 6}, "42", "000042"},
+		{parser.Transform{PadWidth: 4}, "-7", "-007"},
+		{parser.Transform{PadWidth: 2}, "1234", "1234"},
+		{parser.Transform{PadWidth: 5, Prefix: "EMP-", Suffix: "/x"}, "12", "EMP-00012/x"},
+		{parser.Transform{Trim: true, Prefix: "p"}, "   ", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, transformValue(&tt.transform, tt.value), "%+v on %q", tt.transform, tt.value)
+	}
+}
+
+func TestApplyTransforms(t *testing.T) {
+	country := "us"
+	def := &parser.SORDefinition{
+		DisplayName: "Transforms",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "number", ExternalId: "number", Type: "String", Transform: &parser.Transform{PadWidth: 8, Prefix: "E-"}},
+					{Name: "country", ExternalId: "country", Type: "String", Const: &country, Transform: &parser.Transform{Case: parser.TransformCaseUpper}},
+				},
+			},
+			"group": {
+				DisplayName: "Group", ExternalId: "Group",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "ownerId", ExternalId: "ownerId", Type: "String"},
+					{Name: "ownerNumber", ExternalId: "ownerNumber", Type: "String",
+						Lookup: &parser.Lookup{ForeignKey: "ownerId", Attribute: "number"}},
+					{Name: "ownerCountry", ExternalId: "ownerCountry", Type: "String",
+						Lookup: &parser.Lookup{ForeignKey: "ownerId", Attribute: "country"}},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"group_owner": {DisplayName: "Group Owner", Name: "group_owner", FromAttribute: "Group.ownerId", ToAttribute: "User.id"},
+		},
+	}
+	graphInterface, err := model.NewGraph(def, 5)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+	user, _ := graph.GetEntity("User")
+	require.NoError(t, user.AddRow(model.NewPinnedRow(map[string]string{"id": "given", "country": "fr"})))
+
+	generator := NewDataGenerator(t.TempDir(), map[string]int{"User": 5, "Group": 3}, false)
+	generator.idGenerator.(*IDGenerator).SetTopUp(map[string]bool{"User": true})
+	require.NoError(t, generator.Generate(graph))
+
+	numbers := make(map[string]bool)
+	for i := 0; i < user.GetRowCount(); i++ {
+		row := user.GetRowByIndex(i)
+		numbers[row.GetValue("number")] = true
+		if row.GetValue("id") == "given" {
+			assert.Equal(t, "fr", row.GetValue("country"), "supplied values are kept")
+			continue
+		}
+		assert.Regexp(t, "^E-", row.GetValue("number"))
+		assert.Equal(t, "US", row.GetValue("country"))
+	}
+
+	group, _ := graph.GetEntity("Group")
+	for i := 0; i < group.GetRowCount(); i++ {
+		row := group.GetRowByIndex(i)
+		assert.True(t, numbers[row.GetValue("ownerNumber")], "lookup %s follows the transformed values", row.GetValue("ownerNumber"))
+		assert.Contains(t, []string{"US", "fr"}, row.GetValue("ownerCountry"))
+	}
+}
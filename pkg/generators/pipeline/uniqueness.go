@@ -0,0 +1,180 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// maxScopedUniqueAttempts is how many values are generated for a scoped-unique
+// attribute before a numeric suffix is appended to make the value unique; none are
+// once the scope uses every value the attribute's generator can draw
+const maxScopedUniqueAttempts = 100
+
+// scopedIndex records the values an attribute has used within each value of its
+// scope attribute (e.g. the emails used per tenantId)
+type scopedIndex struct {
+	attr    model.AttributeInterface
+	scope   string                     // Name of the scope attribute
+	used    map[string]map[string]bool // Scope value → values used within it
+	domain  int                        // Distinct values the attribute's generator draws; 0 when unlimited
+	widened int                        // Values given a numeric suffix to make them unique
+}
+
+// newScopedIndexes returns an index for each attribute declaring a uniqueness scope
+func newScopedIndexes(attributes []model.AttributeInterface) []*scopedIndex {
+	var indexes []*scopedIndex
+	for _, attr := range attributes {
+		if attr.GetUniqueWithin() == "" {
+			continue
+		}
+		indexes = append(indexes, &scopedIndex{
+			attr:  attr,
+			scope: attr.GetUniqueWithin(),
+			used:  make(map[string]map[string]bool),
+		})
+	}
+	return indexes
+}
+
+// contains reports whether value is already used within the row's scope
+func (s *scopedIndex) contains(row *model.Row, value string) bool {
+	return s.used[row.GetValue(s.scope)][value]
+}
+
+// claim records value as used within the row's scope and returns it; if it is
+// already used, a numeric suffix is appended until it is unique
+func (s *scopedIndex) claim(row *model.Row, value string) string {
+	scopeValue := row.GetValue(s.scope)
+	used := s.used[scopeValue]
+	if used == nil {
+		used = make(map[string]bool)
+		s.used[scopeValue] = used
+	}
+
+	candidate := value
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s-%d", value, n)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// exhausted reports whether the row's scope already uses as many values as the
+// attribute's generator can draw, so drawing more can't find an unused one
+func (s *scopedIndex) exhausted(row *model.Row) bool {
+	return s.domain > 0 && len(s.used[row.GetValue(s.scope)]) >= s.domain
+}
+
+// checkQuota returns an error if more rows share a scope value than the attribute
+// has distinct values. Only scopes set before fields are generated, primary and
+// foreign keys, can be checked up front; others are checked as rows exhaust them.
+func (s *scopedIndex) checkQuota(entity model.EntityInterface) error {
+	scopeAttr, exists := entity.GetAttribute(s.scope)
+	if s.domain == 0 || !exists || !(scopeAttr.IsUnique() || scopeAttr.IsRelationship()) {
+		return nil
+	}
+	rows := make(map[string]int)
+	var crowded string
+	for i := 0; i < entity.GetRowCount(); i++ {
+		value := entity.GetRowByIndex(i).GetValue(s.scope)
+		rows[value]++
+		if rows[value] > rows[crowded] {
+			crowded = value
+		}
+	}
+	if rows[crowded] > s.domain {
+		return fmt.Errorf("attribute '%s' has only %d distinct values, but %d rows share %s '%s'",
+			s.attr.GetName(), s.domain, rows[crowded], s.scope, crowded)
+	}
+	return nil
+}
+
+// valueDomainSize returns how many distinct values generateFieldValue draws for an
+// attribute when few enough to run out within a uniqueness scope (a status, a
+// boolean, a default, a clearly fake phone number), or 0 when effectively unlimited
+func (g *FieldGenerator) valueDomainSize(attr model.AttributeInterface) int {
+	switch {
+	case attr.GetListEncoding() != "":
+		return 0
+	case attr.GetGenerator() != nil:
+		if attr.GetGenerator().Type == parser.GeneratorIPv4 && g.clearlyFakePII {
+			return 3 * 254 // Hosts of the three documentation ranges
+		}
+		return 0
+	}
+
+	switch namePattern(attr.GetName(), g.clearlyFakePII) {
+	case "":
+	case "phone":
+		if g.clearlyFakePII {
+			return 100
+		}
+		return 0
+	case "status":
+		return 3
+	default:
+		return 0
+	}
+	if attr.GetDefault() != nil {
+		return 1
+	}
+
+	switch attr.GetDataType() {
+	case "Integer", "Int64":
+		return maxGeneratedInteger - minGeneratedInteger + 1
+	case "Boolean", "Bool":
+		return 2
+	}
+	return 0
+}
+
+// validateUniquenessScopes reports rows whose value for a scoped-unique attribute
+// repeats within the same scope value, comparing values in the form normalize
+// returns, or as they are when it is nil. Empty values are not checked.
+func validateUniquenessScopes(entity model.EntityInterface, normalize model.ValueNormalizer) []string {
+	var errors []string
+	for _, attr := range entity.GetAttributes() {
+		scope := attr.GetUniqueWithin()
+		if scope == "" {
+			continue
+		}
+
+		scopeAttr, _ := entity.GetAttribute(scope)
+		firstSeen := make(map[[2]string]int) // (scope value, value) → first row index
+		for i := 0; i < entity.GetRowCount(); i++ {
+			row := entity.GetRowByIndex(i)
+			value := row.GetValue(attr.GetName())
+			if value == "" {
+				continue
+			}
+			scopeValue := row.GetValue(scope)
+			key := [2]string{normalizedValue(normalize, scopeAttr, scopeValue), normalizedValue(normalize, attr, value)}
+			if first, exists := firstSeen[key]; exists {
+				errors = append(errors, fmt.Sprintf("entity %s: row %d: %s '%s' is not unique within %s '%s' (first used in row %d)",
+					entity.GetExternalID(), i, attr.GetName(), value, scope, scopeValue, first))
+				continue
+			}
+			firstSeen[key] = i
+		}
+	}
+	return errors
+}
+
+// countScopedValues returns the number of non-empty values of an entity's
+// scoped-unique attributes, the values validateUniquenessScopes checks
+func countScopedValues(entity model.EntityInterface) int {
+	count := 0
+	for _, attr := range entity.GetAttributes() {
+		if attr.GetUniqueWithin() == "" {
+			continue
+		}
+		for i := 0; i < entity.GetRowCount(); i++ {
+			if entity.GetRowByIndex(i).GetValue(attr.GetName()) != "" {
+				count++
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,155 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scopedUniquenessGraph(t *testing.T) *model.Graph {
+	def := &parser.SORDefinition{
+		DisplayName: "Multi-tenant",
+		Description: "SOR with per-tenant unique attributes",
+		Entities: map[string]parser.Entity{
+			"tenant": {
+				DisplayName: "Tenant",
+				ExternalId:  "Tenant",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				},
+			},
+			"account": {
+				DisplayName: "Account",
+				ExternalId:  "Account",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "tenantId", ExternalId: "tenantId", Type: "String"},
+					// Only three possible values, so most rows collide within a tenant
+					{Name: "status", ExternalId: "status", Type: "String", UniqueWithin: "tenantId"},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"account_tenant": {Name: "account_tenant", FromAttribute: "Account.tenantId", ToAttribute: "Tenant.id"},
+		},
+	}
+
+	graphInterface, err := model.NewGraph(def, 20)
+	require.NoError(t, err)
+	return graphInterface.(*model.Graph)
+}
+
+func TestScopedUniquenessGeneration(t *testing.T) {
+	graph := scopedUniquenessGraph(t)
+
+	require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"Tenant": 2, "Account": 20}))
+	require.NoError(t, NewRelationshipLinker().LinkRelationships(graph, false))
+	require.NoError(t, NewFieldGenerator().GenerateFields(graph))
+
+	account, _ := graph.GetEntity("Account")
+	seen := make(map[[2]string]bool)
+	for i := 0; i < account.GetRowCount(); i++ {
+		row := account.GetRowByIndex(i)
+		key := [2]string{row.GetValue("tenantId"), row.GetValue("status")}
+		assert.False(t, seen[key], "status %q repeats within tenant %q", key[1], key[0])
+		seen[key] = true
+	}
+	assert.Empty(t, validateUniquenessScopes(account, nil))
+}
+
+func TestValidateUniquenessScopes(t *testing.T) {
+	graph := scopedUniquenessGraph(t)
+	account, _ := graph.GetEntity("Account")
+
+	for i, values := range [][2]string{{"t1", "active"}, {"t2", "active"}, {"t1", "pending"}, {"t1", "active"}, {"t1", ""}, {"t1", ""}} {
+		require.NoError(t, account.AddRow(model.NewRow(map[string]string{
+			"id": string(rune('a' + i)), "tenantId": values[0], "status": values[1],
+		})))
+	}
+
+	assert.Equal(t, []string{
+		"entity Account: row 3: status 'active' is not unique within tenantId 't1' (first used in row 0)",
+	}, validateUniquenessScopes(account, nil))
+}
+
+func TestScopedUniquenessQuota(t *testing.T) {
+	generate := func(t *testing.T, tenants int, strict bool) (*model.Graph, error) {
+		t.Helper()
+		graph := scopedUniquenessGraph(t)
+		require.NoError(t, NewIDGenerator().GenerateIDs(graph, map[string]int{"Tenant": tenants, "Account": 20}))
+		require.NoError(t, NewRelationshipLinker().LinkRelationships(graph, false))
+		generator := NewFieldGenerator().(*FieldGenerator)
+		generator.SetStrictUniqueness(strict)
+		return graph, generator.GenerateFields(graph)
+	}
+
+	t.Run("values are widened once a scope uses every status", func(t *testing.T) {
+		graph, err := generate(t, 2, false)
+		require.NoError(t, err)
+		account, _ := graph.GetEntity("Account")
+		perTenant := make(map[string]int)
+		for i := 0; i < account.GetRowCount(); i++ {
+			row := account.GetRowByIndex(i)
+			perTenant[row.GetValue("tenantId")]++
+			if perTenant[row.GetValue("tenantId")] <= 3 {
+				assert.Contains(t, []string{"active", "inactive", "pending"}, row.GetValue("status"))
+			}
+		}
+		assert.Empty(t, validateUniquenessScopes(account, nil))
+	})
+
+	t.Run("strict uniqueness fails before generating values", func(t *testing.T) {
+		_, err := generate(t, 2, true)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to generate fields for entity Account: attribute 'status' has only 3 distinct values, but 10 rows share tenantId")
+	})
+
+	t.Run("strict uniqueness passes within the quota", func(t *testing.T) {
+		_, err := generate(t, 20, true)
+		assert.NoError(t, err)
+	})
+}
+
+func TestValueDomainSize(t *testing.T) {
+	text := "n/a"
+	tests := []struct {
+		name     string
+		attr     parser.Attribute
+		fakePII  bool
+		expected int
+	}{
+		{"status", parser.Attribute{Name: "status", Type: "String"}, false, 3},
+		{"boolean", parser.Attribute{Name: "enabled", Type: "Boolean"}, false, 2},
+		{"integer", parser.Attribute{Name: "level", Type: "Integer"}, false, maxGeneratedInteger - minGeneratedInteger + 1},
+		{"default", parser.Attribute{Name: "code", Type: "String", Default: &text}, false, 1},
+		{"fake phone", parser.Attribute{Name: "phone", Type: "String"}, true, 100},
+		{"real phone", parser.Attribute{Name: "phone", Type: "String"}, false, 0},
+		{"fake IPv4", parser.Attribute{Name: "address", Type: "String", Generator: &parser.Generator{Type: parser.GeneratorIPv4}}, true, 762},
+		{"email", parser.Attribute{Name: "email", Type: "String"}, false, 0},
+		{"word", parser.Attribute{Name: "code", Type: "String"}, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.attr.ExternalId = tt.attr.Name
+			def := &parser.SORDefinition{
+				DisplayName: "Domains",
+				Entities: map[string]parser.Entity{
+					"user": {DisplayName: "User", ExternalId: "User", Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true}, tt.attr,
+					}},
+				},
+			}
+			graph, err := model.NewGraph(def, 1)
+			require.NoError(t, err)
+			user, _ := graph.GetEntity("User")
+			attr, _ := user.GetAttribute(tt.attr.Name)
+
+			generator := NewFieldGenerator().(*FieldGenerator)
+			generator.SetClearlyFakePII(tt.fakePII)
+			assert.Equal(t, tt.expected, generator.valueDomainSize(attr))
+		})
+	}
+}
@@ -28,76 +28,128 @@ func (v *Validator) ValidateRelationships(graph *model.Graph) []string {
 
 	// Validate graph structure - ensure relationships are properly defined
 	for _, relationship := range graph.GetAllRelationships() {
-		// Check that source and target entities exist
-		if relationship.GetSourceEntity() == nil {
-			errors = append(errors, fmt.Sprintf("relationship %s has nil source entity", relationship.GetID()))
-			continue
-		}
+		errors = append(errors, validateRelationship(relationship)...)
+	}
 
-		if relationship.GetTargetEntity() == nil {
-			errors = append(errors, fmt.Sprintf("relationship %s has nil target entity", relationship.GetID()))
-			continue
-		}
+	return errors
+}
 
-		// Check that source and target attributes exist
-		if relationship.GetSourceAttribute() == nil {
-			errors = append(errors, fmt.Sprintf("relationship %s has nil source attribute", relationship.GetID()))
-			continue
-		}
+// validateRelationship checks a single relationship's structure and, for
+// verification mode, that every foreign key value exists in the target entity
+func validateRelationship(relationship model.RelationshipInterface) []string {
+	check := checkRelationship(relationship, nil)
+	return append(check.orphans, check.issues...)
+}
 
-		if relationship.GetTargetAttribute() == nil {
-			errors = append(errors, fmt.Sprintf("relationship %s has nil target attribute", relationship.GetID()))
-			continue
+// relationshipCheck is the outcome of checking one relationship
+type relationshipCheck struct {
+	issues  []string // Structural problems and unreferenced inverse values
+	orphans []string // Foreign key values missing from the target entity
+	checked int      // Non-empty foreign key values checked
+}
+
+// checkRelationship checks a relationship, keeping orphaned foreign keys apart
+// from other issues so an error budget can cover them. Values are compared in the
+// form normalize returns, or as they are when it is nil.
+func checkRelationship(relationship model.RelationshipInterface, normalize model.ValueNormalizer) relationshipCheck {
+	var check relationshipCheck
+
+	// Check that source and target entities exist
+	if relationship.GetSourceEntity() == nil {
+		check.issues = append(check.issues, fmt.Sprintf("relationship %s has nil source entity", relationship.GetID()))
+		return check
+	}
+
+	if relationship.GetTargetEntity() == nil {
+		check.issues = append(check.issues, fmt.Sprintf("relationship %s has nil target entity", relationship.GetID()))
+		return check
+	}
+
+	// Check that source and target attributes exist
+	if relationship.GetSourceAttribute() == nil {
+		check.issues = append(check.issues, fmt.Sprintf("relationship %s has nil source attribute", relationship.GetID()))
+		return check
+	}
+
+	if relationship.GetTargetAttribute() == nil {
+		check.issues = append(check.issues, fmt.Sprintf("relationship %s has nil target attribute", relationship.GetID()))
+		return check
+	}
+
+	// For verification mode: validate cross-entity referential integrity
+	// Check that all foreign key values actually exist in target entity
+	sourceEntity := relationship.GetSourceEntity()
+	targetEntity := relationship.GetTargetEntity()
+	sourceAttr := relationship.GetSourceAttribute()
+	targetAttr := relationship.GetTargetAttribute()
+
+	// Get all target values for quick lookup
+	targetValues := make(map[string]bool)
+	targetCSV := targetEntity.ToCSV()
+	targetColIndex := -1
+	for i, header := range targetCSV.Headers {
+		if header == targetAttr.GetName() {
+			targetColIndex = i
+			break
 		}
+	}
 
-		// For verification mode: validate cross-entity referential integrity
-		// Check that all foreign key values actually exist in target entity
-		sourceEntity := relationship.GetSourceEntity()
-		targetEntity := relationship.GetTargetEntity()
-		sourceAttr := relationship.GetSourceAttribute()
-		targetAttr := relationship.GetTargetAttribute()
-
-		// Get all target values for quick lookup
-		targetValues := make(map[string]bool)
-		targetCSV := targetEntity.ToCSV()
-		targetColIndex := -1
-		for i, header := range targetCSV.Headers {
-			if header == targetAttr.GetName() {
-				targetColIndex = i
-				break
+	// A where leaves out the target rows not matching it
+	where := whereColumns(relationship.GetWhere(), targetCSV.Headers)
+	if targetColIndex >= 0 {
+		for _, row := range targetCSV.Rows {
+			if targetColIndex < len(row) && matchesColumns(row, where) {
+				targetValues[normalizedValue(normalize, targetAttr, row[targetColIndex])] = true
 			}
 		}
+	}
+	var matching string
+	if len(where) > 0 {
+		matching = " where " + describeWhere(relationship.GetWhere())
+	}
+
+	// Check source foreign key values
+	sourceCSV := sourceEntity.ToCSV()
+	sourceColIndex := -1
+	for i, header := range sourceCSV.Headers {
+		if header == sourceAttr.GetName() {
+			sourceColIndex = i
+			break
+		}
+	}
 
-		if targetColIndex >= 0 {
-			for _, row := range targetCSV.Rows {
-				if targetColIndex < len(row) {
-					targetValues[row[targetColIndex]] = true
+	if sourceColIndex >= 0 {
+		for rowIdx, row := range sourceCSV.Rows {
+			if sourceColIndex < len(row) {
+				fkValue := row[sourceColIndex]
+				if fkValue == "" {
+					continue
+				}
+				check.checked++
+				if !targetValues[normalizedValue(normalize, sourceAttr, fkValue)] {
+					check.orphans = append(check.orphans, fmt.Sprintf("relationship %s: foreign key '%s' in %s (row %d) does not exist in %s.%s%s",
+						relationship.GetID(), fkValue, sourceEntity.GetExternalID(), rowIdx, targetEntity.GetExternalID(), targetAttr.GetName(), matching))
 				}
 			}
 		}
+	}
 
-		// Check source foreign key values
-		sourceCSV := sourceEntity.ToCSV()
-		sourceColIndex := -1
-		for i, header := range sourceCSV.Headers {
-			if header == sourceAttr.GetName() {
-				sourceColIndex = i
-				break
+	// The inverse relationship treats the target as referencing the source, so every
+	// target value must be used by at least one source row
+	if inverseID := relationship.GetInverseID(); inverseID != "" && sourceColIndex >= 0 && targetColIndex >= 0 {
+		referenced := make(map[string]bool, len(sourceCSV.Rows))
+		for _, row := range sourceCSV.Rows {
+			if sourceColIndex < len(row) {
+				referenced[normalizedValue(normalize, sourceAttr, row[sourceColIndex])] = true
 			}
 		}
-
-		if sourceColIndex >= 0 {
-			for rowIdx, row := range sourceCSV.Rows {
-				if sourceColIndex < len(row) {
-					fkValue := row[sourceColIndex]
-					if fkValue != "" && !targetValues[fkValue] {
-						errors = append(errors, fmt.Sprintf("relationship %s: foreign key '%s' in %s (row %d) does not exist in %s.%s",
-							relationship.GetID(), fkValue, sourceEntity.GetExternalID(), rowIdx, targetEntity.GetExternalID(), targetAttr.GetName()))
-					}
-				}
+		for rowIdx, row := range targetCSV.Rows {
+			if targetColIndex < len(row) && row[targetColIndex] != "" && !referenced[normalizedValue(normalize, targetAttr, row[targetColIndex])] {
+				check.issues = append(check.issues, fmt.Sprintf("relationship %s: inverse relationship %s requires '%s' in %s (row %d) to be referenced by %s.%s",
+					relationship.GetID(), inverseID, row[targetColIndex], targetEntity.GetExternalID(), rowIdx, sourceEntity.GetExternalID(), sourceAttr.GetName()))
 			}
 		}
 	}
 
-	return errors
+	return check
 }
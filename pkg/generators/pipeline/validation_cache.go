@@ -0,0 +1,280 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// validationCacheVersion changes when cached results can no longer be replayed
+const validationCacheVersion = 3
+
+// ValidationCache keeps the results of validate-only checks between runs. Each
+// check's result is keyed by a hash of the SOR definition and the checksums of the
+// files it read, so a re-run only checks the files that changed and the
+// relationships touching them, replaying the rest.
+type ValidationCache struct {
+	Version int                     `json:"version"`
+	Results map[string]*cachedCheck `json:"results"` // Check (e.g. "relationship member") → its result
+
+	path   string
+	used   map[string]bool // Checks of the last validation; the others are dropped on Save
+	hits   int
+	misses int
+}
+
+// cachedCheck is the outcome of one check, as the report entries it added
+type cachedCheck struct {
+	Key     string        `json:"key"` // Definition hash and checksums of the files read
+	Entries []reportEntry `json:"entries,omitempty"`
+}
+
+// reportEntry is one call to ValidationReport.add or addCheck
+type reportEntry struct {
+	Check   string                 `json:"check,omitempty"` // Tallied check; empty for add
+	Level   RelationshipValidation `json:"level"`
+	Checked int                    `json:"checked,omitempty"`
+	Failed  int                    `json:"failed,omitempty"`
+	Issues  []string               `json:"issues,omitempty"`
+}
+
+// LoadValidationCache reads the cache at path; a missing file is an empty cache,
+// and so is one written by another version of the cache
+func LoadValidationCache(path string) (*ValidationCache, error) {
+	cache := &ValidationCache{Version: validationCacheVersion, Results: make(map[string]*cachedCheck), path: path}
+	content, err := os.ReadFile(path) // #nosec G304 - path is from CLI argument, user-controlled
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read validation cache: %w", err)
+	}
+
+	var stored ValidationCache
+	if err := json.Unmarshal(content, &stored); err != nil {
+		return nil, fmt.Errorf("invalid validation cache %s: %w", path, err)
+	}
+	if stored.Version == validationCacheVersion && stored.Results != nil {
+		cache.Results = stored.Results
+	}
+	return cache, nil
+}
+
+// Save writes the cache back to the file it was loaded from, keeping only the
+// checks of the last validation
+func (c *ValidationCache) Save() error {
+	if c.used != nil {
+		for check := range c.Results {
+			if !c.used[check] {
+				delete(c.Results, check)
+			}
+		}
+	}
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode validation cache: %w", err)
+	}
+	if err := os.WriteFile(c.path, append(content, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write validation cache: %w", err)
+	}
+	return nil
+}
+
+// Stats returns how many checks the last validation replayed from the cache and
+// how many it ran
+func (c *ValidationCache) Stats() (hits, misses int) {
+	return c.hits, c.misses
+}
+
+// lookup returns the cached result of a check if its key hasn't changed
+func (c *ValidationCache) lookup(check, key string) (*cachedCheck, bool) {
+	cached, exists := c.Results[check]
+	if !exists || cached.Key != key {
+		return nil, false
+	}
+	return cached, true
+}
+
+// replay adds a cached check's entries to the report
+func (c *cachedCheck) replay(report *ValidationReport) {
+	for _, entry := range c.Entries {
+		if entry.Check == "" {
+			report.add(entry.Level, entry.Issues)
+			continue
+		}
+		report.addCheck(entry.Check, entry.Level, entry.Checked, entry.Failed, entry.Issues)
+	}
+}
+
+// checkRecorder adds a check's entries to the report, keeping them for the cache
+type checkRecorder struct {
+	report *ValidationReport
+	result cachedCheck
+}
+
+func (r *checkRecorder) add(level RelationshipValidation, issues []string) {
+	r.report.add(level, issues)
+	r.result.Entries = append(r.result.Entries, reportEntry{Level: level, Issues: issues})
+}
+
+func (r *checkRecorder) addCheck(check string, level RelationshipValidation, checked, failed int, issues []string) {
+	r.report.addCheck(check, level, checked, failed, issues)
+	r.result.Entries = append(r.result.Entries, reportEntry{Check: check, Level: level, Checked: checked, Failed: failed, Issues: issues})
+}
+
+// validationChecks runs a validation's checks, replaying those whose files haven't
+// changed since the cache recorded them. Without a cache every check runs.
+type validationChecks struct {
+	cache      *ValidationCache
+	report     *ValidationReport
+	definition string            // Hash of the SOR definition
+	checksums  map[string]string // Entity ID → checksum of its files
+}
+
+// newValidationChecks hashes the definition and every entity's files for cache keys.
+// Strict coercion reports issues the default doesn't, so it is hashed with the
+// definition.
+func newValidationChecks(cache *ValidationCache, report *ValidationReport, def *parser.SORDefinition,
+	strictCoercion bool, entities []model.EntityInterface, directory string, layout FileLayout) (*validationChecks, error) {
+	checks := &validationChecks{cache: cache, report: report}
+	if cache == nil {
+		return checks, nil
+	}
+	cache.hits, cache.misses, cache.used = 0, 0, make(map[string]bool)
+
+	encoded, err := json.Marshal(struct {
+		Definition     *parser.SORDefinition `json:"definition"`
+		StrictCoercion bool                  `json:"strictCoercion"`
+	}{def, strictCoercion})
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash SOR definition: %w", err)
+	}
+	sum := sha256.Sum256(encoded)
+	checks.definition = hex.EncodeToString(sum[:])
+
+	checks.checksums = make(map[string]string, len(entities))
+	for _, entity := range entities {
+		checksum, err := entityChecksum(directory, entity, layout)
+		if err != nil {
+			return nil, err
+		}
+		checks.checksums[entity.GetID()] = checksum
+	}
+	return checks, nil
+}
+
+// entityChecksum hashes the names and contents of an entity's CSV files
+func entityChecksum(directory string, entity model.EntityInterface, layout FileLayout) (string, error) {
+	hash := sha256.New()
+	for _, csvPath := range layout.entityDataFiles(directory, entity, ".csv") {
+		file, err := os.Open(csvPath) // #nosec G304 - csvPath is from trusted source
+		if err != nil {
+			return "", fmt.Errorf("failed to checksum %s: %w", csvPath, err)
+		}
+		name, _ := filepath.Rel(directory, csvPath)
+		_, _ = fmt.Fprintf(hash, "%s\x00", name)
+		_, err = io.Copy(hash, file)
+		_ = file.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to checksum %s: %w", csvPath, err)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// key returns the cache key of a check reading the given entities' files
+func (v *validationChecks) key(entityIDs ...string) string {
+	key := v.definition
+	for _, id := range entityIDs {
+		key += ":" + v.checksums[id]
+	}
+	return key
+}
+
+// stale reports whether a check reading the given entities' files has to run
+func (v *validationChecks) stale(check string, entityIDs ...string) bool {
+	if v.cache == nil {
+		return true
+	}
+	_, cached := v.cache.lookup(check, v.key(entityIDs...))
+	return !cached
+}
+
+// run replays a check from the cache, or runs it and caches its entries
+func (v *validationChecks) run(check string, entityIDs []string, fn func(r *checkRecorder)) {
+	recorder := &checkRecorder{report: v.report}
+	if v.cache == nil {
+		fn(recorder)
+		return
+	}
+
+	key := v.key(entityIDs...)
+	v.cache.used[check] = true
+	if cached, exists := v.cache.lookup(check, key); exists {
+		cached.replay(v.report)
+		v.cache.hits++
+		return
+	}
+	fn(recorder)
+	recorder.result.Key = key
+	v.cache.Results[check] = &recorder.result
+	v.cache.misses++
+}
+
+// needsRows reports whether a check reading an entity's rows has to run, so its
+// files must be loaded
+func (v *validationChecks) needsRows(graph *model.Graph, entity model.EntityInterface) bool {
+	id := entity.GetID()
+	if v.stale(loadCheckName(entity), id) || v.stale(uniquenessCheckName(entity), id) {
+		return true
+	}
+	for _, other := range graph.GetEntitiesList() {
+		for _, attr := range other.GetRelationshipAttributes() {
+			if (other.GetID() == id || attr.GetRelatedEntityID() == id) &&
+				v.stale(foreignKeyCheckName(other, attr), other.GetID(), attr.GetRelatedEntityID()) {
+				return true
+			}
+		}
+	}
+	for _, relationship := range graph.GetRelationshipsForEntity(id) {
+		if v.stale(relationshipCheckName(relationship), relationshipEntities(relationship)...) {
+			return true
+		}
+	}
+	return false
+}
+
+// Names of the cached checks
+func structureCheckName(entity model.EntityInterface) string { return "structure " + entity.GetID() }
+func loadCheckName(entity model.EntityInterface) string      { return "load " + entity.GetID() }
+func uniquenessCheckName(entity model.EntityInterface) string {
+	return "unique within " + entity.GetID()
+}
+
+func foreignKeyCheckName(entity model.EntityInterface, attr model.AttributeInterface) string {
+	return "foreign keys " + entity.GetID() + "." + attr.GetName()
+}
+
+func relationshipCheckName(relationship model.RelationshipInterface) string {
+	return "relationship " + relationship.GetID()
+}
+
+// relationshipEntities returns the IDs of the entities a relationship check reads
+func relationshipEntities(relationship model.RelationshipInterface) []string {
+	var ids []string
+	for _, entity := range []model.EntityInterface{relationship.GetSourceEntity(), relationship.GetTargetEntity()} {
+		if entity != nil {
+			ids = append(ids, entity.GetID())
+		}
+	}
+	return ids
+}
@@ -0,0 +1,123 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cacheTestDefinition returns groups, users referencing them and applications
+func cacheTestDefinition() *parser.SORDefinition {
+	return &parser.SORDefinition{
+		DisplayName: "Cache",
+		Entities: map[string]parser.Entity{
+			"group": {DisplayName: "Group", ExternalId: "Group", Attributes: []parser.Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+			}},
+			"user": {DisplayName: "User", ExternalId: "User", Attributes: []parser.Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "groupId", ExternalId: "groupId", Type: "String"},
+			}},
+			"application": {DisplayName: "Application", ExternalId: "Application", Attributes: []parser.Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+			}},
+		},
+		Relationships: map[string]parser.Relationship{
+			"user_group": {DisplayName: "User Group", Name: "user_group", FromAttribute: "User.groupId", ToAttribute: "Group.id"},
+		},
+	}
+}
+
+func TestValidationCache(t *testing.T) {
+	def := cacheTestDefinition()
+	dir := t.TempDir()
+	write := func(t *testing.T, name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	write(t, "Group.csv", "id\ng1\ng2\n")
+	write(t, "User.csv", "id,groupId\nu1,g1\nu2,g3\n")
+	write(t, "Application.csv", "id\na1\na1\n")
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+
+	validate := func(t *testing.T) (*ValidationReport, *ValidationCache) {
+		cache, err := LoadValidationCache(cachePath)
+		require.NoError(t, err)
+		processor := NewValidationProcessor().(*ValidationProcessor)
+		processor.SetCache(cache)
+		report, err := processor.ValidateExistingCSVFilesReport(def, dir)
+		require.NoError(t, err)
+		require.NoError(t, cache.Save())
+		return report, cache
+	}
+	uncached, err := NewValidationProcessor().ValidateExistingCSVFilesReport(def, dir)
+	require.NoError(t, err)
+	require.NotEmpty(t, uncached.Errors)
+
+	first, cache := validate(t)
+	hits, misses := cache.Stats()
+	assert.Equal(t, 0, hits)
+	assert.Equal(t, 11, misses)
+	assert.Equal(t, uncached, first, "an empty cache checks everything")
+
+	second, cache := validate(t)
+	hits, misses = cache.Stats()
+	assert.Equal(t, 0, misses, "unchanged files are not checked again")
+	assert.Equal(t, first, second, "cached results are replayed")
+	assert.Len(t, second.Checks, len(first.Checks))
+
+	// Fixing the orphaned foreign key re-checks the users and the relationship
+	// touching them, but not the groups' own checks or the applications
+	write(t, "User.csv", "id,groupId\nu1,g1\nu2,g2\n")
+	third, cache := validate(t)
+	hits, misses = cache.Stats()
+	assert.Equal(t, 6, hits, "checks of the groups and applications are replayed")
+	assert.Equal(t, 5, misses, "the users' structure, load, foreign key and uniqueness checks and the relationship run")
+	fresh, err := NewValidationProcessor().ValidateExistingCSVFilesReport(def, dir)
+	require.NoError(t, err)
+	assert.Equal(t, fresh, third)
+	assert.Len(t, third.Errors, 1, "the duplicate application id is still reported")
+}
+
+// entityLoaderStub is a CSV loader of another package's kind: it loads single
+// entities but has none of CSVLoader's unexported methods
+type entityLoaderStub struct {
+	loader *CSVLoader
+	loaded []string
+}
+
+func (s *entityLoaderStub) LoadCSVFiles(graph *model.Graph, directory string) []string {
+	return s.loader.LoadCSVFiles(graph, directory)
+}
+
+func (s *entityLoaderStub) LoadEntityCSVFiles(directory string, entities []model.EntityInterface) []string {
+	for _, entity := range entities {
+		s.loaded = append(s.loaded, entity.GetExternalID())
+	}
+	return s.loader.LoadEntityCSVFiles(directory, entities)
+}
+
+func TestValidationCache_CustomEntityLoader(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Group.csv"), []byte("id\ng1\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "User.csv"), []byte("id,groupId\nu1,g1\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Application.csv"), []byte("id\na1\n"), 0600))
+
+	cache, err := LoadValidationCache(filepath.Join(t.TempDir(), "cache.json"))
+	require.NoError(t, err)
+	stub := &entityLoaderStub{loader: &CSVLoader{}}
+	processor := NewValidationProcessor().(*ValidationProcessor)
+	processor.csvLoader = stub
+	processor.SetCache(cache)
+
+	report, err := processor.ValidateExistingCSVFilesReport(cacheTestDefinition(), dir)
+	require.NoError(t, err)
+	assert.Empty(t, report.Errors)
+	slices.Sort(stub.loaded)
+	assert.Equal(t, []string{"Application", "Group", "User"}, stub.loaded)
+}
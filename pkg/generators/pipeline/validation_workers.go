@@ -0,0 +1,58 @@
+package pipeline
+
+import (
+	"sync"
+	"time"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/fatih/color"
+)
+
+// DefaultValidationWorkers is the number of entity files validated at the same time
+const DefaultValidationWorkers = 1
+
+// forEachEntity calls fn for each entity on up to workers goroutines, passing the
+// entity's position so results can be collected in order. With one worker the
+// entities are visited in order on the calling goroutine. fn may only change state
+// belonging to its own entity: its rows, its indexes and its slot of the results.
+//
+// With several workers, each finished entity is reported as a progress line naming
+// the worker, the phase and the time the entity took.
+func forEachEntity(entities []model.EntityInterface, workers int, phase string, fn func(i int, entity model.EntityInterface)) {
+	if workers <= 1 {
+		for i, entity := range entities {
+			fn(i, entity)
+		}
+		return
+	}
+
+	var (
+		mu       sync.Mutex
+		finished int
+		wg       sync.WaitGroup
+	)
+
+	queue := make(chan int)
+	for worker := 1; worker <= workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := range queue {
+				started := time.Now()
+				fn(i, entities[i])
+
+				mu.Lock()
+				finished++
+				color.Cyan("  Worker %d: %s %s in %s (%d/%d)", worker, phase, entities[i].GetExternalID(),
+					time.Since(started).Round(time.Millisecond), finished, len(entities))
+				mu.Unlock()
+			}
+		}(worker)
+	}
+
+	for i := range entities {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+}
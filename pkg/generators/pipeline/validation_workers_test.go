@@ -0,0 +1,93 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForEachEntity(t *testing.T) {
+	def := &parser.SORDefinition{DisplayName: "Workers", Entities: map[string]parser.Entity{}}
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("Entity%02d", i)
+		def.Entities[name] = parser.Entity{DisplayName: name, ExternalId: name,
+			Attributes: []parser.Attribute{{Name: "id", ExternalId: "id", Type: "String", UniqueId: true}}}
+	}
+	graph, err := model.NewGraph(def, 0)
+	require.NoError(t, err)
+	entities := graph.(*model.Graph).GetEntitiesList()
+
+	for _, workers := range []int{0, 1, 4} {
+		visited := make([]int32, len(entities))
+		forEachEntity(entities, workers, "visited", func(i int, entity model.EntityInterface) {
+			assert.Equal(t, entities[i], entity)
+			atomic.AddInt32(&visited[i], 1)
+		})
+		for i, count := range visited {
+			assert.EqualValues(t, 1, count, "workers %d: entity %d visited once", workers, i)
+		}
+	}
+}
+
+func TestValidationWorkers(t *testing.T) {
+	// Ten entities point at Role; some have dangling keys, duplicates or no file
+	def := &parser.SORDefinition{
+		DisplayName: "Workers",
+		Entities: map[string]parser.Entity{
+			"role": {DisplayName: "Role", ExternalId: "Role",
+				Attributes: []parser.Attribute{{Name: "id", ExternalId: "id", Type: "String", UniqueId: true}}},
+		},
+		Relationships: map[string]parser.Relationship{},
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Role.csv"), []byte("id\nrole-1\nrole-2\n"), 0600))
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("User%d", i)
+		def.Entities[name] = parser.Entity{DisplayName: name, ExternalId: name,
+			Attributes: []parser.Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "roleId", ExternalId: "roleId", Type: "String"},
+			}}
+		def.Relationships[name+"_role"] = parser.Relationship{DisplayName: name + " Role", Name: name + "_role",
+			FromAttribute: name + ".roleId", ToAttribute: "Role.id"}
+
+		content := "id,roleId\nu-1,role-1\nu-2,role-2\n"
+		switch i % 4 {
+		case 1:
+			content += fmt.Sprintf("u-3,role-%d\n", 100+i)
+		case 2:
+			content += "u-1,role-1\n"
+		case 3:
+			continue
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name+".csv"), []byte(content), 0600))
+	}
+
+	processors := map[string]func() ValidationProcessorInterface{
+		"in memory": NewValidationProcessor,
+		"streaming": NewStreamingValidationProcessor,
+	}
+	for name, newProcessor := range processors {
+		t.Run(name, func(t *testing.T) {
+			sequential, err := newProcessor().ValidateExistingCSVFilesReport(def, dir)
+			require.NoError(t, err)
+			require.NotEmpty(t, sequential.Errors)
+
+			processor := newProcessor()
+			processor.(interface{ SetWorkers(int) }).SetWorkers(4)
+			parallel, err := processor.ValidateExistingCSVFilesReport(def, dir)
+			require.NoError(t, err)
+
+			assert.Equal(t, sequential.Errors, parallel.Errors, "issues are reported in the same order")
+			assert.Equal(t, sequential.Warnings, parallel.Warnings)
+			assert.Equal(t, sequential.Checks, parallel.Checks)
+		})
+	}
+}
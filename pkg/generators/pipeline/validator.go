@@ -2,11 +2,14 @@ package pipeline
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
+	"sort"
+	"sync"
 
+	"github.com/SGNL-ai/fabricator/pkg/config"
 	"github.com/SGNL-ai/fabricator/pkg/generators/model"
 	"github.com/SGNL-ai/fabricator/pkg/parser"
 )
@@ -16,20 +19,55 @@ type CSVLoaderInterface interface {
 	LoadCSVFiles(graph *model.Graph, directory string) []string
 }
 
+// PartialCSVLoader is implemented by CSV loaders that can seed a graph's entities
+// with rows from CSV files holding only some of their columns, before generation
+// fills in the rest
+type PartialCSVLoader interface {
+	LoadPartialCSVFiles(graph *model.Graph, directory string) (map[string]int, error)
+}
+
+// KeySource returns a function giving an entity's primary keys one at a time,
+// skipping the keys taken reports as held
+type KeySource func(entity model.EntityInterface, taken func(string) bool) func() string
+
+// EntityCSVLoader is implemented by CSV loaders that can load the files of some of
+// a graph's entities, which validation with a cache uses to load only the entities
+// whose files changed. A loader that also has a CompareCoerced(*model.Graph) method
+// is given the graph first, to compare keys in their attribute type's form.
+type EntityCSVLoader interface {
+	LoadEntityCSVFiles(directory string, entities []model.EntityInterface) []string
+}
+
 // ValidationProcessorInterface defines the interface for validation-only mode
 type ValidationProcessorInterface interface {
 	ValidateExistingCSVFiles(def *parser.SORDefinition, directory string) ([]string, error)
+	ValidateExistingCSVFilesReport(def *parser.SORDefinition, directory string) (*ValidationReport, error)
 }
 
-// CSVLoader handles loading existing CSV files into the model
+// CSVLoader handles loading existing CSV files into the model. Values are kept as
+// they are written, and keys are compared in their attribute type's form (see
+// valueCoercion).
 type CSVLoader struct {
-	// Configuration options can be added here
+	workers        int            // Entity files loaded at the same time; 0 uses DefaultValidationWorkers
+	strictCoercion bool           // Record every value not in its attribute type's form, for Coercions
+	layout         FileLayout     // How the files were named and placed when written
+	coercion       *valueCoercion // Forms values are compared in; set by CompareCoerced
+	keys           KeySource      // Fills in partial input rows' missing keys; nil uses a random IDGenerator's
+	files          *InputFiles    // Opens the files, decrypting them; nil reads them in the clear
+
+	mu        sync.Mutex
+	coercions map[string][]string // Entity ID → values coerced while loading its files
 }
 
 // ValidationProcessor handles validation-only mode workflows
 type ValidationProcessor struct {
-	csvLoader CSVLoaderInterface
-	validator ValidatorInterface
+	csvLoader      CSVLoaderInterface
+	workers        int              // Entity files checked at the same time; 0 uses DefaultValidationWorkers
+	cache          *ValidationCache // Results of earlier runs to replay for unchanged files; nil checks everything
+	strictCoercion bool             // Report every value coerced to its attribute's type as an error
+	layout         FileLayout       // How the files were named and placed when written
+	noInterning    bool             // Keep a copy of every loaded value instead of sharing repeated ones
+	files          *InputFiles      // Opens the files, decrypting them; nil reads them in the clear
 }
 
 // NewCSVLoader creates a new CSV loader
@@ -41,44 +79,279 @@ func NewCSVLoader() CSVLoaderInterface {
 func NewValidationProcessor() ValidationProcessorInterface {
 	return &ValidationProcessor{
 		csvLoader: NewCSVLoader(),
-		validator: NewValidation(),
 	}
 }
 
+// SetWorkers configures how many entity files are checked and loaded at the same
+// time. Relationships between entities are checked once all files are loaded.
+func (p *ValidationProcessor) SetWorkers(workers int) {
+	p.workers = workers
+	if loader, ok := p.csvLoader.(interface{ SetWorkers(int) }); ok {
+		loader.SetWorkers(workers)
+	}
+}
+
+// SetStrictCoercion makes validation report every value it coerces to its
+// attribute's type as an error, for files that must already be written the way
+// fabricator writes them
+func (p *ValidationProcessor) SetStrictCoercion(strict bool) {
+	p.strictCoercion = strict
+	if loader, ok := p.csvLoader.(interface{ SetStrictCoercion(bool) }); ok {
+		loader.SetStrictCoercion(strict)
+	}
+}
+
+// coercions returns the values the loader found not in their attribute type's form
+// in an entity's files, for loaders that record them
+func (p *ValidationProcessor) coercions(entityID string) []string {
+	if loader, ok := p.csvLoader.(interface{ Coercions(string) []string }); ok {
+		return loader.Coercions(entityID)
+	}
+	return nil
+}
+
+// SetFileLayout configures how the files were named and placed when they were
+// written; the default layout is used otherwise
+func (p *ValidationProcessor) SetFileLayout(layout FileLayout) {
+	p.layout = layout
+	if loader, ok := p.csvLoader.(interface{ SetFileLayout(FileLayout) }); ok {
+		loader.SetFileLayout(layout)
+	}
+}
+
+// SetInputFiles configures how the files are opened, e.g. decrypted; they are read
+// in the clear otherwise
+func (p *ValidationProcessor) SetInputFiles(files *InputFiles) {
+	p.files = files
+	if loader, ok := p.csvLoader.(interface{ SetInputFiles(*InputFiles) }); ok {
+		loader.SetInputFiles(files)
+	}
+}
+
+// SetValueInterning enables or disables sharing repeated values between the rows
+// loaded; it is on by default
+func (p *ValidationProcessor) SetValueInterning(enabled bool) {
+	p.noInterning = !enabled
+}
+
+// SetCache makes validation replay the checks of files unchanged since the cache
+// recorded them, and record the checks it runs
+func (p *ValidationProcessor) SetCache(cache *ValidationCache) {
+	p.cache = cache
+}
+
 // ValidateExistingCSVFiles validates existing CSV files without generating new data
-// Returns all validation issues found - does not stop on first error
+// Returns all validation errors found - does not stop on first error.
+// Issues from relationships marked "warn" are dropped; use ValidateExistingCSVFilesReport to get them.
 func (p *ValidationProcessor) ValidateExistingCSVFiles(def *parser.SORDefinition, directory string) ([]string, error) {
-	var allErrors []string
+	report, err := p.ValidateExistingCSVFilesReport(def, directory)
+	if err != nil {
+		return nil, err
+	}
+	return report.Errors, nil
+}
+
+// ValidateExistingCSVFilesReport validates existing CSV files, applying each
+// relationship's validation level: issues of "warn" relationships are reported as
+// warnings and "skip" relationships are not checked
+func (p *ValidationProcessor) ValidateExistingCSVFilesReport(def *parser.SORDefinition, directory string) (*ValidationReport, error) {
+	report := &ValidationReport{}
+
+	levels, err := relationshipValidationLevels(def)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create graph from definition
 	graphInterface, err := model.NewGraph(def, 0)
 	if err != nil {
-		return []string{fmt.Sprintf("failed to create graph: %v", err)}, nil
+		report.Errors = append(report.Errors, fmt.Sprintf("failed to create graph: %v", err))
+		return report, nil
 	}
 
 	graph, ok := graphInterface.(*model.Graph)
 	if !ok {
-		return []string{"failed to convert graph to concrete type"}, nil
+		report.Errors = append(report.Errors, "failed to convert graph to concrete type")
+		return report, nil
 	}
+	foldInverseLevels(def, graph, levels)
+	graph.SetValueInterning(!p.noInterning)
+	coercion := newValueCoercion(graph)
 
-	// Load existing CSV files into the graph (collect all loading errors)
-	loadErrors := p.csvLoader.LoadCSVFiles(graph, directory)
-	allErrors = append(allErrors, loadErrors...)
+	// Check CSV structure first so corruption isn't reported as relationship errors
+	entities := graph.GetEntitiesList()
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].GetExternalID() < entities[j].GetExternalID()
+	})
+	cache := p.cache
+	if _, err := os.Stat(directory); err != nil {
+		cache = nil // LoadCSVFiles reports the missing directory
+	}
+	checks, err := newValidationChecks(cache, report, def, p.strictCoercion, entities, directory, p.layout)
+	if err != nil {
+		return nil, err
+	}
+
+	structureErrors := make([][]string, len(entities))
+	forEachEntity(entities, p.workers, "checked structure of", func(i int, entity model.EntityInterface) {
+		if !checks.stale(structureCheckName(entity), entity.GetID()) {
+			return
+		}
+		// Missing files are reported by LoadCSVFiles
+		for _, csvPath := range p.layout.entityDataFiles(directory, entity, p.files.FileName(".csv")) {
+			structureIssues, err := p.files.lint(csvPath)
+			if err != nil {
+				structureErrors[i] = append(structureErrors[i], err.Error())
+				continue
+			}
+			for _, issue := range structureIssues {
+				structureErrors[i] = append(structureErrors[i], "CSV structure: "+issue)
+			}
+		}
+	})
+	for i, entity := range entities {
+		checks.run(structureCheckName(entity), []string{entity.GetID()}, func(r *checkRecorder) {
+			r.add(RelationshipValidationError, structureErrors[i])
+		})
+	}
+
+	// Load existing CSV files into the graph (collect all loading errors). With a
+	// cache, only the files of entities with a check to run are loaded.
+	if checks.cache == nil {
+		report.Errors = append(report.Errors, p.csvLoader.LoadCSVFiles(graph, directory)...)
+		for _, entity := range entities {
+			report.Errors = append(report.Errors, p.coercions(entity.GetID())...)
+		}
+	} else {
+		var stale []model.EntityInterface
+		for _, entity := range entities {
+			if checks.needsRows(graph, entity) {
+				stale = append(stale, entity)
+			}
+		}
+		loadErrors := make(map[string]string, len(stale))
+		for i, loadError := range p.loadEntityCSVFiles(graph, directory, stale) {
+			loadErrors[stale[i].GetID()] = loadError
+		}
+		for _, entity := range entities {
+			checks.run(loadCheckName(entity), []string{entity.GetID()}, func(r *checkRecorder) {
+				if loadError := loadErrors[entity.GetID()]; loadError != "" {
+					r.add(RelationshipValidationError, []string{loadError})
+				}
+				if coercions := p.coercions(entity.GetID()); len(coercions) > 0 {
+					r.add(RelationshipValidationError, coercions)
+				}
+			})
+		}
+	}
 
 	// Continue validation even if some files failed to load
-	// Validate FK relationships using post-generation validation
-	for _, entity := range graph.GetAllEntities() {
-		fkErrors := entity.ValidateAllForeignKeys()
-		for _, errMsg := range fkErrors {
-			allErrors = append(allErrors, fmt.Sprintf("entity %s: %s", entity.GetExternalID(), errMsg))
+	// Validate FK values per attribute so each follows its relationship's level
+	for _, entity := range entities {
+		for _, attr := range entity.GetRelationshipAttributes() {
+			level := foreignKeyValidationLevel(graph, levels, entity, attr.GetName())
+			if level == RelationshipValidationSkip {
+				continue
+			}
+			checks.run(foreignKeyCheckName(entity, attr), []string{entity.GetID(), attr.GetRelatedEntityID()}, func(r *checkRecorder) {
+				var fkErrors []string
+				for _, errMsg := range entity.ValidateForeignKeys(attr.GetName()) {
+					fkErrors = append(fkErrors, fmt.Sprintf("entity %s: %s", entity.GetExternalID(), errMsg))
+				}
+				// The relationship checks below count these values; this only lists them
+				r.addCheck(config.ValidationCheckForeignKeys, level, 0, 0, fkErrors)
+			})
 		}
 	}
 
+	// Validate values that must be unique within a scope (e.g. email per tenant)
+	for _, entity := range entities {
+		checks.run(uniquenessCheckName(entity), []string{entity.GetID()}, func(r *checkRecorder) {
+			issues := validateUniquenessScopes(entity, coercion.normalize)
+			r.addCheck(config.ValidationCheckUniqueWithin, RelationshipValidationError,
+				countScopedValues(entity), len(issues), issues)
+		})
+	}
+
 	// Validate graph-level relationships
-	relationshipErrors := p.validator.ValidateRelationships(graph)
-	allErrors = append(allErrors, relationshipErrors...)
+	for _, relationship := range graph.GetAllRelationships() {
+		level := levels[relationship.GetID()]
+		if level == RelationshipValidationSkip {
+			continue
+		}
+		checks.run(relationshipCheckName(relationship), relationshipEntities(relationship), func(r *checkRecorder) {
+			check := checkRelationship(relationship, coercion.normalize)
+			r.addCheck(config.ValidationCheckForeignKeys, level, check.checked, len(check.orphans), check.orphans)
+			r.add(level, check.issues)
+		})
+	}
+
+	return report, nil
+}
+
+// loadEntityCSVFiles loads the files of the given entities into the graph,
+// returning each entity's loading error, or "" when its files loaded
+func (p *ValidationProcessor) loadEntityCSVFiles(graph *model.Graph, directory string, entities []model.EntityInterface) []string {
+	loader, ok := p.csvLoader.(EntityCSVLoader)
+	if !ok {
+		entityErrors := make([]string, len(entities))
+		for i, entity := range entities {
+			entityErrors[i] = fmt.Sprintf("failed to load CSV for entity %s: the CSV loader can't load single entities", entity.GetID())
+		}
+		return entityErrors
+	}
+	if comparer, ok := loader.(interface{ CompareCoerced(*model.Graph) }); ok {
+		comparer.CompareCoerced(graph)
+	}
+	return loader.LoadEntityCSVFiles(directory, entities)
+}
+
+// SetWorkers configures how many entity files LoadCSVFiles loads at the same time
+func (l *CSVLoader) SetWorkers(workers int) {
+	l.workers = workers
+}
+
+// SetFileLayout configures how the files were named and placed when they were
+// written; the default layout is used otherwise
+func (l *CSVLoader) SetFileLayout(layout FileLayout) {
+	l.layout = layout
+}
+
+// SetInputFiles configures how the files are opened, e.g. decrypted; they are read
+// in the clear otherwise
+func (l *CSVLoader) SetInputFiles(files *InputFiles) {
+	l.files = files
+}
 
-	return allErrors, nil
+// SetStrictCoercion makes the loader record every value not written in its
+// attribute type's form
+func (l *CSVLoader) SetStrictCoercion(strict bool) {
+	l.strictCoercion = strict
+}
+
+// CompareCoerced makes the graph's entities compare keys in their attribute type's
+// form, for the files loaded afterwards
+func (l *CSVLoader) CompareCoerced(graph *model.Graph) {
+	l.coercion = newValueCoercion(graph)
+	graph.SetValueNormalizer(l.coercion.normalize)
+}
+
+// Coercions returns the values not written in their attribute type's form in an
+// entity's files, one issue per value; it's empty unless strict coercion is set
+func (l *CSVLoader) Coercions(entityID string) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.coercions[entityID]
+}
+
+// recordCoercion keeps a value not in its type's form for Coercions; entities load in parallel
+func (l *CSVLoader) recordCoercion(entity model.EntityInterface, issue string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.coercions == nil {
+		l.coercions = make(map[string][]string)
+	}
+	l.coercions[entity.GetID()] = append(l.coercions[entity.GetID()], issue)
 }
 
 // LoadCSVFiles loads existing CSV files into the graph entities
@@ -92,31 +365,49 @@ func (l *CSVLoader) LoadCSVFiles(graph *model.Graph, directory string) []string
 		return errors
 	}
 
-	// Load CSV file for each entity
-	for entityID, entity := range graph.GetAllEntities() {
-		// Determine CSV filename from entity external ID
-		filename := l.getCSVFilename(entity.GetExternalID())
-		csvPath := filepath.Join(directory, filename)
+	l.CompareCoerced(graph)
+	for _, entityError := range l.LoadEntityCSVFiles(directory, graph.GetEntitiesList()) {
+		if entityError != "" {
+			errors = append(errors, entityError)
+		}
+	}
 
-		// Check if CSV file exists
-		if _, err := os.Stat(csvPath); os.IsNotExist(err) {
-			errors = append(errors, fmt.Sprintf("CSV file not found for entity %s: %s", entityID, csvPath))
-			continue
+	return errors
+}
+
+// LoadEntityCSVFiles loads the CSV files of the given entities, returning each
+// entity's loading error, or "" when its files loaded
+func (l *CSVLoader) LoadEntityCSVFiles(directory string, entities []model.EntityInterface) []string {
+	// Load CSV file for each entity; each worker fills only its entity's rows and
+	// primary key index
+	entityErrors := make([]string, len(entities))
+	forEachEntity(entities, l.workers, "loaded", func(i int, entity model.EntityInterface) {
+		// Find the entity's CSV file, or the files of its partitions
+		extension := l.files.FileName(".csv")
+		csvPaths := l.layout.entityDataFiles(directory, entity, extension)
+		if len(csvPaths) == 0 {
+			expected := filepath.Join(directory, l.layout.entityFilePath(entity, extension))
+			if attr, _ := partitionAttribute(entity); attr != nil {
+				expected = filepath.Join(directory, l.layout.entityFilePath(entity, ""), attr.GetExternalID()+"=*"+extension)
+			}
+			entityErrors[i] = fmt.Sprintf("CSV file not found for entity %s: %s", entity.GetID(), expected)
+			return
 		}
 
 		// Load CSV data into entity
-		if err := l.loadEntityCSV(entity, csvPath); err != nil {
-			errors = append(errors, fmt.Sprintf("failed to load CSV for entity %s: %v", entityID, err))
-			continue
+		for _, csvPath := range csvPaths {
+			if err := l.loadEntityCSV(entity, csvPath); err != nil {
+				entityErrors[i] = fmt.Sprintf("failed to load CSV for entity %s: %v", entity.GetID(), err)
+				return
+			}
 		}
-	}
-
-	return errors
+	})
+	return entityErrors
 }
 
 // loadEntityCSV loads a single CSV file into an entity
 func (l *CSVLoader) loadEntityCSV(entity model.EntityInterface, csvPath string) error {
-	file, err := os.Open(csvPath) // #nosec G304 - csvPath is from trusted source
+	file, err := l.files.Open(csvPath)
 	if err != nil {
 		return fmt.Errorf("failed to open CSV file %s: %w", csvPath, err)
 	}
@@ -136,34 +427,152 @@ func (l *CSVLoader) loadEntityCSV(entity model.EntityInterface, csvPath string)
 	headers := records[0]
 	dataRows := records[1:]
 
-	// Load each data row into the entity
+	// Load the data rows into the entity in one batch, up to a row with the wrong
+	// number of columns
+	var attributes []model.AttributeInterface
+	var normalized []string
+	if l.strictCoercion && l.coercion != nil {
+		attributes = columnAttributes(entity, headers)
+	}
+	rows := make([]*model.Row, 0, len(dataRows))
+	var columnsErr error
 	for i, row := range dataRows {
 		if len(row) != len(headers) {
-			return fmt.Errorf("CSV file %s row %d has %d columns, expected %d", csvPath, i+1, len(row), len(headers))
+			columnsErr = fmt.Errorf("CSV file %s row %d has %d columns, expected %d", csvPath, i+1, len(row), len(headers))
+			break
+		}
+
+		if attributes != nil {
+			normalized = l.coercion.normalizeRecord(attributes, row, normalized, func(column int, to string) {
+				l.recordCoercion(entity, coercionIssue(entity.GetExternalID(), i+1, headers[column], row[column], to))
+			})
 		}
 
 		// Create row data map
-		rowData := make(map[string]string)
+		rowData := make(map[string]string, len(headers))
 		for j, value := range row {
 			rowData[headers[j]] = value
 		}
+		rows = append(rows, model.NewRow(rowData))
+	}
 
-		// Add row to entity (AddRow validation will catch duplicates, etc.)
-		if err := entity.AddRow(model.NewRow(rowData)); err != nil {
-			return fmt.Errorf("validation failed for CSV file %s row %d: %w", csvPath, i+1, err)
+	// Add rows to entity (AddRows validation will catch duplicates, etc.)
+	if err := entity.AddRows(rows); err != nil {
+		var rowErr *model.RowError
+		if errors.As(err, &rowErr) {
+			return fmt.Errorf("validation failed for CSV file %s row %d: %w", csvPath, rowErr.Index+1, rowErr.Err)
 		}
+		return fmt.Errorf("validation failed for CSV file %s: %w", csvPath, err)
 	}
 
-	return nil
+	return columnsErr
+}
+
+// LoadPartialCSVFiles seeds entities with rows from CSV files that contain only
+// a subset of the entity's columns. Provided values are pinned so later pipeline
+// stages preserve them and only fill in the missing columns. Entities without a
+// CSV file in the directory are left empty and generated as usual.
+// Returns the number of rows loaded per entity external ID.
+func (l *CSVLoader) LoadPartialCSVFiles(graph *model.Graph, directory string) (map[string]int, error) {
+	if info, err := os.Stat(directory); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("partial input directory %s does not exist", directory)
+	}
+
+	loaded := make(map[string]int)
+	for _, entity := range graph.GetAllEntities() {
+		csvPath := filepath.Join(directory, l.getCSVFilename(entity))
+		if _, err := os.Stat(csvPath); os.IsNotExist(err) {
+			continue
+		}
+
+		count, err := l.loadPartialEntityCSV(entity, csvPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load partial CSV for entity %s: %w", entity.GetExternalID(), err)
+		}
+		loaded[entity.GetExternalID()] = count
+	}
+
+	return loaded, nil
+}
+
+// loadPartialEntityCSV loads a partial CSV file into an entity, mapping headers
+// (attribute external IDs or names) to attributes and generating missing primary keys
+func (l *CSVLoader) loadPartialEntityCSV(entity model.EntityInterface, csvPath string) (int, error) {
+	file, err := os.Open(csvPath) // #nosec G304 - csvPath is built from the user-supplied input directory
+	if err != nil {
+		return 0, fmt.Errorf("failed to open CSV file %s: %w", csvPath, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read CSV file %s: %w", csvPath, err)
+	}
+
+	if len(records) == 0 {
+		return 0, fmt.Errorf("CSV file %s is empty", csvPath)
+	}
+
+	// Resolve each header column to an attribute name
+	headers := records[0]
+	attrNames := make([]string, len(headers))
+	for i, header := range headers {
+		attr, exists := entity.GetAttributeByExternalID(header)
+		if !exists {
+			attr, exists = entity.GetAttribute(header)
+		}
+		if !exists {
+			return 0, fmt.Errorf("column '%s' in %s does not match any attribute of entity %s",
+				header, csvPath, entity.GetExternalID())
+		}
+		attrNames[i] = attr.GetName()
+	}
+
+	// Keys filled in skip the ones the file provides, even in later rows
+	pkName := entity.GetPrimaryKey().GetName()
+	provided := make(map[string]bool)
+	for i, record := range records[1:] {
+		if len(record) != len(headers) {
+			return 0, fmt.Errorf("CSV file %s row %d has %d columns, expected %d", csvPath, i+1, len(record), len(headers))
+		}
+		for j, value := range record {
+			if attrNames[j] == pkName && value != "" {
+				provided[value] = true
+			}
+		}
+	}
+	keys := l.keys
+	if keys == nil {
+		keys = (&IDGenerator{}).KeySource
+	}
+	nextKey := keys(entity, func(id string) bool {
+		return provided[id] || entity.CheckKeyExists(id)
+	})
+
+	for i, record := range records[1:] {
+
+		rowData := make(map[string]string, len(entity.GetAttributes()))
+		for j, value := range record {
+			rowData[attrNames[j]] = value
+		}
+
+		row := model.NewPinnedRow(rowData)
+
+		// Rows without a provided key still need one to participate in relationships
+		if row.GetValue(pkName) == "" {
+			row.SetValue(pkName, nextKey())
+		}
+
+		if err := entity.AddRow(row); err != nil {
+			return 0, fmt.Errorf("invalid row %d in %s: %w", i+1, csvPath, err)
+		}
+	}
+
+	return len(records) - 1, nil
 }
 
 // getCSVFilename determines the CSV filename from entity external ID
-func (l *CSVLoader) getCSVFilename(externalID string) string {
-	// Handle namespace format (e.g., "Sample/EntityName" -> "EntityName.csv")
-	if strings.Contains(externalID, "/") {
-		parts := strings.Split(externalID, "/")
-		return parts[len(parts)-1] + ".csv"
-	}
-	// Simple format (e.g., "EntityName" -> "EntityName.csv")
-	return externalID + ".csv"
+// (e.g., "Sample/EntityName" -> "EntityName.csv")
+func (l *CSVLoader) getCSVFilename(entity model.EntityInterface) string {
+	return l.layout.entityFilePath(entity, ".csv")
 }
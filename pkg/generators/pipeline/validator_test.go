@@ -3,6 +3,7 @@ package pipeline
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/SGNL-ai/fabricator/pkg/parser"
@@ -226,7 +227,8 @@ user-2,Jane,Smith,Extra` // Too many columns in row 2
 		// Should collect malformed CSV errors, not fail fatally
 		assert.NoError(t, err, "Should not fail fatally - should collect errors")
 		assert.NotEmpty(t, errors, "Should collect malformed CSV errors")
-		assert.Contains(t, errors[0], "wrong number of fields", "Error should mention field count mismatch")
+		assert.Contains(t, errors[0], "User.csv:3: row has 4 columns, header has 2", "Structural issue should be reported first with its line")
+		assert.Contains(t, strings.Join(errors, "\n"), "wrong number of fields", "Loader error should still be reported")
 	})
 
 	t.Run("should handle namespaced entity external IDs", func(t *testing.T) {
@@ -0,0 +1,239 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// Sources of an attribute's values, in the order field generation checks them
+const (
+	ValueSourceKey         = "key"         // Primary key from the ID generator
+	ValueSourceForeignKey  = "foreign key" // Key of a referenced row
+	ValueSourceLookup      = "lookup"      // Copied from the row a foreign key references
+	ValueSourceDerived     = "derived"     // Computed from other entities' rows
+	ValueSourceInlineData  = "inline data" // Embedded in the SOR
+	ValueSourceConst       = "const"
+	ValueSourceGenerator   = "generator" // Generator hint, including sequence and hierarchicalCode
+	ValueSourceTimeline    = "timeline"
+	ValueSourceEffective   = "effective dating" // Validity windows of a record's versions
+	ValueSourceCorrelation = "correlation"
+	ValueSourceProfile     = "profile"
+	ValueSourceList        = "list"
+	ValueSourceAddress     = "address"  // Part of a coherent address drawn per row
+	ValueSourceName        = "name"     // Inferred from a pattern in the attribute name
+	ValueSourceDefault     = "default"  // The attribute's default
+	ValueSourceType        = "type"     // Generic value of the data type
+	ValueSourceFallback    = "fallback" // Random words, for strings and unknown types
+)
+
+// ValueSource describes where the values of one attribute come from
+type ValueSource struct {
+	Entity      string // External ID of the entity
+	Attribute   string // Attribute name
+	Type        string // Declared data type
+	Source      string // One of the ValueSource constants
+	Detail      string // What the values look like
+	PersonField string // Field taken from a shared population instead, when one is set
+	Mismatch    bool   // The name pattern's values don't suit the declared type, e.g. statuses in a DateTime
+}
+
+// ValueSources reports, for every attribute in entity and attribute order, which
+// generator or heuristic produces its values in a run without a population, theme or
+// configured ID formats and with strict address coherence, so mis-detected columns
+// can be fixed before generating
+func ValueSources(graph *model.Graph) []ValueSource {
+	var sources []ValueSource
+	for _, entity := range graph.GetEntitiesList() {
+		inline := make(map[string]bool)
+		for _, row := range entity.GetInlineData() {
+			for key := range row {
+				if attr, exists := entity.GetAttribute(key); exists {
+					inline[attr.GetName()] = true
+				} else if attr, exists := entity.GetAttributeByExternalID(key); exists {
+					inline[attr.GetName()] = true
+				}
+			}
+		}
+		var regular []model.AttributeInterface
+		for _, attr := range entity.GetNonRelationshipAttributes() {
+			if !attr.IsUnique() && attr.GetLookup() == nil {
+				regular = append(regular, attr)
+			}
+		}
+		people := personFields(regular)
+		addresses := addressFields(regular)
+
+		for _, attr := range entity.GetAttributes() {
+			source := ValueSource{
+				Entity:      entity.GetExternalID(),
+				Attribute:   attr.GetName(),
+				Type:        attr.GetDataType(),
+				PersonField: people[attr.GetName()],
+			}
+			source.Source, source.Detail = valueSource(graph, entity, attr, inline[attr.GetName()])
+			generated := source.Source == ValueSourceName || source.Source == ValueSourceDefault ||
+				source.Source == ValueSourceType || source.Source == ValueSourceFallback
+			if part, exists := addresses[attr.GetName()]; exists && generated {
+				source.Source, source.Detail = ValueSourceAddress, part+" of a coherent address"
+			}
+			source.Mismatch = source.Source == ValueSourceName && !namePatternSuits(attr)
+			if !generated {
+				source.PersonField = ""
+			}
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// valueSource returns the source of an attribute's values and what they look like,
+// following the precedence of GenerateFields and generateFieldValue
+func valueSource(graph *model.Graph, entity model.EntityInterface, attr model.AttributeInterface, inline bool) (string, string) {
+	name := attr.GetName()
+	if derived := entity.GetDerived(); derived != nil {
+		if name == derived.Key {
+			return ValueSourceDerived, "distinct values of " + derived.From
+		}
+		for _, aggregate := range derived.Aggregates {
+			if aggregate.Attribute == name {
+				return ValueSourceDerived, aggregate.Function + " over " + aggregate.Over
+			}
+		}
+	}
+	if inline {
+		return ValueSourceInlineData, "values embedded in the SOR"
+	}
+
+	switch {
+	case attr.IsRelationship():
+		if relationship := graph.ForeignKeyRelationship(entity, name); relationship != nil {
+			return ValueSourceForeignKey, "references " + relationship.GetTargetEntity().GetExternalID() + "." +
+				relationship.GetTargetAttribute().GetName()
+		}
+		return ValueSourceForeignKey, "references " + attr.GetRelatedEntityID() + "." + attr.GetRelatedAttribute()
+	case attr.IsUnique():
+		if sequenceGenerator(attr) != nil || hierarchicalCodeGenerator(attr) != nil {
+			return ValueSourceGenerator, attr.GetGenerator().Type
+		}
+		return ValueSourceKey, idFormat(attr, nil) + " IDs"
+	case attr.GetLookup() != nil:
+		lookup := attr.GetLookup()
+		if relationship := graph.LookupRelationship(entity, attr); relationship != nil {
+			return ValueSourceLookup, relationship.GetTargetEntity().GetExternalID() + "." + lookup.Attribute + " via " + lookup.ForeignKey
+		}
+		return ValueSourceLookup, lookup.Attribute + " via " + lookup.ForeignKey
+	case attr.GetConst() != nil:
+		return ValueSourceConst, fmt.Sprintf("always '%s'", *attr.GetConst())
+	case sequenceGenerator(attr) != nil, hierarchicalCodeGenerator(attr) != nil:
+		return ValueSourceGenerator, attr.GetGenerator().Type
+	}
+	if timeline := entity.GetTimeline(); timeline != nil && timeline.Attribute == name {
+		return ValueSourceTimeline, fmt.Sprintf("%d clusters between %s and %s", len(timeline.Clusters), timeline.From, timeline.To)
+	}
+	if dating := entity.GetEffectiveDating(); dating != nil {
+		switch name {
+		case dating.ValidFrom, dating.ValidTo:
+			return ValueSourceEffective, fmt.Sprintf("windows of each %s's versions between %s and %s", dating.Key, dating.From, dating.To)
+		case dating.Current:
+			return ValueSourceEffective, fmt.Sprintf("true for each %s's latest version", dating.Key)
+		}
+	}
+	for _, correlation := range entity.GetCorrelations() {
+		for i, correlated := range correlation.Attributes {
+			if correlated == name {
+				return ValueSourceCorrelation, fmt.Sprintf("%s with %s", numericDetail(attr.GetDataType()), correlation.Attributes[1-i])
+			}
+		}
+	}
+	if profile := entity.GetProfile(); profile != nil {
+		for key := range profile.Columns {
+			if key == name || key == attr.GetExternalID() {
+				return ValueSourceProfile, "profiled column " + key
+			}
+		}
+	}
+	if encoding := attr.GetListEncoding(); encoding != "" {
+		_, detail := fieldValueSource(attr)
+		return ValueSourceList, encoding + " lists of " + detail
+	}
+	return fieldValueSource(attr)
+}
+
+// fieldValueSource returns the source of the values generateFieldValue draws for an
+// attribute and what they look like
+func fieldValueSource(attr model.AttributeInterface) (string, string) {
+	if generator := attr.GetGenerator(); generator != nil {
+		switch {
+		case generator.Locale != "":
+			return ValueSourceGenerator, generator.Type + " (" + generator.Locale + ")"
+		case generator.Type == parser.GeneratorCIDR:
+			return ValueSourceGenerator, fmt.Sprintf("cidr (/%d blocks of %s)", generator.PrefixLength, generator.Network)
+		case generator.Subnet != "":
+			return ValueSourceGenerator, generator.Type + " (within " + generator.Subnet + ")"
+		case generator.From != "":
+			return ValueSourceGenerator, generator.Type + " (from " + generator.From + ")"
+		case generator.Vendor != "":
+			return ValueSourceGenerator, generator.Type + " (" + generator.Vendor + ")"
+		}
+		return ValueSourceGenerator, generator.Type
+	}
+
+	switch pattern := namePattern(attr.GetName(), false); pattern {
+	case "email":
+		return ValueSourceName, "email addresses (name starts or ends with 'email')"
+	case "phone":
+		return ValueSourceName, "phone numbers (name starts or ends with 'phone')"
+	case "name":
+		return ValueSourceName, "person names (name starts or ends with 'name')"
+	case "address":
+		return ValueSourceName, "street addresses (name starts or ends with 'address')"
+	case "status":
+		return ValueSourceName, "active, inactive or pending (name starts or ends with 'status')"
+	case "date", "time":
+		return ValueSourceName, fmt.Sprintf("RFC 3339 timestamps (name starts or ends with '%s')", pattern)
+	}
+
+	if value := attr.GetDefault(); value != nil {
+		return ValueSourceDefault, fmt.Sprintf("always '%s'", *value)
+	}
+
+	switch dataType := attr.GetDataType(); dataType {
+	case "Integer", "Int64", "Float", "Double":
+		return ValueSourceType, numericDetail(dataType)
+	case "Boolean", "Bool":
+		return ValueSourceType, "true or false"
+	case "Date":
+		return ValueSourceType, "dates"
+	case "DateTime":
+		return ValueSourceType, "RFC 3339 timestamps"
+	case "String":
+		return ValueSourceFallback, "random words"
+	default:
+		return ValueSourceFallback, fmt.Sprintf("random words (unknown type %s)", dataType)
+	}
+}
+
+// namePatternSuits reports whether the values the attribute's name pattern selects
+// are valid for its data type: timestamps for date and time, text for the others
+func namePatternSuits(attr model.AttributeInterface) bool {
+	switch attr.GetDataType() {
+	case "String":
+		return true
+	case "DateTime":
+		pattern := namePattern(attr.GetName(), false)
+		return pattern == "date" || pattern == "time"
+	}
+	return false
+}
+
+// numericDetail describes the generated range of a numeric type
+func numericDetail(dataType string) string {
+	switch dataType {
+	case "Integer", "Int64":
+		return fmt.Sprintf("integers %d-%d", minGeneratedInteger, maxGeneratedInteger)
+	default:
+		return fmt.Sprintf("decimals %.2f-%.2f", minGeneratedFloat, maxGeneratedFloat)
+	}
+}
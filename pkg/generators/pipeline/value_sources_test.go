@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueSources(t *testing.T) {
+	value := "n/a"
+	def := &parser.SORDefinition{
+		DisplayName: "Value Sources SOR",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User",
+				ExternalId:  "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "email", ExternalId: "email", Type: "String"},
+					{Name: "workEmail", ExternalId: "workEmail", Type: "String"},
+					{Name: "statusChanged", ExternalId: "statusChanged", Type: "DateTime"},
+					{Name: "loginCount", ExternalId: "loginCount", Type: "Integer"},
+					{Name: "notes", ExternalId: "notes", Type: "String", Default: &value},
+					{Name: "region", ExternalId: "region", Type: "String", Const: &value},
+					{Name: "ssn", ExternalId: "ssn", Type: "String", Generator: &parser.Generator{Type: parser.GeneratorSSN}},
+					{Name: "tags", ExternalId: "tags", Type: "String", ListEncoding: "json"},
+					{Name: "title", ExternalId: "title", Type: "String"},
+				},
+			},
+			"membership": {
+				DisplayName: "Membership",
+				ExternalId:  "Membership",
+				Attributes: []parser.Attribute{
+					{Name: "number", ExternalId: "number", Type: "Integer", UniqueId: true},
+					{Name: "userId", ExternalId: "userId", Type: "String"},
+					{Name: "userEmail", ExternalId: "userEmail", Type: "String", Lookup: &parser.Lookup{ForeignKey: "userId", Attribute: "email"}},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"member": {DisplayName: "Member", Name: "member", FromAttribute: "Membership.userId", ToAttribute: "User.id"},
+		},
+	}
+	graphInterface, err := model.NewGraph(def, 10)
+	require.NoError(t, err)
+
+	sources := map[string]ValueSource{}
+	for _, source := range ValueSources(graphInterface.(*model.Graph)) {
+		sources[source.Entity+"."+source.Attribute] = source
+	}
+	tests := []struct {
+		attribute string
+		source    string
+		detail    string
+	}{
+		{"User.id", ValueSourceKey, "uuid IDs"},
+		{"User.email", ValueSourceName, "email addresses (name starts or ends with 'email')"},
+		{"User.workEmail", ValueSourceFallback, "random words"},
+		{"User.statusChanged", ValueSourceName, "active, inactive or pending (name starts or ends with 'status')"},
+		{"User.loginCount", ValueSourceType, "integers 1-1000"},
+		{"User.notes", ValueSourceDefault, "always 'n/a'"},
+		{"User.region", ValueSourceConst, "always 'n/a'"},
+		{"User.ssn", ValueSourceGenerator, "ssn"},
+		{"User.tags", ValueSourceList, "json lists of random words"},
+		{"Membership.number", ValueSourceKey, "sequence IDs"},
+		{"Membership.userId", ValueSourceForeignKey, "references User.id"},
+		{"Membership.userEmail", ValueSourceLookup, "User.email via userId"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.attribute, func(t *testing.T) {
+			source, exists := sources[tt.attribute]
+			require.True(t, exists)
+			assert.Equal(t, tt.source, source.Source)
+			assert.Equal(t, tt.detail, source.Detail)
+		})
+	}
+
+	assert.True(t, sources["User.statusChanged"].Mismatch, "statuses are not valid DateTimes")
+	assert.False(t, sources["User.email"].Mismatch)
+	assert.Equal(t, "email", sources["User.email"].PersonField, "a population would fill it")
+	assert.Empty(t, sources["User.region"].PersonField)
+}
@@ -0,0 +1,270 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/SGNL-ai/fabricator/pkg/console"
+	"github.com/fatih/color"
+)
+
+// writeAttempts is how many times creating a data file, or one write to it, is
+// tried before a transient error fails the run
+const writeAttempts = 3
+
+// writeRetryDelay is the wait before the second attempt, doubled for each later one
+var writeRetryDelay = 50 * time.Millisecond
+
+// WriteFaults injects transient errors into data file writes at configured rates,
+// so retries and the marking of partial output can be exercised. Each attempt
+// fails independently, so a retried attempt usually succeeds.
+type WriteFaults struct {
+	DiskFull         float64 // Chance of each write failing with ENOSPC
+	PermissionDenied float64 // Chance of each file creation failing with EACCES
+	Seed             int64   // Seeds which attempts fail; 0 uses a random seed
+
+	injected atomic.Int64 // Faults injected with these settings
+}
+
+// Injected returns how many faults were injected into the writes of the output
+// files configured with these settings
+func (w *WriteFaults) Injected() int {
+	return int(w.injected.Load())
+}
+
+// ParseWriteFaults parses comma-separated <fault>=<rate> pairs such as
+// "enospc=0.01,eacces=0.005", with an optional seed=<n>
+func ParseWriteFaults(spec string) (*WriteFaults, error) {
+	faults := &WriteFaults{}
+	for _, pair := range strings.Split(spec, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid write fault '%s' (expected <fault>=<rate>)", pair)
+		}
+		if name == "seed" {
+			seed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid write fault seed '%s'", value)
+			}
+			faults.Seed = seed
+			continue
+		}
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("invalid rate '%s' for write fault %s (expected 0 to 1)", value, name)
+		}
+		switch name {
+		case "enospc":
+			faults.DiskFull = rate
+		case "eacces":
+			faults.PermissionDenied = rate
+		default:
+			return nil, fmt.Errorf("unknown write fault '%s' (expected enospc or eacces)", name)
+		}
+	}
+	return faults, nil
+}
+
+// SetWriteFaults configures the faults injected into every data file written; nil
+// disables injection
+func (f *OutputFiles) SetWriteFaults(faults *WriteFaults) {
+	f.faults = nil
+	if faults == nil {
+		return
+	}
+	seed := faults.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	f.faults = &faultInjector{faults: faults, random: rand.New(rand.NewSource(seed))} // #nosec G404 - fault injection doesn't need cryptographic randomness
+}
+
+// faultInjector draws which attempts fail; writers on several goroutines share it
+type faultInjector struct {
+	faults *WriteFaults
+	mu     sync.Mutex
+	random *rand.Rand
+}
+
+// inject returns err wrapped as op on path when an attempt with rate fails
+func (f *faultInjector) inject(rate float64, op, path string, err syscall.Errno) error {
+	if f == nil || rate == 0 {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.random.Float64() >= rate {
+		return nil
+	}
+	f.faults.injected.Add(1)
+	return &os.PathError{Op: op, Path: path, Err: injectedFault{err}}
+}
+
+// injectedFault is an error a faultInjector made up, which is always retried
+type injectedFault struct {
+	errno syscall.Errno
+}
+
+func (e injectedFault) Error() string { return e.errno.Error() }
+func (e injectedFault) Unwrap() error { return e.errno }
+
+// Rates of the configured faults; a nil injector injects none
+func (f *faultInjector) permissionDenied() float64 {
+	if f == nil {
+		return 0
+	}
+	return f.faults.PermissionDenied
+}
+
+func (f *faultInjector) diskFull() float64 {
+	if f == nil {
+		return 0
+	}
+	return f.faults.DiskFull
+}
+
+// transientWriteError reports whether an error may go away when retried: an
+// injected fault, a full disk that can be freed, or an interrupted or would-block
+// call. A denied permission stays denied, so only injected ones are retried.
+func transientWriteError(err error) bool {
+	var injected injectedFault
+	return errors.As(err, &injected) || errors.Is(err, syscall.ENOSPC) ||
+		errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR)
+}
+
+// retryWrite calls attempt until it succeeds, fails with a permanent error or runs
+// out of attempts
+func retryWrite(path string, attempt func() error) error {
+	delay := writeRetryDelay
+	for i := 1; ; i++ {
+		err := attempt()
+		if err == nil || i == writeAttempts || !transientWriteError(err) {
+			return err
+		}
+		console.ClearLine()
+		color.Yellow(console.Text("⚠️  Retrying write to %s: %v"), filepath.Base(path), err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// open opens a data file for writing, retrying transient errors. The file is
+// recorded as partial until it is closed after every write succeeded.
+func (f *OutputFiles) open(path string, flag int, perm os.FileMode) (*dataFile, error) {
+	path = filepath.Clean(path)
+	faults := f.injector()
+	var file *os.File
+	err := retryWrite(path, func() error {
+		if err := faults.inject(faults.permissionDenied(), "open", path, syscall.EACCES); err != nil {
+			return err
+		}
+		var err error
+		file, err = os.OpenFile(path, flag, perm) // #nosec G304 - path is built from the output directory
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	f.record(path, fileStatePartial)
+	return &dataFile{file: file, path: path, files: f, faults: faults}, nil
+}
+
+// injector returns the fault injector of the files, nil when none is configured
+func (f *OutputFiles) injector() *faultInjector {
+	if f == nil {
+		return nil
+	}
+	return f.faults
+}
+
+// dataFile retries the transient errors of writes to a data file, and records it
+// as written once closed without an error
+type dataFile struct {
+	file   *os.File
+	path   string
+	files  *OutputFiles
+	faults *faultInjector
+	failed bool
+}
+
+func (f *dataFile) Write(p []byte) (int, error) {
+	written := 0
+	err := retryWrite(f.path, func() error {
+		if err := f.faults.inject(f.faults.diskFull(), "write", f.path, syscall.ENOSPC); err != nil {
+			return err
+		}
+		n, err := f.file.Write(p[written:])
+		written += n
+		return err
+	})
+	if err != nil {
+		f.failed = true
+	}
+	return written, err
+}
+
+func (f *dataFile) Close() error {
+	err := f.file.Close()
+	if err == nil && !f.failed {
+		f.files.record(f.path, fileStateWritten)
+	}
+	return err
+}
+
+// States of the data files recorded in an OutputFiles' log
+const (
+	fileStatePartial = "partial"
+	fileStateWritten = "written"
+)
+
+// fileLog records the state of each data file opened, so a failed run's manifest
+// can tell written files from partial ones, and the rows of each edge file, so the
+// manifest needn't find the edges again
+type fileLog struct {
+	mu     sync.Mutex
+	states map[string]string // Cleaned path → fileStatePartial or fileStateWritten
+	edges  map[string]int    // Relationship ID → edges written for it
+}
+
+// record sets the state of a data file; files without an OutputFiles aren't recorded
+func (f *OutputFiles) record(path, state string) {
+	if f == nil {
+		return
+	}
+	f.written.mu.Lock()
+	defer f.written.mu.Unlock()
+	if f.written.states == nil {
+		f.written.states = make(map[string]string)
+	}
+	f.written.states[path] = state
+}
+
+// state returns the recorded state of a file, empty when it wasn't opened
+func (f *OutputFiles) state(path string) string {
+	if f == nil {
+		return ""
+	}
+	f.written.mu.Lock()
+	defer f.written.mu.Unlock()
+	return f.written.states[filepath.Clean(path)]
+}
+
+// WroteDataFiles reports whether any data file was opened, so a failed run has
+// output to describe
+func (f *OutputFiles) WroteDataFiles() bool {
+	if f == nil {
+		return false
+	}
+	f.written.mu.Lock()
+	defer f.written.mu.Unlock()
+	return len(f.written.states) > 0
+}
@@ -0,0 +1,103 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWriteFaults(t *testing.T) {
+	faults, err := ParseWriteFaults("enospc=0.01, eacces=0.005,seed=7")
+	require.NoError(t, err)
+	assert.Equal(t, &WriteFaults{DiskFull: 0.01, PermissionDenied: 0.005, Seed: 7}, faults)
+
+	tests := []struct {
+		spec    string
+		wantErr string
+	}{
+		{"enospc", "invalid write fault 'enospc' (expected <fault>=<rate>)"},
+		{"enospc=2", "invalid rate '2' for write fault enospc (expected 0 to 1)"},
+		{"eio=0.1", "unknown write fault 'eio' (expected enospc or eacces)"},
+		{"seed=x", "invalid write fault seed 'x'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := ParseWriteFaults(tt.spec)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestWriteFaults(t *testing.T) {
+	originalDelay := writeRetryDelay
+	writeRetryDelay = 0
+	defer func() { writeRetryDelay = originalDelay }()
+
+	t.Run("transient faults are retried", func(t *testing.T) {
+		faults := &WriteFaults{DiskFull: 0.5, PermissionDenied: 0.5, Seed: 3}
+		files := NewOutputFiles()
+		files.SetWriteFaults(faults)
+		dir := t.TempDir()
+		for i := 0; i < 10; i++ {
+			path := filepath.Join(dir, "file.csv")
+			if err := files.write(path, []byte("id\n1\n")); err != nil {
+				continue // All attempts can fail at these rates
+			}
+			content, err := os.ReadFile(path) // #nosec G304 - test file
+			require.NoError(t, err)
+			assert.Equal(t, "id\n1\n", string(content))
+			assert.Equal(t, fileStateWritten, files.state(path))
+		}
+		assert.Positive(t, faults.Injected())
+	})
+
+	t.Run("a write failing every attempt leaves a partial file", func(t *testing.T) {
+		faults := &WriteFaults{DiskFull: 1}
+		files := NewOutputFiles()
+		files.SetWriteFaults(faults)
+		path := filepath.Join(t.TempDir(), "file.csv")
+		err := files.write(path, []byte("id\n"))
+		assert.ErrorIs(t, err, syscall.ENOSPC)
+		assert.Equal(t, writeAttempts, faults.Injected())
+		assert.Equal(t, fileStatePartial, files.state(path))
+	})
+
+	t.Run("a file that can't be created isn't recorded", func(t *testing.T) {
+		files := NewOutputFiles()
+		files.SetWriteFaults(&WriteFaults{PermissionDenied: 1})
+		_, err := files.create(filepath.Join(t.TempDir(), "file.csv"))
+		assert.ErrorIs(t, err, syscall.EACCES)
+		assert.False(t, files.WroteDataFiles())
+	})
+
+	t.Run("only injected permission errors are retried", func(t *testing.T) {
+		denied := &os.PathError{Op: "open", Path: "file.csv", Err: syscall.EACCES}
+		assert.False(t, transientWriteError(denied), "a real permission error stays denied")
+		assert.True(t, transientWriteError(&os.PathError{Op: "open", Path: "file.csv", Err: injectedFault{syscall.EACCES}}))
+		assert.True(t, transientWriteError(&os.PathError{Op: "write", Path: "file.csv", Err: syscall.ENOSPC}))
+	})
+}
+
+func TestManifest_MarkIncomplete(t *testing.T) {
+	files := NewOutputFiles()
+	dir := t.TempDir()
+	require.NoError(t, files.write(filepath.Join(dir, "Group.csv"), []byte("id\n")))
+	require.NoError(t, files.write(filepath.Join(dir, "User.csv"), []byte("id\n")))
+	files.record(filepath.Join(dir, "User_roles.csv"), fileStatePartial)
+
+	manifest := &Manifest{Format: OutputFormatCSV, files: files, Files: []ManifestEntry{
+		{Entity: "Group", File: "Group.csv"},
+		{Entity: "User", File: "User.csv", ListFiles: map[string]string{"roles": "User_roles.csv"}},
+		{Entity: "Role", File: "Role.csv"},
+	}}
+	manifest.MarkIncomplete(dir, assert.AnError)
+	assert.True(t, manifest.Incomplete)
+	assert.Equal(t, assert.AnError.Error(), manifest.Error)
+	assert.Equal(t, FileStatusWritten, manifest.Files[0].Status)
+	assert.Equal(t, FileStatusPartial, manifest.Files[1].Status, "a list file was cut short")
+	assert.Equal(t, FileStatusMissing, manifest.Files[2].Status)
+}
@@ -0,0 +1,116 @@
+package generators
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// TruncationWarning describes a relationship whose configured row counts cannot be
+// satisfied, so generation would leave rows unlinked or drop them.
+type TruncationWarning struct {
+	// Entity is the external ID of the entity whose rows are affected
+	Entity string
+
+	// Relationships lists the relationship IDs involved
+	Relationships []string
+
+	// Requested is the configured row count for Entity
+	Requested int
+
+	// Possible is how many of those rows the relationships can satisfy
+	Possible int
+
+	// Reason explains what happens to the remaining rows
+	Reason string
+}
+
+// String formats the warning for display.
+func (w *TruncationWarning) String() string {
+	return fmt.Sprintf(
+		"Truncation warning: %s requests %d rows but relationship(s) %s can satisfy only %d. %s",
+		w.Entity,
+		w.Requested,
+		strings.Join(w.Relationships, ", "),
+		w.Possible,
+		w.Reason,
+	)
+}
+
+// DetectTruncation checks planned row counts against the relationships that constrain
+// them, before any data is generated. It reports:
+//
+//   - one-to-one relationships whose source entity has more rows than the target;
+//     the excess source rows are left without a match
+//   - entities linked through several relationships (junction tables) with more rows
+//     than unique FK combinations; duplicate combinations are dropped
+//
+// rowCounts maps entity external IDs to planned row counts. Targets with no rows are
+// ignored since their FKs are deliberately left blank.
+func DetectTruncation(graph *model.Graph, rowCounts map[string]int) []TruncationWarning {
+	warnings := make([]TruncationWarning, 0)
+
+	for _, entity := range graph.GetEntitiesList() {
+		requested := rowCounts[entity.GetExternalID()]
+		if requested == 0 {
+			continue
+		}
+
+		// Relationships where this entity holds the FK, in ID order for stable output
+		var sourceRelationships []model.RelationshipInterface
+		for _, relationship := range graph.GetRelationshipsForEntity(entity.GetID()) {
+			if relationship.GetSourceEntity().GetID() == entity.GetID() {
+				sourceRelationships = append(sourceRelationships, relationship)
+			}
+		}
+		sort.Slice(sourceRelationships, func(i, j int) bool {
+			return sourceRelationships[i].GetID() < sourceRelationships[j].GetID()
+		})
+
+		for _, relationship := range sourceRelationships {
+			targetCount := rowCounts[relationship.GetTargetEntity().GetExternalID()]
+			if relationship.IsOneToOne() && targetCount > 0 && requested > targetCount {
+				warnings = append(warnings, TruncationWarning{
+					Entity:        entity.GetExternalID(),
+					Relationships: []string{relationship.GetID()},
+					Requested:     requested,
+					Possible:      targetCount,
+					Reason: fmt.Sprintf("One-to-one with %s (%d rows): %d rows will have no match",
+						relationship.GetTargetEntity().GetExternalID(), targetCount, requested-targetCount),
+				})
+			}
+		}
+
+		// The linker drops rows whose FK combination repeats once every FK is assigned
+		if len(sourceRelationships) < 2 {
+			continue
+		}
+		combinations := 1
+		relationshipIDs := make([]string, 0, len(sourceRelationships))
+		for _, relationship := range sourceRelationships {
+			relationshipIDs = append(relationshipIDs, relationship.GetID())
+			targetCount := rowCounts[relationship.GetTargetEntity().GetExternalID()]
+			if targetCount == 0 {
+				continue
+			}
+			// Stop multiplying once the limit can't be reached, avoiding overflow
+			if combinations < requested {
+				combinations *= targetCount
+			}
+		}
+		if combinations < requested {
+			warnings = append(warnings, TruncationWarning{
+				Entity:        entity.GetExternalID(),
+				Relationships: relationshipIDs,
+				Requested:     requested,
+				Possible:      combinations,
+				Reason: fmt.Sprintf("Only %d unique FK combinations exist: %d duplicate rows will be dropped",
+					combinations, requested-combinations),
+			})
+		}
+	}
+
+	return warnings
+}
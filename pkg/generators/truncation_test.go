@@ -0,0 +1,116 @@
+package generators
+
+import (
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectTruncation(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Test SOR",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User",
+				ExternalId:  "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", UniqueId: true},
+				},
+			},
+			"profile": {
+				DisplayName: "Profile",
+				ExternalId:  "Profile",
+				Attributes: []parser.Attribute{
+					{Name: "userId", ExternalId: "userId", UniqueId: true},
+				},
+			},
+			"group": {
+				DisplayName: "Group",
+				ExternalId:  "Group",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", UniqueId: true},
+				},
+			},
+			"member": {
+				DisplayName: "Member",
+				ExternalId:  "Member",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", UniqueId: true},
+					{Name: "userId", ExternalId: "userId"},
+					{Name: "groupId", ExternalId: "groupId"},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"profile_user": {Name: "profile_user", FromAttribute: "Profile.userId", ToAttribute: "User.id"},
+			"member_user":  {Name: "member_user", FromAttribute: "Member.userId", ToAttribute: "User.id"},
+			"member_group": {Name: "member_group", FromAttribute: "Member.groupId", ToAttribute: "Group.id"},
+		},
+	}
+
+	graphInterface, err := model.NewGraph(def, 100)
+	require.NoError(t, err)
+	graph, ok := graphInterface.(*model.Graph)
+	require.True(t, ok)
+
+	tests := []struct {
+		name      string
+		rowCounts map[string]int
+		expected  []TruncationWarning
+	}{
+		{
+			name:      "counts that fit produce no warnings",
+			rowCounts: map[string]int{"User": 10, "Profile": 10, "Group": 5, "Member": 50},
+			expected:  []TruncationWarning{},
+		},
+		{
+			name:      "one-to-one source larger than target",
+			rowCounts: map[string]int{"User": 10, "Profile": 15, "Group": 5, "Member": 50},
+			expected: []TruncationWarning{{
+				Entity:        "Profile",
+				Relationships: []string{"profile_user"},
+				Requested:     15,
+				Possible:      10,
+				Reason:        "One-to-one with User (10 rows): 5 rows will have no match",
+			}},
+		},
+		{
+			name:      "junction table larger than FK combinations",
+			rowCounts: map[string]int{"User": 10, "Profile": 10, "Group": 5, "Member": 60},
+			expected: []TruncationWarning{{
+				Entity:        "Member",
+				Relationships: []string{"member_group", "member_user"},
+				Requested:     60,
+				Possible:      50,
+				Reason:        "Only 50 unique FK combinations exist: 10 duplicate rows will be dropped",
+			}},
+		},
+		{
+			name:      "empty targets are ignored",
+			rowCounts: map[string]int{"User": 0, "Profile": 15, "Group": 5, "Member": 5},
+			expected:  []TruncationWarning{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DetectTruncation(graph, tt.rowCounts))
+		})
+	}
+}
+
+func TestTruncationWarning_String(t *testing.T) {
+	warning := TruncationWarning{
+		Entity:        "Member",
+		Relationships: []string{"member_group", "member_user"},
+		Requested:     60,
+		Possible:      50,
+		Reason:        "Only 50 unique FK combinations exist: 10 duplicate rows will be dropped",
+	}
+
+	assert.Equal(t, "Truncation warning: Member requests 60 rows but relationship(s) member_group, member_user "+
+		"can satisfy only 50. Only 50 unique FK combinations exist: 10 duplicate rows will be dropped", warning.String())
+}
@@ -0,0 +1,71 @@
+package mapping
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/SGNL-ai/fabricator/pkg/encryption"
+)
+
+// Encrypted mapping layout: magic | salt | nonce | AES-256-GCM ciphertext
+const (
+	encryptedMagic   = "FABMAP1\n"
+	encryptedMinSize = len(encryptedMagic) + encryption.SaltSize
+)
+
+// ErrNotEncrypted is returned by Decrypt for data that lacks the encrypted mapping header
+var ErrNotEncrypted = errors.New("data is not an encrypted mapping file")
+
+// IsEncrypted reports whether data starts with the encrypted mapping header
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedMagic))
+}
+
+// Encrypt seals plaintext with AES-256-GCM using a key derived from passphrase
+// by encryption.NewAEAD
+func Encrypt(plaintext []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, encryption.SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	aead, err := encryption.NewAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := make([]byte, 0, encryptedMinSize+len(nonce)+len(plaintext)+aead.Overhead())
+	out = append(out, encryptedMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, []byte(encryptedMagic)), nil
+}
+
+// Decrypt opens data produced by Encrypt
+func Decrypt(data []byte, passphrase string) ([]byte, error) {
+	if !IsEncrypted(data) || len(data) < encryptedMinSize {
+		return nil, ErrNotEncrypted
+	}
+	salt := data[len(encryptedMagic):encryptedMinSize]
+
+	aead, err := encryption.NewAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	rest := data[encryptedMinSize:]
+	if len(rest) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted mapping is truncated")
+	}
+
+	plaintext, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], []byte(encryptedMagic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt mapping (wrong key or corrupted file)")
+	}
+	return plaintext, nil
+}
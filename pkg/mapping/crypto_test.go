@@ -0,0 +1,49 @@
+package mapping
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	plaintext := []byte(`{"entity":"User","id":"u1"}` + "\n")
+
+	sealed, err := Encrypt(plaintext, "passphrase")
+	require.NoError(t, err)
+
+	t.Run("Round trip", func(t *testing.T) {
+		opened, err := Decrypt(sealed, "passphrase")
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, opened)
+	})
+
+	t.Run("Salted output differs per call", func(t *testing.T) {
+		again, err := Encrypt(plaintext, "passphrase")
+		require.NoError(t, err)
+		assert.NotEqual(t, sealed, again)
+	})
+
+	t.Run("Wrong passphrase", func(t *testing.T) {
+		_, err := Decrypt(sealed, "other")
+		assert.Error(t, err)
+	})
+
+	t.Run("Tampered data", func(t *testing.T) {
+		tampered := append([]byte(nil), sealed...)
+		tampered[len(tampered)-1] ^= 0xff
+		_, err := Decrypt(tampered, "passphrase")
+		assert.Error(t, err)
+	})
+
+	t.Run("Plain data", func(t *testing.T) {
+		_, err := Decrypt(plaintext, "passphrase")
+		assert.ErrorIs(t, err, ErrNotEncrypted)
+	})
+
+	t.Run("Empty passphrase", func(t *testing.T) {
+		_, err := Encrypt(plaintext, "")
+		assert.Error(t, err)
+	})
+}
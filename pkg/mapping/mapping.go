@@ -0,0 +1,121 @@
+// Package mapping exports the link between generated identifiers and the synthetic
+// identity attributes (names, emails, ...) generated for them, so fabricated
+// identities can be correlated across datasets.
+package mapping
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+)
+
+// identityPatterns are attribute name fragments that mark synthetic identity data
+var identityPatterns = []string{"email", "name", "phone", "address", "login", "username"}
+
+// Entry maps one generated row's identifier to its synthetic identity attributes
+type Entry struct {
+	Entity     string            `json:"entity"`
+	ID         string            `json:"id"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+// IsIdentityAttribute reports whether an attribute holds synthetic identity data.
+// Identifiers and foreign keys are excluded; they are the mapping's keys.
+func IsIdentityAttribute(attr model.AttributeInterface) bool {
+	if attr.IsUnique() || attr.IsRelationship() {
+		return false
+	}
+	name := strings.ToLower(attr.GetName())
+	for _, pattern := range identityPatterns {
+		if strings.Contains(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// Build collects mapping entries for every entity with identity attributes,
+// ordered by entity external ID and then row order
+func Build(graph *model.Graph) []Entry {
+	entities := graph.GetEntitiesList()
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].GetExternalID() < entities[j].GetExternalID()
+	})
+
+	var entries []Entry
+	for _, entity := range entities {
+		pk := entity.GetPrimaryKey()
+		if pk == nil {
+			continue
+		}
+
+		var identityAttrs []model.AttributeInterface
+		for _, attr := range entity.GetAttributes() {
+			if IsIdentityAttribute(attr) {
+				identityAttrs = append(identityAttrs, attr)
+			}
+		}
+		if len(identityAttrs) == 0 {
+			continue
+		}
+
+		for i := 0; i < entity.GetRowCount(); i++ {
+			row := entity.GetRowByIndex(i)
+			entry := Entry{
+				Entity:     entity.GetExternalID(),
+				ID:         row.GetValue(pk.GetName()),
+				Attributes: make(map[string]string, len(identityAttrs)),
+			}
+			for _, attr := range identityAttrs {
+				entry.Attributes[attr.GetExternalID()] = row.GetValue(attr.GetName())
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Marshal encodes entries as JSON lines
+func Marshal(entries []Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return nil, fmt.Errorf("failed to encode mapping entry: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Write saves the identity mapping for a generated graph to path as JSON lines.
+// When passphrase is non-empty the file is encrypted (see Encrypt).
+// Returns the number of entries written.
+func Write(graph *model.Graph, path, passphrase string) (int, error) {
+	entries := Build(graph)
+	data, err := Marshal(entries)
+	if err != nil {
+		return 0, err
+	}
+
+	if passphrase != "" {
+		data, err = Encrypt(data, passphrase)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return 0, fmt.Errorf("failed to create mapping directory: %w", err)
+	}
+	// Mapping files link fabricated identities together, so keep them private
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return 0, fmt.Errorf("failed to write mapping file %s: %w", path, err)
+	}
+	return len(entries), nil
+}
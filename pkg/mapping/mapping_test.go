@@ -0,0 +1,121 @@
+package mapping
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// identityGraph builds a graph with users carrying identity attributes and
+// groups carrying none
+func identityGraph(t *testing.T) *model.Graph {
+	t.Helper()
+	def := &parser.SORDefinition{
+		DisplayName: "Mapping SOR",
+		Description: "SOR used for mapping tests",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User",
+				ExternalId:  "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "email", ExternalId: "mail", Type: "String"},
+					{Name: "displayName", ExternalId: "displayName", Type: "String"},
+					{Name: "title", ExternalId: "title", Type: "String"},
+					{Name: "groupId", ExternalId: "groupId", Type: "String"},
+				},
+			},
+			"group": {
+				DisplayName: "Group",
+				ExternalId:  "Group",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "kind", ExternalId: "kind", Type: "String"},
+				},
+			},
+		},
+		Relationships: map[string]parser.Relationship{
+			"user_group": {Name: "user_group", FromAttribute: "User.groupId", ToAttribute: "Group.id"},
+		},
+	}
+
+	graphInterface, err := model.NewGraph(def, 10)
+	require.NoError(t, err)
+	graph := graphInterface.(*model.Graph)
+
+	users, _ := graph.GetEntity("User")
+	for _, values := range []map[string]string{
+		{"id": "u1", "email": "a@example.com", "displayName": "Ann", "title": "Eng", "groupId": "g1"},
+		{"id": "u2", "email": "b@example.com", "displayName": "Bob", "title": "Ops", "groupId": "g1"},
+	} {
+		require.NoError(t, users.AddRow(model.NewRow(values)))
+	}
+	groups, _ := graph.GetEntity("Group")
+	require.NoError(t, groups.AddRow(model.NewRow(map[string]string{"id": "g1", "kind": "team"})))
+	return graph
+}
+
+func TestBuild(t *testing.T) {
+	entries := Build(identityGraph(t))
+
+	require.Len(t, entries, 2, "entities without identity attributes are skipped")
+	assert.Equal(t, Entry{
+		Entity:     "User",
+		ID:         "u1",
+		Attributes: map[string]string{"mail": "a@example.com", "displayName": "Ann"},
+	}, entries[0], "attributes are keyed by external ID; non-identity and FK columns are excluded")
+	assert.Equal(t, "u2", entries[1].ID)
+}
+
+func readEntries(t *testing.T, data []byte) []Entry {
+	t.Helper()
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var entry Entry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestWrite(t *testing.T) {
+	t.Run("Plain", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nested", "mapping.jsonl")
+		count, err := Write(identityGraph(t), path, "")
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		data, err := os.ReadFile(path) // #nosec G304 - test file path
+		require.NoError(t, err)
+		assert.False(t, IsEncrypted(data))
+		assert.Len(t, readEntries(t, data), 2)
+	})
+
+	t.Run("Encrypted", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "mapping.enc")
+		_, err := Write(identityGraph(t), path, "s3cret")
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path) // #nosec G304 - test file path
+		require.NoError(t, err)
+		assert.True(t, IsEncrypted(data))
+		assert.NotContains(t, string(data), "a@example.com")
+
+		plaintext, err := Decrypt(data, "s3cret")
+		require.NoError(t, err)
+		assert.Len(t, readEntries(t, plaintext), 2)
+	})
+}
@@ -10,7 +10,8 @@ import (
 
 // DiagramOptions configures diagram generation
 type DiagramOptions struct {
-	// Additional options can be added here
+	File   string           // Path of the diagram; named after the SOR in outputDir when empty
+	Render diagrams.Options // Layout, format and entities drawn
 }
 
 // DiagramResult contains the results of diagram generation
@@ -23,19 +24,24 @@ type DiagramResult struct {
 func RunDiagramGeneration(def *parser.SORDefinition, outputDir string, options DiagramOptions) (*DiagramResult, error) {
 	result := &DiagramResult{}
 
-	// Create diagram filename based on SOR name
-	diagramName := util.CleanNameForFilename(def.DisplayName)
+	diagramPath := options.File
+	if diagramPath == "" {
+		// Create diagram filename based on SOR name
+		diagramName := util.CleanNameForFilename(def.DisplayName)
 
-	// Determine extension based on Graphviz availability
-	extension := ".dot"
-	if diagrams.IsGraphvizAvailable() {
-		extension = ".svg"
-	}
+		// Determine extension based on the format and Graphviz availability
+		extension := ".dot"
+		if options.Render.Format != "" {
+			extension = "." + options.Render.Format
+		} else if diagrams.IsGraphvizAvailable() {
+			extension = ".svg"
+		}
 
-	diagramPath := filepath.Join(outputDir, diagramName+extension)
+		diagramPath = filepath.Join(outputDir, diagramName+extension)
+	}
 
 	// Generate the diagram
-	err := diagrams.GenerateERDiagram(def, diagramPath)
+	diagramPath, err := diagrams.GenerateERDiagramWithOptions(def, diagramPath, options.Render)
 	if err != nil {
 		return result, err
 	}
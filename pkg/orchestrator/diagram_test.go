@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/SGNL-ai/fabricator/pkg/diagrams"
 	"github.com/SGNL-ai/fabricator/pkg/parser"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -146,3 +147,22 @@ func TestDiagramOrchestrator(t *testing.T) {
 		assert.Contains(t, []string{".svg", ".dot"}, ext, "Should have appropriate file extension")
 	})
 }
+
+func TestRunDiagramGeneration_File(t *testing.T) {
+	def := &parser.SORDefinition{
+		DisplayName: "Test SOR",
+		Entities: map[string]parser.Entity{
+			"user": {DisplayName: "User", ExternalId: "User", Attributes: []parser.Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+			}},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "docs", "er.dot")
+
+	result, err := RunDiagramGeneration(def, "", DiagramOptions{File: path, Render: diagrams.Options{RankDir: "LR"}})
+	require.NoError(t, err)
+	assert.Equal(t, path, result.Path)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `rankdir="LR"`)
+}
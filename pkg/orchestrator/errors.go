@@ -0,0 +1,14 @@
+package orchestrator
+
+import "github.com/SGNL-ai/fabricator/pkg/errcode"
+
+// Error kinds returned by RunGeneration and RunValidation, matched with errors.Is.
+// They wrap the error that caused them, which may match a more specific kind of
+// the parser or model packages.
+var (
+	ErrUnsatisfiableCounts = errcode.New(errcode.CountsUnsatisfiable, "row counts cannot satisfy relationships")
+	ErrGenerationFailed    = errcode.New(errcode.GenerationFailed, "data generation failed")
+	ErrValidationFailed    = errcode.New(errcode.ValidationFailed, "validation failed")
+	ErrOutputExists        = errcode.New(errcode.OutputExists, "output directory holds files the output mode doesn't allow")
+	ErrAssertionsFailed    = errcode.New(errcode.AssertionsFailed, "generated data breaks assertions")
+)
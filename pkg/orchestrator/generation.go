@@ -2,15 +2,23 @@ package orchestrator
 
 import (
 	"fmt"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/SGNL-ai/fabricator/pkg/config"
+	"github.com/SGNL-ai/fabricator/pkg/console"
 	"github.com/SGNL-ai/fabricator/pkg/diagrams"
+	"github.com/SGNL-ai/fabricator/pkg/encryption"
+	"github.com/SGNL-ai/fabricator/pkg/errcode"
+	"github.com/SGNL-ai/fabricator/pkg/events"
 	"github.com/SGNL-ai/fabricator/pkg/fabricator"
 	"github.com/SGNL-ai/fabricator/pkg/generators"
 	"github.com/SGNL-ai/fabricator/pkg/generators/model"
 	"github.com/SGNL-ai/fabricator/pkg/generators/pipeline"
+	"github.com/SGNL-ai/fabricator/pkg/mapping"
 	"github.com/SGNL-ai/fabricator/pkg/parser"
 	"github.com/SGNL-ai/fabricator/pkg/util"
 	"github.com/fatih/color"
@@ -23,6 +31,131 @@ type GenerationOptions struct {
 	AutoCardinality bool
 	GenerateDiagram bool
 	ValidateResults bool
+	PartialInputDir string          // Directory of partial CSVs whose missing columns are filled in
+	Events          *events.Emitter // Optional receiver of progress events
+	OutputFormat    string          // pipeline.OutputFormatCSV (default), OutputFormatJSONL, OutputFormatGo or OutputFormatNeo4j
+	GoPackage       string          // Package clause of Go fixture files; empty uses pipeline.DefaultGoPackage
+	JSONLShape      string          // Lines of JSON lines output (see pipeline.JSONLShapes); empty writes messages
+	ClearlyFakePII  bool            // Generate PII in obviously fake formats
+
+	// Keep a copy of every value instead of sharing repeated ones, e.g. to compare
+	// memory profiles
+	NoValueInterning bool
+
+	// How entity files are named and placed in the output directory, and in the
+	// directories of partial input and external references; the zero value is the
+	// default layout
+	Layout pipeline.FileLayout
+
+	// Entities with a row count of 0 are written as header-only files
+	IncludeEmptyEntities bool
+
+	// Fail before generating when row counts would leave relationship rows
+	// unmatched or dropped, instead of warning
+	StrictCounts bool
+
+	// Fail when more rows share a scope than a scoped-unique attribute has distinct
+	// values, instead of appending numeric suffixes to the repeats
+	StrictUniqueness bool
+
+	// Identity mapping export; empty MappingFile disables it
+	MappingFile       string
+	MappingPassphrase string // Encrypts the mapping file when set
+
+	// Output pacing for soak tests; 0 means unlimited
+	RowsPerSecond       float64
+	EntityRowsPerSecond map[string]float64 // Per-entity overrides keyed by external ID
+
+	// Entity files written at the same time (CSV output) and bytes buffered per file
+	// between writes to storage; 0 uses the defaults
+	WriteWorkers    int
+	WriteFileBuffer int
+
+	// Exact rows always written, with generated rows making up the rest of each
+	// entity's count; validate them against the SOR with Fixtures.Validate first
+	Fixtures *config.Fixtures
+
+	// Role and SoD distribution for entitlement assignments; the ground truth is
+	// written to pipeline.AccessGroundTruthFile in the output directory
+	AccessConfig *config.AccessConfiguration
+
+	// Seeds the random generator so runs with the same SOR and settings produce the
+	// same data; 0 uses a random seed
+	Seed int64
+
+	// Creates the sources of all generation randomness, for tests that control it
+	// (see pipeline.RandomStreams); it takes precedence over Seed
+	RandomSource pipeline.RandomSource
+
+	// Fill person-like entities from a population of this many people derived from
+	// Seed, so the same people appear across entities and SORs; 0 disables it
+	Population int
+
+	// How closely the address parts of address-like entities agree:
+	// pipeline.AddressCoherenceStrict (default), AddressCoherenceLoose or AddressCoherenceOff
+	AddressCoherence string
+
+	// Built-in theme name, pipeline.ThemeRandom or theme file whose vocabulary names
+	// departments, groups, projects and applications; empty disables it
+	Theme string
+
+	// Overwrite the first rows' fields with boundary values for their type; the
+	// placements are written to pipeline.EdgeCasesFile in the output directory
+	EdgeCases bool
+
+	// Write a payload with this many objects per entity, shaped like an SGNL
+	// ingestion adapter's response, to pipeline.IngestionSamplesDir; 0 disables it
+	IngestionSampleRows int
+
+	// Also write the files, with attributes marked sensitive masked or hashed, to
+	// the sibling directory pipeline.RedactedDir(outputDir)
+	Redacted bool
+
+	// Encrypt data files, and the reports, audit log and descriptor written with
+	// them, as they are written, appending encryption.Extension to their names; nil
+	// writes them in the clear. It can't be combined with a KeyRegistry.
+	Encryptor *encryption.Encryptor
+
+	// Assertions checked over the generated rows, in addition to those of the SOR;
+	// a failing assertion of error severity fails the run after the files are written
+	Assertions []parser.Assertion
+
+	// Transient errors injected into data file writes, to exercise retries and the
+	// marking of partial output in the manifest; nil injects none
+	WriteFaults *pipeline.WriteFaults
+
+	// What happens to files already in the output directory: OutputModeOverwrite
+	// (default), OutputModeClean, OutputModeFailIfExists or OutputModeMerge
+	OutputMode string
+
+	// Rules picking primary key formats (UUID or sequence), checked before
+	// pipeline.DefaultIDFormats
+	IDFormats []pipeline.IDFormatRule
+
+	// External IDs of entities generated and written first, in this order, as far
+	// as their dependencies allow; the others follow
+	EntityOrder []string
+
+	// Registry file of the primary keys earlier runs generated, updated with this
+	// run's keys; generated keys avoid those it holds. Empty disables it.
+	KeyRegistry string
+
+	// Share (0 to 1) of each entity's rows taking keys from the KeyRegistry instead
+	KeyReuse float64
+
+	// Write the run's random decisions to this file as JSON lines; empty disables
+	// it. Without a Seed or RandomSource, a seed is drawn so the run can be repeated.
+	AuditLog string
+
+	// Write a pipeline.DatasetDescriptor of the output (paths, counts, seed, schema
+	// hash) to this file for infrastructure as code steps; empty disables it.
+	// Without a Seed or RandomSource, a seed is drawn so the data can be regenerated.
+	Descriptor string
+
+	// Memory in use (bytes) at which a heap profile and a partial manifest are
+	// written to the output directory, for runs that may die of running out of
+	// memory. 0 disables it.
+	MemoryThreshold uint64
 }
 
 // GenerationResult contains the results of data generation
@@ -33,6 +166,25 @@ type GenerationResult struct {
 	CSVFilesGenerated int
 	DiagramGenerated  bool
 	DiagramPath       string
+	MappingEntries    int            // Identity mapping entries written (0 when disabled)
+	AccessGroundTruth string         // Path of the access simulation report (empty when disabled)
+	SoDViolations     int            // Users violating SoD rules, summed over rules
+	EdgeCasesFile     string         // Path of the edge case placement report (empty when disabled)
+	EdgeCasesPlaced   int            // Boundary values written
+	ManifestFile      string         // Path of the manifest listing the generated files
+	IngestionSamples  string         // Directory of sample ingestion payloads (empty when disabled)
+	CardinalityReport string         // Path of the auto-cardinality explanation (empty when disabled)
+	RedactedDir       string         // Directory of the redacted copy (empty when disabled)
+	AuditLog          string         // Path of the audit log (empty when disabled)
+	Descriptor        string         // Path of the dataset descriptor (empty when disabled)
+	DatasetVersion    string         // Version recorded in the dataset descriptor (empty when disabled)
+	Seed              int64          // Seed the run used (0 when random or from a RandomSource)
+	Theme             string         // Name of the theme used (empty when disabled)
+	KeyRegistry       string         // Path of the key registry (empty when disabled)
+	KeysRecorded      int            // Keys added to the key registry by this run
+	MemoryCapture     *MemoryCapture // What crossing the MemoryThreshold wrote (nil when it wasn't crossed)
+	Assertions        []pipeline.AssertionResult
+	Hints             []Hint // Next steps suggested by the run's statistics
 	ValidationSummary *ValidationSummary
 }
 
@@ -49,7 +201,62 @@ func RunGeneration(def *parser.SORDefinition, outputDir string, options Generati
 		RecordsPerEntity: options.DataVolume,
 	}
 
+	// Reject rate overrides for entities the SOR doesn't define
+	for entityID := range options.EntityRowsPerSecond {
+		if !entityExists(def, entityID) {
+			return nil, errcode.Wrap(model.ErrEntityNotFound, fmt.Errorf("rate override references unknown entity '%s'", entityID))
+		}
+	}
+
+	// Encrypted runs leave nothing in the clear, and the key registry holds keys as they are
+	if options.Encryptor != nil && options.KeyRegistry != "" {
+		return nil, fmt.Errorf("a key registry can't be kept for encrypted output: it would hold the generated keys in the clear")
+	}
+	if options.Encryptor != nil && options.MappingFile != "" && options.MappingPassphrase == "" {
+		return nil, fmt.Errorf("an identity mapping can't be exported in the clear for encrypted output: set a mapping passphrase")
+	}
+
+	// Apply the output mode before anything is written
+	if options.PartialInputDir != "" && options.OutputMode == OutputModeClean && sameDir(options.PartialInputDir, outputDir) {
+		return nil, fmt.Errorf("refusing to clean %s: it holds the partial input being filled", outputDir)
+	}
+	previousManifest, err := prepareOutputDir(outputDir, options.OutputMode)
+	if err != nil {
+		return nil, err
+	}
+	var previousRedacted *pipeline.Manifest
+	if options.Redacted {
+		if previousRedacted, err = prepareOutputDir(pipeline.RedactedDir(outputDir), options.OutputMode); err != nil {
+			return nil, err
+		}
+	}
+	if options.OutputMode == OutputModeMerge && previousManifest != nil {
+		if err := pipeline.CheckMergeable(previousManifest, options.OutputFormat, options.Encryptor != nil, outputDir); err != nil {
+			return nil, errcode.Wrap(ErrOutputExists, err)
+		}
+	}
+
+	// Audited and described runs must be repeatable, so they get a seed if none was given
+	var audit *pipeline.AuditLog
+	seed, seedReason := options.Seed, "given"
+	if options.AuditLog != "" {
+		audit = pipeline.NewAuditLog()
+	}
+	if options.AuditLog != "" || options.Descriptor != "" {
+		for options.RandomSource == nil && seed == 0 {
+			seed, seedReason = rand.Int63(), "drawn"
+		}
+	}
+
+	if options.RandomSource != nil {
+		seed, seedReason = 0, "random source"
+	}
+	streams := pipeline.NewRandomStreams(seed, options.RandomSource)
+	result.Seed = seed
+	audit.Record(pipeline.AuditRecord{Decision: pipeline.AuditSeed, Seed: seed, Reason: seedReason})
+
 	// Create graph from definition with data volume for memory optimization
+	started := options.Events.PhaseStarted("graph")
 	graphInterface, err := model.NewGraph(def, options.DataVolume)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create entity graph: %w", err)
@@ -59,6 +266,19 @@ func RunGeneration(def *parser.SORDefinition, outputDir string, options Generati
 	if !ok {
 		return nil, fmt.Errorf("failed to convert graph to concrete type")
 	}
+	graph.SetValueInterning(!options.NoValueInterning)
+	if len(options.EntityOrder) > 0 {
+		if err := graph.SetEntityOrder(options.EntityOrder); err != nil {
+			return nil, err
+		}
+	}
+	options.Events.PhaseFinished("graph", started)
+
+	// Check the assertions against the SOR before spending time generating
+	assertions, err := pipeline.CompileAssertions(graph, slices.Concat(def.Assertions, options.Assertions))
+	if err != nil {
+		return nil, err
+	}
 
 	// Display parsing statistics from the constructed graph
 	statistics := graph.GetStatistics()
@@ -67,34 +287,272 @@ func RunGeneration(def *parser.SORDefinition, outputDir string, options Generati
 	// Build row counts map (per-entity or uniform)
 	rowCounts := BuildRowCountsMap(def, options.CountConfig, options.DataVolume)
 
+	// Check up front that the row counts can satisfy the relationships
+	truncations := generators.DetectTruncation(graph, rowCounts)
+	if len(truncations) > 0 {
+		if options.StrictCounts {
+			messages := make([]string, 0, len(truncations))
+			for _, truncation := range truncations {
+				messages = append(messages, truncation.String())
+			}
+			bullet := console.Text("\n  • ")
+			return nil, fmt.Errorf("%w:%s%s", ErrUnsatisfiableCounts, bullet, strings.Join(messages, bullet))
+		}
+		color.Yellow(console.Text("\n⚠️  Truncation Warnings:"))
+		for _, truncation := range truncations {
+			color.Yellow(console.Text("  • %s"), truncation.String())
+			options.Events.Warning(truncation.String())
+		}
+		color.Yellow("\nNote: Use --strict-counts to fail instead of generating truncated data.")
+	}
+
 	// Initialize and run the data generation pipeline
+	files := pipeline.NewOutputFiles()
+	files.SetLayout(options.Layout)
+	files.SetEncryptor(options.Encryptor)
+	files.SetWriteFaults(options.WriteFaults)
 	generator := pipeline.NewDataGenerator(outputDir, rowCounts, options.AutoCardinality)
-	if err := generator.Generate(graph); err != nil {
-		return nil, fmt.Errorf("data generation failed: %w", err)
+	generator.SetOutputFiles(files)
+	generator.SetRandomStreams(streams)
+	if options.PartialInputDir != "" {
+		generator.SetPartialInput(options.PartialInputDir)
+	}
+	if options.Fixtures != nil {
+		generator.SetFixtures(options.Fixtures)
+	}
+	externalRefs, err := pipeline.ExternalReferences(def)
+	if err != nil {
+		return nil, err
+	}
+	for i := range externalRefs {
+		externalRefs[i].Layout = options.Layout
+	}
+	generator.SetExternalReferences(externalRefs)
+	generator.SetEventEmitter(options.Events)
+	generator.SetClearlyFakePII(options.ClearlyFakePII)
+	generator.SetStrictUniqueness(options.StrictUniqueness)
+	generator.SetAddressCoherence(options.AddressCoherence)
+	if options.Population > 0 {
+		// Without a seed the population is random, but still shared within the run
+		populationSeed := seed
+		if populationSeed == 0 {
+			populationSeed = streams.Seed("population")
+		}
+		population, err := pipeline.NewPopulation(options.Population, populationSeed, options.ClearlyFakePII)
+		if err != nil {
+			return nil, err
+		}
+		generator.SetPopulation(population)
+	}
+	if options.Theme != "" {
+		// A random theme is drawn from the seed, so the run can be repeated
+		theme, err := pipeline.LoadTheme(options.Theme, streams)
+		if err != nil {
+			return nil, err
+		}
+		generator.SetTheme(theme)
+		result.Theme = theme.Name
+	}
+	generator.SetIncludeEmptyEntities(options.IncludeEmptyEntities)
+	generator.SetIDFormats(options.IDFormats)
+	var keys *pipeline.KeyRegistry
+	if options.KeyRegistry != "" {
+		if keys, err = pipeline.LoadKeyRegistry(options.KeyRegistry); err != nil {
+			return nil, err
+		}
+		generator.SetKeyRegistry(keys, options.KeyReuse)
+	}
+	if err := generator.SetOutputFormat(options.OutputFormat); err != nil {
+		return nil, err
+	}
+	if options.GoPackage != "" {
+		if err := generator.SetGoPackage(options.GoPackage); err != nil {
+			return nil, err
+		}
+	}
+	if options.JSONLShape != "" {
+		if err := generator.SetJSONLShape(options.JSONLShape); err != nil {
+			return nil, err
+		}
+	}
+	generator.SetThrottle(pipeline.NewThrottle(options.RowsPerSecond, options.EntityRowsPerSecond))
+	generator.SetWriteWorkers(options.WriteWorkers)
+	generator.SetWriteFileBuffer(options.WriteFileBuffer)
+	if options.AccessConfig != nil {
+		generator.SetAccessSimulation(options.AccessConfig)
+	}
+	generator.SetEdgeCases(options.EdgeCases)
+	generator.SetAuditLog(audit)
+	if options.Redacted {
+		generator.SetRedactedOutput(pipeline.RedactedDir(outputDir))
+	}
+	var watch *memoryWatch
+	if options.MemoryThreshold > 0 {
+		// The watch only sees the planned files, as it can't read the graph while
+		// it's generated
+		planned := pipeline.NewManifest(graph, options.OutputFormat, files)
+		for i := range planned.Files {
+			planned.Files[i].Rows = rowCounts[planned.Files[i].Entity]
+		}
+		watch = startMemoryWatch(options.MemoryThreshold, outputDir, planned, options.Events)
+	}
+	err = generator.Generate(graph)
+	if watch != nil {
+		result.MemoryCapture = watch.stop()
+	}
+	if err != nil {
+		err = fmt.Errorf("%w: %w", ErrGenerationFailed, err)
+		// Files already written must not pass for a complete run
+		if files.WroteDataFiles() {
+			manifest := pipeline.NewManifest(graph, options.OutputFormat, files)
+			manifest.MarkIncomplete(outputDir, err)
+			if writeErr := pipeline.WriteManifest(filepath.Join(outputDir, pipeline.ManifestFile), manifest); writeErr != nil {
+				return nil, fmt.Errorf("%w (and the incomplete manifest could not be written: %w)", err, writeErr)
+			}
+		}
+		return nil, err
+	}
+
+	// Check the assertions while the generated rows are still in memory
+	result.Assertions = assertions.Evaluate()
+	for _, assertion := range result.Assertions {
+		if !assertion.Passed {
+			options.Events.Warning("assertion failed: " + assertion.String())
+		}
+	}
+
+	// Remember this run's keys for the next snapshot
+	if keys != nil {
+		result.KeysRecorded = keys.Record(graph)
+		if err := pipeline.WriteKeyRegistry(options.KeyRegistry, keys); err != nil {
+			return nil, err
+		}
+		result.KeyRegistry = options.KeyRegistry
+	}
+
+	// Write the access simulation's ground truth next to the data
+	if truth := generator.AccessGroundTruth(); truth != nil {
+		path := files.FileName(filepath.Join(outputDir, pipeline.AccessGroundTruthFile))
+		if err := pipeline.WriteAccessGroundTruth(path, truth, files); err != nil {
+			return nil, err
+		}
+		result.AccessGroundTruth = path
+		for _, rule := range truth.SoDRules {
+			result.SoDViolations += len(rule.Violators)
+		}
+	}
+
+	// List where boundary values were placed so tests can find them
+	if options.EdgeCases {
+		path := files.FileName(filepath.Join(outputDir, pipeline.EdgeCasesFile))
+		if err := pipeline.WriteEdgeCases(path, generator.EdgeCases(), files); err != nil {
+			return nil, err
+		}
+		result.EdgeCasesFile = path
+		result.EdgeCasesPlaced = len(generator.EdgeCases())
+	}
+
+	// Record the decisions that explain the generated rows
+	if options.AuditLog != "" {
+		path := files.FileName(options.AuditLog)
+		if err := pipeline.WriteAuditLog(path, audit, files); err != nil {
+			return nil, err
+		}
+		result.AuditLog = path
+	}
+
+	// List the generated files, including any renamed to be valid on every OS
+	manifest := pipeline.NewManifest(graph, options.OutputFormat, files)
+	for _, file := range manifest.DataFiles() {
+		if _, err := os.Stat(filepath.Join(outputDir, filepath.FromSlash(file))); err == nil {
+			result.CSVFilesGenerated++
+		}
+	}
+	if err := finishManifest(manifest, previousManifest, outputDir, options.OutputMode, options.Events); err != nil {
+		return nil, err
+	}
+	manifestPath := filepath.Join(outputDir, pipeline.ManifestFile)
+	if err := pipeline.WriteManifest(manifestPath, manifest); err != nil {
+		return nil, err
+	}
+	result.ManifestFile = manifestPath
+
+	// Describe the dataset for the steps provisioning environments with it
+	if options.Descriptor != "" {
+		descriptor, err := pipeline.NewDatasetDescriptor(def, manifest, outputDir, seed)
+		if err != nil {
+			return nil, err
+		}
+		path := files.FileName(options.Descriptor)
+		if err := pipeline.WriteDatasetDescriptor(path, descriptor, files); err != nil {
+			return nil, err
+		}
+		result.Descriptor = path
+		result.DatasetVersion = descriptor.DatasetVersion
+	}
+
+	// The run finished, so the complete manifest replaces the partial one
+	if capture := result.MemoryCapture; capture != nil && capture.Manifest != "" {
+		if err := os.Remove(capture.Manifest); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		capture.Manifest = ""
+	}
+
+	// The redacted copy holds the same files, so it gets the same manifest
+	if options.Redacted {
+		result.RedactedDir = pipeline.RedactedDir(outputDir)
+		redacted := pipeline.NewManifest(graph, options.OutputFormat, files)
+		if err := finishManifest(redacted, previousRedacted, result.RedactedDir, options.OutputMode, nil); err != nil {
+			return nil, err
+		}
+		redactedManifest := filepath.Join(result.RedactedDir, pipeline.ManifestFile)
+		if err := pipeline.WriteManifest(redactedManifest, redacted); err != nil {
+			return nil, err
+		}
+	}
+
+	// Explain the cardinality chosen per relationship so dataset shape can be reviewed
+	cardinality := pipeline.ExplainCardinality(graph, options.AutoCardinality)
+	if options.AutoCardinality {
+		path := files.FileName(filepath.Join(outputDir, pipeline.CardinalityReportFile))
+		if err := pipeline.WriteCardinalityReport(path, cardinality, files); err != nil {
+			return nil, err
+		}
+		result.CardinalityReport = path
+	}
+
+	// Show adapter developers what an ingestion of the data would send
+	if options.IngestionSampleRows > 0 {
+		dir := filepath.Join(outputDir, pipeline.IngestionSamplesDir)
+		if _, err := pipeline.WriteIngestionSamples(graph, dir, options.IngestionSampleRows, files); err != nil {
+			return nil, err
+		}
+		result.IngestionSamples = dir
+	}
+
+	// Export the identity mapping if requested
+	if options.MappingFile != "" {
+		entries, err := mapping.Write(graph, options.MappingFile, options.MappingPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export identity mapping: %w", err)
+		}
+		result.MappingEntries = entries
 	}
 
 	// Detect and emit cardinality warnings if using per-entity counts
 	if options.CountConfig != nil {
 		warnings := generators.DetectCardinalityViolations(graph, def, rowCounts)
 		if len(warnings) > 0 {
-			color.Yellow("\n⚠️  Cardinality Warnings:")
+			color.Yellow(console.Text("\n⚠️  Cardinality Warnings:"))
 			for _, warning := range warnings {
-				color.Yellow("  • %s", warning.String())
+				color.Yellow(console.Text("  • %s"), warning.String())
+				options.Events.Warning(warning.String())
 			}
 			color.Yellow("\nNote: CSV files were generated with best-effort relationship assignment.")
 		}
 	}
 
-	// Count generated files
-	files, err := os.ReadDir(outputDir)
-	if err == nil {
-		for _, file := range files {
-			if filepath.Ext(file.Name()) == ".csv" {
-				result.CSVFilesGenerated++
-			}
-		}
-	}
-
 	// Calculate results
 	result.EntitiesProcessed = len(def.Entities)
 	result.TotalRecords = result.EntitiesProcessed * options.DataVolume
@@ -110,8 +568,10 @@ func RunGeneration(def *parser.SORDefinition, outputDir string, options Generati
 
 	// Run validation if requested
 	if options.ValidateResults {
+		started := options.Events.PhaseStarted("validate")
 		validator := pipeline.NewValidation()
 		relationshipErrors := validator.ValidateRelationships(graph)
+		options.Events.PhaseFinished("validate", started)
 
 		result.ValidationSummary = &ValidationSummary{
 			Errors:             relationshipErrors,
@@ -119,9 +579,33 @@ func RunGeneration(def *parser.SORDefinition, outputDir string, options Generati
 		}
 	}
 
+	// Suggest what to change for the next run from what this one produced
+	result.Hints = nextStepHints(runStatistics{
+		Cardinality:     cardinality,
+		Truncations:     truncations,
+		Manifest:        manifest,
+		OutputDir:       outputDir,
+		AutoCardinality: options.AutoCardinality,
+		MemoryCapture:   result.MemoryCapture,
+	})
+
+	// The files stay written so a failing assertion can be investigated
+	if pipeline.AssertionsFailed(result.Assertions) {
+		return result, ErrAssertionsFailed
+	}
 	return result, nil
 }
 
+// entityExists reports whether the definition has an entity with the given external ID
+func entityExists(def *parser.SORDefinition, externalID string) bool {
+	for _, entity := range def.Entities {
+		if entity.ExternalId == externalID {
+			return true
+		}
+	}
+	return false
+}
+
 // generateERDiagram creates an ER diagram for the SOR
 func generateERDiagram(def *parser.SORDefinition, outputDir string) (string, error) {
 	// Create diagram filename based on SOR name
@@ -145,12 +629,23 @@ func generateERDiagram(def *parser.SORDefinition, outputDir string) (string, err
 }
 
 // BuildRowCountsMap constructs a map of entity external IDs to row counts.
-// If a CountConfiguration is provided, it uses those values.
+// Entities with inline data count their rows and derived entities have none to
+// generate. For the others, if a CountConfiguration is provided, it uses those values.
 // Otherwise, it creates a uniform map with the default dataVolume.
 func BuildRowCountsMap(def *parser.SORDefinition, countConfig *config.CountConfiguration, defaultVolume int) map[string]int {
 	rowCounts := make(map[string]int, len(def.Entities))
 
 	for _, entity := range def.Entities {
+		// Entities embedding their rows always have exactly those
+		if len(entity.Data) > 0 {
+			rowCounts[entity.ExternalId] = len(entity.Data)
+			continue
+		}
+		// Derived entities get one row per value they're computed from
+		if entity.Derived != nil {
+			rowCounts[entity.ExternalId] = 0
+			continue
+		}
 		if countConfig != nil {
 			// Use configured count, or default if not specified
 			rowCounts[entity.ExternalId] = countConfig.GetCount(entity.ExternalId, defaultVolume)
@@ -1,10 +1,20 @@
 package orchestrator
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/SGNL-ai/fabricator/pkg/config"
+	"github.com/SGNL-ai/fabricator/pkg/encryption"
+	"github.com/SGNL-ai/fabricator/pkg/errcode"
+	"github.com/SGNL-ai/fabricator/pkg/events"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/generators/pipeline"
 	"github.com/SGNL-ai/fabricator/pkg/parser"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -227,4 +237,518 @@ func TestGenerationOrchestrator(t *testing.T) {
 		assert.NoError(t, err, "Generation should succeed")
 		assert.Nil(t, result.ValidationSummary, "Should not include validation summary when not requested")
 	})
+	t.Run("should emit progress events when an emitter is configured", func(t *testing.T) {
+		def := &parser.SORDefinition{
+			DisplayName: "Test SOR",
+			Description: "Test Description",
+			Entities: map[string]parser.Entity{
+				"user": {
+					DisplayName: "User",
+					ExternalId:  "User",
+					Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					},
+				},
+			},
+		}
+
+		var buf bytes.Buffer
+		emitter := events.NewEmitter(events.NewWriterSink(&buf))
+
+		_, err := RunGeneration(def, t.TempDir(), GenerationOptions{DataVolume: 3, Events: emitter})
+		require.NoError(t, err)
+		require.NoError(t, emitter.Close())
+
+		output := buf.String()
+		for _, phase := range []string{"graph", "ids", "relationships", "fields", "write"} {
+			assert.Contains(t, output, "phase_started phase="+phase)
+			assert.Contains(t, output, "phase_finished phase="+phase)
+		}
+		assert.Contains(t, output, "entity_generated entity=User rows=3")
+		assert.Contains(t, output, "rows_written entity=User rows=3")
+	})
+
+	t.Run("should export the identity mapping when requested", func(t *testing.T) {
+		def := &parser.SORDefinition{
+			DisplayName: "Test SOR",
+			Description: "Test Description",
+			Entities: map[string]parser.Entity{
+				"user": {
+					DisplayName: "User",
+					ExternalId:  "User",
+					Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+						{Name: "email", ExternalId: "email", Type: "String"},
+					},
+				},
+			},
+		}
+
+		outputDir := t.TempDir()
+		mappingPath := filepath.Join(outputDir, "mapping.jsonl")
+		result, err := RunGeneration(def, outputDir, GenerationOptions{DataVolume: 4, MappingFile: mappingPath})
+		require.NoError(t, err)
+		assert.Equal(t, 4, result.MappingEntries)
+		assert.FileExists(t, mappingPath)
+	})
+
+	t.Run("should reject rate overrides for unknown entities", func(t *testing.T) {
+		def := &parser.SORDefinition{
+			DisplayName: "Test SOR",
+			Description: "Test Description",
+			Entities: map[string]parser.Entity{
+				"user": {
+					DisplayName: "User",
+					ExternalId:  "User",
+					Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					},
+				},
+			},
+		}
+
+		_, err := RunGeneration(def, t.TempDir(), GenerationOptions{
+			DataVolume:          3,
+			EntityRowsPerSecond: map[string]float64{"Account": 5},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown entity 'Account'")
+		assert.ErrorIs(t, err, model.ErrEntityNotFound)
+		assert.Equal(t, errcode.EntityNotFound, errcode.Of(err))
+	})
+
+	t.Run("should write header-only files for entities with zero rows", func(t *testing.T) {
+		def := &parser.SORDefinition{
+			DisplayName: "Test SOR",
+			Description: "Test Description",
+			Entities: map[string]parser.Entity{
+				"user": {
+					DisplayName: "User",
+					ExternalId:  "User",
+					Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+						{Name: "name", ExternalId: "name", Type: "String"},
+					},
+				},
+				"account": {
+					DisplayName: "Account",
+					ExternalId:  "Account",
+					Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+						{Name: "userId", ExternalId: "userId", Type: "String"},
+					},
+				},
+			},
+			Relationships: map[string]parser.Relationship{
+				"account_user": {Name: "account_user", FromAttribute: "Account.userId", ToAttribute: "User.id"},
+			},
+		}
+		countConfig := &config.CountConfiguration{
+			EntityCounts: map[string]int{"User": 0, "Account": 3},
+			AllowEmpty:   true,
+		}
+
+		outputDir := t.TempDir()
+		_, err := RunGeneration(def, outputDir, GenerationOptions{
+			DataVolume:           5,
+			CountConfig:          countConfig,
+			IncludeEmptyEntities: true,
+		})
+		require.NoError(t, err)
+
+		users, err := os.ReadFile(filepath.Join(outputDir, "User.csv"))
+		require.NoError(t, err)
+		assert.Equal(t, "id,name\n", string(users))
+
+		accounts, err := os.ReadFile(filepath.Join(outputDir, "Account.csv"))
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(accounts)), "\n")
+		require.Len(t, lines, 4)
+		for _, line := range lines[1:] {
+			assert.True(t, strings.HasSuffix(line, ","), "FK to the empty entity should be blank: %q", line)
+		}
+	})
+
+	t.Run("should reject zero row counts unless empty entities are included", func(t *testing.T) {
+		def := &parser.SORDefinition{
+			DisplayName: "Test SOR",
+			Description: "Test Description",
+			Entities: map[string]parser.Entity{
+				"user": {
+					DisplayName: "User",
+					ExternalId:  "User",
+					Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					},
+				},
+			},
+		}
+
+		_, err := RunGeneration(def, t.TempDir(), GenerationOptions{DataVolume: 0})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "must be greater than 0")
+	})
+
+	t.Run("should fail on truncating row counts when strict", func(t *testing.T) {
+		def := &parser.SORDefinition{
+			DisplayName: "Test SOR",
+			Description: "Test Description",
+			Entities: map[string]parser.Entity{
+				"user": {
+					DisplayName: "User",
+					ExternalId:  "User",
+					Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					},
+				},
+				"profile": {
+					DisplayName: "Profile",
+					ExternalId:  "Profile",
+					Attributes: []parser.Attribute{
+						{Name: "userId", ExternalId: "userId", Type: "String", UniqueId: true},
+					},
+				},
+			},
+			Relationships: map[string]parser.Relationship{
+				"profile_user": {Name: "profile_user", FromAttribute: "Profile.userId", ToAttribute: "User.id"},
+			},
+		}
+		countConfig := &config.CountConfiguration{EntityCounts: map[string]int{"User": 2, "Profile": 4}}
+
+		_, err := RunGeneration(def, t.TempDir(), GenerationOptions{DataVolume: 100, CountConfig: countConfig, StrictCounts: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Profile requests 4 rows but relationship(s) profile_user can satisfy only 2")
+		assert.ErrorIs(t, err, ErrUnsatisfiableCounts)
+
+		var buf bytes.Buffer
+		emitter := events.NewEmitter(events.NewWriterSink(&buf))
+		_, err = RunGeneration(def, t.TempDir(), GenerationOptions{DataVolume: 100, CountConfig: countConfig, Events: emitter})
+		require.NoError(t, err)
+		require.NoError(t, emitter.Close())
+		assert.Contains(t, buf.String(), "Truncation warning: Profile requests 4 rows")
+	})
+
+	t.Run("should write access simulation ground truth", func(t *testing.T) {
+		def := &parser.SORDefinition{
+			DisplayName: "Test SOR",
+			Description: "Test Description",
+			Entities: map[string]parser.Entity{
+				"user": {
+					DisplayName: "User",
+					ExternalId:  "User",
+					Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					},
+				},
+				"role": {
+					DisplayName: "Role",
+					ExternalId:  "Role",
+					Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					},
+				},
+				"grant": {
+					DisplayName: "Grant",
+					ExternalId:  "Grant",
+					Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+						{Name: "userId", ExternalId: "userId", Type: "String"},
+						{Name: "roleId", ExternalId: "roleId", Type: "String"},
+					},
+				},
+			},
+			Relationships: map[string]parser.Relationship{
+				"grant_user": {Name: "grant_user", FromAttribute: "Grant.userId", ToAttribute: "User.id"},
+				"grant_role": {Name: "grant_role", FromAttribute: "Grant.roleId", ToAttribute: "Role.id"},
+			},
+		}
+		countConfig := &config.CountConfiguration{EntityCounts: map[string]int{"User": 50, "Role": 7, "Grant": 100}}
+		accessConfig := &config.AccessConfiguration{
+			Assignment:  "Grant",
+			User:        "userId",
+			Entitlement: "roleId",
+			Roles: []config.AccessRole{
+				{Name: "approve", Entitlements: 1, Users: 0.2},
+				{Name: "create", Entitlements: 1, Users: 0.2},
+			},
+			SoDRules: []config.SoDRule{{Name: "four-eyes", Roles: []string{"approve", "create"}, Rate: 0.1}},
+		}
+
+		outputDir := t.TempDir()
+		result, err := RunGeneration(def, outputDir, GenerationOptions{DataVolume: 100, CountConfig: countConfig, AccessConfig: accessConfig})
+		require.NoError(t, err)
+		assert.Equal(t, 5, result.SoDViolations)
+		assert.Equal(t, filepath.Join(outputDir, "access_ground_truth.json"), result.AccessGroundTruth)
+
+		content, err := os.ReadFile(result.AccessGroundTruth) // #nosec G304 - test file
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"rule": "four-eyes"`)
+	})
+
+	t.Run("should write edge cases and list their placements", func(t *testing.T) {
+		def := &parser.SORDefinition{
+			DisplayName: "Test SOR",
+			Description: "Test Description",
+			Entities: map[string]parser.Entity{
+				"user": {
+					DisplayName: "User",
+					ExternalId:  "User",
+					Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+						{Name: "nickname", ExternalId: "nickname", Type: "String"},
+						{Name: "age", ExternalId: "age", Type: "Integer"},
+						{Name: "joined", ExternalId: "joined", Type: "DateTime"},
+					},
+				},
+			},
+		}
+
+		outputDir := t.TempDir()
+		result, err := RunGeneration(def, outputDir, GenerationOptions{DataVolume: 5, EdgeCases: true})
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(outputDir, "edge_cases.json"), result.EdgeCasesFile)
+		assert.Equal(t, 8, result.EdgeCasesPlaced, "3 string, 3 integer and 2 date-time cases")
+
+		file, err := os.Open(filepath.Join(outputDir, "User.csv")) // #nosec G304 - test file
+		require.NoError(t, err)
+		defer func() { _ = file.Close() }()
+		records, err := csv.NewReader(file).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 6)
+		assert.Equal(t, []string{"", "-2147483648", "1970-01-01T00:00:00Z"}, records[1][1:])
+		assert.Equal(t, "9999-12-31T23:59:59Z", records[2][3])
+
+		content, err := os.ReadFile(result.EdgeCasesFile) // #nosec G304 - test file
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"case": "maxLength"`)
+	})
+
+	t.Run("should write a manifest of the generated files", func(t *testing.T) {
+		def := &parser.SORDefinition{
+			DisplayName: "Manifest SOR",
+			Description: "SOR with an external ID invalid as a Windows filename",
+			Entities: map[string]parser.Entity{
+				"role": {
+					DisplayName: "Role",
+					ExternalId:  "App/Role:Admin",
+					Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					},
+				},
+			},
+		}
+
+		outputDir := t.TempDir()
+		result, err := RunGeneration(def, outputDir, GenerationOptions{DataVolume: 4})
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(outputDir, "manifest.json"), result.ManifestFile)
+		assert.FileExists(t, filepath.Join(outputDir, "Role_Admin.csv"))
+
+		content, err := os.ReadFile(result.ManifestFile) // #nosec G304 - test file
+		require.NoError(t, err)
+		var manifest pipeline.Manifest
+		require.NoError(t, json.Unmarshal(content, &manifest))
+		assert.Equal(t, []pipeline.ManifestEntry{
+			{Entity: "App/Role:Admin", File: "Role_Admin.csv", Rows: 4, Unsanitized: "Role:Admin.csv"},
+		}, manifest.Files)
+	})
+
+	t.Run("should mark the manifest incomplete when writing fails", func(t *testing.T) {
+		def := &parser.SORDefinition{
+			DisplayName: "Faulty SOR",
+			Entities: map[string]parser.Entity{
+				"group": {DisplayName: "Group", ExternalId: "Group", Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				}},
+				"user": {DisplayName: "User", ExternalId: "User", Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				}},
+			},
+		}
+		faults := &pipeline.WriteFaults{DiskFull: 1, Seed: 1}
+		outputDir := t.TempDir()
+		_, err := RunGeneration(def, outputDir, GenerationOptions{
+			DataVolume:  2,
+			WriteFaults: faults,
+		})
+		require.ErrorIs(t, err, ErrGenerationFailed)
+		assert.ErrorContains(t, err, "no space left on device")
+		assert.Equal(t, 3, faults.Injected(), "every attempt of the first file's write fails")
+
+		content, err := os.ReadFile(filepath.Join(outputDir, pipeline.ManifestFile)) // #nosec G304 - test file
+		require.NoError(t, err)
+		var manifest pipeline.Manifest
+		require.NoError(t, json.Unmarshal(content, &manifest))
+		assert.True(t, manifest.Incomplete)
+		assert.Contains(t, manifest.Error, "no space left on device")
+		require.Len(t, manifest.Files, 2)
+		statuses := map[string]string{}
+		for _, entry := range manifest.Files {
+			statuses[entry.Entity] = entry.Status
+		}
+		assert.ElementsMatch(t, []string{pipeline.FileStatusPartial, pipeline.FileStatusMissing},
+			[]string{statuses["Group"], statuses["User"]}, "the file being written is partial and the next isn't started")
+	})
+
+	t.Run("should fail after writing when an assertion fails", func(t *testing.T) {
+		def := &parser.SORDefinition{
+			DisplayName: "Asserted SOR",
+			Entities: map[string]parser.Entity{
+				"user": {DisplayName: "User", ExternalId: "User", Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				}},
+			},
+			Assertions: []parser.Assertion{{Name: "ids are set", Entity: "User", Compare: "id != ''", Severity: "warn"}},
+		}
+
+		outputDir := t.TempDir()
+		result, err := RunGeneration(def, outputDir, GenerationOptions{
+			DataVolume: 3,
+			Assertions: []parser.Assertion{{Name: "no users", Entity: "User", Compare: "id == 'none'"}},
+		})
+		require.ErrorIs(t, err, ErrAssertionsFailed)
+		require.NotNil(t, result)
+		require.Len(t, result.Assertions, 2)
+		assert.True(t, result.Assertions[0].Passed)
+		assert.Equal(t, 3, result.Assertions[1].Failed)
+		assert.FileExists(t, filepath.Join(outputDir, "User.csv"))
+
+		_, err = RunGeneration(def, t.TempDir(), GenerationOptions{
+			DataVolume: 3,
+			Assertions: []parser.Assertion{{Name: "unknown", Entity: "Group", Compare: "id == ''"}},
+		})
+		assert.EqualError(t, err, "assertion 'unknown': unknown entity 'Group'")
+	})
+
+	t.Run("should reproduce the same data with the same seed", func(t *testing.T) {
+		p := parser.NewParser("../../examples/okta.sgnl.yaml")
+		require.NoError(t, p.Parse())
+
+		first, second := t.TempDir(), t.TempDir()
+		for _, dir := range []string{first, second} {
+			_, err := RunGeneration(p.Definition, dir, GenerationOptions{DataVolume: 20, AutoCardinality: true, Seed: 7})
+			require.NoError(t, err)
+		}
+
+		files, err := filepath.Glob(filepath.Join(first, "*.csv"))
+		require.NoError(t, err)
+		require.NotEmpty(t, files)
+		for _, file := range files {
+			expected, err := os.ReadFile(file) // #nosec G304 - test file
+			require.NoError(t, err)
+			actual, err := os.ReadFile(filepath.Join(second, filepath.Base(file))) // #nosec G304 - test file
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(actual), filepath.Base(file))
+		}
+	})
+
+	t.Run("should record a drawn seed that reproduces the run", func(t *testing.T) {
+		p := parser.NewParser("../../examples/okta.sgnl.yaml")
+		require.NoError(t, p.Parse())
+
+		audited, rerun := t.TempDir(), t.TempDir()
+		auditPath := filepath.Join(audited, "audit.jsonl")
+		result, err := RunGeneration(p.Definition, audited, GenerationOptions{DataVolume: 10, AuditLog: auditPath})
+		require.NoError(t, err)
+		require.NotZero(t, result.Seed)
+		assert.Equal(t, auditPath, result.AuditLog)
+
+		content, err := os.ReadFile(auditPath) // #nosec G304 - test file
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		var first pipeline.AuditRecord
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+		assert.Equal(t, pipeline.AuditRecord{Decision: pipeline.AuditSeed, Seed: result.Seed, Reason: "drawn"}, first)
+		assert.Contains(t, string(content), `"decision":"entity_seed"`)
+		assert.Contains(t, string(content), `"decision":"cardinality"`)
+
+		_, err = RunGeneration(p.Definition, rerun, GenerationOptions{DataVolume: 10, Seed: result.Seed})
+		require.NoError(t, err)
+		files, err := filepath.Glob(filepath.Join(audited, "*.csv"))
+		require.NoError(t, err)
+		require.NotEmpty(t, files)
+		for _, file := range files {
+			expected, err := os.ReadFile(file) // #nosec G304 - test file
+			require.NoError(t, err)
+			actual, err := os.ReadFile(filepath.Join(rerun, filepath.Base(file))) // #nosec G304 - test file
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(actual), filepath.Base(file))
+		}
+	})
+
+	t.Run("should describe the dataset with a version its data determines", func(t *testing.T) {
+		p := parser.NewParser("../../examples/okta.sgnl.yaml")
+		require.NoError(t, p.Parse())
+
+		describe := func(options GenerationOptions) pipeline.DatasetDescriptor {
+			dir := t.TempDir()
+			options.DataVolume, options.Descriptor = 10, filepath.Join(t.TempDir(), "dataset.json")
+			result, err := RunGeneration(p.Definition, dir, options)
+			require.NoError(t, err)
+			assert.Equal(t, options.Descriptor, result.Descriptor)
+
+			content, err := os.ReadFile(options.Descriptor) // #nosec G304 - test file
+			require.NoError(t, err)
+			var descriptor pipeline.DatasetDescriptor
+			require.NoError(t, json.Unmarshal(content, &descriptor))
+			assert.Equal(t, result.DatasetVersion, descriptor.DatasetVersion)
+			assert.Equal(t, result.Seed, descriptor.Seed)
+			assert.Equal(t, dir, descriptor.OutputDirectory)
+			return descriptor
+		}
+
+		first := describe(GenerationOptions{})
+		require.NotZero(t, first.Seed, "a seed is drawn so the data can be regenerated")
+		assert.Equal(t, pipeline.DescriptorVersion, first.DescriptorVersion)
+		assert.Regexp(t, "^sha256:[0-9a-f]{64}$", first.SchemaHash)
+		require.NotEmpty(t, first.Entities)
+		total := 0
+		for _, entity := range first.Entities {
+			total += entity.Rows
+			assert.NotEmpty(t, entity.Files, entity.Entity)
+		}
+		assert.Equal(t, total, first.TotalRows)
+
+		assert.Equal(t, first.DatasetVersion, describe(GenerationOptions{Seed: first.Seed}).DatasetVersion)
+		assert.NotEqual(t, first.DatasetVersion, describe(GenerationOptions{Seed: first.Seed + 1}).DatasetVersion)
+	})
+
+	t.Run("should encrypt the reports written with encrypted output", func(t *testing.T) {
+		p := parser.NewParser("../../examples/okta.sgnl.yaml")
+		require.NoError(t, p.Parse())
+		encryptor, err := encryption.NewEncryptor("s3cret")
+		require.NoError(t, err)
+
+		dir := t.TempDir()
+		result, err := RunGeneration(p.Definition, dir, GenerationOptions{
+			DataVolume:      5,
+			EdgeCases:       true,
+			AutoCardinality: true,
+			Encryptor:       encryptor,
+			AuditLog:        filepath.Join(dir, "audit.jsonl"),
+			Descriptor:      filepath.Join(dir, "dataset.json"),
+		})
+		require.NoError(t, err)
+		for _, path := range []string{result.EdgeCasesFile, result.AuditLog, result.Descriptor, result.CardinalityReport} {
+			assert.Equal(t, encryption.Extension, filepath.Ext(path))
+			content, err := os.ReadFile(path) // #nosec G304 - test file
+			require.NoError(t, err)
+			assert.False(t, json.Valid(content), "%s is encrypted", path)
+		}
+		assert.NoFileExists(t, filepath.Join(dir, pipeline.EdgeCasesFile))
+
+		_, err = RunGeneration(p.Definition, t.TempDir(), GenerationOptions{
+			DataVolume:  5,
+			Encryptor:   encryptor,
+			KeyRegistry: filepath.Join(t.TempDir(), "keys.json"),
+		})
+		assert.ErrorContains(t, err, "key registry can't be kept for encrypted output")
+
+		_, err = RunGeneration(p.Definition, t.TempDir(), GenerationOptions{
+			DataVolume:  5,
+			Encryptor:   encryptor,
+			MappingFile: filepath.Join(t.TempDir(), "mapping.csv"),
+		})
+		assert.ErrorContains(t, err, "identity mapping can't be exported in the clear")
+	})
 }
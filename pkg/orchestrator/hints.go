@@ -0,0 +1,108 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators"
+	"github.com/SGNL-ai/fabricator/pkg/generators/pipeline"
+)
+
+const (
+	// lowParentCoverage is the share of target rows referenced below which a
+	// relationship is said to leave parents without children
+	lowParentCoverage = 0.5
+
+	// largeFileBytes is the data file size above which loaders commonly struggle
+	largeFileBytes = 2 << 30
+)
+
+// Hint is a next step the summary suggests, drawn from what the run produced
+type Hint struct {
+	Finding    string // What the run's statistics show
+	Suggestion string // What to try about it
+}
+
+// String formats the hint for display
+func (h Hint) String() string {
+	return h.Finding + "; consider " + h.Suggestion
+}
+
+// runStatistics holds what a finished run measured, for choosing hints
+type runStatistics struct {
+	Cardinality     []pipeline.CardinalityChoice
+	Truncations     []generators.TruncationWarning
+	Manifest        *pipeline.Manifest
+	OutputDir       string
+	AutoCardinality bool
+	MemoryCapture   *MemoryCapture
+}
+
+// nextStepHints suggests what to change for the next run, in a fixed order: link
+// coverage, truncated counts, file sizes, then memory
+func nextStepHints(stats runStatistics) []Hint {
+	var hints []Hint
+
+	var sparse []string
+	for _, choice := range stats.Cardinality {
+		if choice.TargetRows > 0 && choice.ForeignKeys > 0 &&
+			float64(choice.TargetsReferenced) < lowParentCoverage*float64(choice.TargetRows) {
+			sparse = append(sparse, choice.Relationship)
+		}
+	}
+	if len(sparse) > 0 {
+		suggestion := "raising the referencing entities' row counts in a --count-config"
+		if !stats.AutoCardinality {
+			suggestion = "--auto-cardinality or " + suggestion
+		}
+		hints = append(hints, Hint{
+			Finding: fmt.Sprintf("%d relationship(s) had low parent coverage, referencing under half of their target rows (%s)",
+				len(sparse), strings.Join(sparse, ", ")),
+			Suggestion: suggestion,
+		})
+	}
+
+	if len(stats.Truncations) > 0 {
+		var entities []string
+		seen := make(map[string]bool)
+		for _, truncation := range stats.Truncations {
+			if !seen[truncation.Entity] {
+				seen[truncation.Entity] = true
+				entities = append(entities, truncation.Entity)
+			}
+		}
+		hints = append(hints, Hint{
+			Finding:    fmt.Sprintf("Requested row counts left rows unlinked in %s", strings.Join(entities, ", ")),
+			Suggestion: "matching the row counts of related entities in a --count-config, or --strict-counts to fail instead",
+		})
+	}
+
+	if stats.Manifest != nil {
+		var large []string
+		for _, entry := range stats.Manifest.Files {
+			if len(entry.Partitions) > 0 {
+				continue
+			}
+			info, err := os.Stat(filepath.Join(stats.OutputDir, filepath.FromSlash(entry.File)))
+			if err == nil && info.Size() > largeFileBytes {
+				large = append(large, entry.Entity)
+			}
+		}
+		if len(large) > 0 {
+			hints = append(hints, Hint{
+				Finding:    fmt.Sprintf("Output exceeds 2 GiB per file for %s", strings.Join(large, ", ")),
+				Suggestion: "partitionBy on those entities to split their rows over several files",
+			})
+		}
+	}
+
+	if capture := stats.MemoryCapture; capture != nil && capture.Profile != "" {
+		hints = append(hints, Hint{
+			Finding:    fmt.Sprintf("Memory in use reached %d MiB, over the --memprofile-threshold", capture.Bytes>>20),
+			Suggestion: "go tool pprof -top " + capture.Profile + " to see what held it",
+		})
+	}
+	return hints
+}
@@ -0,0 +1,78 @@
+package orchestrator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators"
+	"github.com/SGNL-ai/fabricator/pkg/generators/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextStepHints(t *testing.T) {
+	t.Run("should suggest nothing for a well-shaped run", func(t *testing.T) {
+		hints := nextStepHints(runStatistics{
+			Cardinality: []pipeline.CardinalityChoice{
+				{Relationship: "Member", ForeignKeys: 100, TargetRows: 50, TargetsReferenced: 40},
+				// Relationships left empty on purpose aren't low coverage
+				{Relationship: "Owner", ForeignKeys: 0, TargetRows: 50},
+			},
+			AutoCardinality: true,
+		})
+		assert.Empty(t, hints)
+	})
+
+	t.Run("should name relationships with low parent coverage", func(t *testing.T) {
+		stats := runStatistics{
+			Cardinality: []pipeline.CardinalityChoice{
+				{Relationship: "Member", ForeignKeys: 10, TargetRows: 50, TargetsReferenced: 10},
+				{Relationship: "Manager", ForeignKeys: 50, TargetRows: 50, TargetsReferenced: 24},
+				{Relationship: "Owner", ForeignKeys: 50, TargetRows: 50, TargetsReferenced: 25},
+			},
+			AutoCardinality: true,
+		}
+		hints := nextStepHints(stats)
+		require.Len(t, hints, 1)
+		assert.Equal(t, "2 relationship(s) had low parent coverage, referencing under half of their target rows (Member, Manager); "+
+			"consider raising the referencing entities' row counts in a --count-config", hints[0].String())
+
+		stats.AutoCardinality = false
+		assert.Contains(t, nextStepHints(stats)[0].Suggestion, "--auto-cardinality")
+	})
+
+	t.Run("should name each truncated entity once", func(t *testing.T) {
+		hints := nextStepHints(runStatistics{Truncations: []generators.TruncationWarning{
+			{Entity: "Profile"}, {Entity: "Profile"}, {Entity: "Membership"},
+		}})
+		require.Len(t, hints, 1)
+		assert.Equal(t, "Requested row counts left rows unlinked in Profile, Membership", hints[0].Finding)
+	})
+
+	t.Run("should suggest partitioning entities whose file exceeds 2 GiB", func(t *testing.T) {
+		dir := t.TempDir()
+		// A sparse file takes no space but reports its full size
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "Event.csv"), nil, 0600))
+		require.NoError(t, os.Truncate(filepath.Join(dir, "Event.csv"), largeFileBytes+1))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "User.csv"), []byte("id\n"), 0600))
+
+		hints := nextStepHints(runStatistics{
+			Manifest: &pipeline.Manifest{Files: []pipeline.ManifestEntry{
+				{Entity: "User", File: "User.csv"},
+				{Entity: "Event", File: "Event.csv"},
+			}},
+			OutputDir: dir,
+		})
+		require.Len(t, hints, 1)
+		assert.Equal(t, "Output exceeds 2 GiB per file for Event", hints[0].Finding)
+		assert.Contains(t, hints[0].Suggestion, "partitionBy")
+	})
+
+	t.Run("should point at the heap profile written over the memory threshold", func(t *testing.T) {
+		hints := nextStepHints(runStatistics{MemoryCapture: &MemoryCapture{Bytes: 3 << 30, Profile: "out/" + MemoryProfileFile}})
+		require.Len(t, hints, 1)
+		assert.Equal(t, "Memory in use reached 3072 MiB, over the --memprofile-threshold; "+
+			"consider go tool pprof -top out/memory-threshold.pprof to see what held it", hints[0].String())
+	})
+}
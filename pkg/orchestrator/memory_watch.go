@@ -0,0 +1,144 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/metrics"
+	"runtime/pprof"
+	"time"
+
+	"github.com/SGNL-ai/fabricator/pkg/console"
+	"github.com/SGNL-ai/fabricator/pkg/events"
+	"github.com/SGNL-ai/fabricator/pkg/generators/pipeline"
+	"github.com/fatih/color"
+)
+
+// Files the memory watch writes to the output directory
+const (
+	MemoryProfileFile   = "memory-threshold.pprof"
+	PartialManifestFile = "manifest.partial.json"
+)
+
+// memoryWatchInterval is how often the memory watch samples the process's memory
+var memoryWatchInterval = 250 * time.Millisecond
+
+// MemoryCapture describes what the memory watch wrote when memory crossed the
+// threshold
+type MemoryCapture struct {
+	Bytes    uint64 // Memory in use when the threshold was crossed
+	Profile  string // Path of the heap profile
+	Manifest string // Path of the partial manifest; empty once a finished run removed it
+}
+
+// memoryWatch samples the memory the process holds from the operating system
+// while data is generated. The first time it crosses the threshold, it writes a
+// heap profile and a manifest of the files written so far, so a run killed for
+// running out of memory leaves enough behind to see why.
+type memoryWatch struct {
+	threshold uint64
+	dir       string
+	manifest  *pipeline.Manifest // Planned files, marked incomplete when captured
+	events    *events.Emitter
+
+	stopped  chan struct{}
+	done     chan struct{}
+	captured *MemoryCapture
+}
+
+// startMemoryWatch starts watching memory against a threshold in bytes; manifest
+// lists the files the run plans to write to dir, and is owned by the watch
+func startMemoryWatch(threshold uint64, dir string, manifest *pipeline.Manifest, emitter *events.Emitter) *memoryWatch {
+	w := &memoryWatch{
+		threshold: threshold,
+		dir:       dir,
+		manifest:  manifest,
+		events:    emitter,
+		stopped:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// stop ends the watch and returns what it captured, or nil if memory stayed below
+// the threshold
+func (w *memoryWatch) stop() *MemoryCapture {
+	close(w.stopped)
+	<-w.done
+	return w.captured
+}
+
+func (w *memoryWatch) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(memoryWatchInterval)
+	defer ticker.Stop()
+	for {
+		if used := memoryInUse(); used >= w.threshold {
+			w.capture(used)
+			return
+		}
+		select {
+		case <-w.stopped:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// capture writes the heap profile and the partial manifest
+func (w *memoryWatch) capture(used uint64) {
+	w.captured = &MemoryCapture{Bytes: used}
+	cause := fmt.Errorf("memory in use reached %d MiB, over the %d MiB threshold, while generating",
+		used>>20, w.threshold>>20)
+
+	profile := filepath.Join(w.dir, MemoryProfileFile)
+	if err := writeHeapProfile(profile); err != nil {
+		w.warn(fmt.Sprintf("%v; the heap profile could not be written: %v", cause, err))
+	} else {
+		w.captured.Profile = profile
+	}
+
+	manifest := filepath.Join(w.dir, PartialManifestFile)
+	w.manifest.MarkIncomplete(w.dir, cause)
+	if err := pipeline.WriteManifest(manifest, w.manifest); err != nil {
+		w.warn(fmt.Sprintf("%v; the partial manifest could not be written: %v", cause, err))
+	} else {
+		w.captured.Manifest = manifest
+	}
+
+	if w.captured.Profile != "" || w.captured.Manifest != "" {
+		w.warn(fmt.Sprintf("%v; wrote %s and %s", cause, MemoryProfileFile, PartialManifestFile))
+	}
+}
+
+func (w *memoryWatch) warn(message string) {
+	console.ClearLine()
+	color.Yellow(console.Text("⚠️  %s"), message)
+	w.events.Warning(message)
+}
+
+// writeHeapProfile writes a heap profile to path
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path) // #nosec G304 - path is in the output directory
+	if err != nil {
+		return err
+	}
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// memoryInUse returns the memory the Go runtime holds from the operating system
+// and hasn't released, which is what an out-of-memory kill counts
+func memoryInUse() uint64 {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+	total, released := samples[0].Value.Uint64(), samples[1].Value.Uint64()
+	return total - released
+}
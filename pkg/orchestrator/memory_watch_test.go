@@ -0,0 +1,74 @@
+package orchestrator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/generators/pipeline"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func memoryWatchDefinition() *parser.SORDefinition {
+	return &parser.SORDefinition{
+		DisplayName: "Memory Watch",
+		Entities: map[string]parser.Entity{
+			"user": {
+				DisplayName: "User", ExternalId: "User",
+				Attributes: []parser.Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "name", ExternalId: "name", Type: "String"},
+				},
+			},
+		},
+	}
+}
+
+func TestMemoryWatch(t *testing.T) {
+	t.Run("should write a heap profile and a partial manifest over the threshold", func(t *testing.T) {
+		graph, err := model.NewGraph(memoryWatchDefinition(), 5)
+		require.NoError(t, err)
+		dir := t.TempDir()
+
+		// Any running process holds more than a byte
+		watch := startMemoryWatch(1, dir, pipeline.NewManifest(graph.(*model.Graph), "csv", nil), nil)
+		capture := watch.stop()
+		require.NotNil(t, capture)
+		assert.NotZero(t, capture.Bytes)
+		assert.Equal(t, filepath.Join(dir, MemoryProfileFile), capture.Profile)
+		assert.FileExists(t, capture.Profile)
+
+		manifest, err := pipeline.ReadManifest(capture.Manifest)
+		require.NoError(t, err)
+		assert.True(t, manifest.Incomplete)
+		assert.Contains(t, manifest.Error, "over the 0 MiB threshold")
+		require.Len(t, manifest.Files, 1)
+		assert.Equal(t, pipeline.FileStatusMissing, manifest.Files[0].Status)
+	})
+
+	t.Run("should capture nothing below the threshold", func(t *testing.T) {
+		graph, err := model.NewGraph(memoryWatchDefinition(), 5)
+		require.NoError(t, err)
+		dir := t.TempDir()
+
+		watch := startMemoryWatch(^uint64(0), dir, pipeline.NewManifest(graph.(*model.Graph), "csv", nil), nil)
+		assert.Nil(t, watch.stop())
+		assert.NoFileExists(t, filepath.Join(dir, MemoryProfileFile))
+	})
+
+	t.Run("should keep the profile but drop the partial manifest when the run finishes", func(t *testing.T) {
+		dir := t.TempDir()
+		result, err := RunGeneration(memoryWatchDefinition(), dir, GenerationOptions{DataVolume: 5, MemoryThreshold: 1})
+		require.NoError(t, err)
+
+		require.NotNil(t, result.MemoryCapture)
+		assert.FileExists(t, result.MemoryCapture.Profile)
+		assert.Empty(t, result.MemoryCapture.Manifest)
+		_, err = os.Stat(filepath.Join(dir, PartialManifestFile))
+		assert.True(t, os.IsNotExist(err), "the complete manifest replaces the partial one")
+		assert.FileExists(t, filepath.Join(dir, pipeline.ManifestFile))
+	})
+}
@@ -0,0 +1,123 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/console"
+	"github.com/SGNL-ai/fabricator/pkg/errcode"
+	"github.com/SGNL-ai/fabricator/pkg/events"
+	"github.com/SGNL-ai/fabricator/pkg/generators/pipeline"
+	"github.com/fatih/color"
+)
+
+// Output modes, deciding what happens to files already in the output directory
+const (
+	// OutputModeOverwrite replaces the files the run writes and keeps the others,
+	// warning about entities an earlier run's manifest listed that this run doesn't
+	OutputModeOverwrite = ""
+
+	// OutputModeClean removes everything in the output directory first. Only a
+	// directory holding a manifest, i.e. earlier fabricator output, is cleaned.
+	OutputModeClean = "clean"
+
+	// OutputModeFailIfExists fails when the output directory holds any file
+	OutputModeFailIfExists = "fail-if-exists"
+
+	// OutputModeMerge keeps the files of entities the run doesn't write and lists
+	// them, from the earlier run's manifest, in the new manifest
+	OutputModeMerge = "merge"
+)
+
+// prepareOutputDir applies the output mode to dir before anything is written, and
+// returns the manifest an earlier run left there, or nil
+func prepareOutputDir(dir, mode string) (*pipeline.Manifest, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) || (err == nil && len(entries) == 0) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output directory: %w", err)
+	}
+
+	manifestPath := filepath.Join(dir, pipeline.ManifestFile)
+	_, statErr := os.Stat(manifestPath)
+	hasManifest := statErr == nil
+
+	switch mode {
+	case OutputModeOverwrite, OutputModeMerge:
+		if !hasManifest {
+			return nil, nil
+		}
+		previous, err := pipeline.ReadManifest(manifestPath)
+		if err != nil && mode == OutputModeMerge {
+			return nil, err
+		}
+		return previous, nil
+	case OutputModeFailIfExists:
+		return nil, errcode.Wrap(ErrOutputExists, fmt.Errorf("output directory %s is not empty (%d entries)", dir, len(entries)))
+	case OutputModeClean:
+		if !hasManifest {
+			return nil, errcode.Wrap(ErrOutputExists, fmt.Errorf(
+				"refusing to clean %s: it has no %s, so it may not hold fabricator output", dir, pipeline.ManifestFile))
+		}
+		for _, entry := range entries {
+			if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+				return nil, fmt.Errorf("failed to clean output directory: %w", err)
+			}
+		}
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported output mode '%s' (supported: %s, %s, %s)", mode, OutputModeClean, OutputModeFailIfExists, OutputModeMerge)
+	}
+}
+
+// finishManifest completes a run's manifest before it is written to dir: with
+// OutputModeMerge, the earlier run's other entities are added; otherwise entities
+// whose files the earlier run left behind are reported
+func finishManifest(manifest, previous *pipeline.Manifest, dir, mode string, emitter *events.Emitter) error {
+	if previous == nil {
+		return nil
+	}
+	if mode == OutputModeMerge {
+		replaced, err := pipeline.MergeManifest(manifest, previous, dir)
+		if err != nil {
+			return errcode.Wrap(ErrOutputExists, err)
+		}
+		if len(replaced) > 0 {
+			color.Yellow(console.Text("⚠️  Replaced the files of %d entities from the earlier run in %s: %s"),
+				len(replaced), dir, strings.Join(replaced, ", "))
+		}
+		return nil
+	}
+
+	listed := make(map[string]bool, len(manifest.Files))
+	for _, entry := range manifest.Files {
+		listed[entry.Entity] = true
+	}
+	var leftover []string
+	for _, entry := range previous.Files {
+		if listed[entry.Entity] {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(entry.File))); err == nil {
+			leftover = append(leftover, entry.Entity)
+		}
+	}
+	if len(leftover) > 0 {
+		message := fmt.Sprintf("%s still holds the files of %d entities from an earlier run (%s); use --clean to remove them or --merge to keep them listed",
+			dir, len(leftover), strings.Join(leftover, ", "))
+		color.Yellow(console.Text("⚠️  %s"), message)
+		emitter.Warning(message)
+	}
+	return nil
+}
+
+// sameDir reports whether two paths name the same directory
+func sameDir(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
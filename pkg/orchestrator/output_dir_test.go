@@ -0,0 +1,110 @@
+package orchestrator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/errcode"
+	"github.com/SGNL-ai/fabricator/pkg/generators/pipeline"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputModes(t *testing.T) {
+	// sor defines one entity per external ID, e.g. an Okta and a GitHub SOR
+	// that share a User entity
+	sor := func(externalIDs ...string) *parser.SORDefinition {
+		def := &parser.SORDefinition{DisplayName: "Test SOR", Entities: map[string]parser.Entity{}}
+		for _, id := range externalIDs {
+			def.Entities[id] = parser.Entity{
+				DisplayName: id,
+				ExternalId:  id,
+				Attributes:  []parser.Attribute{{Name: "id", ExternalId: "id", Type: "String", UniqueId: true}},
+			}
+		}
+		return def
+	}
+	generate := func(dir, mode string, externalIDs ...string) (*GenerationResult, error) {
+		return RunGeneration(sor(externalIDs...), dir, GenerationOptions{DataVolume: 2, OutputMode: mode})
+	}
+	listed := func(t *testing.T, dir string) []string {
+		t.Helper()
+		manifest, err := pipeline.ReadManifest(filepath.Join(dir, pipeline.ManifestFile))
+		require.NoError(t, err)
+		var entities []string
+		for _, entry := range manifest.Files {
+			entities = append(entities, entry.Entity)
+		}
+		return entities
+	}
+
+	t.Run("overwrite keeps other files out of the manifest", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := generate(dir, OutputModeOverwrite, "User", "Group")
+		require.NoError(t, err)
+		result, err := generate(dir, OutputModeOverwrite, "User", "Repository")
+		require.NoError(t, err)
+
+		assert.FileExists(t, filepath.Join(dir, "Group.csv"))
+		assert.ElementsMatch(t, []string{"User", "Repository"}, listed(t, dir))
+		assert.Equal(t, 2, result.CSVFilesGenerated, "the leftover file isn't counted")
+	})
+
+	t.Run("clean removes earlier output", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := generate(dir, OutputModeOverwrite, "User", "Group")
+		require.NoError(t, err)
+		_, err = generate(dir, OutputModeClean, "User", "Repository")
+		require.NoError(t, err)
+
+		assert.NoFileExists(t, filepath.Join(dir, "Group.csv"))
+		assert.ElementsMatch(t, []string{"User", "Repository"}, listed(t, dir))
+	})
+
+	t.Run("clean refuses a directory without a manifest", func(t *testing.T) {
+		dir := t.TempDir()
+		notes := filepath.Join(dir, "notes.txt")
+		require.NoError(t, os.WriteFile(notes, []byte("keep"), 0600))
+
+		_, err := generate(dir, OutputModeClean, "User")
+		assert.ErrorIs(t, err, ErrOutputExists)
+		assert.Equal(t, errcode.OutputExists, errcode.Of(err))
+		assert.FileExists(t, notes)
+	})
+
+	t.Run("fail if exists", func(t *testing.T) {
+		_, err := generate(t.TempDir(), OutputModeFailIfExists, "User")
+		require.NoError(t, err, "an empty directory is fine")
+
+		dir := t.TempDir()
+		_, err = generate(dir, OutputModeOverwrite, "User")
+		require.NoError(t, err)
+		_, err = generate(dir, OutputModeFailIfExists, "Group")
+		assert.ErrorIs(t, err, ErrOutputExists)
+		assert.NoFileExists(t, filepath.Join(dir, "Group.csv"))
+	})
+
+	t.Run("merge lists earlier entities after this run's", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := generate(dir, OutputModeOverwrite, "User", "Group")
+		require.NoError(t, err)
+		_, err = generate(dir, OutputModeMerge, "User", "Repository")
+		require.NoError(t, err)
+
+		assert.FileExists(t, filepath.Join(dir, "Group.csv"))
+		assert.ElementsMatch(t, []string{"User", "Repository", "Group"}, listed(t, dir))
+		assert.Equal(t, "Group", listed(t, dir)[2])
+	})
+
+	t.Run("merge refuses another format before writing", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := generate(dir, OutputModeOverwrite, "User")
+		require.NoError(t, err)
+		_, err = RunGeneration(sor("Group"), dir, GenerationOptions{DataVolume: 2, OutputMode: OutputModeMerge, OutputFormat: pipeline.OutputFormatJSONL})
+		assert.ErrorIs(t, err, ErrOutputExists)
+		assert.ErrorContains(t, err, "cannot merge jsonl output")
+		assert.NoFileExists(t, filepath.Join(dir, "Group.jsonl"))
+	})
+}
@@ -2,10 +2,18 @@ package orchestrator
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
-	"os"
+	"io"
+	"io/fs"
 	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/SGNL-ai/fabricator/pkg/config"
+	"github.com/SGNL-ai/fabricator/pkg/encryption"
+	"github.com/SGNL-ai/fabricator/pkg/errcode"
+	"github.com/SGNL-ai/fabricator/pkg/events"
 	"github.com/SGNL-ai/fabricator/pkg/fabricator"
 	"github.com/SGNL-ai/fabricator/pkg/generators/model"
 	"github.com/SGNL-ai/fabricator/pkg/generators/pipeline"
@@ -14,22 +22,56 @@ import (
 
 // ValidationOptions configures the validation process
 type ValidationOptions struct {
-	GenerateDiagram bool
+	GenerateDiagram        bool
+	RelationshipValidation map[string]string           // Relationship key → skip, warn or error; overrides the YAML
+	Streaming              bool                        // Read files row by row, keeping only key indexes in memory
+	Workers                int                         // Entity files loaded and indexed at the same time
+	Cache                  string                      // File of check results replayed for unchanged files; empty checks everything
+	Tolerances             map[string]config.Tolerance // Error budget per check; issues within it become warnings
+	StrictCoercion         bool                        // Report every value coerced to its attribute's type as an error
+	DedupeOutput           string                      // Directory to write a copy of the files to without rows repeating a key
+	Events                 *events.Emitter             // Optional receiver of progress events
+	Layout                 pipeline.FileLayout         // How the files were named and placed when written
+	NoValueInterning       bool                        // Keep a copy of every loaded value instead of sharing repeated ones
+	Decryptor              *encryption.Decryptor       // Decrypts files written encrypted; nil reads them in the clear
 }
 
 // ValidationResult contains the results of validation-only mode
 type ValidationResult struct {
-	FilesValidated   int
-	RecordsValidated int
-	ValidationErrors []string
-	DiagramGenerated bool
-	DiagramPath      string
+	FilesValidated     int
+	RecordsValidated   int
+	ValidationErrors   []string
+	ValidationWarnings []string                   // Issues from relationships marked "warn" or within tolerance
+	ToleranceResults   []pipeline.ToleranceResult // Checks measured against their error budget
+	DiagramGenerated   bool
+	DiagramPath        string
+	CachedChecks       int // Checks replayed from the validation cache
+	RunChecks          int // Checks run, with a validation cache
+
+	// DuplicateKeys groups the duplicate keys by likely cause, when validation found
+	// errors or DedupeOutput is set
+	DuplicateKeys []pipeline.DuplicateKeyGroup
+	DedupeDropped int // Rows left out of the copy in DedupeOutput
 }
 
 // RunValidation orchestrates the validation-only workflow
 func RunValidation(def *parser.SORDefinition, outputDir string, options ValidationOptions) (*ValidationResult, error) {
 	result := &ValidationResult{}
 
+	// Decrypted files are only read, as a cache or deduplicated copy would hold keys in the clear
+	if options.Decryptor != nil && options.Cache != "" {
+		return nil, fmt.Errorf("a validation cache can't be kept for encrypted files: it would hold keys in the clear")
+	}
+	if options.Decryptor != nil && options.DedupeOutput != "" {
+		return nil, fmt.Errorf("a deduplicated copy can't be written of encrypted files: it would be written in the clear")
+	}
+	files := pipeline.NewInputFiles(options.Decryptor)
+
+	def, err := applyRelationshipValidation(def, options.RelationshipValidation)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create graph from definition to get statistics
 	graphInterface, err := model.NewGraph(def, 0)
 	if err != nil {
@@ -46,15 +88,74 @@ func RunValidation(def *parser.SORDefinition, outputDir string, options Validati
 	fabricator.PrintGraphStatistics(statistics)
 
 	// Use ValidationProcessor to load and validate CSV files
+	started := options.Events.PhaseStarted("validate")
 	processor := pipeline.NewValidationProcessor()
-	validationErrors, err := processor.ValidateExistingCSVFiles(def, outputDir)
+	if options.Streaming {
+		processor = pipeline.NewStreamingValidationProcessor()
+	}
+	if layered, ok := processor.(interface{ SetFileLayout(pipeline.FileLayout) }); ok {
+		layered.SetFileLayout(options.Layout)
+	}
+	if decrypting, ok := processor.(interface{ SetInputFiles(*pipeline.InputFiles) }); ok {
+		decrypting.SetInputFiles(files)
+	}
+	if interning, ok := processor.(interface{ SetValueInterning(bool) }); ok {
+		interning.SetValueInterning(!options.NoValueInterning)
+	}
+	if parallel, ok := processor.(interface{ SetWorkers(int) }); ok {
+		parallel.SetWorkers(options.Workers)
+	}
+	if strict, ok := processor.(interface{ SetStrictCoercion(bool) }); ok {
+		strict.SetStrictCoercion(options.StrictCoercion)
+	}
+	var cache *pipeline.ValidationCache
+	if options.Cache != "" {
+		cached, ok := processor.(*pipeline.ValidationProcessor)
+		if !ok {
+			return nil, fmt.Errorf("a validation cache can't be used with streaming validation")
+		}
+		cache, err = pipeline.LoadValidationCache(options.Cache)
+		if err != nil {
+			return nil, err
+		}
+		cached.SetCache(cache)
+	}
+	report, err := processor.ValidateExistingCSVFilesReport(def, outputDir)
 	if err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	if cache != nil {
+		if err := cache.Save(); err != nil {
+			return nil, err
+		}
+		result.CachedChecks, result.RunChecks = cache.Stats()
+	}
+	options.Events.PhaseFinished("validate", started)
+
+	// Explain duplicate keys, which validation reports one row at a time
+	if len(report.Errors) > 0 || options.DedupeOutput != "" {
+		duplicates, err := pipeline.AnalyzeDuplicateKeys(def, outputDir, options.DedupeOutput, options.Layout, files)
+		if err != nil {
+			if options.DedupeOutput != "" {
+				return nil, fmt.Errorf("failed to write deduplicated copy: %w", err)
+			}
+			report.Warnings = append(report.Warnings, fmt.Sprintf("duplicate key analysis failed: %v", err))
+		} else {
+			result.DuplicateKeys, result.DedupeDropped = duplicates.Groups, duplicates.Dropped
+		}
+	}
+	result.ToleranceResults = report.ApplyTolerances(options.Tolerances)
+	for _, validationError := range report.Errors {
+		options.Events.ValidationIssue(validationError)
+	}
+	for _, warning := range report.Warnings {
+		options.Events.Warning(warning)
 	}
 
 	// Count files and records validated
-	result.ValidationErrors = validationErrors
-	result.FilesValidated, result.RecordsValidated = countValidatedData(outputDir)
+	result.ValidationErrors = report.Errors
+	result.ValidationWarnings = report.Warnings
+	result.FilesValidated, result.RecordsValidated = countValidatedData(outputDir, files)
 
 	// Generate ER diagram if requested
 	if options.GenerateDiagram {
@@ -68,33 +169,78 @@ func RunValidation(def *parser.SORDefinition, outputDir string, options Validati
 	return result, nil
 }
 
-// countValidatedData counts CSV files and records in the directory
-func countValidatedData(directory string) (int, int) {
-	files, err := os.ReadDir(directory)
-	if err != nil {
-		return 0, 0
+// applyRelationshipValidation returns a copy of def with relationship validation
+// levels replaced by the given overrides; the caller's definition is not modified
+func applyRelationshipValidation(def *parser.SORDefinition, overrides map[string]string) (*parser.SORDefinition, error) {
+	if len(overrides) == 0 {
+		return def, nil
+	}
+
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	updated := *def
+	updated.Relationships = make(map[string]parser.Relationship, len(def.Relationships))
+	for key, relationship := range def.Relationships {
+		updated.Relationships[key] = relationship
+	}
+
+	for _, key := range keys {
+		relationship, exists := updated.Relationships[key]
+		if !exists {
+			return nil, errcode.Wrap(model.ErrRelationshipNotFound, fmt.Errorf("relationship validation override for unknown relationship: %s", key))
+		}
+		if _, err := pipeline.ParseRelationshipValidation(overrides[key]); err != nil {
+			return nil, fmt.Errorf("relationship %s: %w", key, err)
+		}
+		relationship.Validation = overrides[key]
+		updated.Relationships[key] = relationship
 	}
 
+	return &updated, nil
+}
+
+// countValidatedData counts CSV files and records in the directory and its domain
+// folders, reading records one at a time so large files aren't held in memory
+func countValidatedData(directory string, files *pipeline.InputFiles) (int, int) {
 	filesCount := 0
 	recordsCount := 0
 
-	for _, file := range files {
-		if filepath.Ext(file.Name()) == ".csv" {
-			filesCount++
-
-			// Count records in this CSV file
-			csvPath := filepath.Join(directory, file.Name())
-			// #nosec G304 - csvPath is safely constructed from directory listing
-			if csvFile, err := os.Open(csvPath); err == nil {
-				if reader := csv.NewReader(csvFile); reader != nil {
-					if records, err := reader.ReadAll(); err == nil && len(records) > 1 {
-						recordsCount += len(records) - 1 // Exclude header row
-					}
-				}
-				_ = csvFile.Close()
+	_ = filepath.WalkDir(directory, func(csvPath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(entry.Name(), files.FileName(".csv")) {
+			return nil
+		}
+		filesCount++
+
+		// Count records in this CSV file
+		if csvFile, err := files.Open(csvPath); err == nil {
+			if records, err := countCSVRecords(csvFile); err == nil && records > 1 {
+				recordsCount += records - 1 // Exclude header row
 			}
+			_ = csvFile.Close()
 		}
-	}
+		return nil
+	})
 
 	return filesCount, recordsCount
 }
+
+// countCSVRecords counts the records read from r, including the header
+func countCSVRecords(r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	count := 0
+	for {
+		_, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		count++
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/SGNL-ai/fabricator/pkg/encryption"
 	"github.com/SGNL-ai/fabricator/pkg/parser"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -214,9 +215,100 @@ user-1`
 		assert.True(t, result.DiagramGenerated, "Should generate diagram when enabled")
 		assert.NotEmpty(t, result.DiagramPath, "Should provide diagram path when enabled")
 	})
+
+	t.Run("should apply relationship validation overrides", func(t *testing.T) {
+		def := &parser.SORDefinition{
+			DisplayName: "Test SOR",
+			Description: "Test Description",
+			Entities: map[string]parser.Entity{
+				"user": {
+					DisplayName: "User",
+					ExternalId:  "User",
+					Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+						{Name: "roleId", ExternalId: "roleId", Type: "String"},
+					},
+				},
+				"role": {
+					DisplayName: "Role",
+					ExternalId:  "Role",
+					Attributes: []parser.Attribute{
+						{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					},
+				},
+			},
+			Relationships: map[string]parser.Relationship{
+				"user_role": {
+					DisplayName:   "User Role",
+					Name:          "user_role",
+					FromAttribute: "User.roleId",
+					ToAttribute:   "Role.id",
+				},
+			},
+		}
+
+		tempDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, "User.csv"), []byte("id,roleId\nuser-1,role-999\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, "Role.csv"), []byte("id\nrole-1\n"), 0644))
+
+		result, err := RunValidation(def, tempDir, ValidationOptions{
+			RelationshipValidation: map[string]string{"user_role": "warn"},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, result.ValidationErrors)
+		assert.NotEmpty(t, result.ValidationWarnings)
+		assert.Empty(t, def.Relationships["user_role"].Validation, "Should not modify the caller's definition")
+
+		_, err = RunValidation(def, tempDir, ValidationOptions{
+			RelationshipValidation: map[string]string{"unknown": "skip"},
+		})
+		assert.ErrorContains(t, err, "unknown relationship: unknown")
+	})
 }
 
 // Helper function for string contains check
 func contains(s, substr string) bool {
 	return strings.Contains(s, substr)
 }
+
+func TestRunValidation_EncryptedFiles(t *testing.T) {
+	p := parser.NewParser("../../examples/okta.sgnl.yaml")
+	require.NoError(t, p.Parse())
+	encryptor, err := encryption.NewEncryptor("s3cret")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	_, err = RunGeneration(p.Definition, dir, GenerationOptions{DataVolume: 5, Encryptor: encryptor})
+	require.NoError(t, err)
+
+	decryptor, err := encryption.NewDecryptor("s3cret")
+	require.NoError(t, err)
+	for _, streaming := range []bool{false, true} {
+		result, err := RunValidation(p.Definition, dir, ValidationOptions{Decryptor: decryptor, Streaming: streaming, Workers: 4})
+		require.NoError(t, err)
+		assert.Empty(t, result.ValidationErrors, "streaming: %t", streaming)
+		assert.Equal(t, len(p.Definition.Entities), result.FilesValidated)
+		assert.Equal(t, 5*len(p.Definition.Entities), result.RecordsValidated)
+	}
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		wrong, err := encryption.NewDecryptor("other")
+		require.NoError(t, err)
+		result, err := RunValidation(p.Definition, dir, ValidationOptions{Decryptor: wrong})
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.ValidationErrors)
+	})
+
+	t.Run("files aren't found without decryption", func(t *testing.T) {
+		result, err := RunValidation(p.Definition, dir, ValidationOptions{})
+		require.NoError(t, err)
+		assert.Contains(t, strings.Join(result.ValidationErrors, "\n"), "CSV file not found")
+	})
+
+	t.Run("nothing is written in the clear", func(t *testing.T) {
+		_, err := RunValidation(p.Definition, dir, ValidationOptions{Decryptor: decryptor, Cache: filepath.Join(t.TempDir(), "cache.json")})
+		assert.ErrorContains(t, err, "validation cache can't be kept for encrypted files")
+		_, err = RunValidation(p.Definition, dir, ValidationOptions{Decryptor: decryptor, DedupeOutput: t.TempDir()})
+		assert.ErrorContains(t, err, "deduplicated copy can't be written of encrypted files")
+	})
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// validateAttributeAliases checks that every attributeAlias resolves to one
+// attribute: no two attributes share an alias, no alias is also another attribute's
+// Entity.attribute reference, and none is another attribute's externalId in its own
+// entity, where attributes are looked up by either. Relationships using an ambiguous
+// alias are reported too. All issues are returned together in an AliasIssuesError.
+func validateAttributeAliases(def *SORDefinition) error {
+	entityIDs := make([]string, 0, len(def.Entities))
+	for id := range def.Entities {
+		entityIDs = append(entityIDs, id)
+	}
+	sort.Strings(entityIDs)
+
+	// Attributes using each alias, and every Entity.attribute reference
+	aliases := make(map[string][]string)
+	references := make(map[string]bool)
+	var issues []string
+	for _, id := range entityIDs {
+		entity := def.Entities[id]
+		externalIDs := make(map[string]string, len(entity.Attributes))
+		for _, attr := range entity.Attributes {
+			externalIDs[attr.ExternalId] = entity.ExternalId + "." + attr.ExternalId
+			references[entity.ExternalId+"."+attr.ExternalId] = true
+		}
+		for _, attr := range entity.Attributes {
+			if attr.AttributeAlias == "" {
+				continue
+			}
+			attribute := entity.ExternalId + "." + attr.ExternalId
+			aliases[attr.AttributeAlias] = append(aliases[attr.AttributeAlias], attribute)
+			if other, exists := externalIDs[attr.AttributeAlias]; exists && other != attribute {
+				issues = append(issues, fmt.Sprintf("attributeAlias '%s' of %s is the externalId of %s in the same entity",
+					attr.AttributeAlias, attribute, other))
+			}
+		}
+	}
+
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+
+	ambiguous := make(map[string][]string)
+	for _, alias := range names {
+		attributes := aliases[alias]
+		if len(attributes) > 1 {
+			issues = append(issues, fmt.Sprintf("attributeAlias '%s' is used by %s", alias, strings.Join(attributes, " and ")))
+			ambiguous[alias] = attributes
+		}
+		// An alias may repeat its own attribute's reference, but not another's
+		if references[alias] && (len(attributes) > 1 || attributes[0] != alias) {
+			var others []string
+			for _, attribute := range attributes {
+				if attribute != alias {
+					others = append(others, attribute)
+				}
+			}
+			issues = append(issues, fmt.Sprintf("attributeAlias '%s' of %s is also the Entity.attribute reference of %s",
+				alias, strings.Join(others, " and "), alias))
+			if len(others) == len(attributes) {
+				ambiguous[alias] = append(others, alias)
+			}
+		}
+	}
+
+	relationshipIDs := make([]string, 0, len(def.Relationships))
+	for id := range def.Relationships {
+		relationshipIDs = append(relationshipIDs, id)
+	}
+	sort.Strings(relationshipIDs)
+	for _, id := range relationshipIDs {
+		rel := def.Relationships[id]
+		fields := []struct{ name, reference string }{{"fromAttribute", rel.FromAttribute}}
+		if rel.ExternalDirectory == "" {
+			fields = append(fields, struct{ name, reference string }{"toAttribute", rel.ToAttribute})
+		}
+		for _, field := range fields {
+			if attributes, exists := ambiguous[field.reference]; exists {
+				issues = append(issues, fmt.Sprintf("relationship %s: %s '%s' is ambiguous, matching %s",
+					id, field.name, field.reference, strings.Join(attributes, " and ")))
+			}
+		}
+	}
+
+	if len(issues) > 0 {
+		return &AliasIssuesError{Issues: issues}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAttributeAliases(t *testing.T) {
+	// Users and groups, each with a key and a name, aliased as given
+	definition := func(userAliases, groupAliases [2]string, relationships map[string]Relationship) *SORDefinition {
+		entity := func(externalID string, aliases [2]string) Entity {
+			return Entity{
+				ExternalId: externalID,
+				Attributes: []Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true, AttributeAlias: aliases[0]},
+					{Name: "name", ExternalId: "name", Type: "String", AttributeAlias: aliases[1]},
+				},
+			}
+		}
+		return &SORDefinition{
+			Entities:      map[string]Entity{"user": entity("User", userAliases), "group": entity("Group", groupAliases)},
+			Relationships: relationships,
+		}
+	}
+	member := map[string]Relationship{"member": {FromAttribute: "a-user", ToAttribute: "a-group"}}
+
+	tests := []struct {
+		name       string
+		def        *SORDefinition
+		wantIssues []string
+	}{
+		{name: "no aliases", def: definition([2]string{}, [2]string{}, nil)},
+		{name: "distinct aliases", def: definition([2]string{"a-user", "a-name"}, [2]string{"a-group", ""}, member)},
+		{name: "alias repeating its own reference", def: definition([2]string{"User.id", ""}, [2]string{}, nil)},
+		{name: "alias of two entities",
+			def: definition([2]string{"a-user", ""}, [2]string{"a-user", "a-group"}, member),
+			wantIssues: []string{
+				"attributeAlias 'a-user' is used by Group.id and User.id",
+				"relationship member: fromAttribute 'a-user' is ambiguous, matching Group.id and User.id",
+			}},
+		{name: "alias of two attributes of an entity",
+			def:        definition([2]string{"a-user", "a-user"}, [2]string{}, nil),
+			wantIssues: []string{"attributeAlias 'a-user' is used by User.id and User.name"}},
+		{name: "alias that is another attribute's reference",
+			def: definition([2]string{"a-user", ""}, [2]string{"User.id", ""},
+				map[string]Relationship{"member": {FromAttribute: "Group.name", ToAttribute: "User.id"}}),
+			wantIssues: []string{
+				"attributeAlias 'User.id' of Group.id is also the Entity.attribute reference of User.id",
+				"relationship member: toAttribute 'User.id' is ambiguous, matching Group.id and User.id",
+			}},
+		{name: "alias that is another attribute's externalId",
+			def:        definition([2]string{"", "id"}, [2]string{}, nil),
+			wantIssues: []string{"attributeAlias 'id' of User.name is the externalId of User.id in the same entity"}},
+		{name: "every issue at once",
+			def: definition([2]string{"a-user", "id"}, [2]string{"a-user", ""}, nil),
+			wantIssues: []string{
+				"attributeAlias 'id' of User.name is the externalId of User.id in the same entity",
+				"attributeAlias 'a-user' is used by Group.id and User.id",
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAttributeAliases(tt.def)
+			if tt.wantIssues == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var issues *AliasIssuesError
+			require.ErrorAs(t, err, &issues)
+			assert.Equal(t, tt.wantIssues, issues.Issues)
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// expandClones replaces every entity declared with cloneOf by a full definition.
+// A clone starts from a copy of its source entity's attributes, drops those named in
+// removeAttributes, then applies its own attributes: an attribute with the same name
+// as a cloned one replaces it in place, any other is appended. Copied attributes lose
+// their attributeAlias, which must stay unique to one attribute. Unset descriptive
+// fields and correlations are inherited. Clones of clones are expanded in order.
+func expandClones(def *SORDefinition) error {
+	if def == nil {
+		return nil
+	}
+
+	expanded := make(map[string]bool)
+	inProgress := make(map[string]bool)
+
+	var expand func(id string, chain []string) error
+	expand = func(id string, chain []string) error {
+		entity := def.Entities[id]
+		if entity.CloneOf == "" || expanded[id] {
+			return nil
+		}
+		if inProgress[id] {
+			return fmt.Errorf("entity clone cycle: %s", strings.Join(append(chain, id), " -> "))
+		}
+
+		if _, exists := def.Entities[entity.CloneOf]; !exists {
+			return fmt.Errorf("entity %s is a clone of unknown entity '%s'", id, entity.CloneOf)
+		}
+
+		inProgress[id] = true
+		if err := expand(entity.CloneOf, append(chain, id)); err != nil {
+			return err
+		}
+		delete(inProgress, id)
+
+		clone, err := cloneEntity(def.Entities[entity.CloneOf], entity)
+		if err != nil {
+			return fmt.Errorf("entity %s: %w", id, err)
+		}
+		def.Entities[id] = clone
+		expanded[id] = true
+		return nil
+	}
+
+	// Expand in a stable order so error messages are deterministic
+	ids := make([]string, 0, len(def.Entities))
+	for id := range def.Entities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if err := expand(id, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cloneEntity builds the expanded definition of overrides applied on top of source
+func cloneEntity(source, overrides Entity) (Entity, error) {
+	result := overrides
+
+	// Copy source attributes, dropping removed ones
+	removed := make(map[string]bool, len(overrides.RemoveAttributes))
+	for _, name := range overrides.RemoveAttributes {
+		removed[name] = true
+	}
+	attributes := make([]Attribute, 0, len(source.Attributes)+len(overrides.Attributes))
+	position := make(map[string]int, len(source.Attributes))
+	for _, attr := range source.Attributes {
+		if removed[attr.Name] {
+			delete(removed, attr.Name)
+			continue
+		}
+		// Aliases identify a single attribute across the SOR, so copies don't keep them
+		attr.AttributeAlias = ""
+		position[attr.Name] = len(attributes)
+		attributes = append(attributes, attr)
+	}
+	if len(removed) > 0 {
+		names := make([]string, 0, len(removed))
+		for name := range removed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return Entity{}, fmt.Errorf("cannot remove attributes not present in '%s': %s",
+			overrides.CloneOf, strings.Join(names, ", "))
+	}
+
+	// Apply added and overridden attributes
+	for _, attr := range overrides.Attributes {
+		if i, exists := position[attr.Name]; exists {
+			attributes[i] = attr
+			continue
+		}
+		position[attr.Name] = len(attributes)
+		attributes = append(attributes, attr)
+	}
+	result.Attributes = attributes
+
+	// Inherit unset descriptive fields
+	if result.Description == "" {
+		result.Description = source.Description
+	}
+	if !result.PagesOrderedById {
+		result.PagesOrderedById = source.PagesOrderedById
+	}
+	if result.SyncFrequency == "" {
+		result.SyncFrequency = source.SyncFrequency
+	}
+	if result.SyncMinInterval == 0 {
+		result.SyncMinInterval = source.SyncMinInterval
+	}
+	if result.ApiCallFrequency == "" {
+		result.ApiCallFrequency = source.ApiCallFrequency
+	}
+	if result.ApiCallMinInterval == 0 {
+		result.ApiCallMinInterval = source.ApiCallMinInterval
+	}
+	if result.Domain == "" {
+		result.Domain = source.Domain
+	}
+	if result.Tags == nil {
+		result.Tags = append([]string(nil), source.Tags...)
+	}
+	if result.Payloads == nil {
+		result.Payloads = source.Payloads
+	}
+	if result.Junction == nil {
+		result.Junction = source.Junction
+	}
+	if result.Derived == nil {
+		result.Derived = source.Derived
+	}
+	if result.PartitionBy == "" {
+		if _, exists := position[source.PartitionBy]; exists {
+			result.PartitionBy = source.PartitionBy
+		}
+	}
+	if result.Timeline == nil && source.Timeline != nil {
+		if _, exists := position[source.Timeline.Attribute]; exists {
+			result.Timeline = source.Timeline
+		}
+	}
+	// Profiles are resolved against the SOR's directory after expansion, so the
+	// clone's inherited path is resolved like the source's
+	if result.Profile == "" && len(result.Data) == 0 && result.Derived == nil {
+		result.Profile = source.Profile
+	}
+	if result.EffectiveDating == nil && source.EffectiveDating != nil {
+		dating, kept := source.EffectiveDating, true
+		for _, name := range []string{dating.Key, dating.ValidFrom, dating.ValidTo, dating.Current} {
+			if _, exists := position[name]; name != "" && !exists {
+				kept = false
+			}
+		}
+		if kept {
+			result.EffectiveDating = dating
+		}
+	}
+
+	// Inherit correlations whose attributes survived the clone
+	if result.Correlations == nil {
+		for _, correlation := range source.Correlations {
+			kept := true
+			for _, name := range correlation.Attributes {
+				if _, exists := position[name]; !exists {
+					kept = false
+				}
+			}
+			if kept {
+				result.Correlations = append(result.Correlations, correlation)
+			}
+		}
+	}
+
+	return result, nil
+}
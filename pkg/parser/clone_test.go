@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cloneSourceEntity() Entity {
+	return Entity{
+		DisplayName:   "User",
+		ExternalId:    "User",
+		Description:   "Active users",
+		SyncFrequency: "DAILY",
+		Attributes: []Attribute{
+			{Name: "id", ExternalId: "id", Type: "String", UniqueId: true, AttributeAlias: "user-id-alias"},
+			{Name: "email", ExternalId: "email", Type: "String"},
+			{Name: "lastLogin", ExternalId: "lastLogin", Type: "DateTime"},
+			{Name: "tenure", ExternalId: "tenure", Type: "Integer"},
+			{Name: "salary", ExternalId: "salary", Type: "Float"},
+		},
+		Correlations: []Correlation{{Attributes: []string{"tenure", "salary"}, Coefficient: 0.5}},
+	}
+}
+
+func attributeNames(entity Entity) []string {
+	names := make([]string, 0, len(entity.Attributes))
+	for _, attr := range entity.Attributes {
+		names = append(names, attr.Name)
+	}
+	return names
+}
+
+func TestExpandClones(t *testing.T) {
+	t.Run("Copies, removes, overrides and adds attributes", func(t *testing.T) {
+		def := &SORDefinition{Entities: map[string]Entity{
+			"user": cloneSourceEntity(),
+			"archivedUser": {
+				CloneOf:          "user",
+				DisplayName:      "ArchivedUser",
+				ExternalId:       "ArchivedUser",
+				RemoveAttributes: []string{"lastLogin"},
+				Attributes: []Attribute{
+					{Name: "email", ExternalId: "archivedEmail", Type: "String"},
+					{Name: "archivedAt", ExternalId: "archivedAt", Type: "DateTime"},
+				},
+			},
+		}}
+
+		require.NoError(t, expandClones(def))
+
+		clone := def.Entities["archivedUser"]
+		assert.Equal(t, []string{"id", "email", "tenure", "salary", "archivedAt"}, attributeNames(clone))
+		assert.Equal(t, "archivedEmail", clone.Attributes[1].ExternalId, "overrides replace cloned attributes in place")
+		assert.Empty(t, clone.Attributes[0].AttributeAlias, "copied attributes drop their alias")
+		assert.Equal(t, "user-id-alias", def.Entities["user"].Attributes[0].AttributeAlias, "source is unchanged")
+		assert.Equal(t, "ArchivedUser", clone.ExternalId)
+		assert.Equal(t, "Active users", clone.Description, "unset fields are inherited")
+		assert.Equal(t, "DAILY", clone.SyncFrequency)
+		assert.Len(t, clone.Correlations, 1)
+	})
+
+	t.Run("Drops correlations on removed attributes", func(t *testing.T) {
+		def := &SORDefinition{Entities: map[string]Entity{
+			"user":    cloneSourceEntity(),
+			"contact": {CloneOf: "user", DisplayName: "Contact", ExternalId: "Contact", RemoveAttributes: []string{"salary"}},
+		}}
+
+		require.NoError(t, expandClones(def))
+		assert.Empty(t, def.Entities["contact"].Correlations)
+	})
+
+	t.Run("Expands clones of clones", func(t *testing.T) {
+		def := &SORDefinition{Entities: map[string]Entity{
+			"user":     cloneSourceEntity(),
+			"archived": {CloneOf: "user", DisplayName: "Archived", ExternalId: "Archived", RemoveAttributes: []string{"lastLogin"}},
+			"purged":   {CloneOf: "archived", DisplayName: "Purged", ExternalId: "Purged", RemoveAttributes: []string{"email"}},
+		}}
+
+		require.NoError(t, expandClones(def))
+		assert.Equal(t, []string{"id", "tenure", "salary"}, attributeNames(def.Entities["purged"]))
+	})
+
+	t.Run("Inherits effective dating while its attributes survive", func(t *testing.T) {
+		source := cloneSourceEntity()
+		source.Attributes = append(source.Attributes,
+			Attribute{Name: "validFrom", ExternalId: "validFrom", Type: "Date"},
+			Attribute{Name: "validTo", ExternalId: "validTo", Type: "Date"})
+		source.EffectiveDating = &EffectiveDating{Key: "email", ValidFrom: "validFrom", ValidTo: "validTo", From: "2020-01-01", To: "2024-01-01"}
+		def := &SORDefinition{Entities: map[string]Entity{
+			"user":    source,
+			"history": {CloneOf: "user", DisplayName: "History", ExternalId: "History"},
+			"undated": {CloneOf: "user", DisplayName: "Undated", ExternalId: "Undated", RemoveAttributes: []string{"validTo"}},
+		}}
+
+		require.NoError(t, expandClones(def))
+		assert.Equal(t, source.EffectiveDating, def.Entities["history"].EffectiveDating)
+		assert.Nil(t, def.Entities["undated"].EffectiveDating, "dating on a removed attribute is dropped")
+	})
+
+	tests := []struct {
+		name     string
+		entities map[string]Entity
+		wantErr  string
+	}{
+		{
+			name: "Unknown source",
+			entities: map[string]Entity{
+				"copy": {CloneOf: "missing", DisplayName: "Copy", ExternalId: "Copy"},
+			},
+			wantErr: "unknown entity 'missing'",
+		},
+		{
+			name: "Cycle",
+			entities: map[string]Entity{
+				"a": {CloneOf: "b", DisplayName: "A", ExternalId: "A"},
+				"b": {CloneOf: "a", DisplayName: "B", ExternalId: "B"},
+			},
+			wantErr: "clone cycle",
+		},
+		{
+			name: "Removing a missing attribute",
+			entities: map[string]Entity{
+				"user": cloneSourceEntity(),
+				"copy": {CloneOf: "user", DisplayName: "Copy", ExternalId: "Copy", RemoveAttributes: []string{"nickname"}},
+			},
+			wantErr: "nickname",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := expandClones(&SORDefinition{Entities: tt.entities})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestParseClonedEntity(t *testing.T) {
+	yamlContent := `displayName: Clone SOR
+description: SOR with a cloned entity
+entities:
+  user:
+    displayName: User
+    externalId: User
+    attributes:
+      - name: id
+        externalId: id
+        type: String
+        uniqueId: true
+      - name: lastLogin
+        externalId: lastLogin
+        type: DateTime
+  archivedUser:
+    displayName: ArchivedUser
+    externalId: ArchivedUser
+    cloneOf: user
+    removeAttributes: [lastLogin]
+`
+	path := filepath.Join(t.TempDir(), "sor.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(yamlContent), 0600))
+
+	parser := NewParser(path)
+	require.NoError(t, parser.Parse())
+	assert.Equal(t, []string{"id"}, attributeNames(parser.Definition.Entities["archivedUser"]))
+}
@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Aggregate functions of derived entities
+const (
+	AggregateCount = "count"
+	AggregateSum   = "sum"
+	AggregateMin   = "min"
+	AggregateMax   = "max"
+)
+
+// validateDerived checks that each derived entity computes every attribute from
+// generated entities: its key holds the values of an existing From attribute and its
+// other attributes are aggregates over attributes referencing them. Derived entities
+// are computed last, so nothing may be derived from them or reference them.
+func validateDerived(def *SORDefinition) error {
+	byExternalID := make(map[string]Entity, len(def.Entities))
+	for _, entity := range def.Entities {
+		byExternalID[entity.ExternalId] = entity
+	}
+
+	// Check entities in order so the first error is the same on every run
+	ids := make([]string, 0, len(def.Entities))
+	for id := range def.Entities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		entity := def.Entities[id]
+		derived := entity.Derived
+		if derived == nil {
+			continue
+		}
+		switch {
+		case len(entity.Data) > 0:
+			return fmt.Errorf("entity %s cannot be derived and have inline data", id)
+		case entity.Payloads != nil || entity.Timeline != nil || entity.Junction != nil || len(entity.Correlations) > 0:
+			return fmt.Errorf("entity %s is derived, so its rows cannot have payloads, a timeline, junction options or correlations", id)
+		}
+
+		if _, _, err := derivedReference(byExternalID, derived.From); err != nil {
+			return fmt.Errorf("entity %s derived from: %w", id, err)
+		}
+
+		computed := make(map[string]bool, len(entity.Attributes))
+		attributes := make(map[string]Attribute, len(entity.Attributes))
+		for _, attr := range entity.Attributes {
+			attributes[attr.Name] = attr
+		}
+		if key, exists := attributes[derived.Key]; !exists || !key.UniqueId {
+			return fmt.Errorf("entity %s derived key '%s' must be its uniqueId attribute", id, derived.Key)
+		}
+		computed[derived.Key] = true
+
+		for _, aggregate := range derived.Aggregates {
+			if _, exists := attributes[aggregate.Attribute]; !exists {
+				return fmt.Errorf("entity %s aggregate sets unknown attribute '%s'", id, aggregate.Attribute)
+			}
+			if computed[aggregate.Attribute] {
+				return fmt.Errorf("entity %s computes attribute '%s' twice", id, aggregate.Attribute)
+			}
+			computed[aggregate.Attribute] = true
+
+			over, _, err := derivedReference(byExternalID, aggregate.Over)
+			if err != nil {
+				return fmt.Errorf("entity %s aggregate '%s' over: %w", id, aggregate.Attribute, err)
+			}
+			switch aggregate.Function {
+			case AggregateCount:
+				if aggregate.Value != "" {
+					return fmt.Errorf("entity %s aggregate '%s' counts rows, so it takes no value", id, aggregate.Attribute)
+				}
+			case AggregateSum, AggregateMin, AggregateMax:
+				value, exists := attributeNamed(over, aggregate.Value)
+				if !exists {
+					return fmt.Errorf("entity %s aggregate '%s' needs the value attribute of %s to %s, got '%s'",
+						id, aggregate.Attribute, over.ExternalId, aggregate.Function, aggregate.Value)
+				}
+				if aggregate.Function == AggregateSum && !IsNumericType(value.Type) {
+					return fmt.Errorf("entity %s aggregate '%s' cannot sum %s attribute '%s'",
+						id, aggregate.Attribute, value.Type, value.Name)
+				}
+			default:
+				return fmt.Errorf("entity %s aggregate '%s' has unknown function '%s' (expected count, sum, min or max)",
+					id, aggregate.Attribute, aggregate.Function)
+			}
+		}
+
+		for _, attr := range entity.Attributes {
+			if !computed[attr.Name] {
+				return fmt.Errorf("entity %s is derived but attribute '%s' is neither its key nor an aggregate", id, attr.Name)
+			}
+		}
+	}
+
+	for id, rel := range def.Relationships {
+		entityID, _, found := strings.Cut(rel.ToAttribute, ".")
+		if target, exists := byExternalID[entityID]; found && exists && target.Derived != nil {
+			return fmt.Errorf("relationship %s references derived entity %s, whose rows are computed last", id, entityID)
+		}
+	}
+	return nil
+}
+
+// derivedReference resolves an Entity.attribute reference by externalIds to an
+// entity that isn't derived itself and the attribute
+func derivedReference(byExternalID map[string]Entity, reference string) (Entity, Attribute, error) {
+	entityID, attrID, found := strings.Cut(reference, ".")
+	if !found {
+		return Entity{}, Attribute{}, fmt.Errorf("'%s' must be Entity.attribute", reference)
+	}
+	entity, exists := byExternalID[entityID]
+	if !exists {
+		return Entity{}, Attribute{}, fmt.Errorf("'%s' references unknown entity %s", reference, entityID)
+	}
+	if entity.Derived != nil {
+		return Entity{}, Attribute{}, fmt.Errorf("'%s' references derived entity %s", reference, entityID)
+	}
+	for _, attr := range entity.Attributes {
+		if attr.ExternalId == attrID {
+			return entity, attr, nil
+		}
+	}
+	return Entity{}, Attribute{}, fmt.Errorf("'%s' references unknown attribute %s of %s", reference, attrID, entityID)
+}
+
+// attributeNamed finds an entity's attribute by name
+func attributeNamed(entity Entity, name string) (Attribute, bool) {
+	for _, attr := range entity.Attributes {
+		if attr.Name == name {
+			return attr, true
+		}
+	}
+	return Attribute{}, false
+}
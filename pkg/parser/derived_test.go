@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateDerived(t *testing.T) {
+	definition := func(derived *Derived, extra ...Attribute) *SORDefinition {
+		return &SORDefinition{
+			Entities: map[string]Entity{
+				"user": {ExternalId: "User", Attributes: []Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				}},
+				"order": {ExternalId: "Order", Attributes: []Attribute{
+					{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+					{Name: "userId", ExternalId: "userId", Type: "String"},
+					{Name: "amount", ExternalId: "amount", Type: "Int64"},
+				}},
+				"summary": {ExternalId: "UserSummary", Attributes: append([]Attribute{
+					{Name: "userId", ExternalId: "userId", Type: "String", UniqueId: true},
+					{Name: "orders", ExternalId: "orders", Type: "Int64"},
+				}, extra...), Derived: derived},
+			},
+		}
+	}
+	orders := Aggregate{Attribute: "orders", Function: AggregateCount, Over: "Order.userId"}
+
+	tests := []struct {
+		name    string
+		def     *SORDefinition
+		wantErr string
+	}{
+		{name: "count", def: definition(&Derived{From: "User.id", Key: "userId", Aggregates: []Aggregate{orders}})},
+		{name: "sum", def: definition(&Derived{From: "User.id", Key: "userId", Aggregates: []Aggregate{
+			{Attribute: "orders", Function: AggregateSum, Over: "Order.userId", Value: "amount"}}})},
+		{name: "unknown from entity", def: definition(&Derived{From: "Account.id", Key: "userId", Aggregates: []Aggregate{orders}}),
+			wantErr: "entity summary derived from: 'Account.id' references unknown entity Account"},
+		{name: "key isn't the unique ID", def: definition(&Derived{From: "User.id", Key: "orders"}),
+			wantErr: "entity summary derived key 'orders' must be its uniqueId attribute"},
+		{name: "attribute left out", def: definition(&Derived{From: "User.id", Key: "userId"}),
+			wantErr: "entity summary is derived but attribute 'orders' is neither its key nor an aggregate"},
+		{name: "count with a value", def: definition(&Derived{From: "User.id", Key: "userId", Aggregates: []Aggregate{
+			{Attribute: "orders", Function: AggregateCount, Over: "Order.userId", Value: "amount"}}}),
+			wantErr: "aggregate 'orders' counts rows, so it takes no value"},
+		{name: "sum of strings", def: definition(&Derived{From: "User.id", Key: "userId", Aggregates: []Aggregate{
+			{Attribute: "orders", Function: AggregateSum, Over: "Order.userId", Value: "userId"}}}),
+			wantErr: "aggregate 'orders' cannot sum String attribute 'userId'"},
+		{name: "max without a value", def: definition(&Derived{From: "User.id", Key: "userId", Aggregates: []Aggregate{
+			{Attribute: "orders", Function: AggregateMax, Over: "Order.userId"}}}),
+			wantErr: "aggregate 'orders' needs the value attribute of Order to max"},
+		{name: "unknown function", def: definition(&Derived{From: "User.id", Key: "userId", Aggregates: []Aggregate{
+			{Attribute: "orders", Function: "avg", Over: "Order.userId"}}}),
+			wantErr: "unknown function 'avg'"},
+		{name: "referenced by a relationship", def: func() *SORDefinition {
+			def := definition(&Derived{From: "User.id", Key: "userId", Aggregates: []Aggregate{orders}})
+			def.Relationships = map[string]Relationship{
+				"order_summary": {FromAttribute: "Order.userId", ToAttribute: "UserSummary.userId"},
+			}
+			return def
+		}(), wantErr: "relationship order_summary references derived entity UserSummary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDerived(tt.def)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DistinctDisplayNames returns a display name per entity key that no other entity
+// shares. Entities whose display name is unique keep it. When several share one
+// (common in merged catalogs), each is qualified by its external ID namespace,
+// e.g. "User (Okta)", or by its entity key when the namespaces don't tell them
+// apart. Entities without a display name use their external ID without namespace.
+func DistinctDisplayNames(entities map[string]Entity) map[string]string {
+	keys := make([]string, 0, len(entities))
+	for key := range entities {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	base := make(map[string]string, len(keys))
+	shared := make(map[string][]string)
+	for _, key := range keys {
+		base[key] = entityBaseName(entities[key])
+		shared[base[key]] = append(shared[base[key]], key)
+	}
+
+	names := make(map[string]string, len(keys))
+	used := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if len(shared[base[key]]) == 1 {
+			names[key] = base[key]
+			used[base[key]] = true
+		}
+	}
+
+	for _, key := range keys {
+		group := shared[base[key]]
+		if len(group) == 1 {
+			continue
+		}
+
+		qualifier := key
+		if namespace := entityNamespace(entities[key]); namespace != "" {
+			sameNamespace := 0
+			for _, other := range group {
+				if entityNamespace(entities[other]) == namespace {
+					sameNamespace++
+				}
+			}
+			if sameNamespace == 1 {
+				qualifier = namespace
+			}
+		}
+
+		name := fmt.Sprintf("%s (%s)", base[key], qualifier)
+		if used[name] {
+			name = fmt.Sprintf("%s (%s)", base[key], key)
+		}
+		// A literal display name can still match a qualified one; number the rest
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s (%s %d)", base[key], key, n)
+		}
+		names[key] = name
+		used[name] = true
+	}
+	return names
+}
+
+// entityBaseName returns the entity's display name, falling back to its external ID
+// without namespace
+func entityBaseName(entity Entity) string {
+	if entity.DisplayName != "" {
+		return entity.DisplayName
+	}
+	return entity.ExternalId[strings.LastIndex(entity.ExternalId, "/")+1:]
+}
+
+// entityNamespace returns the namespace prefix of the entity's external ID
+// ("Okta" for "Okta/User"), or "" when it has none
+func entityNamespace(entity Entity) string {
+	if i := strings.LastIndex(entity.ExternalId, "/"); i > 0 {
+		return entity.ExternalId[:i]
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistinctDisplayNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		entities map[string]Entity
+		want     map[string]string
+	}{
+		{
+			name: "Unique names are kept",
+			entities: map[string]Entity{
+				"user":  {DisplayName: "User", ExternalId: "Okta/User"},
+				"group": {DisplayName: "Group", ExternalId: "Okta/Group"},
+			},
+			want: map[string]string{"user": "User", "group": "Group"},
+		},
+		{
+			name: "Shared names are qualified by namespace",
+			entities: map[string]Entity{
+				"okta_user":  {DisplayName: "User", ExternalId: "Okta/User"},
+				"azure_user": {DisplayName: "User", ExternalId: "Azure/User"},
+			},
+			want: map[string]string{"okta_user": "User (Okta)", "azure_user": "User (Azure)"},
+		},
+		{
+			name: "Shared names without distinct namespaces are qualified by entity key",
+			entities: map[string]Entity{
+				"user":     {DisplayName: "User", ExternalId: "Okta/User"},
+				"user_v2":  {DisplayName: "User", ExternalId: "Okta/UserV2"},
+				"external": {DisplayName: "User", ExternalId: "ExternalUser"},
+			},
+			want: map[string]string{"user": "User (user)", "user_v2": "User (user_v2)", "external": "User (external)"},
+		},
+		{
+			name: "Missing display names fall back to the external ID",
+			entities: map[string]Entity{
+				"a": {ExternalId: "Okta/User"},
+				"b": {DisplayName: "User", ExternalId: "Azure/User"},
+			},
+			want: map[string]string{"a": "User (Okta)", "b": "User (Azure)"},
+		},
+		{
+			name: "Qualified names never match a literal display name",
+			entities: map[string]Entity{
+				"okta_user":  {DisplayName: "User", ExternalId: "Okta/User"},
+				"azure_user": {DisplayName: "User", ExternalId: "Azure/User"},
+				"literal":    {DisplayName: "User (Okta)", ExternalId: "Legacy"},
+			},
+			want: map[string]string{"okta_user": "User (okta_user)", "azure_user": "User (Azure)", "literal": "User (Okta)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DistinctDisplayNames(tt.entities))
+		})
+	}
+}
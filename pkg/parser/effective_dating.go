@@ -0,0 +1,78 @@
+package parser
+
+import "fmt"
+
+// validateEffectiveDating checks that an entity's effective dating names a record
+// key and distinct, generated Date or DateTime attributes for the window, a Boolean
+// current flag if any, and an ordered range
+func validateEffectiveDating(entityID string, entity Entity) error {
+	dating := entity.EffectiveDating
+	if dating == nil {
+		return nil
+	}
+	if entity.Derived != nil {
+		return fmt.Errorf("entity %s is derived, so it cannot be effective-dated", entityID)
+	}
+
+	attributes := make(map[string]*Attribute, len(entity.Attributes))
+	for i := range entity.Attributes {
+		attributes[entity.Attributes[i].Name] = &entity.Attributes[i]
+	}
+
+	key, exists := attributes[dating.Key]
+	switch {
+	case !exists:
+		return fmt.Errorf("entity %s effectiveDating key references unknown attribute '%s'", entityID, dating.Key)
+	case key.UniqueId || key.List:
+		return fmt.Errorf("entity %s effectiveDating key '%s' cannot be the uniqueId or a list, as a record has several versions",
+			entityID, key.Name)
+	}
+
+	dated := map[string]string{"validFrom": dating.ValidFrom, "validTo": dating.ValidTo}
+	if dating.Current != "" {
+		dated["current"] = dating.Current
+	}
+	for _, field := range []string{"validFrom", "validTo", "current"} {
+		name, set := dated[field]
+		if !set {
+			continue
+		}
+		attr, exists := attributes[name]
+		switch {
+		case !exists:
+			return fmt.Errorf("entity %s effectiveDating %s references unknown attribute '%s'", entityID, field, name)
+		case name == dating.Key:
+			return fmt.Errorf("entity %s effectiveDating %s cannot be the key '%s'", entityID, field, name)
+		case field == "current" && attr.Type != "Boolean" && attr.Type != "Bool":
+			return fmt.Errorf("entity %s effectiveDating current '%s' must be a Boolean, got %s", entityID, name, attr.Type)
+		case field != "current" && attr.Type != "Date" && attr.Type != "DateTime":
+			return fmt.Errorf("entity %s effectiveDating %s '%s' must be a Date or DateTime, got %s", entityID, field, name, attr.Type)
+		case attr.UniqueId || attr.UniqueWithin != "" || attr.List:
+			return fmt.Errorf("entity %s effectiveDating %s '%s' cannot be unique or a list", entityID, field, name)
+		case attr.Generator != nil || attr.Const != nil || attr.Default != nil || attr.Lookup != nil || attr.SharedValues != nil:
+			return fmt.Errorf("entity %s effectiveDating %s '%s' cannot also have a generator, const, default, lookup or shared values",
+				entityID, field, name)
+		case entity.Timeline != nil && entity.Timeline.Attribute == name:
+			return fmt.Errorf("entity %s effectiveDating %s '%s' is already shaped by the timeline", entityID, field, name)
+		}
+	}
+	if dating.ValidFrom == dating.ValidTo {
+		return fmt.Errorf("entity %s effectiveDating validFrom and validTo must be different attributes", entityID)
+	}
+
+	from, err := ParseTimelineTime(dating.From)
+	if err != nil {
+		return fmt.Errorf("entity %s effectiveDating from: %w", entityID, err)
+	}
+	to, err := ParseTimelineTime(dating.To)
+	if err != nil {
+		return fmt.Errorf("entity %s effectiveDating to: %w", entityID, err)
+	}
+	if !from.Before(to) {
+		return fmt.Errorf("entity %s effectiveDating from %s must be before to %s", entityID, dating.From, dating.To)
+	}
+	if dating.MaxOverlap < 0 {
+		return fmt.Errorf("entity %s effectiveDating maxOverlap must be at least 1, got %d", entityID, dating.MaxOverlap)
+	}
+	return nil
+}
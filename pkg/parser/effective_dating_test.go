@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateEffectiveDating(t *testing.T) {
+	// Job rows are versions of each employee's job, current as of the end of 2024
+	entity := func(dating EffectiveDating, extra ...Attribute) Entity {
+		return Entity{
+			ExternalId:      "Job",
+			EffectiveDating: &dating,
+			Attributes: append([]Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "employeeId", ExternalId: "employeeId", Type: "String"},
+				{Name: "validFrom", ExternalId: "validFrom", Type: "Date"},
+				{Name: "validTo", ExternalId: "validTo", Type: "DateTime"},
+				{Name: "isCurrent", ExternalId: "isCurrent", Type: "Boolean"},
+				{Name: "title", ExternalId: "title", Type: "String"},
+			}, extra...),
+		}
+	}
+	valid := EffectiveDating{Key: "employeeId", ValidFrom: "validFrom", ValidTo: "validTo", Current: "isCurrent",
+		From: "2020-01-01", To: "2024-12-31"}
+	with := func(change func(*EffectiveDating)) EffectiveDating {
+		dating := valid
+		change(&dating)
+		return dating
+	}
+
+	tests := []struct {
+		name    string
+		entity  Entity
+		wantErr string
+	}{
+		{name: "valid", entity: entity(valid)},
+		{name: "overlapping versions", entity: entity(with(func(d *EffectiveDating) { d.MaxOverlap = 2 }))},
+		{name: "without a current flag", entity: entity(with(func(d *EffectiveDating) { d.Current = "" }))},
+		{name: "unknown key",
+			entity:  entity(with(func(d *EffectiveDating) { d.Key = "employee" })),
+			wantErr: "entity job effectiveDating key references unknown attribute 'employee'"},
+		{name: "unique key",
+			entity:  entity(with(func(d *EffectiveDating) { d.Key = "id" })),
+			wantErr: "effectiveDating key 'id' cannot be the uniqueId or a list"},
+		{name: "window of text",
+			entity:  entity(with(func(d *EffectiveDating) { d.ValidTo = "title" })),
+			wantErr: "effectiveDating validTo 'title' must be a Date or DateTime, got String"},
+		{name: "current of text",
+			entity:  entity(with(func(d *EffectiveDating) { d.Current = "title" })),
+			wantErr: "effectiveDating current 'title' must be a Boolean, got String"},
+		{name: "one attribute for both ends",
+			entity:  entity(with(func(d *EffectiveDating) { d.ValidTo = "validFrom" })),
+			wantErr: "effectiveDating validFrom and validTo must be different attributes"},
+		{name: "window with a generator",
+			entity: entity(with(func(d *EffectiveDating) { d.ValidTo = "endsAt" }),
+				Attribute{Name: "endsAt", ExternalId: "endsAt", Type: "Date", Generator: &Generator{Type: GeneratorSequence}}),
+			wantErr: "effectiveDating validTo 'endsAt' cannot also have a generator"},
+		{name: "reversed range",
+			entity:  entity(with(func(d *EffectiveDating) { d.From, d.To = d.To, d.From })),
+			wantErr: "effectiveDating from 2024-12-31 must be before to 2020-01-01"},
+		{name: "bad date",
+			entity:  entity(with(func(d *EffectiveDating) { d.From = "2020" })),
+			wantErr: "effectiveDating from: '2020' is neither a date"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateEffectiveDating("job", tt.entity)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
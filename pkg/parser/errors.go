@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/console"
+	"github.com/SGNL-ai/fabricator/pkg/errcode"
+)
+
+// Error kinds returned by Parse and the other loaders of the package, matched with
+// errors.Is
+var (
+	ErrReadFile           = errcode.New(errcode.FileUnreadable, "failed to read file")
+	ErrSchemaValidation   = errcode.New(errcode.SchemaInvalid, "schema validation failed")
+	ErrInvalidYAML        = errcode.New(errcode.YAMLInvalid, "failed to parse YAML")
+	ErrVariables          = errcode.New(errcode.DefinitionInvalid, "failed to substitute variables")
+	ErrOverlay            = errcode.New(errcode.DefinitionInvalid, "failed to apply overlay")
+	ErrCloneExpansion     = errcode.New(errcode.DefinitionInvalid, "failed to expand cloned entities")
+	ErrInvalidDefinition  = errcode.New(errcode.DefinitionInvalid, "validation failed")
+	ErrRelationshipIssues = errcode.New(errcode.RelationshipIssues, "relationship issues")
+	ErrSchemaMismatch     = errcode.New(errcode.SchemaMismatch, "SOR differs from the tenant's schema")
+)
+
+// RelationshipIssuesError lists the relationships whose attributes couldn't be
+// resolved. It matches ErrRelationshipIssues.
+type RelationshipIssuesError struct {
+	Issues    []string // One message per invalid relationship
+	Total     int      // Relationships in the definition
+	Direct    int      // Valid direct relationships
+	PathBased int      // Path-based relationships
+}
+
+func (e *RelationshipIssuesError) Error() string {
+	message := fmt.Sprintf("Found %d relationship issues (out of %d total relationships):\n", len(e.Issues), e.Total)
+	for _, issue := range e.Issues {
+		message += console.Text("• ") + issue + "\n"
+	}
+	message += fmt.Sprintf("\nValid relationships: %d direct, %d path-based", e.Direct, e.PathBased)
+	return message
+}
+
+func (e *RelationshipIssuesError) Unwrap() error {
+	return ErrRelationshipIssues
+}
+
+// AliasIssuesError lists the attributeAlias values that don't resolve to a single
+// attribute, and the relationships using them
+type AliasIssuesError struct {
+	Issues []string // One message per duplicate or colliding alias and per relationship using one
+}
+
+func (e *AliasIssuesError) Error() string {
+	message := fmt.Sprintf("Found %d attributeAlias issues:\n", len(e.Issues))
+	for _, issue := range e.Issues {
+		message += console.Text("• ") + issue + "\n"
+	}
+	return strings.TrimSuffix(message, "\n")
+}
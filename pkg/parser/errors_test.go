@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseErrorKinds(t *testing.T) {
+	dir := t.TempDir()
+	write := func(t *testing.T, name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		err := NewParser(filepath.Join(dir, "missing.yaml")).Parse()
+		assert.ErrorIs(t, err, ErrReadFile)
+		assert.ErrorIs(t, err, os.ErrNotExist, "the cause is kept")
+		assert.Equal(t, errcode.FileUnreadable, errcode.Of(err))
+	})
+
+	t.Run("schema violation", func(t *testing.T) {
+		err := NewParser(write(t, "schema.yaml", "displayName: Test\n")).Parse()
+		assert.ErrorIs(t, err, ErrSchemaValidation)
+		assert.Equal(t, errcode.SchemaInvalid, errcode.Of(err))
+	})
+
+	t.Run("relationship issues", func(t *testing.T) {
+		path := write(t, "relationships.yaml", `displayName: Test
+description: Test
+entities:
+  user:
+    displayName: User
+    externalId: User
+    attributes:
+      - name: id
+        externalId: id
+        type: String
+        uniqueId: true
+relationships:
+  broken:
+    displayName: Broken
+    name: broken
+    fromAttribute: User.managerId
+    toAttribute: User.id
+`)
+		err := NewParser(path).Parse()
+		assert.ErrorIs(t, err, ErrInvalidDefinition)
+		assert.ErrorIs(t, err, ErrRelationshipIssues)
+		assert.Equal(t, errcode.RelationshipIssues, errcode.Of(err), "the most specific kind wins")
+		assert.Contains(t, err.Error(), "validation failed: Found 1 relationship issues")
+
+		var issues *RelationshipIssuesError
+		require.True(t, errors.As(err, &issues))
+		assert.Len(t, issues.Issues, 1)
+		assert.Equal(t, 1, issues.Total)
+	})
+}
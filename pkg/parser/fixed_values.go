@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// validateFixedValues checks that const and default values parse as their
+// attribute's type and aren't combined with settings that generate values
+func validateFixedValues(entityID string, entity Entity) error {
+	correlated := make(map[string]bool)
+	for _, correlation := range entity.Correlations {
+		for _, name := range correlation.Attributes {
+			correlated[name] = true
+		}
+	}
+
+	for _, attr := range entity.Attributes {
+		if attr.Const == nil && attr.Default == nil {
+			continue
+		}
+		if attr.Const != nil && attr.Default != nil {
+			return fmt.Errorf("entity %s attribute '%s' cannot have both const and default", entityID, attr.Name)
+		}
+
+		setting, value := "const", attr.Const
+		if attr.Default != nil {
+			setting, value = "default", attr.Default
+		}
+
+		switch {
+		case attr.UniqueId:
+			return fmt.Errorf("entity %s attribute '%s' is a uniqueId and cannot have a %s value", entityID, attr.Name, setting)
+		case attr.UniqueWithin != "":
+			return fmt.Errorf("entity %s attribute '%s' is unique within '%s' and cannot have a %s value",
+				entityID, attr.Name, attr.UniqueWithin, setting)
+		case attr.Generator != nil:
+			return fmt.Errorf("entity %s attribute '%s' cannot have both a generator and a %s value", entityID, attr.Name, setting)
+		case correlated[attr.Name]:
+			return fmt.Errorf("entity %s attribute '%s' cannot have both a correlation and a %s value", entityID, attr.Name, setting)
+		}
+
+		if err := validateValueForType(attr.Type, *value); err != nil {
+			return fmt.Errorf("entity %s attribute '%s' %s value '%s' is not a valid %s: %w",
+				entityID, attr.Name, setting, *value, attr.Type, err)
+		}
+	}
+	return nil
+}
+
+// validateValueForType checks that a value is written the way generated values of
+// the type are. Empty values are allowed for every type and leave the column blank.
+func validateValueForType(attrType, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	var err error
+	switch attrType {
+	case "Integer":
+		_, err = strconv.ParseInt(value, 10, 32)
+	case "Int64":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "Float", "Double":
+		_, err = strconv.ParseFloat(value, 64)
+	case "Boolean", "Bool":
+		_, err = strconv.ParseBool(value)
+	case "Date":
+		_, err = time.Parse("2006-01-02", value)
+	case "DateTime":
+		_, err = time.Parse(time.RFC3339, value)
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateFixedValues(t *testing.T) {
+	value := func(v string) *string { return &v }
+	entity := func(attr Attribute, correlations ...Correlation) Entity {
+		return Entity{
+			DisplayName: "Account",
+			ExternalId:  "Account",
+			Attributes: []Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "tenantId", ExternalId: "tenantId", Type: "String"},
+				{Name: "score", ExternalId: "score", Type: "Integer"},
+				attr,
+			},
+			Correlations: correlations,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		entity  Entity
+		wantErr string
+	}{
+		{name: "String const", entity: entity(Attribute{Name: "sorType", Type: "String", Const: value("okta")})},
+		{name: "Integer default", entity: entity(Attribute{Name: "risk", Type: "Integer", Default: value("0")})},
+		{name: "Empty const", entity: entity(Attribute{Name: "risk", Type: "Integer", Const: value("")})},
+		{name: "Boolean const", entity: entity(Attribute{Name: "active", Type: "Boolean", Const: value("true")})},
+		{name: "DateTime default", entity: entity(Attribute{Name: "seen", Type: "DateTime", Default: value("2024-01-01T00:00:00Z")})},
+		{
+			name:    "Both const and default",
+			entity:  entity(Attribute{Name: "sorType", Type: "String", Const: value("okta"), Default: value("okta")}),
+			wantErr: "cannot have both const and default",
+		},
+		{
+			name:    "Invalid integer",
+			entity:  entity(Attribute{Name: "risk", Type: "Integer", Default: value("high")}),
+			wantErr: "default value 'high' is not a valid Integer",
+		},
+		{
+			name:    "Invalid date",
+			entity:  entity(Attribute{Name: "joined", Type: "Date", Const: value("01/02/2024")}),
+			wantErr: "const value '01/02/2024' is not a valid Date",
+		},
+		{
+			name:    "Unique ID",
+			entity:  entity(Attribute{Name: "key", Type: "String", UniqueId: true, Const: value("k")}),
+			wantErr: "is a uniqueId and cannot have a const value",
+		},
+		{
+			name:    "Scoped uniqueness",
+			entity:  entity(Attribute{Name: "email", Type: "String", UniqueWithin: "tenantId", Default: value("x")}),
+			wantErr: "unique within 'tenantId' and cannot have a default value",
+		},
+		{
+			name:    "Generator",
+			entity:  entity(Attribute{Name: "ssn", Type: "String", Generator: &Generator{Type: GeneratorSSN}, Const: value("x")}),
+			wantErr: "cannot have both a generator and a const value",
+		},
+		{
+			name: "Correlated attribute",
+			entity: entity(Attribute{Name: "rank", Type: "Integer", Default: value("1")},
+				Correlation{Attributes: []string{"rank", "score"}, Coefficient: 0.5}),
+			wantErr: "cannot have both a correlation and a default value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFixedValues("account", tt.entity)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Built-in attribute value generators selectable with an attribute's generator hint
+const (
+	GeneratorSSN        = "ssn"
+	GeneratorIBAN       = "iban"
+	GeneratorCreditCard = "creditCard"
+	GeneratorNationalID = "nationalId"
+	GeneratorIPv4       = "ipv4"
+	GeneratorIPv6       = "ipv6"
+	GeneratorMAC        = "mac"
+	GeneratorSequence   = "sequence"
+	GeneratorCIDR       = "cidr"
+	GeneratorHostname   = "hostname"
+
+	GeneratorHierarchicalCode = "hierarchicalCode"
+)
+
+// NationalIDLocales lists the locales supported by the nationalId generator
+var NationalIDLocales = []string{"CA", "GB", "IN", "US"}
+
+// Generator selects a built-in value generator for an attribute instead of
+// inferring one from the attribute's name and type
+type Generator struct {
+	Type   string `yaml:"type"`             // Generator name, e.g. ssn or creditCard
+	Locale string `yaml:"locale,omitempty"` // Country code for locale-specific generators (nationalId)
+
+	// Sequence options; start and step default to 1 when omitted from the YAML
+	Start   int64 `yaml:"start,omitempty"`   // First value of the sequence
+	Step    int64 `yaml:"step,omitempty"`    // Increment between consecutive rows
+	Padding int   `yaml:"padding,omitempty"` // Minimum digits, zero-padded (per level for hierarchicalCode)
+
+	// Hierarchical code options; prefix defaults to ORG, branching and depth to 3
+	// and padding to 2 when omitted from the YAML
+	Prefix    string `yaml:"prefix,omitempty"`    // Leading segment of every code
+	Branching int    `yaml:"branching,omitempty"` // Children per code
+	Depth     int    `yaml:"depth,omitempty"`     // Levels per tree, including the top-level code
+
+	// Network options (see network.go); network defaults to 10.0.0.0/8 and
+	// prefixLength to 24 for cidr when omitted from the YAML
+	Network      string `yaml:"network,omitempty"`      // Block cidr carves its blocks from
+	PrefixLength int    `yaml:"prefixLength,omitempty"` // Prefix length of each cidr block
+	Subnet       string `yaml:"subnet,omitempty"`       // CIDR block, or attribute holding one, ipv4 addresses come from
+	Vendor       string `yaml:"vendor,omitempty"`       // Vendor name or OUI (aa:bb:cc) starting mac addresses
+	From         string `yaml:"from,omitempty"`         // Attribute a hostname is derived from, e.g. a device name
+	Domain       string `yaml:"domain,omitempty"`       // Domain appended to hostnames
+}
+
+// UnmarshalYAML accepts either a generator name or a mapping with type and options:
+//
+//	generator: ssn
+//	generator: {type: nationalId, locale: GB}
+//	generator: {type: sequence, start: 1000, step: 10, padding: 8}
+//	generator: {type: hierarchicalCode, prefix: CC, branching: 5, depth: 4}
+//	generator: {type: cidr, network: 10.20.0.0/16, prefixLength: 24}
+func (g *Generator) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		g.Type = value.Value
+	} else {
+		type plain Generator
+		if err := value.Decode((*plain)(g)); err != nil {
+			return err
+		}
+	}
+
+	if g.Type == GeneratorSequence {
+		if !hasMappingKey(value, "start") {
+			g.Start = 1
+		}
+		if !hasMappingKey(value, "step") {
+			g.Step = 1
+		}
+	}
+
+	if g.Type == GeneratorCIDR {
+		if !hasMappingKey(value, "network") {
+			g.Network = "10.0.0.0/8"
+		}
+		if !hasMappingKey(value, "prefixLength") {
+			g.PrefixLength = 24
+		}
+	}
+
+	if g.Type == GeneratorHierarchicalCode {
+		if !hasMappingKey(value, "prefix") {
+			g.Prefix = "ORG"
+		}
+		if !hasMappingKey(value, "branching") {
+			g.Branching = 3
+		}
+		if !hasMappingKey(value, "depth") {
+			g.Depth = 3
+		}
+		if !hasMappingKey(value, "padding") {
+			g.Padding = 2
+		}
+	}
+	return nil
+}
+
+// hasMappingKey reports whether a YAML mapping node sets the given key
+func hasMappingKey(node *yaml.Node, key string) bool {
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return true
+		}
+	}
+	return false
+}
+
+// validateGenerators checks that an entity's generator hints name known generators
+// with valid options and are not applied to correlated attributes
+func validateGenerators(entityID string, entity Entity) error {
+	correlated := make(map[string]bool)
+	for _, correlation := range entity.Correlations {
+		for _, name := range correlation.Attributes {
+			correlated[name] = true
+		}
+	}
+
+	for _, attr := range entity.Attributes {
+		if attr.Generator == nil {
+			continue
+		}
+
+		switch attr.Generator.Type {
+		case GeneratorSSN, GeneratorIBAN, GeneratorCreditCard, GeneratorIPv6:
+		case GeneratorIPv4, GeneratorMAC, GeneratorCIDR, GeneratorHostname:
+			if err := validateNetworkGenerator(entityID, entity, attr); err != nil {
+				return err
+			}
+		case GeneratorSequence:
+			if attr.Generator.Step < 1 {
+				return fmt.Errorf("entity %s attribute '%s' sequence step must be at least 1, got %d",
+					entityID, attr.Name, attr.Generator.Step)
+			}
+			if attr.Generator.Padding < 0 {
+				return fmt.Errorf("entity %s attribute '%s' sequence padding cannot be negative", entityID, attr.Name)
+			}
+			if attr.Type != "String" && attr.Type != "Integer" && attr.Type != "Int64" {
+				return fmt.Errorf("entity %s attribute '%s' sequence generator needs a String, Integer or Int64 attribute, got %s",
+					entityID, attr.Name, attr.Type)
+			}
+		case GeneratorHierarchicalCode:
+			if attr.Generator.Branching < 1 {
+				return fmt.Errorf("entity %s attribute '%s' hierarchicalCode branching must be at least 1, got %d",
+					entityID, attr.Name, attr.Generator.Branching)
+			}
+			if attr.Generator.Depth < 1 {
+				return fmt.Errorf("entity %s attribute '%s' hierarchicalCode depth must be at least 1, got %d",
+					entityID, attr.Name, attr.Generator.Depth)
+			}
+			if attr.Generator.Padding < 0 {
+				return fmt.Errorf("entity %s attribute '%s' hierarchicalCode padding cannot be negative", entityID, attr.Name)
+			}
+			if strings.Contains(attr.Generator.Prefix, "-") {
+				return fmt.Errorf("entity %s attribute '%s' hierarchicalCode prefix cannot contain '-', which separates levels",
+					entityID, attr.Name)
+			}
+			if attr.Type != "String" {
+				return fmt.Errorf("entity %s attribute '%s' hierarchicalCode generator needs a String attribute, got %s",
+					entityID, attr.Name, attr.Type)
+			}
+		case GeneratorNationalID:
+			if !isNationalIDLocale(attr.Generator.Locale) {
+				return fmt.Errorf("entity %s attribute '%s' nationalId generator needs a locale (supported: %s)",
+					entityID, attr.Name, strings.Join(NationalIDLocales, ", "))
+			}
+		default:
+			return fmt.Errorf("entity %s attribute '%s' has unknown generator '%s' (supported: %s)",
+				entityID, attr.Name, attr.Generator.Type, strings.Join(generatorTypes(), ", "))
+		}
+
+		if correlated[attr.Name] {
+			return fmt.Errorf("entity %s attribute '%s' cannot have both a generator and a correlation", entityID, attr.Name)
+		}
+	}
+	return nil
+}
+
+// isNationalIDLocale reports whether the nationalId generator supports a locale
+func isNationalIDLocale(locale string) bool {
+	for _, supported := range NationalIDLocales {
+		if locale == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// generatorTypes returns the names of all built-in generators, sorted
+func generatorTypes() []string {
+	types := []string{
+		GeneratorSSN, GeneratorIBAN, GeneratorCreditCard, GeneratorNationalID,
+		GeneratorIPv4, GeneratorIPv6, GeneratorMAC, GeneratorSequence, GeneratorHierarchicalCode,
+		GeneratorCIDR, GeneratorHostname,
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,199 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestGeneratorUnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected Generator
+	}{
+		{name: "shorthand name", yaml: "generator: ssn", expected: Generator{Type: "ssn"}},
+		{name: "mapping with options", yaml: "generator: {type: nationalId, locale: GB}", expected: Generator{Type: "nationalId", Locale: "GB"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attr Attribute
+			require.NoError(t, yaml.Unmarshal([]byte(tt.yaml), &attr))
+			require.NotNil(t, attr.Generator)
+			assert.Equal(t, tt.expected, *attr.Generator)
+		})
+	}
+}
+
+func TestValidateGenerators(t *testing.T) {
+	entity := func(generator *Generator, correlations ...Correlation) Entity {
+		return Entity{
+			DisplayName: "User",
+			ExternalId:  "User",
+			Attributes: []Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "value", ExternalId: "value", Type: "Integer", Generator: generator},
+				{Name: "other", ExternalId: "other", Type: "Integer"},
+			},
+			Correlations: correlations,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		entity  Entity
+		wantErr string
+	}{
+		{name: "no generator", entity: entity(nil)},
+		{name: "known generator", entity: entity(&Generator{Type: GeneratorCreditCard})},
+		{name: "national ID with locale", entity: entity(&Generator{Type: GeneratorNationalID, Locale: "IN"})},
+		{name: "unknown generator", entity: entity(&Generator{Type: "passport"}), wantErr: "unknown generator 'passport'"},
+		{name: "national ID without locale", entity: entity(&Generator{Type: GeneratorNationalID}), wantErr: "needs a locale"},
+		{name: "national ID with unsupported locale", entity: entity(&Generator{Type: GeneratorNationalID, Locale: "ZZ"}), wantErr: "supported: CA, GB, IN, US"},
+		{
+			name:    "correlated attribute",
+			entity:  entity(&Generator{Type: GeneratorSSN}, Correlation{Attributes: []string{"value", "other"}, Coefficient: 0.5}),
+			wantErr: "cannot have both a generator and a correlation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateGenerators("user", tt.entity)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseGeneratorHints(t *testing.T) {
+	yamlContent := `displayName: PII SOR
+description: SOR with generator hints
+entities:
+  user:
+    displayName: User
+    externalId: User
+    attributes:
+      - name: id
+        externalId: id
+        type: String
+        uniqueId: true
+      - name: ssn
+        externalId: ssn
+        type: String
+        generator: ssn
+      - name: nino
+        externalId: nino
+        type: String
+        generator:
+          type: nationalId
+          locale: GB
+`
+	path := filepath.Join(t.TempDir(), "sor.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(yamlContent), 0600))
+
+	parser := NewParser(path)
+	require.NoError(t, parser.Parse())
+
+	attributes := parser.Definition.Entities["user"].Attributes
+	assert.Equal(t, &Generator{Type: GeneratorSSN}, attributes[1].Generator)
+	assert.Equal(t, &Generator{Type: GeneratorNationalID, Locale: "GB"}, attributes[2].Generator)
+}
+
+func TestGeneratorUnmarshalYAML_SequenceDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected Generator
+	}{
+		{name: "shorthand", yaml: "generator: sequence", expected: Generator{Type: "sequence", Start: 1, Step: 1}},
+		{name: "padding only", yaml: "generator: {type: sequence, padding: 6}", expected: Generator{Type: "sequence", Start: 1, Step: 1, Padding: 6}},
+		{name: "explicit zero start", yaml: "generator: {type: sequence, start: 0, step: 10}", expected: Generator{Type: "sequence", Start: 0, Step: 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attr Attribute
+			require.NoError(t, yaml.Unmarshal([]byte(tt.yaml), &attr))
+			require.NotNil(t, attr.Generator)
+			assert.Equal(t, tt.expected, *attr.Generator)
+		})
+	}
+}
+
+func TestValidateGenerators_Sequence(t *testing.T) {
+	entity := func(attrType string, generator Generator) Entity {
+		return Entity{
+			DisplayName: "Invoice",
+			ExternalId:  "Invoice",
+			Attributes: []Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "number", ExternalId: "number", Type: attrType, Generator: &generator},
+			},
+		}
+	}
+
+	assert.NoError(t, validateGenerators("invoice", entity("String", Generator{Type: GeneratorSequence, Start: 1, Step: 1, Padding: 8})))
+	assert.NoError(t, validateGenerators("invoice", entity("Int64", Generator{Type: GeneratorSequence, Start: -10, Step: 2})))
+	assert.ErrorContains(t, validateGenerators("invoice", entity("String", Generator{Type: GeneratorSequence, Step: 0})), "step must be at least 1")
+	assert.ErrorContains(t, validateGenerators("invoice", entity("String", Generator{Type: GeneratorSequence, Step: 1, Padding: -1})), "padding cannot be negative")
+	assert.ErrorContains(t, validateGenerators("invoice", entity("Float", Generator{Type: GeneratorSequence, Step: 1})), "String, Integer or Int64")
+}
+
+func TestGeneratorUnmarshalYAML_HierarchicalCodeDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected Generator
+	}{
+		{name: "shorthand", yaml: "generator: hierarchicalCode",
+			expected: Generator{Type: "hierarchicalCode", Prefix: "ORG", Branching: 3, Depth: 3, Padding: 2}},
+		{name: "options", yaml: "generator: {type: hierarchicalCode, prefix: CC, branching: 7, depth: 2, padding: 0}",
+			expected: Generator{Type: "hierarchicalCode", Prefix: "CC", Branching: 7, Depth: 2}},
+		{name: "explicit empty prefix", yaml: "generator: {type: hierarchicalCode, prefix: ''}",
+			expected: Generator{Type: "hierarchicalCode", Branching: 3, Depth: 3, Padding: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attr Attribute
+			require.NoError(t, yaml.Unmarshal([]byte(tt.yaml), &attr))
+			require.NotNil(t, attr.Generator)
+			assert.Equal(t, tt.expected, *attr.Generator)
+		})
+	}
+}
+
+func TestValidateGenerators_HierarchicalCode(t *testing.T) {
+	entity := func(attrType string, generator Generator) Entity {
+		return Entity{
+			DisplayName: "OrgUnit",
+			ExternalId:  "OrgUnit",
+			Attributes: []Attribute{
+				{Name: "code", ExternalId: "code", Type: attrType, UniqueId: true, Generator: &generator},
+			},
+		}
+	}
+	valid := Generator{Type: GeneratorHierarchicalCode, Prefix: "ORG", Branching: 3, Depth: 3, Padding: 2}
+
+	assert.NoError(t, validateGenerators("orgUnit", entity("String", valid)))
+
+	invalid := valid
+	invalid.Branching = 0
+	assert.ErrorContains(t, validateGenerators("orgUnit", entity("String", invalid)), "branching must be at least 1")
+	invalid = valid
+	invalid.Depth = 0
+	assert.ErrorContains(t, validateGenerators("orgUnit", entity("String", invalid)), "depth must be at least 1")
+	invalid = valid
+	invalid.Prefix = "ORG-EU"
+	assert.ErrorContains(t, validateGenerators("orgUnit", entity("String", invalid)), "cannot contain '-'")
+	assert.ErrorContains(t, validateGenerators("orgUnit", entity("Integer", valid)), "needs a String attribute")
+}
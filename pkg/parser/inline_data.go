@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+)
+
+// validateInlineData checks the rows an entity embeds with data
+func validateInlineData(entityID string, entity Entity) error {
+	return ValidateRows(entityID, entity, entity.Data, "data row")
+}
+
+// ValidateRows checks rows supplied for an entity instead of generated, such as its
+// inline data or fixtures: each names only the entity's attributes, by name or
+// externalId, holds values valid for their types and has its own unique ID. label
+// names a row in errors, e.g. "data row".
+func ValidateRows(entityID string, entity Entity, rows []map[string]string, label string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	attributes := make(map[string]Attribute, 2*len(entity.Attributes))
+	var uniqueID Attribute
+	for _, attr := range entity.Attributes {
+		attributes[attr.Name] = attr
+		attributes[attr.ExternalId] = attr
+		if attr.UniqueId {
+			uniqueID = attr
+		}
+	}
+
+	seen := make(map[string]int, len(rows))
+	for i, row := range rows {
+		// Check keys in order so the first error is the same on every run
+		keys := make([]string, 0, len(row))
+		for key := range row {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		id := ""
+		for _, key := range keys {
+			attr, exists := attributes[key]
+			if !exists {
+				return fmt.Errorf("entity %s %s %d sets unknown attribute '%s'", entityID, label, i+1, key)
+			}
+			if err := validateValueForType(attr.Type, row[key]); err != nil {
+				return fmt.Errorf("entity %s %s %d value '%s' of '%s' is not a valid %s: %w",
+					entityID, label, i+1, row[key], key, attr.Type, err)
+			}
+			if attr.Name == uniqueID.Name {
+				id = row[key]
+			}
+		}
+		if id == "" {
+			return fmt.Errorf("entity %s %s %d has no value for its uniqueId '%s'", entityID, label, i+1, uniqueID.Name)
+		}
+		if previous, exists := seen[id]; exists {
+			return fmt.Errorf("entity %s %ss %d and %d have the same uniqueId '%s'", entityID, label, previous, i+1, id)
+		}
+		seen[id] = i + 1
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateInlineData(t *testing.T) {
+	entity := func(rows ...map[string]string) Entity {
+		return Entity{
+			ExternalId: "Tier",
+			Attributes: []Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "name", ExternalId: "displayName", Type: "String"},
+				{Name: "rank", ExternalId: "rank", Type: "Integer"},
+			},
+			Data: rows,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		entity  Entity
+		wantErr string
+	}{
+		{name: "no data", entity: entity()},
+		{name: "names and external IDs", entity: entity(
+			map[string]string{"id": "1", "displayName": "Bronze", "rank": "1"},
+			map[string]string{"id": "2", "name": "Silver"},
+		)},
+		{name: "unknown attribute", entity: entity(map[string]string{"id": "1", "colour": "red"}),
+			wantErr: "entity tier data row 1 sets unknown attribute 'colour'"},
+		{name: "invalid value", entity: entity(map[string]string{"id": "1", "rank": "first"}),
+			wantErr: "value 'first' of 'rank' is not a valid Integer"},
+		{name: "missing unique ID", entity: entity(map[string]string{"name": "Bronze"}),
+			wantErr: "entity tier data row 1 has no value for its uniqueId 'id'"},
+		{name: "repeated unique ID", entity: entity(map[string]string{"id": "1"}, map[string]string{"id": "1"}),
+			wantErr: "entity tier data rows 1 and 2 have the same uniqueId '1'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateInlineData("tier", tt.entity)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Encodings for the values of a list attribute
+const (
+	ListEncodingJSON      = "json"      // A JSON array in the column, e.g. ["a","b"]
+	ListEncodingSemicolon = "semicolon" // Values joined by ';', with ';' and '\' escaped by '\'
+	ListEncodingRows      = "rows"      // One row per value in a separate <entity>.<attribute>.csv
+)
+
+// ListEncodings lists the supported list encodings
+var ListEncodings = []string{ListEncodingJSON, ListEncodingSemicolon, ListEncodingRows}
+
+// validateListEncodings checks that list encodings are known and set on list
+// attributes whose values are generated per row
+func validateListEncodings(entityID string, entity Entity) error {
+	correlated := make(map[string]bool)
+	for _, correlation := range entity.Correlations {
+		for _, name := range correlation.Attributes {
+			correlated[name] = true
+		}
+	}
+
+	for _, attr := range entity.Attributes {
+		if attr.ListEncoding == "" {
+			continue
+		}
+
+		known := false
+		for _, encoding := range ListEncodings {
+			known = known || attr.ListEncoding == encoding
+		}
+		switch {
+		case !known:
+			return fmt.Errorf("entity %s attribute '%s' has unknown listEncoding '%s' (supported: %s)",
+				entityID, attr.Name, attr.ListEncoding, strings.Join(ListEncodings, ", "))
+		case !attr.List:
+			return fmt.Errorf("entity %s attribute '%s' has a listEncoding but is not a list (set list: true)", entityID, attr.Name)
+		case attr.UniqueId || attr.UniqueWithin != "":
+			return fmt.Errorf("entity %s list attribute '%s' cannot be unique", entityID, attr.Name)
+		case attr.Const != nil || attr.Default != nil:
+			return fmt.Errorf("entity %s list attribute '%s' cannot have a const or default value", entityID, attr.Name)
+		case attr.Generator != nil && (attr.Generator.Type == GeneratorSequence || attr.Generator.Type == GeneratorHierarchicalCode):
+			return fmt.Errorf("entity %s list attribute '%s' cannot use the %s generator", entityID, attr.Name, attr.Generator.Type)
+		case correlated[attr.Name]:
+			return fmt.Errorf("entity %s list attribute '%s' cannot be correlated", entityID, attr.Name)
+		case entity.Timeline != nil && entity.Timeline.Attribute == attr.Name:
+			return fmt.Errorf("entity %s list attribute '%s' cannot be the timeline attribute", entityID, attr.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateListEncodings(t *testing.T) {
+	value := func(v string) *string { return &v }
+	entity := func(attr Attribute) Entity {
+		return Entity{
+			DisplayName: "Account",
+			ExternalId:  "Account",
+			Attributes: []Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "created", ExternalId: "created", Type: "DateTime"},
+				attr,
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		entity  Entity
+		wantErr string
+	}{
+		{name: "JSON", entity: entity(Attribute{Name: "tags", Type: "String", List: true, ListEncoding: ListEncodingJSON})},
+		{name: "Semicolon", entity: entity(Attribute{Name: "scores", Type: "Integer", List: true, ListEncoding: ListEncodingSemicolon})},
+		{name: "Rows with a generator", entity: entity(Attribute{Name: "ips", Type: "String", List: true, ListEncoding: ListEncodingRows,
+			Generator: &Generator{Type: GeneratorIPv4}})},
+		{name: "List without an encoding", entity: entity(Attribute{Name: "tags", Type: "String", List: true})},
+		{
+			name:    "Unknown encoding",
+			entity:  entity(Attribute{Name: "tags", Type: "String", List: true, ListEncoding: "csv"}),
+			wantErr: "unknown listEncoding 'csv' (supported: json, semicolon, rows)",
+		},
+		{
+			name:    "Not a list",
+			entity:  entity(Attribute{Name: "tags", Type: "String", ListEncoding: ListEncodingJSON}),
+			wantErr: "has a listEncoding but is not a list",
+		},
+		{
+			name:    "Unique",
+			entity:  entity(Attribute{Name: "tags", Type: "String", List: true, UniqueWithin: "created", ListEncoding: ListEncodingJSON}),
+			wantErr: "cannot be unique",
+		},
+		{
+			name:    "Const",
+			entity:  entity(Attribute{Name: "tags", Type: "String", List: true, Const: value("a"), ListEncoding: ListEncodingJSON}),
+			wantErr: "cannot have a const or default value",
+		},
+		{
+			name: "Sequence",
+			entity: entity(Attribute{Name: "codes", Type: "Integer", List: true, ListEncoding: ListEncodingRows,
+				Generator: &Generator{Type: GeneratorSequence, Start: 1, Step: 1}}),
+			wantErr: "cannot use the sequence generator",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateListEncodings("Account", tt.entity)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package parser
+
+import "fmt"
+
+// validateLookups checks that each lookup attribute copies its value through another
+// single-valued attribute of the entity, and takes no value from anywhere else.
+// Whether that attribute is a foreign key is checked once relationships are resolved.
+func validateLookups(entityID string, entity Entity) error {
+	byName := make(map[string]Attribute, len(entity.Attributes))
+	for _, attr := range entity.Attributes {
+		byName[attr.Name] = attr
+	}
+	correlated := make(map[string]bool)
+	for _, correlation := range entity.Correlations {
+		for _, name := range correlation.Attributes {
+			correlated[name] = true
+		}
+	}
+
+	for _, attr := range entity.Attributes {
+		lookup := attr.Lookup
+		if lookup == nil {
+			continue
+		}
+		if lookup.ForeignKey == "" || lookup.Attribute == "" {
+			return fmt.Errorf("entity %s attribute '%s' lookup needs a foreignKey and an attribute", entityID, attr.Name)
+		}
+
+		switch {
+		case attr.UniqueId:
+			return fmt.Errorf("entity %s attribute '%s' is a uniqueId and cannot be a lookup", entityID, attr.Name)
+		case attr.UniqueWithin != "":
+			return fmt.Errorf("entity %s attribute '%s' is unique within '%s' and cannot be a lookup",
+				entityID, attr.Name, attr.UniqueWithin)
+		case attr.Generator != nil, attr.Const != nil, attr.Default != nil, correlated[attr.Name]:
+			return fmt.Errorf("entity %s attribute '%s' is a lookup and cannot also have a generator, const, default or correlation",
+				entityID, attr.Name)
+		}
+
+		foreignKey, exists := byName[lookup.ForeignKey]
+		switch {
+		case !exists:
+			return fmt.Errorf("entity %s attribute '%s' looks up through unknown attribute '%s'", entityID, attr.Name, lookup.ForeignKey)
+		case foreignKey.Name == attr.Name:
+			return fmt.Errorf("entity %s attribute '%s' cannot look up through itself", entityID, attr.Name)
+		case foreignKey.List:
+			return fmt.Errorf("entity %s attribute '%s' cannot look up through list attribute '%s'", entityID, attr.Name, lookup.ForeignKey)
+		}
+	}
+	return nil
+}
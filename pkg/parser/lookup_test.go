@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateLookups(t *testing.T) {
+	// Tickets copy the email of the user their assigneeId references
+	entity := func(email Attribute, extra ...Attribute) Entity {
+		return Entity{
+			ExternalId: "Ticket",
+			Attributes: append([]Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "assigneeId", ExternalId: "assigneeId", Type: "String"},
+				email,
+			}, extra...),
+		}
+	}
+	lookup := &Lookup{ForeignKey: "assigneeId", Attribute: "email"}
+	value := "x"
+
+	tests := []struct {
+		name    string
+		entity  Entity
+		wantErr string
+	}{
+		{name: "no lookup", entity: entity(Attribute{Name: "assigneeEmail", Type: "String"})},
+		{name: "lookup", entity: entity(Attribute{Name: "assigneeEmail", Type: "String", Lookup: lookup})},
+		{name: "missing attribute",
+			entity:  entity(Attribute{Name: "assigneeEmail", Type: "String", Lookup: &Lookup{ForeignKey: "assigneeId"}}),
+			wantErr: "entity ticket attribute 'assigneeEmail' lookup needs a foreignKey and an attribute"},
+		{name: "unknown foreign key",
+			entity:  entity(Attribute{Name: "assigneeEmail", Type: "String", Lookup: &Lookup{ForeignKey: "ownerId", Attribute: "email"}}),
+			wantErr: "looks up through unknown attribute 'ownerId'"},
+		{name: "through itself",
+			entity:  entity(Attribute{Name: "assigneeEmail", Type: "String", Lookup: &Lookup{ForeignKey: "assigneeEmail", Attribute: "email"}}),
+			wantErr: "cannot look up through itself"},
+		{name: "through a list",
+			entity: entity(Attribute{Name: "watcherEmail", Type: "String", Lookup: &Lookup{ForeignKey: "watcherIds", Attribute: "email"}},
+				Attribute{Name: "watcherIds", Type: "String", List: true}),
+			wantErr: "cannot look up through list attribute 'watcherIds'"},
+		{name: "with a const",
+			entity:  entity(Attribute{Name: "assigneeEmail", Type: "String", Lookup: lookup, Const: &value}),
+			wantErr: "is a lookup and cannot also have a generator, const, default or correlation"},
+		{name: "unique within a scope",
+			entity:  entity(Attribute{Name: "assigneeEmail", Type: "String", Lookup: lookup, UniqueWithin: "assigneeId"}),
+			wantErr: "is unique within 'assigneeId' and cannot be a lookup"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateLookups("ticket", tt.entity)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
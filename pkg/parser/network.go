@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MACVendors maps the vendor names the mac generator accepts to an OUI each
+// registers, which starts the vendor's MAC addresses
+var MACVendors = map[string]string{
+	"apple":   "00:03:93",
+	"aruba":   "00:0b:86",
+	"cisco":   "00:00:0c",
+	"dell":    "00:14:22",
+	"hp":      "3c:d9:2b",
+	"intel":   "00:1b:21",
+	"juniper": "00:05:85",
+	"vmware":  "00:50:56",
+}
+
+var (
+	ouiPattern      = regexp.MustCompile(`^[0-9A-Fa-f]{2}:[0-9A-Fa-f]{2}:[0-9A-Fa-f]{2}$`)
+	hostnamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.-]*[A-Za-z0-9])?$`)
+)
+
+// Deferred reports whether the generator's values are assigned once every entity's
+// fields and lookups are set, as they depend on other rows or attributes: ipv4
+// addresses within a subnet, and hostnames derived from another attribute
+func (g *Generator) Deferred() bool {
+	switch g.Type {
+	case GeneratorIPv4:
+		return g.Subnet != ""
+	case GeneratorHostname:
+		return g.From != ""
+	}
+	return false
+}
+
+// IsIPv4CIDR reports whether value is an IPv4 CIDR block such as 10.0.0.0/24
+func IsIPv4CIDR(value string) bool {
+	ip, _, err := net.ParseCIDR(value)
+	return err == nil && ip.To4() != nil
+}
+
+// validateNetworkGenerator checks the options of the ipv4, mac, cidr and hostname
+// generators: blocks and OUIs must parse, and attributes named by subnet and from
+// must be other single-valued attributes of the entity
+func validateNetworkGenerator(entityID string, entity Entity, attr Attribute) error {
+	generator := attr.Generator
+	switch generator.Type {
+	case GeneratorCIDR:
+		_, network, err := net.ParseCIDR(generator.Network)
+		if err != nil || network.IP.To4() == nil {
+			return fmt.Errorf("entity %s attribute '%s' cidr network '%s' is not an IPv4 CIDR block",
+				entityID, attr.Name, generator.Network)
+		}
+		if bits, _ := network.Mask.Size(); generator.PrefixLength < bits || generator.PrefixLength > 32 {
+			return fmt.Errorf("entity %s attribute '%s' cidr prefixLength must be between %d and 32, got %d",
+				entityID, attr.Name, bits, generator.PrefixLength)
+		}
+		if attr.UniqueId || attr.List || attr.Type != "String" {
+			return fmt.Errorf("entity %s attribute '%s' cidr generator needs a single-valued String attribute that isn't a uniqueId",
+				entityID, attr.Name)
+		}
+	case GeneratorIPv4:
+		if generator.Subnet != "" && !IsIPv4CIDR(generator.Subnet) {
+			if err := validateSourceAttribute(entityID, entity, attr, "subnet", generator.Subnet); err != nil {
+				return err
+			}
+		}
+	case GeneratorMAC:
+		if _, known := MACVendors[strings.ToLower(generator.Vendor)]; generator.Vendor != "" && !known && !ouiPattern.MatchString(generator.Vendor) {
+			return fmt.Errorf("entity %s attribute '%s' mac vendor '%s' is neither an OUI (aa:bb:cc) nor a known vendor (%s)",
+				entityID, attr.Name, generator.Vendor, strings.Join(macVendorNames(), ", "))
+		}
+	case GeneratorHostname:
+		if generator.Domain != "" && !hostnamePattern.MatchString(generator.Domain) {
+			return fmt.Errorf("entity %s attribute '%s' hostname domain '%s' is not a domain name", entityID, attr.Name, generator.Domain)
+		}
+		if generator.From != "" {
+			if err := validateSourceAttribute(entityID, entity, attr, "from", generator.From); err != nil {
+				return err
+			}
+		}
+	}
+
+	if generator.Deferred() && (attr.UniqueId || attr.UniqueWithin != "" || attr.List) {
+		return fmt.Errorf("entity %s attribute '%s' %s generator with a %s cannot be a uniqueId, unique within an attribute or a list",
+			entityID, attr.Name, generator.Type, deferredOption(generator))
+	}
+	return nil
+}
+
+// validateSourceAttribute checks that a generator option names another
+// single-valued attribute of the entity
+func validateSourceAttribute(entityID string, entity Entity, attr Attribute, option, name string) error {
+	source, exists := attributeNamed(entity, name)
+	switch {
+	case !exists && option == "subnet":
+		return fmt.Errorf("entity %s attribute '%s' subnet '%s' is neither an IPv4 CIDR block nor an attribute of the entity",
+			entityID, attr.Name, name)
+	case !exists:
+		return fmt.Errorf("entity %s attribute '%s' %s '%s' is not an attribute of the entity", entityID, attr.Name, option, name)
+	case source.Name == attr.Name:
+		return fmt.Errorf("entity %s attribute '%s' %s cannot name the attribute itself", entityID, attr.Name, option)
+	case source.List:
+		return fmt.Errorf("entity %s attribute '%s' %s cannot name list attribute '%s'", entityID, attr.Name, option, name)
+	case source.Generator != nil && source.Generator.Deferred():
+		return fmt.Errorf("entity %s attribute '%s' %s cannot name '%s', whose %s generator has a %s",
+			entityID, attr.Name, option, name, source.Generator.Type, deferredOption(source.Generator))
+	}
+	return nil
+}
+
+// deferredOption names the option making a generator deferred
+func deferredOption(generator *Generator) string {
+	if generator.Type == GeneratorIPv4 {
+		return "subnet"
+	}
+	return "from"
+}
+
+// macVendorNames returns the vendor names the mac generator accepts, sorted
+func macVendorNames() []string {
+	names := make([]string, 0, len(MACVendors))
+	for name := range MACVendors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestCIDRGeneratorDefaults(t *testing.T) {
+	var generator Generator
+	require.NoError(t, yaml.Unmarshal([]byte("{type: cidr}"), &generator))
+	assert.Equal(t, "10.0.0.0/8", generator.Network)
+	assert.Equal(t, 24, generator.PrefixLength)
+
+	require.NoError(t, yaml.Unmarshal([]byte("{type: cidr, network: 172.16.0.0/12, prefixLength: 26}"), &generator))
+	assert.Equal(t, "172.16.0.0/12", generator.Network)
+	assert.Equal(t, 26, generator.PrefixLength)
+}
+
+func TestValidateNetworkGenerators(t *testing.T) {
+	// Devices take an address within their site's block and a hostname from their name
+	entity := func(extra ...Attribute) Entity {
+		return Entity{
+			ExternalId: "Device",
+			Attributes: append([]Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "name", ExternalId: "name", Type: "String"},
+				{Name: "siteCidr", ExternalId: "siteCidr", Type: "String"},
+				{Name: "tags", ExternalId: "tags", Type: "String", List: true},
+			}, extra...),
+		}
+	}
+	attr := func(name string, generator Generator) Attribute {
+		return Attribute{Name: name, ExternalId: name, Type: "String", Generator: &generator}
+	}
+
+	tests := []struct {
+		name    string
+		entity  Entity
+		wantErr string
+	}{
+		{name: "cidr", entity: entity(attr("block", Generator{Type: GeneratorCIDR, Network: "10.20.0.0/16", PrefixLength: 24}))},
+		{name: "cidr network",
+			entity:  entity(attr("block", Generator{Type: GeneratorCIDR, Network: "2001:db8::/32", PrefixLength: 48})),
+			wantErr: "cidr network '2001:db8::/32' is not an IPv4 CIDR block"},
+		{name: "cidr prefix shorter than the network",
+			entity:  entity(attr("block", Generator{Type: GeneratorCIDR, Network: "10.20.0.0/16", PrefixLength: 8})),
+			wantErr: "cidr prefixLength must be between 16 and 32, got 8"},
+		{name: "ipv4 in a literal block", entity: entity(attr("ip", Generator{Type: GeneratorIPv4, Subnet: "192.168.1.0/24"}))},
+		{name: "ipv4 in an attribute's block", entity: entity(attr("ip", Generator{Type: GeneratorIPv4, Subnet: "siteCidr"}))},
+		{name: "ipv4 in an unknown attribute",
+			entity:  entity(attr("ip", Generator{Type: GeneratorIPv4, Subnet: "site"})),
+			wantErr: "subnet 'site' is neither an IPv4 CIDR block nor an attribute of the entity"},
+		{name: "ipv4 in a list",
+			entity:  entity(attr("ip", Generator{Type: GeneratorIPv4, Subnet: "tags"})),
+			wantErr: "subnet cannot name list attribute 'tags'"},
+		{name: "unique ipv4 in a block",
+			entity: entity(Attribute{Name: "ip", ExternalId: "ip", Type: "String", UniqueWithin: "name",
+				Generator: &Generator{Type: GeneratorIPv4, Subnet: "siteCidr"}}),
+			wantErr: "ipv4 generator with a subnet cannot be a uniqueId, unique within an attribute or a list"},
+		{name: "mac of a vendor", entity: entity(attr("mac", Generator{Type: GeneratorMAC, Vendor: "Cisco"}))},
+		{name: "mac of an OUI", entity: entity(attr("mac", Generator{Type: GeneratorMAC, Vendor: "AC:DE:48"}))},
+		{name: "mac of an unknown vendor",
+			entity:  entity(attr("mac", Generator{Type: GeneratorMAC, Vendor: "acme"})),
+			wantErr: "mac vendor 'acme' is neither an OUI (aa:bb:cc) nor a known vendor (apple, aruba, cisco"},
+		{name: "hostname", entity: entity(attr("hostname", Generator{Type: GeneratorHostname, From: "name", Domain: "corp.example.com"}))},
+		{name: "hostname domain",
+			entity:  entity(attr("hostname", Generator{Type: GeneratorHostname, Domain: "corp example"})),
+			wantErr: "hostname domain 'corp example' is not a domain name"},
+		{name: "hostname from itself",
+			entity:  entity(attr("hostname", Generator{Type: GeneratorHostname, From: "hostname"})),
+			wantErr: "from cannot name the attribute itself"},
+		{name: "hostname from an address in a block",
+			entity: entity(attr("hostname", Generator{Type: GeneratorHostname, From: "ip"}),
+				attr("ip", Generator{Type: GeneratorIPv4, Subnet: "siteCidr"})),
+			wantErr: "from cannot name 'ip', whose ipv4 generator has a subnet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateGenerators("device", tt.entity)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
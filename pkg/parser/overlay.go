@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// overlayCountsKey is the top-level key of an overlay holding row counts rather
+// than a patch of the definition
+const overlayCountsKey = "counts"
+
+// applyOverlays patches a SOR's YAML with overlay files, in order, and returns the
+// merged YAML and the row counts the overlays set by entity, a later overlay's
+// winning. An overlay is a partial SOR merged key by key: mappings are merged
+// recursively, a null value removes the key, lists of items with a name (e.g.
+// attributes) are merged item by item by name, and any other value replaces the
+// base's. Variables are substituted in each overlay as in the base.
+func applyOverlays(data []byte, overlays []string, variables map[string]string, require bool) ([]byte, map[string]int, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidYAML, err)
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("the definition is not a mapping")
+	}
+	base := document.Content[0]
+
+	var counts map[string]int
+	for _, path := range overlays {
+		overlay, err := loadOverlay(path, variables, require)
+		if err != nil {
+			return nil, nil, fmt.Errorf("overlay %s: %w", path, err)
+		}
+		if overlay == nil {
+			continue
+		}
+
+		if at := mappingIndex(overlay, overlayCountsKey); at >= 0 {
+			var overlayCounts map[string]int
+			if err := overlay.Content[at+1].Decode(&overlayCounts); err != nil {
+				return nil, nil, fmt.Errorf("overlay %s: counts must map entities to row counts: %w", path, err)
+			}
+			for entityID, count := range overlayCounts {
+				if count < 0 {
+					return nil, nil, fmt.Errorf("overlay %s: invalid count %d for entity %s (expected a non-negative integer)",
+						path, count, entityID)
+				}
+				if counts == nil {
+					counts = make(map[string]int)
+				}
+				counts[entityID] = count
+			}
+			overlay.Content = append(overlay.Content[:at], overlay.Content[at+2:]...)
+		}
+		mergeNode(base, overlay)
+	}
+
+	merged, err := yaml.Marshal(&document)
+	if err != nil {
+		return nil, nil, err
+	}
+	return merged, counts, nil
+}
+
+// loadOverlay reads an overlay file's top-level mapping, or nil for an empty file
+func loadOverlay(path string, variables map[string]string, require bool) (*yaml.Node, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path is from CLI argument
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReadFile, err)
+	}
+	if data, err = substituteVariables(data, variables, require); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrVariables, err)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidYAML, err)
+	}
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+	if document.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a mapping of definition keys")
+	}
+	return document.Content[0], nil
+}
+
+// mergeNode merges overlay onto base and returns the result, changing base in place
+// where they merge
+func mergeNode(base, overlay *yaml.Node) *yaml.Node {
+	switch {
+	case base.Kind == yaml.MappingNode && overlay.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(overlay.Content); i += 2 {
+			key, value := overlay.Content[i], overlay.Content[i+1]
+			at := mappingIndex(base, key.Value)
+			switch {
+			case value.Kind == yaml.ScalarNode && value.ShortTag() == "!!null":
+				if at >= 0 {
+					base.Content = append(base.Content[:at], base.Content[at+2:]...)
+				}
+			case at >= 0:
+				base.Content[at+1] = mergeNode(base.Content[at+1], value)
+			default:
+				base.Content = append(base.Content, key, value)
+			}
+		}
+		return base
+
+	case base.Kind == yaml.SequenceNode && overlay.Kind == yaml.SequenceNode && namedItems(base) && namedItems(overlay):
+		for _, item := range overlay.Content {
+			name := itemName(item)
+			merged := false
+			for i, existing := range base.Content {
+				if itemName(existing) == name {
+					base.Content[i] = mergeNode(existing, item)
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				base.Content = append(base.Content, item)
+			}
+		}
+		return base
+	}
+	return overlay
+}
+
+// mappingIndex returns the position of a key among a mapping's content, or -1
+func mappingIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// namedItems reports whether every item of a list is a mapping with a name
+func namedItems(list *yaml.Node) bool {
+	for _, item := range list.Content {
+		if itemName(item) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// itemName returns the name of a list item, or "" when it has none
+func itemName(item *yaml.Node) string {
+	if item.Kind != yaml.MappingNode {
+		return ""
+	}
+	if at := mappingIndex(item, "name"); at >= 0 && item.Content[at+1].Kind == yaml.ScalarNode {
+		return item.Content[at+1].Value
+	}
+	return ""
+}
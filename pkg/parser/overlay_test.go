@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const overlayBaseSOR = `displayName: Base
+description: Base schema
+entities:
+  user:
+    displayName: User
+    externalId: User
+    attributes:
+      - name: id
+        externalId: id
+        type: String
+        uniqueId: true
+      - name: status
+        externalId: status
+        type: String
+  group:
+    displayName: Group
+    externalId: Group
+    attributes:
+      - name: id
+        externalId: id
+        type: String
+        uniqueId: true
+      - name: ownerId
+        externalId: ownerId
+        type: String
+relationships:
+  group_owner:
+    displayName: Group Owner
+    name: group_owner
+    fromAttribute: Group.ownerId
+    toAttribute: User.id
+`
+
+func TestOverlays(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+	base := write("base.yaml", overlayBaseSOR)
+
+	t.Run("should merge overlays onto the base in order", func(t *testing.T) {
+		contractors := write("contractors.yaml", `displayName: ${SCENARIO}
+entities:
+  contractor:
+    displayName: Contractor
+    externalId: Contractor
+    cloneOf: user
+  user:
+    attributes:
+      - name: status
+        const: active
+      - name: region
+        externalId: region
+        type: String
+counts:
+  User: 50
+  Contractor: 5
+`)
+		noGroups := write("no-groups.yaml", `entities:
+  group: null
+relationships: null
+counts:
+  User: 10
+`)
+
+		parser := NewParser(base)
+		parser.Variables = map[string]string{"SCENARIO": "Contractors"}
+		parser.Overlays = []string{contractors, noGroups}
+		require.NoError(t, parser.Parse())
+
+		def := parser.Definition
+		assert.Equal(t, "Contractors", def.DisplayName)
+		assert.Equal(t, "Base schema", def.Description, "keys an overlay doesn't set are kept")
+		assert.NotContains(t, def.Entities, "group")
+		assert.Empty(t, def.Relationships)
+
+		user := def.Entities["user"]
+		require.Len(t, user.Attributes, 3)
+		assert.Equal(t, "status", user.Attributes[1].Name)
+		assert.Equal(t, "status", user.Attributes[1].ExternalId, "merged attributes keep their other keys")
+		require.NotNil(t, user.Attributes[1].Const)
+		assert.Equal(t, "active", *user.Attributes[1].Const)
+		assert.Equal(t, "region", user.Attributes[2].Name)
+		assert.Len(t, def.Entities["contractor"].Attributes, 3, "clones are expanded after merging")
+
+		assert.Equal(t, map[string]int{"User": 10, "Contractor": 5}, parser.OverlayCounts)
+	})
+
+	t.Run("should validate the merged definition", func(t *testing.T) {
+		orphan := write("orphan.yaml", "entities:\n  user: null\n")
+		parser := NewParser(base)
+		parser.Overlays = []string{orphan}
+		assert.ErrorIs(t, parser.Parse(), ErrInvalidDefinition)
+	})
+
+	t.Run("should reject invalid overlays", func(t *testing.T) {
+		tests := map[string]string{
+			"not a mapping":   "- user\n",
+			"negative counts": "counts:\n  User: -1\n",
+			"invalid counts":  "counts: [User]\n",
+		}
+		for name, content := range tests {
+			parser := NewParser(base)
+			parser.Overlays = []string{write("invalid.yaml", content)}
+			assert.ErrorIs(t, parser.Parse(), ErrOverlay, name)
+		}
+
+		parser := NewParser(base)
+		parser.Overlays = []string{filepath.Join(dir, "missing.yaml")}
+		err := parser.Parse()
+		assert.ErrorIs(t, err, ErrOverlay)
+		assert.ErrorIs(t, err, ErrReadFile)
+	})
+}
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/SGNL-ai/fabricator/pkg/console"
 	"github.com/santhosh-tekuri/jsonschema/v6"
 	"gopkg.in/yaml.v3"
 )
@@ -20,6 +22,19 @@ type Parser struct {
 	FilePath   string
 	schema     *jsonschema.Schema
 	Quiet      bool // Suppress debug output when true
+
+	// Values for ${NAME} references in the YAML, taking precedence over the
+	// environment (see LoadVariables). References without a value or default are
+	// kept as written unless RequireVariables is set, as generating data or
+	// comparing with a tenant needs them all, but linting or diagramming a template
+	// doesn't.
+	Variables        map[string]string
+	RequireVariables bool
+
+	// Overlay files patching the definition, applied in order (see applyOverlays),
+	// and the row counts they set by entity once loaded
+	Overlays      []string
+	OverlayCounts map[string]int
 }
 
 // NewParser creates a new Parser instance
@@ -54,31 +69,64 @@ func (p *Parser) initSchema() error {
 
 // Parse loads and parses the YAML file
 func (p *Parser) Parse() error {
+	if err := p.Load(); err != nil {
+		return err
+	}
+
+	// Validate the parsed data (business logic validation)
+	if err := p.validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidDefinition, err)
+	}
+
+	return nil
+}
+
+// Load reads the YAML file into Definition, checking it against the schema and
+// expanding cloned entities, but without Parse's validation of the definition's
+// contents, e.g. to diagnose every unresolved relationship attribute at once
+func (p *Parser) Load() error {
 	// Read the YAML file
 	data, err := os.ReadFile(p.FilePath)
 	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+		return fmt.Errorf("%w: %w", ErrReadFile, err)
+	}
+
+	// Fill in ${NAME} references so one SOR can serve several environments
+	data, err = substituteVariables(data, p.Variables, p.RequireVariables)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrVariables, err)
+	}
+
+	// Patch the definition with scenario overlays sharing it as their base
+	if len(p.Overlays) > 0 {
+		data, p.OverlayCounts, err = applyOverlays(data, p.Overlays, p.Variables, p.RequireVariables)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrOverlay, err)
+		}
 	}
 
 	// First, perform JSON Schema validation on the raw YAML
 	err = p.validateSchema(data)
 	if err != nil {
-		return fmt.Errorf("schema validation failed: %w", err)
+		return fmt.Errorf("%w: %w", ErrSchemaValidation, err)
 	}
 
 	// Parse the YAML content
 	p.Definition = &SORDefinition{}
 	err = yaml.Unmarshal(data, p.Definition)
 	if err != nil {
-		return fmt.Errorf("failed to parse YAML: %w", err)
+		return fmt.Errorf("%w: %w", ErrInvalidYAML, err)
 	}
 
-	// Validate the parsed data (business logic validation)
-	err = p.validate()
+	// Expand entities declared as clones of other entities
+	err = expandClones(p.Definition)
 	if err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+		return fmt.Errorf("%w: %w", ErrCloneExpansion, err)
 	}
 
+	// Profiles sit next to the SOR, wherever fabricator is run from
+	resolveProfilePaths(p.Definition, filepath.Dir(p.FilePath))
+
 	return nil
 }
 
@@ -132,6 +180,11 @@ func (p *Parser) validate() error {
 		return fmt.Errorf("no entities defined")
 	}
 
+	foreignKeys := make(map[string]bool, len(p.Definition.Relationships))
+	for _, rel := range p.Definition.Relationships {
+		foreignKeys[rel.FromAttribute] = rel.FromAttribute != ""
+	}
+
 	// Check that each entity has at least one attribute and a valid external ID
 	for id, entity := range p.Definition.Entities {
 		if entity.ExternalId == "" {
@@ -163,6 +216,75 @@ func (p *Parser) validate() error {
 			return fmt.Errorf("entity %s (%s) has no attribute marked as uniqueId",
 				id, entity.DisplayName)
 		}
+
+		if err := validateCorrelations(id, entity); err != nil {
+			return err
+		}
+
+		if err := validateGenerators(id, entity); err != nil {
+			return err
+		}
+
+		if err := validateUniquenessScopes(id, entity); err != nil {
+			return err
+		}
+
+		if err := validateFixedValues(id, entity); err != nil {
+			return err
+		}
+
+		if err := validateTimeline(id, entity); err != nil {
+			return err
+		}
+
+		if err := validateEffectiveDating(id, entity); err != nil {
+			return err
+		}
+
+		if err := validateListEncodings(id, entity); err != nil {
+			return err
+		}
+
+		if err := validateTransforms(id, entity, foreignKeys); err != nil {
+			return err
+		}
+
+		if err := validateTimeZones(id, entity); err != nil {
+			return err
+		}
+
+		if err := validateInlineData(id, entity); err != nil {
+			return err
+		}
+
+		if err := validatePayloads(id, entity); err != nil {
+			return err
+		}
+
+		if err := validatePartitionBy(id, entity); err != nil {
+			return err
+		}
+
+		if err := validateLookups(id, entity); err != nil {
+			return err
+		}
+
+		if err := validateProfile(id, entity); err != nil {
+			return err
+		}
+	}
+
+	if err := validateDerived(p.Definition); err != nil {
+		return err
+	}
+
+	if err := validateSharedValues(p.Definition); err != nil {
+		return err
+	}
+
+	// Aliases must resolve to one attribute before relationships are resolved by them
+	if err := validateAttributeAliases(p.Definition); err != nil {
+		return err
 	}
 
 	// Validate relationships
@@ -174,7 +296,39 @@ func (p *Parser) validate() error {
 	return nil
 }
 
-// buildAttributeSuggestions creates helpful debugging information when an attribute cannot be found
+// validateExternalRelationship checks a relationship whose target lives in another SOR's output.
+// Returns a description of the problem, or an empty string if the relationship is valid.
+func (p *Parser) validateExternalRelationship(rel Relationship, aliasMap, entityAttrMap map[string]struct {
+	EntityID      string
+	AttributeName string
+	ExternalID    string
+	UniqueID      bool
+}) string {
+	if rel.FromAttribute == "" || rel.ToAttribute == "" {
+		return "external relationships need both fromAttribute and toAttribute"
+	}
+
+	fromInfo, found := aliasMap[rel.FromAttribute]
+	if !found {
+		fromInfo, found = entityAttrMap[rel.FromAttribute]
+	}
+	if !found {
+		return fmt.Sprintf("fromAttribute '%s' does not match any entity attribute%s",
+			rel.FromAttribute, p.buildAttributeSuggestions(rel.FromAttribute, aliasMap, entityAttrMap))
+	}
+	if fromInfo.UniqueID {
+		return fmt.Sprintf("fromAttribute '%s' is a uniqueId and cannot hold external keys", rel.FromAttribute)
+	}
+
+	if dot := strings.LastIndex(rel.ToAttribute, "."); dot <= 0 || dot == len(rel.ToAttribute)-1 {
+		return fmt.Sprintf("toAttribute '%s' must be <entity>.<column> for an external relationship", rel.ToAttribute)
+	}
+
+	return ""
+}
+
+// buildAttributeSuggestions creates helpful debugging information when an attribute
+// cannot be found: the format searched and the closest references that exist
 func (p *Parser) buildAttributeSuggestions(attrRef string, aliasMap, entityAttrMap map[string]struct {
 	EntityID      string
 	AttributeName string
@@ -191,34 +345,19 @@ func (p *Parser) buildAttributeSuggestions(attrRef string, aliasMap, entityAttrM
 		suggestions.WriteString("Attribute alias format")
 	}
 
-	// Show some examples of available formats
-	suggestions.WriteString("\n    Available attribute aliases (sample):")
-	count := 0
+	// Show the references closest to the one given, across all entities
+	candidates := make([]string, 0, len(aliasMap)+len(entityAttrMap))
 	for alias := range aliasMap {
-		if count >= 5 {
-			suggestions.WriteString("\n      ... and more")
-			break
-		}
-		suggestions.WriteString(fmt.Sprintf("\n      - %s", alias))
-		count++
-	}
-
-	if count == 0 {
-		suggestions.WriteString("\n      (none found)")
+		candidates = append(candidates, alias)
 	}
-
-	suggestions.WriteString("\n    Available Entity.Attribute patterns (sample):")
-	count = 0
 	for pattern := range entityAttrMap {
-		if count >= 5 {
-			suggestions.WriteString("\n      ... and more")
-			break
-		}
-		suggestions.WriteString(fmt.Sprintf("\n      - %s", pattern))
-		count++
+		candidates = append(candidates, pattern)
 	}
-
-	if count == 0 {
+	suggestions.WriteString("\n    Closest attribute references:")
+	for _, candidate := range closestReferences(attrRef, candidates, closestCandidates) {
+		suggestions.WriteString(fmt.Sprintf("\n      - %s", candidate))
+	}
+	if len(candidates) == 0 {
 		suggestions.WriteString("\n      (none found)")
 	}
 
@@ -252,7 +391,7 @@ func (p *Parser) validateRelationships() error {
 	// Build the attribute maps and collect entity info for debugging
 	fmt.Fprintf(os.Stderr, "\nDEBUG: Discovered %d entities:\n", len(p.Definition.Entities))
 	for entityID, entity := range p.Definition.Entities {
-		fmt.Fprintf(os.Stderr, "  • Entity ID: %s, External ID: %s, Display Name: %s (%d attributes)\n",
+		fmt.Fprintf(os.Stderr, console.Text("  • Entity ID: %s, External ID: %s, Display Name: %s (%d attributes)\n"),
 			entityID, entity.ExternalId, entity.DisplayName, len(entity.Attributes))
 
 		for _, attr := range entity.Attributes {
@@ -300,6 +439,38 @@ func (p *Parser) validateRelationships() error {
 
 	// Validate each relationship
 	for relID, rel := range p.Definition.Relationships {
+		// Only foreign keys assigned by this SOR can be limited to some target rows
+		if len(rel.Where) > 0 && (len(rel.Path) > 0 || rel.ChildEntity != "" || rel.ExternalDirectory != "") {
+			invalidRelationships = append(invalidRelationships,
+				fmt.Sprintf("relationship %s: where only applies to fromAttribute/toAttribute relationships within the SOR", relID))
+			continue
+		}
+
+		// Weights, like a where, choose among rows of a target within the SOR
+		if len(rel.Weights) > 0 && (len(rel.Path) > 0 || rel.ChildEntity != "" || rel.ExternalDirectory != "") {
+			invalidRelationships = append(invalidRelationships,
+				fmt.Sprintf("relationship %s: weights only apply to fromAttribute/toAttribute relationships within the SOR", relID))
+			continue
+		}
+		if err := validateWeights(rel.Weights); err != nil {
+			invalidRelationships = append(invalidRelationships, fmt.Sprintf("relationship %s: %v", relID, err))
+			continue
+		}
+
+		// Only keys streamed from another SOR's output are sampled. The keys of an
+		// entity within the SOR are generated in memory with its rows, so sampling
+		// them would save nothing.
+		if rel.Sampling != nil && rel.ExternalDirectory == "" {
+			invalidRelationships = append(invalidRelationships,
+				fmt.Sprintf("relationship %s: sampling only applies to relationships with an externalDirectory; keys of entities in this SOR are already in memory", relID))
+			continue
+		}
+		if rel.Sampling != nil && rel.Sampling.Method != "reservoir" && rel.Sampling.Size != 0 {
+			invalidRelationships = append(invalidRelationships,
+				fmt.Sprintf("relationship %s: sampling size only applies to the reservoir method", relID))
+			continue
+		}
+
 		// First, validate path-based relationships
 		if len(rel.Path) > 0 {
 			pathBasedRelationships++
@@ -347,6 +518,17 @@ func (p *Parser) validateRelationships() error {
 			continue
 		}
 
+		// External relationships point into another SOR's output, so only the source is checked here
+		if rel.ExternalDirectory != "" {
+			if problem := p.validateExternalRelationship(rel, attributeAliasMap, entityAttributeMap); problem != "" {
+				invalidRelationships = append(invalidRelationships,
+					fmt.Sprintf("relationship %s: %s", relID, problem))
+				continue
+			}
+			validRelationships++
+			continue
+		}
+
 		// Check if attributes match real entities - try both mapping approaches
 		var fromInfo, toInfo struct {
 			EntityID      string
@@ -444,19 +626,104 @@ func (p *Parser) validateRelationships() error {
 
 	// Report validation results
 	if len(invalidRelationships) > 0 {
-		// Build detailed error message
-		errorMsg := fmt.Sprintf("Found %d relationship issues (out of %d total relationships):\n",
-			len(invalidRelationships), len(p.Definition.Relationships))
+		return &RelationshipIssuesError{
+			Issues:    invalidRelationships,
+			Total:     len(p.Definition.Relationships),
+			Direct:    validRelationships,
+			PathBased: pathBasedRelationships,
+		}
+	}
 
-		for _, msg := range invalidRelationships {
-			errorMsg += "• " + msg + "\n"
+	return nil
+}
+
+// validateCorrelations checks that an entity's correlations reference distinct,
+// non-unique numeric attributes and that each pair is declared only once
+func validateCorrelations(entityID string, entity Entity) error {
+	attributes := make(map[string]Attribute, len(entity.Attributes))
+	for _, attr := range entity.Attributes {
+		attributes[attr.Name] = attr
+	}
+
+	seen := make(map[[2]string]bool)
+	for i, correlation := range entity.Correlations {
+		if len(correlation.Attributes) != 2 {
+			return fmt.Errorf("entity %s correlation %d must list exactly 2 attributes", entityID, i+1)
+		}
+		if correlation.Coefficient < -1 || correlation.Coefficient > 1 {
+			return fmt.Errorf("entity %s correlation %d coefficient %g must be between -1 and 1",
+				entityID, i+1, correlation.Coefficient)
 		}
 
-		errorMsg += fmt.Sprintf("\nValid relationships: %d direct, %d path-based",
-			validRelationships, pathBasedRelationships)
+		a, b := correlation.Attributes[0], correlation.Attributes[1]
+		if a == b {
+			return fmt.Errorf("entity %s correlation %d must reference two different attributes", entityID, i+1)
+		}
+		for _, name := range correlation.Attributes {
+			attr, exists := attributes[name]
+			if !exists {
+				return fmt.Errorf("entity %s correlation %d references unknown attribute '%s'", entityID, i+1, name)
+			}
+			if attr.UniqueId || !IsNumericType(attr.Type) {
+				return fmt.Errorf("entity %s correlation %d attribute '%s' must be a non-unique numeric attribute (Integer, Int64, Float, Double)",
+					entityID, i+1, name)
+			}
+		}
 
-		return fmt.Errorf("%s", errorMsg)
+		pair := [2]string{a, b}
+		if b < a {
+			pair = [2]string{b, a}
+		}
+		if seen[pair] {
+			return fmt.Errorf("entity %s declares the correlation between '%s' and '%s' more than once", entityID, a, b)
+		}
+		seen[pair] = true
+	}
+	return nil
+}
+
+// validateUniquenessScopes checks that uniqueWithin names another attribute of the
+// entity and is only set on attributes whose values are generated independently
+func validateUniquenessScopes(entityID string, entity Entity) error {
+	attributes := make(map[string]bool, len(entity.Attributes))
+	for _, attr := range entity.Attributes {
+		attributes[attr.Name] = true
+	}
+	correlated := make(map[string]bool)
+	for _, correlation := range entity.Correlations {
+		for _, name := range correlation.Attributes {
+			correlated[name] = true
+		}
 	}
 
+	for _, attr := range entity.Attributes {
+		if attr.UniqueWithin == "" {
+			continue
+		}
+		if attr.UniqueId {
+			return fmt.Errorf("entity %s attribute '%s' is a uniqueId and already unique; remove uniqueWithin",
+				entityID, attr.Name)
+		}
+		if attr.UniqueWithin == attr.Name {
+			return fmt.Errorf("entity %s attribute '%s' cannot be unique within itself", entityID, attr.Name)
+		}
+		if !attributes[attr.UniqueWithin] {
+			return fmt.Errorf("entity %s attribute '%s' is unique within unknown attribute '%s'",
+				entityID, attr.Name, attr.UniqueWithin)
+		}
+		if correlated[attr.Name] {
+			return fmt.Errorf("entity %s attribute '%s' cannot be both correlated and unique within '%s'",
+				entityID, attr.Name, attr.UniqueWithin)
+		}
+	}
 	return nil
 }
+
+// IsNumericType reports whether an attribute type holds numbers
+func IsNumericType(attrType string) bool {
+	switch attrType {
+	case "Integer", "Int64", "Float", "Double":
+		return true
+	}
+	return false
+}
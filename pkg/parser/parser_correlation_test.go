@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func correlationEntity(correlations ...Correlation) Entity {
+	return Entity{
+		DisplayName: "Employee",
+		ExternalId:  "Employee",
+		Attributes: []Attribute{
+			{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+			{Name: "tenure", ExternalId: "tenure", Type: "Integer"},
+			{Name: "salary", ExternalId: "salary", Type: "Float"},
+			{Name: "bonus", ExternalId: "bonus", Type: "Double"},
+			{Name: "title", ExternalId: "title", Type: "String"},
+		},
+		Correlations: correlations,
+	}
+}
+
+func TestValidateCorrelations(t *testing.T) {
+	tests := []struct {
+		name         string
+		correlations []Correlation
+		wantErr      string
+	}{
+		{name: "No correlations"},
+		{
+			name: "Valid pairs",
+			correlations: []Correlation{
+				{Attributes: []string{"tenure", "salary"}, Coefficient: 0.6},
+				{Attributes: []string{"salary", "bonus"}, Coefficient: -0.3},
+			},
+		},
+		{name: "Wrong attribute count", correlations: []Correlation{{Attributes: []string{"tenure"}, Coefficient: 0.5}}, wantErr: "exactly 2 attributes"},
+		{name: "Coefficient out of range", correlations: []Correlation{{Attributes: []string{"tenure", "salary"}, Coefficient: 1.5}}, wantErr: "between -1 and 1"},
+		{name: "Same attribute twice", correlations: []Correlation{{Attributes: []string{"tenure", "tenure"}, Coefficient: 0.5}}, wantErr: "two different attributes"},
+		{name: "Unknown attribute", correlations: []Correlation{{Attributes: []string{"tenure", "age"}, Coefficient: 0.5}}, wantErr: "unknown attribute 'age'"},
+		{name: "Non-numeric attribute", correlations: []Correlation{{Attributes: []string{"tenure", "title"}, Coefficient: 0.5}}, wantErr: "non-unique numeric"},
+		{name: "Unique attribute", correlations: []Correlation{{Attributes: []string{"id", "tenure"}, Coefficient: 0.5}}, wantErr: "non-unique numeric"},
+		{
+			name: "Duplicate pair",
+			correlations: []Correlation{
+				{Attributes: []string{"tenure", "salary"}, Coefficient: 0.6},
+				{Attributes: []string{"salary", "tenure"}, Coefficient: 0.2},
+			},
+			wantErr: "more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCorrelations("employee", correlationEntity(tt.correlations...))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestParseCorrelations(t *testing.T) {
+	yamlContent := `displayName: Correlation SOR
+description: SOR with correlated attributes
+entities:
+  employee:
+    displayName: Employee
+    externalId: Employee
+    attributes:
+      - name: id
+        externalId: id
+        type: String
+        uniqueId: true
+      - name: tenure
+        externalId: tenure
+        type: Integer
+      - name: salary
+        externalId: salary
+        type: Float
+    correlations:
+      - attributes: [tenure, salary]
+        coefficient: 0.6
+`
+	path := filepath.Join(t.TempDir(), "sor.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(yamlContent), 0600))
+
+	parser := NewParser(path)
+	require.NoError(t, parser.Parse())
+
+	correlations := parser.Definition.Entities["employee"].Correlations
+	require.Len(t, correlations, 1)
+	assert.Equal(t, []string{"tenure", "salary"}, correlations[0].Attributes)
+	assert.InDelta(t, 0.6, correlations[0].Coefficient, 1e-9)
+}
@@ -1,10 +1,14 @@
 package parser
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestRelationshipValidation tests the validateRelationships function
@@ -286,3 +290,119 @@ func TestRelationshipValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRelationshipValidationLevel(t *testing.T) {
+	yamlContent := `displayName: Validation SOR
+description: SOR with relationship validation levels
+entities:
+  user:
+    displayName: User
+    externalId: User
+    attributes:
+      - name: id
+        externalId: id
+        type: String
+        uniqueId: true
+      - name: roleId
+        externalId: roleId
+        type: String
+  role:
+    displayName: Role
+    externalId: Role
+    attributes:
+      - name: id
+        externalId: id
+        type: String
+        uniqueId: true
+relationships:
+  user_role:
+    displayName: User Role
+    name: user_role
+    fromAttribute: User.roleId
+    toAttribute: Role.id
+    validation: %s
+`
+	writeSOR := func(t *testing.T, level string) string {
+		path := filepath.Join(t.TempDir(), "sor.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(yamlContent, level)), 0600))
+		return path
+	}
+
+	t.Run("accepts known level", func(t *testing.T) {
+		parser := NewParser(writeSOR(t, "warn"))
+		require.NoError(t, parser.Parse())
+		assert.Equal(t, "warn", parser.Definition.Relationships["user_role"].Validation)
+	})
+
+	t.Run("rejects unknown level", func(t *testing.T) {
+		parser := NewParser(writeSOR(t, "ignore"))
+		assert.Error(t, parser.Parse())
+	})
+}
+
+func TestParseExternalRelationship(t *testing.T) {
+	yamlContent := `displayName: PagerDuty
+description: SOR referencing Okta users
+entities:
+  user:
+    displayName: User
+    externalId: User
+    attributes:
+      - name: id
+        externalId: id
+        type: String
+        uniqueId: true
+      - name: oktaId
+        externalId: oktaId
+        type: String
+relationships:
+  okta_user:
+    displayName: Okta User
+    name: okta_user
+    fromAttribute: %s
+    toAttribute: %s
+    externalDirectory: ../okta-output
+`
+	parse := func(t *testing.T, from, to string) error {
+		path := filepath.Join(t.TempDir(), "sor.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(yamlContent, from, to)), 0600))
+		parser := NewParser(path)
+		if err := parser.Parse(); err != nil {
+			return err
+		}
+		assert.Equal(t, "../okta-output", parser.Definition.Relationships["okta_user"].ExternalDirectory)
+		return nil
+	}
+
+	t.Run("target outside the definition is accepted", func(t *testing.T) {
+		assert.NoError(t, parse(t, "User.oktaId", "OktaUser.id"))
+	})
+
+	t.Run("source must exist", func(t *testing.T) {
+		assert.ErrorContains(t, parse(t, "User.missing", "OktaUser.id"), "does not match any entity attribute")
+	})
+
+	t.Run("source cannot be a uniqueId", func(t *testing.T) {
+		assert.ErrorContains(t, parse(t, "User.id", "OktaUser.id"), "cannot hold external keys")
+	})
+
+	t.Run("target needs entity and column", func(t *testing.T) {
+		assert.ErrorContains(t, parse(t, "User.oktaId", "OktaUser"), "<entity>.<column>")
+	})
+
+	t.Run("sampling streams the key file", func(t *testing.T) {
+		sor := fmt.Sprintf(yamlContent, "User.oktaId", "OktaUser.id")
+		path := filepath.Join(t.TempDir(), "sor.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(sor+"    sampling:\n      method: reservoir\n      size: 1000\n"), 0600))
+		parser := NewParser(path)
+		require.NoError(t, parser.Parse())
+		assert.Equal(t, &KeySampling{Method: "reservoir", Size: 1000}, parser.Definition.Relationships["okta_user"].Sampling)
+
+		require.NoError(t, os.WriteFile(path, []byte(sor+"    sampling:\n      method: stride\n      size: 1000\n"), 0600))
+		assert.ErrorContains(t, NewParser(path).Parse(), "sampling size only applies to the reservoir method")
+
+		internal := strings.Replace(sor, "OktaUser.id\n    externalDirectory: ../okta-output", "User.id", 1)
+		require.NoError(t, os.WriteFile(path, []byte(internal+"    sampling:\n      method: stride\n"), 0600))
+		assert.ErrorContains(t, NewParser(path).Parse(), "sampling only applies to relationships with an externalDirectory; keys of entities in this SOR are already in memory")
+	})
+}
@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateUniquenessScopes(t *testing.T) {
+	entity := func(attr Attribute, correlations ...Correlation) Entity {
+		return Entity{
+			DisplayName: "Account",
+			ExternalId:  "Account",
+			Attributes: []Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "tenantId", ExternalId: "tenantId", Type: "String"},
+				{Name: "score", ExternalId: "score", Type: "Integer"},
+				attr,
+			},
+			Correlations: correlations,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		entity  Entity
+		wantErr string
+	}{
+		{name: "No scope", entity: entity(Attribute{Name: "email", ExternalId: "email", Type: "String"})},
+		{name: "Valid scope", entity: entity(Attribute{Name: "email", ExternalId: "email", Type: "String", UniqueWithin: "tenantId"})},
+		{
+			name:    "Unknown scope attribute",
+			entity:  entity(Attribute{Name: "email", ExternalId: "email", Type: "String", UniqueWithin: "orgId"}),
+			wantErr: "unknown attribute 'orgId'",
+		},
+		{
+			name:    "Scoped to itself",
+			entity:  entity(Attribute{Name: "email", ExternalId: "email", Type: "String", UniqueWithin: "email"}),
+			wantErr: "cannot be unique within itself",
+		},
+		{
+			name:    "Unique ID",
+			entity:  entity(Attribute{Name: "key", ExternalId: "key", Type: "String", UniqueId: true, UniqueWithin: "tenantId"}),
+			wantErr: "already unique",
+		},
+		{
+			name: "Correlated attribute",
+			entity: entity(Attribute{Name: "rank", ExternalId: "rank", Type: "Integer", UniqueWithin: "tenantId"},
+				Correlation{Attributes: []string{"rank", "score"}, Coefficient: 0.5}),
+			wantErr: "cannot be both correlated and unique",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateUniquenessScopes("account", tt.entity)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package parser
+
+import "fmt"
+
+// validatePartitionBy checks that an entity's partitionBy names one of its
+// attributes holding a single, repeating value per row
+func validatePartitionBy(entityID string, entity Entity) error {
+	if entity.PartitionBy == "" {
+		return nil
+	}
+
+	for _, attr := range entity.Attributes {
+		if attr.Name != entity.PartitionBy {
+			continue
+		}
+		switch {
+		case attr.UniqueId:
+			return fmt.Errorf("entity %s cannot be partitioned by its uniqueId attribute '%s', which would give a file per row",
+				entityID, attr.Name)
+		case attr.List:
+			return fmt.Errorf("entity %s cannot be partitioned by list attribute '%s'", entityID, attr.Name)
+		}
+		return nil
+	}
+	return fmt.Errorf("entity %s partitionBy references unknown attribute '%s'", entityID, entity.PartitionBy)
+}
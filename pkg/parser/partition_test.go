@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePartitionBy(t *testing.T) {
+	entity := func(partitionBy string) Entity {
+		return Entity{
+			ExternalId: "User",
+			Attributes: []Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "region", ExternalId: "region", Type: "String"},
+				{Name: "roles", ExternalId: "roles", Type: "String", List: true},
+			},
+			PartitionBy: partitionBy,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		entity  Entity
+		wantErr string
+	}{
+		{name: "not partitioned", entity: entity("")},
+		{name: "by region", entity: entity("region")},
+		{name: "unknown attribute", entity: entity("country"),
+			wantErr: "entity user partitionBy references unknown attribute 'country'"},
+		{name: "by unique ID", entity: entity("id"),
+			wantErr: "cannot be partitioned by its uniqueId attribute 'id'"},
+		{name: "by list", entity: entity("roles"),
+			wantErr: "cannot be partitioned by list attribute 'roles'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePartitionBy("user", tt.entity)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package parser
+
+import "fmt"
+
+// validatePayloads checks that an entity's payloads either requires distinct rows or
+// sets a duplicate share, and that copied payloads can't break uniqueness
+func validatePayloads(entityID string, entity Entity) error {
+	payloads := entity.Payloads
+	if payloads == nil {
+		return nil
+	}
+
+	switch {
+	case payloads.Distinct && payloads.DuplicateShare != 0:
+		return fmt.Errorf("entity %s payloads cannot be distinct and have a duplicateShare", entityID)
+	case payloads.DuplicateShare < 0 || payloads.DuplicateShare >= 1:
+		return fmt.Errorf("entity %s payloads duplicateShare %g must be greater than 0 and less than 1",
+			entityID, payloads.DuplicateShare)
+	case len(entity.Data) > 0:
+		return fmt.Errorf("entity %s cannot have both payloads and inline data", entityID)
+	}
+
+	if payloads.DuplicateShare > 0 {
+		for _, attr := range entity.Attributes {
+			if attr.UniqueWithin != "" {
+				return fmt.Errorf("entity %s payloads duplicateShare cannot copy attribute '%s', which is unique within %s",
+					entityID, attr.Name, attr.UniqueWithin)
+			}
+			if attr.Generator != nil && !attr.UniqueId &&
+				(attr.Generator.Type == GeneratorSequence || attr.Generator.Type == GeneratorHierarchicalCode ||
+					attr.Generator.Type == GeneratorCIDR) {
+				return fmt.Errorf("entity %s payloads duplicateShare cannot copy attribute '%s', whose %s values are unique",
+					entityID, attr.Name, attr.Generator.Type)
+			}
+		}
+	}
+	return nil
+}
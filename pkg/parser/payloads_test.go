@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePayloads(t *testing.T) {
+	entity := func(payloads *Payloads, extra ...Attribute) Entity {
+		return Entity{
+			ExternalId: "User",
+			Attributes: append([]Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "status", ExternalId: "status", Type: "String"},
+			}, extra...),
+			Payloads: payloads,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		entity  Entity
+		wantErr string
+	}{
+		{name: "no payloads", entity: entity(nil)},
+		{name: "distinct", entity: entity(&Payloads{Distinct: true})},
+		{name: "duplicate share", entity: entity(&Payloads{DuplicateShare: 0.1})},
+		{name: "both", entity: entity(&Payloads{Distinct: true, DuplicateShare: 0.1}),
+			wantErr: "entity user payloads cannot be distinct and have a duplicateShare"},
+		{name: "share of all rows", entity: entity(&Payloads{DuplicateShare: 1}),
+			wantErr: "duplicateShare 1 must be greater than 0 and less than 1"},
+		{name: "with inline data", entity: func() Entity {
+			e := entity(&Payloads{Distinct: true})
+			e.Data = []map[string]string{{"id": "1"}}
+			return e
+		}(), wantErr: "entity user cannot have both payloads and inline data"},
+		{name: "duplicate share with scoped uniqueness",
+			entity:  entity(&Payloads{DuplicateShare: 0.1}, Attribute{Name: "login", ExternalId: "login", Type: "String", UniqueWithin: "status"}),
+			wantErr: "cannot copy attribute 'login', which is unique within status"},
+		{name: "duplicate share with a sequence",
+			entity:  entity(&Payloads{DuplicateShare: 0.1}, Attribute{Name: "number", ExternalId: "number", Type: "String", Generator: &Generator{Type: GeneratorSequence}}),
+			wantErr: "cannot copy attribute 'number', whose sequence values are unique"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePayloads("user", tt.entity)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Profile summarizes the columns of a real table, captured wherever the data lives,
+// so generated rows can match its shape without the rows themselves being shared
+type Profile struct {
+	Rows    int                      `json:"rows"`    // Number of rows profiled; topValues counts are out of these
+	Columns map[string]ProfileColumn `json:"columns"` // Keyed by attribute name or externalId
+}
+
+// ProfileColumn describes the values of one column of a profiled table
+type ProfileColumn struct {
+	NullRate  float64        `json:"nullRate,omitempty"`  // Fraction of rows with no value, in [0, 1]
+	Distinct  int            `json:"distinct,omitempty"`  // Number of distinct non-null values; 0 when unknown
+	TopValues []ProfileValue `json:"topValues,omitempty"` // Most common values and the rows holding each
+	Min       *float64       `json:"min,omitempty"`       // Smallest value of a numeric column
+	Max       *float64       `json:"max,omitempty"`       // Largest value of a numeric column
+}
+
+// ProfileValue is one of a column's most common values
+type ProfileValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// LoadProfile reads a statistical profile from a JSON file. Relative paths are
+// resolved from the working directory; the Parser has already made those of a
+// SOR file relative to its directory (see resolveProfilePaths).
+func LoadProfile(path string) (*Profile, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open profile: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	var profile Profile
+	if err := decoder.Decode(&profile); err != nil {
+		return nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+	}
+	return &profile, nil
+}
+
+// resolveProfilePaths joins dir, the directory of the SOR file, to the relative
+// profile paths of the definition's entities, so they name the same files from any
+// working directory
+func resolveProfilePaths(def *SORDefinition, dir string) {
+	for id, entity := range def.Entities {
+		if entity.Profile != "" && !filepath.IsAbs(entity.Profile) {
+			entity.Profile = filepath.Join(dir, entity.Profile)
+			def.Entities[id] = entity
+		}
+	}
+}
+
+// validateProfile checks that an entity's profile loads and describes only the
+// entity's generated attributes, with shares and ranges that fit its row count and
+// their types
+func validateProfile(entityID string, entity Entity) error {
+	if entity.Profile == "" {
+		return nil
+	}
+	switch {
+	case len(entity.Data) > 0:
+		return fmt.Errorf("entity %s cannot have both a profile and inline data", entityID)
+	case entity.Derived != nil:
+		return fmt.Errorf("entity %s cannot have both a profile and derived rows", entityID)
+	}
+
+	profile, err := LoadProfile(entity.Profile)
+	if err != nil {
+		return fmt.Errorf("entity %s: %w", entityID, err)
+	}
+	if profile.Rows <= 0 {
+		return fmt.Errorf("entity %s profile %s must have a positive rows count", entityID, entity.Profile)
+	}
+
+	attributes := make(map[string]Attribute, 2*len(entity.Attributes))
+	for _, attr := range entity.Attributes {
+		attributes[attr.Name] = attr
+		attributes[attr.ExternalId] = attr
+	}
+
+	// Check columns in order so the first error is the same on every run
+	keys := make([]string, 0, len(profile.Columns))
+	for key := range profile.Columns {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	profiled := make(map[string]string, len(keys))
+	for _, key := range keys {
+		column := profile.Columns[key]
+		attr, exists := attributes[key]
+		switch {
+		case !exists:
+			return fmt.Errorf("entity %s profile column '%s' does not match any attribute", entityID, key)
+		case attr.UniqueId:
+			return fmt.Errorf("entity %s profile column '%s' is the uniqueId, whose values are always distinct", entityID, key)
+		case attr.Lookup != nil:
+			return fmt.Errorf("entity %s profile column '%s' is a lookup, whose values are copied", entityID, key)
+		}
+		if previous, exists := profiled[attr.Name]; exists {
+			return fmt.Errorf("entity %s profile columns '%s' and '%s' both describe attribute '%s'", entityID, previous, key, attr.Name)
+		}
+		profiled[attr.Name] = key
+		if err := validateProfileColumn(profile.Rows, attr, column); err != nil {
+			return fmt.Errorf("entity %s profile column '%s': %w", entityID, key, err)
+		}
+	}
+	return nil
+}
+
+// validateProfileColumn checks one column of a profile against its attribute
+func validateProfileColumn(rows int, attr Attribute, column ProfileColumn) error {
+	if column.NullRate < 0 || column.NullRate > 1 {
+		return fmt.Errorf("nullRate %g must be between 0 and 1", column.NullRate)
+	}
+	if column.Distinct < 0 {
+		return fmt.Errorf("distinct %d cannot be negative", column.Distinct)
+	}
+	if column.Distinct > 0 && column.Distinct < len(column.TopValues) {
+		return fmt.Errorf("distinct %d is less than its %d top values", column.Distinct, len(column.TopValues))
+	}
+
+	seen := make(map[string]bool, len(column.TopValues))
+	total := 0
+	for _, top := range column.TopValues {
+		if top.Value == "" {
+			return fmt.Errorf("top values cannot be empty; nullRate sets the share of empty values")
+		}
+		if top.Count <= 0 {
+			return fmt.Errorf("top value '%s' must have a positive count", top.Value)
+		}
+		if seen[top.Value] {
+			return fmt.Errorf("top value '%s' is listed twice", top.Value)
+		}
+		seen[top.Value] = true
+		if attr.ListEncoding == "" {
+			if err := validateValueForType(attr.Type, top.Value); err != nil {
+				return fmt.Errorf("top value '%s' is not a valid %s: %w", top.Value, attr.Type, err)
+			}
+		}
+		total += top.Count
+	}
+	if nonNull := int(math.Round(float64(rows) * (1 - column.NullRate))); total > nonNull {
+		return fmt.Errorf("top values count %d rows, more than the %d of %d profiled rows that aren't null", total, nonNull, rows)
+	}
+
+	if column.Min == nil && column.Max == nil {
+		return nil
+	}
+	if column.Min == nil || column.Max == nil {
+		return fmt.Errorf("min and max must be set together")
+	}
+	switch attr.Type {
+	case "Integer", "Int64":
+		if *column.Min != math.Trunc(*column.Min) || *column.Max != math.Trunc(*column.Max) {
+			return fmt.Errorf("min and max of an %s attribute must be whole numbers", attr.Type)
+		}
+	case "Float", "Double":
+	default:
+		return fmt.Errorf("min and max apply only to numeric attributes, not %s", attr.Type)
+	}
+	if *column.Min > *column.Max {
+		return fmt.Errorf("min %g is greater than max %g", *column.Min, *column.Max)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateProfile(t *testing.T) {
+	dir := t.TempDir()
+	profileFile := func(content string) string {
+		path := filepath.Join(dir, "profile.json")
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	entity := func(profile string) Entity {
+		return Entity{
+			ExternalId: "User",
+			Attributes: []Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "status", ExternalId: "userStatus", Type: "String"},
+				{Name: "age", ExternalId: "age", Type: "Integer"},
+				{Name: "managerName", ExternalId: "managerName", Type: "String", Lookup: &Lookup{ForeignKey: "managerId", Attribute: "name"}},
+			},
+			Profile: profile,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		profile string
+		wantErr string
+	}{
+		{name: "valid", profile: `{"rows": 100, "columns": {
+			"userStatus": {"nullRate": 0.1, "distinct": 4, "topValues": [{"value": "active", "count": 70}, {"value": "suspended", "count": 20}]},
+			"age": {"min": 18, "max": 65}}}`},
+		{name: "unknown field", profile: `{"rows": 100, "columns": {"status": {"nulls": 3}}}`,
+			wantErr: `unknown field "nulls"`},
+		{name: "no rows", profile: `{"columns": {}}`,
+			wantErr: "must have a positive rows count"},
+		{name: "unknown column", profile: `{"rows": 10, "columns": {"title": {}}}`,
+			wantErr: "entity user profile column 'title' does not match any attribute"},
+		{name: "unique id", profile: `{"rows": 10, "columns": {"id": {"nullRate": 0.5}}}`,
+			wantErr: "column 'id' is the uniqueId"},
+		{name: "lookup", profile: `{"rows": 10, "columns": {"managerName": {"nullRate": 0.5}}}`,
+			wantErr: "column 'managerName' is a lookup"},
+		{name: "name and external id", profile: `{"rows": 10, "columns": {"status": {}, "userStatus": {}}}`,
+			wantErr: "profile columns 'status' and 'userStatus' both describe attribute 'status'"},
+		{name: "null rate", profile: `{"rows": 10, "columns": {"status": {"nullRate": 1.5}}}`,
+			wantErr: "nullRate 1.5 must be between 0 and 1"},
+		{name: "distinct below top values", profile: `{"rows": 10, "columns": {"status": {"distinct": 1, "topValues": [{"value": "a", "count": 1}, {"value": "b", "count": 1}]}}}`,
+			wantErr: "distinct 1 is less than its 2 top values"},
+		{name: "repeated top value", profile: `{"rows": 10, "columns": {"status": {"topValues": [{"value": "a", "count": 1}, {"value": "a", "count": 1}]}}}`,
+			wantErr: "top value 'a' is listed twice"},
+		{name: "top value of the wrong type", profile: `{"rows": 10, "columns": {"age": {"topValues": [{"value": "old", "count": 1}]}}}`,
+			wantErr: "top value 'old' is not a valid Integer"},
+		{name: "top values exceed non-null rows", profile: `{"rows": 10, "columns": {"status": {"nullRate": 0.5, "topValues": [{"value": "a", "count": 6}]}}}`,
+			wantErr: "top values count 6 rows, more than the 5 of 10 profiled rows that aren't null"},
+		{name: "range of a string", profile: `{"rows": 10, "columns": {"status": {"min": 1, "max": 2}}}`,
+			wantErr: "min and max apply only to numeric attributes, not String"},
+		{name: "half a range", profile: `{"rows": 10, "columns": {"age": {"min": 1}}}`,
+			wantErr: "min and max must be set together"},
+		{name: "fractional integer range", profile: `{"rows": 10, "columns": {"age": {"min": 1.5, "max": 2}}}`,
+			wantErr: "must be whole numbers"},
+		{name: "inverted range", profile: `{"rows": 10, "columns": {"age": {"min": 65, "max": 18}}}`,
+			wantErr: "min 65 is greater than max 18"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateProfile("user", entity(profileFile(tt.profile)))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		err := validateProfile("user", entity(filepath.Join(dir, "missing.json")))
+		assert.ErrorContains(t, err, "entity user: failed to open profile")
+	})
+
+	t.Run("with inline data", func(t *testing.T) {
+		e := entity("profile.json")
+		e.Data = []map[string]string{{"id": "1"}}
+		assert.ErrorContains(t, validateProfile("user", e), "entity user cannot have both a profile and inline data")
+	})
+}
+
+func TestParseResolvesProfileFromSORDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "profiles"), 0750))
+	profile := filepath.Join(dir, "profiles", "user.json")
+	require.NoError(t, os.WriteFile(profile, []byte(`{"rows": 10, "columns": {"status": {"nullRate": 0.5}}}`), 0600))
+	sor := strings.Replace(overlayBaseSOR, "    externalId: User\n", "    externalId: User\n    profile: profiles/user.json\n", 1)
+	sor = strings.Replace(sor, "relationships:\n", "  admin:\n    displayName: Admin\n    externalId: Admin\n    cloneOf: user\nrelationships:\n", 1)
+	path := filepath.Join(dir, "sor.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(sor), 0600))
+
+	// The working directory is the package's, not the SOR's
+	parser := NewParser(path)
+	require.NoError(t, parser.Parse())
+	assert.Equal(t, profile, parser.Definition.Entities["user"].Profile)
+	assert.Equal(t, profile, parser.Definition.Entities["admin"].Profile, "clones inherit the resolved profile")
+}
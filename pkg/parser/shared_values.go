@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+)
+
+// validateSharedValues checks that each attribute sharing values takes them from a
+// single-valued attribute of another generated entity, which doesn't share values
+// itself, and that its own values are generated rather than fixed or copied
+func validateSharedValues(def *SORDefinition) error {
+	byExternalID := make(map[string]Entity, len(def.Entities))
+	for _, entity := range def.Entities {
+		byExternalID[entity.ExternalId] = entity
+	}
+
+	// Check entities in order so the first error is the same on every run
+	ids := make([]string, 0, len(def.Entities))
+	for id := range def.Entities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		entity := def.Entities[id]
+		for _, attr := range entity.Attributes {
+			shared := attr.SharedValues
+			if shared == nil {
+				continue
+			}
+
+			switch {
+			case shared.Overlap <= 0 || shared.Overlap > 1:
+				return fmt.Errorf("entity %s attribute '%s' sharedValues overlap %g must be greater than 0 and at most 1",
+					id, attr.Name, shared.Overlap)
+			case entity.Derived != nil:
+				return fmt.Errorf("entity %s is derived, so attribute '%s' cannot share values", id, attr.Name)
+			case attr.UniqueId, attr.List, attr.Const != nil, attr.Lookup != nil:
+				return fmt.Errorf("entity %s attribute '%s' shares values, so it cannot be a uniqueId, a list, a const or a lookup",
+					id, attr.Name)
+			}
+
+			source, from, err := derivedReference(byExternalID, shared.From)
+			switch {
+			case err != nil:
+				return fmt.Errorf("entity %s attribute '%s' sharedValues from: %w", id, attr.Name, err)
+			case source.ExternalId == entity.ExternalId:
+				return fmt.Errorf("entity %s attribute '%s' sharedValues from '%s' must be an attribute of another entity",
+					id, attr.Name, shared.From)
+			case from.List:
+				return fmt.Errorf("entity %s attribute '%s' cannot share values with list attribute '%s'", id, attr.Name, shared.From)
+			case from.SharedValues != nil:
+				return fmt.Errorf("entity %s attribute '%s' cannot share values with '%s', which shares values itself",
+					id, attr.Name, shared.From)
+			}
+		}
+	}
+	return nil
+}
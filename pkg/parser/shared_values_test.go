@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSharedValues(t *testing.T) {
+	// Assets name their owner by email, matching some User.email values
+	definition := func(ownerEmail Attribute, userAttrs ...Attribute) *SORDefinition {
+		return &SORDefinition{Entities: map[string]Entity{
+			"user": {ExternalId: "User", Attributes: append([]Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "email", ExternalId: "email", Type: "String"},
+			}, userAttrs...)},
+			"asset": {ExternalId: "Asset", Attributes: []Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "serial", ExternalId: "serial", Type: "String"},
+				ownerEmail,
+			}},
+		}}
+	}
+	shared := func(from string, overlap float64) *SharedValues {
+		return &SharedValues{From: from, Overlap: overlap}
+	}
+	value := "x"
+
+	tests := []struct {
+		name       string
+		definition *SORDefinition
+		wantErr    string
+	}{
+		{name: "no shared values", definition: definition(Attribute{Name: "ownerEmail", Type: "String"})},
+		{name: "shared values",
+			definition: definition(Attribute{Name: "ownerEmail", Type: "String", SharedValues: shared("User.email", 0.6)})},
+		{name: "all rows shared",
+			definition: definition(Attribute{Name: "ownerEmail", Type: "String", SharedValues: shared("User.email", 1)})},
+		{name: "no overlap",
+			definition: definition(Attribute{Name: "ownerEmail", Type: "String", SharedValues: shared("User.email", 0)}),
+			wantErr:    "entity asset attribute 'ownerEmail' sharedValues overlap 0 must be greater than 0 and at most 1"},
+		{name: "overlap above one",
+			definition: definition(Attribute{Name: "ownerEmail", Type: "String", SharedValues: shared("User.email", 1.5)}),
+			wantErr:    "overlap 1.5 must be greater than 0 and at most 1"},
+		{name: "unknown attribute",
+			definition: definition(Attribute{Name: "ownerEmail", Type: "String", SharedValues: shared("User.mail", 0.5)}),
+			wantErr:    "sharedValues from: 'User.mail' references unknown attribute mail of User"},
+		{name: "same entity",
+			definition: definition(Attribute{Name: "ownerEmail", Type: "String", SharedValues: shared("Asset.serial", 0.5)}),
+			wantErr:    "sharedValues from 'Asset.serial' must be an attribute of another entity"},
+		{name: "with a const",
+			definition: definition(Attribute{Name: "ownerEmail", Type: "String", Const: &value, SharedValues: shared("User.email", 0.5)}),
+			wantErr:    "shares values, so it cannot be a uniqueId, a list, a const or a lookup"},
+		{name: "from a list",
+			definition: definition(Attribute{Name: "ownerEmail", Type: "String", SharedValues: shared("User.aliases", 0.5)},
+				Attribute{Name: "aliases", ExternalId: "aliases", Type: "String", List: true}),
+			wantErr: "cannot share values with list attribute 'User.aliases'"},
+		{name: "chained",
+			definition: definition(Attribute{Name: "ownerEmail", Type: "String", SharedValues: shared("User.workEmail", 0.5)},
+				Attribute{Name: "workEmail", ExternalId: "workEmail", Type: "String", SharedValues: shared("Asset.serial", 0.5)}),
+			wantErr: "cannot share values with 'User.workEmail', which shares values itself"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSharedValues(tt.definition)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FilterByTags returns a copy of def keeping only the entities selected by their
+// tags, and the relationships between them. Untagged entities are always kept. A
+// tagged entity is kept when it has one of the with tags, or any tag when with is
+// empty, and none of the without tags. Relationships from or to a dropped entity,
+// and path-based relationships through them, are dropped too.
+//
+// It returns the external IDs of the dropped entities, sorted. Tags in with or
+// without that no entity has are rejected as likely typos.
+func FilterByTags(def *SORDefinition, with, without []string) (*SORDefinition, []string, error) {
+	if def == nil || (len(with) == 0 && len(without) == 0) {
+		return def, nil, nil
+	}
+
+	known := make(map[string]bool)
+	for _, entity := range def.Entities {
+		for _, tag := range entity.Tags {
+			known[tag] = true
+		}
+	}
+	for _, tag := range append(append([]string{}, with...), without...) {
+		if !known[tag] {
+			return nil, nil, fmt.Errorf("no entity has the tag '%s'", tag)
+		}
+	}
+
+	filtered := *def
+	filtered.Entities = make(map[string]Entity, len(def.Entities))
+	var dropped []string
+	droppedKeys := make(map[string]bool)
+	for key, entity := range def.Entities {
+		if entitySelected(entity.Tags, with, without) {
+			filtered.Entities[key] = entity
+			continue
+		}
+		dropped = append(dropped, entity.ExternalId)
+		droppedKeys[key] = true
+	}
+	sort.Strings(dropped)
+	if len(filtered.Entities) == 0 {
+		return nil, nil, fmt.Errorf("the tag filters leave no entities to generate")
+	}
+	if len(dropped) == 0 {
+		return def, nil, nil
+	}
+
+	// Attribute references of the dropped entities, by alias and Entity.attribute
+	droppedRefs := make(map[string]bool)
+	for key := range droppedKeys {
+		entity := def.Entities[key]
+		for _, attr := range entity.Attributes {
+			if attr.AttributeAlias != "" {
+				droppedRefs[attr.AttributeAlias] = true
+			}
+			droppedRefs[entity.ExternalId+"."+attr.ExternalId] = true
+		}
+	}
+
+	filtered.Relationships = make(map[string]Relationship, len(def.Relationships))
+	for key, relationship := range def.Relationships {
+		if droppedRefs[relationship.FromAttribute] || droppedKeys[relationship.ChildEntity] ||
+			(relationship.ExternalDirectory == "" && droppedRefs[relationship.ToAttribute]) {
+			continue
+		}
+		filtered.Relationships[key] = relationship
+	}
+	// Path-based relationships need every step
+	for key, relationship := range filtered.Relationships {
+		for _, step := range relationship.Path {
+			if _, exists := filtered.Relationships[step.Relationship]; !exists {
+				delete(filtered.Relationships, key)
+				break
+			}
+		}
+	}
+	return &filtered, dropped, nil
+}
+
+// entitySelected returns whether an entity with tags passes the with and without
+// filters
+func entitySelected(tags, with, without []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		for _, excluded := range without {
+			if tag == excluded {
+				return false
+			}
+		}
+	}
+	if len(with) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		for _, included := range with {
+			if tag == included {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseTags splits a comma-separated list of tags, ignoring blanks
+func ParseTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterByTags(t *testing.T) {
+	id := func(alias string) []Attribute {
+		return []Attribute{{Name: "id", ExternalId: "id", UniqueId: true, AttributeAlias: alias}, {Name: "ref", ExternalId: "ref"}}
+	}
+	def := &SORDefinition{
+		DisplayName: "Tags",
+		Entities: map[string]Entity{
+			"user":    {ExternalId: "User", Attributes: id("user-id")},
+			"device":  {ExternalId: "Device", Tags: []string{"beta"}, Attributes: id("device-id")},
+			"license": {ExternalId: "License", Tags: []string{"optional"}, Attributes: id("license-id")},
+		},
+		Relationships: map[string]Relationship{
+			"user_device":  {Name: "user_device", FromAttribute: "User.ref", ToAttribute: "device-id"},
+			"device_user":  {Name: "device_user", FromAttribute: "Device.ref", ToAttribute: "User.id"},
+			"user_license": {Name: "user_license", FromAttribute: "License.ref", ToAttribute: "user-id"},
+			"device_path":  {Name: "device_path", Path: []RelationshipPath{{Relationship: "user_device"}, {Relationship: "user_license"}}},
+		},
+	}
+	keys := func(def *SORDefinition) ([]string, []string) {
+		var entities, relationships []string
+		for key := range def.Entities {
+			entities = append(entities, key)
+		}
+		for key := range def.Relationships {
+			relationships = append(relationships, key)
+		}
+		return entities, relationships
+	}
+
+	t.Run("no filters keep the definition", func(t *testing.T) {
+		filtered, dropped, err := FilterByTags(def, nil, nil)
+		require.NoError(t, err)
+		assert.Same(t, def, filtered)
+		assert.Empty(t, dropped)
+	})
+
+	t.Run("with tags keeps untagged entities and those tagged", func(t *testing.T) {
+		filtered, dropped, err := FilterByTags(def, []string{"beta"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"License"}, dropped)
+		entities, relationships := keys(filtered)
+		assert.ElementsMatch(t, []string{"user", "device"}, entities)
+		assert.ElementsMatch(t, []string{"user_device", "device_user"}, relationships,
+			"relationships and paths through dropped entities are dropped")
+		assert.Len(t, def.Entities, 3, "the definition itself is unchanged")
+	})
+
+	t.Run("without tags drops tagged entities", func(t *testing.T) {
+		filtered, dropped, err := FilterByTags(def, nil, []string{"beta"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Device"}, dropped)
+		_, relationships := keys(filtered)
+		assert.ElementsMatch(t, []string{"user_license"}, relationships)
+	})
+
+	t.Run("unknown tags are rejected", func(t *testing.T) {
+		_, _, err := FilterByTags(def, []string{"betta"}, nil)
+		assert.ErrorContains(t, err, "no entity has the tag 'betta'")
+	})
+
+	t.Run("tags are comma-separated", func(t *testing.T) {
+		assert.Equal(t, []string{"beta", "optional"}, ParseTags(" beta, ,optional"))
+		assert.Empty(t, ParseTags(""))
+	})
+}
@@ -0,0 +1,150 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TenantSchemaTimeout bounds fetching a tenant's SOR schema from a URL
+const TenantSchemaTimeout = 30 * time.Second
+
+// LoadTenantSchema reads the SOR definition a tenant exports, from a YAML or JSON
+// file or an http(s) URL. A URL is fetched with GET, sending token as a bearer token
+// unless it is empty. Fields this definition format doesn't know are ignored, so
+// the export can carry whatever else the tenant records.
+func LoadTenantSchema(source, token string) (*SORDefinition, error) {
+	var data []byte
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		fetched, err := fetchTenantSchema(source, token)
+		if err != nil {
+			return nil, err
+		}
+		data = fetched
+	} else {
+		read, err := os.ReadFile(filepath.Clean(source))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrReadFile, err)
+		}
+		data = read
+	}
+
+	def := &SORDefinition{}
+	if err := yaml.Unmarshal(data, def); err != nil {
+		return nil, fmt.Errorf("%w: tenant schema %s: %w", ErrInvalidYAML, source, err)
+	}
+	if len(def.Entities) == 0 {
+		return nil, fmt.Errorf("tenant schema %s has no entities", source)
+	}
+	return def, nil
+}
+
+// fetchTenantSchema gets the body of a tenant schema URL
+func fetchTenantSchema(url, token string) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tenant schema URL %s: %w", url, err)
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	client := &http.Client{Timeout: TenantSchemaTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tenant schema: %w", err)
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch tenant schema %s: %s", url, response.Status)
+	}
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tenant schema: %w", err)
+	}
+	return data, nil
+}
+
+// SchemaDifference is one way a SOR definition differs from the tenant's
+type SchemaDifference struct {
+	Entity    string // External ID of the entity
+	Attribute string // External ID of the attribute; empty when the whole entity differs
+	Message   string // How it differs, e.g. "missing from the tenant"
+}
+
+func (d SchemaDifference) String() string {
+	if d.Attribute == "" {
+		return fmt.Sprintf("%s: %s", d.Entity, d.Message)
+	}
+	return fmt.Sprintf("%s.%s: %s", d.Entity, d.Attribute, d.Message)
+}
+
+// CompareTenantSchema lists how the entities, attributes and attribute types of def
+// differ from those of the tenant's SOR, matched by external ID; types are compared
+// ignoring case. The differences are sorted by entity, then attribute.
+func CompareTenantSchema(def, tenant *SORDefinition) []SchemaDifference {
+	local, remote := entitiesByExternalID(def), entitiesByExternalID(tenant)
+
+	var differences []SchemaDifference
+	for externalID, entity := range local {
+		other, exists := remote[externalID]
+		if !exists {
+			differences = append(differences, SchemaDifference{Entity: externalID, Message: "missing from the tenant"})
+			continue
+		}
+		differences = append(differences, compareAttributes(externalID, entity, other)...)
+	}
+	for externalID := range remote {
+		if _, exists := local[externalID]; !exists {
+			differences = append(differences, SchemaDifference{Entity: externalID, Message: "missing from the local SOR"})
+		}
+	}
+
+	sort.Slice(differences, func(i, j int) bool {
+		if differences[i].Entity != differences[j].Entity {
+			return differences[i].Entity < differences[j].Entity
+		}
+		return differences[i].Attribute < differences[j].Attribute
+	})
+	return differences
+}
+
+// compareAttributes lists how one entity's attributes differ from the tenant's
+func compareAttributes(entityID string, entity, tenant Entity) []SchemaDifference {
+	remote := make(map[string]Attribute, len(tenant.Attributes))
+	for _, attr := range tenant.Attributes {
+		remote[attr.ExternalId] = attr
+	}
+
+	var differences []SchemaDifference
+	for _, attr := range entity.Attributes {
+		other, exists := remote[attr.ExternalId]
+		switch {
+		case !exists:
+			differences = append(differences, SchemaDifference{Entity: entityID, Attribute: attr.ExternalId, Message: "missing from the tenant"})
+		case !strings.EqualFold(attr.Type, other.Type):
+			differences = append(differences, SchemaDifference{Entity: entityID, Attribute: attr.ExternalId,
+				Message: fmt.Sprintf("type is %s locally but %s in the tenant", attr.Type, other.Type)})
+		}
+		delete(remote, attr.ExternalId)
+	}
+	for externalID := range remote {
+		differences = append(differences, SchemaDifference{Entity: entityID, Attribute: externalID, Message: "missing from the local SOR"})
+	}
+	return differences
+}
+
+// entitiesByExternalID indexes a definition's entities by external ID
+func entitiesByExternalID(def *SORDefinition) map[string]Entity {
+	entities := make(map[string]Entity, len(def.Entities))
+	for _, entity := range def.Entities {
+		entities[entity.ExternalId] = entity
+	}
+	return entities
+}
@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareTenantSchema(t *testing.T) {
+	local := &SORDefinition{Entities: map[string]Entity{
+		"user": {ExternalId: "User", Attributes: []Attribute{
+			{ExternalId: "id", Type: "String"},
+			{ExternalId: "age", Type: "Int64"},
+			{ExternalId: "nickname", Type: "String"},
+		}},
+		"group": {ExternalId: "Group", Attributes: []Attribute{{ExternalId: "id", Type: "String"}}},
+	}}
+
+	path := filepath.Join(t.TempDir(), "tenant.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"displayName": "Tenant",
+		"tenantOnlyField": true,
+		"entities": {
+			"user": {"externalId": "User", "attributes": [
+				{"externalId": "id", "type": "string"},
+				{"externalId": "age", "type": "Double"},
+				{"externalId": "email", "type": "String"}
+			]},
+			"role": {"externalId": "Role", "attributes": []}
+		}
+	}`), 0600))
+	tenant, err := LoadTenantSchema(path, "")
+	require.NoError(t, err, "a JSON export with fields of its own is read")
+
+	var lines []string
+	for _, difference := range CompareTenantSchema(local, tenant) {
+		lines = append(lines, difference.String())
+	}
+	assert.Equal(t, []string{
+		"Group: missing from the tenant",
+		"Role: missing from the local SOR",
+		"User.age: type is Int64 locally but Double in the tenant",
+		"User.email: missing from the local SOR",
+		"User.nickname: missing from the tenant",
+	}, lines)
+
+	empty := filepath.Join(t.TempDir(), "empty.yaml")
+	require.NoError(t, os.WriteFile(empty, []byte("displayName: Empty\n"), 0600))
+	_, err = LoadTenantSchema(empty, "")
+	assert.EqualError(t, err, "tenant schema "+empty+" has no entities")
+}
@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	_ "time/tzdata" // Random zones don't depend on the system's zone database
+)
+
+// Time zones a DateTime attribute's values can be written in
+const (
+	TimeZoneUTC    = "utc"    // Every value in UTC, e.g. 2024-03-01T09:30:00Z
+	TimeZoneOffset = "offset" // Every value at one fixed offset, e.g. 2024-03-01T15:00:00+05:30
+	TimeZoneRandom = "random" // Each row's values in a zone drawn from TimeZoneNames
+)
+
+// TimeZoneModes lists the supported time zone modes
+var TimeZoneModes = []string{TimeZoneUTC, TimeZoneOffset, TimeZoneRandom}
+
+// TimeZoneNames are the IANA zones random time zones are drawn from: where most
+// users of a global tenant plausibly are
+var TimeZoneNames = []string{
+	"America/New_York", "America/Chicago", "America/Denver", "America/Los_Angeles", "America/Sao_Paulo",
+	"Europe/London", "Europe/Paris", "Europe/Berlin", "Africa/Johannesburg", "Asia/Dubai",
+	"Asia/Kolkata", "Asia/Singapore", "Asia/Tokyo", "Australia/Sydney", "Pacific/Auckland",
+}
+
+var timeZoneOffset = regexp.MustCompile(`^[+-](0[0-9]|1[0-4]):[0-5][0-9]$`)
+
+// TimeZone makes a DateTime attribute's values explicit about their zone, so
+// ingestion doesn't have to guess at implicit ones
+type TimeZone struct {
+	Mode   string `yaml:"mode"`             // utc, offset or random
+	Offset string `yaml:"offset,omitempty"` // ±HH:MM, for the offset mode
+	// Column names a String attribute of the entity given each row's zone: the IANA
+	// name for random zones, UTC or the offset otherwise
+	Column string `yaml:"column,omitempty"`
+}
+
+// Location returns the fixed location of the utc and offset modes, or nil for random
+func (z *TimeZone) Location() *time.Location {
+	switch z.Mode {
+	case TimeZoneUTC:
+		return time.UTC
+	case TimeZoneOffset:
+		parsed, _ := time.Parse("-07:00", z.Offset) // Checked by validateTimeZones
+		_, seconds := parsed.Zone()
+		return time.FixedZone(z.Offset, seconds)
+	}
+	return nil
+}
+
+// validateTimeZones checks that time zones are set on single-valued DateTime
+// attributes with a known mode, and that their columns are String attributes of the
+// entity which attributes sharing them agree on
+func validateTimeZones(entityID string, entity Entity) error {
+	byName := make(map[string]Attribute, len(entity.Attributes))
+	for _, attr := range entity.Attributes {
+		byName[attr.Name] = attr
+	}
+
+	columns := make(map[string]TimeZone)
+	for _, attr := range entity.Attributes {
+		zone := attr.TimeZone
+		if zone == nil {
+			continue
+		}
+
+		known := false
+		for _, mode := range TimeZoneModes {
+			known = known || zone.Mode == mode
+		}
+		switch {
+		case !known:
+			return fmt.Errorf("entity %s attribute '%s' has unknown timeZone mode '%s' (supported: %s)",
+				entityID, attr.Name, zone.Mode, strings.Join(TimeZoneModes, ", "))
+		case attr.Type != "DateTime":
+			return fmt.Errorf("entity %s attribute '%s' has a timeZone but is %s, not DateTime", entityID, attr.Name, attr.Type)
+		case attr.List:
+			return fmt.Errorf("entity %s list attribute '%s' cannot have a timeZone", entityID, attr.Name)
+		case zone.Mode == TimeZoneOffset && !timeZoneOffset.MatchString(zone.Offset):
+			return fmt.Errorf("entity %s attribute '%s' timeZone offset '%s' is not ±HH:MM", entityID, attr.Name, zone.Offset)
+		case zone.Mode != TimeZoneOffset && zone.Offset != "":
+			return fmt.Errorf("entity %s attribute '%s' timeZone offset is only used with the offset mode", entityID, attr.Name)
+		}
+
+		if zone.Column == "" {
+			continue
+		}
+		column, exists := byName[zone.Column]
+		switch {
+		case !exists:
+			return fmt.Errorf("entity %s attribute '%s' timeZone column '%s' is not an attribute", entityID, attr.Name, zone.Column)
+		case column.Type != "String" || column.List:
+			return fmt.Errorf("entity %s attribute '%s' timeZone column '%s' must be a single-valued String", entityID, attr.Name, zone.Column)
+		case column.UniqueId, column.Lookup != nil, column.TimeZone != nil:
+			return fmt.Errorf("entity %s attribute '%s' timeZone column '%s' cannot be a uniqueId, a lookup or have a timeZone",
+				entityID, attr.Name, zone.Column)
+		}
+		if other, exists := columns[zone.Column]; exists && (other.Mode != zone.Mode || other.Offset != zone.Offset) {
+			return fmt.Errorf("entity %s attributes sharing timeZone column '%s' must have the same mode and offset",
+				entityID, zone.Column)
+		}
+		columns[zone.Column] = *zone
+	}
+	return nil
+}
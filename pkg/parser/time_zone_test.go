@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTimeZones(t *testing.T) {
+	entity := func(attrs ...Attribute) Entity {
+		return Entity{
+			DisplayName: "User",
+			ExternalId:  "User",
+			Attributes: append([]Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "timeZone", ExternalId: "timeZone", Type: "String"},
+			}, attrs...),
+		}
+	}
+	at := func(name string, zone TimeZone) Attribute {
+		return Attribute{Name: name, ExternalId: name, Type: "DateTime", TimeZone: &zone}
+	}
+
+	tests := []struct {
+		name    string
+		entity  Entity
+		wantErr string
+	}{
+		{name: "UTC", entity: entity(at("createdAt", TimeZone{Mode: TimeZoneUTC}))},
+		{name: "Offset", entity: entity(at("createdAt", TimeZone{Mode: TimeZoneOffset, Offset: "+05:30"}))},
+		{
+			name:   "Random sharing a column",
+			entity: entity(at("createdAt", TimeZone{Mode: TimeZoneRandom, Column: "timeZone"}), at("lastLogin", TimeZone{Mode: TimeZoneRandom, Column: "timeZone"})),
+		},
+		{
+			name:    "Unknown mode",
+			entity:  entity(at("createdAt", TimeZone{Mode: "local"})),
+			wantErr: "unknown timeZone mode 'local' (supported: utc, offset, random)",
+		},
+		{
+			name:    "Not a DateTime",
+			entity:  entity(Attribute{Name: "birthday", Type: "Date", TimeZone: &TimeZone{Mode: TimeZoneUTC}}),
+			wantErr: "has a timeZone but is Date, not DateTime",
+		},
+		{
+			name:    "Invalid offset",
+			entity:  entity(at("createdAt", TimeZone{Mode: TimeZoneOffset, Offset: "+5"})),
+			wantErr: "timeZone offset '+5' is not ±HH:MM",
+		},
+		{
+			name:    "Offset without the offset mode",
+			entity:  entity(at("createdAt", TimeZone{Mode: TimeZoneUTC, Offset: "+01:00"})),
+			wantErr: "offset is only used with the offset mode",
+		},
+		{
+			name:    "Unknown column",
+			entity:  entity(at("createdAt", TimeZone{Mode: TimeZoneRandom, Column: "zone"})),
+			wantErr: "timeZone column 'zone' is not an attribute",
+		},
+		{
+			name:    "Key column",
+			entity:  entity(at("createdAt", TimeZone{Mode: TimeZoneRandom, Column: "id"})),
+			wantErr: "cannot be a uniqueId",
+		},
+		{
+			name:    "Conflicting column",
+			entity:  entity(at("createdAt", TimeZone{Mode: TimeZoneRandom, Column: "timeZone"}), at("lastLogin", TimeZone{Mode: TimeZoneUTC, Column: "timeZone"})),
+			wantErr: "sharing timeZone column 'timeZone' must have the same mode and offset",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTimeZones("User", tt.entity)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("should give fixed modes their location", func(t *testing.T) {
+		assert.Equal(t, time.UTC, (&TimeZone{Mode: TimeZoneUTC}).Location())
+		at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC).In((&TimeZone{Mode: TimeZoneOffset, Offset: "-03:30"}).Location())
+		assert.Equal(t, "2024-03-01T08:30:00-03:30", at.Format(time.RFC3339))
+		assert.Nil(t, (&TimeZone{Mode: TimeZoneRandom}).Location())
+		for _, name := range TimeZoneNames {
+			_, err := time.LoadLocation(name)
+			assert.NoError(t, err, name)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseTimelineTime parses a timeline bound or cluster start, written either as a
+// date (2006-01-02, meaning midnight UTC) or as an RFC 3339 timestamp
+func ParseTimelineTime(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' is neither a date (YYYY-MM-DD) nor an RFC 3339 timestamp", value)
+	}
+	return t.UTC(), nil
+}
+
+// validateTimeline checks that an entity's timeline shapes a generated Date or
+// DateTime attribute, that its range is ordered and that its clusters fit inside it
+func validateTimeline(entityID string, entity Entity) error {
+	timeline := entity.Timeline
+	if timeline == nil {
+		return nil
+	}
+
+	var attr *Attribute
+	for i := range entity.Attributes {
+		if entity.Attributes[i].Name == timeline.Attribute {
+			attr = &entity.Attributes[i]
+		}
+	}
+	switch {
+	case attr == nil:
+		return fmt.Errorf("entity %s timeline references unknown attribute '%s'", entityID, timeline.Attribute)
+	case attr.Type != "Date" && attr.Type != "DateTime":
+		return fmt.Errorf("entity %s timeline attribute '%s' must be a Date or DateTime, got %s",
+			entityID, attr.Name, attr.Type)
+	case attr.UniqueId || attr.UniqueWithin != "":
+		return fmt.Errorf("entity %s timeline attribute '%s' cannot be unique", entityID, attr.Name)
+	case attr.Generator != nil || attr.Const != nil || attr.Default != nil:
+		return fmt.Errorf("entity %s timeline attribute '%s' cannot also have a generator, const or default value",
+			entityID, attr.Name)
+	}
+
+	from, err := ParseTimelineTime(timeline.From)
+	if err != nil {
+		return fmt.Errorf("entity %s timeline from: %w", entityID, err)
+	}
+	to, err := ParseTimelineTime(timeline.To)
+	if err != nil {
+		return fmt.Errorf("entity %s timeline to: %w", entityID, err)
+	}
+	if !from.Before(to) {
+		return fmt.Errorf("entity %s timeline from %s must be before to %s", entityID, timeline.From, timeline.To)
+	}
+
+	total := 0.0
+	for i, cluster := range timeline.Clusters {
+		at, err := ParseTimelineTime(cluster.At)
+		if err != nil {
+			return fmt.Errorf("entity %s timeline cluster %d at: %w", entityID, i+1, err)
+		}
+		if cluster.WindowDays < 1 {
+			return fmt.Errorf("entity %s timeline cluster %d windowDays must be at least 1, got %d",
+				entityID, i+1, cluster.WindowDays)
+		}
+		if cluster.Share <= 0 || cluster.Share > 1 {
+			return fmt.Errorf("entity %s timeline cluster %d share %g must be greater than 0 and at most 1",
+				entityID, i+1, cluster.Share)
+		}
+		end := at.AddDate(0, 0, cluster.WindowDays)
+		if at.Before(from) || end.After(to) {
+			return fmt.Errorf("entity %s timeline cluster %d (%s plus %d days) must lie between from %s and to %s",
+				entityID, i+1, cluster.At, cluster.WindowDays, timeline.From, timeline.To)
+		}
+		total += cluster.Share
+	}
+	if total > 1+1e-9 {
+		return fmt.Errorf("entity %s timeline cluster shares add up to %g, which is more than 1", entityID, total)
+	}
+	return nil
+}
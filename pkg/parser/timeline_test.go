@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTimeline(t *testing.T) {
+	entity := func(timeline *Timeline) Entity {
+		return Entity{
+			DisplayName: "User",
+			ExternalId:  "User",
+			Attributes: []Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				{Name: "createdAt", ExternalId: "createdAt", Type: "DateTime"},
+				{Name: "name", ExternalId: "name", Type: "String"},
+			},
+			Timeline: timeline,
+		}
+	}
+	onboarding := []TimelineCluster{{At: "2023-03-01", WindowDays: 30, Share: 0.7}}
+
+	tests := []struct {
+		name    string
+		entity  Entity
+		wantErr string
+	}{
+		{name: "No timeline", entity: entity(nil)},
+		{
+			name:   "Onboarding cluster",
+			entity: entity(&Timeline{Attribute: "createdAt", From: "2023-01-01", To: "2024-12-31", Clusters: onboarding}),
+		},
+		{
+			name:   "RFC 3339 bounds without clusters",
+			entity: entity(&Timeline{Attribute: "createdAt", From: "2023-01-01T00:00:00Z", To: "2023-06-30T12:00:00+02:00"}),
+		},
+		{
+			name:    "Unknown attribute",
+			entity:  entity(&Timeline{Attribute: "joined", From: "2023-01-01", To: "2024-01-01"}),
+			wantErr: "references unknown attribute 'joined'",
+		},
+		{
+			name:    "Not a date",
+			entity:  entity(&Timeline{Attribute: "name", From: "2023-01-01", To: "2024-01-01"}),
+			wantErr: "must be a Date or DateTime, got String",
+		},
+		{
+			name:    "Unparseable bound",
+			entity:  entity(&Timeline{Attribute: "createdAt", From: "01/01/2023", To: "2024-01-01"}),
+			wantErr: "timeline from: '01/01/2023' is neither a date",
+		},
+		{
+			name:    "Reversed range",
+			entity:  entity(&Timeline{Attribute: "createdAt", From: "2024-01-01", To: "2023-01-01"}),
+			wantErr: "must be before to",
+		},
+		{
+			name: "Cluster outside range",
+			entity: entity(&Timeline{Attribute: "createdAt", From: "2023-01-01", To: "2023-03-15",
+				Clusters: onboarding}),
+			wantErr: "cluster 1 (2023-03-01 plus 30 days) must lie between",
+		},
+		{
+			name: "Shares above one",
+			entity: entity(&Timeline{Attribute: "createdAt", From: "2023-01-01", To: "2024-12-31",
+				Clusters: append(onboarding, TimelineCluster{At: "2024-01-01", WindowDays: 10, Share: 0.5})}),
+			wantErr: "shares add up to 1.2",
+		},
+		{
+			name: "Empty window",
+			entity: entity(&Timeline{Attribute: "createdAt", From: "2023-01-01", To: "2024-12-31",
+				Clusters: []TimelineCluster{{At: "2023-03-01", Share: 0.5}}}),
+			wantErr: "windowDays must be at least 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTimeline("user", tt.entity)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
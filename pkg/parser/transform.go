@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Letter cases a transform can put an attribute's values in
+const (
+	TransformCaseUpper = "upper"
+	TransformCaseLower = "lower"
+)
+
+// TransformCases lists the supported transform cases
+var TransformCases = []string{TransformCaseUpper, TransformCaseLower}
+
+// Transform rewrites an attribute's values just before they are written, for
+// systems expecting e.g. uppercase country codes or zero-padded numbers. Values are
+// trimmed, cased, padded and given the prefix and suffix, in that order; empty
+// values stay empty.
+type Transform struct {
+	Trim     bool   `yaml:"trim,omitempty"`     // Strip leading and trailing whitespace
+	Case     string `yaml:"case,omitempty"`     // upper or lower
+	PadWidth int    `yaml:"padWidth,omitempty"` // Left-pad with zeros, after any sign, to this many characters
+	Prefix   string `yaml:"prefix,omitempty"`
+	Suffix   string `yaml:"suffix,omitempty"`
+}
+
+// validateTransforms checks that transforms use known cases and widths, and are set
+// on single-valued attributes that are neither unique nor foreign keys: rewriting
+// those could make distinct values collide or break the references between rows.
+// foreignKeys holds the fromAttribute references of the SOR's relationships.
+func validateTransforms(entityID string, entity Entity, foreignKeys map[string]bool) error {
+	for _, attr := range entity.Attributes {
+		transform := attr.Transform
+		if transform == nil {
+			continue
+		}
+
+		switch {
+		case transform.Case != "" && !slices.Contains(TransformCases, transform.Case):
+			return fmt.Errorf("entity %s attribute '%s' has unknown transform case '%s' (supported: %s)",
+				entityID, attr.Name, transform.Case, strings.Join(TransformCases, ", "))
+		case transform.PadWidth < 0:
+			return fmt.Errorf("entity %s attribute '%s' has a negative transform padWidth", entityID, attr.Name)
+		case attr.List:
+			return fmt.Errorf("entity %s list attribute '%s' cannot have a transform", entityID, attr.Name)
+		case attr.UniqueId, attr.UniqueWithin != "":
+			return fmt.Errorf("entity %s attribute '%s' is unique and cannot have a transform", entityID, attr.Name)
+		case foreignKeys[entity.ExternalId+"."+attr.ExternalId], attr.AttributeAlias != "" && foreignKeys[attr.AttributeAlias]:
+			return fmt.Errorf("entity %s attribute '%s' is a foreign key and cannot have a transform", entityID, attr.Name)
+		}
+	}
+	return nil
+}
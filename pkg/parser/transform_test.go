@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTransforms(t *testing.T) {
+	entity := func(attr Attribute) Entity {
+		return Entity{
+			DisplayName: "Account",
+			ExternalId:  "Account",
+			Attributes: []Attribute{
+				{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+				attr,
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		entity  Entity
+		wantErr string
+	}{
+		{name: "Upper", entity: entity(Attribute{Name: "country", Type: "String", Transform: &Transform{Case: TransformCaseUpper, Trim: true}})},
+		{name: "Padded with a prefix", entity: entity(Attribute{Name: "number", Type: "String", Transform: &Transform{PadWidth: 8, Prefix: "EMP-"}})},
+		{
+			name:    "Unknown case",
+			entity:  entity(Attribute{Name: "country", Type: "String", Transform: &Transform{Case: "title"}}),
+			wantErr: "unknown transform case 'title' (supported: upper, lower)",
+		},
+		{
+			name:    "Negative width",
+			entity:  entity(Attribute{Name: "number", Type: "String", Transform: &Transform{PadWidth: -1}}),
+			wantErr: "has a negative transform padWidth",
+		},
+		{
+			name:    "List",
+			entity:  entity(Attribute{Name: "tags", Type: "String", List: true, Transform: &Transform{Case: TransformCaseLower}}),
+			wantErr: "list attribute 'tags' cannot have a transform",
+		},
+		{
+			name:    "Unique",
+			entity:  entity(Attribute{Name: "number", Type: "String", UniqueWithin: "id", Transform: &Transform{PadWidth: 8}}),
+			wantErr: "attribute 'number' is unique and cannot have a transform",
+		},
+		{
+			name:    "Foreign key",
+			entity:  entity(Attribute{Name: "ownerId", ExternalId: "ownerId", Type: "String", Transform: &Transform{Prefix: "USR-"}}),
+			wantErr: "attribute 'ownerId' is a foreign key and cannot have a transform",
+		},
+		{
+			name: "Foreign key by alias",
+			entity: entity(Attribute{Name: "managerId", ExternalId: "managerId", AttributeAlias: "manager", Type: "String",
+				Transform: &Transform{Case: TransformCaseLower}}),
+			wantErr: "attribute 'managerId' is a foreign key and cannot have a transform",
+		},
+	}
+	foreignKeys := map[string]bool{"Account.ownerId": true, "manager": true}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTransforms("Account", tt.entity, foreignKeys)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/util/editdistance"
+)
+
+// closestCandidates is how many candidate references an unresolved one lists
+const closestCandidates = 3
+
+// UnresolvedAttribute is a relationship's fromAttribute or toAttribute that matches
+// no attribute alias or Entity.attribute reference, with the references closest to it
+type UnresolvedAttribute struct {
+	Relationship string   // Key of the relationship
+	Field        string   // fromAttribute or toAttribute
+	Reference    string   // The reference as written
+	Candidates   []string // Closest attribute aliases and Entity.attribute references, closest first
+}
+
+func (u UnresolvedAttribute) String() string {
+	message := fmt.Sprintf("relationship %s: %s '%s' does not match any entity attribute", u.Relationship, u.Field, u.Reference)
+	if len(u.Candidates) > 0 {
+		message += " (closest: " + strings.Join(u.Candidates, ", ") + ")"
+	}
+	return message
+}
+
+// UnresolvedAttributes lists every fromAttribute and toAttribute of the definition's
+// relationships, ordered by relationship key, that matches no attribute. Path-based
+// and childEntity relationships have none, and only the fromAttribute of an external
+// relationship refers to this definition.
+func UnresolvedAttributes(def *SORDefinition) []UnresolvedAttribute {
+	references := attributeReferences(def)
+	candidates := make([]string, 0, len(references))
+	for reference := range references {
+		candidates = append(candidates, reference)
+	}
+
+	ids := make([]string, 0, len(def.Relationships))
+	for id := range def.Relationships {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var unresolved []UnresolvedAttribute
+	for _, id := range ids {
+		rel := def.Relationships[id]
+		if len(rel.Path) > 0 || rel.ChildEntity != "" {
+			continue
+		}
+		fields := []struct{ name, reference string }{{"fromAttribute", rel.FromAttribute}}
+		if rel.ExternalDirectory == "" {
+			fields = append(fields, struct{ name, reference string }{"toAttribute", rel.ToAttribute})
+		}
+		for _, field := range fields {
+			if field.reference == "" || references[field.reference] {
+				continue
+			}
+			unresolved = append(unresolved, UnresolvedAttribute{
+				Relationship: id,
+				Field:        field.name,
+				Reference:    field.reference,
+				Candidates:   closestReferences(field.reference, candidates, closestCandidates),
+			})
+		}
+	}
+	return unresolved
+}
+
+// attributeReferences returns every reference a relationship can use for an
+// attribute: its alias, if any, and Entity.attribute by externalIds
+func attributeReferences(def *SORDefinition) map[string]bool {
+	references := make(map[string]bool)
+	for _, entity := range def.Entities {
+		for _, attr := range entity.Attributes {
+			if attr.AttributeAlias != "" {
+				references[attr.AttributeAlias] = true
+			}
+			references[entity.ExternalId+"."+attr.ExternalId] = true
+		}
+	}
+	return references
+}
+
+// closestReferences returns up to n candidates closest to reference by edit
+// distance, ignoring case, closest first
+func closestReferences(reference string, candidates []string, n int) []string {
+	type scored struct {
+		candidate string
+		distance  int
+	}
+	scores := make([]scored, 0, len(candidates))
+	for _, candidate := range candidates {
+		scores = append(scores, scored{candidate, editdistance.Levenshtein(strings.ToLower(reference), strings.ToLower(candidate))})
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].distance != scores[j].distance {
+			return scores[i].distance < scores[j].distance
+		}
+		return scores[i].candidate < scores[j].candidate
+	})
+
+	closest := make([]string, 0, n)
+	for i := 0; i < len(scores) && i < n; i++ {
+		closest = append(closest, scores[i].candidate)
+	}
+	return closest
+}
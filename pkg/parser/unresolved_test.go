@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnresolvedAttributes(t *testing.T) {
+	def := &SORDefinition{
+		Entities: map[string]Entity{
+			"user": {ExternalId: "User", Attributes: []Attribute{
+				{Name: "id", ExternalId: "id", UniqueId: true},
+				{Name: "managerId", ExternalId: "managerId", AttributeAlias: "user-manager-alias"},
+			}},
+			"member": {ExternalId: "GroupMember", Attributes: []Attribute{
+				{Name: "id", ExternalId: "id", UniqueId: true},
+				{Name: "userId", ExternalId: "userId"},
+			}},
+		},
+		Relationships: map[string]Relationship{
+			"member":   {FromAttribute: "GroupMember.userID", ToAttribute: "User.id"},
+			"manager":  {FromAttribute: "user-manger-alias", ToAttribute: "Users.id"},
+			"resolved": {FromAttribute: "user-manager-alias", ToAttribute: "User.id"},
+			"external": {FromAttribute: "GroupMember.userId", ToAttribute: "Account.id", ExternalDirectory: "../other"},
+			"path":     {Path: []RelationshipPath{{Relationship: "member"}}},
+		},
+	}
+
+	unresolved := UnresolvedAttributes(def)
+	assert.Equal(t, []UnresolvedAttribute{
+		{Relationship: "manager", Field: "fromAttribute", Reference: "user-manger-alias",
+			Candidates: []string{"user-manager-alias", "User.managerId", "User.id"}},
+		{Relationship: "manager", Field: "toAttribute", Reference: "Users.id",
+			Candidates: []string{"User.id", "User.managerId", "GroupMember.id"}},
+		{Relationship: "member", Field: "fromAttribute", Reference: "GroupMember.userID",
+			Candidates: []string{"GroupMember.userId", "GroupMember.id", "User.id"}},
+	}, unresolved)
+	assert.Equal(t, "relationship member: fromAttribute 'GroupMember.userID' does not match any entity attribute "+
+		"(closest: GroupMember.userId, GroupMember.id, User.id)", unresolved[2].String())
+}
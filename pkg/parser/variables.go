@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// variableReference matches ${VAR} and ${VAR:-default} in SOR string values, and
+// $${ escaping a literal ${
+var variableReference = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// variableName matches the names a vars file may set
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// LoadVariables reads a vars file: a YAML mapping of variable names to the values
+// substituted for ${NAME} in a SOR
+func LoadVariables(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path is from CLI argument
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReadFile, err)
+	}
+	var variables map[string]string
+	if err := yaml.Unmarshal(data, &variables); err != nil {
+		return nil, fmt.Errorf("%w: vars file %s: %w", ErrInvalidYAML, path, err)
+	}
+	for name := range variables {
+		if !variableName.MatchString(name) {
+			return nil, fmt.Errorf("%w: vars file %s: '%s' is not a variable name (letters, digits and _, not starting with a digit)",
+				ErrVariables, path, name)
+		}
+	}
+	return variables, nil
+}
+
+// substituteVariables replaces the variable references in the string values of a
+// SOR's YAML, not its keys, with their values: those of variables first, then the
+// environment's, then the reference's default. Values are substituted after the
+// YAML is parsed, so they can't change its structure. It returns data unchanged
+// when it holds no references. References to undefined variables are kept as
+// written, or with require, fail with an error naming every one.
+func substituteVariables(data []byte, variables map[string]string, require bool) ([]byte, error) {
+	if !bytes.Contains(data, []byte("${")) {
+		return data, nil
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidYAML, err)
+	}
+
+	undefined := make(map[string]bool)
+	substitute := func(value string) string {
+		return variableReference.ReplaceAllStringFunc(value, func(reference string) string {
+			if reference == "$${" {
+				return "${"
+			}
+			match := variableReference.FindStringSubmatch(reference)
+			name, hasDefault := match[1], strings.Contains(reference, ":-")
+			if value, ok := variables[name]; ok {
+				return value
+			}
+			if value, ok := os.LookupEnv(name); ok {
+				return value
+			}
+			if !hasDefault {
+				undefined[name] = true
+				return reference
+			}
+			return match[2]
+		})
+	}
+
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		switch node.Kind {
+		case yaml.ScalarNode:
+			if node.ShortTag() == "!!str" {
+				node.Value = substitute(node.Value)
+			}
+		case yaml.MappingNode:
+			for i := 1; i < len(node.Content); i += 2 {
+				walk(node.Content[i])
+			}
+		default:
+			for _, child := range node.Content {
+				walk(child)
+			}
+		}
+	}
+	walk(&document)
+
+	if require && len(undefined) > 0 {
+		names := make([]string, 0, len(undefined))
+		for name := range undefined {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("undefined variable(s) %s: set them in the environment or a vars file, or give a default with ${NAME:-default}",
+			strings.Join(names, ", "))
+	}
+	return yaml.Marshal(&document)
+}
@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const variablesSOR = `displayName: ${SOR_NAME}
+description: Costs $${AMOUNT} per ${UNIT:-seat}
+hostname: ${TENANT}.example.com
+adapterConfig: '{"region": "${REGION:-us-east-1}"}'
+entities:
+  user:
+    displayName: User
+    externalId: User
+    attributes:
+      - name: id
+        externalId: id
+        type: String
+        uniqueId: true
+`
+
+func TestSubstituteVariables(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sor.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(variablesSOR), 0600))
+
+	t.Run("should take values from the vars file, the environment, then defaults", func(t *testing.T) {
+		t.Setenv("TENANT", "staging")
+		t.Setenv("SOR_NAME", "From Env")
+
+		parser := NewParser(path)
+		parser.Variables = map[string]string{"SOR_NAME": "Test SOR"}
+		require.NoError(t, parser.Parse())
+		assert.Equal(t, "Test SOR", parser.Definition.DisplayName)
+		assert.Equal(t, "Costs ${AMOUNT} per seat", parser.Definition.Description, "$${ escapes a reference")
+		assert.Equal(t, "staging.example.com", parser.Definition.Hostname)
+		assert.Equal(t, `{"region": "us-east-1"}`, parser.Definition.AdapterConfig)
+	})
+
+	t.Run("should keep substituted values strings", func(t *testing.T) {
+		parser := NewParser(path)
+		parser.Variables = map[string]string{"SOR_NAME": "true", "TENANT": "prod: {eu}"}
+		require.NoError(t, parser.Parse())
+		assert.Equal(t, "true", parser.Definition.DisplayName)
+		assert.Equal(t, "prod: {eu}.example.com", parser.Definition.Hostname)
+	})
+
+	t.Run("should keep undefined variables as written", func(t *testing.T) {
+		parser := NewParser(path)
+		require.NoError(t, parser.Parse())
+		assert.Equal(t, "${SOR_NAME}", parser.Definition.DisplayName)
+		assert.Equal(t, "${TENANT}.example.com", parser.Definition.Hostname)
+		assert.Equal(t, `{"region": "us-east-1"}`, parser.Definition.AdapterConfig, "defaults still apply")
+	})
+
+	t.Run("should name every undefined variable when required", func(t *testing.T) {
+		parser := NewParser(path)
+		parser.RequireVariables = true
+		err := parser.Parse()
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrVariables))
+		assert.Contains(t, err.Error(), "undefined variable(s) SOR_NAME, TENANT")
+	})
+}
+
+func TestLoadVariables(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vars.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("TENANT: prod\nPORT: 8443\n"), 0600))
+	variables, err := LoadVariables(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"TENANT": "prod", "PORT": "8443"}, variables)
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalid, []byte("tenant-name: prod\n"), 0600))
+	_, err = LoadVariables(invalid)
+	assert.ErrorIs(t, err, ErrVariables)
+	assert.ErrorContains(t, err, "'tenant-name' is not a variable name")
+
+	_, err = LoadVariables(filepath.Join(dir, "missing.yaml"))
+	assert.ErrorIs(t, err, ErrReadFile)
+}
@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// WeightOperators lists the operators a weight's condition can use
+var WeightOperators = []string{"contains", "==", "!=", "<=", ">=", "<", ">"}
+
+// weightCondition matches "<attribute> <op> <value>"
+var weightCondition = regexp.MustCompile(`^\s*(\S+)\s+(contains|<=|>=|==|!=|<|>)\s+(.+?)\s*$`)
+
+// ParseWeightCondition splits a weight's condition into its target attribute (name
+// or externalId), operator and value, with quotes around the value removed
+func ParseWeightCondition(when string) (attribute, op, value string, err error) {
+	match := weightCondition.FindStringSubmatch(when)
+	if match == nil {
+		return "", "", "", fmt.Errorf("invalid weight condition '%s' (expected <attribute> <op> <value>, op one of %s)",
+			when, strings.Join(WeightOperators, " "))
+	}
+	return match[1], match[2], strings.Trim(match[3], `"'`), nil
+}
+
+// validateWeights checks that a relationship's weights have valid conditions and
+// aren't negative. Their attributes are resolved with the relationship's target.
+func validateWeights(weights []TargetWeight) error {
+	for _, weight := range weights {
+		if _, _, _, err := ParseWeightCondition(weight.When); err != nil {
+			return err
+		}
+		if weight.Weight < 0 {
+			return fmt.Errorf("weight %g for '%s' is negative", weight.Weight, weight.When)
+		}
+	}
+	return nil
+}
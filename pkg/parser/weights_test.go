@@ -0,0 +1,29 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWeightCondition(t *testing.T) {
+	attribute, op, value, err := ParseWeightCondition(`title contains "Vice President"`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"title", "contains", "Vice President"}, []string{attribute, op, value})
+
+	attribute, op, value, err = ParseWeightCondition("level >= 7")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"level", ">=", "7"}, []string{attribute, op, value})
+
+	for _, when := range []string{"title", "title ~ Manager", "title contains", "titlecontains Manager"} {
+		_, _, _, err := ParseWeightCondition(when)
+		assert.ErrorContains(t, err, "invalid weight condition", when)
+	}
+}
+
+func TestValidateWeights(t *testing.T) {
+	assert.NoError(t, validateWeights([]TargetWeight{{When: "title contains Manager", Weight: 3}, {When: "status == inactive", Weight: 0}}))
+	assert.ErrorContains(t, validateWeights([]TargetWeight{{When: "title contains Manager", Weight: -1}}), "is negative")
+	assert.ErrorContains(t, validateWeights([]TargetWeight{{When: "Manager", Weight: 2}}), "invalid weight condition")
+}
@@ -14,6 +14,7 @@ type SORDefinition struct {
 	Auth                      []map[string]AuthConfig `yaml:"auth"`
 	Entities                  map[string]Entity       `yaml:"entities"`
 	Relationships             map[string]Relationship `yaml:"relationships"`
+	Assertions                []Assertion             `yaml:"assertions,omitempty"` // Checks evaluated over the generated rows
 }
 
 // AuthConfig represents authentication configuration
@@ -23,28 +24,185 @@ type AuthConfig struct {
 
 // Entity represents a data entity in the SOR
 type Entity struct {
-	DisplayName        string      `yaml:"displayName"`
-	ExternalId         string      `yaml:"externalId"`
-	Description        string      `yaml:"description"`
-	PagesOrderedById   bool        `yaml:"pagesOrderedById"`
-	Attributes         []Attribute `yaml:"attributes"`
-	EntityAlias        string      `yaml:"entityAlias"`
-	SyncFrequency      string      `yaml:"syncFrequency,omitempty"`
-	SyncMinInterval    int         `yaml:"syncMinInterval,omitempty"`
-	ApiCallFrequency   string      `yaml:"apiCallFrequency,omitempty"`
-	ApiCallMinInterval int         `yaml:"apiCallMinInterval,omitempty"`
+	DisplayName        string              `yaml:"displayName"`
+	ExternalId         string              `yaml:"externalId"`
+	Description        string              `yaml:"description"`
+	PagesOrderedById   bool                `yaml:"pagesOrderedById"`
+	Attributes         []Attribute         `yaml:"attributes"`
+	EntityAlias        string              `yaml:"entityAlias"`
+	SyncFrequency      string              `yaml:"syncFrequency,omitempty"`
+	SyncMinInterval    int                 `yaml:"syncMinInterval,omitempty"`
+	ApiCallFrequency   string              `yaml:"apiCallFrequency,omitempty"`
+	ApiCallMinInterval int                 `yaml:"apiCallMinInterval,omitempty"`
+	Correlations       []Correlation       `yaml:"correlations,omitempty"`     // Optional correlations between numeric attributes
+	CloneOf            string              `yaml:"cloneOf,omitempty"`          // Key of an entity whose definition this one copies
+	RemoveAttributes   []string            `yaml:"removeAttributes,omitempty"` // Names of cloned attributes to drop
+	Domain             string              `yaml:"domain,omitempty"`           // Logical group (e.g. identity, billing) for diagrams and output folders
+	Timeline           *Timeline           `yaml:"timeline,omitempty"`         // Optional clustering of a creation timestamp around go-live dates
+	EffectiveDating    *EffectiveDating    `yaml:"effectiveDating,omitempty"`  // Rows are versions of records, each valid over a window of dates
+	Tags               []string            `yaml:"tags,omitempty"`             // Labels (e.g. beta, optional) selecting the entity with --with-tags
+	Data               []map[string]string `yaml:"data,omitempty"`             // Rows used verbatim instead of generated ones, keyed by attribute name or externalId
+	Payloads           *Payloads           `yaml:"payloads,omitempty"`         // Whether rows' generated columns must differ, or repeat on purpose
+	Junction           *Junction           `yaml:"junction,omitempty"`         // How a self-join junction table pairs its keys
+	Derived            *Derived            `yaml:"derived,omitempty"`          // Rows computed from other entities' generated rows
+	PartitionBy        string              `yaml:"partitionBy,omitempty"`      // Name of an attribute splitting the output into one file per value
+	Profile            string              `yaml:"profile,omitempty"`          // Path of a JSON statistical profile shaping the generated columns, relative to the SOR file
+}
+
+// Assertion is an expectation about the generated data, checked over every row of
+// an entity (or those matching Where) once generation finishes. It sets exactly one
+// of References, ReferencedBy or Compare:
+//
+//	assertions:
+//	  - name: every active user has a role
+//	    entity: User
+//	    where: {status: active}
+//	    referencedBy: UserRole
+//	    min: 1
+//	  - name: no ticket closed before created
+//	    entity: Ticket
+//	    compare: closedAt >= createdAt
+//	  - name: few orphan assets
+//	    entity: Asset
+//	    references: ownerId
+//	    tolerance: 5%
+type Assertion struct {
+	Name      string            `yaml:"name"`
+	Entity    string            `yaml:"entity"`              // External ID of the entity whose rows are checked
+	Where     map[string]string `yaml:"where,omitempty"`     // Only rows whose attributes hold these values are checked
+	Tolerance string            `yaml:"tolerance,omitempty"` // Failing rows allowed: a percentage ("5%") or a count; none by default
+	Severity  string            `yaml:"severity,omitempty"`  // error (default) fails the run, warn only reports
+
+	// References is a foreign key attribute that must reference an existing row
+	References string `yaml:"references,omitempty"`
+
+	// ReferencedBy is an entity whose rows must reference each checked row between
+	// Min (default 1) and Max times, through the foreign key Via or any of them
+	ReferencedBy string `yaml:"referencedBy,omitempty"`
+	Via          string `yaml:"via,omitempty"`
+	Min          *int   `yaml:"min,omitempty"`
+	Max          *int   `yaml:"max,omitempty"`
+
+	// Compare is "<attribute> <op> <attribute or value>" with op one of < <= > >= ==
+	// !=, comparing numbers, dates or text; rows with either side empty are skipped
+	Compare string `yaml:"compare,omitempty"`
+}
+
+// Derived makes an entity a view over generated data: it gets one row per distinct
+// value of the From attribute, computed once the other entities are generated, such
+// as a UserSummary counting each user's roles and groups in their junction tables
+type Derived struct {
+	From       string      `yaml:"from"`                 // Entity.attribute (externalIds) whose distinct values give one row each
+	Key        string      `yaml:"key"`                  // Name of the uniqueId attribute holding the From value
+	Aggregates []Aggregate `yaml:"aggregates,omitempty"` // Attributes computed from the rows referencing the From value
+}
+
+// Aggregate computes an attribute of a derived entity from the rows of another
+// entity whose Over attribute holds the derived row's From value
+type Aggregate struct {
+	Attribute string `yaml:"attribute"`       // Name of the derived entity's attribute receiving the result
+	Function  string `yaml:"function"`        // count, sum, min or max
+	Over      string `yaml:"over"`            // Entity.attribute (externalIds) referencing the From value, e.g. UserRole.userId
+	Value     string `yaml:"value,omitempty"` // Name of the Over entity's attribute summed or compared; not used by count
+}
+
+// Junction controls the pairs of a junction table whose foreign keys reference the
+// same attribute, such as a user_peers table with two keys into User
+type Junction struct {
+	Unordered   bool `yaml:"unordered,omitempty"`   // (a,b) and (b,a) are the same pair, so only one appears
+	NoSelfPairs bool `yaml:"noSelfPairs,omitempty"` // No row pairs a key with itself
+}
+
+// Payloads controls whether the generated, non-key columns of an entity's rows
+// repeat: Distinct guarantees no two rows share them all, while DuplicateShare
+// makes a fraction of the rows copy another row's, to exercise dedup logic
+type Payloads struct {
+	Distinct       bool    `yaml:"distinct,omitempty"`       // No two rows have the same payload
+	DuplicateShare float64 `yaml:"duplicateShare,omitempty"` // Fraction of rows repeating another row's payload, in (0, 1)
+}
+
+// Timeline shapes when an entity's records were created: shares of the rows fall
+// inside clusters (e.g. an onboarding window after go-live) and the rest form a
+// long tail that thins out towards To
+type Timeline struct {
+	Attribute string            `yaml:"attribute"`          // Name of the Date or DateTime attribute holding the creation time
+	From      string            `yaml:"from"`               // Earliest creation time (2006-01-02 or RFC3339)
+	To        string            `yaml:"to"`                 // Latest creation time (2006-01-02 or RFC3339)
+	Clusters  []TimelineCluster `yaml:"clusters,omitempty"` // Windows holding fixed shares of the rows
+}
+
+// EffectiveDating makes an entity's rows dated versions of records, as in HR systems
+// where a job or salary row is valid from one date until the next version replaces
+// it. Rows sharing a Key value are one record's versions: their windows start in
+// order between From and To, at most MaxOverlap of them are valid at any moment, and
+// the latest is open-ended and current.
+//
+//	effectiveDating:
+//	  key: employeeId
+//	  validFrom: effectiveFrom
+//	  validTo: effectiveTo
+//	  current: isCurrent
+//	  from: 2019-01-01
+//	  to: 2025-01-01
+type EffectiveDating struct {
+	Key        string `yaml:"key"`                  // Name of the attribute identifying the record, e.g. a foreign key to Employee
+	ValidFrom  string `yaml:"validFrom"`            // Name of the Date or DateTime attribute where a version's window starts
+	ValidTo    string `yaml:"validTo"`              // Name of the Date or DateTime attribute where it ends (exclusive); empty for the latest version
+	Current    string `yaml:"current,omitempty"`    // Name of a Boolean attribute, true for each record's latest version
+	From       string `yaml:"from"`                 // Earliest window start (2006-01-02 or RFC3339)
+	To         string `yaml:"to"`                   // Date the records are current as of; windows start and end before it
+	MaxOverlap int    `yaml:"maxOverlap,omitempty"` // Versions of a record valid at the same time; 1 (the default) means none overlap
+}
+
+// TimelineCluster places a share of an entity's rows in the days following a date
+type TimelineCluster struct {
+	At         string  `yaml:"at"`         // Start of the window (2006-01-02 or RFC3339), e.g. a go-live date
+	WindowDays int     `yaml:"windowDays"` // Length of the window in days
+	Share      float64 `yaml:"share"`      // Fraction of the rows created inside the window, in (0, 1]
+}
+
+// Correlation declares a target Pearson correlation between two numeric attributes of an entity
+type Correlation struct {
+	Attributes  []string `yaml:"attributes"`  // Names of the two correlated attributes
+	Coefficient float64  `yaml:"coefficient"` // Target correlation in [-1, 1]
 }
 
 // Attribute represents an attribute of an entity
 type Attribute struct {
-	Name           string `yaml:"name"`
-	ExternalId     string `yaml:"externalId"`
-	Description    string `yaml:"description"`
-	Type           string `yaml:"type"`
-	Indexed        bool   `yaml:"indexed"`
-	UniqueId       bool   `yaml:"uniqueId,omitempty"`       // Defaults to false when not specified
-	AttributeAlias string `yaml:"attributeAlias,omitempty"` // Optional in some YAML formats
-	List           bool   `yaml:"list,omitempty"`           // Optional in some YAML formats
+	Name           string        `yaml:"name"`
+	ExternalId     string        `yaml:"externalId"`
+	Description    string        `yaml:"description"`
+	Type           string        `yaml:"type"`
+	Indexed        bool          `yaml:"indexed"`
+	UniqueId       bool          `yaml:"uniqueId,omitempty"`       // Defaults to false when not specified
+	AttributeAlias string        `yaml:"attributeAlias,omitempty"` // Optional in some YAML formats
+	List           bool          `yaml:"list,omitempty"`           // Optional in some YAML formats
+	Generator      *Generator    `yaml:"generator,omitempty"`      // Optional built-in value generator
+	UniqueWithin   string        `yaml:"uniqueWithin,omitempty"`   // Name of an attribute scoping this attribute's uniqueness (e.g. tenantId)
+	Const          *string       `yaml:"const,omitempty"`          // Value every row gets (e.g. sorType: okta)
+	Default        *string       `yaml:"default,omitempty"`        // Value used when no generator or name inference applies
+	ListEncoding   string        `yaml:"listEncoding,omitempty"`   // How a list attribute's values are written: json, semicolon or rows
+	Sensitive      bool          `yaml:"sensitive,omitempty"`      // Masked or hashed in the redacted copy of the output
+	Lookup         *Lookup       `yaml:"lookup,omitempty"`         // Copies an attribute of the row a foreign key references
+	Transform      *Transform    `yaml:"transform,omitempty"`      // Rewrites values as they are written (case, padding, prefix, suffix)
+	SharedValues   *SharedValues `yaml:"sharedValues,omitempty"`   // Takes part of its values from another entity's attribute
+	TimeZone       *TimeZone     `yaml:"timeZone,omitempty"`       // Zone a DateTime attribute's values are written in
+}
+
+// SharedValues makes an attribute take part of its values from an attribute of
+// another entity that no relationship links it to, e.g. Asset.ownerEmail holding
+// some User.email values, so joins on the two columns match a known share of rows
+type SharedValues struct {
+	From    string  `yaml:"from"`    // Entity.attribute (externalIds) whose values are shared
+	Overlap float64 `yaml:"overlap"` // Fraction of the rows taking a value from From, in (0, 1]
+}
+
+// Lookup copies into an attribute the value of an attribute on the row referenced
+// by one of the entity's foreign keys, e.g. Ticket.assigneeEmail from the User row
+// Ticket.assigneeId references
+type Lookup struct {
+	ForeignKey string `yaml:"foreignKey"` // Name of the entity's foreign key attribute
+	Attribute  string `yaml:"attribute"`  // Name of the attribute copied from the referenced row
 }
 
 // RelationshipPath represents a path step in a relationship
@@ -61,6 +219,34 @@ type Relationship struct {
 	ToAttribute   string             `yaml:"toAttribute,omitempty"`
 	Path          []RelationshipPath `yaml:"path,omitempty"`
 	ChildEntity   string             `yaml:"childEntity,omitempty"`
+	Validation    string             `yaml:"validation,omitempty"` // skip, warn or error (default)
+	// Where limits the target rows foreign keys reference to those whose attributes
+	// (names or externalIds) hold these values, e.g. {status: active}
+	Where map[string]string `yaml:"where,omitempty"`
+	// ExternalDirectory holds another SOR's generated CSVs; toAttribute then names
+	// <entity>.<column> in that directory instead of an entity in this definition
+	ExternalDirectory string `yaml:"externalDirectory,omitempty"`
+	// Sampling streams the external key file instead of loading every key, so
+	// memory stays flat however large the referenced entity is
+	Sampling *KeySampling `yaml:"sampling,omitempty"`
+	// Weights make target rows matching their conditions more or less likely to be
+	// referenced, e.g. managers holding more group memberships
+	Weights []TargetWeight `yaml:"weights,omitempty"`
+}
+
+// TargetWeight scales how often a relationship references the target rows matching
+// a condition; a row matching several weights has them multiplied, and one matching
+// none has weight 1
+type TargetWeight struct {
+	When   string  `yaml:"when"`   // "<attribute> <op> <value>", e.g. "title contains Manager"
+	Weight float64 `yaml:"weight"` // Relative weight, 0 for rows never referenced
+}
+
+// KeySampling chooses how an external relationship's foreign keys are drawn from
+// its key file as the file is streamed
+type KeySampling struct {
+	Method string `yaml:"method"`         // reservoir (random keys) or stride (keys evenly spaced through the file)
+	Size   int    `yaml:"size,omitempty"` // Keys a reservoir holds; defaults to one per row to assign
 }
 
 // RelationshipLink represents a link between two entities for data generation purposes
@@ -0,0 +1,256 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SGNL-ai/fabricator/pkg/events"
+)
+
+//go:embed report.html.tmpl
+var reportTemplate string
+
+// Report is an event sink that collects a run's progress and, when closed, writes
+// a single self-contained HTML page summarizing it: entity counts and timing, phase
+// timing, validation issues and warnings grouped by type, the ER diagram and the
+// configuration used.
+type Report struct {
+	path        string
+	title       string
+	started     time.Time
+	config      []Setting
+	entities    map[string]*EntitySummary
+	order       []string // Entity external IDs in first-seen order
+	phases      []PhaseSummary
+	issues      []string
+	warnings    []string
+	diagramPath string
+}
+
+// Setting is one configuration value shown in the report
+type Setting struct {
+	Name  string
+	Value string
+}
+
+// EntitySummary holds the counts and timing recorded for one entity
+type EntitySummary struct {
+	Entity    string
+	Generated int
+	Written   int
+	WriteTime time.Duration
+}
+
+// PhaseSummary holds the duration of one completed run phase
+type PhaseSummary struct {
+	Phase    string
+	Duration time.Duration
+}
+
+// IssueGroup is a set of messages of the same type
+type IssueGroup struct {
+	Type     string
+	Messages []string
+}
+
+// New creates a report that is written to path when closed
+func New(path, title string) *Report {
+	return &Report{
+		path:     path,
+		title:    title,
+		started:  time.Now(),
+		entities: make(map[string]*EntitySummary),
+	}
+}
+
+// AddSetting records a configuration value to show in the report
+func (r *Report) AddSetting(name, value string) {
+	r.config = append(r.config, Setting{Name: name, Value: value})
+}
+
+// SetDiagram sets the ER diagram file to embed: SVG files are inlined and
+// other formats (DOT) are shown as source
+func (r *Report) SetDiagram(path string) {
+	r.diagramPath = path
+}
+
+// Emit records an event
+func (r *Report) Emit(event events.Event) error {
+	switch event.Type {
+	case events.PhaseFinished:
+		r.phases = append(r.phases, PhaseSummary{
+			Phase:    event.Phase,
+			Duration: time.Duration(event.DurationMs) * time.Millisecond,
+		})
+	case events.EntityGenerated:
+		r.entity(event.Entity).Generated = event.Rows
+	case events.RowsWritten:
+		summary := r.entity(event.Entity)
+		summary.Written = event.Rows
+		summary.WriteTime = time.Duration(event.DurationMs) * time.Millisecond
+	case events.ValidationIssue:
+		r.issues = append(r.issues, event.Message)
+	case events.Warning:
+		r.warnings = append(r.warnings, event.Message)
+	}
+	return nil
+}
+
+// entity returns the summary for an entity, creating it on first use
+func (r *Report) entity(externalID string) *EntitySummary {
+	summary, exists := r.entities[externalID]
+	if !exists {
+		summary = &EntitySummary{Entity: externalID}
+		r.entities[externalID] = summary
+		r.order = append(r.order, externalID)
+	}
+	return summary
+}
+
+// Close renders the report and writes it to its path
+func (r *Report) Close() error {
+	page, err := r.Render()
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(r.path); dir != "" {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return fmt.Errorf("failed to create report directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(r.path, []byte(page), 0600); err != nil {
+		return fmt.Errorf("failed to write HTML report: %w", err)
+	}
+	return nil
+}
+
+// Render returns the report as an HTML page
+func (r *Report) Render() (string, error) {
+	tmpl, err := template.New("report").Parse(reportTemplate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse report template: %w", err)
+	}
+
+	entities := make([]EntitySummary, 0, len(r.order))
+	totalRows := 0
+	for _, externalID := range r.order {
+		entities = append(entities, *r.entities[externalID])
+		totalRows += r.entities[externalID].Written
+	}
+
+	data := struct {
+		Title         string
+		Generated     string
+		Duration      time.Duration
+		Settings      []Setting
+		Entities      []EntitySummary
+		TotalRows     int
+		Phases        []PhaseSummary
+		Issues        []IssueGroup
+		IssueCount    int
+		Warnings      []IssueGroup
+		DiagramSVG    template.HTML
+		DiagramSource string
+		DiagramError  string
+	}{
+		Title:      r.title,
+		Generated:  time.Now().Format(time.RFC1123),
+		Duration:   time.Since(r.started).Round(time.Millisecond),
+		Settings:   r.config,
+		Entities:   entities,
+		TotalRows:  totalRows,
+		Phases:     r.phases,
+		Issues:     groupMessages(r.issues, issueTypes, "Other"),
+		IssueCount: len(r.issues),
+		Warnings:   groupMessages(r.warnings, warningTypes, "General"),
+	}
+
+	if r.diagramPath != "" {
+		content, err := os.ReadFile(r.diagramPath) // #nosec G304 - path is the diagram generated for this run
+		switch {
+		case err != nil:
+			data.DiagramError = fmt.Sprintf("diagram %s could not be read: %v", r.diagramPath, err)
+		case strings.EqualFold(filepath.Ext(r.diagramPath), ".svg"):
+			// The SVG is produced by Graphviz from the SOR definition, not user data
+			data.DiagramSVG = template.HTML(stripXMLProlog(string(content))) // #nosec G203
+		default:
+			data.DiagramSource = string(content)
+		}
+	}
+
+	var page strings.Builder
+	if err := tmpl.Execute(&page, data); err != nil {
+		return "", fmt.Errorf("failed to render report: %w", err)
+	}
+	return page.String(), nil
+}
+
+// messageType maps messages containing a marker to a display type
+type messageType struct {
+	marker string
+	name   string
+}
+
+// issueTypes classifies validation issues; the first matching marker wins
+var issueTypes = []messageType{
+	{marker: "CSV structure:", name: "CSV structure"},
+	{marker: "foreign key", name: "Referential integrity"},
+	{marker: "not unique within", name: "Scoped uniqueness"},
+	{marker: "duplicate value", name: "Uniqueness"},
+	{marker: "CSV file not found", name: "Missing files"},
+	{marker: "failed to load CSV", name: "Unreadable files"},
+	{marker: "relationship", name: "Relationships"},
+}
+
+// warningTypes classifies warnings; the first matching marker wins
+var warningTypes = []messageType{
+	{marker: "Cardinality warning", name: "Cardinality"},
+	{marker: "Truncation warning", name: "Truncation"},
+}
+
+// groupMessages groups messages by type, in type order with unmatched messages last
+func groupMessages(messages []string, types []messageType, fallback string) []IssueGroup {
+	grouped := make(map[string][]string)
+	for _, message := range messages {
+		name := fallback
+		for _, t := range types {
+			if strings.Contains(message, t.marker) {
+				name = t.name
+				break
+			}
+		}
+		grouped[name] = append(grouped[name], message)
+	}
+
+	rank := make(map[string]int, len(types))
+	for i, t := range types {
+		if _, exists := rank[t.name]; !exists {
+			rank[t.name] = i
+		}
+	}
+	rank[fallback] = len(types)
+
+	groups := make([]IssueGroup, 0, len(grouped))
+	for name, groupMessages := range grouped {
+		groups = append(groups, IssueGroup{Type: name, Messages: groupMessages})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return rank[groups[i].Type] < rank[groups[j].Type]
+	})
+	return groups
+}
+
+// stripXMLProlog removes the XML declaration and DOCTYPE that precede the <svg>
+// element so the SVG can be inlined in HTML
+func stripXMLProlog(svg string) string {
+	if start := strings.Index(svg, "<svg"); start > 0 {
+		return svg[start:]
+	}
+	return svg
+}
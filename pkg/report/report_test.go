@@ -0,0 +1,93 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	t.Run("summarizes entities, phases and issues", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "out", "report.html")
+		r := New(path, "Fabricator report: okta.sgnl.yaml")
+		r.AddSetting("Input file", "okta.sgnl.yaml")
+
+		emitter := events.NewEmitter(r)
+		emitter.EntityGenerated("User", 10)
+		emitter.EntityGenerated("Group", 3)
+		emitter.Emit(events.Event{Type: events.PhaseFinished, Phase: "fields", DurationMs: 12})
+		emitter.EntityWritten("User", 10, 0)
+		emitter.EntityWritten("Group", 3, 0)
+		emitter.ValidationIssue("entity Member: row 2: foreign key value 'x' does not exist in related entity 'User.id'")
+		emitter.ValidationIssue("CSV structure: Member.csv: missing column 'groupId'")
+		emitter.ValidationIssue("something unexpected <b>")
+		emitter.Warning("Truncation warning: Profile requests 15 rows")
+		require.NoError(t, emitter.Close())
+
+		content, err := os.ReadFile(path) // #nosec G304 - test file
+		require.NoError(t, err)
+		page := string(content)
+
+		assert.Contains(t, page, "<title>Fabricator report: okta.sgnl.yaml</title>")
+		assert.Contains(t, page, "<td>User</td><td class=\"num\">10</td><td class=\"num\">10</td>")
+		assert.Contains(t, page, "<th class=\"num\">13</th>", "total rows written")
+		assert.Contains(t, page, "<td>fields</td><td class=\"num\">12ms</td>")
+		assert.Contains(t, page, "3 issue(s) found.")
+		assert.Contains(t, page, "CSV structure (1)")
+		assert.Contains(t, page, "Referential integrity (1)")
+		assert.Contains(t, page, "Other (1)")
+		assert.Contains(t, page, "something unexpected &lt;b&gt;", "messages are escaped")
+		assert.Contains(t, page, "Truncation (1)")
+		assert.Contains(t, page, "<tr><th>Input file</th><td>okta.sgnl.yaml</td></tr>")
+	})
+
+	t.Run("reports a clean run", func(t *testing.T) {
+		page, err := New("unused.html", "clean").Render()
+		require.NoError(t, err)
+		assert.Contains(t, page, "No validation issues.")
+		assert.NotContains(t, page, "<h2>Warnings</h2>")
+		assert.NotContains(t, page, "<h2>ER Diagram</h2>")
+	})
+
+	t.Run("inlines SVG diagrams", func(t *testing.T) {
+		diagram := filepath.Join(t.TempDir(), "erd.svg")
+		require.NoError(t, os.WriteFile(diagram, []byte("<?xml version=\"1.0\"?>\n<svg width=\"10\"><g/></svg>"), 0600))
+
+		r := New("unused.html", "diagram")
+		r.SetDiagram(diagram)
+		page, err := r.Render()
+		require.NoError(t, err)
+		assert.Contains(t, page, "<div class=\"diagram\"><svg width=\"10\"><g/></svg></div>")
+		assert.NotContains(t, page, "<?xml")
+	})
+
+	t.Run("shows DOT diagrams as source", func(t *testing.T) {
+		diagram := filepath.Join(t.TempDir(), "erd.dot")
+		require.NoError(t, os.WriteFile(diagram, []byte("digraph { A -> B }"), 0600))
+
+		r := New("unused.html", "diagram")
+		r.SetDiagram(diagram)
+		page, err := r.Render()
+		require.NoError(t, err)
+		assert.Contains(t, page, "<pre>digraph { A -&gt; B }</pre>")
+	})
+}
+
+func TestGroupMessages(t *testing.T) {
+	groups := groupMessages([]string{
+		"unrelated",
+		"entity User: duplicate value '1' for unique attribute 'id'",
+		"entity Member: row 1: email 'a' is not unique within tenantId 't' (first used in row 0)",
+		"CSV file not found for entity User: /tmp/User.csv",
+	}, issueTypes, "Other")
+
+	var types []string
+	for _, group := range groups {
+		types = append(types, group.Type)
+	}
+	assert.Equal(t, []string{"Scoped uniqueness", "Uniqueness", "Missing files", "Other"}, types)
+}
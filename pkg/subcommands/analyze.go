@@ -0,0 +1,123 @@
+package subcommands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/config"
+	"github.com/SGNL-ai/fabricator/pkg/console"
+	"github.com/SGNL-ai/fabricator/pkg/generators"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/orchestrator"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// DefaultAnalyzeRows is the planned row count of entities without a configured count
+const DefaultAnalyzeRows = 100
+
+// AnalyzeOptions holds the options for the analyze subcommand
+type AnalyzeOptions struct {
+	// SORFile is the path to the SOR YAML definition file
+	SORFile string
+
+	// CountConfigFile is an optional row count configuration
+	CountConfigFile string
+
+	// DataVolume is the row count of entities the count configuration doesn't list
+	// (defaults to DefaultAnalyzeRows)
+	DataVolume int
+
+	// Output is where to write the analysis (defaults to stdout)
+	Output io.Writer
+}
+
+// Analyze reports what generation would produce for a SOR and row counts without
+// writing any data: each entity's planned rows, each relationship's cardinality and
+// why it was chosen, and the truncation warnings generation would give
+func Analyze(opts AnalyzeOptions) error {
+	if opts.SORFile == "" {
+		return fmt.Errorf("SOR file path is required")
+	}
+	if opts.DataVolume <= 0 {
+		opts.DataVolume = DefaultAnalyzeRows
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+
+	p := parser.NewParser(opts.SORFile)
+	if err := p.Parse(); err != nil {
+		return fmt.Errorf("failed to parse SOR file: %w", err)
+	}
+	def := p.Definition
+
+	var countConfig *config.CountConfiguration
+	if opts.CountConfigFile != "" {
+		cfg, err := config.LoadConfiguration(opts.CountConfigFile)
+		if err != nil {
+			return fmt.Errorf("failed to load count configuration: %w", err)
+		}
+		entityIDs := make([]string, 0, len(def.Entities))
+		for _, entity := range def.Entities {
+			entityIDs = append(entityIDs, entity.ExternalId)
+		}
+		if err := cfg.Validate(entityIDs); err != nil {
+			return fmt.Errorf("count configuration validation failed: %w", err)
+		}
+		countConfig = cfg
+	}
+	rowCounts := orchestrator.BuildRowCountsMap(def, countConfig, opts.DataVolume)
+
+	graphInterface, err := model.NewGraph(def, opts.DataVolume)
+	if err != nil {
+		return fmt.Errorf("failed to create graph: %w", err)
+	}
+	graph, ok := graphInterface.(*model.Graph)
+	if !ok {
+		return fmt.Errorf("failed to convert graph to concrete type")
+	}
+
+	return writeAnalysis(opts.Output, graph, rowCounts)
+}
+
+// writeAnalysis writes the entities, relationships and warnings sections
+func writeAnalysis(w io.Writer, graph *model.Graph, rowCounts map[string]int) error {
+	var out strings.Builder
+
+	entities := graph.GetEntitiesList()
+	total := 0
+	fmt.Fprintf(&out, "Entities (%d):\n", len(entities))
+	for _, entity := range entities {
+		rows := rowCounts[entity.GetExternalID()]
+		total += rows
+		fmt.Fprintf(&out, "  %s: %d rows, %d attributes, key %s\n", entity.GetExternalID(), rows,
+			len(entity.GetAttributes()), entity.GetPrimaryKey().GetExternalID())
+	}
+	fmt.Fprintf(&out, "  Total: %d rows\n", total)
+
+	relationships := graph.GetAllRelationships()
+	fmt.Fprintf(&out, "\nRelationships (%d):\n", len(relationships))
+	for _, relationship := range relationships {
+		fmt.Fprintf(&out, console.Text("  %s: %s.%s → %s.%s, %s\n"), relationship.GetID(),
+			relationship.GetSourceEntity().GetExternalID(), relationship.GetSourceAttribute().GetExternalID(),
+			relationship.GetTargetEntity().GetExternalID(), relationship.GetTargetAttribute().GetExternalID(),
+			relationship.GetCardinality())
+		if reason := relationship.GetCardinalityReason(); reason != "" {
+			fmt.Fprintf(&out, "    because %s\n", reason)
+		}
+		if inverse := relationship.GetInverseID(); inverse != "" {
+			fmt.Fprintf(&out, "    also generates its inverse %s\n", inverse)
+		}
+	}
+
+	truncations := generators.DetectTruncation(graph, rowCounts)
+	fmt.Fprintf(&out, "\nWarnings (%d):\n", len(truncations))
+	for _, truncation := range truncations {
+		fmt.Fprintf(&out, console.Text("  • %s\n"), truncation.String())
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
@@ -0,0 +1,43 @@
+package subcommands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyze(t *testing.T) {
+	dir := t.TempDir()
+	sorPath := filepath.Join(dir, "sor.yaml")
+	require.NoError(t, os.WriteFile(sorPath, []byte(traceSOR), 0600))
+	countsPath := filepath.Join(dir, "counts.yaml")
+	require.NoError(t, os.WriteFile(countsPath, []byte("User: 2\nMembership: 10\n"), 0600))
+
+	var buf bytes.Buffer
+	require.NoError(t, Analyze(AnalyzeOptions{SORFile: sorPath, CountConfigFile: countsPath, DataVolume: 3, Output: &buf}))
+	output := buf.String()
+
+	assert.Contains(t, output, "Entities (3):\n  Group: 3 rows, 2 attributes, key id\n")
+	assert.Contains(t, output, "  Membership: 10 rows, 3 attributes, key id\n")
+	assert.Contains(t, output, "  Total: 15 rows\n")
+	assert.Contains(t, output, "  Member: Membership.userId → User.id, N:1\n    because User.id is a unique ID and Membership.userId is not\n")
+	assert.Contains(t, output, "    also generates its inverse UserMemberships\n")
+	assert.Contains(t, output, "Warnings (1):\n  • Truncation warning: Membership requests 10 rows")
+
+	t.Run("without row counts every entity gets the default", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, Analyze(AnalyzeOptions{SORFile: sorPath, Output: &buf}))
+		assert.Contains(t, buf.String(), "  Total: 300 rows\n")
+		assert.Contains(t, buf.String(), "Warnings (0):\n")
+	})
+
+	t.Run("unknown entities in the count configuration are rejected", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(countsPath, []byte("Users: 2\n"), 0600))
+		err := Analyze(AnalyzeOptions{SORFile: sorPath, CountConfigFile: countsPath})
+		assert.ErrorContains(t, err, "count configuration validation failed")
+	})
+}
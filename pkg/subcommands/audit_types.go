@@ -0,0 +1,92 @@
+package subcommands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/generators/pipeline"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// AuditTypesOptions holds the options for the audit-types subcommand
+type AuditTypesOptions struct {
+	// SORFile is the path to the SOR YAML definition file
+	SORFile string
+
+	// Output is where to write the report (defaults to stdout)
+	Output io.Writer
+}
+
+// AuditTypes writes, for every attribute of a SOR, which generator or heuristic
+// produces its values: a name pattern such as email or date, its data type, or the
+// random words strings fall back to. Mis-detected columns can then be fixed with a
+// generator hint, a default or a rename before any data is generated.
+func AuditTypes(opts AuditTypesOptions) error {
+	if opts.SORFile == "" {
+		return fmt.Errorf("SOR file path is required")
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+
+	p := parser.NewParser(opts.SORFile)
+	if err := p.Parse(); err != nil {
+		return fmt.Errorf("failed to parse SOR file: %w", err)
+	}
+	graphInterface, err := model.NewGraph(p.Definition, 0)
+	if err != nil {
+		return fmt.Errorf("failed to create graph: %w", err)
+	}
+	graph, ok := graphInterface.(*model.Graph)
+	if !ok {
+		return fmt.Errorf("failed to convert graph to concrete type")
+	}
+
+	sources := pipeline.ValueSources(graph)
+	var out strings.Builder
+	var fallbacks, mismatches []string
+	for start := 0; start < len(sources); {
+		// Align the columns of each entity's attributes
+		end := start
+		nameWidth, typeWidth, sourceWidth := 0, 0, 0
+		for ; end < len(sources) && sources[end].Entity == sources[start].Entity; end++ {
+			nameWidth = max(nameWidth, len(sources[end].Attribute))
+			typeWidth = max(typeWidth, len(sources[end].Type))
+			sourceWidth = max(sourceWidth, len(sources[end].Source))
+		}
+
+		fmt.Fprintf(&out, "%s\n", sources[start].Entity)
+		for _, source := range sources[start:end] {
+			fmt.Fprintf(&out, "  %-*s  %-*s  %-*s  %s", nameWidth, source.Attribute, typeWidth, source.Type,
+				sourceWidth, source.Source, source.Detail)
+			if source.PersonField != "" {
+				fmt.Fprintf(&out, "; %s from --population", source.PersonField)
+			}
+			if source.Mismatch {
+				fmt.Fprintf(&out, "; not valid for %s", source.Type)
+				mismatches = append(mismatches, source.Entity+"."+source.Attribute)
+			}
+			out.WriteString("\n")
+			if source.Source == pipeline.ValueSourceFallback {
+				fallbacks = append(fallbacks, source.Entity+"."+source.Attribute)
+			}
+		}
+		start = end
+	}
+
+	if len(mismatches) > 0 {
+		fmt.Fprintf(&out, "\nName-based values that don't match the attribute's type: %s\n", strings.Join(mismatches, ", "))
+		out.WriteString("Their names take precedence over their types; rename them, or set a const or a profile column.\n")
+	}
+	if len(fallbacks) > 0 {
+		fmt.Fprintf(&out, "\n%d of %d attributes fall back to random words: %s\n", len(fallbacks), len(sources), strings.Join(fallbacks, ", "))
+		out.WriteString("Set a generator, a profile column, a default or a const for those that need realistic values.\n")
+	} else {
+		fmt.Fprintf(&out, "\nNone of %d attributes fall back to random words\n", len(sources))
+	}
+	_, err = io.WriteString(opts.Output, out.String())
+	return err
+}
@@ -0,0 +1,30 @@
+package subcommands
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditTypes(t *testing.T) {
+	sorPath := "../../examples/okta.sgnl.yaml"
+	if _, err := os.Stat(sorPath); os.IsNotExist(err) {
+		t.Skip("Skipping test: example SOR file not found")
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, AuditTypes(AuditTypesOptions{SORFile: sorPath, Output: &buf}))
+	out := buf.String()
+
+	assert.Contains(t, out, "GroupMember\n  id       String  key          uuid IDs\n  groupId  String  foreign key  references Group.id\n")
+	assert.Regexp(t, `profile__email +String +name +email addresses \(name starts or ends with 'email'\); email from --population\n`, out)
+	assert.Regexp(t, `statusChanged +DateTime +name +active, inactive or pending .*; not valid for DateTime\n`, out)
+	assert.Contains(t, out, "Name-based values that don't match the attribute's type: User.statusChanged")
+	assert.Regexp(t, `\n\d+ of \d+ attributes fall back to random words: Application.label, `, out)
+
+	err := AuditTypes(AuditTypesOptions{})
+	assert.ErrorContains(t, err, "SOR file path is required")
+}
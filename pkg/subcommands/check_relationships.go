@@ -0,0 +1,63 @@
+package subcommands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/errcode"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// CheckRelationshipsOptions holds the options for the check-relationships subcommand
+type CheckRelationshipsOptions struct {
+	// SORFile is the path to the SOR YAML definition file
+	SORFile string
+
+	// Output is where to write the diagnostics (defaults to stdout)
+	Output io.Writer
+}
+
+// CheckRelationships lists every relationship fromAttribute and toAttribute that
+// matches no entity attribute, each with the closest references across entities.
+// The SOR is loaded without the checks generation runs, so other problems in the
+// definition don't hide these. Returns an error matching parser.ErrRelationshipIssues
+// when any attribute is unresolved.
+func CheckRelationships(opts CheckRelationshipsOptions) error {
+	if opts.SORFile == "" {
+		return fmt.Errorf("SOR file path is required")
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+
+	p := parser.NewParser(opts.SORFile)
+	if err := p.Load(); err != nil {
+		return fmt.Errorf("failed to load SOR file: %w", err)
+	}
+
+	unresolved := parser.UnresolvedAttributes(p.Definition)
+	if len(unresolved) == 0 {
+		_, err := fmt.Fprintf(opts.Output, "All attributes of %d relationships resolve\n", len(p.Definition.Relationships))
+		return err
+	}
+
+	var out strings.Builder
+	relationships := 0
+	for i, attribute := range unresolved {
+		if i == 0 || unresolved[i-1].Relationship != attribute.Relationship {
+			fmt.Fprintf(&out, "%s\n", attribute.Relationship)
+			relationships++
+		}
+		fmt.Fprintf(&out, "  %s '%s' matches no attribute\n", attribute.Field, attribute.Reference)
+		if len(attribute.Candidates) > 0 {
+			fmt.Fprintf(&out, "    closest: %s\n", strings.Join(attribute.Candidates, ", "))
+		}
+	}
+	if _, err := io.WriteString(opts.Output, out.String()); err != nil {
+		return err
+	}
+	return errcode.Wrap(parser.ErrRelationshipIssues, fmt.Errorf("%d unresolved attributes in %d of %d relationships",
+		len(unresolved), relationships, len(p.Definition.Relationships)))
+}
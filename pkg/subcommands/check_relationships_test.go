@@ -0,0 +1,42 @@
+package subcommands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRelationships(t *testing.T) {
+	sorPath := "../../examples/okta.sgnl.yaml"
+	if _, err := os.Stat(sorPath); os.IsNotExist(err) {
+		t.Skip("Skipping test: example SOR file not found")
+	}
+
+	t.Run("Resolving relationships pass", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, CheckRelationships(CheckRelationshipsOptions{SORFile: sorPath, Output: &buf}))
+		assert.Contains(t, buf.String(), "relationships resolve")
+	})
+
+	t.Run("Every unresolved attribute is listed with the closest references", func(t *testing.T) {
+		data, err := os.ReadFile(sorPath)
+		require.NoError(t, err)
+		mistyped := strings.Replace(string(data), "fromAttribute: GroupMember.userId", "fromAttribute: GroupMember.userID", 1)
+		mistyped = strings.Replace(mistyped, "toAttribute: Group.id", "toAttribute: Groups.id", 1)
+		path := filepath.Join(t.TempDir(), "sor.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(mistyped), 0600))
+
+		var buf bytes.Buffer
+		err = CheckRelationships(CheckRelationshipsOptions{SORFile: path, Output: &buf})
+		require.ErrorIs(t, err, parser.ErrRelationshipIssues)
+		assert.ErrorContains(t, err, "2 unresolved attributes in 2 of")
+		assert.Contains(t, buf.String(), "GroupMembership\n  toAttribute 'Groups.id' matches no attribute\n    closest: Group.id,")
+		assert.Contains(t, buf.String(), "Member\n  fromAttribute 'GroupMember.userID' matches no attribute\n    closest: GroupMember.userId,")
+	})
+}
@@ -0,0 +1,75 @@
+package subcommands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// CompareSchemaOptions holds the options for the compare-schema subcommand
+type CompareSchemaOptions struct {
+	// SORFile is the path to the SOR YAML definition file
+	SORFile string
+
+	// Variables are the values of ${NAME} references in the SOR, taking precedence
+	// over the environment
+	Variables map[string]string
+
+	// Overlays are files patching the SOR, applied in order
+	Overlays []string
+
+	// Tenant is the file or http(s) URL of the tenant's SOR schema export
+	Tenant string
+
+	// Token is sent as a bearer token when Tenant is a URL; empty sends none
+	Token string
+
+	// Output is where to write the differences (defaults to stdout)
+	Output io.Writer
+}
+
+// CompareSchema checks that the SOR's entities, attribute external IDs and types
+// match those of the tenant's SOR schema, so data isn't generated from a stale copy
+// of a template. Returns an error matching parser.ErrSchemaMismatch listing how
+// many differences were written.
+func CompareSchema(opts CompareSchemaOptions) error {
+	if opts.SORFile == "" {
+		return fmt.Errorf("SOR file path is required")
+	}
+	if opts.Tenant == "" {
+		return fmt.Errorf("tenant schema file or URL is required")
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+
+	p := parser.NewParser(opts.SORFile)
+	p.Variables = opts.Variables
+	p.RequireVariables = true
+	p.Overlays = opts.Overlays
+	if err := p.Load(); err != nil {
+		return fmt.Errorf("failed to load SOR file: %w", err)
+	}
+	tenant, err := parser.LoadTenantSchema(opts.Tenant, opts.Token)
+	if err != nil {
+		return err
+	}
+
+	differences := parser.CompareTenantSchema(p.Definition, tenant)
+	if len(differences) == 0 {
+		_, err := fmt.Fprintf(opts.Output, "SOR matches the tenant's schema (%d entities)\n", len(tenant.Entities))
+		return err
+	}
+
+	var out strings.Builder
+	for _, difference := range differences {
+		fmt.Fprintf(&out, "%s\n", difference)
+	}
+	if _, err := io.WriteString(opts.Output, out.String()); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %d differences from %s", parser.ErrSchemaMismatch, len(differences), opts.Tenant)
+}
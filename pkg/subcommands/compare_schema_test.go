@@ -0,0 +1,56 @@
+package subcommands
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareSchema(t *testing.T) {
+	sorPath := "../../examples/okta.sgnl.yaml"
+	data, err := os.ReadFile(sorPath)
+	if os.IsNotExist(err) {
+		t.Skip("Skipping test: example SOR file not found")
+	}
+	require.NoError(t, err)
+
+	t.Run("An export of the same SOR matches", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, CompareSchema(CompareSchemaOptions{SORFile: sorPath, Tenant: sorPath, Output: &buf}))
+		assert.Equal(t, "SOR matches the tenant's schema (4 entities)\n", buf.String())
+	})
+
+	t.Run("A tenant URL is fetched with the token", func(t *testing.T) {
+		stale := strings.Replace(string(data), "externalId: Application", "externalId: App", 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(stale))
+		}))
+		defer server.Close()
+
+		var buf bytes.Buffer
+		err := CompareSchema(CompareSchemaOptions{SORFile: sorPath, Tenant: server.URL, Token: "secret", Output: &buf})
+		require.ErrorIs(t, err, parser.ErrSchemaMismatch)
+		assert.ErrorContains(t, err, "2 differences from "+server.URL)
+		assert.Equal(t, "App: missing from the local SOR\nApplication: missing from the tenant\n", buf.String())
+
+		err = CompareSchema(CompareSchemaOptions{SORFile: sorPath, Tenant: server.URL, Output: &buf})
+		assert.ErrorContains(t, err, "401 Unauthorized")
+	})
+
+	t.Run("A missing export can't be read", func(t *testing.T) {
+		err := CompareSchema(CompareSchemaOptions{SORFile: sorPath, Tenant: filepath.Join(t.TempDir(), "tenant.yaml")})
+		assert.ErrorIs(t, err, parser.ErrReadFile)
+	})
+}
@@ -0,0 +1,54 @@
+package subcommands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/SGNL-ai/fabricator/pkg/encryption"
+)
+
+// DecryptOptions holds the options for the decrypt subcommand
+type DecryptOptions struct {
+	// InputFile is the path to a data file written with --encrypt
+	InputFile string
+
+	// Passphrase is the secret the file was encrypted with
+	Passphrase string
+
+	// Output is where to write the decrypted content (defaults to stdout)
+	Output io.Writer
+}
+
+// Decrypt decrypts a data file written with --encrypt, streaming its content so
+// large files aren't held in memory
+func Decrypt(opts DecryptOptions) error {
+	if opts.InputFile == "" {
+		return fmt.Errorf("encrypted file path is required")
+	}
+	if opts.Passphrase == "" {
+		return fmt.Errorf("encryption passphrase is empty; check the --key-env variable")
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+
+	file, err := os.Open(opts.InputFile) // #nosec G304 - path is from CLI argument, user-controlled
+	if err != nil {
+		return fmt.Errorf("failed to open encrypted file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	decryptor, err := encryption.NewDecryptor(opts.Passphrase)
+	if err != nil {
+		return err
+	}
+	reader, err := decryptor.NewReader(file)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(opts.Output, reader); err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", opts.InputFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package subcommands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/SGNL-ai/fabricator/pkg/mapping"
+)
+
+// DecryptMappingOptions holds the options for the decrypt-mapping subcommand
+type DecryptMappingOptions struct {
+	// InputFile is the path to the encrypted identity mapping file
+	InputFile string
+
+	// Passphrase is the secret the mapping was encrypted with
+	Passphrase string
+
+	// Output is where to write the decrypted JSON lines (defaults to stdout)
+	Output io.Writer
+}
+
+// DecryptMapping decrypts an identity mapping written with --mapping-key-env
+func DecryptMapping(opts DecryptMappingOptions) error {
+	if opts.InputFile == "" {
+		return fmt.Errorf("mapping file path is required")
+	}
+	if opts.Passphrase == "" {
+		return fmt.Errorf("mapping passphrase is empty; check the --key-env variable")
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+
+	data, err := os.ReadFile(opts.InputFile) // #nosec G304 - path is from CLI argument, user-controlled
+	if err != nil {
+		return fmt.Errorf("failed to read mapping file: %w", err)
+	}
+
+	plaintext, err := mapping.Decrypt(data, opts.Passphrase)
+	if err != nil {
+		return err
+	}
+
+	if _, err := opts.Output.Write(plaintext); err != nil {
+		return fmt.Errorf("failed to write decrypted mapping: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package subcommands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/mapping"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecryptMapping(t *testing.T) {
+	plaintext := []byte(`{"entity":"User","id":"u1","attributes":{"email":"a@example.com"}}` + "\n")
+	sealed, err := mapping.Encrypt(plaintext, "s3cret")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "mapping.enc")
+	require.NoError(t, os.WriteFile(path, sealed, 0600))
+
+	t.Run("Writes decrypted mapping", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := DecryptMapping(DecryptMappingOptions{InputFile: path, Passphrase: "s3cret", Output: &buf})
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, buf.Bytes())
+	})
+
+	tests := []struct {
+		name string
+		opts DecryptMappingOptions
+	}{
+		{name: "Missing input", opts: DecryptMappingOptions{Passphrase: "s3cret"}},
+		{name: "Missing passphrase", opts: DecryptMappingOptions{InputFile: path}},
+		{name: "Wrong passphrase", opts: DecryptMappingOptions{InputFile: path, Passphrase: "nope"}},
+		{name: "Nonexistent file", opts: DecryptMappingOptions{InputFile: filepath.Join(t.TempDir(), "missing"), Passphrase: "s3cret"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Output = &bytes.Buffer{}
+			assert.Error(t, DecryptMapping(tt.opts))
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package subcommands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/encryption"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecrypt(t *testing.T) {
+	plaintext := []byte("id,email\nu1,a@example.com\n")
+	encryptor, err := encryption.NewEncryptor("s3cret")
+	require.NoError(t, err)
+	var sealed bytes.Buffer
+	writer, err := encryptor.NewWriter(&sealed)
+	require.NoError(t, err)
+	_, err = writer.Write(plaintext)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	path := filepath.Join(t.TempDir(), "User.csv.enc")
+	require.NoError(t, os.WriteFile(path, sealed.Bytes(), 0600))
+
+	t.Run("Writes decrypted file", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := Decrypt(DecryptOptions{InputFile: path, Passphrase: "s3cret", Output: &buf})
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, buf.Bytes())
+	})
+
+	tests := []struct {
+		name string
+		opts DecryptOptions
+	}{
+		{name: "Missing input", opts: DecryptOptions{Passphrase: "s3cret"}},
+		{name: "Missing passphrase", opts: DecryptOptions{InputFile: path}},
+		{name: "Wrong passphrase", opts: DecryptOptions{InputFile: path, Passphrase: "nope"}},
+		{name: "Nonexistent file", opts: DecryptOptions{InputFile: filepath.Join(t.TempDir(), "missing"), Passphrase: "s3cret"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Output = &bytes.Buffer{}
+			assert.Error(t, Decrypt(tt.opts))
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package subcommands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/console"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/SGNL-ai/fabricator/pkg/util"
+)
+
+// Output formats for the dependency-layers subcommand
+const (
+	LayersFormatText = "text"
+	LayersFormatDOT  = "dot"
+)
+
+// DependencyLayersOptions holds the options for the dependency-layers subcommand
+type DependencyLayersOptions struct {
+	// SORFile is the path to the SOR YAML definition file
+	SORFile string
+
+	// Format is LayersFormatText (default) or LayersFormatDOT
+	Format string
+
+	// Output is where to write the layers (defaults to stdout)
+	Output io.Writer
+}
+
+// DependencyLayers writes the topological layers used to order generation: layer 0
+// holds independent entities and each later layer depends only on earlier ones.
+// Every dependency lists the relationship that causes it.
+func DependencyLayers(opts DependencyLayersOptions) error {
+	if opts.SORFile == "" {
+		return fmt.Errorf("SOR file path is required")
+	}
+	if opts.Format == "" {
+		opts.Format = LayersFormatText
+	}
+	if opts.Format != LayersFormatText && opts.Format != LayersFormatDOT {
+		return fmt.Errorf("unsupported format '%s' (supported: %s, %s)", opts.Format, LayersFormatText, LayersFormatDOT)
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+
+	p := parser.NewParser(opts.SORFile)
+	if err := p.Parse(); err != nil {
+		return fmt.Errorf("failed to parse SOR file: %w", err)
+	}
+	def := p.Definition
+
+	entityIDs := make([]string, 0, len(def.Entities))
+	for id := range def.Entities {
+		entityIDs = append(entityIDs, id)
+	}
+	dependencies := util.EntityDependencies(def.Entities, def.Relationships)
+	layers, unresolved := util.DependencyLayers(entityIDs, dependencies)
+
+	if opts.Format == LayersFormatDOT {
+		return writeLayersDOT(opts.Output, def, layers, unresolved, dependencies)
+	}
+	return writeLayersText(opts.Output, def, layers, unresolved, dependencies)
+}
+
+// writeLayersText writes one section per layer, listing each entity with the
+// relationships that make it depend on earlier entities
+func writeLayersText(w io.Writer, def *parser.SORDefinition, layers [][]string, unresolved []string, dependencies []util.Dependency) error {
+	var out strings.Builder
+
+	writeEntities := func(ids []string) {
+		for _, id := range ids {
+			fmt.Fprintf(&out, "  %s\n", def.Entities[id].ExternalId)
+			for _, dependency := range dependencies {
+				if dependency.After != id {
+					continue
+				}
+				rel := def.Relationships[dependency.Relationship]
+				fmt.Fprintf(&out, console.Text("    ← %s via %s (%s → %s)\n"), def.Entities[dependency.Before].ExternalId,
+					dependency.Relationship, rel.FromAttribute, rel.ToAttribute)
+			}
+		}
+	}
+
+	for i, layer := range layers {
+		fmt.Fprintf(&out, "Layer %d:\n", i)
+		writeEntities(layer)
+	}
+	if len(unresolved) > 0 {
+		out.WriteString("Unresolved (dependency cycle):\n")
+		writeEntities(unresolved)
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// writeLayersDOT writes a Graphviz digraph with one cluster per layer and an edge,
+// labelled with the relationship ID, per dependency
+func writeLayersDOT(w io.Writer, def *parser.SORDefinition, layers [][]string, unresolved []string, dependencies []util.Dependency) error {
+	var out strings.Builder
+
+	out.WriteString("digraph dependencies {\n")
+	out.WriteString("  rankdir=LR;\n")
+	out.WriteString("  node [shape=box];\n")
+
+	writeCluster := func(name, label string, ids []string) {
+		fmt.Fprintf(&out, "  subgraph cluster_%s {\n", name)
+		fmt.Fprintf(&out, "    label=%q;\n", label)
+		for _, id := range ids {
+			fmt.Fprintf(&out, "    %q;\n", def.Entities[id].ExternalId)
+		}
+		out.WriteString("  }\n")
+	}
+
+	for i, layer := range layers {
+		writeCluster(fmt.Sprintf("layer_%d", i), fmt.Sprintf("Layer %d", i), layer)
+	}
+	if len(unresolved) > 0 {
+		writeCluster("unresolved", "Unresolved (dependency cycle)", unresolved)
+	}
+
+	for _, dependency := range dependencies {
+		fmt.Fprintf(&out, "  %q -> %q [label=%q];\n", def.Entities[dependency.Before].ExternalId,
+			def.Entities[dependency.After].ExternalId, dependency.Relationship)
+	}
+	out.WriteString("}\n")
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
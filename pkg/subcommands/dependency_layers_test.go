@@ -0,0 +1,54 @@
+package subcommands
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencyLayers(t *testing.T) {
+	sorPath := "../../examples/okta.sgnl.yaml"
+	if _, err := os.Stat(sorPath); os.IsNotExist(err) {
+		t.Skip("Skipping test: example SOR file not found")
+	}
+
+	t.Run("Text lists layers and causing relationships", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := DependencyLayers(DependencyLayersOptions{SORFile: sorPath, Output: &buf})
+		require.NoError(t, err)
+
+		assert.Equal(t, "Layer 0:\n  Application\n  Group\n  User\n"+
+			"Layer 1:\n  GroupMember\n"+
+			"    ← Group via GroupMembership (GroupMember.groupId → Group.id)\n"+
+			"    ← User via Member (GroupMember.userId → User.id)\n", buf.String())
+	})
+
+	t.Run("DOT clusters layers and labels edges", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := DependencyLayers(DependencyLayersOptions{SORFile: sorPath, Format: LayersFormatDOT, Output: &buf})
+		require.NoError(t, err)
+
+		output := buf.String()
+		assert.Contains(t, output, "digraph dependencies {")
+		assert.Contains(t, output, "subgraph cluster_layer_0 {")
+		assert.Contains(t, output, `"User" -> "GroupMember" [label="Member"];`)
+	})
+
+	tests := []struct {
+		name string
+		opts DependencyLayersOptions
+	}{
+		{name: "Missing SOR file path", opts: DependencyLayersOptions{}},
+		{name: "Unsupported format", opts: DependencyLayersOptions{SORFile: sorPath, Format: "svg"}},
+		{name: "Nonexistent SOR file", opts: DependencyLayersOptions{SORFile: "nonexistent.yaml"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Output = &bytes.Buffer{}
+			assert.Error(t, DependencyLayers(tt.opts))
+		})
+	}
+}
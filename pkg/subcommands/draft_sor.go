@@ -0,0 +1,61 @@
+package subcommands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// draftSOR is a SOR YAML drafted from another description of the data (an API spec
+// or sample files). It mirrors parser.SORDefinition but leaves out settings the
+// source can't provide.
+type draftSOR struct {
+	DisplayName   string                       `yaml:"displayName"`
+	Description   string                       `yaml:"description"`
+	Entities      map[string]draftEntity       `yaml:"entities"`
+	Relationships map[string]draftRelationship `yaml:"relationships,omitempty"`
+}
+
+type draftEntity struct {
+	DisplayName string           `yaml:"displayName"`
+	ExternalId  string           `yaml:"externalId"`
+	Description string           `yaml:"description,omitempty"`
+	Attributes  []draftAttribute `yaml:"attributes"`
+}
+
+type draftAttribute struct {
+	Name        string `yaml:"name"`
+	ExternalId  string `yaml:"externalId"`
+	Description string `yaml:"description,omitempty"`
+	Type        string `yaml:"type"`
+	UniqueId    bool   `yaml:"uniqueId,omitempty"`
+	List        bool   `yaml:"list,omitempty"`
+}
+
+type draftRelationship struct {
+	DisplayName   string `yaml:"displayName"`
+	Name          string `yaml:"name"`
+	FromAttribute string `yaml:"fromAttribute"`
+	ToAttribute   string `yaml:"toAttribute"`
+}
+
+// writeDraftSOR writes sor as YAML, headed by a comment naming the subcommand and
+// source it was drafted from
+func writeDraftSOR(w io.Writer, subcommand, source string, sor *draftSOR) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Draft SOR definition generated by fabricator %s from %s\n", subcommand, source)
+	fmt.Fprintln(&buf, "# Review unique IDs, types and relationships before generating data.")
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(sor); err != nil {
+		return fmt.Errorf("failed to encode draft SOR: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode draft SOR: %w", err)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
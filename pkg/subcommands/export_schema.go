@@ -0,0 +1,440 @@
+package subcommands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/SGNL-ai/fabricator/pkg/util"
+)
+
+// Output formats for the export-schema subcommand
+const (
+	SchemaFormatJSONSchema = "jsonschema"
+	SchemaFormatAvro       = "avro"
+	SchemaFormatDDL        = "ddl"
+)
+
+// jsonSchemaDraft is the JSON Schema dialect of exported schemas
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// ExportSchemaOptions holds the options for the export-schema subcommand
+type ExportSchemaOptions struct {
+	// SORFile is the path to the SOR YAML definition file
+	SORFile string
+
+	// Format is SchemaFormatJSONSchema, SchemaFormatAvro or SchemaFormatDDL
+	Format string
+
+	// OutputDir, when set, receives one schema file per entity
+	// (<entity>.schema.json, <entity>.avsc or <entity>.sql)
+	OutputDir string
+
+	// Output is where to write all schemas when OutputDir is empty (defaults to stdout)
+	Output io.Writer
+}
+
+// entitySchema is one entity's exported schema
+type entitySchema struct {
+	entity  parser.Entity
+	content []byte
+}
+
+// ExportSchema converts the SOR definition into per-entity schemas describing the
+// generated data, so systems consuming it can configure their parsers. Entities are
+// exported in generation order, so DDL foreign keys always reference earlier tables.
+//
+// Written to a single stream, JSON Schemas are combined under "$defs", Avro records
+// form a top-level union (JSON array) and DDL statements are concatenated.
+func ExportSchema(opts ExportSchemaOptions) error {
+	if opts.SORFile == "" {
+		return fmt.Errorf("SOR file path is required")
+	}
+	switch opts.Format {
+	case SchemaFormatJSONSchema, SchemaFormatAvro, SchemaFormatDDL:
+	default:
+		return fmt.Errorf("unsupported format '%s' (supported: %s, %s, %s)",
+			opts.Format, SchemaFormatJSONSchema, SchemaFormatAvro, SchemaFormatDDL)
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+
+	p := parser.NewParser(opts.SORFile)
+	if err := p.Parse(); err != nil {
+		return fmt.Errorf("failed to parse SOR file: %w", err)
+	}
+	def := p.Definition
+
+	entityIDs := make([]string, 0, len(def.Entities))
+	for id := range def.Entities {
+		entityIDs = append(entityIDs, id)
+	}
+	dependencies := util.EntityDependencies(def.Entities, def.Relationships)
+	layers, unresolved := util.DependencyLayers(entityIDs, dependencies)
+	var ordered []string
+	for _, layer := range layers {
+		ordered = append(ordered, layer...)
+	}
+	ordered = append(ordered, unresolved...)
+
+	schemas := make([]entitySchema, 0, len(ordered))
+	for _, id := range ordered {
+		entity := def.Entities[id]
+		var content []byte
+		var err error
+		switch opts.Format {
+		case SchemaFormatJSONSchema:
+			content, err = json.MarshalIndent(entityJSONSchema(entity, true), "", "  ")
+		case SchemaFormatAvro:
+			content, err = json.MarshalIndent(entityAvroSchema(def, entity), "", "  ")
+		case SchemaFormatDDL:
+			content = []byte(entityDDL(def, id, dependencies))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to encode schema for entity %s: %w", entity.ExternalId, err)
+		}
+		schemas = append(schemas, entitySchema{entity: entity, content: content})
+	}
+
+	if opts.OutputDir != "" {
+		return writeSchemaFiles(opts.OutputDir, opts.Format, schemas)
+	}
+	return writeSchemaStream(opts.Output, def, opts.Format, schemas)
+}
+
+// writeSchemaFiles writes each entity's schema to its own file in dir
+func writeSchemaFiles(dir, format string, schemas []entitySchema) error {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	extension := map[string]string{
+		SchemaFormatJSONSchema: ".schema.json",
+		SchemaFormatAvro:       ".avsc",
+		SchemaFormatDDL:        ".sql",
+	}[format]
+
+	for _, schema := range schemas {
+		content := schema.content
+		if format != SchemaFormatDDL {
+			content = append(content, '\n')
+		}
+		filePath := filepath.Join(dir, schemaFileBase(schema.entity.ExternalId)+extension)
+		if err := os.WriteFile(filePath, content, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", filePath, err)
+		}
+	}
+	return nil
+}
+
+// writeSchemaStream writes all schemas as a single document
+func writeSchemaStream(w io.Writer, def *parser.SORDefinition, format string, schemas []entitySchema) error {
+	var out []byte
+	switch format {
+	case SchemaFormatJSONSchema:
+		defs := make(map[string]json.RawMessage, len(schemas))
+		for _, schema := range schemas {
+			// Definitions nested under $defs don't repeat the dialect
+			content, err := json.Marshal(entityJSONSchema(schema.entity, false))
+			if err != nil {
+				return fmt.Errorf("failed to encode schema for entity %s: %w", schema.entity.ExternalId, err)
+			}
+			defs[schema.entity.ExternalId] = content
+		}
+		combined := map[string]interface{}{
+			"$schema": jsonSchemaDraft,
+			"title":   def.DisplayName,
+			"$defs":   defs,
+		}
+		if def.Description != "" {
+			combined["description"] = def.Description
+		}
+		content, err := json.MarshalIndent(combined, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode schemas: %w", err)
+		}
+		out = append(content, '\n')
+
+	case SchemaFormatAvro:
+		records := make([]json.RawMessage, 0, len(schemas))
+		for _, schema := range schemas {
+			records = append(records, schema.content)
+		}
+		content, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode schemas: %w", err)
+		}
+		out = append(content, '\n')
+
+	case SchemaFormatDDL:
+		statements := make([]string, 0, len(schemas))
+		for _, schema := range schemas {
+			statements = append(statements, string(schema.content))
+		}
+		out = []byte(strings.Join(statements, "\n"))
+	}
+
+	_, err := w.Write(out)
+	return err
+}
+
+// entityJSONSchema builds a JSON Schema for one entity's rows. Properties are the
+// output columns (attribute external IDs); unique IDs are required.
+func entityJSONSchema(entity parser.Entity, standalone bool) map[string]interface{} {
+	properties := make(map[string]interface{}, len(entity.Attributes))
+	required := make([]string, 0)
+	for _, attr := range entity.Attributes {
+		property := jsonSchemaType(attr.Type)
+		if attr.List {
+			property = map[string]interface{}{"type": "array", "items": property}
+		}
+		if attr.Description != "" {
+			property["description"] = attr.Description
+		}
+		properties[attr.ExternalId] = property
+		if attr.UniqueId {
+			required = append(required, attr.ExternalId)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"title":                entity.DisplayName,
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+	if standalone {
+		schema["$schema"] = jsonSchemaDraft
+		schema["$id"] = entity.ExternalId
+	}
+	if entity.Description != "" {
+		schema["description"] = entity.Description
+	}
+	return schema
+}
+
+// jsonSchemaType maps a SOR attribute type to a JSON Schema type
+func jsonSchemaType(dataType string) map[string]interface{} {
+	switch dataType {
+	case "Integer", "Int64":
+		return map[string]interface{}{"type": "integer"}
+	case "Boolean", "Bool":
+		return map[string]interface{}{"type": "boolean"}
+	case "Float", "Double":
+		return map[string]interface{}{"type": "number"}
+	case "Date":
+		return map[string]interface{}{"type": "string", "format": "date"}
+	case "DateTime":
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	default:
+		return map[string]interface{}{"type": "string"}
+	}
+}
+
+// avroField is a field of an Avro record schema
+type avroField struct {
+	Name    string      `json:"name"`
+	Type    interface{} `json:"type"`
+	Doc     string      `json:"doc,omitempty"`
+	Aliases []string    `json:"aliases,omitempty"`
+	Default interface{} `json:"default,omitempty"`
+}
+
+// avroRecord is an Avro record schema
+type avroRecord struct {
+	Type      string      `json:"type"`
+	Name      string      `json:"name"`
+	Namespace string      `json:"namespace,omitempty"`
+	Doc       string      `json:"doc,omitempty"`
+	Aliases   []string    `json:"aliases,omitempty"`
+	Fields    []avroField `json:"fields"`
+}
+
+// avroNull is the JSON null default of an optional Avro field; omitempty drops a
+// plain nil, so the default is carried as a raw message
+var avroNull = json.RawMessage("null")
+
+// entityAvroSchema builds an Avro record schema for one entity's rows. Unique IDs
+// are required; other fields are optional (a union with null, defaulting to null).
+// Names that aren't valid Avro names are sanitized and the original kept as an alias.
+func entityAvroSchema(def *parser.SORDefinition, entity parser.Entity) avroRecord {
+	record := avroRecord{
+		Type:      "record",
+		Name:      avroName(path.Base(entity.ExternalId)),
+		Namespace: avroNamespace(def),
+		Doc:       entity.Description,
+		Fields:    make([]avroField, 0, len(entity.Attributes)),
+	}
+	if record.Name != entity.ExternalId {
+		record.Aliases = []string{entity.ExternalId}
+	}
+
+	for _, attr := range entity.Attributes {
+		var fieldType interface{} = avroType(attr.Type)
+		if attr.List {
+			fieldType = map[string]interface{}{"type": "array", "items": fieldType}
+		}
+		field := avroField{Name: avroName(attr.ExternalId), Doc: attr.Description}
+		if field.Name != attr.ExternalId {
+			field.Aliases = []string{attr.ExternalId}
+		}
+		if attr.UniqueId {
+			field.Type = fieldType
+		} else {
+			field.Type = []interface{}{"null", fieldType}
+			field.Default = avroNull
+		}
+		record.Fields = append(record.Fields, field)
+	}
+	return record
+}
+
+// avroType maps a SOR attribute type to an Avro type
+func avroType(dataType string) interface{} {
+	switch dataType {
+	case "Integer":
+		return "int"
+	case "Int64":
+		return "long"
+	case "Boolean", "Bool":
+		return "boolean"
+	case "Float":
+		return "float"
+	case "Double":
+		return "double"
+	case "Date":
+		return map[string]string{"type": "int", "logicalType": "date"}
+	case "DateTime":
+		return map[string]string{"type": "long", "logicalType": "timestamp-millis"}
+	default:
+		return "string"
+	}
+}
+
+// avroNamespace derives a namespace from the SOR type (e.g. "Okta-1.0.1" → "Okta_1_0_1")
+func avroNamespace(def *parser.SORDefinition) string {
+	if def.Type == "" {
+		return ""
+	}
+	return avroName(def.Type)
+}
+
+// avroName makes s a valid Avro name: letters, digits and underscores, not
+// starting with a digit
+func avroName(s string) string {
+	var name strings.Builder
+	for _, r := range s {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+			name.WriteRune(r)
+		} else {
+			name.WriteRune('_')
+		}
+	}
+	if name.Len() == 0 || unicode.IsDigit(rune(name.String()[0])) {
+		return "_" + name.String()
+	}
+	return name.String()
+}
+
+// entityDDL builds a CREATE TABLE statement for one entity. Columns are the output
+// columns, unique IDs form the primary key and relationships to another entity's
+// unique ID become foreign keys.
+func entityDDL(def *parser.SORDefinition, entityID string, dependencies []util.Dependency) string {
+	entity := def.Entities[entityID]
+
+	var lines []string
+	var primaryKey []string
+	for _, attr := range entity.Attributes {
+		column := fmt.Sprintf("  %s %s", quoteIdentifier(attr.ExternalId), sqlType(attr))
+		if attr.UniqueId {
+			column += " NOT NULL"
+			primaryKey = append(primaryKey, quoteIdentifier(attr.ExternalId))
+		}
+		lines = append(lines, column)
+	}
+	if len(primaryKey) > 0 {
+		lines = append(lines, fmt.Sprintf("  PRIMARY KEY (%s)", strings.Join(primaryKey, ", ")))
+	}
+
+	for _, dependency := range dependencies {
+		if dependency.After != entityID || dependency.Before == entityID {
+			continue
+		}
+		rel := def.Relationships[dependency.Relationship]
+		_, from, fromOK := resolveColumn(def, rel.FromAttribute)
+		toEntity, to, toOK := resolveColumn(def, rel.ToAttribute)
+		if !fromOK || !toOK || !to.UniqueId {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  FOREIGN KEY (%s) REFERENCES %s (%s)",
+			quoteIdentifier(from.ExternalId), quoteIdentifier(def.Entities[toEntity].ExternalId), quoteIdentifier(to.ExternalId)))
+	}
+
+	var out strings.Builder
+	if entity.Description != "" {
+		fmt.Fprintf(&out, "-- %s\n", strings.ReplaceAll(entity.Description, "\n", " "))
+	}
+	fmt.Fprintf(&out, "CREATE TABLE %s (\n%s\n);\n", quoteIdentifier(entity.ExternalId), strings.Join(lines, ",\n"))
+	return out.String()
+}
+
+// resolveColumn finds the entity key and attribute named by an attributeAlias or
+// Entity.Attribute reference
+func resolveColumn(def *parser.SORDefinition, reference string) (string, parser.Attribute, bool) {
+	for id, entity := range def.Entities {
+		for _, attr := range entity.Attributes {
+			if attr.AttributeAlias == reference || entity.ExternalId+"."+attr.ExternalId == reference {
+				return id, attr, true
+			}
+		}
+	}
+	return "", parser.Attribute{}, false
+}
+
+// sqlType maps a SOR attribute to a standard SQL column type; lists are stored
+// as text, as they are in the generated CSVs
+func sqlType(attr parser.Attribute) string {
+	if attr.List {
+		return "TEXT"
+	}
+	switch attr.Type {
+	case "Integer":
+		return "INTEGER"
+	case "Int64":
+		return "BIGINT"
+	case "Boolean", "Bool":
+		return "BOOLEAN"
+	case "Float":
+		return "REAL"
+	case "Double":
+		return "DOUBLE PRECISION"
+	case "Date":
+		return "DATE"
+	case "DateTime":
+		return "TIMESTAMP"
+	default:
+		return "TEXT"
+	}
+}
+
+// quoteIdentifier quotes a SQL identifier, doubling embedded quotes
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// schemaFileBase returns the file name base for an entity's schema, matching the
+// generated data files (namespace prefixes such as "KeystoneV1/" are dropped)
+func schemaFileBase(externalID string) string {
+	if externalID == "" {
+		return "unknown"
+	}
+	return path.Base(externalID)
+}
@@ -0,0 +1,124 @@
+package subcommands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportSchema(t *testing.T) {
+	sorPath := "../../examples/okta.sgnl.yaml"
+	if _, err := os.Stat(sorPath); os.IsNotExist(err) {
+		t.Skip("Skipping test: example SOR file not found")
+	}
+
+	t.Run("JSON Schema combines entities under $defs", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := ExportSchema(ExportSchemaOptions{SORFile: sorPath, Format: SchemaFormatJSONSchema, Output: &buf})
+		require.NoError(t, err)
+
+		var doc struct {
+			Schema string `json:"$schema"`
+			Defs   map[string]struct {
+				Type       string                            `json:"type"`
+				Properties map[string]map[string]interface{} `json:"properties"`
+				Required   []string                          `json:"required"`
+			} `json:"$defs"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+		assert.Equal(t, jsonSchemaDraft, doc.Schema)
+		require.Contains(t, doc.Defs, "GroupMember")
+		member := doc.Defs["GroupMember"]
+		assert.Equal(t, "object", member.Type)
+		assert.Equal(t, []string{"id"}, member.Required)
+		assert.Equal(t, "string", member.Properties["groupId"]["type"])
+	})
+
+	t.Run("Avro writes one record per entity in generation order", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := ExportSchema(ExportSchemaOptions{SORFile: sorPath, Format: SchemaFormatAvro, Output: &buf})
+		require.NoError(t, err)
+
+		var records []avroRecord
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &records))
+		var names []string
+		for _, record := range records {
+			names = append(names, record.Name)
+			assert.Equal(t, "record", record.Type)
+			assert.Equal(t, "Okta_1_0_1", record.Namespace)
+		}
+		assert.Equal(t, []string{"Application", "Group", "User", "GroupMember"}, names)
+	})
+
+	t.Run("DDL declares primary and foreign keys", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := ExportSchema(ExportSchemaOptions{SORFile: sorPath, Format: SchemaFormatDDL, Output: &buf})
+		require.NoError(t, err)
+
+		output := buf.String()
+		assert.Contains(t, output, "CREATE TABLE \"GroupMember\" (\n  \"id\" TEXT NOT NULL,\n")
+		assert.Contains(t, output, "  PRIMARY KEY (\"id\"),\n")
+		assert.Contains(t, output, "  FOREIGN KEY (\"groupId\") REFERENCES \"Group\" (\"id\"),\n")
+		assert.Contains(t, output, "  FOREIGN KEY (\"userId\") REFERENCES \"User\" (\"id\")\n);\n")
+		assert.Less(t, bytes.Index(buf.Bytes(), []byte(`CREATE TABLE "User"`)),
+			bytes.Index(buf.Bytes(), []byte(`CREATE TABLE "GroupMember"`)), "referenced tables are created first")
+	})
+
+	t.Run("Output directory receives one file per entity", func(t *testing.T) {
+		dir := t.TempDir()
+		err := ExportSchema(ExportSchemaOptions{SORFile: sorPath, Format: SchemaFormatAvro, OutputDir: dir})
+		require.NoError(t, err)
+
+		for _, name := range []string{"Application", "Group", "GroupMember", "User"} {
+			assert.FileExists(t, filepath.Join(dir, name+".avsc"))
+		}
+	})
+
+	tests := []struct {
+		name string
+		opts ExportSchemaOptions
+	}{
+		{name: "Missing SOR file path", opts: ExportSchemaOptions{Format: SchemaFormatDDL}},
+		{name: "Unsupported format", opts: ExportSchemaOptions{SORFile: sorPath, Format: "protobuf"}},
+		{name: "Nonexistent SOR file", opts: ExportSchemaOptions{SORFile: "nonexistent.yaml", Format: SchemaFormatDDL}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Output = &bytes.Buffer{}
+			assert.Error(t, ExportSchema(tt.opts))
+		})
+	}
+}
+
+func TestEntityAvroSchema(t *testing.T) {
+	def := &parser.SORDefinition{Type: "Test-1.0"}
+	entity := parser.Entity{
+		ExternalId: "KeystoneV1/Role-Assignment",
+		Attributes: []parser.Attribute{
+			{Name: "id", ExternalId: "id", Type: "String", UniqueId: true},
+			{Name: "created", ExternalId: "created.at", Type: "DateTime"},
+			{Name: "tags", ExternalId: "tags", Type: "String", List: true},
+		},
+	}
+
+	content, err := json.Marshal(entityAvroSchema(def, entity))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "record",
+		"name": "Role_Assignment",
+		"namespace": "Test_1_0",
+		"aliases": ["KeystoneV1/Role-Assignment"],
+		"fields": [
+			{"name": "id", "type": "string"},
+			{"name": "created_at", "aliases": ["created.at"], "default": null,
+			 "type": ["null", {"type": "long", "logicalType": "timestamp-millis"}]},
+			{"name": "tags", "default": null, "type": ["null", {"type": "array", "items": "string"}]}
+		]
+	}`, string(content))
+}
@@ -0,0 +1,345 @@
+package subcommands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ImportOpenAPIOptions holds the options for the import-openapi subcommand
+type ImportOpenAPIOptions struct {
+	// SpecFile is the path to the OpenAPI 3 or Swagger 2 document (YAML or JSON)
+	SpecFile string
+
+	// Output is where to write the draft SOR YAML (defaults to stdout)
+	Output io.Writer
+}
+
+// openAPIDocument holds the parts of an OpenAPI 3 or Swagger 2 document that
+// describe data shapes
+type openAPIDocument struct {
+	Info struct {
+		Title       string `yaml:"title"`
+		Description string `yaml:"description"`
+	} `yaml:"info"`
+	Components struct {
+		Schemas namedSchemas `yaml:"schemas"`
+	} `yaml:"components"`
+	Definitions namedSchemas `yaml:"definitions"` // Swagger 2
+}
+
+// openAPISchema is a schema object, reduced to what maps onto SOR attributes
+type openAPISchema struct {
+	Ref         string          `yaml:"$ref"`
+	Type        yaml.Node       `yaml:"type"` // A name, or a list of names in OpenAPI 3.1
+	Format      string          `yaml:"format"`
+	Description string          `yaml:"description"`
+	Properties  namedSchemas    `yaml:"properties"`
+	Items       *openAPISchema  `yaml:"items"`
+	AllOf       []openAPISchema `yaml:"allOf"`
+}
+
+// namedSchema is a schema with the name it was declared under
+type namedSchema struct {
+	name   string
+	schema openAPISchema
+}
+
+// namedSchemas keeps schemas and properties in document order, so the draft lists
+// attributes the way the API documents them
+type namedSchemas []namedSchema
+
+// UnmarshalYAML decodes a mapping of names to schemas, preserving its order
+func (s *namedSchemas) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping of schemas", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var schema openAPISchema
+		if err := node.Content[i+1].Decode(&schema); err != nil {
+			return err
+		}
+		*s = append(*s, namedSchema{name: node.Content[i].Value, schema: schema})
+	}
+	return nil
+}
+
+// typeName returns the schema's type, ignoring "null" in OpenAPI 3.1 type lists
+func (s openAPISchema) typeName() string {
+	switch s.Type.Kind {
+	case yaml.ScalarNode:
+		return s.Type.Value
+	case yaml.SequenceNode:
+		for _, item := range s.Type.Content {
+			if item.Value != "null" {
+				return item.Value
+			}
+		}
+	}
+	return ""
+}
+
+// openAPIImporter converts one document's schemas into a draft SOR
+type openAPIImporter struct {
+	schemas  map[string]openAPISchema
+	entities map[string]bool           // Schemas that become entities
+	keys     map[string]draftAttribute // Unique ID attribute per entity
+}
+
+// ImportOpenAPI converts the schemas of an OpenAPI document into a draft SOR YAML:
+// object schemas become entities, their properties attributes, and properties that
+// $ref another object schema become relationships to that entity's unique ID.
+// Entities without an id property get a synthetic one. The draft is a starting
+// point for review, not a finished definition.
+func ImportOpenAPI(opts ImportOpenAPIOptions) error {
+	if opts.SpecFile == "" {
+		return fmt.Errorf("OpenAPI file path is required")
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+
+	data, err := os.ReadFile(opts.SpecFile) // #nosec G304 - path is from CLI argument, user-controlled
+	if err != nil {
+		return fmt.Errorf("failed to read OpenAPI file: %w", err)
+	}
+	// JSON documents are valid YAML, so one decoder handles both
+	var doc openAPIDocument
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse OpenAPI file %s: %w", opts.SpecFile, err)
+	}
+
+	declared := doc.Components.Schemas
+	if len(declared) == 0 {
+		declared = doc.Definitions
+	}
+	if len(declared) == 0 {
+		return fmt.Errorf("no schemas found in %s (expected components.schemas or definitions)", opts.SpecFile)
+	}
+
+	sor, err := newOpenAPIImporter(declared).draft(declared)
+	if err != nil {
+		return err
+	}
+	sor.DisplayName = doc.Info.Title
+	if sor.DisplayName == "" {
+		sor.DisplayName = "Imported API"
+	}
+	sor.Description = doc.Info.Description
+	if sor.Description == "" {
+		sor.Description = fmt.Sprintf("Draft SOR imported from %s", opts.SpecFile)
+	}
+
+	return writeDraftSOR(opts.Output, "import-openapi", opts.SpecFile, sor)
+}
+
+// newOpenAPIImporter indexes the declared schemas and decides which are entities
+func newOpenAPIImporter(declared namedSchemas) *openAPIImporter {
+	imp := &openAPIImporter{
+		schemas:  make(map[string]openAPISchema, len(declared)),
+		entities: make(map[string]bool),
+		keys:     make(map[string]draftAttribute),
+	}
+	for _, named := range declared {
+		imp.schemas[named.name] = named.schema
+	}
+	for _, named := range declared {
+		if len(imp.properties(named.schema, 0)) > 0 {
+			imp.entities[named.name] = true
+		}
+	}
+	return imp
+}
+
+// draft builds the entities and relationships in declaration order
+func (imp *openAPIImporter) draft(declared namedSchemas) (*draftSOR, error) {
+	// Unique IDs come first, since relationships take their type from the target's key
+	for _, named := range declared {
+		if imp.entities[named.name] {
+			imp.keys[named.name] = imp.uniqueID(named.name, imp.properties(named.schema, 0))
+		}
+	}
+
+	sor := &draftSOR{
+		Entities:      make(map[string]draftEntity),
+		Relationships: make(map[string]draftRelationship),
+	}
+	for _, named := range declared {
+		if !imp.entities[named.name] {
+			continue
+		}
+		entity := draftEntity{
+			DisplayName: named.name,
+			ExternalId:  named.name,
+			Description: named.schema.Description,
+		}
+
+		key := imp.keys[named.name]
+		properties := imp.properties(named.schema, 0)
+		if !hasProperty(properties, key.Name) {
+			entity.Attributes = append(entity.Attributes, key)
+		}
+		for _, property := range properties {
+			if property.name == key.Name {
+				entity.Attributes = append(entity.Attributes, key)
+				continue
+			}
+
+			attr, target := imp.attribute(property)
+			entity.Attributes = append(entity.Attributes, attr)
+			if target != "" {
+				id := fmt.Sprintf("%s_%s", named.name, property.name)
+				sor.Relationships[id] = draftRelationship{
+					DisplayName:   fmt.Sprintf("%s %s", named.name, property.name),
+					Name:          id,
+					FromAttribute: fmt.Sprintf("%s.%s", named.name, property.name),
+					ToAttribute:   fmt.Sprintf("%s.%s", target, imp.keys[target].ExternalId),
+				}
+			}
+		}
+
+		if _, exists := sor.Entities[named.name]; exists {
+			return nil, fmt.Errorf("schema %s is declared twice", named.name)
+		}
+		sor.Entities[named.name] = entity
+	}
+	if len(sor.Entities) == 0 {
+		return nil, fmt.Errorf("no object schemas with properties to import")
+	}
+	return sor, nil
+}
+
+// properties returns a schema's properties, merging allOf members and following
+// $refs. depth guards against reference cycles.
+func (imp *openAPIImporter) properties(schema openAPISchema, depth int) namedSchemas {
+	if depth > 16 {
+		return nil
+	}
+	if schema.Ref != "" {
+		referenced, exists := imp.schemas[refName(schema.Ref)]
+		if !exists {
+			return nil
+		}
+		return imp.properties(referenced, depth+1)
+	}
+
+	var result namedSchemas
+	for _, member := range schema.AllOf {
+		result = append(result, imp.properties(member, depth+1)...)
+	}
+	for _, property := range schema.Properties {
+		if !hasProperty(result, property.name) {
+			result = append(result, property)
+		}
+	}
+	return result
+}
+
+// uniqueID picks the property identifying an entity: id, <entity>Id or uuid.
+// Without one, a synthetic String id is added.
+func (imp *openAPIImporter) uniqueID(entity string, properties namedSchemas) draftAttribute {
+	for _, candidate := range []string{"id", strings.ToLower(entity) + "id", "uuid"} {
+		for _, property := range properties {
+			if strings.ToLower(property.name) == candidate {
+				attr, _ := imp.attribute(property)
+				if attr.Type == "" {
+					attr.Type = "String" // A key referencing an entity not yet keyed
+				}
+				attr.UniqueId = true
+				attr.List = false
+				return attr
+			}
+		}
+	}
+	return draftAttribute{
+		Name:        "id",
+		ExternalId:  "id",
+		Description: "Synthetic unique ID; the schema declares no id property",
+		Type:        "String",
+		UniqueId:    true,
+	}
+}
+
+// attribute maps a property to an SOR attribute. A property referencing another
+// entity (directly or as array items) takes the type of that entity's unique ID,
+// and the entity is returned as the relationship target.
+func (imp *openAPIImporter) attribute(property namedSchema) (draftAttribute, string) {
+	attr := draftAttribute{
+		Name:        property.name,
+		ExternalId:  property.name,
+		Description: property.schema.Description,
+	}
+
+	schema := property.schema
+	if schema.typeName() == "array" && schema.Items != nil {
+		attr.List = true
+		schema = *schema.Items
+	}
+
+	// References to entities become relationships; other references (enums,
+	// value objects) are typed from the referenced schema
+	for depth := 0; schema.Ref != "" && depth < 16; depth++ {
+		name := refName(schema.Ref)
+		if imp.entities[name] {
+			attr.Type = imp.keys[name].Type
+			return attr, name
+		}
+		referenced, exists := imp.schemas[name]
+		if !exists {
+			break
+		}
+		if attr.Description == "" {
+			attr.Description = referenced.Description
+		}
+		schema = referenced
+	}
+
+	attr.Type = openAPIAttributeType(schema.typeName(), schema.Format)
+	return attr, ""
+}
+
+// openAPIAttributeType maps an OpenAPI type and format to an SOR attribute type.
+// Objects and unknown types become Strings.
+func openAPIAttributeType(typeName, format string) string {
+	switch typeName {
+	case "integer":
+		if format == "int64" {
+			return "Int64"
+		}
+		return "Integer"
+	case "number":
+		if format == "double" {
+			return "Double"
+		}
+		return "Float"
+	case "boolean":
+		return "Boolean"
+	case "string":
+		switch format {
+		case "date":
+			return "Date"
+		case "date-time":
+			return "DateTime"
+		}
+	}
+	return "String"
+}
+
+// refName returns the schema name a local $ref points to
+// (#/components/schemas/User or #/definitions/User)
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+// hasProperty reports whether properties include one with the given name
+func hasProperty(properties namedSchemas, name string) bool {
+	for _, property := range properties {
+		if property.name == name {
+			return true
+		}
+	}
+	return false
+}
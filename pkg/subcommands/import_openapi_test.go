@@ -0,0 +1,148 @@
+package subcommands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOpenAPISpec = `openapi: 3.1.0
+info:
+  title: Directory API
+  description: Users and groups
+components:
+  schemas:
+    Status:
+      type: string
+      enum: [active, suspended]
+    Group:
+      type: object
+      properties:
+        id:
+          type: integer
+          format: int64
+        name:
+          type: string
+    User:
+      type: object
+      description: A directory user
+      properties:
+        email:
+          type: string
+        id:
+          type: string
+        createdAt:
+          type: [string, "null"]
+          format: date-time
+        status:
+          $ref: '#/components/schemas/Status'
+        manager:
+          $ref: '#/components/schemas/User'
+        groups:
+          type: array
+          items:
+            $ref: '#/components/schemas/Group'
+    Contractor:
+      allOf:
+        - $ref: '#/components/schemas/User'
+        - properties:
+            agency:
+              type: string
+    Address:
+      type: object
+      properties:
+        city:
+          type: string
+`
+
+func TestImportOpenAPI(t *testing.T) {
+	importSpec := func(t *testing.T, name, content string) *parser.SORDefinition {
+		t.Helper()
+		dir := t.TempDir()
+		specPath := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(specPath, []byte(content), 0600))
+
+		var buf bytes.Buffer
+		require.NoError(t, ImportOpenAPI(ImportOpenAPIOptions{SpecFile: specPath, Output: &buf}))
+		assert.Contains(t, buf.String(), "# Draft SOR definition generated by fabricator import-openapi")
+
+		// The draft must be a valid SOR definition
+		sorPath := filepath.Join(dir, "sor.yaml")
+		require.NoError(t, os.WriteFile(sorPath, buf.Bytes(), 0600))
+		p := parser.NewParser(sorPath)
+		require.NoError(t, p.Parse())
+		return p.Definition
+	}
+
+	t.Run("object schemas become entities with typed attributes", func(t *testing.T) {
+		def := importSpec(t, "openapi.yaml", testOpenAPISpec)
+		assert.Equal(t, "Directory API", def.DisplayName)
+		assert.ElementsMatch(t, []string{"Group", "User", "Contractor", "Address"}, keys(def.Entities))
+
+		user := def.Entities["User"]
+		assert.Equal(t, "A directory user", user.Description)
+		types := make(map[string]string)
+		for _, attr := range user.Attributes {
+			types[attr.Name] = attr.Type
+		}
+		assert.Equal(t, map[string]string{
+			"email": "String", "id": "String", "createdAt": "DateTime",
+			"status": "String", "manager": "String", "groups": "Int64",
+		}, types)
+		assert.True(t, user.Attributes[1].UniqueId)
+		assert.True(t, user.Attributes[5].List)
+
+		contractor := def.Entities["Contractor"]
+		assert.Len(t, contractor.Attributes, 7, "allOf merges the referenced properties")
+	})
+
+	t.Run("references to entities become relationships", func(t *testing.T) {
+		def := importSpec(t, "openapi.yaml", testOpenAPISpec)
+		assert.Equal(t, "Group.id", def.Relationships["User_groups"].ToAttribute)
+		assert.Equal(t, "User.manager", def.Relationships["User_manager"].FromAttribute)
+		assert.NotContains(t, def.Relationships, "User_status", "enum schemas aren't entities")
+	})
+
+	t.Run("entities without an id get a synthetic one", func(t *testing.T) {
+		def := importSpec(t, "openapi.yaml", testOpenAPISpec)
+		address := def.Entities["Address"]
+		require.Len(t, address.Attributes, 2)
+		assert.Equal(t, "id", address.Attributes[0].Name)
+		assert.True(t, address.Attributes[0].UniqueId)
+	})
+
+	t.Run("Swagger 2 JSON definitions", func(t *testing.T) {
+		def := importSpec(t, "swagger.json", `{
+  "swagger": "2.0",
+  "info": {"title": "Legacy API"},
+  "definitions": {
+    "Account": {"type": "object", "properties": {"accountId": {"type": "string"}, "balance": {"type": "number", "format": "double"}}}
+  }
+}`)
+		account := def.Entities["Account"]
+		require.Len(t, account.Attributes, 2)
+		assert.True(t, account.Attributes[0].UniqueId, "<entity>Id is used as the unique ID")
+		assert.Equal(t, "Double", account.Attributes[1].Type)
+	})
+
+	t.Run("specs without schemas are rejected", func(t *testing.T) {
+		specPath := filepath.Join(t.TempDir(), "empty.yaml")
+		require.NoError(t, os.WriteFile(specPath, []byte("openapi: 3.0.0\ninfo: {title: Empty}\n"), 0600))
+		err := ImportOpenAPI(ImportOpenAPIOptions{SpecFile: specPath, Output: &bytes.Buffer{}})
+		assert.ErrorContains(t, err, "no schemas found")
+	})
+}
+
+// keys returns the keys of a map of entities
+func keys(entities map[string]parser.Entity) []string {
+	result := make([]string, 0, len(entities))
+	for key := range entities {
+		result = append(result, key)
+	}
+	return result
+}
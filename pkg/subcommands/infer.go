@@ -0,0 +1,334 @@
+package subcommands
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultInferSampleRows is how many rows of each CSV file infer examines by default
+const DefaultInferSampleRows = 10000
+
+// inferForeignKeyOverlap is the share of a column's values that must appear in an
+// entity's unique ID for a column named after that entity to reference it
+const inferForeignKeyOverlap = 0.8
+
+// InferOptions holds the options for the infer subcommand
+type InferOptions struct {
+	// InputDir is the directory holding one CSV file per entity
+	InputDir string
+
+	// SampleRows is the number of data rows read from each file (0 reads all rows)
+	SampleRows int
+
+	// Output is where to write the draft SOR YAML (defaults to stdout)
+	Output io.Writer
+}
+
+// inferredColumn collects what the sampled values of one CSV column reveal
+type inferredColumn struct {
+	name     string
+	dataType string
+	values   map[string]bool // Distinct non-empty values
+	filled   int             // Rows with a non-empty value
+}
+
+// inferredEntity is one CSV file read as an entity
+type inferredEntity struct {
+	name    string
+	file    string
+	rows    int
+	columns []*inferredColumn
+	key     *inferredColumn // nil when no column is unique
+}
+
+// Infer inspects a directory of CSV files and writes a draft SOR YAML: every file
+// becomes an entity and its header columns attributes, typed by the values they
+// hold. The unique ID is a column whose sampled values are all present and
+// distinct, preferring id-like names. Columns become relationships when they are
+// named after another entity and mostly hold its unique IDs, or when every value
+// is one of another entity's String unique IDs. The draft is a starting point for
+// review, not a finished definition.
+func Infer(opts InferOptions) error {
+	if opts.InputDir == "" {
+		return fmt.Errorf("input directory is required")
+	}
+	if opts.SampleRows < 0 {
+		return fmt.Errorf("sample rows must not be negative, got %d", opts.SampleRows)
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+
+	files, err := filepath.Glob(filepath.Join(opts.InputDir, "*.csv"))
+	if err != nil {
+		return fmt.Errorf("failed to list CSV files: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no CSV files found in %s", opts.InputDir)
+	}
+	sort.Strings(files)
+
+	entities := make([]*inferredEntity, 0, len(files))
+	for _, file := range files {
+		entity, err := inferEntity(file, opts.SampleRows)
+		if err != nil {
+			return err
+		}
+		entities = append(entities, entity)
+	}
+
+	sor := &draftSOR{
+		DisplayName:   filepath.Base(filepath.Clean(opts.InputDir)),
+		Description:   fmt.Sprintf("Draft SOR inferred from the CSV files in %s", opts.InputDir),
+		Entities:      make(map[string]draftEntity, len(entities)),
+		Relationships: make(map[string]draftRelationship),
+	}
+	for _, entity := range entities {
+		sor.Entities[entity.name] = entity.draft()
+		for _, column := range entity.columns {
+			if column == entity.key {
+				continue
+			}
+			target := inferReference(column, entities)
+			if target == nil {
+				continue
+			}
+			id := fmt.Sprintf("%s_%s", entity.name, column.name)
+			sor.Relationships[id] = draftRelationship{
+				DisplayName:   fmt.Sprintf("%s %s", entity.name, column.name),
+				Name:          id,
+				FromAttribute: fmt.Sprintf("%s.%s", entity.name, column.name),
+				ToAttribute:   fmt.Sprintf("%s.%s", target.name, target.key.name),
+			}
+		}
+	}
+
+	return writeDraftSOR(opts.Output, "infer", opts.InputDir, sor)
+}
+
+// inferEntity reads the header and up to sampleRows rows of a CSV file
+func inferEntity(path string, sampleRows int) (*inferredEntity, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header of %s: %w", path, err)
+	}
+
+	entity := &inferredEntity{
+		name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		file: filepath.Base(path),
+	}
+	seen := make(map[string]bool, len(header))
+	for _, name := range header {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			return nil, fmt.Errorf("%s: header has an empty or repeated column name '%s'", path, name)
+		}
+		seen[name] = true
+		entity.columns = append(entity.columns, &inferredColumn{name: name, values: make(map[string]bool)})
+	}
+
+	for sampleRows == 0 || entity.rows < sampleRows {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		entity.rows++
+		for i, column := range entity.columns {
+			if i < len(record) && record[i] != "" {
+				column.values[record[i]] = true
+				column.filled++
+			}
+		}
+	}
+
+	for _, column := range entity.columns {
+		column.dataType = inferColumnType(column.values)
+	}
+	entity.key = entity.uniqueColumn()
+	return entity, nil
+}
+
+// uniqueColumn picks the unique ID among the columns filled and distinct in every
+// sampled row: id, <entity>Id, uuid, any other *id column, then the first one.
+// Booleans, floats and timestamps are never keys.
+func (e *inferredEntity) uniqueColumn() *inferredColumn {
+	var candidates []*inferredColumn
+	for _, column := range e.columns {
+		switch column.dataType {
+		case "Boolean", "Float", "Date", "DateTime":
+			continue
+		}
+		if e.rows > 0 && column.filled == e.rows && len(column.values) == e.rows {
+			candidates = append(candidates, column)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	entity := normalizeColumnName(e.name)
+	for _, match := range []func(string) bool{
+		func(name string) bool { return name == "id" },
+		func(name string) bool { return name == entity+"id" || name == singular(entity)+"id" },
+		func(name string) bool { return name == "uuid" },
+		func(name string) bool { return strings.HasSuffix(name, "id") },
+	} {
+		for _, column := range candidates {
+			if match(normalizeColumnName(column.name)) {
+				return column
+			}
+		}
+	}
+	return candidates[0]
+}
+
+// draft converts the entity to its draft SOR form. Without a unique column, a
+// synthetic String id is added so the draft still parses.
+func (e *inferredEntity) draft() draftEntity {
+	entity := draftEntity{
+		DisplayName: e.name,
+		ExternalId:  e.name,
+		Description: fmt.Sprintf("Inferred from %s (%d sampled rows)", e.file, e.rows),
+	}
+	if e.key == nil {
+		name := "id"
+		for _, column := range e.columns {
+			if column.name == name {
+				name = "syntheticId"
+			}
+		}
+		entity.Attributes = append(entity.Attributes, draftAttribute{
+			Name:        name,
+			ExternalId:  name,
+			Description: "Synthetic unique ID; no column is unique in the sampled rows",
+			Type:        "String",
+			UniqueId:    true,
+		})
+	}
+	for _, column := range e.columns {
+		entity.Attributes = append(entity.Attributes, draftAttribute{
+			Name:       column.name,
+			ExternalId: column.name,
+			Type:       column.dataType,
+			UniqueId:   column == e.key,
+		})
+	}
+	return entity
+}
+
+// inferColumnType returns the narrowest SOR type all values parse as. Columns
+// without values are Strings.
+func inferColumnType(values map[string]bool) string {
+	if len(values) == 0 {
+		return "String"
+	}
+	matches := func(parse func(string) bool) bool {
+		for value := range values {
+			if !parse(value) {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case matches(func(v string) bool { lower := strings.ToLower(v); return lower == "true" || lower == "false" }):
+		return "Boolean"
+	case matches(func(v string) bool { _, err := strconv.ParseInt(v, 10, 32); return err == nil }):
+		return "Integer"
+	case matches(func(v string) bool { _, err := strconv.ParseInt(v, 10, 64); return err == nil }):
+		return "Int64"
+	case matches(func(v string) bool {
+		f, err := strconv.ParseFloat(v, 64)
+		return err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	}):
+		return "Float"
+	case matches(func(v string) bool { _, err := time.Parse("2006-01-02", v); return err == nil }):
+		return "Date"
+	case matches(func(v string) bool { _, err := time.Parse(time.RFC3339, v); return err == nil }):
+		return "DateTime"
+	}
+	return "String"
+}
+
+// inferReference returns the entity a column refers to, or nil. A column named
+// after an entity (user_id, userId or user for users) refers to it when most of its
+// values are that entity's unique IDs. Otherwise, the column must hold only String
+// unique IDs of one entity, so small integers shared by unrelated columns don't
+// produce relationships.
+func inferReference(column *inferredColumn, entities []*inferredEntity) *inferredEntity {
+	name := normalizeColumnName(column.name)
+
+	var best *inferredEntity
+	bestOverlap := 0.0
+	for _, target := range entities {
+		if target.key == nil || (len(column.values) > 0 && target.key.dataType != column.dataType) {
+			continue
+		}
+		overlap := valueOverlap(column.values, target.key.values)
+
+		entity := normalizeColumnName(target.name)
+		key := normalizeColumnName(target.key.name)
+		named := false
+		for _, prefix := range []string{entity, singular(entity)} {
+			if name == prefix || name == prefix+"id" || name == prefix+key {
+				named = true
+			}
+		}
+		if named && (len(column.values) == 0 || overlap >= inferForeignKeyOverlap) {
+			return target
+		}
+
+		if target.key.dataType == "String" && overlap == 1 && overlap > bestOverlap {
+			best, bestOverlap = target, overlap
+		}
+	}
+	return best
+}
+
+// valueOverlap returns the share of values found in keys
+func valueOverlap(values, keys map[string]bool) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	found := 0
+	for value := range values {
+		if keys[value] {
+			found++
+		}
+	}
+	return float64(found) / float64(len(values))
+}
+
+// normalizeColumnName lower-cases a name and drops separators, so user_id, user-id
+// and userId compare equal
+func normalizeColumnName(name string) string {
+	return strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(name))
+}
+
+// singular strips a plural s from an entity name (users → user, but not status)
+func singular(name string) string {
+	if strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") && !strings.HasSuffix(name, "us") {
+		return strings.TrimSuffix(name, "s")
+	}
+	return name
+}
@@ -0,0 +1,109 @@
+package subcommands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfer(t *testing.T) {
+	writeFiles := func(t *testing.T, files map[string]string) string {
+		t.Helper()
+		dir := t.TempDir()
+		for name, content := range files {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+		}
+		return dir
+	}
+	infer := func(t *testing.T, dir string, sampleRows int) *parser.SORDefinition {
+		t.Helper()
+		var buf bytes.Buffer
+		require.NoError(t, Infer(InferOptions{InputDir: dir, SampleRows: sampleRows, Output: &buf}))
+		assert.Contains(t, buf.String(), "# Draft SOR definition generated by fabricator infer")
+
+		// The draft must be a valid SOR definition
+		sorPath := filepath.Join(t.TempDir(), "sor.yaml")
+		require.NoError(t, os.WriteFile(sorPath, buf.Bytes(), 0600))
+		p := parser.NewParser(sorPath)
+		require.NoError(t, p.Parse())
+		return p.Definition
+	}
+	attributeTypes := func(entity parser.Entity) map[string]string {
+		types := make(map[string]string)
+		for _, attr := range entity.Attributes {
+			types[attr.Name] = attr.Type
+		}
+		return types
+	}
+
+	dir := writeFiles(t, map[string]string{
+		"users.csv": "uuid,email,managerUuid,groups_id,active,score,joined,lastLogin\n" +
+			"a1b2-1,ann@example.com,,10,true,1.5,2024-01-02,2024-01-02T10:00:00Z\n" +
+			"a1b2-2,bob@example.com,a1b2-1,10,false,2,2024-02-03,2024-02-03T11:30:00Z\n" +
+			"a1b2-3,cid@example.com,a1b2-1,20,TRUE,3.25,2024-03-04,2024-03-04T12:45:00Z\n",
+		"groups.csv": "name,id,size\n" +
+			"staff,20,1\n" +
+			"guests,30,2\n" +
+			"admins,10,5000000000\n",
+		"events.csv": "kind,count\n" +
+			"login,10\n" +
+			"login,20\n" +
+			"logout,10\n",
+		"README.txt": "not a CSV",
+	})
+
+	t.Run("files become entities with typed attributes", func(t *testing.T) {
+		def := infer(t, dir, DefaultInferSampleRows)
+		assert.ElementsMatch(t, []string{"users", "groups", "events"}, keys(def.Entities))
+		assert.Equal(t, filepath.Base(dir), def.DisplayName)
+
+		assert.Equal(t, map[string]string{
+			"uuid": "String", "email": "String", "managerUuid": "String", "groups_id": "Integer",
+			"active": "Boolean", "score": "Float", "joined": "Date", "lastLogin": "DateTime",
+		}, attributeTypes(def.Entities["users"]))
+		assert.Equal(t, "Int64", attributeTypes(def.Entities["groups"])["size"])
+	})
+
+	t.Run("unique IDs prefer id-like columns", func(t *testing.T) {
+		def := infer(t, dir, DefaultInferSampleRows)
+		assert.True(t, def.Entities["users"].Attributes[0].UniqueId, "uuid is unique")
+		groups := def.Entities["groups"]
+		assert.False(t, groups.Attributes[0].UniqueId, "name is unique too, but id is preferred")
+		assert.True(t, groups.Attributes[1].UniqueId)
+
+		events := def.Entities["events"]
+		require.Len(t, events.Attributes, 3)
+		assert.Equal(t, "id", events.Attributes[0].Name, "no column is unique, so a synthetic id is added")
+		assert.True(t, events.Attributes[0].UniqueId)
+	})
+
+	t.Run("relationships come from names and value overlap", func(t *testing.T) {
+		def := infer(t, dir, DefaultInferSampleRows)
+		assert.Equal(t, "groups.id", def.Relationships["users_groups_id"].ToAttribute)
+		assert.Equal(t, "users.managerUuid", def.Relationships["users_managerUuid"].FromAttribute)
+		assert.Equal(t, "users.uuid", def.Relationships["users_managerUuid"].ToAttribute)
+		assert.NotContains(t, def.Relationships, "events_count", "integer overlap alone isn't a reference")
+		assert.Len(t, def.Relationships, 2)
+	})
+
+	t.Run("sample rows limit what is read", func(t *testing.T) {
+		def := infer(t, dir, 1)
+		assert.Equal(t, "String", attributeTypes(def.Entities["users"])["managerUuid"], "sampled column is empty")
+		assert.Equal(t, "Integer", attributeTypes(def.Entities["groups"])["size"], "later values aren't sampled")
+	})
+
+	t.Run("directories without CSV files are rejected", func(t *testing.T) {
+		err := Infer(InferOptions{InputDir: writeFiles(t, map[string]string{"notes.txt": "x"}), Output: &bytes.Buffer{}})
+		assert.ErrorContains(t, err, "no CSV files found")
+	})
+
+	t.Run("repeated column names are rejected", func(t *testing.T) {
+		err := Infer(InferOptions{InputDir: writeFiles(t, map[string]string{"a.csv": "id,id\n1,2\n"}), Output: &bytes.Buffer{}})
+		assert.ErrorContains(t, err, "repeated column name 'id'")
+	})
+}
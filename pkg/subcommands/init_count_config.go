@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/SGNL-ai/fabricator/pkg/config"
+	"github.com/SGNL-ai/fabricator/pkg/console"
 	"github.com/SGNL-ai/fabricator/pkg/parser"
 	"github.com/fatih/color"
 )
@@ -51,10 +52,11 @@ func InitCountConfig(opts InitCountConfigOptions) error {
 
 	// Extract entities from the parsed definition
 	entities := make([]config.TemplateEntity, 0, len(p.Definition.Entities))
-	for _, entity := range p.Definition.Entities {
+	displayNames := parser.DistinctDisplayNames(p.Definition.Entities)
+	for id, entity := range p.Definition.Entities {
 		entities = append(entities, config.TemplateEntity{
 			ExternalID:   entity.ExternalId,
-			DisplayName:  entity.DisplayName,
+			DisplayName:  displayNames[id],
 			Description:  entity.Description,
 			DefaultCount: opts.DefaultCount,
 		})
@@ -69,7 +71,7 @@ func InitCountConfig(opts InitCountConfigOptions) error {
 	}
 
 	// Write a success message to stderr (so it doesn't interfere with the YAML output)
-	_, _ = color.New(color.FgGreen).Fprintf(os.Stderr, "✓ Generated row count configuration template with %d entities\n", len(entities))
+	_, _ = color.New(color.FgGreen).Fprintf(os.Stderr, console.Text("✓ Generated row count configuration template with %d entities\n"), len(entities))
 	_, _ = color.New(color.FgCyan).Fprintf(os.Stderr, "  Redirect output to a file: fabricator init-count-config -f %s > counts.yaml\n", opts.SORFile)
 
 	return nil
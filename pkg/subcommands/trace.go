@@ -0,0 +1,353 @@
+package subcommands
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/SGNL-ai/fabricator/pkg/console"
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/generators/pipeline"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+)
+
+// Directions a trace step can follow a relationship in, matching the directions of
+// path-based relationships in the SOR YAML
+const (
+	TraceForward  = "forward"  // From the fromAttribute entity to the toAttribute entity
+	TraceBackward = "backward" // From the toAttribute entity to the fromAttribute entity
+)
+
+// TraceOptions holds the options for the trace subcommand
+type TraceOptions struct {
+	// SORFile is the path to the SOR YAML definition file
+	SORFile string
+
+	// InputDir is the directory holding the generated CSV files
+	InputDir string
+
+	// DomainFolders reads each entity's file from a subfolder named after its domain
+	DomainFolders bool
+
+	// FilenameReplacement is the replacement for characters invalid in filenames
+	// used when the files were written; empty uses the default
+	FilenameReplacement string
+
+	// Query selects the starting row and the relationships to follow
+	Query TraceQuery
+
+	// Output is where to write the connected rows (defaults to stdout)
+	Output io.Writer
+}
+
+// TraceQuery is a starting row and a path of relationships to follow from it
+type TraceQuery struct {
+	// From is the starting entity: its external ID, YAML key or display name
+	From string
+
+	// ID is the unique ID of the starting row
+	ID string
+
+	// Path lists relationship keys or names, each optionally suffixed with
+	// ":forward" or ":backward". Path-based relationships expand to their steps.
+	Path []string
+}
+
+// TraceStep holds the rows reached at one point along a trace
+type TraceStep struct {
+	Relationship string     // Relationship followed to reach the rows; empty for the starting row
+	Link         string     // The attributes joined, e.g. "User.id ← GroupMember.userId"
+	Entity       string     // External ID of the entity the rows belong to
+	Columns      []string   // Attribute external IDs, in definition order
+	Rows         [][]string // Distinct rows reached, in file order
+}
+
+// traceHop is a resolved path step: which attribute values lead to which entity
+type traceHop struct {
+	relationship string
+	from, to     model.AttributeInterface
+}
+
+// Trace loads the CSV files of a generated dataset and writes the rows connected to
+// one starting row along a path of relationships, so relationship generation can be
+// checked without grepping across files
+func Trace(opts TraceOptions) error {
+	if opts.SORFile == "" {
+		return fmt.Errorf("SOR file path is required")
+	}
+	if opts.InputDir == "" {
+		return fmt.Errorf("input directory is required")
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+
+	p := parser.NewParser(opts.SORFile)
+	if err := p.Parse(); err != nil {
+		return fmt.Errorf("failed to parse SOR file: %w", err)
+	}
+
+	graphInterface, err := model.NewGraph(p.Definition, 0)
+	if err != nil {
+		return fmt.Errorf("failed to create graph: %w", err)
+	}
+	graph, ok := graphInterface.(*model.Graph)
+	if !ok {
+		return fmt.Errorf("failed to convert graph to concrete type")
+	}
+
+	replacement := opts.FilenameReplacement
+	if replacement == "" {
+		replacement = pipeline.DefaultFilenameReplacement
+	}
+	layout, err := pipeline.NewFileLayout(replacement, opts.DomainFolders)
+	if err != nil {
+		return err
+	}
+	loader := pipeline.NewCSVLoader()
+	if layered, ok := loader.(interface{ SetFileLayout(pipeline.FileLayout) }); ok {
+		layered.SetFileLayout(layout)
+	}
+	if loadErrors := loader.LoadCSVFiles(graph, opts.InputDir); len(loadErrors) > 0 {
+		return fmt.Errorf("failed to load CSV files: %s", strings.Join(loadErrors, "; "))
+	}
+
+	steps, err := TraceGraph(graph, p.Definition, opts.Query)
+	if err != nil {
+		return err
+	}
+	return writeTrace(opts.Output, opts.Query, steps)
+}
+
+// TraceGraph follows the query's path through the rows of a graph, whether loaded
+// from CSV files or still in memory after generation. The first step holds the
+// starting row; each later step holds the distinct rows linked to the previous one.
+func TraceGraph(graph model.GraphInterface, def *parser.SORDefinition, query TraceQuery) ([]TraceStep, error) {
+	entity, err := traceEntity(graph, def, query.From)
+	if err != nil {
+		return nil, err
+	}
+	if query.ID == "" {
+		return nil, fmt.Errorf("starting row ID is required")
+	}
+
+	hops, err := traceHops(graph, def, entity, query.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	// The starting row is found by its unique ID
+	key := entity.GetPrimaryKey()
+	rows := matchingRows(entity, key, map[string]bool{query.ID: true})
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no %s row has %s '%s'", entity.GetExternalID(), key.GetExternalID(), query.ID)
+	}
+	steps := []TraceStep{newTraceStep(entity, rows)}
+
+	for _, hop := range hops {
+		values := make(map[string]bool, len(rows))
+		for _, row := range rows {
+			if value := row.GetValue(hop.from.GetName()); value != "" {
+				values[value] = true
+			}
+		}
+		entity = hop.to.GetParentEntity()
+		rows = matchingRows(entity, hop.to, values)
+
+		step := newTraceStep(entity, rows)
+		step.Relationship = hop.relationship
+		step.Link = fmt.Sprintf(console.Text("%s.%s → %s.%s"), hop.from.GetParentEntity().GetExternalID(), hop.from.GetExternalID(),
+			entity.GetExternalID(), hop.to.GetExternalID())
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// traceEntity finds the starting entity by external ID, YAML key or display name
+func traceEntity(graph model.GraphInterface, def *parser.SORDefinition, name string) (model.EntityInterface, error) {
+	if name == "" {
+		return nil, fmt.Errorf("starting entity is required")
+	}
+	if key, exists := def.Entities[name]; exists {
+		name = key.ExternalId
+	}
+	for _, entity := range graph.GetAllEntities() {
+		if entity.GetExternalID() == name || entity.GetID() == name {
+			return entity, nil
+		}
+	}
+
+	known := make([]string, 0, len(def.Entities))
+	for _, entity := range def.Entities {
+		known = append(known, entity.ExternalId)
+	}
+	sort.Strings(known)
+	return nil, fmt.Errorf("unknown entity '%s' (entities: %s)", name, strings.Join(known, ", "))
+}
+
+// traceHops resolves each path step to the attributes it joins, starting from entity
+func traceHops(graph model.GraphInterface, def *parser.SORDefinition, entity model.EntityInterface, path []string) ([]traceHop, error) {
+	// Path-based relationships stand for their steps
+	var steps []parser.RelationshipPath
+	for _, step := range path {
+		name, direction, _ := strings.Cut(strings.TrimSpace(step), ":")
+		key, err := traceRelationshipKey(def, name)
+		if err != nil {
+			return nil, err
+		}
+		if len(def.Relationships[key].Path) == 0 {
+			steps = append(steps, parser.RelationshipPath{Relationship: key, Direction: direction})
+			continue
+		}
+		if direction != "" {
+			return nil, fmt.Errorf("relationship %s is path-based and takes no direction", key)
+		}
+		steps = append(steps, def.Relationships[key].Path...)
+	}
+
+	hops := make([]traceHop, 0, len(steps))
+	for _, step := range steps {
+		direction := strings.ToLower(step.Direction)
+		if direction != "" && direction != TraceForward && direction != TraceBackward {
+			return nil, fmt.Errorf("relationship %s: unknown direction '%s' (use %s or %s)",
+				step.Relationship, step.Direction, TraceForward, TraceBackward)
+		}
+		if def.Relationships[step.Relationship].ExternalDirectory != "" {
+			return nil, fmt.Errorf("relationship %s references another SOR's output and can't be traced", step.Relationship)
+		}
+
+		// An inverse pair is generated as one relationship; its other half runs the
+		// opposite way
+		relationship, exists := graph.GetRelationship(step.Relationship)
+		inverse := false
+		if !exists {
+			for _, candidate := range graph.GetAllRelationships() {
+				if candidate.GetInverseID() == step.Relationship {
+					relationship, exists, inverse = candidate, true, true
+				}
+			}
+		}
+		if !exists {
+			return nil, fmt.Errorf("relationship %s is not part of the generated graph", step.Relationship)
+		}
+
+		source, target := relationship.GetSourceAttribute(), relationship.GetTargetAttribute()
+		if inverse {
+			source, target = target, source
+		}
+		forward := source.GetParentEntity() == entity
+		backward := target.GetParentEntity() == entity
+		switch {
+		case direction == TraceForward && !forward, direction == TraceBackward && !backward, !forward && !backward:
+			return nil, fmt.Errorf("relationship %s links %s to %s, not %s",
+				step.Relationship, source.GetParentEntity().GetExternalID(), target.GetParentEntity().GetExternalID(),
+				traceDescribe(entity, direction))
+		case direction == TraceBackward || (direction == "" && !forward):
+			source, target = target, source
+		}
+
+		hops = append(hops, traceHop{relationship: step.Relationship, from: source, to: target})
+		entity = target.GetParentEntity()
+	}
+	return hops, nil
+}
+
+// traceRelationshipKey finds a relationship by YAML key, or by name when only one
+// relationship has it
+func traceRelationshipKey(def *parser.SORDefinition, name string) (string, error) {
+	if _, exists := def.Relationships[name]; exists {
+		return name, nil
+	}
+	var matches []string
+	for key, relationship := range def.Relationships {
+		if relationship.Name == name {
+			matches = append(matches, key)
+		}
+	}
+	sort.Strings(matches)
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("unknown relationship '%s'", name)
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("relationship name '%s' is shared by %s; use the key", name, strings.Join(matches, ", "))
+}
+
+// traceDescribe names the side of a relationship a step expected entity to be on
+func traceDescribe(entity model.EntityInterface, direction string) string {
+	switch direction {
+	case TraceForward:
+		return "from " + entity.GetExternalID()
+	case TraceBackward:
+		return "to " + entity.GetExternalID()
+	}
+	return entity.GetExternalID()
+}
+
+// matchingRows returns the rows whose value for attr is one of values
+func matchingRows(entity model.EntityInterface, attr model.AttributeInterface, values map[string]bool) []*model.Row {
+	var rows []*model.Row
+	if len(values) == 0 {
+		return rows
+	}
+	for i := 0; i < entity.GetRowCount(); i++ {
+		if row := entity.GetRowByIndex(i); row != nil && values[row.GetValue(attr.GetName())] {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// newTraceStep copies the rows' values in the entity's column order
+func newTraceStep(entity model.EntityInterface, rows []*model.Row) TraceStep {
+	attributes := entity.GetAttributes()
+	step := TraceStep{Entity: entity.GetExternalID(), Columns: make([]string, 0, len(attributes))}
+	for _, attr := range attributes {
+		step.Columns = append(step.Columns, attr.GetExternalID())
+	}
+	for _, row := range rows {
+		values := make([]string, 0, len(attributes))
+		for _, attr := range attributes {
+			values = append(values, row.GetValue(attr.GetName()))
+		}
+		step.Rows = append(step.Rows, values)
+	}
+	return step
+}
+
+// writeTrace writes each step as a heading and its rows as CSV with a header
+func writeTrace(w io.Writer, query TraceQuery, steps []TraceStep) error {
+	var out strings.Builder
+	for i, step := range steps {
+		if i == 0 {
+			fmt.Fprintf(&out, "# %s %s: %s\n", step.Entity, query.ID, traceRowCount(len(step.Rows)))
+		} else {
+			fmt.Fprintf(&out, "\n# via %s (%s): %s\n", step.Relationship, step.Link, traceRowCount(len(step.Rows)))
+		}
+		if len(step.Rows) == 0 {
+			continue
+		}
+
+		writer := csv.NewWriter(&out)
+		_ = writer.Write(step.Columns)
+		_ = writer.WriteAll(step.Rows)
+		if err := writer.Error(); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// traceRowCount returns "1 row" or "N rows"
+func traceRowCount(count int) string {
+	if count == 1 {
+		return "1 row"
+	}
+	return fmt.Sprintf("%d rows", count)
+}
@@ -0,0 +1,189 @@
+package subcommands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/generators/model"
+	"github.com/SGNL-ai/fabricator/pkg/generators/pipeline"
+	"github.com/SGNL-ai/fabricator/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const traceSOR = `displayName: Trace
+description: Trace test SOR
+entities:
+  User:
+    displayName: User
+    externalId: User
+    attributes:
+      - {name: id, externalId: id, type: String, uniqueId: true}
+      - {name: managerId, externalId: managerId, type: String}
+  Group:
+    displayName: Group
+    externalId: Group
+    attributes:
+      - {name: id, externalId: id, type: String, uniqueId: true}
+      - {name: name, externalId: name, type: String}
+  Membership:
+    displayName: Membership
+    externalId: Membership
+    attributes:
+      - {name: id, externalId: id, type: String, uniqueId: true}
+      - {name: userId, externalId: userId, type: String}
+      - {name: groupId, externalId: groupId, type: String}
+relationships:
+  Member:
+    displayName: Member
+    name: member
+    fromAttribute: Membership.userId
+    toAttribute: User.id
+  UserMemberships:
+    displayName: User Memberships
+    name: memberships
+    fromAttribute: User.id
+    toAttribute: Membership.userId
+  GroupMembership:
+    displayName: Group Membership
+    name: group
+    fromAttribute: Membership.groupId
+    toAttribute: Group.id
+  Manager:
+    displayName: Manager
+    name: manager
+    fromAttribute: User.managerId
+    toAttribute: User.id
+  UserGroups:
+    displayName: User Groups
+    name: groups
+    path:
+      - relationship: Member
+        direction: Backward
+      - relationship: GroupMembership
+        direction: Forward
+`
+
+func TestTrace(t *testing.T) {
+	dir := t.TempDir()
+	sorPath := filepath.Join(dir, "sor.yaml")
+	require.NoError(t, os.WriteFile(sorPath, []byte(traceSOR), 0600))
+	dataDir := filepath.Join(dir, "output")
+	require.NoError(t, os.MkdirAll(dataDir, 0750))
+	for name, content := range map[string]string{
+		"User.csv":       "id,managerId\nu1,\nu2,u1\nu3,u1\n",
+		"Group.csv":      "id,name\ng1,Admins\ng2,Staff\ng3,Guests\n",
+		"Membership.csv": "id,userId,groupId\nm1,u1,g1\nm2,u2,g2\nm3,u1,g2\n",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dataDir, name), []byte(content), 0600))
+	}
+
+	trace := func(t *testing.T, query TraceQuery) (string, error) {
+		t.Helper()
+		var buf bytes.Buffer
+		err := Trace(TraceOptions{SORFile: sorPath, InputDir: dataDir, Query: query, Output: &buf})
+		return buf.String(), err
+	}
+
+	t.Run("rows along a path are written per step", func(t *testing.T) {
+		output, err := trace(t, TraceQuery{From: "User", ID: "u1", Path: []string{"Member", "group"}})
+		require.NoError(t, err)
+		assert.Equal(t, "# User u1: 1 row\nid,managerId\nu1,\n"+
+			"\n# via Member (User.id → Membership.userId): 2 rows\nid,userId,groupId\nm1,u1,g1\nm3,u1,g2\n"+
+			"\n# via GroupMembership (Membership.groupId → Group.id): 2 rows\nid,name\ng1,Admins\ng2,Staff\n", output)
+	})
+
+	t.Run("path-based relationships expand to their steps", func(t *testing.T) {
+		output, err := trace(t, TraceQuery{From: "User", ID: "u2", Path: []string{"UserGroups"}})
+		require.NoError(t, err)
+		assert.Contains(t, output, "# via Member (User.id → Membership.userId): 1 row\n")
+		assert.Contains(t, output, "# via GroupMembership (Membership.groupId → Group.id): 1 row\nid,name\ng2,Staff\n")
+	})
+
+	t.Run("self-references follow the direction given", func(t *testing.T) {
+		output, err := trace(t, TraceQuery{From: "User", ID: "u2", Path: []string{"Manager"}})
+		require.NoError(t, err)
+		assert.Contains(t, output, "# via Manager (User.managerId → User.id): 1 row\nid,managerId\nu1,\n")
+
+		output, err = trace(t, TraceQuery{From: "User", ID: "u1", Path: []string{"Manager:backward"}})
+		require.NoError(t, err)
+		assert.Contains(t, output, "# via Manager (User.id → User.managerId): 2 rows\nid,managerId\nu2,u1\nu3,u1\n")
+	})
+
+	t.Run("inverse relationships run opposite to the one generated", func(t *testing.T) {
+		output, err := trace(t, TraceQuery{From: "User", ID: "u2", Path: []string{"UserMemberships"}})
+		require.NoError(t, err)
+		assert.Contains(t, output, "# via UserMemberships (User.id → Membership.userId): 1 row\nid,userId,groupId\nm2,u2,g2\n")
+	})
+
+	t.Run("empty steps end the chain", func(t *testing.T) {
+		output, err := trace(t, TraceQuery{From: "Group", ID: "g3", Path: []string{"GroupMembership", "Member"}})
+		require.NoError(t, err)
+		assert.Contains(t, output, "# via GroupMembership (Group.id → Membership.groupId): 0 rows\n\n# via Member")
+	})
+
+	t.Run("in-memory graphs can be traced", func(t *testing.T) {
+		p := parser.NewParser(sorPath)
+		require.NoError(t, p.Parse())
+		graph, err := model.NewGraph(p.Definition, 0)
+		require.NoError(t, err)
+		require.Empty(t, pipeline.NewCSVLoader().LoadCSVFiles(graph.(*model.Graph), dataDir))
+
+		steps, err := TraceGraph(graph, p.Definition, TraceQuery{From: "Membership", ID: "m3", Path: []string{"Member", "Manager"}})
+		require.NoError(t, err)
+		require.Len(t, steps, 3)
+		assert.Equal(t, [][]string{{"u1", ""}}, steps[1].Rows)
+		assert.Empty(t, steps[2].Rows, "u1 has no manager")
+	})
+
+	tests := []struct {
+		name  string
+		query TraceQuery
+		err   string
+	}{
+		{name: "unknown entity", query: TraceQuery{From: "Users", ID: "u1"}, err: "unknown entity 'Users'"},
+		{name: "missing row", query: TraceQuery{From: "User", ID: "u9"}, err: "no User row has id 'u9'"},
+		{name: "unknown relationship", query: TraceQuery{From: "User", ID: "u1", Path: []string{"Owner"}}, err: "unknown relationship 'Owner'"},
+		{name: "unconnected relationship", query: TraceQuery{From: "User", ID: "u1", Path: []string{"GroupMembership"}},
+			err: "relationship GroupMembership links Membership to Group, not User"},
+		{name: "wrong direction", query: TraceQuery{From: "User", ID: "u1", Path: []string{"Member:forward"}},
+			err: "relationship Member links Membership to User, not from User"},
+		{name: "unknown direction", query: TraceQuery{From: "User", ID: "u1", Path: []string{"Member:up"}}, err: "unknown direction 'up'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := trace(t, tt.query)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestTrace_FileLayout(t *testing.T) {
+	dir := t.TempDir()
+	sorPath := filepath.Join(dir, "sor.yaml")
+	require.NoError(t, os.WriteFile(sorPath, []byte(`displayName: Layout
+description: Trace layout test SOR
+entities:
+  Role:
+    displayName: Role
+    externalId: App/Role:Admin
+    domain: identity
+    attributes:
+      - {name: id, externalId: id, type: String, uniqueId: true}
+`), 0600))
+	dataDir := filepath.Join(dir, "output")
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "identity"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "identity", "Role-Admin.csv"), []byte("id\nr1\n"), 0600))
+
+	var buf bytes.Buffer
+	err := Trace(TraceOptions{SORFile: sorPath, InputDir: dataDir, DomainFolders: true, FilenameReplacement: "-",
+		Query: TraceQuery{From: "Role", ID: "r1"}, Output: &buf})
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "r1")
+
+	err = Trace(TraceOptions{SORFile: sorPath, InputDir: dataDir, DomainFolders: true,
+		Query: TraceQuery{From: "Role", ID: "r1"}, Output: &buf})
+	assert.ErrorContains(t, err, "Role_Admin.csv", "the default replacement names another file")
+}
@@ -0,0 +1,99 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SGNL-ai/fabricator/pkg/events"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/SGNL-ai/fabricator"
+
+// Sink is an events.Sink that turns run phases into spans under a single
+// "fabricator.run" root span and records row, foreign key and validation counters
+type Sink struct {
+	ctx    context.Context
+	root   trace.Span
+	tracer trace.Tracer
+	phases map[string]trace.Span
+
+	rowsGenerated    metric.Int64Counter
+	rowsWritten      metric.Int64Counter
+	fkLookups        metric.Int64Counter
+	validationErrors metric.Int64Counter
+	warnings         metric.Int64Counter
+}
+
+// NewSink creates a sink that records spans and counters with the given providers
+func NewSink(tracerProvider trace.TracerProvider, meterProvider metric.MeterProvider) (*Sink, error) {
+	meter := meterProvider.Meter(instrumentationName)
+	s := &Sink{
+		tracer: tracerProvider.Tracer(instrumentationName),
+		phases: make(map[string]trace.Span),
+	}
+
+	counters := []struct {
+		target      *metric.Int64Counter
+		name        string
+		description string
+	}{
+		{&s.rowsGenerated, "fabricator.rows.generated", "Rows generated per entity"},
+		{&s.rowsWritten, "fabricator.rows.written", "Rows written to CSV per entity"},
+		{&s.fkLookups, "fabricator.fk.lookups", "Foreign key values assigned per relationship"},
+		{&s.validationErrors, "fabricator.validation.errors", "Validation issues found"},
+		{&s.warnings, "fabricator.warnings", "Non-fatal warnings raised"},
+	}
+	for _, c := range counters {
+		counter, err := meter.Int64Counter(c.name, metric.WithDescription(c.description))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create counter %s: %w", c.name, err)
+		}
+		*c.target = counter
+	}
+
+	s.ctx, s.root = s.tracer.Start(context.Background(), "fabricator.run")
+	return s, nil
+}
+
+// Emit records a single event
+func (s *Sink) Emit(event events.Event) error {
+	switch event.Type {
+	case events.PhaseStarted:
+		_, span := s.tracer.Start(s.ctx, "fabricator."+event.Phase, trace.WithTimestamp(event.Timestamp))
+		s.phases[event.Phase] = span
+	case events.PhaseFinished:
+		if span, exists := s.phases[event.Phase]; exists {
+			span.End(trace.WithTimestamp(event.Timestamp))
+			delete(s.phases, event.Phase)
+		}
+	case events.EntityGenerated:
+		s.rowsGenerated.Add(s.ctx, int64(event.Rows), metric.WithAttributes(attribute.String("entity", event.Entity)))
+	case events.RowsWritten:
+		s.rowsWritten.Add(s.ctx, int64(event.Rows), metric.WithAttributes(attribute.String("entity", event.Entity)))
+	case events.RelationshipLinked:
+		s.fkLookups.Add(s.ctx, int64(event.Rows), metric.WithAttributes(
+			attribute.String("relationship", event.Relationship),
+			attribute.String("entity", event.Entity),
+		))
+	case events.ValidationIssue:
+		s.validationErrors.Add(s.ctx, 1)
+		s.root.AddEvent("validation_issue", trace.WithAttributes(attribute.String("message", event.Message)))
+	case events.Warning:
+		s.warnings.Add(s.ctx, 1)
+		s.root.AddEvent("warning", trace.WithAttributes(attribute.String("message", event.Message)))
+	}
+	return nil
+}
+
+// Close ends any phase spans left open by a failed run and then the root span
+func (s *Sink) Close() error {
+	for phase, span := range s.phases {
+		span.End()
+		delete(s.phases, phase)
+	}
+	s.root.End()
+	return nil
+}
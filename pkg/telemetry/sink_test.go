@@ -0,0 +1,111 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/SGNL-ai/fabricator/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func counterTotals(t *testing.T, reader *sdkmetric.ManualReader) map[string]int64 {
+	t.Helper()
+	var data metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &data))
+
+	totals := make(map[string]int64)
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			require.True(t, ok, "metric %s should be an int64 sum", m.Name)
+			for _, point := range sum.DataPoints {
+				totals[m.Name] += point.Value
+			}
+		}
+	}
+	return totals
+}
+
+func TestSink(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	sink, err := NewSink(tracerProvider, meterProvider)
+	require.NoError(t, err)
+
+	emitter := events.NewEmitter(sink)
+	started := emitter.PhaseStarted("parse")
+	emitter.PhaseFinished("parse", started)
+	emitter.PhaseStarted("write")
+	emitter.EntityGenerated("User", 10)
+	emitter.EntityGenerated("Group", 5)
+	emitter.RowsWritten("User", 10)
+	emitter.RelationshipLinked("group_owner", "Group", 5)
+	emitter.ValidationIssue("duplicate id")
+	emitter.ValidationIssue("missing reference")
+	emitter.Warning("cardinality adjusted")
+	require.NoError(t, emitter.Close())
+
+	t.Run("records phases as child spans of the run", func(t *testing.T) {
+		spans := recorder.Ended()
+		require.Len(t, spans, 3, "open phases are ended when the sink closes")
+
+		names := make(map[string]sdktrace.ReadOnlySpan)
+		for _, span := range spans {
+			names[span.Name()] = span
+		}
+		require.Contains(t, names, "fabricator.run")
+		require.Contains(t, names, "fabricator.parse")
+		require.Contains(t, names, "fabricator.write")
+
+		root := names["fabricator.run"]
+		assert.Equal(t, root.SpanContext().SpanID(), names["fabricator.parse"].Parent().SpanID())
+		assert.Equal(t, root.SpanContext().SpanID(), names["fabricator.write"].Parent().SpanID())
+		assert.Len(t, root.Events(), 3, "validation issues and warnings are recorded on the run span")
+	})
+
+	t.Run("records counters", func(t *testing.T) {
+		totals := counterTotals(t, reader)
+		assert.Equal(t, int64(15), totals["fabricator.rows.generated"])
+		assert.Equal(t, int64(10), totals["fabricator.rows.written"])
+		assert.Equal(t, int64(5), totals["fabricator.fk.lookups"])
+		assert.Equal(t, int64(2), totals["fabricator.validation.errors"])
+		assert.Equal(t, int64(1), totals["fabricator.warnings"])
+	})
+}
+
+func TestParseEndpoint(t *testing.T) {
+	tests := []struct {
+		name         string
+		endpoint     string
+		wantHost     string
+		wantInsecure bool
+		wantErr      bool
+	}{
+		{name: "Bare host and port", endpoint: "localhost:4318", wantHost: "localhost:4318", wantInsecure: true},
+		{name: "HTTP URL", endpoint: "http://collector:4318", wantHost: "collector:4318", wantInsecure: true},
+		{name: "HTTPS URL", endpoint: "https://collector.example.com", wantHost: "collector.example.com"},
+		{name: "Empty", endpoint: "", wantErr: true},
+		{name: "Unsupported scheme", endpoint: "grpc://collector:4317", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, insecure, err := parseEndpoint(tt.endpoint)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantHost, host)
+			assert.Equal(t, tt.wantInsecure, insecure)
+		})
+	}
+}
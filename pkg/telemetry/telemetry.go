@@ -0,0 +1,92 @@
+// Package telemetry exports run progress as OpenTelemetry traces and metrics.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ServiceName is reported as the service.name resource attribute
+const ServiceName = "fabricator"
+
+// Provider owns the trace and metric pipelines that export to an OTLP collector
+type Provider struct {
+	tracerProvider *trace.TracerProvider
+	meterProvider  *metric.MeterProvider
+}
+
+// Setup creates trace and metric exporters that send OTLP over HTTP to endpoint.
+// The endpoint may be a bare "host:port" (plain HTTP) or a URL with an http or https scheme.
+func Setup(ctx context.Context, endpoint string) (*Provider, error) {
+	host, insecure, err := parseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	traceOptions := []otlptracehttp.Option{otlptracehttp.WithEndpoint(host)}
+	metricOptions := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(host)}
+	if insecure {
+		traceOptions = append(traceOptions, otlptracehttp.WithInsecure())
+		metricOptions = append(metricOptions, otlpmetrichttp.WithInsecure())
+	}
+
+	traceExporter, err := otlptracehttp.New(ctx, traceOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+	metricExporter, err := otlpmetrichttp.New(ctx, metricOptions...)
+	if err != nil {
+		_ = traceExporter.Shutdown(ctx)
+		return nil, fmt.Errorf("failed to create metric exporter: %w", err)
+	}
+
+	res := resource.NewSchemaless(attribute.String("service.name", ServiceName))
+	return &Provider{
+		tracerProvider: trace.NewTracerProvider(trace.WithBatcher(traceExporter), trace.WithResource(res)),
+		meterProvider:  metric.NewMeterProvider(metric.WithReader(metric.NewPeriodicReader(metricExporter)), metric.WithResource(res)),
+	}, nil
+}
+
+// NewSink creates an event sink that records the run on this provider
+func (p *Provider) NewSink() (*Sink, error) {
+	return NewSink(p.tracerProvider, p.meterProvider)
+}
+
+// Shutdown flushes pending spans and metrics and stops the exporters
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return errors.Join(p.tracerProvider.Shutdown(ctx), p.meterProvider.Shutdown(ctx))
+}
+
+// parseEndpoint splits an endpoint into the host:port the exporters expect and
+// whether the connection should skip TLS
+func parseEndpoint(endpoint string) (string, bool, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return "", false, fmt.Errorf("OTLP endpoint must not be empty")
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid OTLP endpoint '%s': %w", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", false, fmt.Errorf("invalid OTLP endpoint '%s': scheme must be http or https", endpoint)
+	}
+	if u.Host == "" {
+		return "", false, fmt.Errorf("invalid OTLP endpoint '%s': missing host", endpoint)
+	}
+	return u.Host, u.Scheme == "http", nil
+}
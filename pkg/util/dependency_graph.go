@@ -3,6 +3,7 @@ package util
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/SGNL-ai/fabricator/pkg/parser"
@@ -37,6 +38,42 @@ func BuildEntityDependencyGraph(
 		}
 	}
 
+	// Add an edge per dependency: source -> target means "source should be processed before target"
+	for _, dependency := range EntityDependencies(entities, relationships) {
+		err := entityGraph.AddEdge(dependency.Before, dependency.After)
+		if err != nil {
+
+			// Handle the error based on its type
+			if errors.Is(err, graph.ErrEdgeCreatesCycle) {
+				return nil, err
+			} else if errors.Is(err, graph.ErrEdgeAlreadyExists) {
+				// Edge already exists, we can ignore this
+				continue
+			} else {
+				// Other unexpected error
+				return nil, fmt.Errorf("failed to add edge from %s to %s: %w",
+					dependency.Before, dependency.After, err)
+			}
+		}
+	}
+
+	return entityGraph, nil
+}
+
+// Dependency records that one entity must be generated before another because of a relationship
+type Dependency struct {
+	Before       string // Entity ID holding the referenced key
+	After        string // Entity ID holding the foreign key
+	Relationship string // Relationship ID that causes the dependency
+}
+
+// EntityDependencies returns the generation-order dependencies implied by the
+// relationships of a SOR definition, sorted by relationship ID. Entity IDs are the
+// keys of the entities map.
+func EntityDependencies(
+	entities map[string]parser.Entity,
+	relationships map[string]parser.Relationship,
+) []Dependency {
 	// Create maps to find entities/attributes by different identifiers
 	// Map of attribute alias to (entityID, attrName, uniqueID)
 	attributeAliasMap := make(map[string]struct {
@@ -82,13 +119,27 @@ func BuildEntityDependencyGraph(
 		}
 	}
 
-	// Process relationships to add edges
-	for _, relationship := range relationships {
+	// Process relationships in key order so dependencies are deterministic
+	relationshipIDs := make([]string, 0, len(relationships))
+	for relationshipID := range relationships {
+		relationshipIDs = append(relationshipIDs, relationshipID)
+	}
+	sort.Strings(relationshipIDs)
+
+	var dependencies []Dependency
+	for _, relationshipID := range relationshipIDs {
+		relationship := relationships[relationshipID]
+
 		// Skip path-based relationships for now
 		if len(relationship.Path) > 0 {
 			continue
 		}
 
+		// External relationships target another SOR's output, not an entity here
+		if relationship.ExternalDirectory != "" {
+			continue
+		}
+
 		// Parse the entity-attribute pairs from the relationship
 		fromEntityID, _, fromUniqueID := ParseEntityAttribute(
 			entities, relationship.FromAttribute, attributeAliasMap, entityAttributeMap)
@@ -135,27 +186,15 @@ func BuildEntityDependencyGraph(
 				continue
 			}
 
-			// Add the edge: source -> target means "source should be processed before target"
-
-			err := entityGraph.AddEdge(sourceEntityID, targetEntityID)
-			if err != nil {
-
-				// Handle the error based on its type
-				if errors.Is(err, graph.ErrEdgeCreatesCycle) {
-					return nil, err
-				} else if errors.Is(err, graph.ErrEdgeAlreadyExists) {
-					// Edge already exists, we can ignore this
-					continue
-				} else {
-					// Other unexpected error
-					return nil, fmt.Errorf("failed to add edge from %s to %s: %w",
-						sourceEntityID, targetEntityID, err)
-				}
-			}
+			dependencies = append(dependencies, Dependency{
+				Before:       sourceEntityID,
+				After:        targetEntityID,
+				Relationship: relationshipID,
+			})
 		}
 	}
 
-	return entityGraph, nil
+	return dependencies
 }
 
 // ParseEntityAttribute is a helper function to extract entity and attribute info from a reference
@@ -343,6 +343,40 @@ func TestBuildEntityDependencyGraph(t *testing.T) {
 	})
 }
 
+func TestEntityDependencies(t *testing.T) {
+	entities := map[string]parser.Entity{
+		"user": {
+			DisplayName: "User",
+			ExternalId:  "User",
+			Attributes: []parser.Attribute{
+				{Name: "id", ExternalId: "id", UniqueId: true},
+			},
+		},
+		"group": {
+			DisplayName: "Group",
+			ExternalId:  "Group",
+			Attributes: []parser.Attribute{
+				{Name: "id", ExternalId: "id", UniqueId: true},
+				{Name: "ownerId", ExternalId: "ownerId"},
+				{Name: "oktaId", ExternalId: "oktaId"},
+			},
+		},
+	}
+
+	relationships := map[string]parser.Relationship{
+		"group_owner":  {FromAttribute: "Group.ownerId", ToAttribute: "User.id"},
+		"group_backup": {FromAttribute: "Group.ownerId", ToAttribute: "User.id"},
+		"reverse":      {FromAttribute: "User.id", ToAttribute: "Group.ownerId"},
+		"okta_user":    {FromAttribute: "Group.oktaId", ToAttribute: "User.id", ExternalDirectory: "../okta"},
+		"path_based":   {Path: []parser.RelationshipPath{{Relationship: "group_owner"}}},
+	}
+
+	assert.Equal(t, []Dependency{
+		{Before: "user", After: "group", Relationship: "group_backup"},
+		{Before: "user", After: "group", Relationship: "group_owner"},
+	}, EntityDependencies(entities, relationships))
+}
+
 func TestGetTopologicalOrder(t *testing.T) {
 	t.Run("should return topological order", func(t *testing.T) {
 		entities := map[string]parser.Entity{
@@ -0,0 +1,60 @@
+package util
+
+import "sort"
+
+// DependencyLayers groups entities into generation layers: layer 0 holds entities that
+// depend on nothing, and each later layer holds entities whose dependencies are all in
+// earlier layers. Entities within a layer are sorted. Entities caught in (or behind) a
+// dependency cycle cannot be layered and are returned, sorted, as unresolved.
+func DependencyLayers(entityIDs []string, dependencies []Dependency) (layers [][]string, unresolved []string) {
+	pending := make(map[string]int, len(entityIDs))
+	dependents := make(map[string][]string)
+	for _, id := range entityIDs {
+		pending[id] = 0
+	}
+	seen := make(map[[2]string]bool)
+	for _, dependency := range dependencies {
+		edge := [2]string{dependency.Before, dependency.After}
+		if seen[edge] {
+			continue // Several relationships between the same entities count once
+		}
+		seen[edge] = true
+		pending[dependency.After]++
+		dependents[dependency.Before] = append(dependents[dependency.Before], dependency.After)
+	}
+
+	var current []string
+	for id, count := range pending {
+		if count == 0 {
+			current = append(current, id)
+		}
+	}
+
+	placed := 0
+	for len(current) > 0 {
+		sort.Strings(current)
+		layers = append(layers, current)
+		placed += len(current)
+
+		var next []string
+		for _, id := range current {
+			for _, dependent := range dependents[id] {
+				pending[dependent]--
+				if pending[dependent] == 0 {
+					next = append(next, dependent)
+				}
+			}
+		}
+		current = next
+	}
+
+	if placed < len(pending) {
+		for id, count := range pending {
+			if count > 0 {
+				unresolved = append(unresolved, id)
+			}
+		}
+		sort.Strings(unresolved)
+	}
+	return layers, unresolved
+}
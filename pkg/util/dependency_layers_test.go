@@ -0,0 +1,54 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDependencyLayers(t *testing.T) {
+	tests := []struct {
+		name               string
+		entities           []string
+		dependencies       []Dependency
+		expectedLayers     [][]string
+		expectedUnresolved []string
+	}{
+		{
+			name:           "independent entities share layer 0",
+			entities:       []string{"role", "user"},
+			expectedLayers: [][]string{{"role", "user"}},
+		},
+		{
+			name:     "chain and diamond",
+			entities: []string{"app", "role", "user", "grant"},
+			dependencies: []Dependency{
+				{Before: "app", After: "role", Relationship: "role_app"},
+				{Before: "role", After: "grant", Relationship: "grant_role"},
+				{Before: "user", After: "grant", Relationship: "grant_user"},
+				{Before: "user", After: "grant", Relationship: "grant_approver"},
+			},
+			expectedLayers: [][]string{{"app", "user"}, {"role"}, {"grant"}},
+		},
+		{
+			name:     "cycle is unresolved",
+			entities: []string{"a", "b", "c", "d"},
+			dependencies: []Dependency{
+				{Before: "a", After: "b", Relationship: "ab"},
+				{Before: "b", After: "c", Relationship: "bc"},
+				{Before: "c", After: "b", Relationship: "cb"},
+				{Before: "c", After: "d", Relationship: "cd"},
+			},
+			expectedLayers:     [][]string{{"a"}},
+			expectedUnresolved: []string{"b", "c", "d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layers, unresolved := DependencyLayers(tt.entities, tt.dependencies)
+			assert.Equal(t, tt.expectedLayers, layers)
+			assert.Equal(t, tt.expectedUnresolved, unresolved)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+// Package editdistance measures how far apart strings are, for suggesting the name
+// a misspelled one likely meant. It is a package of its own, rather than part of
+// util, because the parser uses it and util depends on the parser.
+package editdistance
+
+// Levenshtein returns the number of single-character insertions, deletions and
+// substitutions needed to turn a into b
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
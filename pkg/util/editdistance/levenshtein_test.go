@@ -0,0 +1,14 @@
+package editdistance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 3, Levenshtein("kitten", "sitting"))
+	assert.Equal(t, 0, Levenshtein("user", "user"))
+	assert.Equal(t, 4, Levenshtein("", "user"))
+	assert.Equal(t, 1, Levenshtein("straße", "strase"), "runes, not bytes, are edited")
+}
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// WatchFile calls onChange each time the file at path changes, comparing its
+// modification time and size every interval, until ctx is done. Polling needs no
+// platform support and follows editors that save by writing a new file and
+// renaming it over the old one. The file must exist when watching starts.
+func WatchFile(ctx context.Context, path string, interval time.Duration, onChange func()) error {
+	last, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil {
+				continue // Briefly missing while an editor replaces it
+			}
+			if !info.ModTime().Equal(last.ModTime()) || info.Size() != last.Size() {
+				last = info
+				onChange()
+			}
+		}
+	}
+}
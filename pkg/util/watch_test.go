@@ -0,0 +1,39 @@
+package util
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sor.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("displayName: A\n"), 0600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan struct{}, 10)
+	done := make(chan error)
+	go func() {
+		done <- WatchFile(ctx, path, 5*time.Millisecond, func() { changes <- struct{}{} })
+	}()
+
+	// Sizes differ so the change is seen even where modification times are coarse
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, os.WriteFile(path, []byte("displayName: Changed\n"), 0600))
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("change not seen")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+	assert.Empty(t, changes, "one change is reported once")
+
+	assert.Error(t, WatchFile(context.Background(), filepath.Join(t.TempDir(), "missing.yaml"), time.Millisecond, func() {}))
+}